@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"codectx/internal/config"
+)
+
+// runAlias manages the aliases stored in the config:
+//
+//	codectx alias save NAME -- ARGS...
+//	codectx alias list
+//	codectx alias delete NAME
+func runAlias(args []string) error {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		if len(cfg.Aliases) == 0 {
+			fmt.Println("No aliases saved. Use \"codectx alias save NAME -- ARGS...\" to add one.")
+			return nil
+		}
+		names := make([]string, 0, len(cfg.Aliases))
+		for name := range cfg.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%-16s %s\n", name, commandLine(cfg.Aliases[name]))
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "save":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: codectx alias save NAME -- ARGS...")
+		}
+		name, aliasArgs := args[1], args[2:]
+		if len(aliasArgs) > 0 && aliasArgs[0] == "--" {
+			aliasArgs = aliasArgs[1:]
+		}
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, `/\`) || isSubcommand(name) {
+			return fmt.Errorf("invalid alias name: %q", name)
+		}
+		if len(aliasArgs) == 0 {
+			return fmt.Errorf("alias %s has no arguments", name)
+		}
+		if cfg.Aliases == nil {
+			cfg.Aliases = make(map[string][]string)
+		}
+		cfg.Aliases[name] = aliasArgs
+		if err := cfg.Save(path); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("Saved alias %s: %s\n", name, commandLine(aliasArgs))
+		return nil
+
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: codectx alias delete NAME")
+		}
+		if _, ok := cfg.Aliases[args[1]]; !ok {
+			return fmt.Errorf("no alias named %s", args[1])
+		}
+		delete(cfg.Aliases, args[1])
+		if err := cfg.Save(path); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("Deleted alias %s\n", args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown alias command: %s (save, list, delete)", args[0])
+	}
+}
+
+// expandAlias replaces a leading alias name with its saved arguments, keeping
+// the remaining arguments after them. A directory with the same name as an
+// alias is scanned instead.
+func expandAlias(args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isSubcommand(args[0]) || isDirectory(args[0]) {
+		return args, nil
+	}
+	path, err := config.DefaultPath()
+	if err != nil {
+		return args, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return args, nil
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	aliasArgs, ok := cfg.Aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	expanded := append([]string{}, aliasArgs...)
+	return append(expanded, args[1:]...), nil
+}
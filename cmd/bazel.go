@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"codectx/internal/bazel"
+)
+
+// bazelFiles narrows the included files (with a leading slash) to the source
+// files of the --bazel-target targets and their dependencies, as resolved by
+// bazel query
+func (r *runner) bazelFiles(ctx context.Context, targetDir string, included []string) ([]string, error) {
+	if r.opts.Verbose {
+		fmt.Fprintf(r.stderr, "Querying the sources of %s...\n", r.opts.BazelTarget)
+	}
+	sources, err := bazel.SourceFiles(ctx, targetDir, r.opts.BazelTarget)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.Verbose {
+		fmt.Fprintf(r.stderr, "Bazel target %s: %d source files\n", r.opts.BazelTarget, len(sources))
+	}
+	inTarget := make(map[string]bool, len(sources))
+	for _, relPath := range sources {
+		inTarget["/"+relPath] = true
+	}
+	return slices.DeleteFunc(included, func(relPath string) bool {
+		return !inTarget[relPath]
+	}), nil
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"codectx/internal/benchtree"
+	"codectx/internal/filter"
+	"codectx/internal/formatter"
+	"codectx/internal/platform"
+	"codectx/internal/scanner"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
+)
+
+// benchConfig selects what "codectx bench" measures on the generated tree
+type benchConfig struct {
+	Runs       int    // Times each stage is run; the fastest run is reported
+	Format     string // Output format of the format stage
+	Extensions string // --extensions of the filter stage
+	Exclude    string // --exclude of the filter stage
+}
+
+// benchResult is the fastest run of a stage over the files it handled
+type benchResult struct {
+	Stage    string
+	Duration time.Duration
+	Files    int
+	Bytes    int64
+}
+
+// runBench measures the scan, filter, tokenize, and format stages on a
+// synthetic tree: "codectx bench [--files N] [--lines N] [--runs N] [--dir DIR]".
+// It is left out of the help, being meant for checking performance work.
+func runBench(args []string) error {
+	var spec benchtree.Spec
+	var config benchConfig
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.IntVar(&spec.Files, "files", benchtree.DefaultFiles, "Number of files in the tree")
+	flags.IntVar(&spec.Lines, "lines", benchtree.DefaultLines, "Lines of each text file")
+	flags.IntVar(&spec.FilesPerDir, "files-per-dir", benchtree.DefaultFilesPerDir, "Files in each directory")
+	flags.IntVar(&spec.DirsPerDir, "dirs-per-dir", benchtree.DefaultDirsPerDir, "Subdirectories of each directory")
+	flags.IntVar(&config.Runs, "runs", 3, "Times each stage is run; the fastest run is reported")
+	flags.StringVar(&config.Format, "format", string(formatter.TextFormat), "Output format of the format stage")
+	flags.StringVar(&config.Extensions, "extensions", "", "Filter by file extensions in the filter stage")
+	flags.StringVar(&config.Exclude, "exclude", "", "Exclude patterns in the filter stage")
+	dir := flags.String("dir", "", "Generate the tree in this new directory and keep it (default: a temporary directory)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument: %s", flags.Arg(0))
+	}
+	if config.Runs < 1 {
+		return errors.New("--runs must be at least 1")
+	}
+	if !formatter.IsBuiltinFormat(config.Format) {
+		return fmt.Errorf("unsupported format: %s", config.Format)
+	}
+
+	if *dir == "" {
+		tempDir, err := os.MkdirTemp("", "codectx-bench-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		*dir = tempDir
+	} else if err := os.Mkdir(*dir, 0755); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	paths, err := benchtree.Generate(*dir, spec)
+	if err != nil {
+		return fmt.Errorf("failed to generate the tree: %w", err)
+	}
+	fmt.Printf("Generated %s files in %s (%.2fs)\n\n", utils.FormatCount(len(paths)), *dir, time.Since(start).Seconds())
+
+	results, err := benchmarkTree(*dir, config)
+	if err != nil {
+		return err
+	}
+	printBenchResults(os.Stdout, results)
+	return nil
+}
+
+// benchmarkTree runs each stage of a scan of dir config.Runs times, the way a
+// scan runs them, and returns the fastest run of each
+func benchmarkTree(dir string, config benchConfig) ([]benchResult, error) {
+	var results []benchResult
+	measure := func(stage string, run func() (int, int64, error)) error {
+		result := benchResult{Stage: stage}
+		for i := 0; i < max(config.Runs, 1); i++ {
+			start := time.Now()
+			files, size, err := run()
+			if err != nil {
+				return fmt.Errorf("%s: %w", stage, err)
+			}
+			if elapsed := time.Since(start); i == 0 || elapsed < result.Duration {
+				result.Duration = elapsed
+			}
+			result.Files, result.Bytes = files, size
+		}
+		results = append(results, result)
+		return nil
+	}
+
+	scan := scanner.NewScanner(dir, false)
+	var root *scanner.FileEntry
+	var relPaths []string
+	err := measure("scan", func() (int, int64, error) {
+		var err error
+		if root, err = scan.Scan(); err != nil {
+			return 0, 0, err
+		}
+		relPaths = scan.GetRelativePaths(root)
+		return len(relPaths), 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Select the text files the filter includes, as a scan does
+	fileFilter := filter.NewFilter(config.Extensions, config.Exclude, false)
+	fileFilter.SetRootDir(dir)
+	var included []string
+	var includedSize int64
+	err = measure("filter", func() (int, int64, error) {
+		included, includedSize = nil, 0
+		for _, relPath := range relPaths {
+			fullPath := platform.JoinSlash(dir, relPath)
+			if !fileFilter.ShouldInclude(fullPath) {
+				continue
+			}
+			if isText, err := utils.IsTextFile(fullPath); err != nil || !isText {
+				continue
+			}
+			info, err := scan.Stat(fullPath)
+			if err != nil {
+				return 0, 0, err
+			}
+			included = append(included, relPath)
+			includedSize += info.Size()
+		}
+		return len(relPaths), 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = measure("tokenize", func() (int, int64, error) {
+		for _, relPath := range included {
+			if _, err := stats.EstimateTokens(platform.JoinSlash(dir, relPath)); err != nil {
+				return 0, 0, err
+			}
+		}
+		return len(included), includedSize, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tree := scan.GenerateTree(root)
+	err = measure("format", func() (int, int64, error) {
+		output := &formatter.Formatter{
+			Format:          formatter.OutputFormat(config.Format),
+			ShowLineNumbers: true,
+			Writer:          io.Discard,
+			BufferSize:      formatter.DefaultBufferSize,
+			Stat:            scan.Stat,
+		}
+		if err := output.FormatTree(tree); err != nil {
+			return 0, 0, err
+		}
+		for _, relPath := range included {
+			if err := output.FormatFileContent(platform.JoinSlash(dir, relPath), relPath[1:]); err != nil {
+				return 0, 0, err
+			}
+		}
+		return len(included), includedSize, output.Finalize()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// printBenchResults writes the results as a table of durations and throughput
+func printBenchResults(w io.Writer, results []benchResult) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Stage\tTime\tFiles\tFiles/s\tMB/s\t")
+	for _, result := range results {
+		seconds := result.Duration.Seconds()
+		throughput := "-"
+		if result.Bytes > 0 && seconds > 0 {
+			throughput = fmt.Sprintf("%.1f", float64(result.Bytes)/(1024*1024)/seconds)
+		}
+		filesPerSecond := "-"
+		if seconds > 0 {
+			filesPerSecond = utils.FormatCount(int64(float64(result.Files) / seconds))
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n", result.Stage, result.Duration.Round(time.Microsecond), utils.FormatCount(result.Files), filesPerSecond, throughput)
+	}
+	table.Flush()
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// errConfirmAborted is returned when the confirmation prompt is quit or its
+// input ends before every group is answered
+var errConfirmAborted = errors.New("confirmation aborted; nothing was output")
+
+// confirmGroup is a directory and the included files directly in it
+type confirmGroup struct {
+	dir   string
+	files []string
+}
+
+// confirmFiles lists the included files grouped by directory on out and asks
+// on in whether to include each group: y includes it, n leaves it out, all
+// includes it and every remaining group, and q aborts. Paths have a leading
+// slash; the confirmed ones are returned in their original order.
+func confirmFiles(included []string, in io.Reader, out io.Writer) ([]string, error) {
+	var groups []*confirmGroup
+	groupIndex := make(map[string]*confirmGroup)
+	for _, relPath := range included {
+		dir := path.Dir(relPath[1:])
+		group, ok := groupIndex[dir]
+		if !ok {
+			group = &confirmGroup{dir: dir}
+			groupIndex[dir] = group
+			groups = append(groups, group)
+		}
+		group.files = append(group.files, relPath)
+	}
+
+	accepted := make(map[string]bool)
+	reader := bufio.NewReader(in)
+	all := false
+	for _, group := range groups {
+		if all {
+			accepted[group.dir] = true
+			continue
+		}
+		label := group.dir + "/"
+		if group.dir == "." {
+			label = "./"
+		}
+		fmt.Fprintf(out, "\n%s (%s)\n", label, plural(len(group.files), "file"))
+		for _, relPath := range group.files {
+			fmt.Fprintf(out, "  %s\n", relPath[1:])
+		}
+		answered := false
+		for !answered {
+			fmt.Fprintf(out, "Include %s? [y/n/all/q] ", label)
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Fprintln(out)
+				return nil, errConfirmAborted
+			}
+			answered = true
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				accepted[group.dir] = true
+			case "n", "no":
+			case "a", "all":
+				accepted[group.dir] = true
+				all = true
+			case "q", "quit":
+				return nil, errConfirmAborted
+			default:
+				answered = false
+			}
+		}
+	}
+
+	var confirmed []string
+	for _, relPath := range included {
+		if accepted[path.Dir(relPath[1:])] {
+			confirmed = append(confirmed, relPath)
+		}
+	}
+	fmt.Fprintf(out, "\nIncluding %d of %d files\n", len(confirmed), len(included))
+	return confirmed, nil
+}
+
+// plural formats a count with a noun, such as "1 file" or "3 files"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"codectx/internal/config"
+)
+
+// envPrefix starts the environment variables bound to options, e.g. CODECTX_FORMAT
+const envPrefix = "CODECTX_"
+
+// unboundFlags are not read from the environment or config defaults
+var unboundFlags = map[string]bool{
+	"help":    true,
+	"version": true,
+	"json":    true,
+	"ext":     true, // Alias of --extensions in "codectx query"
+	"history": true, // CODECTX_HISTORY opts in to recording runs instead
+}
+
+// loadConfig reads ~/.codectx/config.json, which may not exist
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return &config.Config{}, nil
+	}
+	return config.Load(path)
+}
+
+// envName returns the environment variable bound to a flag, e.g. CODECTX_MAX_FILE_SIZE
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyDefaults resolves the options not given on the command line. Values in
+// the config's "defaults" take precedence over CODECTX_* environment variables,
+// and flags take precedence over both.
+func applyDefaults(flags *flag.FlagSet, defaults map[string]any) error {
+	for name := range defaults {
+		if flags.Lookup(name) == nil || len(name) == 1 || unboundFlags[name] {
+			return fmt.Errorf("unknown option in config defaults: %s", name)
+		}
+	}
+
+	// Flags set on the command line, including through their short aliases
+	explicit := make(map[string]bool)
+	explicitTargets := make(map[uintptr]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+		if target := flagTarget(f); target != 0 {
+			explicitTargets[target] = true
+		}
+	})
+
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || len(f.Name) == 1 || unboundFlags[f.Name] || explicit[f.Name] || explicitTargets[flagTarget(f)] {
+			return
+		}
+		if value, ok := defaults[f.Name]; ok {
+			if setErr := setConfigValue(f, value); setErr != nil {
+				err = fmt.Errorf("invalid value for %s in config defaults: %w", f.Name, setErr)
+			}
+			return
+		}
+		if value := os.Getenv(envName(f.Name)); value != "" {
+			if setErr := f.Value.Set(value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", value, envName(f.Name), setErr)
+			}
+		}
+	})
+	return err
+}
+
+// setConfigValue sets a flag from a JSON value. Lists set repeatable flags once per element.
+func setConfigValue(f *flag.Flag, value any) error {
+	switch v := value.(type) {
+	case string:
+		return f.Value.Set(v)
+	case bool:
+		return f.Value.Set(strconv.FormatBool(v))
+	case float64:
+		return f.Value.Set(strconv.FormatFloat(v, 'f', -1, 64))
+	case []any:
+		for _, element := range v {
+			if err := setConfigValue(f, element); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported value %v", value)
+	}
+}
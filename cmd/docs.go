@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Formats generated by "codectx docs"
+const (
+	docsMan      = "man"
+	docsMarkdown = "markdown"
+)
+
+// flagDoc describes one option for the generated documentation
+type flagDoc struct {
+	names    []string // Long name first, then short aliases
+	arg      string   // Argument placeholder, or "" for boolean flags
+	optional bool     // The argument may be omitted (--name or --name=VALUE)
+	usage    string
+	def      string
+}
+
+// envDoc describes an environment variable read by codectx
+type envDoc struct {
+	name  string
+	usage string
+}
+
+// environmentDocs lists the environment variables codectx reads
+var environmentDocs = []envDoc{
+	{"CODECTX_<OPTION>", "Default for --<option> when it is not given, e.g. CODECTX_FORMAT=markdown or CODECTX_MAX_FILE_SIZE=2MB. Dashes become underscores; config file defaults take precedence."},
+	{"CODECTX_PAGER", "Pager for terminal output; takes precedence over PAGER. An empty value or \"cat\" disables paging."},
+	{"PAGER", "Pager for terminal output (default: less)."},
+	{"LESS", "Options for less; FRX is used when unset."},
+	{"NO_COLOR", "Disables --color=auto when set to a non-empty value."},
+	{"CODECTX_HISTORY", "Records each run in ~/.codectx/history.jsonl when set to 1 or true."},
+	{"CODECTX_UPDATE_URL", "GitHub releases API URL used by self-update, for mirrors."},
+	{"GITHUB_TOKEN", "GitHub token used by \"codectx pr\" to read private repositories; GH_TOKEN is used when unset."},
+	{"GITLAB_TOKEN", "GitLab token used by \"codectx pr\" to read private projects."},
+	{"CODECTX_REGISTRY_USERNAME", "Registry username used by \"codectx image\" to pull private images, with CODECTX_REGISTRY_PASSWORD; the credentials of docker login are used when unset."},
+	{"CODECTX_OUTPUT_AUTHORIZATION", "Authorization header sent when --output is an http(s) URL, e.g. \"Bearer TOKEN\"."},
+	{"AWS_ACCESS_KEY_ID", "AWS credentials for s3:// outputs, with AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN."},
+	{"AWS_REGION", "Region of s3:// outputs; AWS_DEFAULT_REGION is used when unset (default: us-east-1)."},
+	{"AWS_ENDPOINT_URL_S3", "Endpoint of an S3-compatible service for s3:// outputs; AWS_ENDPOINT_URL is used when unset."},
+}
+
+// runDocs writes the man page or Markdown CLI reference to standard output
+func runDocs(format string) error {
+	w := bufio.NewWriter(os.Stdout)
+	docs := collectFlagDocs(flag.CommandLine)
+	if format == docsMan {
+		writeManPage(w, docs)
+	} else {
+		writeMarkdownReference(w, docs)
+	}
+	return w.Flush()
+}
+
+// collectFlagDocs describes the defined flags in alphabetical order, folding
+// single-letter aliases into the flag that writes to the same variable
+func collectFlagDocs(flags *flag.FlagSet) []flagDoc {
+	var docs []flagDoc
+	byTarget := make(map[uintptr]int)
+	var aliases []*flag.Flag
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			aliases = append(aliases, f)
+			return
+		}
+		doc := flagDoc{names: []string{f.Name}, usage: f.Usage, def: f.DefValue}
+		if _, ok := f.Value.(*optionalStringValue); ok {
+			doc.arg, doc.optional = "VALUE", true
+		} else if name, _ := flag.UnquoteUsage(f); name != "" {
+			doc.arg = argPlaceholder(name)
+		}
+		if doc.def == "false" || doc.def == "[]" {
+			doc.def = ""
+		}
+		byTarget[flagTarget(f)] = len(docs)
+		docs = append(docs, doc)
+	})
+
+	for _, alias := range aliases {
+		if index, ok := byTarget[flagTarget(alias)]; ok {
+			docs[index].names = append(docs[index].names, alias.Name)
+		}
+	}
+	return docs
+}
+
+// flagTarget identifies the variable a flag writes to. Flags registered with
+// flag.StringVar and friends on the same variable share the same pointer.
+func flagTarget(f *flag.Flag) uintptr {
+	value := reflect.ValueOf(f.Value)
+	if value.Kind() != reflect.Pointer {
+		return 0
+	}
+	return value.Pointer()
+}
+
+// argPlaceholder maps the type names reported by flag.UnquoteUsage to placeholders
+func argPlaceholder(name string) string {
+	switch name {
+	case "int", "int64", "uint", "uint64", "float":
+		return "N"
+	default:
+		return "VALUE"
+	}
+}
+
+// spelling returns the option names and argument placeholder, e.g. "-f, --format" and " VALUE"
+func (d flagDoc) spelling() (string, string) {
+	var short, long []string
+	for _, name := range d.names {
+		if len(name) == 1 {
+			short = append(short, "-"+name)
+		} else {
+			long = append(long, "--"+name)
+		}
+	}
+	names := strings.Join(append(short, long...), ", ")
+	switch {
+	case d.optional:
+		return names, "[=" + d.arg + "]"
+	case d.arg != "":
+		return names, " " + d.arg
+	}
+	return names, ""
+}
+
+// writeManPage writes a roff man page for section 1
+func writeManPage(w io.Writer, docs []flagDoc) {
+	fmt.Fprintf(w, ".TH CODECTX 1 \"\" \"codectx %s\" \"User Commands\"\n", version)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, "codectx \\- unified directory and file content viewer")
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, ".B codectx")
+	fmt.Fprintln(w, "[\\fIOPTIONS\\fR] [\\fIDIRECTORY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx docs")
+	fmt.Fprintln(w, "\\fBman\\fR|\\fBmarkdown\\fR")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx history")
+	fmt.Fprintln(w, "[\\fIINDEX\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx again")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx focus")
+	fmt.Fprintln(w, "\\fIFILE\\fR|\\fISYMBOL\\fR [\\fIOPTIONS\\fR] [\\fIDIRECTORY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx pr")
+	fmt.Fprintln(w, "\\fIURL\\fR|\\fINUMBER\\fR [\\fIOPTIONS\\fR] [\\fIDIRECTORY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx image")
+	fmt.Fprintln(w, "\\fIREF\\fR [\\fIOPTIONS\\fR] [\\fIDIRECTORY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx alias")
+	fmt.Fprintln(w, "\\fBsave\\fR \\fINAME\\fR \\fB\\-\\-\\fR \\fIARGS\\fR...|\\fBlist\\fR|\\fBdelete\\fR \\fINAME\\fR")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx plugins")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx render")
+	fmt.Fprintln(w, "\\fIFILE\\fR|\\fB\\-\\fR [\\fB\\-\\-format\\fR \\fIFORMAT\\fR] [\\fB\\-o\\fR \\fIOUTPUT\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx query")
+	fmt.Fprintln(w, "\\fBfiles\\fR [\\fIOPTIONS\\fR] [\\fIDIRECTORY\\fR]|\\fBtokens\\fR \\fIPATH\\fR|\\fBlanguage-of\\fR \\fIPATH\\fR")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx merge")
+	fmt.Fprintln(w, "[\\fILABEL\\fR=]\\fIFILE\\fR... [\\fB\\-\\-format\\fR \\fIFORMAT\\fR] [\\fB\\-o\\fR \\fIOUTPUT\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx serve")
+	fmt.Fprintln(w, "\\fB\\-\\-grpc\\fR [\\fB\\-\\-listen\\fR \\fIADDRESS\\fR] [\\fB\\-\\-root\\fR \\fIDIRECTORY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx verify")
+	fmt.Fprintln(w, "\\fIFILE\\fR [\\fB\\-\\-key\\fR \\fIPUBKEY\\fR]")
+	fmt.Fprintln(w, ".br")
+	fmt.Fprintln(w, ".B codectx self-update")
+	fmt.Fprintln(w, "[\\fB\\-\\-check\\fR]")
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, roffEscape("codectx scans a directory and writes its tree and file contents as text, Markdown, HTML, or JSON, formatted as context for AI assistants. DIRECTORY defaults to the current directory."))
+	fmt.Fprintln(w, ".SH OPTIONS")
+	for _, doc := range docs {
+		fmt.Fprintln(w, ".TP")
+		names, arg := doc.spelling()
+		if arg != "" {
+			arg = "\\fI" + roffEscape(arg) + "\\fR"
+		}
+		fmt.Fprintf(w, "\\fB%s\\fR%s\n", roffEscape(names), arg)
+		fmt.Fprintln(w, roffEscape(doc.description()))
+	}
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, env := range environmentDocs {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, "\\fB%s\\fR\n", env.name)
+		fmt.Fprintln(w, roffEscape(env.usage))
+	}
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintln(w, "git(1), less(1)")
+}
+
+// writeMarkdownReference writes the CLI reference as Markdown
+func writeMarkdownReference(w io.Writer, docs []flagDoc) {
+	fmt.Fprintln(w, "# codectx CLI reference")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "<!-- Generated by `codectx docs markdown`; do not edit. -->")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "```")
+	fmt.Fprintln(w, "codectx [OPTIONS] [DIRECTORY]")
+	fmt.Fprintln(w, "codectx docs man|markdown")
+	fmt.Fprintln(w, "codectx history [INDEX]")
+	fmt.Fprintln(w, "codectx again")
+	fmt.Fprintln(w, "codectx focus FILE|SYMBOL [OPTIONS] [DIRECTORY]")
+	fmt.Fprintln(w, "codectx pr URL|NUMBER [OPTIONS] [DIRECTORY]")
+	fmt.Fprintln(w, "codectx image REF [OPTIONS] [DIRECTORY]")
+	fmt.Fprintln(w, "codectx alias save NAME -- ARGS... | list | delete NAME")
+	fmt.Fprintln(w, "codectx plugins")
+	fmt.Fprintln(w, "codectx render FILE|- [--format FORMAT] [-o OUTPUT]")
+	fmt.Fprintln(w, "codectx query files [OPTIONS] [DIRECTORY] | tokens PATH | language-of PATH")
+	fmt.Fprintln(w, "codectx merge [LABEL=]FILE... [--format FORMAT] [-o OUTPUT]")
+	fmt.Fprintln(w, "codectx serve --grpc [--listen ADDRESS] [--root DIRECTORY]")
+	fmt.Fprintln(w, "codectx verify FILE [--key PUBKEY]")
+	fmt.Fprintln(w, "codectx self-update [--check]")
+	fmt.Fprintln(w, "```")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Options")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Option | Description |")
+	fmt.Fprintln(w, "|--------|-------------|")
+	for _, doc := range docs {
+		names, arg := doc.spelling()
+		fmt.Fprintf(w, "| `%s%s` | %s |\n", names, arg, markdownCellEscape(doc.description()))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Environment")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Variable | Description |")
+	fmt.Fprintln(w, "|----------|-------------|")
+	for _, env := range environmentDocs {
+		fmt.Fprintf(w, "| `%s` | %s |\n", env.name, markdownCellEscape(env.usage))
+	}
+}
+
+// description returns the usage text with the default value, if any
+func (d flagDoc) description() string {
+	if d.def == "" || strings.Contains(d.usage, "default") {
+		return d.usage
+	}
+	return fmt.Sprintf("%s (default: %s)", d.usage, d.def)
+}
+
+// roffEscape escapes text for use in a roff document
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
+
+// markdownCellEscape escapes text for use in a Markdown table cell
+func markdownCellEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import "strings"
+
+// optionalStringValue is a string flag that may also be given without a value.
+// "--name" sets the implicit value, while "--name=value" sets an explicit one.
+type optionalStringValue struct {
+	target   *string
+	implicit string
+}
+
+// newOptionalStringValue creates an optional-value flag writing to target
+func newOptionalStringValue(target *string, implicit string) *optionalStringValue {
+	return &optionalStringValue{target: target, implicit: implicit}
+}
+
+// String returns the current value of the flag
+func (v *optionalStringValue) String() string {
+	if v == nil || v.target == nil {
+		return ""
+	}
+	return *v.target
+}
+
+// Set stores the flag value, mapping a bare flag to the implicit value
+func (v *optionalStringValue) Set(value string) error {
+	switch value {
+	case "true":
+		*v.target = v.implicit
+	case "false":
+		*v.target = ""
+	default:
+		*v.target = value
+	}
+	return nil
+}
+
+// IsBoolFlag allows the flag to be given without a value
+func (v *optionalStringValue) IsBoolFlag() bool {
+	return true
+}
+
+// stringSliceValue is a repeatable string flag that collects every occurrence
+type stringSliceValue struct {
+	target *[]string
+}
+
+// newStringSliceValue creates a repeatable flag appending to target
+func newStringSliceValue(target *[]string) *stringSliceValue {
+	return &stringSliceValue{target: target}
+}
+
+// String returns the collected values
+func (v *stringSliceValue) String() string {
+	if v == nil || v.target == nil {
+		return ""
+	}
+	return strings.Join(*v.target, ",")
+}
+
+// Set appends a value
+func (v *stringSliceValue) Set(value string) error {
+	*v.target = append(*v.target, value)
+	return nil
+}
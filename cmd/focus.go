@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/analysis"
+	"codectx/internal/platform"
+)
+
+// focusTarget splits "codectx focus TARGET ARGS..." into the target and the
+// remaining arguments. Other arguments are returned unchanged with no target.
+func focusTarget(args []string) (string, []string) {
+	if len(args) < 2 || args[0] != "focus" || isDirectory(args[0]) {
+		return "", args
+	}
+	return args[1], args[2:]
+}
+
+// focusPath returns the target of "codectx focus" relative to targetDir when
+// it names a file, or unchanged when it is a symbol name
+func focusPath(targetDir, target string) (string, error) {
+	info, err := os.Stat(target)
+	if err != nil || info.IsDir() {
+		return target, nil
+	}
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	relPath, err := platform.RelSlash(targetDir, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return "", fmt.Errorf("focus target %s is not in %s", target, targetDir)
+	}
+	return relPath, nil
+}
+
+// focusFiles narrows the included files (with a leading slash) to the context
+// slice around the focus target, in the order of the slice
+func (r *runner) focusFiles(targetDir string, included []string) ([]string, error) {
+	target, err := focusPath(targetDir, r.opts.Focus)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(included))
+	for i, relPath := range included {
+		paths[i] = relPath[1:]
+	}
+	focus, err := analysis.BuildFocus(targetDir, paths, target, r.opts.FocusTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opts.Verbose {
+		for _, file := range focus.Files {
+			fmt.Fprintf(r.stderr, "Focus %s: %s (~%d tokens)\n", file.Relation, file.Path, file.Tokens)
+		}
+	}
+	if focus.Omitted > 0 {
+		fmt.Fprintf(r.stderr, "Warning: %d related files omitted to stay within --focus-tokens %d\n", focus.Omitted, r.opts.FocusTokens)
+	}
+
+	files := make([]string, len(focus.Files))
+	for i, relPath := range focus.Paths() {
+		files[i] = "/" + relPath
+	}
+	return files, nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"codectx/internal/history"
+)
+
+// historyEnabled reports whether runs are recorded, which is opt-in via CODECTX_HISTORY
+func historyEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("CODECTX_HISTORY"))
+	return enabled
+}
+
+// recordHistory appends the current run to the history. Failures are reported
+// as warnings since they must not fail the run itself.
+func recordHistory(start time.Time, summary runSummary) {
+	if !historyEnabled() {
+		return
+	}
+	path, err := history.DefaultPath()
+	if err == nil {
+		var dir string
+		if dir, err = os.Getwd(); err == nil {
+			err = history.Append(path, history.Entry{
+				Time:       start,
+				Dir:        dir,
+				Args:       os.Args[1:],
+				Files:      summary.Files,
+				Tokens:     summary.Tokens,
+				DurationMS: time.Since(start).Milliseconds(),
+			})
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+	}
+}
+
+// runHistory lists the recorded runs, or re-runs the one with the given index
+func runHistory(args []string) error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if len(args) == 1 {
+		index, err := strconv.Atoi(args[0])
+		if err != nil || index < 1 || index > len(entries) {
+			return fmt.Errorf("invalid history index: %s (1-%d)", args[0], len(entries))
+		}
+		return rerun(entries[index-1])
+	}
+
+	if len(entries) == 0 {
+		if !historyEnabled() {
+			fmt.Println("No history recorded. Set CODECTX_HISTORY=1 to record runs.")
+		} else {
+			fmt.Println("No history recorded yet.")
+		}
+		return nil
+	}
+	for i, entry := range entries {
+		fmt.Printf("%4d  %s  %s\n", i+1, entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Dir)
+		fmt.Printf("      %s  (%d files, ~%d tokens, %s)\n",
+			commandLine(entry.Args), entry.Files, entry.Tokens, time.Duration(entry.DurationMS)*time.Millisecond)
+	}
+	return nil
+}
+
+// runAgain re-runs the most recently recorded command
+func runAgain() error {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no recorded runs to repeat (set CODECTX_HISTORY=1 to record runs)")
+	}
+	return rerun(entries[len(entries)-1])
+}
+
+// rerun runs a recorded command again in its original working directory
+func rerun(entry history.Entry) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the codectx binary: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Running in %s: %s\n", entry.Dir, commandLine(entry.Args))
+
+	command := exec.Command(executable, entry.Args...)
+	command.Dir = entry.Dir
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("re-run failed: %w", err)
+	}
+	return nil
+}
+
+// commandLine formats arguments as a shell command, quoting those that need it
+func commandLine(args []string) string {
+	parts := []string{"codectx"}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/formatter"
+	"codectx/internal/hooks"
+	"codectx/internal/remote"
+)
+
+// hookEnv describes the run to hook commands through environment variables
+func hookEnv(opts Options, targetDir string) []string {
+	output := opts.Output
+	if output != "" && !remote.IsRemote(output) {
+		if abs, err := filepath.Abs(output); err == nil {
+			output = abs
+		}
+	}
+	return []string{
+		"CODECTX_TARGET_DIR=" + targetDir,
+		"CODECTX_FORMAT=" + strings.ToLower(opts.Format),
+		"CODECTX_OUTPUT=" + output,
+	}
+}
+
+// transformHook returns the formatter transform that pipes file content through
+// the per-file-transform hooks, or nil if there are none
+func transformHook(opts Options, targetDir string) formatter.TransformFunc {
+	configured := opts.Hooks
+	if configured == nil || len(configured.PerFileTransform) == 0 {
+		return nil
+	}
+	env := hookEnv(opts, targetDir)
+	return func(path string, content []byte) ([]byte, error) {
+		relPath, err := filepath.Rel(targetDir, path)
+		if err != nil {
+			relPath = path
+		}
+		relPath = filepath.ToSlash(relPath)
+		fileEnv := append(env[:len(env):len(env)], "CODECTX_FILE="+relPath, "CODECTX_FILE_PATH="+path)
+		return hooks.Transform(configured.PerFileTransform, relPath, content, targetDir, fileEnv)
+	}
+}
+
+// runPostOutputHooks runs the post-output hooks once the output is complete
+func runPostOutputHooks(opts Options, targetDir string) error {
+	if opts.Hooks == nil || opts.DryRun {
+		return nil
+	}
+	return hooks.RunStage(opts.Hooks.PostOutput, targetDir, hookEnv(opts, targetDir))
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"codectx/internal/oci"
+)
+
+// imageTarget splits "codectx image REF ARGS..." into the image reference
+// and the remaining arguments. Other arguments are returned unchanged with
+// no image.
+func imageTarget(args []string) (string, []string) {
+	if len(args) < 2 || args[0] != "image" || isDirectory(args[0]) {
+		return "", args
+	}
+	return args[1], args[2:]
+}
+
+// pullImage pulls the image of "codectx image" into a temporary directory
+// and returns the directory to scan: the image's root, or the directory
+// given as TARGET_DIR within it. The returned function removes the image.
+func pullImage(ctx context.Context, opts Options, stderr io.Writer) (string, *oci.Image, func(), error) {
+	ref, err := oci.ParseReference(opts.Image)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	platform := oci.DefaultPlatform()
+	if opts.ImagePlatform != "" {
+		if platform, err = oci.ParsePlatform(opts.ImagePlatform); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "codectx-image-")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+	// The root is named after the image, as the tree shows it
+	root := filepath.Join(tempDir, ref.Name())
+	if err := os.Mkdir(root, 0755); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	fmt.Fprintf(stderr, "Pulling %s (%s)...\n", ref, platform)
+	image, err := oci.Pull(ctx, ref, platform, root)
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	if opts.Verbose {
+		fmt.Fprintf(stderr, "Pulled %s: %d layers\n", image.Digest, image.Layers)
+	}
+
+	targetDir := root
+	if opts.TargetDir != "" {
+		targetDir = filepath.Join(root, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(opts.TargetDir))))
+	}
+	return targetDir, image, cleanup, nil
+}
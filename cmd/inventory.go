@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"codectx/internal/formatter"
+	"codectx/internal/language"
+	"codectx/internal/platform"
+	"codectx/internal/scanner"
+)
+
+// summaryInventory lists the included files of targetDir for --summary in
+// output order, with the sizes, line counts, and token estimates collected for
+// the tree
+func summaryInventory(targetDir string, details map[string]*scanner.EntryDetails, included []string) []formatter.InventoryEntry {
+	inventory := make([]formatter.InventoryEntry, 0, len(included))
+	for _, relPath := range included {
+		entry := formatter.InventoryEntry{Path: relPath[1:]}
+		if lang, ok := language.DetectFile(platform.JoinSlash(targetDir, relPath)); ok {
+			entry.Language = lang.Name
+		}
+		if fileDetails := details[relPath]; fileDetails != nil {
+			entry.SizeBytes = fileDetails.Size
+			entry.Lines = fileDetails.Lines
+			entry.Tokens = fileDetails.Tokens
+		}
+		inventory = append(inventory, entry)
+	}
+	return inventory
+}
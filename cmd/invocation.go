@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+
+	"codectx/internal/analysis"
+	"codectx/internal/config"
+	"codectx/internal/filter"
+	"codectx/internal/formatter"
+	"codectx/internal/images"
+	"codectx/internal/minified"
+)
+
+// invocation records the command line, version, config file, and filters of
+// the run for the output metadata
+func (r *runner) invocation() *formatter.Invocation {
+	invocation := &formatter.Invocation{
+		Version: currentVersionInfo().Version,
+		Filters: activeFilters(r.opts),
+	}
+	if r.opts.Args != nil {
+		invocation.Command = commandLine(r.opts.Args)
+	}
+	if path, err := config.DefaultPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			sum := sha256.Sum256(data)
+			invocation.ConfigFile = path
+			invocation.ConfigHash = hex.EncodeToString(sum[:])
+		}
+	}
+	return invocation
+}
+
+// scanOptions describes the options that chose, limited, and ordered the files
+// for the JSON metadata, with the extensions and exclude patterns as the filter
+// applies them
+func (r *runner) scanOptions(filter *filter.Filter, totalLimit int64, sortOrder []string) formatter.JSONScanOptions {
+	return formatter.JSONScanOptions{
+		ExtensionsFilter: filter.Extensions,
+		ExcludePatterns:  filter.ExcludePatterns,
+		IncludeRegex:     r.opts.IncludeRegex,
+		ExcludeRegex:     r.opts.ExcludeRegex,
+		Format:           strings.ToLower(r.opts.Format),
+		MaxFileSize:      r.opts.MaxFileSize,
+		CharacterLimit:   totalLimit,
+		Budget:           r.opts.Budget,
+		MinSize:          r.opts.MinSize,
+		MaxSize:          r.opts.MaxSize,
+		ModifiedSince:    r.opts.ModifiedSince,
+		IncludeDotfiles:  r.opts.IncludeDotfiles,
+		GitOnly:          r.opts.GitOnly,
+		RespectGitignore: r.opts.RespectGitignore && !r.opts.IgnoreGitignore,
+		IncludeGitInfo:   r.opts.IncludeGitInfo,
+		GitStatus:        r.opts.GitStatus,
+		SortOrder:        sortOrder,
+	}
+}
+
+// activeFilters lists the options that chose the included files as the flags,
+// or the focus and pr commands, that set them, whether they came from the
+// command line, the config defaults, or the environment
+func activeFilters(opts Options) []string {
+	var filters []string
+	add := func(name, value string) {
+		if value != "" {
+			filters = append(filters, "--"+name+" "+value)
+		}
+	}
+	enabled := func(name string, set bool) {
+		if set {
+			filters = append(filters, "--"+name)
+		}
+	}
+
+	add("extensions", opts.Extensions)
+	add("exclude", opts.Exclude)
+	for _, pattern := range opts.IncludeRegex {
+		add("include-regex", pattern)
+	}
+	for _, pattern := range opts.ExcludeRegex {
+		add("exclude-regex", pattern)
+	}
+	enabled("include-dotfiles", opts.IncludeDotfiles)
+	add("min-size", opts.MinSize)
+	add("max-size", opts.MaxSize)
+	add("modified-since", opts.ModifiedSince)
+	add("project", opts.Project)
+	add("workspace", opts.Workspace)
+	add("bazel-target", opts.BazelTarget)
+	if opts.MaxFiles > 0 {
+		add("max-files", strconv.Itoa(opts.MaxFiles))
+	}
+	if opts.MaxDepth > 0 {
+		add("max-depth", strconv.Itoa(opts.MaxDepth))
+	}
+	if opts.GitOnly != "" {
+		filters = append(filters, "--git-only="+opts.GitOnly)
+	}
+	enabled("include-untracked", opts.IncludeUntracked)
+	enabled("respect-gitignore", opts.RespectGitignore && !opts.IgnoreGitignore)
+	if opts.Tests != analysis.TestsInclude {
+		add("tests", opts.Tests)
+	}
+	enabled("schema-only", opts.SchemaOnly)
+	enabled("iac", opts.IaC)
+	if opts.Focus != "" {
+		filters = append(filters, "focus "+opts.Focus)
+	}
+	if opts.PR != "" {
+		filters = append(filters, "pr "+opts.PR)
+	}
+	enabled("changelog-context", opts.ChangelogContext)
+	if opts.DeadFiles == analysis.DeadFilesExclude {
+		add("dead-files", opts.DeadFiles)
+	}
+	if opts.Images == images.ModeSkip {
+		add("images", opts.Images)
+	}
+	if opts.Minified == minified.ModeSkip {
+		add("minified", opts.Minified)
+	}
+	return filters
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"codectx/internal/git"
+	"codectx/internal/platform"
+)
+
+// lfsObject is an included file tracked with Git LFS
+type lfsObject struct {
+	size    int64 // Size of the object's content
+	fetched bool  // The content is in the working tree rather than a pointer
+}
+
+// lfsObjects finds the Git LFS pointer files among the included files, keyed
+// by their clean relative path. With --lfs-fetch, it first downloads their
+// objects with "git lfs pull"; the pointers left are those it couldn't fetch.
+func (r *runner) lfsObjects(targetDir string, included []string) map[string]lfsObject {
+	objects := make(map[string]lfsObject)
+	var pointers []string
+	for _, relPath := range included {
+		if pointer, ok, err := git.DetectLFSPointer(platform.JoinSlash(targetDir, relPath)); err == nil && ok {
+			objects[relPath[1:]] = lfsObject{size: pointer.Size}
+			pointers = append(pointers, relPath[1:])
+		}
+	}
+	if !r.opts.LFSFetch || len(pointers) == 0 {
+		return objects
+	}
+
+	fmt.Fprintf(r.stderr, "Fetching %d Git LFS objects...\n", len(pointers))
+	if err := git.PullLFS(targetDir, pointers); err != nil {
+		fmt.Fprintf(r.stderr, "Warning: %v; their pointers are described instead\n", err)
+	}
+	for _, relPath := range pointers {
+		if _, ok, err := git.DetectLFSPointer(platform.JoinSlash(targetDir, relPath)); err == nil && !ok {
+			objects[relPath] = lfsObject{size: objects[relPath].size, fetched: true}
+		}
+	}
+	return objects
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/formatter"
+	"codectx/internal/scanner"
+)
+
+// runMerge combines JSON outputs into one document:
+// "codectx merge [LABEL=]FILE... [--format FORMAT] [-o OUTPUT]"
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	format := flags.String("format", "", "Output format (text, html, markdown, json, or a plugin format; default: from the output file's extension)")
+	flags.StringVar(format, "f", "", "Output format (short)")
+	output := flags.String("output", "", "Output file (default: stdout)")
+	flags.StringVar(output, "o", "", "Output file (short)")
+	noLineNumbers := flags.Bool("no-line-numbers", false, "Don't show line numbers")
+	flags.BoolVar(noLineNumbers, "n", false, "Don't show line numbers (short)")
+	treeStyle := flags.String("tree-style", "unicode", "Tree drawing style (unicode, ascii, bold, none)")
+
+	// The flags may come before, between, or after the files
+	var paths []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		paths = append(paths, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(paths) < 2 {
+		return errors.New("usage: codectx merge [LABEL=]FILE [LABEL=]FILE... [--format FORMAT] [-o OUTPUT]")
+	}
+	chars, err := scanner.ParseTreeStyle(*treeStyle)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := readMergeInputs(paths)
+	if err != nil {
+		return err
+	}
+	merged := formatter.Merge(inputs, chars)
+
+	if *format == "" {
+		*format = formatForExtension(*output)
+	}
+	if strings.EqualFold(*format, string(formatter.JSONFormat)) || !formatter.IsBuiltinFormat(*format) {
+		data, err := json.MarshalIndent(merged, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		if !formatter.IsBuiltinFormat(*format) {
+			return renderWithPlugin(bytes.NewReader(data), strings.ToLower(*format), *output)
+		}
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		file, err := formatter.CreateOutput(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	f, err := formatter.NewFormatter(*format, !*noLineNumbers, *output, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := f.RenderDocument(merged); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readMergeInputs reads the JSON outputs to merge. An input is labeled with
+// the name of its file unless given as LABEL=FILE.
+func readMergeInputs(paths []string) ([]formatter.MergeInput, error) {
+	labels := make(map[string]bool)
+	inputs := make([]formatter.MergeInput, 0, len(paths))
+	for _, path := range paths {
+		label := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if name, file, ok := strings.Cut(path, "="); ok && name != "" && !strings.ContainsAny(name, `/\`) {
+			label, path = name, file
+		}
+		if labels[label] {
+			return nil, fmt.Errorf("two inputs are labeled %q; name them with LABEL=FILE", label)
+		}
+		labels[label] = true
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var doc formatter.JSONOutput
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON output %s: %w", path, err)
+		}
+		inputs = append(inputs, formatter.MergeInput{Label: label, Document: &doc})
+	}
+	return inputs, nil
+}
+
+// formatForExtension picks the output format matching a file name's extension,
+// defaulting to text
+func formatForExtension(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return string(formatter.MarkdownFormat)
+	case ".html", ".htm":
+		return string(formatter.HTMLFormat)
+	case ".json":
+		return string(formatter.JSONFormat)
+	}
+	return string(formatter.TextFormat)
+}
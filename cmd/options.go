@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"flag"
+	"time"
+
+	"codectx/internal/analysis"
+	"codectx/internal/hooks"
+	"codectx/internal/images"
+	"codectx/internal/minified"
+	"codectx/internal/scanner"
+)
+
+// Options configures one run of codectx. Execute fills it from the command
+// line; programs embedding codectx start from DefaultOptions and call RunWithOptions.
+type Options struct {
+	TargetDir string   // Directory to scan (default: current directory)
+	Args      []string // Command line arguments, recorded in the output metadata (nil when embedded)
+
+	// Output format
+	Format string
+
+	// Filtering options
+	Extensions      string
+	Exclude         string
+	IncludeDotfiles bool
+	MinSize         string
+	MaxSize         string
+	ModifiedSince   string
+	Project         string // Name or path of a detected project to scope the scan to
+	Workspace       string // Name or path of a JS/TS workspace to scope the scan to, with the workspaces it depends on
+	BazelTarget     string // Bazel target pattern whose source files, resolved with bazel query, are the only ones scanned
+	IncludeRegex    []string
+	ExcludeRegex    []string
+	Materialize     bool // Check out the files of a sparse checkout that the include filters match before scanning
+
+	// Scan limits for pathological trees (0 for no limit)
+	MaxFiles    int
+	MaxDepth    int
+	ScanTimeout time.Duration
+
+	OneFileSystem bool
+
+	// Size limits
+	Limit       string
+	MaxFileSize string
+	Budget      string
+
+	MaxFileTokens        int64
+	MaxFileBytesIncluded string
+	MaxLineLength        string
+
+	// Header and footer templates
+	HeaderFile string
+	FooterFile string
+	Vars       []string
+
+	// Extensions
+	Plugins []string     // Analyzer plugins to run
+	Hooks   *hooks.Hooks // Hooks to run (nil for none)
+
+	// Languages of extensions (".gotmpl") and file names ("Jenkinsfile"),
+	// overriding the built-in ones for the whole process
+	Languages map[string]string
+
+	// Statistics
+	Stats bool
+	SI    bool // Show sizes in powers of 1000 (kB, MB) instead of 1024
+
+	// Git integration
+	GitOnly          string
+	IncludeUntracked bool
+	RespectGitignore bool
+	IgnoreGitignore  bool
+	IncludeGitInfo   bool
+	GitStatus        bool
+	History          int  // Include the last N commits as a section (0 for none)
+	ChangelogContext bool // Include the changes since the last tag and only the files they touch
+	LFSFetch         bool // Download the Git LFS objects of included pointer files instead of describing them
+
+	// Advanced analysis
+	HealthCheck         bool
+	DebugPatterns       []string // Regexes of debug statements flagged by the health check, besides the built-in ones
+	ComplexityAnalysis  bool
+	ComplexityThreshold int // Cyclomatic complexity above which functions are reported
+	NestingThreshold    int // Nesting depth above which functions are reported
+	LengthThreshold     int // Length in lines above which functions are reported
+	LanguageStats       bool
+	Hotspots            bool   // Rank files by churn (commits) times complexity
+	Ownership           bool   // Show primary contributors and bus factors from the Git history
+	DeadFiles           string // analysis.DeadFilesReport or DeadFilesExclude to find unused packages and assets ("" for none)
+	DocCoverage         bool   // Measure how many public symbols have doc comments
+	StringsReport       bool   // List the user-facing string literals
+	MinStringLength     int    // Length from which string literals are listed
+	ConfigInventory     bool   // List the environment variables and configuration keys
+
+	// Dependency audit in the health check
+	AuditDeps bool   // Look up known vulnerabilities of the dependencies in OSV
+	OSVDB     string // Directory of OSV records to use instead of the OSV API
+	SARIF     string // File to write the audit findings to as SARIF
+
+	Coverage string // Go coverage profile or LCOV report to annotate files with their test coverage
+
+	// Repository map
+	RepoMap   bool
+	MapTokens int
+
+	Xref bool // Add an index of where symbols are defined and used
+
+	Summary bool // Output the tree, statistics, and an inventory of the files instead of their contents
+
+	APISurface string // analysis.APISurfaceReplace or APISurfaceAppend to output the exported API of Go packages ("" for none)
+
+	// Focused context slice ("codectx focus TARGET")
+	Focus       string // File (relative to the working directory) or symbol to focus on ("" for none)
+	FocusTokens int
+
+	// Pull request review context ("codectx pr URL|NUMBER")
+	PR string // Pull request URL, or number on the origin remote ("" for none)
+
+	// Container image scanning ("codectx image REF"), with TargetDir a directory in the image
+	Image         string // Image reference, such as "nginx:1.27" ("" to scan a directory)
+	ImagePlatform string // Platform to pull from multi-platform images, such as "linux/arm64" ("" for Linux on this architecture)
+
+	// Test files
+	PairTests bool   // Place each test file right after the source file it covers
+	Tests     string // analysis.TestsInclude, TestsSkip, or TestsOnly
+
+	SchemaOnly     bool // Include only the database schema, migrations, and ORM models
+	IaC            bool // Include only Terraform, Helm charts, and Kubernetes manifests, by module
+	ContractsFirst bool // Place API contracts (OpenAPI, .proto, GraphQL, Thrift) at the top of the output
+
+	NoKeyFiles   bool
+	NoExtract    bool
+	ExtractPDF   bool
+	KeepDataURIs bool
+	RedactPII    bool
+	Anonymize    string
+	Images       string
+	Minified     string
+	NormalizeEOL string
+	ExpandTabs   int
+	DetectIndent bool
+	Dedupe       bool
+
+	// Tree rendering
+	ASCIITree   bool
+	TreeStyle   string
+	TreeDetails string
+	Color       string
+
+	NaturalSort      bool // Order names with numbers by value, e.g. file2 before file10
+	DirectoryRecords bool // List each directory with its file counts and size in JSON output
+
+	// Other options
+	NoPager       bool
+	Output        string
+	OutputSpec    []string // FORMAT=PATH outputs written from the same scan, the first in place of --format and --output
+	NoLineNumbers bool
+	Verbose       bool
+	DryRun        bool
+	Confirm       bool
+	Porcelain     bool // Write tab-separated records of the files on stdout, and the output only to Output
+
+	// Policy
+	PolicyOverride bool
+
+	// Signing
+	Sign string // Ed25519 private key signing the output file
+}
+
+// DefaultOptions returns the options used when no flags are given
+func DefaultOptions() Options {
+	return Options{
+		TargetDir:           ".",
+		Format:              "text",
+		MaxFileSize:         "1MB",
+		MaxLineLength:       "1MB",
+		IgnoreGitignore:     true,
+		MapTokens:           analysis.DefaultRepoMapTokens,
+		FocusTokens:         analysis.DefaultFocusTokens,
+		ComplexityThreshold: analysis.DefaultComplexityThreshold,
+		NestingThreshold:    analysis.DefaultNestingThreshold,
+		LengthThreshold:     analysis.DefaultLengthThreshold,
+		MinStringLength:     analysis.DefaultMinStringLength,
+		Tests:               analysis.TestsInclude,
+		Images:              images.ModePlaceholder,
+		Minified:            minified.ModePlaceholder,
+		TreeStyle:           "unicode",
+	}
+}
+
+// defineFlags registers the command line flags that fill opts, using its
+// current values as the defaults
+func defineFlags(flags *flag.FlagSet, opts *Options) {
+	flags.StringVar(&opts.Format, "format", opts.Format, "Output format (text, html, markdown, json, or a plugin format)")
+	flags.StringVar(&opts.Format, "f", opts.Format, "Output format (short)")
+
+	flags.StringVar(&opts.Extensions, "extensions", opts.Extensions, "Filter by file extensions (comma-separated)")
+	flags.StringVar(&opts.Extensions, "e", opts.Extensions, "Filter by file extensions (short)")
+
+	flags.StringVar(&opts.Exclude, "exclude", opts.Exclude, "Exclude patterns (comma-separated)")
+	flags.StringVar(&opts.Exclude, "x", opts.Exclude, "Exclude patterns (short)")
+
+	flags.BoolVar(&opts.IncludeDotfiles, "include-dotfiles", opts.IncludeDotfiles, "Include dotfiles")
+
+	flags.StringVar(&opts.MinSize, "min-size", opts.MinSize, "Only include files at least this large (e.g., 1KB)")
+	flags.StringVar(&opts.MaxSize, "max-size", opts.MaxSize, "Only include files at most this large (e.g., 100KB)")
+	flags.Var(newStringSliceValue(&opts.IncludeRegex), "include-regex", "Only include paths matching this regex (repeatable)")
+	flags.Var(newStringSliceValue(&opts.ExcludeRegex), "exclude-regex", "Exclude paths matching this regex; prefix with ! to re-include (repeatable)")
+	flags.BoolVar(&opts.Materialize, "materialize", opts.Materialize, "In a sparse checkout, check out the files --extensions and --include-regex match before scanning")
+	flags.StringVar(&opts.ModifiedSince, "modified-since", opts.ModifiedSince, "Only include files modified since a date or age (e.g., 2024-01-01, 7d)")
+	flags.StringVar(&opts.Project, "project", opts.Project, "Only scan the detected project with this name or path (see --stats)")
+	flags.StringVar(&opts.BazelTarget, "bazel-target", opts.BazelTarget, "Only scan the source files of a Bazel target and its dependencies, resolved with bazel query (e.g., //service/api/...)")
+	flags.StringVar(&opts.Workspace, "workspace", opts.Workspace, "Only scan the pnpm, Yarn, npm, or Nx workspace with this name or path, and the workspaces it depends on")
+
+	flags.IntVar(&opts.MaxFiles, "max-files", opts.MaxFiles, "Stop scanning after this many files (0 for no limit)")
+	flags.IntVar(&opts.MaxDepth, "max-depth", opts.MaxDepth, "Don't scan more than this many directory levels deep (0 for no limit)")
+	flags.DurationVar(&opts.ScanTimeout, "scan-timeout", opts.ScanTimeout, "Stop scanning after this long, e.g. 30s (0 for no limit)")
+	flags.BoolVar(&opts.OneFileSystem, "one-file-system", opts.OneFileSystem, "Don't descend into directories on other file systems (mount points)")
+
+	flags.StringVar(&opts.Limit, "limit", opts.Limit, "Maximum total character limit, e.g. 100000 or 2MB (0 for no limit)")
+	flags.StringVar(&opts.Limit, "l", opts.Limit, "Maximum total character limit (short)")
+
+	flags.StringVar(&opts.MaxFileSize, "max-file-size", opts.MaxFileSize, "Maximum file size (e.g., 1MB, 500KB)")
+	flags.Int64Var(&opts.MaxFileTokens, "max-file-tokens", opts.MaxFileTokens, "Truncate each file after about this many tokens (0 for no limit)")
+	flags.StringVar(&opts.MaxFileBytesIncluded, "max-file-bytes-included", opts.MaxFileBytesIncluded, "Truncate each file after this many bytes (e.g., 20KB)")
+	flags.StringVar(&opts.MaxLineLength, "max-line-length", opts.MaxLineLength, "Maximum length of a single line before the rest of the file is skipped")
+	flags.StringVar(&opts.HeaderFile, "header-file", opts.HeaderFile, "Template placed before the generated context")
+	flags.StringVar(&opts.FooterFile, "footer-file", opts.FooterFile, "Template placed after the generated context")
+	flags.Var(newStringSliceValue(&opts.Vars), "var", "Template variable as key=value (repeatable)")
+	flags.Var(newStringSliceValue(&opts.Plugins), "plugin", "Run the analyzer plugin codectx-NAME on PATH (repeatable)")
+	flags.StringVar(&opts.Budget, "budget", opts.Budget, "Split the limit across path groups (e.g., \"tests/**=10%,docs/**=5%\")")
+
+	flags.BoolVar(&opts.Stats, "stats", opts.Stats, "Show statistics")
+	flags.BoolVar(&opts.SI, "si", opts.SI, "Show sizes in powers of 1000 (kB, MB, GB) instead of 1024")
+
+	flags.StringVar(&opts.Output, "output", opts.Output, "Output file")
+	flags.StringVar(&opts.Output, "o", opts.Output, "Output file (short)")
+	flags.Var(newStringSliceValue(&opts.OutputSpec), "output-spec", "Write the output in several formats from one scan, as FORMAT=PATH[,FORMAT=PATH...] (repeatable)")
+	flags.StringVar(&opts.Sign, "sign", opts.Sign, "Write a detached signature of the output file to FILE.sig with this Ed25519 key")
+	flags.BoolVar(&opts.NoPager, "no-pager", opts.NoPager, "Don't pipe terminal output into $PAGER")
+
+	flags.BoolVar(&opts.ASCIITree, "ascii-tree", opts.ASCIITree, "Draw the directory tree with ASCII characters")
+	flags.StringVar(&opts.TreeStyle, "tree-style", opts.TreeStyle, "Tree drawing style (unicode, ascii, bold, none)")
+	flags.Var(newOptionalStringValue(&opts.TreeDetails, scanner.DefaultTreeDetails), "tree-details", "Annotate tree entries with details (size, lines, tokens; default: lines,tokens)")
+	flags.BoolVar(&opts.NaturalSort, "natural-sort", opts.NaturalSort, "Order names containing numbers by value, e.g. file2 before file10")
+	flags.BoolVar(&opts.DirectoryRecords, "directory-records", opts.DirectoryRecords, "List each directory with its file counts and total size in JSON output")
+	flags.Var(newOptionalStringValue(&opts.Color, colorAuto), "color", "Highlight text output with ANSI colors (auto, always, never; default: auto)")
+
+	flags.BoolVar(&opts.RepoMap, "repo-map", opts.RepoMap, "Output a ranked map of declarations instead of file contents")
+	flags.IntVar(&opts.MapTokens, "map-tokens", opts.MapTokens, "Token budget of the repository map (0 for no limit)")
+	flags.Var(newOptionalStringValue(&opts.APISurface, analysis.APISurfaceReplace), "api-surface", "Output the exported API of Go packages in place of their files (=append adds it after the file contents)")
+	flags.BoolVar(&opts.Xref, "xref", opts.Xref, "Add an index of where symbols are defined and which files use them")
+	flags.BoolVar(&opts.Summary, "summary", opts.Summary, "Output the tree, statistics, and an inventory of the files instead of their contents")
+	flags.IntVar(&opts.FocusTokens, "focus-tokens", opts.FocusTokens, "Token budget of \"codectx focus\" (0 for no limit)")
+	flags.StringVar(&opts.ImagePlatform, "image-platform", opts.ImagePlatform, "Platform of \"codectx image\" to pull from multi-platform images (e.g., linux/arm64; default: linux on this architecture)")
+
+	flags.BoolVar(&opts.PairTests, "pair-tests", opts.PairTests, "Place each test file right after the source file it covers")
+	flags.StringVar(&opts.Tests, "tests", opts.Tests, "Include test files (include), leave them out (skip), or include only them (only)")
+	flags.BoolVar(&opts.SchemaOnly, "schema-only", opts.SchemaOnly, "Include only schema files, migrations in the order they run, and ORM models")
+	flags.BoolVar(&opts.IaC, "iac", opts.IaC, "Include only infrastructure as code (Terraform, Helm charts, Kubernetes manifests), grouped by module, and count its resources in the stats")
+
+	flags.BoolVar(&opts.NoKeyFiles, "no-key-files", opts.NoKeyFiles, "Don't tag or prioritize key files (entry points, manifests, READMEs, ...)")
+	flags.BoolVar(&opts.ContractsFirst, "contracts-first", opts.ContractsFirst, "Output API contracts (OpenAPI, AsyncAPI, .proto, GraphQL, Thrift) before all other files")
+
+	flags.BoolVar(&opts.NoExtract, "no-extract", opts.NoExtract, "Don't convert notebooks (.ipynb, .rmd) and documents (.docx, .odt) to plain text")
+	flags.BoolVar(&opts.ExtractPDF, "extract-pdf", opts.ExtractPDF, "Include the text of PDF files instead of skipping them as binary")
+	flags.BoolVar(&opts.KeepDataURIs, "keep-data-uris", opts.KeepDataURIs, "Keep base64 data URIs and embedded blobs instead of replacing them with placeholders")
+	flags.StringVar(&opts.Anonymize, "anonymize", opts.Anonymize, "Rename the identifiers of a mapping file (\"original = replacement\" lines) throughout the output")
+	flags.BoolVar(&opts.RedactPII, "redact-pii", opts.RedactPII, "Replace email addresses, phone numbers, and national ID numbers in file content with placeholders")
+	flags.StringVar(&opts.Images, "images", opts.Images, "How to include image files: placeholder, embed (HTML thumbnails), or skip")
+	flags.StringVar(&opts.Minified, "minified", opts.Minified, "How to include minified JS and CSS: placeholder, skip, or include")
+	flags.StringVar(&opts.NormalizeEOL, "normalize-eol", opts.NormalizeEOL, "Write file content with lf or crlf line endings")
+	flags.IntVar(&opts.ExpandTabs, "expand-tabs", opts.ExpandTabs, "Expand tabs in file content to tab stops this many columns apart (0 keeps tabs)")
+	flags.BoolVar(&opts.DetectIndent, "detect-indent", opts.DetectIndent, "Detect each file's indent style and rewrite lines indented the other way")
+	flags.BoolVar(&opts.Dedupe, "dedupe", opts.Dedupe, "Include identical files once and replace later copies with a stub")
+
+	flags.BoolVar(&opts.NoLineNumbers, "no-line-numbers", opts.NoLineNumbers, "Don't show line numbers")
+	flags.BoolVar(&opts.NoLineNumbers, "n", opts.NoLineNumbers, "Don't show line numbers (short)")
+
+	flags.BoolVar(&opts.Verbose, "verbose", opts.Verbose, "Verbose output")
+	flags.BoolVar(&opts.Verbose, "v", opts.Verbose, "Verbose output (short)")
+
+	flags.BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "Show files that would be processed without processing them")
+	flags.BoolVar(&opts.PolicyOverride, "policy-override", opts.PolicyOverride, "Output files denied by .codectx-policy.yaml instead of stopping, with a warning for each")
+	flags.BoolVar(&opts.Confirm, "confirm", opts.Confirm, "List the files by directory and ask which directories to include before output")
+	flags.BoolVar(&opts.Porcelain, "porcelain", opts.Porcelain, "Write stable tab-separated records of the files processed, skipped, and failed on stdout")
+
+	// Git integration flags
+	flags.Var(newOptionalStringValue(&opts.GitOnly, gitOnlyTracked), "git-only", "Only include Git tracked files (=working also includes untracked, non-ignored files)")
+	flags.BoolVar(&opts.IncludeUntracked, "include-untracked", opts.IncludeUntracked, "With --git-only, also include untracked files not ignored by Git")
+	flags.BoolVar(&opts.RespectGitignore, "respect-gitignore", opts.RespectGitignore, "Respect .gitignore patterns")
+	flags.BoolVar(&opts.IgnoreGitignore, "ignore-gitignore", opts.IgnoreGitignore, "Ignore .gitignore patterns (default)")
+	flags.BoolVar(&opts.IncludeGitInfo, "include-git-info", opts.IncludeGitInfo, "Include Git information in output")
+	flags.BoolVar(&opts.GitStatus, "git-status", opts.GitStatus, "Show Git status information")
+	flags.BoolVar(&opts.ChangelogContext, "changelog-context", opts.ChangelogContext, "Include the commits and diff since the last tag, and only the files they changed, for writing release notes")
+	flags.BoolVar(&opts.LFSFetch, "lfs-fetch", opts.LFSFetch, "Download the Git LFS objects of included pointer files with git lfs pull and include their content")
+	flags.IntVar(&opts.History, "history", opts.History, "Include the last N commits (hash, author, date, subject, files) as a section and in the JSON metadata")
+
+	// Advanced analysis flags
+	flags.BoolVar(&opts.HealthCheck, "health-check", opts.HealthCheck, "Perform project health check")
+	flags.Var(newStringSliceValue(&opts.DebugPatterns), "debug-pattern", "Also flag lines matching this regex as debug statements in the health check (repeatable)")
+	flags.BoolVar(&opts.ComplexityAnalysis, "complexity-analysis", opts.ComplexityAnalysis, "Perform complexity analysis")
+	flags.IntVar(&opts.ComplexityThreshold, "complexity-threshold", opts.ComplexityThreshold, "With --complexity-analysis, report functions with a cyclomatic complexity above N (all functions are listed in the JSON metadata)")
+	flags.IntVar(&opts.NestingThreshold, "nesting-threshold", opts.NestingThreshold, "Report functions with blocks nested deeper than N levels (in the complexity analysis and health check)")
+	flags.IntVar(&opts.LengthThreshold, "function-length-threshold", opts.LengthThreshold, "Report functions longer than N lines (in the complexity analysis and health check)")
+	flags.BoolVar(&opts.LanguageStats, "language-stats", opts.LanguageStats, "Show language statistics")
+	flags.BoolVar(&opts.Hotspots, "hotspots", opts.Hotspots, "Rank files by Git churn times complexity (in the stats and JSON metadata)")
+	flags.BoolVar(&opts.Ownership, "ownership", opts.Ownership, "Show primary contributors and bus factors per directory from the Git history (in the stats and JSON metadata)")
+	flags.Var(newOptionalStringValue(&opts.DeadFiles, analysis.DeadFilesReport), "dead-files", "List Go packages no other package imports and assets no file references (=exclude also leaves them out)")
+	flags.BoolVar(&opts.DocCoverage, "doc-coverage", opts.DocCoverage, "Show the share of public Go, Python, and JS/TS symbols with doc comments and list the undocumented ones (in the stats and JSON metadata)")
+	flags.BoolVar(&opts.StringsReport, "strings-report", opts.StringsReport, "List the string literals of UI, handler, and command files that read as prose, with file and line (in the stats and JSON metadata)")
+	flags.IntVar(&opts.MinStringLength, "min-string-length", opts.MinStringLength, "With --strings-report, list string literals of at least N characters")
+	flags.BoolVar(&opts.ConfigInventory, "config-inventory", opts.ConfigInventory, "List the environment variables the code reads and the keys of JSON, YAML, and TOML config files (in the stats and JSON metadata)")
+	flags.BoolVar(&opts.AuditDeps, "audit-deps", opts.AuditDeps, "Check dependencies for known vulnerabilities in the health check (queries the OSV API)")
+	flags.StringVar(&opts.OSVDB, "osv-db", opts.OSVDB, "Audit dependencies against a directory of OSV records instead of the OSV API")
+	flags.StringVar(&opts.SARIF, "sarif", opts.SARIF, "Write the dependency audit findings to this file as SARIF")
+	flags.StringVar(&opts.Coverage, "coverage", opts.Coverage, "Annotate files with their test coverage from a Go coverage profile or LCOV report")
+}
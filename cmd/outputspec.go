@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"codectx/internal/formatter"
+	"codectx/internal/utils"
+)
+
+// outputSpec is one FORMAT=PATH entry of --output-spec
+type outputSpec struct {
+	Format string
+	Path   string
+}
+
+// parseOutputSpecs parses the --output-spec values, each a comma-separated list
+// such as "markdown=ctx.md,json=ctx.json", in order
+func parseOutputSpecs(values []string) ([]outputSpec, error) {
+	var specs []outputSpec
+	paths := make(map[string]bool)
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			format, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			format, path = strings.ToLower(strings.TrimSpace(format)), strings.TrimSpace(path)
+			if !ok || format == "" || path == "" {
+				return nil, fmt.Errorf("invalid --output-spec entry %q (expected FORMAT=PATH)", entry)
+			}
+			if !formatter.IsBuiltinFormat(format) {
+				return nil, fmt.Errorf("unsupported --output-spec format: %s (expected text, html, markdown, or json)", format)
+			}
+			if paths[path] {
+				return nil, fmt.Errorf("--output-spec writes %s twice", path)
+			}
+			paths[path] = true
+			specs = append(specs, outputSpec{Format: format, Path: path})
+		}
+	}
+	return specs, nil
+}
+
+// applyOutputSpecs makes the first --output-spec entry the output of the run,
+// as --format and --output would, and returns the others
+func applyOutputSpecs(opts *Options) ([]outputSpec, error) {
+	specs, err := parseOutputSpecs(opts.OutputSpec)
+	if err != nil || len(specs) == 0 {
+		return nil, err
+	}
+	if opts.Output != "" {
+		return nil, errors.New("--output-spec can't be combined with --output")
+	}
+	opts.Format, opts.Output = specs[0].Format, specs[0].Path
+	return specs[1:], nil
+}
+
+// addOutputs creates the extra --output-spec outputs on f, each written like
+// the main output; anonymizer is nil unless --anonymize is set
+func (r *runner) addOutputs(f *formatter.Formatter, anonymizer *utils.Anonymizer) error {
+	for _, spec := range r.outputs {
+		file, err := formatter.CreateOutput(spec.Path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		var writer io.Writer = file
+		// JSON encodes the content's line endings; other formats are written line by line
+		if r.opts.NormalizeEOL == utils.EOLCRLF && spec.Format != string(formatter.JSONFormat) {
+			writer = utils.NewCRLFWriter(writer)
+		}
+		if anonymizer != nil {
+			writer = anonymizer.NewWriter(writer)
+		}
+		if err := f.AddOutput(spec.Format, writer); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// writesJSON reports whether the output, or one of the --output-spec outputs,
+// is JSON, whose metadata carries the statistics and analyses
+func (r *runner) writesJSON() bool {
+	if strings.EqualFold(r.opts.Format, string(formatter.JSONFormat)) {
+		return true
+	}
+	for _, spec := range r.outputs {
+		if spec.Format == string(formatter.JSONFormat) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pagerCommand returns the pager command from CODECTX_PAGER or PAGER, defaulting
+// to less. It returns nil when paging is disabled with an empty value or "cat".
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("CODECTX_PAGER")
+	if !ok {
+		pager, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		pager = "less"
+	}
+
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	return args
+}
+
+// startPager pipes standard output through the pager, as git does. Like git, it
+// sets LESS=FRX unless LESS is already set, so that less passes colors through and
+// exits right away when the output fits on one screen. The returned function
+// closes the pipe and waits for the pager to exit; it is nil when no pager runs.
+// Output written to the returned file, or to os.Stdout, goes to the pager.
+func startPager() (*os.File, func(), error) {
+	args := pagerCommand()
+	if args == nil {
+		return nil, nil, nil
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	pager := exec.Command(path, args[1:]...)
+	pager.Stdin = reader
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	pager.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		pager.Env = append(pager.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		pager.Env = append(pager.Env, "LV=-c")
+	}
+	if err := pager.Start(); err != nil {
+		reader.Close()
+		writer.Close()
+		return nil, nil, err
+	}
+	reader.Close()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	return writer, func() {
+		writer.Close()
+		os.Stdout = stdout
+		pager.Wait()
+	}, nil
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codectx/internal/formatter"
+	"codectx/internal/git"
+	"codectx/internal/limits"
+	"codectx/internal/platform"
+	"codectx/internal/plugin"
+	"codectx/internal/utils"
+)
+
+// runPlugins lists the plugins found on PATH with what they provide
+func runPlugins() error {
+	plugins := plugin.Discover(os.Getenv("PATH"))
+	if len(plugins) == 0 {
+		fmt.Printf("No plugins found. Plugins are executables named %sNAME on PATH.\n", plugin.Prefix)
+		return nil
+	}
+	for _, p := range plugins {
+		info, err := p.Info()
+		if err != nil {
+			fmt.Printf("%-16s %s (error: %v)\n", p.Name, p.Path, err)
+			continue
+		}
+		var provides []string
+		if info.Analyzer {
+			provides = append(provides, "analyzer")
+		}
+		for _, format := range info.Formats {
+			provides = append(provides, "format "+format)
+		}
+		fmt.Printf("%-16s %s\n", p.Name, p.Path)
+		if info.Description != "" {
+			fmt.Printf("%-16s %s\n", "", info.Description)
+		}
+		if len(provides) > 0 {
+			fmt.Printf("%-16s provides: %s\n", "", strings.Join(provides, ", "))
+		}
+	}
+	return nil
+}
+
+// startAnalyzers starts the analyzer plugins named by --plugin
+func startAnalyzers(names []string) ([]*plugin.Analyzer, error) {
+	var analyzers []*plugin.Analyzer
+	for _, name := range names {
+		p, ok := plugin.Find(os.Getenv("PATH"), name)
+		if !ok {
+			return nil, fmt.Errorf("plugin not found: %s (looked for %s%s on PATH)", name, plugin.Prefix, name)
+		}
+		analyzer, err := p.StartAnalyzer()
+		if err != nil {
+			return nil, err
+		}
+		analyzers = append(analyzers, analyzer)
+	}
+	return analyzers, nil
+}
+
+// pluginFileEvent describes an included file for the analyzer plugins
+func pluginFileEvent(fullPath, relPath string, stat platform.StatFunc) plugin.FileEvent {
+	event := plugin.FileEvent{Path: relPath, FullPath: fullPath}
+	if info, err := platform.Stat(stat, fullPath); err == nil {
+		event.Size = info.Size()
+	}
+	return event
+}
+
+// finishAnalyzers waits for the analyzer plugins and prints their findings to stderr
+func finishAnalyzers(analyzers []*plugin.Analyzer, stderr io.Writer) {
+	var findings []plugin.Finding
+	for _, analyzer := range analyzers {
+		result, err := analyzer.Finish()
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+		}
+		findings = append(findings, result...)
+	}
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintln(stderr, "\nPlugin findings:")
+	for _, finding := range findings {
+		location := finding.Path
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, finding.Line)
+		}
+		if location != "" {
+			location += ": "
+		}
+		severity := ""
+		if finding.Severity != "" {
+			severity = finding.Severity + ": "
+		}
+		fmt.Fprintf(stderr, "  [%s] %s%s%s\n", finding.Plugin, location, severity, finding.Message)
+	}
+}
+
+// createFormatter creates the formatter for --format. Formats that are not
+// built in are handled by a plugin, which reads the JSON output on stdin.
+func createFormatter(opts Options, stdout io.Writer, sizeLimiter *limits.SizeLimiter, gitInfo *git.GitInfo) (*formatter.Formatter, error) {
+	if formatter.IsBuiltinFormat(opts.Format) {
+		f, err := formatter.NewFormatter(opts.Format, !opts.NoLineNumbers, opts.Output, sizeLimiter, gitInfo)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Output == "" {
+			f.Writer = stdout
+		}
+		f.EOL = opts.NormalizeEOL
+		// JSON encodes the content's line endings; other formats are written line by line
+		if opts.NormalizeEOL == utils.EOLCRLF && f.Format != formatter.JSONFormat {
+			f.Writer = utils.NewCRLFWriter(f.Writer)
+		}
+		return f, nil
+	}
+
+	format := strings.ToLower(opts.Format)
+	p, ok := plugin.FindFormat(os.Getenv("PATH"), format)
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+	out := stdout
+	var file io.WriteCloser
+	if opts.Output != "" {
+		var err error
+		if file, err = formatter.CreateOutput(opts.Output); err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		out = file
+	}
+	writer, err := p.StartFormatter(format, out)
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+
+	f, err := formatter.NewFormatter("json", !opts.NoLineNumbers, "", sizeLimiter, gitInfo)
+	if err != nil {
+		return nil, err
+	}
+	f.Writer = &pluginOutput{plugin: writer, file: file}
+	f.EOL = opts.NormalizeEOL
+	return f, nil
+}
+
+// pluginOutput passes the JSON output to a formatter plugin. Closing it waits
+// for the plugin, then closes the output file.
+type pluginOutput struct {
+	plugin *plugin.FormatWriter
+	file   io.WriteCloser // Output file, or nil for stdout
+}
+
+// Write passes output to the plugin
+func (o *pluginOutput) Write(data []byte) (int, error) {
+	return o.plugin.Write(data)
+}
+
+// Close waits for the plugin and closes the output file
+func (o *pluginOutput) Close() error {
+	err := o.plugin.Close()
+	if o.file != nil {
+		if closeErr := o.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/forge"
+	"codectx/internal/git"
+	"codectx/internal/platform"
+	"codectx/internal/policy"
+)
+
+// maxPolicyViolationsShown bounds the denied files listed in the policy error
+const maxPolicyViolationsShown = 20
+
+// loadPolicy reads the policy file of the scanned directory, or else of the
+// root of its Git repository. It returns the policy, nil if there is none, and
+// the path of targetDir relative to the policy file's directory.
+func loadPolicy(targetDir string) (*policy.Policy, string, error) {
+	p, err := policy.Load(filepath.Join(targetDir, policy.FileName))
+	if p != nil || err != nil {
+		return p, "", err
+	}
+
+	repoRoot, err := git.GetRepoRoot(targetDir)
+	if err != nil {
+		return nil, "", nil
+	}
+	p, err = policy.Load(filepath.Join(repoRoot, policy.FileName))
+	if p == nil || err != nil {
+		return nil, "", err
+	}
+	prefix := ""
+	if rel, err := platform.RelSlash(repoRoot, targetDir); err == nil && rel != "." {
+		prefix = rel
+	}
+	return p, prefix, nil
+}
+
+// checkPolicy checks the included files (with a leading slash) against the
+// policy file, along with the files whose content reaches the output through
+// other sections (without one). Denied files stop the run unless
+// --policy-override is given, in which case they are reported as warnings. It
+// returns the decision recorded in the metadata, or nil when there is no
+// policy file.
+func (r *runner) checkPolicy(targetDir string, included, sectionPaths []string) (*policy.Decision, error) {
+	p, prefix, err := loadPolicy(targetDir)
+	if p == nil || err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(included)+len(sectionPaths))
+	checked := make(map[string]bool, len(included))
+	for _, relPath := range included {
+		paths = append(paths, relPath[1:])
+		checked[relPath[1:]] = true
+	}
+	for _, relPath := range sectionPaths {
+		if !checked[relPath] {
+			paths = append(paths, relPath)
+			checked[relPath] = true
+		}
+	}
+	decision := &policy.Decision{File: p.File, Rules: len(p.Deny), Outcome: policy.OutcomePassed}
+	decision.Violations = p.Check(prefix, paths)
+	if len(decision.Violations) == 0 {
+		if r.opts.Verbose {
+			fmt.Fprintf(r.stderr, "Policy %s: no denied files included\n", p.File)
+		}
+		return decision, nil
+	}
+
+	if !r.opts.PolicyOverride {
+		var b strings.Builder
+		fmt.Fprintf(&b, "policy %s denies exporting %d included files:", p.File, len(decision.Violations))
+		for i, violation := range decision.Violations {
+			if i == maxPolicyViolationsShown {
+				fmt.Fprintf(&b, "\n  ... and %d more", len(decision.Violations)-i)
+				break
+			}
+			fmt.Fprintf(&b, "\n  %s", violationText(violation))
+		}
+		b.WriteString("\nexclude them (e.g., with --exclude) or rerun with --policy-override")
+		return nil, errors.New(b.String())
+	}
+
+	decision.Outcome = policy.OutcomeOverridden
+	for _, violation := range decision.Violations {
+		fmt.Fprintf(r.stderr, "Warning: policy override: including %s\n", violationText(violation))
+	}
+	return decision, nil
+}
+
+// sectionPaths returns the paths (without a leading slash) whose content the
+// sections after the tree show besides the included files: review comments
+// discuss the lines of their files. The changelog diff needs no paths of its
+// own, as it is built from the included files once they are checked.
+func sectionPaths(pullRequest *forge.PullRequest) []string {
+	var paths []string
+	if pullRequest != nil {
+		for _, comment := range pullRequest.Comments {
+			if comment.Path != "" {
+				paths = append(paths, comment.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// violationText describes a denied file with the rule that denies it
+func violationText(violation policy.Violation) string {
+	text := fmt.Sprintf("%s (denied by %s", violation.Path, violation.Pattern)
+	if violation.Reason != "" {
+		text += ": " + violation.Reason
+	}
+	return text + ")"
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// porcelainVersion is the version of the --porcelain record format. Records
+// may gain trailing fields within a version; any other change bumps it.
+const porcelainVersion = 1
+
+// Reasons of the --porcelain skip records
+const (
+	skipExcluded   = "excluded"    // Left out by the filters (extensions, patterns, sizes, dates)
+	skipBinary     = "binary"      // Not a text file
+	skipMinified   = "minified"    // A minified asset with --minified skip
+	skipLinked     = "linked"      // The same physical file as an included path
+	skipTests      = "tests"       // Left out by --tests
+	skipNarrowed   = "narrowed"    // Outside the workspace, Bazel target, focus, pull request, or changelog
+	skipSchema     = "schema-only" // Not a schema file with --schema-only
+	skipIaC        = "iac"         // Not infrastructure as code with --iac
+	skipDead       = "dead"        // Unused, with --dead-files exclude
+	skipDeclined   = "declined"    // Declined at the --confirm prompt
+	skipDuplicate  = "duplicate"   // Identical to an earlier file with --dedupe
+	skipSummarized = "summarized"  // Replaced by the repository map or the API surface
+)
+
+// porcelainWriter writes the --porcelain records: one line per event on
+// stdout, with tab-separated fields. Its methods do nothing on a nil writer,
+// so that a run without --porcelain needn't check.
+//
+//	codectx-porcelain VERSION
+//	file PATH
+//	skip PATH REASON [DETAIL]
+//	error PATH MESSAGE
+//	done FILES TOKENS
+type porcelainWriter struct {
+	w io.Writer
+}
+
+// newPorcelainWriter starts the records with the version line
+func newPorcelainWriter(w io.Writer) *porcelainWriter {
+	p := &porcelainWriter{w: w}
+	p.record("codectx-porcelain", strconv.Itoa(porcelainVersion))
+	return p
+}
+
+// file records a file included in the output
+func (p *porcelainWriter) file(path string) {
+	p.record("file", path)
+}
+
+// skip records a file left out of the output, with one of the skip reasons
+// and, for duplicates and linked files, the path of the included file
+func (p *porcelainWriter) skip(path, reason string, detail ...string) {
+	p.record(append([]string{"skip", path, reason}, detail...)...)
+}
+
+// failed records a file that couldn't be read or formatted
+func (p *porcelainWriter) failed(path string, err error) {
+	p.record("error", path, err.Error())
+}
+
+// done records the end of the output
+func (p *porcelainWriter) done(summary runSummary) {
+	p.record("done", strconv.Itoa(summary.Files), strconv.FormatInt(summary.Tokens, 10))
+}
+
+// snapshot copies the included files before a step that narrows them, for
+// narrowed. It returns nil when there are no records to write.
+func (p *porcelainWriter) snapshot(included []string) []string {
+	if p == nil {
+		return nil
+	}
+	return slices.Clone(included)
+}
+
+// narrowed records the files of before (with a leading slash) that a step
+// left out of after
+func (p *porcelainWriter) narrowed(before, after []string, reason string) {
+	if p == nil {
+		return
+	}
+	kept := make(map[string]bool, len(after))
+	for _, relPath := range after {
+		kept[relPath] = true
+	}
+	for _, relPath := range before {
+		if !kept[relPath] {
+			p.skip(relPath[1:], reason)
+		}
+	}
+}
+
+// record writes one record. Fields with tabs, line breaks, quotes, or
+// backslashes are written as double-quoted strings with C-style escapes.
+func (p *porcelainWriter) record(fields ...string) {
+	if p == nil {
+		return
+	}
+	for i, field := range fields {
+		if strings.ContainsAny(field, `"\`) || strings.IndexFunc(field, isNotPrint) >= 0 {
+			fields[i] = strconv.Quote(field)
+		}
+	}
+	fmt.Fprintln(p.w, strings.Join(fields, "\t"))
+}
+
+// isNotPrint reports whether r is a control or other unprintable character
+func isNotPrint(r rune) bool {
+	return !strconv.IsPrint(r)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"codectx/internal/forge"
+	"codectx/internal/git"
+	"codectx/internal/platform"
+)
+
+// prTarget splits "codectx pr URL|NUMBER ARGS..." into the pull request and
+// the remaining arguments. Other arguments are returned unchanged with no
+// pull request.
+func prTarget(args []string) (string, []string) {
+	if len(args) < 2 || args[0] != "pr" || isDirectory(args[0]) {
+		return "", args
+	}
+	return args[1], args[2:]
+}
+
+// pullRequestFiles fetches the pull request of "codectx pr" and narrows the
+// included files (with a leading slash) to those it changes, in its order
+func (r *runner) pullRequestFiles(targetDir string, included []string) ([]string, *forge.PullRequest, error) {
+	// A number refers to a pull request on the origin remote
+	remoteURL, _ := git.GetRemoteURL(targetDir, "origin")
+	ref, err := forge.ParseRef(r.opts.PR, remoteURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	pr, err := forge.Fetch(ref, forge.Token(ref.Forge))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info, err := git.GetGitInfo(targetDir); err == nil && pr.HeadCommit != "" && info.CommitHash != pr.HeadCommit {
+		fmt.Fprintf(r.stderr, "Warning: the checked out commit is not the head of pull request #%d (%s); file contents may differ\n", pr.Number, shortHash(pr.HeadCommit))
+	}
+
+	// Changed paths are relative to the repository root
+	prefix := ""
+	if repoRoot, err := git.GetRepoRoot(targetDir); err == nil {
+		if rel, err := platform.RelSlash(repoRoot, targetDir); err == nil && rel != "." {
+			prefix = rel + "/"
+		}
+	}
+	includedSet := make(map[string]bool, len(included))
+	for _, relPath := range included {
+		includedSet[relPath] = true
+	}
+
+	var files []string
+	missing := 0
+	for _, file := range pr.Files {
+		if file.Removed() {
+			continue
+		}
+		relPath, ok := strings.CutPrefix(file.Path, prefix)
+		if !ok {
+			continue // Outside the scanned directory
+		}
+		if !includedSet["/"+relPath] {
+			missing++
+			continue
+		}
+		files = append(files, "/"+relPath)
+		if r.opts.Verbose {
+			fmt.Fprintf(r.stderr, "Pull request #%d %s: %s\n", pr.Number, file.Status, relPath)
+		}
+	}
+	if missing > 0 {
+		fmt.Fprintf(r.stderr, "Warning: %d files changed by pull request #%d are not included (excluded, or not checked out)\n", missing, pr.Number)
+	}
+	return files, pr, nil
+}
+
+// shortHash abbreviates a commit hash for messages
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
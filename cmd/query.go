@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"codectx/internal/language"
+	"codectx/internal/stats"
+)
+
+// queryUsage lists the queries of "codectx query"
+const queryUsage = "usage: codectx query files [OPTIONS] [DIR] | tokens PATH [OPTIONS] | language-of PATH"
+
+// queryFile describes a file in the results of "codectx query files"
+type queryFile struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size_bytes"`
+	Language string `json:"language,omitempty"`
+	Tokens   int    `json:"tokens"`
+}
+
+// queryFilesResult is the result of "codectx query files"
+type queryFilesResult struct {
+	Directory  string      `json:"directory"`
+	Files      []queryFile `json:"files"`
+	TotalFiles int         `json:"total_files"`
+	TotalSize  int64       `json:"total_size_bytes"`
+	Tokens     int         `json:"tokens"`
+}
+
+// queryTokensResult is the result of "codectx query tokens"
+type queryTokensResult struct {
+	Path   string `json:"path"`
+	Files  int    `json:"files"`
+	Tokens int    `json:"tokens"`
+}
+
+// queryLanguageResult is the result of "codectx query language-of"
+type queryLanguageResult struct {
+	Path     string `json:"path"`
+	Language string `json:"language"` // Identifier, or "" if unknown
+	Name     string `json:"name,omitempty"`
+}
+
+// runQuery answers a question about the files codectx would output, as JSON
+// on stdout: "codectx query files|tokens|language-of ..."
+func runQuery(args []string) error {
+	if len(args) == 0 {
+		return errors.New(queryUsage)
+	}
+	var result any
+	var err error
+	switch args[0] {
+	case "files":
+		result, err = queryFiles(args[1:])
+	case "tokens":
+		result, err = queryTokens(args[1:])
+	case "language-of":
+		result, err = queryLanguageOf(args[1:])
+	default:
+		return fmt.Errorf("unknown query %q; %s", args[0], queryUsage)
+	}
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// parseQueryOptions parses the scan options of a query, which may come
+// before or after its arguments, and applies the config defaults like a scan
+func parseQueryOptions(name string, args []string) (Options, []string, error) {
+	opts := DefaultOptions()
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	defineFlags(flags, &opts)
+	flags.StringVar(&opts.Extensions, "ext", opts.Extensions, "Filter by file extensions (comma-separated)")
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return opts, nil, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return opts, nil, err
+	}
+	if err := applyDefaults(flags, cfg.Defaults); err != nil {
+		return opts, nil, err
+	}
+	opts.Languages = cfg.Languages
+	return opts, positional, nil
+}
+
+// selectFiles runs the scan pipeline without output and returns what it
+// would include
+func selectFiles(ctx context.Context, opts Options) (runSummary, error) {
+	opts.DryRun = true
+	opts.NoPager = true
+	opts.Stats = false
+	opts.Output, opts.OutputSpec, opts.Sign = "", nil, ""
+	opts.Confirm, opts.Porcelain = false, false
+	stderr := io.Discard
+	if opts.Verbose {
+		stderr = os.Stderr
+	}
+	return runWithOptions(ctx, opts, io.Discard, stderr)
+}
+
+// queryFiles lists the files a scan of DIR with the given options would
+// include: "codectx query files [OPTIONS] [DIR]"
+func queryFiles(args []string) (*queryFilesResult, error) {
+	opts, positional, err := parseQueryOptions("query files", args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) > 1 {
+		return nil, fmt.Errorf("unexpected argument: %s", positional[1])
+	}
+	if len(positional) == 1 {
+		opts.TargetDir = positional[0]
+	}
+
+	summary, err := selectFiles(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &queryFilesResult{Directory: summary.TargetDir, Files: []queryFile{}}
+	for _, relPath := range summary.Paths {
+		fullPath := filepath.Join(summary.TargetDir, filepath.FromSlash(relPath))
+		file := queryFile{Path: relPath}
+		if info, err := os.Stat(fullPath); err == nil {
+			file.Size = info.Size()
+		}
+		if lang, ok := language.DetectFile(fullPath); ok {
+			file.Language = lang.ID
+		}
+		file.Tokens, _ = stats.EstimateTokens(fullPath)
+		result.Files = append(result.Files, file)
+		result.TotalSize += file.Size
+		result.Tokens += file.Tokens
+	}
+	result.TotalFiles = len(result.Files)
+	return result, nil
+}
+
+// queryTokens estimates the tokens of a file, or of the files a scan of a
+// directory would include: "codectx query tokens PATH [OPTIONS]"
+func queryTokens(args []string) (*queryTokensResult, error) {
+	opts, positional, err := parseQueryOptions("query tokens", args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != 1 {
+		return nil, errors.New("usage: codectx query tokens PATH [OPTIONS]")
+	}
+	path := positional[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if err := language.SetOverrides(opts.Languages); err != nil {
+			return nil, err
+		}
+		tokens, err := stats.EstimateTokens(path)
+		if err != nil {
+			return nil, err
+		}
+		return &queryTokensResult{Path: path, Files: 1, Tokens: tokens}, nil
+	}
+
+	opts.TargetDir = path
+	files, err := selectFiles(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &queryTokensResult{Path: path, Files: len(files.Paths)}
+	for _, relPath := range files.Paths {
+		tokens, _ := stats.EstimateTokens(filepath.Join(files.TargetDir, filepath.FromSlash(relPath)))
+		result.Tokens += tokens
+	}
+	return result, nil
+}
+
+// queryLanguageOf detects the language of a file from its name, extension,
+// and content, honoring the config's overrides: "codectx query language-of PATH"
+func queryLanguageOf(args []string) (*queryLanguageResult, error) {
+	if len(args) != 1 {
+		return nil, errors.New("usage: codectx query language-of PATH")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := language.SetOverrides(cfg.Languages); err != nil {
+		return nil, err
+	}
+	result := &queryLanguageResult{Path: args[0]}
+	if lang, ok := language.DetectFile(args[0]); ok {
+		result.Language, result.Name = lang.ID, lang.Name
+	}
+	return result, nil
+}
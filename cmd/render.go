@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codectx/internal/formatter"
+	"codectx/internal/limits"
+	"codectx/internal/plugin"
+)
+
+// runRender formats a JSON output again without scanning:
+// "codectx render FILE|- [--format FORMAT] [-o OUTPUT] [--max-memory SIZE]"
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := flags.String("format", "text", "Output format (text, html, markdown, or a plugin format)")
+	flags.StringVar(format, "f", "text", "Output format (short)")
+	output := flags.String("output", "", "Output file (default: stdout)")
+	flags.StringVar(output, "o", "", "Output file (short)")
+	noLineNumbers := flags.Bool("no-line-numbers", false, "Don't show line numbers")
+	flags.BoolVar(noLineNumbers, "n", false, "Don't show line numbers (short)")
+	maxMemory := flags.String("max-memory", "", "File contents held in memory before spilling the rest to disk (e.g., 512MB)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: codectx render FILE|- [--format FORMAT] [-o OUTPUT] [--max-memory SIZE]")
+	}
+	inputPath := flags.Arg(0)
+	// The flags may also follow the file
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument: %s", flags.Arg(0))
+	}
+
+	memoryLimit, err := limits.ParseSize(*maxMemory)
+	if err != nil {
+		return fmt.Errorf("invalid --max-memory: %w", err)
+	}
+
+	var input io.Reader = os.Stdin
+	if inputPath != "-" {
+		file, err := os.Open(inputPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	// A plugin format reads the JSON output as is
+	if !formatter.IsBuiltinFormat(*format) {
+		return renderWithPlugin(input, strings.ToLower(*format), *output)
+	}
+
+	f, err := formatter.NewFormatter(*format, !*noLineNumbers, *output, nil, nil)
+	if err != nil {
+		return err
+	}
+	f.MaxMemory = memoryLimit
+	if err := f.Render(input); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render %s: %w", inputPath, err)
+	}
+	return f.Close()
+}
+
+// renderWithPlugin pipes a JSON output into the plugin providing format
+func renderWithPlugin(input io.Reader, format, output string) error {
+	p, ok := plugin.FindFormat(os.Getenv("PATH"), format)
+	if !ok {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := formatter.CreateOutput(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := p.StartFormatter(format, out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, input); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -1,109 +1,69 @@
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
-
-	"codectx/internal/filter"
-	"codectx/internal/formatter"
-	"codectx/internal/git"
-	"codectx/internal/limits"
-	"codectx/internal/scanner"
-	"codectx/internal/stats"
-	"codectx/internal/utils"
+	"time"
 )
 
-// Command line flags
+// Command line flags that are not part of Options
 var (
-	// Output format
-	formatFlag string
-
-	// Filtering options
-	extensionsFlag  string
-	excludeFlag     string
-	includeDotfiles bool
-
-	// Size limits
-	limitFlag       int64
-	maxFileSizeFlag string
-
-	// Statistics
-	statsFlag bool
-
-	// Git integration
-	gitOnlyFlag          bool
-	respectGitignoreFlag bool
-	ignoreGitignoreFlag  bool
-	includeGitInfoFlag   bool
-	gitStatusFlag        bool
+	noHooksFlag     bool
+	helpFlag        bool
+	versionFlag     bool
+	versionJSONFlag bool
+)
 
-	// Advanced analysis
-	healthCheckFlag        bool
-	complexityAnalysisFlag bool
-	languageStatsFlag      bool
+// Modes accepted by --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
 
-	// Other options
-	outputFlag        string
-	noLineNumbersFlag bool
-	verboseFlag       bool
-	helpFlag          bool
-	versionFlag       bool
-	dryRunFlag        bool
+// Modes accepted by --git-only
+const (
+	gitOnlyTracked = "tracked"
+	gitOnlyWorking = "working"
 )
 
 // Execute runs the root command
 func Execute() error {
 	// Define flags
-	flag.StringVar(&formatFlag, "format", "text", "Output format (text, html, markdown, json)")
-	flag.StringVar(&formatFlag, "f", "text", "Output format (short)")
-
-	flag.StringVar(&extensionsFlag, "extensions", "", "Filter by file extensions (comma-separated)")
-	flag.StringVar(&extensionsFlag, "e", "", "Filter by file extensions (short)")
-
-	flag.StringVar(&excludeFlag, "exclude", "", "Exclude patterns (comma-separated)")
-	flag.StringVar(&excludeFlag, "x", "", "Exclude patterns (short)")
-
-	flag.BoolVar(&includeDotfiles, "include-dotfiles", false, "Include dotfiles")
+	opts := DefaultOptions()
+	defineFlags(flag.CommandLine, &opts)
 
-	flag.Int64Var(&limitFlag, "limit", 0, "Maximum total character limit (0 for no limit)")
-	flag.Int64Var(&limitFlag, "l", 0, "Maximum total character limit (short)")
-
-	flag.StringVar(&maxFileSizeFlag, "max-file-size", "1MB", "Maximum file size (e.g., 1MB, 500KB)")
-
-	flag.BoolVar(&statsFlag, "stats", false, "Show statistics")
-
-	flag.StringVar(&outputFlag, "output", "", "Output file")
-	flag.StringVar(&outputFlag, "o", "", "Output file (short)")
-
-	flag.BoolVar(&noLineNumbersFlag, "no-line-numbers", false, "Don't show line numbers")
-	flag.BoolVar(&noLineNumbersFlag, "n", false, "Don't show line numbers (short)")
-
-	flag.BoolVar(&verboseFlag, "verbose", false, "Verbose output")
-	flag.BoolVar(&verboseFlag, "v", false, "Verbose output (short)")
+	flag.BoolVar(&noHooksFlag, "no-hooks", false, "Don't run the hooks from the config file")
 
 	flag.BoolVar(&helpFlag, "help", false, "Show help")
 	flag.BoolVar(&helpFlag, "h", false, "Show help (short)")
 
 	flag.BoolVar(&versionFlag, "version", false, "Show version")
+	flag.BoolVar(&versionJSONFlag, "json", false, "With --version, print build information as JSON")
 
-	flag.BoolVar(&dryRunFlag, "dry-run", false, "Show files that would be processed without processing them")
-
-	// Git integration flags
-	flag.BoolVar(&gitOnlyFlag, "git-only", false, "Only include Git tracked files")
-	flag.BoolVar(&respectGitignoreFlag, "respect-gitignore", false, "Respect .gitignore patterns")
-	flag.BoolVar(&ignoreGitignoreFlag, "ignore-gitignore", true, "Ignore .gitignore patterns (default)")
-	flag.BoolVar(&includeGitInfoFlag, "include-git-info", false, "Include Git information in output")
-	flag.BoolVar(&gitStatusFlag, "git-status", false, "Show Git status information")
-
-	// Advanced analysis flags
-	flag.BoolVar(&healthCheckFlag, "health-check", false, "Perform project health check")
-	flag.BoolVar(&complexityAnalysisFlag, "complexity-analysis", false, "Perform complexity analysis")
-	flag.BoolVar(&languageStatsFlag, "language-stats", false, "Show language statistics")
+	// Expand a saved alias, then run a subcommand instead of scanning
+	arguments, err := expandAlias(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	if handled, err := runSubcommand(arguments); handled {
+		return err
+	}
+	opts.Focus, arguments = focusTarget(arguments)
+	opts.PR, arguments = prTarget(arguments)
+	opts.Image, arguments = imageTarget(arguments)
 
-	// Parse flags
-	flag.Parse()
+	// Parse flags, then fill in the rest from the config file and environment
+	flag.CommandLine.Parse(arguments)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyDefaults(flag.CommandLine, cfg.Defaults); err != nil {
+		return err
+	}
 
 	// Show help
 	if helpFlag {
@@ -113,235 +73,35 @@ func Execute() error {
 
 	// Show version
 	if versionFlag {
-		fmt.Println("codectx v0.1.0")
-		return nil
+		return printVersion(versionJSONFlag)
 	}
 
 	// Get target directory
-	targetDir := "."
-	args := flag.Args()
-	if len(args) > 0 {
-		targetDir = args[0]
-	}
-
-	// Resolve absolute path
-	absTargetDir, err := filepath.Abs(targetDir)
-	if err != nil {
-		return fmt.Errorf("failed to resolve absolute path: %w", err)
-	}
-
-	// Check if directory exists
-	info, err := os.Stat(absTargetDir)
-	if err != nil {
-		return fmt.Errorf("failed to access target directory: %w", err)
+	if args := flag.Args(); len(args) > 0 {
+		opts.TargetDir = args[0]
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("%s is not a directory", absTargetDir)
-	}
-
-	// Run the command
-	return run(absTargetDir)
-}
-
-// run executes the main functionality
-func run(targetDir string) error {
-	if verboseFlag {
-		fmt.Printf("Scanning directory: %s\n", targetDir)
-	}
-
-	// Initialize stats collector if stats flag is set
-	var statsCollector *stats.StatsCollector
-	var advancedStatsCollector *stats.AdvancedStatsCollector
-
-	// Check if any advanced stats options are enabled
-	advancedStatsEnabled := statsFlag && (healthCheckFlag || complexityAnalysisFlag || languageStatsFlag)
 
-	if advancedStatsEnabled {
-		// Use advanced stats collector
-		options := stats.AdvancedStatsOptions{
-			HealthCheck:        healthCheckFlag,
-			ComplexityAnalysis: complexityAnalysisFlag,
-			LanguageStats:      languageStatsFlag,
-			GitInfo:            includeGitInfoFlag,
-			GitStatus:          gitStatusFlag,
-		}
+	// Record the command line, with any alias expanded, in the output metadata
+	opts.Args = arguments
 
-		var err error
-		advancedStatsCollector, err = stats.CollectAdvancedStats(targetDir, options)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to collect advanced stats: %v\n", err)
-		}
-
-		// Use the basic stats collector from the advanced one
-		statsCollector = advancedStatsCollector.StatsCollector
-	} else if statsFlag {
-		// Use basic stats collector
-		statsCollector = stats.NewStatsCollector()
-	}
+	// Use the language overrides from the config file
+	opts.Languages = cfg.Languages
 
-	// Handle Git status flag
-	if gitStatusFlag {
-		if err := git.PrintGitStatus(targetDir); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get Git status: %v\n", err)
-		}
-		// If only Git status is requested, return after printing it
-		if !statsFlag && !gitOnlyFlag && !includeGitInfoFlag {
-			return nil
-		}
+	// Use the hooks from the config file unless disabled
+	if !noHooksFlag {
+		opts.Hooks = cfg.Hooks
 	}
 
-	// Get Git tracked files if --git-only is specified
-	var gitTrackedFiles []string
-	if gitOnlyFlag {
-		var err error
-		gitTrackedFiles, err = git.GetGitTrackedFiles(targetDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get Git tracked files: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Continuing without Git tracking filter\n")
-		}
-	}
-
-	// Get Git info if --include-git-info is specified
-	var gitInfo *git.GitInfo
-	if includeGitInfoFlag {
-		var err error
-		gitInfo, err = git.GetGitInfo(targetDir)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get Git info: %v\n", err)
-		}
-	}
-
-	// Create a scanner
-	scanner := scanner.NewScanner(targetDir, includeDotfiles)
-
-	// Scan the directory
-	root, err := scanner.Scan()
-	if err != nil {
-		return fmt.Errorf("failed to scan directory: %w", err)
-	}
-
-	// Generate the tree
-	tree := scanner.GenerateTree(root)
-
-	// Create a filter
-	filter := filter.NewFilter(extensionsFlag, excludeFlag, includeDotfiles)
-
-	// Handle .gitignore if needed
-	if respectGitignoreFlag && !ignoreGitignoreFlag {
-		gitIgnoreParser := git.NewGitIgnoreParser(targetDir)
-		if err := gitIgnoreParser.ParseAllGitIgnores(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to parse .gitignore files: %v\n", err)
-		} else {
-			filter.SetGitIgnoreParser(gitIgnoreParser)
-		}
-	}
-
-	// Set Git tracked files if --git-only is specified
-	if gitOnlyFlag && len(gitTrackedFiles) > 0 {
-		filter.SetGitTrackedFiles(gitTrackedFiles)
-	}
-
-	// Create a size limiter
-	sizeLimiter, err := limits.NewSizeLimiter(maxFileSizeFlag, limitFlag)
-	if err != nil {
-		return fmt.Errorf("failed to create size limiter: %w", err)
-	}
-
-	// Create a formatter
-	formatter, err := formatter.NewFormatter(formatFlag, !noLineNumbersFlag, outputFlag, sizeLimiter, gitInfo)
+	// Run the command
+	start := time.Now()
+	summary, err := runWithOptions(context.Background(), opts, os.Stdout, os.Stderr)
 	if err != nil {
-		return fmt.Errorf("failed to create formatter: %w", err)
-	}
-	defer formatter.Close()
-
-	// Format the tree
-	if err := formatter.FormatTree(tree); err != nil {
-		return fmt.Errorf("failed to format tree: %w", err)
+		return err
 	}
-
-	// Get all file paths
-	paths := scanner.GetRelativePaths(root)
-
-	// Count directories for stats
-	if statsCollector != nil {
-		// Count the root directory
-		statsCollector.AddDirectory(targetDir)
-
-		// Count all subdirectories
-		for _, child := range root.Children {
-			if child.IsDir {
-				countDirectories(child, statsCollector)
-			}
-		}
-	}
-
-	// Process each file
-	for _, relPath := range paths {
-		fullPath := filepath.Join(targetDir, relPath[1:]) // Remove leading slash
-		cleanRelPath := relPath[1:] // Clean relative path without leading slash
-
-		// Check if the file should be included
-		if !filter.ShouldInclude(fullPath) {
-			if verboseFlag {
-				fmt.Fprintf(os.Stderr, "Skipping file: %s\n", cleanRelPath)
-			}
-			continue
-		}
-
-		// Check if it's a text file
-		isText, err := utils.IsTextFile(fullPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to check if file is text: %v\n", err)
-			continue
-		}
-
-		if !isText {
-			fmt.Fprintf(os.Stderr, "Warning: skipping binary file: %s\n", cleanRelPath)
-			continue
-		}
-
-		// Update stats if stats flag is set
-		if statsCollector != nil {
-			if err := statsCollector.AddFile(fullPath, isText); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to add file to stats: %v\n", err)
-			}
-		}
-
-		// If dry run flag is set, just print the file path and skip formatting
-		if dryRunFlag {
-			fmt.Fprintf(os.Stderr, "Would process file: %s\n", cleanRelPath)
-			continue
-		}
-
-		// Format the file content
-		if err := formatter.FormatFileContent(fullPath, cleanRelPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to format file content: %v\n", err)
-			continue
-		}
-	}
-
-	// Print stats if stats flag is set
-	if advancedStatsCollector != nil {
-		advancedStatsCollector.PrintAdvancedStats()
-	} else if statsCollector != nil {
-		statsCollector.PrintStats()
-	}
-
+	recordHistory(start, summary)
 	return nil
 }
 
-// countDirectories recursively counts directories
-func countDirectories(entry *scanner.FileEntry, statsCollector *stats.StatsCollector) {
-	if entry.IsDir {
-		statsCollector.AddDirectory(entry.Path)
-		for _, child := range entry.Children {
-			if child.IsDir {
-				countDirectories(child, statsCollector)
-			}
-		}
-	}
-}
-
 // printHelp shows the help message
 func printHelp() {
 	fmt.Println("codectx - Unified directory and file content viewer")
@@ -352,30 +112,134 @@ func printHelp() {
 	fmt.Println("Arguments:")
 	fmt.Println("  TARGET_DIR    Directory to scan (default: current directory)")
 	fmt.Println("")
+	fmt.Println("Commands:")
+	fmt.Println("  codectx docs man|markdown      Generate the man page or Markdown CLI reference")
+	fmt.Println("  codectx history [INDEX]        List recorded runs, or re-run one (record with CODECTX_HISTORY=1)")
+	fmt.Println("  codectx again                  Re-run the last recorded command")
+	fmt.Println("  codectx focus FILE|SYMBOL [OPTIONS] [TARGET_DIR]")
+	fmt.Println("                                 Include FILE (or the file defining SYMBOL), what it uses, and what uses it")
+	fmt.Println("  codectx pr URL|NUMBER [OPTIONS] [TARGET_DIR]")
+	fmt.Println("                                 Include a pull request's details, comments, and changed files")
+	fmt.Println("  codectx image REF [OPTIONS] [DIR_IN_IMAGE]")
+	fmt.Println("                                 Pull a container image and scan its filesystem")
+	fmt.Println("  codectx alias save NAME -- ARGS...")
+	fmt.Println("                                 Save ARGS as \"codectx NAME\" (also: alias list, alias delete NAME)")
+	fmt.Println("  codectx plugins                List the codectx-* plugins found on PATH")
+	fmt.Println("  codectx render FILE|- [--format FORMAT] [-o OUTPUT] [--max-memory SIZE]")
+	fmt.Println("                                 Format a JSON output again in another format, without scanning")
+	fmt.Println("  codectx query files [OPTIONS] [TARGET_DIR] | tokens PATH | language-of PATH")
+	fmt.Println("                                 Print the files a scan would include, token estimates, or a file's language as JSON")
+	fmt.Println("  codectx merge [LABEL=]FILE... [--format FORMAT] [-o OUTPUT]")
+	fmt.Println("                                 Combine JSON outputs of several repositories under labeled roots")
+	fmt.Println("  codectx serve --grpc [--listen ADDRESS] [--root DIR]")
+	fmt.Println("                                 Serve scans, files, stats, and analyses to other programs over gRPC")
+	fmt.Println("  codectx verify FILE [--key PUBKEY]")
+	fmt.Println("                                 Check a file signed with --sign and show what produced it")
+	fmt.Println("  codectx self-update [--check]  Install the latest release")
+	fmt.Println("")
 	fmt.Println("Options:")
-	fmt.Println("  -f, --format <FORMAT>                Output format (text, html, markdown, json)")
+	fmt.Println("  -f, --format <FORMAT>                Output format (text, html, markdown, json, or a plugin format)")
 	fmt.Println("  -e, --extensions <EXT1,EXT2,...>     Filter by file extensions")
 	fmt.Println("  -x, --exclude <PATTERN1,PATTERN2,..> Exclude patterns")
 	fmt.Println("      --include-dotfiles               Include dotfiles")
-	fmt.Println("  -l, --limit <NUMBER>                 Maximum total character limit (0 for no limit)")
+	fmt.Println("      --include-regex <REGEX>          Only include paths matching a regex (repeatable)")
+	fmt.Println("      --exclude-regex <REGEX>          Exclude paths matching a regex; !REGEX re-includes (repeatable)")
+	fmt.Println("      --min-size <SIZE>                Only include files at least this large (e.g., 1KB)")
+	fmt.Println("      --max-size <SIZE>                Only include files at most this large (e.g., 100KB)")
+	fmt.Println("      --modified-since <DATE|AGE>      Only include files modified since (e.g., 2024-01-01, 7d)")
+	fmt.Println("      --project <NAME>                 Only scan the detected project with this name or path")
+	fmt.Println("      --workspace <NAME>               Only scan a JS/TS workspace and the workspaces it depends on")
+	fmt.Println("      --bazel-target <PATTERN>         Only scan the source files of a Bazel target and its dependencies")
+	fmt.Println("      --max-files <NUMBER>             Stop scanning after this many files; the output is partial")
+	fmt.Println("      --max-depth <NUMBER>             Don't scan more than this many directory levels deep")
+	fmt.Println("      --scan-timeout <DURATION>        Stop scanning after this long (e.g., 30s); the output is partial")
+	fmt.Println("      --one-file-system                Don't descend into mount points (network or bind mounts)")
+	fmt.Println("  -l, --limit <SIZE>                   Maximum total character limit, e.g. 100000 or 2MB (0 for no limit)")
 	fmt.Println("      --max-file-size <SIZE>           Maximum file size (e.g., 1MB, 500KB)")
+	fmt.Println("      --max-file-tokens <NUMBER>       Truncate each file after about this many tokens")
+	fmt.Println("      --max-file-bytes-included <SIZE> Truncate each file after this many bytes (e.g., 20KB)")
+	fmt.Println("      --max-line-length <SIZE>         Maximum length of a single line (default: 1MB)")
+	fmt.Println("      --budget <PATTERN=SHARE,...>     Split the limit across path groups (e.g., tests/**=10%)")
+	fmt.Println("      --repo-map                       Output a ranked map of functions and types instead of file contents")
+	fmt.Println("      --map-tokens <NUMBER>            Token budget of the repository map (default: 1024)")
+	fmt.Println("      --api-surface[=MODE]             Output the exported API of Go packages (MODE: replace, append)")
+	fmt.Println("      --xref                           Add an index of where symbols are defined and used")
+	fmt.Println("      --summary                        Output the tree, statistics, and a file inventory instead of file contents")
+	fmt.Println("      --focus-tokens <NUMBER>          Token budget of \"codectx focus\" (default: 16000)")
+	fmt.Println("      --image-platform <OS/ARCH>       Platform of \"codectx image\" in multi-platform images (default: linux/ARCH)")
+	fmt.Println("      --pair-tests                     Place each test file right after the source file it covers")
+	fmt.Println("      --tests <MODE>                   Include test files as a group: include, skip, only (default: include)")
+	fmt.Println("      --schema-only                    Include only schema files, migrations, and ORM models")
+	fmt.Println("      --iac                            Include only Terraform, Helm charts, and Kubernetes manifests")
+	fmt.Println("      --no-key-files                   Don't tag or prioritize key files (main.go, go.mod, README, ...)")
+	fmt.Println("      --contracts-first                Output API contracts (OpenAPI, .proto, GraphQL, ...) first")
+	fmt.Println("      --no-extract                     Don't convert notebooks and documents (.ipynb, .rmd, .docx, .odt) to text")
+	fmt.Println("      --extract-pdf                    Include the text of PDF files instead of skipping them as binary")
+	fmt.Println("      --keep-data-uris                 Keep base64 data URIs and blobs instead of replacing them with placeholders")
+	fmt.Println("      --redact-pii                     Replace emails, phone numbers, and national IDs with placeholders")
+	fmt.Println("      --anonymize <FILE>               Rename identifiers throughout the output (\"original = replacement\" lines)")
+	fmt.Println("      --images <MODE>                  How to include images: placeholder, embed (HTML thumbnails), skip (default: placeholder)")
+	fmt.Println("      --minified <MODE>                How to include minified JS/CSS and bundles: placeholder, skip, include (default: placeholder)")
+	fmt.Println("      --normalize-eol <lf|crlf>        Write file content with these line endings, whatever the platform")
+	fmt.Println("      --expand-tabs <N>                Expand tabs in file content to tab stops every N columns")
+	fmt.Println("      --detect-indent                  Normalize each file's indentation to its dominant style")
+	fmt.Println("      --dedupe                         Include identical files once; later copies become \"identical to PATH\" stubs")
 	fmt.Println("      --stats                          Show statistics")
-	fmt.Println("  -o, --output <FILE>                  Output file (default: stdout)")
+	fmt.Println("      --si                             Show sizes in powers of 1000 (kB, MB, GB) instead of 1024")
+	fmt.Println("  -o, --output <FILE>                  Output file, s3://BUCKET/KEY, or http(s) URL to PUT to (default: stdout)")
+	fmt.Println("      --output-spec <FORMAT=FILE,...>  Write several formats from one scan (e.g., markdown=ctx.md,json=ctx.json)")
+	fmt.Println("      --sign <KEY>                     Sign the output file with an Ed25519 key, writing FILE.sig")
+	fmt.Println("      --no-pager                       Don't pipe terminal output into $PAGER (less -R)")
 	fmt.Println("  -n, --no-line-numbers                Don't show line numbers")
+	fmt.Println("      --ascii-tree                     Draw the directory tree with ASCII characters")
+	fmt.Println("      --tree-style <STYLE>             Tree drawing style (unicode, ascii, bold, none)")
+	fmt.Println("      --tree-details[=FIELDS]          Annotate tree entries with size, lines, and/or tokens")
+	fmt.Println("      --natural-sort                   Order names containing numbers by value (file2 before file10)")
+	fmt.Println("      --directory-records              List each directory with its file counts and size in JSON output")
+	fmt.Println("      --color[=WHEN]                   Syntax-highlight text output (WHEN: auto, always, never; default: auto)")
+	fmt.Println("      --header-file <FILE>             Template placed before the output (e.g., {{.TotalTokens}})")
+	fmt.Println("      --footer-file <FILE>             Template placed after the output")
+	fmt.Println("      --var <KEY=VALUE>                Template variable for header/footer (repeatable)")
+	fmt.Println("      --plugin <NAME>                  Run the analyzer plugin codectx-NAME from PATH (repeatable)")
+	fmt.Println("      --no-hooks                       Don't run the hooks from ~/.codectx/config.json")
 	fmt.Println("  -v, --verbose                        Verbose output")
 	fmt.Println("  -h, --help                           Show help")
 	fmt.Println("      --version                        Show version")
+	fmt.Println("      --json                           With --version, print version, commit, and build date as JSON")
 	fmt.Println("      --dry-run                        Show files without processing")
+	fmt.Println("      --policy-override                Output files denied by .codectx-policy.yaml, with warnings")
+	fmt.Println("      --confirm                        Ask which directories to include before output (y/n/all)")
+	fmt.Println("      --porcelain                      Write tab-separated records of the files on stdout for scripts and editors")
 	fmt.Println("")
 	fmt.Println("Git Integration Options:")
-	fmt.Println("      --git-only                       Only include Git tracked files")
+	fmt.Println("      --git-only[=MODE]                Only include Git tracked files (MODE: tracked, working)")
+	fmt.Println("      --include-untracked              With --git-only, also include untracked, non-ignored files")
 	fmt.Println("      --respect-gitignore              Respect .gitignore patterns")
 	fmt.Println("      --ignore-gitignore               Ignore .gitignore patterns (default)")
 	fmt.Println("      --include-git-info               Include Git information in output")
 	fmt.Println("      --git-status                     Show Git status information")
+	fmt.Println("      --history N                      Include the last N commits with the files they changed")
+	fmt.Println("      --changelog-context              Include the commits and diff since the last tag and the files changed")
+	fmt.Println("      --materialize                    In a sparse checkout, check out the files the include filters match")
+	fmt.Println("      --lfs-fetch                      Download the Git LFS objects of included pointer files")
 	fmt.Println("")
 	fmt.Println("Advanced Analysis Options:")
 	fmt.Println("      --health-check                   Perform project health check")
+	fmt.Println("      --debug-pattern <REGEX>          Also flag lines matching REGEX as debug statements (repeatable)")
 	fmt.Println("      --complexity-analysis            Perform complexity analysis")
+	fmt.Println("      --complexity-threshold N         Report functions with a cyclomatic complexity above N (default: 10)")
+	fmt.Println("      --nesting-threshold N            Report functions nested deeper than N levels (default: 4)")
+	fmt.Println("      --function-length-threshold N    Report functions longer than N lines (default: 60)")
 	fmt.Println("      --language-stats                 Show language statistics")
+	fmt.Println("      --hotspots                       Rank risky files by Git churn times complexity")
+	fmt.Println("      --ownership                      Show primary contributors and bus factors per directory")
+	fmt.Println("      --dead-files[=exclude]           List unused Go packages and assets (=exclude leaves them out)")
+	fmt.Println("      --doc-coverage                   Show the share of public symbols with doc comments")
+	fmt.Println("      --strings-report                 List user-facing string literals with file and line")
+	fmt.Println("      --min-string-length N            Minimum length of listed strings (default: 20)")
+	fmt.Println("      --config-inventory               List environment variables and config file keys")
+	fmt.Println("      --audit-deps                     Check dependencies for known vulnerabilities (queries the OSV API)")
+	fmt.Println("      --osv-db <DIR>                   Audit against a directory of OSV records instead of the API")
+	fmt.Println("      --sarif <FILE>                   Write the dependency audit findings as SARIF")
+	fmt.Println("      --coverage <FILE>                Annotate files with test coverage (coverage.out, lcov.info)")
 }
@@ -0,0 +1,1374 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"codectx/internal/analysis"
+	"codectx/internal/audit"
+	"codectx/internal/bazel"
+	"codectx/internal/coverage"
+	"codectx/internal/dedupe"
+	"codectx/internal/extract"
+	"codectx/internal/filter"
+	"codectx/internal/forge"
+	"codectx/internal/formatter"
+	"codectx/internal/git"
+	"codectx/internal/hooks"
+	"codectx/internal/images"
+	"codectx/internal/language"
+	"codectx/internal/limits"
+	"codectx/internal/minified"
+	"codectx/internal/oci"
+	"codectx/internal/platform"
+	"codectx/internal/scanner"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
+)
+
+// runSummary describes what a run included
+type runSummary struct {
+	Files     int      // Files included in the output
+	Tokens    int64    // Estimated tokens of the output
+	TargetDir string   // Absolute path of the directory scanned
+	Paths     []string // Files included, relative to TargetDir
+}
+
+// runner carries the options and output streams of one run
+type runner struct {
+	opts      Options
+	stdin     io.Reader // Answers to the --confirm prompt
+	stdout    io.Writer
+	stderr    io.Writer
+	porcelain *porcelainWriter // --porcelain records, or nil
+	image     *oci.Image       // Image of "codectx image", or nil
+	outputs   []outputSpec     // --output-spec outputs after the first
+}
+
+// RunWithOptions scans opts.TargetDir and writes the context to stdout, or to
+// opts.Output if set, with warnings and progress on stderr. Canceling ctx stops
+// the run between files.
+func RunWithOptions(ctx context.Context, opts Options, stdout, stderr io.Writer) error {
+	_, err := runWithOptions(ctx, opts, stdout, stderr)
+	return err
+}
+
+// runWithOptions runs codectx and reports what the output included
+func runWithOptions(ctx context.Context, opts Options, stdout, stderr io.Writer) (runSummary, error) {
+	// The first --output-spec output takes the place of --format and --output
+	outputs, err := applyOutputSpecs(&opts)
+	if err != nil {
+		return runSummary{}, err
+	}
+
+	targetDir := opts.TargetDir
+	if targetDir == "" {
+		targetDir = "."
+	}
+
+	// Scan the filesystem of a container image instead of a directory
+	var image *oci.Image
+	if opts.Image != "" {
+		imageDir, pulled, cleanup, err := pullImage(ctx, opts, stderr)
+		if err != nil {
+			return runSummary{}, err
+		}
+		defer cleanup()
+		targetDir, image = imageDir, pulled
+	}
+
+	// Resolve absolute path
+	absTargetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return runSummary{}, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	absTargetDir = platform.NormalizeVolume(absTargetDir)
+
+	// Check if directory exists
+	info, err := os.Stat(absTargetDir)
+	if err != nil {
+		return runSummary{}, fmt.Errorf("failed to access target directory: %w", err)
+	}
+	if !info.IsDir() {
+		return runSummary{}, fmt.Errorf("%s is not a directory", absTargetDir)
+	}
+
+	// Scope the run to one project of a monorepo
+	if opts.Project != "" {
+		projects, err := analysis.DetectProjects(absTargetDir)
+		if err != nil {
+			return runSummary{}, err
+		}
+		project, err := analysis.FindProject(projects, opts.Project)
+		if err != nil {
+			return runSummary{}, err
+		}
+		absTargetDir = filepath.Join(absTargetDir, filepath.FromSlash(project.Path))
+	}
+
+	// Load the signing key before scanning, so that a bad key fails early
+	signingKey, err := signingKey(opts)
+	if err != nil {
+		return runSummary{}, err
+	}
+
+	r := &runner{opts: opts, stdin: os.Stdin, stdout: stdout, stderr: stderr, image: image, outputs: outputs}
+	// --porcelain reserves stdout for its records, and messages go to stderr
+	if opts.Porcelain {
+		r.porcelain = newPorcelainWriter(stdout)
+		r.stdout = stderr
+	}
+	summary, err := r.run(ctx, absTargetDir)
+	if err != nil {
+		return summary, err
+	}
+
+	// Sign the output before the hooks, so that they can pick up the signature
+	if signingKey != nil {
+		if err := signOutput(opts, signingKey, stderr); err != nil {
+			return summary, err
+		}
+	}
+
+	// The output is complete once run returns, so it can be picked up by the hooks
+	if err := runPostOutputHooks(opts, absTargetDir); err != nil {
+		return summary, err
+	}
+	r.porcelain.done(summary)
+	return summary, nil
+}
+
+// run scans targetDir and writes the context, returning what was included
+func (r *runner) run(ctx context.Context, targetDir string) (summary runSummary, err error) {
+	if err := language.SetOverrides(r.opts.Languages); err != nil {
+		return summary, err
+	}
+
+	// Run the pre-scan hooks
+	if r.opts.Hooks != nil {
+		if err := hooks.RunStage(r.opts.Hooks.PreScan, targetDir, hookEnv(r.opts, targetDir)); err != nil {
+			return summary, err
+		}
+	}
+
+	if r.opts.Verbose {
+		fmt.Fprintf(r.stdout, "Scanning directory: %s\n", targetDir)
+	}
+
+	// Initialize stats collector if stats flag is set
+	var statsCollector *stats.StatsCollector
+	var advancedStatsCollector *stats.AdvancedStatsCollector
+
+	// Read the test coverage report
+	var coverageReport *coverage.Report
+	if r.opts.Coverage != "" {
+		if coverageReport, err = coverage.Load(r.opts.Coverage, targetDir); err != nil {
+			return summary, fmt.Errorf("failed to read coverage report: %w", err)
+		}
+	}
+
+	debugPatterns, err := analysis.CompileDebugPatterns(r.opts.DebugPatterns)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --debug-pattern: %w", err)
+	}
+
+	// Check if any advanced stats options are enabled
+	advancedStatsEnabled := r.opts.Stats && (r.opts.HealthCheck || r.opts.ComplexityAnalysis || r.opts.LanguageStats || r.opts.Hotspots || r.opts.Ownership || r.opts.DeadFiles != "" || r.opts.DocCoverage || r.opts.StringsReport || r.opts.ConfigInventory || r.opts.IaC)
+
+	if advancedStatsEnabled {
+		// Use advanced stats collector
+		options := stats.AdvancedStatsOptions{
+			HealthCheck:        r.opts.HealthCheck,
+			ComplexityAnalysis: r.opts.ComplexityAnalysis,
+			LanguageStats:      r.opts.LanguageStats,
+			GitInfo:            r.opts.IncludeGitInfo,
+			GitStatus:          r.opts.GitStatus,
+		}
+		if r.opts.AuditDeps {
+			options.AuditDeps = &audit.Options{DBDir: r.opts.OSVDB}
+		}
+		options.Coverage = coverageReport
+		options.DebugPatterns = debugPatterns
+
+		var err error
+		advancedStatsCollector, err = stats.CollectAdvancedStats(targetDir, options)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to collect advanced stats: %v\n", err)
+		}
+
+		// Use the basic stats collector from the advanced one
+		statsCollector = advancedStatsCollector.StatsCollector
+
+		if r.opts.SARIF != "" {
+			if err := writeSARIF(r.opts.SARIF, advancedStatsCollector.HealthCheck); err != nil {
+				fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+			}
+		}
+	} else if r.opts.Stats || r.opts.Summary || r.writesJSON() {
+		// Use basic stats collector, whose totals the JSON metadata and the summary report
+		statsCollector = stats.NewStatsCollector()
+	}
+	if statsCollector != nil {
+		statsCollector.SizeUnits = r.sizeUnits()
+	}
+	if r.opts.Stats {
+		projects, err := analysis.DetectProjects(targetDir)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+		statsCollector.SetProjects(targetDir, projects)
+	}
+
+	// Detect the frameworks and tools in use for the stats, JSON metadata, and summary
+	var stack []analysis.StackComponent
+	if r.opts.Stats || r.opts.Summary || r.writesJSON() {
+		var err error
+		stack, err = analysis.DetectStack(targetDir)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+		}
+		if statsCollector != nil {
+			statsCollector.Stack = stack
+		}
+	}
+
+	// Handle Git status flag
+	if r.opts.GitStatus {
+		if err := git.PrintGitStatus(targetDir, r.stdout); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to get Git status: %v\n", err)
+		}
+		// If only Git status is requested, return after printing it
+		if !r.opts.Stats && r.opts.GitOnly == "" && !r.opts.IncludeGitInfo {
+			return summary, nil
+		}
+	}
+
+	// Get Git tracked files if --git-only is specified
+	if r.opts.IncludeUntracked && (r.opts.GitOnly == "" || r.opts.GitOnly == gitOnlyTracked) {
+		r.opts.GitOnly = gitOnlyWorking
+	}
+	// gitTrackedFiles is nil when --git-only is off or the list could not be read;
+	// an empty list from a repository without files includes nothing
+	var gitTrackedFiles []string
+	if r.opts.GitOnly != "" {
+		var err error
+		switch r.opts.GitOnly {
+		case gitOnlyTracked:
+			gitTrackedFiles, err = git.GetGitTrackedFiles(targetDir)
+		case gitOnlyWorking:
+			gitTrackedFiles, err = git.GetGitWorkingFiles(targetDir)
+		default:
+			return summary, fmt.Errorf("unsupported --git-only mode: %s (expected tracked or working)", r.opts.GitOnly)
+		}
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to get Git tracked files: %v\n", err)
+			fmt.Fprintf(r.stderr, "Continuing without Git tracking filter\n")
+			gitTrackedFiles = nil
+		} else if gitTrackedFiles == nil {
+			gitTrackedFiles = []string{}
+		}
+	}
+
+	// Get Git info if --include-git-info is specified
+	var gitInfo *git.GitInfo
+	if r.opts.IncludeGitInfo {
+		var err error
+		gitInfo, err = git.GetGitInfo(targetDir)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to get Git info: %v\n", err)
+		}
+	}
+
+	// Choose the tree connectors
+	if r.opts.ASCIITree {
+		r.opts.TreeStyle = "ascii"
+	}
+	treeChars, err := scanner.ParseTreeStyle(r.opts.TreeStyle)
+	if err != nil {
+		return summary, err
+	}
+	treeDetails, err := scanner.ParseTreeDetails(r.opts.TreeDetails)
+	if err != nil {
+		return summary, err
+	}
+
+	// Note the files a sparse checkout leaves out, and check out those the
+	// include filters match if asked to
+	sparse, err := r.sparseCheckout(targetDir)
+	if err != nil {
+		return summary, err
+	}
+
+	// Create a scanner
+	scanner := scanner.NewScanner(targetDir, r.opts.IncludeDotfiles)
+	scanner.TreeChars = treeChars
+	scanner.TreeDetails = treeDetails
+	scanner.SizeUnits = r.sizeUnits()
+	scanner.MaxFiles = r.opts.MaxFiles
+	scanner.MaxDepth = r.opts.MaxDepth
+	scanner.Timeout = r.opts.ScanTimeout
+	scanner.OneFileSystem = r.opts.OneFileSystem
+	scanner.NaturalSort = r.opts.NaturalSort
+
+	// Scan the directory
+	root, err := scanner.ScanContext(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("failed to scan directory: %w", err)
+	}
+	if scanner.Stopped != "" {
+		fmt.Fprintf(r.stderr, "Warning: stopped scanning after %s; the output is partial\n", scanner.Stopped)
+	}
+	if scanner.DepthSkipped > 0 {
+		fmt.Fprintf(r.stderr, "Warning: %d directories below --max-depth %d were not scanned\n", scanner.DepthSkipped, r.opts.MaxDepth)
+	}
+	if r.opts.Verbose && scanner.MountsSkipped > 0 {
+		fmt.Fprintf(r.stderr, "Skipped the contents of %d mount points on other file systems\n", scanner.MountsSkipped)
+	}
+	var denied []string
+	for _, path := range scanner.Denied {
+		if relPath, err := platform.RelSlash(targetDir, path); err == nil {
+			denied = append(denied, relPath+"/")
+		}
+	}
+	if r.opts.Verbose && scanner.SpecialSkipped > 0 {
+		fmt.Fprintf(r.stderr, "Skipped %d special files (sockets, FIFOs, device nodes)\n", scanner.SpecialSkipped)
+	}
+
+	// Collect sizes, line counts, and token estimates for the tree or the summary's inventory
+	if len(treeDetails) > 0 || r.opts.Summary {
+		scanner.CollectDetails(root, stats.EstimateTokens)
+	}
+
+	// Parse size and modification time filters
+	minSize, err := limits.ParseSize(r.opts.MinSize)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --min-size: %w", err)
+	}
+	maxSize, err := limits.ParseSize(r.opts.MaxSize)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --max-size: %w", err)
+	}
+	modifiedSince, err := filter.ParseModifiedSince(r.opts.ModifiedSince, time.Now())
+	if err != nil {
+		return summary, err
+	}
+
+	// Create a filter
+	filter := filter.NewFilter(r.opts.Extensions, r.opts.Exclude, r.opts.IncludeDotfiles)
+
+	// Apply regex patterns against paths relative to the target directory
+	filter.SetRootDir(targetDir)
+	filter.Stat = scanner.Stat
+	if err := filter.SetRegexPatterns(r.opts.IncludeRegex, r.opts.ExcludeRegex); err != nil {
+		return summary, err
+	}
+
+	// Apply size and modification time filters
+	filter.SetSizeRange(minSize, maxSize)
+	filter.SetModifiedSince(modifiedSince)
+
+	// Handle .gitignore if needed
+	if r.opts.RespectGitignore && !r.opts.IgnoreGitignore {
+		gitIgnoreParser := git.NewGitIgnoreParser(targetDir)
+		if err := gitIgnoreParser.ParseAllGitIgnores(); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to parse .gitignore files: %v\n", err)
+		} else {
+			filter.SetGitIgnoreParser(gitIgnoreParser)
+		}
+	}
+
+	// Set Git tracked files if --git-only is specified
+	if gitTrackedFiles != nil {
+		repoRoot, err := git.GetRepoRoot(targetDir)
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to get Git repository root: %v\n", err)
+		} else {
+			filter.SetGitRepoRoot(repoRoot)
+		}
+		filter.SetGitTrackedFiles(gitTrackedFiles)
+	}
+
+	// Create a size limiter
+	totalLimit, err := limits.ParseSize(r.opts.Limit)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --limit: %w", err)
+	}
+	sizeLimiter, err := limits.NewSizeLimiter(r.opts.MaxFileSize, totalLimit)
+	if err != nil {
+		return summary, fmt.Errorf("failed to create size limiter: %w", err)
+	}
+	sizeLimiter.Stat = scanner.Stat
+	sizeLimiter.SizeUnits = r.sizeUnits()
+	maxFileBytesIncluded, err := limits.ParseSize(r.opts.MaxFileBytesIncluded)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --max-file-bytes-included: %w", err)
+	}
+	if r.opts.MaxFileTokens < 0 {
+		return summary, fmt.Errorf("invalid --max-file-tokens: %d", r.opts.MaxFileTokens)
+	}
+	sizeLimiter.SetFileInclusionLimits(maxFileBytesIncluded, r.opts.MaxFileTokens)
+	if r.opts.Budget != "" {
+		budgets, err := limits.ParseBudget(r.opts.Budget)
+		if err != nil {
+			return summary, err
+		}
+		if err := sizeLimiter.SetBudgets(budgets); err != nil {
+			return summary, err
+		}
+	}
+
+	maxLineLength, err := limits.ParseSize(r.opts.MaxLineLength)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --max-line-length: %w", err)
+	}
+
+	colorize, err := r.useColor()
+	if err != nil {
+		return summary, err
+	}
+
+	// Page interactive output like git; the terminal check must come before
+	// standard output is redirected to the pager. --confirm prompts on the
+	// terminal, so it isn't paged, and --porcelain output is for programs.
+	if stdout, ok := r.stdout.(*os.File); ok && !r.opts.NoPager && !r.opts.Confirm && r.porcelain == nil && r.opts.Output == "" && platform.IsTerminal(stdout) {
+		pagerInput, stopPager, err := startPager()
+		if err != nil && r.opts.Verbose {
+			fmt.Fprintf(r.stderr, "Warning: failed to start pager: %v\n", err)
+		}
+		if stopPager != nil {
+			r.stdout = pagerInput
+			defer stopPager()
+		}
+	}
+
+	// Rename the identifiers of the --anonymize mapping in everything written
+	// to stdout and to the output file
+	var anonymizer *utils.Anonymizer
+	if r.opts.Anonymize != "" {
+		if anonymizer, err = utils.LoadAnonymizer(r.opts.Anonymize); err != nil {
+			return summary, err
+		}
+		stdout := anonymizer.NewWriter(r.stdout)
+		r.stdout = stdout
+		defer stdout.Flush()
+	}
+
+	imageMode, err := images.ParseMode(r.opts.Images)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --images: %w", err)
+	}
+	minifiedMode, err := minified.ParseMode(r.opts.Minified)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --minified: %w", err)
+	}
+	testsMode, err := analysis.ParseTestsMode(r.opts.Tests)
+	if err != nil {
+		return summary, fmt.Errorf("invalid --tests: %w", err)
+	}
+	if r.opts.BazelTarget != "" {
+		if err := bazel.ValidatePattern(r.opts.BazelTarget); err != nil {
+			return summary, fmt.Errorf("invalid --bazel-target: %w", err)
+		}
+	}
+	var apiSurfaceMode string
+	if r.opts.APISurface != "" {
+		if apiSurfaceMode, err = analysis.ParseAPISurfaceMode(r.opts.APISurface); err != nil {
+			return summary, fmt.Errorf("invalid --api-surface: %w", err)
+		}
+	}
+	var deadFilesMode string
+	if r.opts.DeadFiles != "" {
+		if deadFilesMode, err = analysis.ParseDeadFilesMode(r.opts.DeadFiles); err != nil {
+			return summary, fmt.Errorf("invalid --dead-files: %w", err)
+		}
+	}
+	if r.opts.ExpandTabs < 0 {
+		return summary, fmt.Errorf("invalid --expand-tabs: %d is negative", r.opts.ExpandTabs)
+	}
+	if r.opts.ComplexityThreshold < 0 {
+		return summary, fmt.Errorf("invalid --complexity-threshold: %d is negative", r.opts.ComplexityThreshold)
+	}
+	if r.opts.NestingThreshold < 0 {
+		return summary, fmt.Errorf("invalid --nesting-threshold: %d is negative", r.opts.NestingThreshold)
+	}
+	if r.opts.LengthThreshold < 0 {
+		return summary, fmt.Errorf("invalid --function-length-threshold: %d is negative", r.opts.LengthThreshold)
+	}
+	if r.opts.MinStringLength < 0 {
+		return summary, fmt.Errorf("invalid --min-string-length: %d is negative", r.opts.MinStringLength)
+	}
+	if r.opts.History < 0 {
+		return summary, fmt.Errorf("invalid --history: %d is negative", r.opts.History)
+	}
+	if r.opts.NormalizeEOL != "" {
+		if r.opts.NormalizeEOL, err = utils.ParseEOL(r.opts.NormalizeEOL); err != nil {
+			return summary, fmt.Errorf("invalid --normalize-eol: %w", err)
+		}
+	}
+
+	// Select the files to include
+	extractOptions := extract.Options{Documents: !r.opts.NoExtract, PDF: r.opts.ExtractPDF}
+	var included []string
+	deniedFiles := make(map[string]bool)
+	for _, relPath := range scanner.GetRelativePaths(root) {
+		fullPath := platform.JoinSlash(targetDir, relPath)
+		cleanRelPath := relPath[1:] // Clean relative path without leading slash
+
+		// Check if the file should be included
+		if !filter.ShouldInclude(fullPath) {
+			if r.opts.Verbose {
+				fmt.Fprintf(r.stderr, "Skipping file: %s\n", cleanRelPath)
+			}
+			r.porcelain.skip(cleanRelPath, skipExcluded)
+			continue
+		}
+
+		// Notebooks, documents, and PDFs are converted to text, so they are never skipped as binary
+		if extract.Supported(fullPath, extractOptions) {
+			included = append(included, relPath)
+			continue
+		}
+
+		// Images are described by a placeholder instead of being skipped as binary
+		if imageMode != images.ModeSkip && images.IsImage(fullPath) {
+			included = append(included, relPath)
+			continue
+		}
+
+		// Check if it's a text file
+		isText, err := utils.IsTextFile(fullPath)
+		if errors.Is(err, fs.ErrPermission) {
+			deniedFiles[cleanRelPath] = true
+			denied = append(denied, cleanRelPath)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to check if file is text: %v\n", err)
+			r.porcelain.failed(cleanRelPath, err)
+			continue
+		}
+
+		if !isText {
+			if mime, err := utils.DetectMIME(fullPath); err == nil {
+				fmt.Fprintf(r.stderr, "Warning: skipping binary file: %s (%s)\n", cleanRelPath, mime)
+			} else {
+				fmt.Fprintf(r.stderr, "Warning: skipping binary file: %s\n", cleanRelPath)
+			}
+			r.porcelain.skip(cleanRelPath, skipBinary)
+			continue
+		}
+
+		// Minified assets are text but cost many tokens for little insight
+		if minifiedMode == minified.ModeSkip {
+			if info, ok, err := minified.Detect(fullPath); err == nil && ok {
+				if r.opts.Verbose {
+					fmt.Fprintf(r.stderr, "Skipping minified file: %s (%s)\n", cleanRelPath, info.Describe(r.sizeUnits()))
+				}
+				r.porcelain.skip(cleanRelPath, skipMinified)
+				continue
+			}
+		}
+
+		included = append(included, relPath)
+	}
+
+	// Include each physical file once when hard links or bind mounts show it at several paths
+	included, linkGroups := r.skipLinkedCopies(included, scanner.LinkedFiles(root))
+
+	// Include or exclude the test files as a group
+	if testsMode != analysis.TestsInclude {
+		before := r.porcelain.snapshot(included)
+		included = withCleanPaths(included, func(paths []string) []string {
+			return analysis.FilterTests(paths, testsMode)
+		})
+		r.porcelain.narrowed(before, included, skipTests)
+	}
+
+	// Narrow the files to the workspace and the workspaces it depends on
+	narrowedFrom := r.porcelain.snapshot(included)
+	if r.opts.Workspace != "" {
+		if included, err = r.workspaceFiles(targetDir, included); err != nil {
+			return summary, err
+		}
+	}
+
+	// Narrow the files to the sources of the Bazel target
+	if r.opts.BazelTarget != "" {
+		if included, err = r.bazelFiles(ctx, targetDir, included); err != nil {
+			return summary, err
+		}
+	}
+
+	// Narrow the files to the context slice around the focus target
+	if r.opts.Focus != "" {
+		if included, err = r.focusFiles(targetDir, included); err != nil {
+			return summary, err
+		}
+	}
+
+	// Narrow the files to those changed by the pull request
+	var pullRequest *forge.PullRequest
+	if r.opts.PR != "" {
+		if included, pullRequest, err = r.pullRequestFiles(targetDir, included); err != nil {
+			return summary, err
+		}
+	}
+
+	// Narrow the files to those changed since the last tag
+	var changelog *git.Changelog
+	if r.opts.ChangelogContext {
+		if changelog, err = git.GetChangelog(targetDir); err != nil {
+			return summary, fmt.Errorf("failed to get the changes since the last tag: %w", err)
+		}
+		if changelog.Since == "" {
+			fmt.Fprintln(r.stderr, "Warning: no tag found before HEAD; including the changes in all history")
+		}
+		changed := make(map[string]bool, len(changelog.Files))
+		for _, file := range changelog.Files {
+			changed["/"+file.Path] = true
+		}
+		included = slices.DeleteFunc(included, func(relPath string) bool {
+			return !changed[relPath]
+		})
+	}
+	r.porcelain.narrowed(narrowedFrom, included, skipNarrowed)
+
+	// Narrow the files to the database schema, migrations, and models
+	if r.opts.SchemaOnly {
+		before := r.porcelain.snapshot(included)
+		included = withCleanPaths(included, func(paths []string) []string {
+			return analysis.SchemaFiles(targetDir, paths)
+		})
+		r.porcelain.narrowed(before, included, skipSchema)
+	}
+
+	// Narrow the files to infrastructure as code, grouped by module
+	if r.opts.IaC {
+		before := r.porcelain.snapshot(included)
+		included = withCleanPaths(included, func(paths []string) []string {
+			return analysis.IaCFiles(targetDir, paths)
+		})
+		r.porcelain.narrowed(before, included, skipIaC)
+	}
+
+	// Find the packages and assets nothing uses, and leave them out if asked to
+	var deadFiles []analysis.DeadFile
+	if deadFilesMode != "" {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		deadFiles = analysis.FindDeadFiles(targetDir, paths)
+		if advancedStatsCollector != nil {
+			advancedStatsCollector.DeadFiles = deadFiles
+		}
+		if deadFilesMode == analysis.DeadFilesExclude {
+			dead := make(map[string]bool)
+			for _, file := range deadFiles {
+				for _, relPath := range file.Files {
+					dead["/"+relPath] = true
+				}
+			}
+			before := r.porcelain.snapshot(included)
+			included = slices.DeleteFunc(included, func(relPath string) bool {
+				return dead[relPath]
+			})
+			r.porcelain.narrowed(before, included, skipDead)
+			if r.opts.Verbose {
+				fmt.Fprintf(r.stdout, "Excluded %d dead files\n", len(dead))
+			}
+		}
+	}
+
+	// Tag key files and, when output is limited, include them before the budget is spent
+	var keyFiles []string
+	sortOrder := []string{formatter.SortByPath}
+	if r.opts.NaturalSort {
+		sortOrder = []string{formatter.SortByNaturalPath}
+	}
+	if !r.opts.NoKeyFiles {
+		includedSet := make(map[string]bool, len(included))
+		for _, relPath := range included {
+			includedSet[relPath[1:]] = true
+		}
+		keyFiles = scanner.MarkKeyFiles(root, func(relPath string) bool {
+			return includedSet[relPath] && analysis.IsKeyFile(relPath)
+		})
+		if sizeLimiter.IsLimited() && r.opts.Focus == "" && r.opts.PR == "" && !r.opts.SchemaOnly && !r.opts.IaC {
+			included = keyFilesFirst(included)
+			sortOrder = append(sortOrder, formatter.SortKeyFilesFirst)
+		}
+	}
+
+	// Place the API contracts first, so that the budget is spent on them first
+	if r.opts.ContractsFirst {
+		included = withCleanPaths(included, func(paths []string) []string {
+			return analysis.ContractsFirst(targetDir, paths)
+		})
+		sortOrder = append(sortOrder, formatter.SortContractsFirst)
+	}
+
+	// Ask which directories to include before anything is output
+	if r.opts.Confirm {
+		before := r.porcelain.snapshot(included)
+		included, err = confirmFiles(included, r.stdin, r.stderr)
+		if err != nil {
+			return summary, err
+		}
+		r.porcelain.narrowed(before, included, skipDeclined)
+	}
+
+	// Stop before anything is output if the policy file denies an included
+	// file, or one that review comments quote
+	policyDecision, err := r.checkPolicy(targetDir, included, sectionPaths(pullRequest))
+	if err != nil {
+		return summary, err
+	}
+
+	// Find the files tracked with Git LFS, downloading their objects if asked to
+	var lfsObjects map[string]lfsObject
+	if r.opts.LFSFetch || statsCollector != nil {
+		lfsObjects = r.lfsObjects(targetDir, included)
+
+		// The pointers were text, but the fetched content may be binary
+		included = slices.DeleteFunc(included, func(relPath string) bool {
+			fullPath := platform.JoinSlash(targetDir, relPath)
+			if !lfsObjects[relPath[1:]].fetched || extract.Supported(fullPath, extractOptions) || (imageMode != images.ModeSkip && images.IsImage(fullPath)) {
+				return false
+			}
+			if isText, err := utils.IsTextFile(fullPath); err != nil || isText {
+				return false
+			}
+			fmt.Fprintf(r.stderr, "Warning: skipping binary file: %s (fetched from Git LFS)\n", relPath[1:])
+			r.porcelain.skip(relPath[1:], skipBinary)
+			delete(lfsObjects, relPath[1:])
+			return true
+		})
+	}
+
+	// Find the files embedded with //go:embed, so that those left out of the
+	// output, such as binary assets, are still named
+	var goEmbeds []analysis.GoEmbed
+	if slices.ContainsFunc(included, func(relPath string) bool { return strings.HasSuffix(relPath, ".go") }) {
+		includedPaths := make([]string, len(included))
+		for i, relPath := range included {
+			includedPaths[i] = relPath[1:]
+		}
+		var allPaths []string
+		for _, relPath := range scanner.GetRelativePaths(root) {
+			allPaths = append(allPaths, relPath[1:])
+		}
+		goEmbeds = analysis.FindGoEmbeds(targetDir, includedPaths, allPaths)
+	}
+
+	// Place each test right after the source it covers
+	if r.opts.PairTests {
+		included = withCleanPaths(included, analysis.PairTests)
+		sortOrder = append(sortOrder, formatter.SortPairedTests)
+	}
+
+	// Rank the included files by churn and complexity for the advanced stats
+	// and JSON metadata
+	var hotspots []analysis.Hotspot
+	if r.opts.Hotspots && (advancedStatsCollector != nil || r.writesJSON()) {
+		churn, err := git.GetChurn(targetDir, analysis.HotspotPeriod)
+		if err == nil {
+			includedChurn := make(map[string]int, len(included))
+			for _, relPath := range included {
+				if commits := churn[relPath[1:]]; commits > 0 {
+					includedChurn[relPath[1:]] = commits
+				}
+			}
+			hotspots, err = analysis.RankHotspots(targetDir, includedChurn)
+		}
+		if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to rank hotspots: %v\n", err)
+		} else if advancedStatsCollector != nil {
+			advancedStatsCollector.Hotspots = hotspots
+		}
+	}
+
+	// Measure the complexity, nesting, and length of each function in the
+	// included files for the advanced stats, health check, and JSON metadata
+	var functions *analysis.FunctionReport
+	healthCheck := advancedStatsCollector != nil && advancedStatsCollector.HealthCheck != nil
+	if healthCheck || (r.opts.ComplexityAnalysis && (advancedStatsCollector != nil || r.writesJSON())) {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		thresholds := analysis.FunctionThresholds{
+			Complexity: r.opts.ComplexityThreshold,
+			Nesting:    r.opts.NestingThreshold,
+			Length:     r.opts.LengthThreshold,
+		}
+		if report, err := analysis.AnalyzeFunctions(targetDir, paths, thresholds); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to analyze functions: %v\n", err)
+		} else {
+			if healthCheck {
+				advancedStatsCollector.HealthCheck.AddFunctionFindings(report)
+			}
+			if r.opts.ComplexityAnalysis {
+				functions = report
+				if advancedStatsCollector != nil {
+					advancedStatsCollector.Functions = report
+				}
+			}
+		}
+	}
+
+	// Find the undocumented public symbols of the included files for the
+	// advanced stats and JSON metadata
+	var docCoverage *analysis.DocCoverage
+	if r.opts.DocCoverage && (advancedStatsCollector != nil || r.writesJSON()) {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		if docCoverage, err = analysis.AnalyzeDocCoverage(targetDir, paths); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to analyze documentation coverage: %v\n", err)
+		} else if advancedStatsCollector != nil {
+			advancedStatsCollector.DocCoverage = docCoverage
+		}
+	}
+
+	// Extract the user-facing strings of the included files for the advanced
+	// stats and JSON metadata
+	var userStrings []analysis.StringLiteral
+	if r.opts.StringsReport && (advancedStatsCollector != nil || r.writesJSON()) {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		if userStrings, err = analysis.FindUserStrings(targetDir, paths, r.opts.MinStringLength); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to extract strings: %v\n", err)
+		}
+		// The report quotes file content, so it is redacted like the files
+		if r.opts.RedactPII {
+			for i := range userStrings {
+				userStrings[i].Text, _ = utils.RedactPII(userStrings[i].Text)
+			}
+		}
+		if err == nil && advancedStatsCollector != nil {
+			advancedStatsCollector.Strings = userStrings
+		}
+	}
+
+	// Inventory the environment variables and configuration keys of the
+	// included files for the advanced stats and JSON metadata
+	var configInventory *analysis.ConfigInventory
+	if r.opts.ConfigInventory && (advancedStatsCollector != nil || r.writesJSON()) {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		if configInventory, err = analysis.FindConfigInventory(targetDir, paths); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to inventory configuration: %v\n", err)
+		} else if advancedStatsCollector != nil {
+			advancedStatsCollector.ConfigInventory = configInventory
+		}
+	}
+
+	// Count the resources of the infrastructure-as-code modules for the
+	// advanced stats and JSON metadata
+	var iac *analysis.IaCInventory
+	if r.opts.IaC && (advancedStatsCollector != nil || r.writesJSON()) {
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		if iac, err = analysis.FindIaC(targetDir, paths); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to count infrastructure resources: %v\n", err)
+		} else if advancedStatsCollector != nil {
+			advancedStatsCollector.IaC = iac
+		}
+	}
+
+	// Find who owns the included files for the advanced stats and JSON metadata
+	var ownership *analysis.Ownership
+	if r.opts.Ownership && (advancedStatsCollector != nil || r.writesJSON()) {
+		if authors, err := git.GetAuthors(targetDir); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to analyze ownership: %v\n", err)
+		} else {
+			ownedPaths := make([]string, len(included))
+			for i, relPath := range included {
+				ownedPaths[i] = relPath[1:]
+			}
+			ownership = analysis.ComputeOwnership(ownedPaths, authors)
+			if advancedStatsCollector != nil {
+				advancedStatsCollector.Ownership = ownership
+			}
+		}
+	}
+
+	// Get the recent commits to list after the tree
+	var history []git.Commit
+	if r.opts.History > 0 {
+		var err error
+		if history, err = git.GetHistory(targetDir, r.opts.History); err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to get commit history: %v\n", err)
+		}
+	}
+
+	// Generate the tree, marking the files we weren't allowed to read
+	scanner.MarkDenied(root, deniedFiles)
+	tree := scanner.GenerateTree(root)
+
+	// Render the header and footer templates
+	var header, footer string
+	if r.opts.HeaderFile != "" || r.opts.FooterFile != "" {
+		vars, err := formatter.ParseVars(r.opts.Vars)
+		if err != nil {
+			return summary, err
+		}
+		data := templateData(targetDir, r.opts.Format, included, scanner.Stat)
+		if r.opts.HeaderFile != "" {
+			if header, err = formatter.RenderTemplateFile(r.opts.HeaderFile, data, vars); err != nil {
+				return summary, err
+			}
+		}
+		if r.opts.FooterFile != "" {
+			if footer, err = formatter.RenderTemplateFile(r.opts.FooterFile, data, vars); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	// Start the analyzer plugins
+	analyzers, err := startAnalyzers(r.opts.Plugins)
+	if err != nil {
+		return summary, err
+	}
+
+	// Create a formatter; a plugin format is fed the JSON output
+	// Without --output, --porcelain writes only its records
+	documentOut := r.stdout
+	if r.porcelain != nil {
+		documentOut = io.Discard
+	}
+	formatter, err := createFormatter(r.opts, documentOut, sizeLimiter, gitInfo)
+	if err != nil {
+		return summary, fmt.Errorf("failed to create formatter: %w", err)
+	}
+	if anonymizer != nil && r.opts.Output != "" {
+		formatter.Writer = anonymizer.NewWriter(formatter.Writer)
+	}
+	defer func() {
+		if closeErr := formatter.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	if err := r.addOutputs(formatter, anonymizer); err != nil {
+		return summary, err
+	}
+	formatter.TreeDetails = len(treeDetails) > 0
+	formatter.MaxLineLength = int(maxLineLength)
+	formatter.Header = header
+	formatter.SetKeyFiles(keyFiles)
+	formatter.SetStack(stack)
+	formatter.SetCoverage(coverageReport)
+	formatter.SetHotspots(hotspots)
+	formatter.SetOwnership(ownership)
+	formatter.SetFunctions(functions)
+	formatter.SetDeadFiles(deadFiles)
+	formatter.SetDocCoverage(docCoverage)
+	formatter.SetStrings(userStrings)
+	formatter.SetConfigInventory(configInventory)
+	formatter.SetIaC(iac)
+	formatter.SetGoEmbeds(goEmbeds)
+	formatter.SetPolicyDecision(policyDecision)
+	formatter.SetHistory(history)
+	formatter.SetPullRequest(pullRequest)
+	formatter.SetImage(r.image)
+	if changelog != nil {
+		// The diff shows only the files the output includes, once they are final
+		paths := make([]string, len(included))
+		for i, relPath := range included {
+			paths[i] = relPath[1:]
+		}
+		if changelog.Diff, err = git.GetChangelogDiff(targetDir, changelog, paths); err != nil {
+			return summary, fmt.Errorf("failed to get the changes since the last tag: %w", err)
+		}
+	}
+	formatter.SetChangelog(changelog)
+	formatter.SetLinkGroups(linkGroups)
+	if r.opts.DirectoryRecords {
+		formatter.SetDirectories(scanner.DirectoryRecords(root))
+	}
+	formatter.SetInvocation(r.invocation())
+	formatter.SetTargetDirectory(targetDir)
+	formatter.SetScanOptions(r.scanOptions(filter, totalLimit, sortOrder))
+	formatter.Extract = extractOptions
+	formatter.Stat = scanner.Stat
+	formatter.KeepDataURIs = r.opts.KeepDataURIs
+	formatter.RedactPII = r.opts.RedactPII
+	formatter.Images = imageMode
+	formatter.Minified = minifiedMode
+	formatter.TabWidth = r.opts.ExpandTabs
+	formatter.DetectIndent = r.opts.DetectIndent
+	formatter.Color = colorize
+	formatter.SizeUnits = r.sizeUnits()
+	formatter.Transform = transformHook(r.opts, targetDir)
+	formatter.Footer = footer
+
+	// Format the tree
+	if err := formatter.FormatTree(tree); err != nil {
+		return summary, fmt.Errorf("failed to format tree: %w", err)
+	}
+
+	// Count directories for stats
+	if statsCollector != nil {
+		statsCollector.Stat = scanner.Stat
+
+		// Count the root directory
+		statsCollector.AddDirectory(targetDir)
+
+		// Count all subdirectories
+		for _, child := range root.Children {
+			if child.IsDir {
+				countDirectories(child, statsCollector)
+			}
+		}
+	}
+
+	// Extract the exported API of the Go packages, which may replace their files
+	var apiSurface *analysis.APISurface
+	apiSurfaceFiles := make(map[string]bool)
+	if apiSurfaceMode != "" && !r.opts.DryRun {
+		apiPaths := make([]string, len(included))
+		for i, relPath := range included {
+			apiPaths[i] = relPath[1:]
+		}
+		apiSurface, err = analysis.BuildAPISurface(targetDir, apiPaths)
+		if err != nil {
+			return summary, fmt.Errorf("failed to extract API surface: %w", err)
+		}
+		if apiSurfaceMode == analysis.APISurfaceReplace {
+			for _, relPath := range apiSurface.Files {
+				apiSurfaceFiles[relPath] = true
+			}
+		}
+	}
+
+	// Process each file
+	var dedupeIndex *dedupe.Index
+	if r.opts.Dedupe {
+		dedupeIndex = dedupe.NewIndex()
+		dedupeIndex.Stat = scanner.Stat
+	}
+	for _, relPath := range included {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		fullPath := platform.JoinSlash(targetDir, relPath)
+		cleanRelPath := relPath[1:] // Clean relative path without leading slash
+
+		// Update stats if stats flag is set
+		if statsCollector != nil {
+			if err := statsCollector.AddFile(fullPath, !images.IsImage(fullPath)); err != nil {
+				fmt.Fprintf(r.stderr, "Warning: failed to add file to stats: %v\n", err)
+			}
+			if object, ok := lfsObjects[cleanRelPath]; ok {
+				statsCollector.AddLFSObject(object.size, object.fetched)
+			}
+		}
+
+		// Send the file to the analyzer plugins
+		for _, analyzer := range analyzers {
+			if err := analyzer.File(pluginFileEvent(fullPath, cleanRelPath, scanner.Stat)); err != nil {
+				fmt.Fprintf(r.stderr, "Warning: %v\n", err)
+			}
+		}
+
+		// If dry run flag is set, just print the file path and skip formatting
+		if r.opts.DryRun {
+			fmt.Fprintf(r.stderr, "Would process file: %s\n", cleanRelPath)
+			r.porcelain.file(cleanRelPath)
+			continue
+		}
+
+		// The repository map and the summary replace the file contents, and the API surface those of Go packages
+		if r.opts.RepoMap || r.opts.Summary || apiSurfaceFiles[cleanRelPath] {
+			r.porcelain.skip(cleanRelPath, skipSummarized)
+			continue
+		}
+
+		// Replace files identical to an earlier one with a stub
+		if dedupeIndex != nil {
+			original, err := dedupeIndex.Check(fullPath, cleanRelPath)
+			if err != nil {
+				fmt.Fprintf(r.stderr, "Warning: failed to check for duplicate content: %v\n", err)
+			} else if original != "" {
+				if err := formatter.FormatDuplicate(fullPath, cleanRelPath, original); err != nil {
+					fmt.Fprintf(r.stderr, "Warning: failed to format file content: %v\n", err)
+				}
+				r.porcelain.skip(cleanRelPath, skipDuplicate, original)
+				continue
+			}
+		}
+
+		// Format the file content
+		if err := formatter.FormatFileContent(fullPath, cleanRelPath); errors.Is(err, fs.ErrPermission) {
+			denied = append(denied, cleanRelPath)
+			continue
+		} else if err != nil {
+			fmt.Fprintf(r.stderr, "Warning: failed to format file content: %v\n", err)
+			r.porcelain.failed(cleanRelPath, err)
+			continue
+		}
+		r.porcelain.file(cleanRelPath)
+	}
+
+	// Build and format the repository map
+	if r.opts.RepoMap && !r.opts.DryRun {
+		mapPaths := make([]string, len(included))
+		for i, relPath := range included {
+			mapPaths[i] = relPath[1:]
+		}
+		repoMap, err := analysis.BuildRepoMap(targetDir, mapPaths, r.opts.MapTokens)
+		if err != nil {
+			return summary, fmt.Errorf("failed to build repo map: %w", err)
+		}
+		if coverageReport != nil {
+			repoMap.AddCoverage(coverageReport)
+		}
+		if err := formatter.FormatRepoMap(repoMap); err != nil {
+			return summary, fmt.Errorf("failed to format repo map: %w", err)
+		}
+	}
+
+	// Format the API surface
+	if apiSurface != nil {
+		if err := formatter.FormatAPISurface(apiSurface); err != nil {
+			return summary, fmt.Errorf("failed to format API surface: %w", err)
+		}
+	}
+
+	// Build and format the cross-reference index
+	if r.opts.Xref && !r.opts.DryRun {
+		xrefPaths := make([]string, len(included))
+		for i, relPath := range included {
+			xrefPaths[i] = relPath[1:]
+		}
+		index, err := analysis.BuildXref(targetDir, xrefPaths)
+		if err != nil {
+			return summary, fmt.Errorf("failed to build xref: %w", err)
+		}
+		if err := formatter.FormatXref(index); err != nil {
+			return summary, fmt.Errorf("failed to format xref: %w", err)
+		}
+	}
+
+	// Format the statistics and the inventory of the files left out
+	if r.opts.Summary && !r.opts.DryRun {
+		if err := formatter.FormatSummary(statsCollector, summaryInventory(targetDir, scanner.FileDetails(root), included)); err != nil {
+			return summary, fmt.Errorf("failed to format summary: %w", err)
+		}
+	}
+
+	// Report the analyzer plugin findings
+	finishAnalyzers(analyzers, r.stderr)
+
+	// Summarize the paths we weren't allowed to read
+	formatter.SetDenied(denied)
+	formatter.SetSparseCheckout(sparse)
+	for _, relPath := range denied {
+		r.porcelain.failed(relPath, fs.ErrPermission)
+	}
+	if len(denied) > 0 && r.opts.Verbose {
+		for _, relPath := range denied {
+			fmt.Fprintf(r.stderr, "Permission denied: %s\n", relPath)
+		}
+		fmt.Fprintf(r.stderr, "Warning: permission denied for %d paths\n", len(denied))
+	} else if len(denied) > 0 {
+		fmt.Fprintf(r.stderr, "Warning: permission denied for %d paths (use --verbose to list them)\n", len(denied))
+	}
+
+	summary.Files = len(included)
+	summary.Tokens = sizeLimiter.OutputSize() / 4
+	summary.TargetDir = targetDir
+	summary.Paths = make([]string, len(included))
+	for i, relPath := range included {
+		summary.Paths[i] = relPath[1:]
+	}
+
+	// Print stats if stats flag is set
+	if statsCollector != nil {
+		statsCollector.AddEmbeddedData(formatter.EmbeddedData())
+		statsCollector.AddPIIRedacted(formatter.PIIRedacted())
+		if dedupeIndex != nil {
+			statsCollector.AddDuplicates(dedupeIndex.Duplicates, dedupeIndex.SavedTokens())
+		}
+		formatter.SetStats(statsCollector)
+	}
+	// The statistics may go to the same stdout as the buffered output
+	if advancedStatsCollector != nil || r.opts.Stats {
+		if err := formatter.Flush(); err != nil {
+			return summary, fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	if advancedStatsCollector != nil {
+		advancedStatsCollector.PrintAdvancedStats(r.stdout)
+	} else if r.opts.Stats {
+		statsCollector.PrintStats(r.stdout)
+	}
+
+	return summary, nil
+}
+
+// sizeUnits resolves --si to the units sizes are shown in
+func (r *runner) sizeUnits() utils.SizeUnits {
+	if r.opts.SI {
+		return utils.SIUnits
+	}
+	return utils.BinaryUnits
+}
+
+// useColor resolves --color. In auto mode, text output is highlighted only when it
+// goes to a terminal and NO_COLOR is not set.
+func (r *runner) useColor() (bool, error) {
+	mode := r.opts.Color
+	if strings.ToLower(r.opts.Format) != "text" {
+		return false, nil
+	}
+	switch strings.ToLower(mode) {
+	case "", colorNever:
+		return false, nil
+	case colorAlways:
+		return true, nil
+	case colorAuto:
+		stdout, ok := r.stdout.(*os.File)
+		return ok && r.opts.Output == "" && os.Getenv("NO_COLOR") == "" && platform.IsTerminal(stdout), nil
+	default:
+		return false, fmt.Errorf("invalid --color: %q (expected auto, always, or never)", mode)
+	}
+}
+
+// keyFilesFirst moves key files to the front, keeping the order within each group
+func keyFilesFirst(paths []string) []string {
+	ordered := make([]string, 0, len(paths))
+	for _, relPath := range paths {
+		if analysis.IsKeyFile(relPath[1:]) {
+			ordered = append(ordered, relPath)
+		}
+	}
+	for _, relPath := range paths {
+		if !analysis.IsKeyFile(relPath[1:]) {
+			ordered = append(ordered, relPath)
+		}
+	}
+	return ordered
+}
+
+// withCleanPaths applies fn, which takes and returns paths without a leading
+// slash, to included paths with one
+func withCleanPaths(included []string, fn func([]string) []string) []string {
+	paths := make([]string, len(included))
+	for i, relPath := range included {
+		paths[i] = relPath[1:]
+	}
+	paths = fn(paths)
+	for i, relPath := range paths {
+		paths[i] = "/" + relPath
+	}
+	return paths
+}
+
+// skipLinkedCopies drops files that are the same physical file as an earlier
+// included file. It returns the remaining files and the groups of paths
+// (without a leading slash) that were included once, first the included one.
+func (r *runner) skipLinkedCopies(included []string, linked map[string]string) ([]string, [][]string) {
+	if len(linked) == 0 {
+		return included, nil
+	}
+
+	var groups [][]string
+	groupIndex := make(map[string]int)
+	kept := included[:0]
+	for _, relPath := range included {
+		original, ok := linked[relPath]
+		if !ok {
+			original = relPath
+		}
+		index, seen := groupIndex[original]
+		if !seen {
+			groupIndex[original] = len(groups)
+			groups = append(groups, []string{relPath[1:]})
+			kept = append(kept, relPath)
+			continue
+		}
+		groups[index] = append(groups[index], relPath[1:])
+		if r.opts.Verbose {
+			fmt.Fprintf(r.stderr, "Skipping file: %s (same file as %s)\n", relPath[1:], groups[index][0])
+		}
+		r.porcelain.skip(relPath[1:], skipLinked, groups[index][0])
+	}
+
+	var linkGroups [][]string
+	for _, group := range groups {
+		if len(group) > 1 {
+			linkGroups = append(linkGroups, group)
+		}
+	}
+	return kept, linkGroups
+}
+
+// templateData summarizes the included files for header and footer templates
+func templateData(targetDir, format string, included []string, stat platform.StatFunc) formatter.TemplateData {
+	data := formatter.TemplateData{
+		ProjectName: filepath.Base(targetDir),
+		TargetDir:   targetDir,
+		Format:      strings.ToLower(format),
+		Date:        time.Now().Format("2006-01-02"),
+		TotalFiles:  len(included),
+	}
+	for _, relPath := range included {
+		fullPath := platform.JoinSlash(targetDir, relPath)
+		if info, err := platform.Stat(stat, fullPath); err == nil {
+			data.TotalSize += info.Size()
+		}
+		if images.IsImage(fullPath) {
+			continue
+		}
+		if tokens, err := stats.EstimateTokens(fullPath); err == nil {
+			data.TotalTokens += tokens
+		}
+	}
+	return data
+}
+
+// countDirectories recursively counts directories
+func countDirectories(entry *scanner.FileEntry, statsCollector *stats.StatsCollector) {
+	if entry.IsDir {
+		statsCollector.AddDirectory(entry.Path)
+		for _, child := range entry.Children {
+			if child.IsDir {
+				countDirectories(child, statsCollector)
+			}
+		}
+	}
+}
+
+// writeSARIF writes the dependency audit findings of a health check to path
+func writeSARIF(path string, health *analysis.HealthCheck) error {
+	if health == nil || !health.DependenciesAudited {
+		return fmt.Errorf("no dependency audit results (--sarif needs --health-check and --audit-deps); %s not written", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create SARIF file: %w", err)
+	}
+	if err := audit.WriteSARIF(file, health.Vulnerabilities, version); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write SARIF file: %w", err)
+	}
+	return file.Close()
+}
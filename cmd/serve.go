@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"codectx/internal/analysis"
+	"codectx/internal/language"
+	"codectx/internal/platform"
+	"codectx/internal/rpc"
+	"codectx/internal/stats"
+)
+
+// defaultServeAddress is where "codectx serve" listens without --listen
+const defaultServeAddress = "localhost:50051"
+
+// runServe serves the scans of a directory tree to other programs:
+// "codectx serve --grpc [--listen ADDRESS] [--root DIR]"
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	grpc := flags.Bool("grpc", false, "Serve the codectx.v1.Codectx gRPC service")
+	listen := flags.String("listen", defaultServeAddress, "Address to listen on")
+	root := flags.String("root", ".", "Directory whose subdirectories may be scanned")
+	verbose := flags.Bool("verbose", false, "Log each call to stderr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument: %s", flags.Arg(0))
+	}
+	if !*grpc {
+		return errors.New("usage: codectx serve --grpc [--listen ADDRESS] [--root DIR]")
+	}
+
+	service, err := newCodectxService(*root, os.Stderr)
+	if err != nil {
+		return err
+	}
+	service.verbose = *verbose
+	server := rpc.NewServer()
+	service.register(server)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Serving %s over gRPC on %s for %s\n", rpc.ServiceName, *listen, service.root)
+	return server.ListenAndServe(ctx, *listen)
+}
+
+// codectxService implements the codectx.v1.Codectx gRPC service with the
+// pipeline of the command line
+type codectxService struct {
+	root    string  // Absolute path, with symlinks resolved
+	base    Options // Options of every scan, with the config defaults
+	verbose bool
+	stderr  io.Writer
+
+	// The pipeline sets process-wide state, such as the language overrides,
+	// so scans run one at a time
+	mu sync.Mutex
+}
+
+// newCodectxService serves the directories within root
+func newCodectxService(root string, stderr io.Writer) (*codectxService, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+	if !isDirectory(root) {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	opts := DefaultOptions()
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	defineFlags(flags, &opts)
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDefaults(flags, cfg.Defaults); err != nil {
+		return nil, err
+	}
+	opts.Languages = cfg.Languages
+	return &codectxService{root: root, base: opts, stderr: stderr}, nil
+}
+
+// register adds the methods of the service to server
+func (s *codectxService) register(server *rpc.Server) {
+	server.Handle(rpc.ServiceName, "Scan", s.logged("Scan", s.scan))
+	server.Handle(rpc.ServiceName, "GetFile", s.logged("GetFile", s.getFile))
+	server.Handle(rpc.ServiceName, "GetStats", s.logged("GetStats", s.getStats))
+	server.Handle(rpc.ServiceName, "Analyze", s.logged("Analyze", s.analyze))
+}
+
+// logged logs the calls of a method and their errors with --verbose
+func (s *codectxService) logged(name string, method rpc.Method) rpc.Method {
+	return func(ctx context.Context, request []byte, send func(rpc.Marshaler) error) error {
+		err := method(ctx, request, send)
+		if s.verbose {
+			if err != nil {
+				fmt.Fprintf(s.stderr, "%s: %v\n", name, err)
+			} else {
+				fmt.Fprintf(s.stderr, "%s: ok\n", name)
+			}
+		}
+		return err
+	}
+}
+
+// scan streams a File for each file the scan selects
+func (s *codectxService) scan(ctx context.Context, request []byte, send func(rpc.Marshaler) error) error {
+	var req rpc.ScanRequest
+	if err := req.Unmarshal(request); err != nil {
+		return rpc.Errorf(rpc.InvalidArgument, "invalid ScanRequest: %v", err)
+	}
+	summary, err := s.selectFiles(ctx, &req)
+	if err != nil {
+		return err
+	}
+	for _, relPath := range summary.Paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file, err := describeFile(summary.TargetDir, relPath, !req.OmitContent)
+		if err != nil {
+			return rpc.Errorf(rpc.Internal, "%s: %v", relPath, err)
+		}
+		if err := send(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getFile returns one file within the root that the policy file doesn't deny
+func (s *codectxService) getFile(ctx context.Context, request []byte, send func(rpc.Marshaler) error) error {
+	var req rpc.GetFileRequest
+	if err := req.Unmarshal(request); err != nil {
+		return rpc.Errorf(rpc.InvalidArgument, "invalid GetFileRequest: %v", err)
+	}
+	dir, err := s.resolveDirectory(req.Directory)
+	if err != nil {
+		return err
+	}
+	relPath := filepath.FromSlash(req.Path)
+	if !filepath.IsLocal(relPath) {
+		return rpc.Errorf(rpc.InvalidArgument, "path must be relative to the directory: %s", req.Path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, relPath))
+	if err != nil {
+		return rpc.Errorf(rpc.NotFound, "no such file: %s", req.Path)
+	}
+	if !within(s.root, resolved) {
+		return rpc.Errorf(rpc.PermissionDenied, "%s is outside the served root", req.Path)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return rpc.Errorf(rpc.InvalidArgument, "not a regular file: %s", req.Path)
+	}
+	if info.Size() > rpc.MaxMessageSize {
+		return rpc.Errorf(rpc.FailedPrecondition, "%s is larger than %d bytes", req.Path, rpc.MaxMessageSize)
+	}
+
+	// Check the target of a symlink against the policy file too
+	slashPath := filepath.ToSlash(filepath.Clean(relPath))
+	checked := []string{slashPath}
+	if target, err := platform.RelSlash(dir, resolved); err == nil && target != slashPath && filepath.IsLocal(target) {
+		checked = append(checked, target)
+	}
+	p, prefix, err := loadPolicy(dir)
+	if err != nil {
+		return rpc.Errorf(rpc.FailedPrecondition, "%v", err)
+	}
+	if p != nil {
+		if violations := p.Check(prefix, checked); len(violations) > 0 {
+			return rpc.Errorf(rpc.PermissionDenied, "policy %s denies exporting %s", p.File, violationText(violations[0]))
+		}
+	}
+
+	file, err := describeFile(dir, slashPath, true)
+	if err != nil {
+		return rpc.Errorf(rpc.Internal, "%s: %v", req.Path, err)
+	}
+	return send(file)
+}
+
+// getStats sums up the files the scan selects, per language
+func (s *codectxService) getStats(ctx context.Context, request []byte, send func(rpc.Marshaler) error) error {
+	var req rpc.ScanRequest
+	if err := req.Unmarshal(request); err != nil {
+		return rpc.Errorf(rpc.InvalidArgument, "invalid ScanRequest: %v", err)
+	}
+	summary, err := s.selectFiles(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	result := &rpc.Stats{}
+	byLanguage := make(map[string]*rpc.LanguageStats)
+	for _, relPath := range summary.Paths {
+		file, err := describeFile(summary.TargetDir, relPath, false)
+		if err != nil {
+			return rpc.Errorf(rpc.Internal, "%s: %v", relPath, err)
+		}
+		name := "Other"
+		if file.Language != "" {
+			name = language.Lookup(file.Language).Name
+		}
+		langStats := byLanguage[name]
+		if langStats == nil {
+			langStats = &rpc.LanguageStats{Language: name}
+			byLanguage[name] = langStats
+		}
+		langStats.Files++
+		langStats.SizeBytes += file.SizeBytes
+		langStats.Tokens += file.Tokens
+		result.TotalFiles++
+		result.TotalSizeBytes += file.SizeBytes
+		result.Tokens += file.Tokens
+	}
+	for _, langStats := range byLanguage {
+		result.Languages = append(result.Languages, *langStats)
+	}
+	slices.SortFunc(result.Languages, func(a, b rpc.LanguageStats) int {
+		return cmp.Or(cmp.Compare(b.Tokens, a.Tokens), strings.Compare(a.Language, b.Language))
+	})
+	return send(result)
+}
+
+// analyze detects the stack, key files, complex functions, and unused files
+// of the files the scan selects
+func (s *codectxService) analyze(ctx context.Context, request []byte, send func(rpc.Marshaler) error) error {
+	var req rpc.AnalyzeRequest
+	if err := req.Unmarshal(request); err != nil {
+		return rpc.Errorf(rpc.InvalidArgument, "invalid AnalyzeRequest: %v", err)
+	}
+	if req.Scan == nil {
+		req.Scan = &rpc.ScanRequest{}
+	}
+	summary, err := s.selectFiles(ctx, req.Scan)
+	if err != nil {
+		return err
+	}
+
+	result := &rpc.Analysis{}
+	stack, err := analysis.DetectStack(summary.TargetDir)
+	if err != nil {
+		return rpc.Errorf(rpc.Internal, "failed to detect the stack: %v", err)
+	}
+	for _, component := range stack {
+		result.Stack = append(result.Stack, rpc.StackComponent{Name: component.Name, Category: component.Category, Evidence: component.Evidence})
+	}
+	for _, relPath := range summary.Paths {
+		if analysis.IsKeyFile(relPath) {
+			result.KeyFiles = append(result.KeyFiles, relPath)
+		}
+	}
+
+	thresholds := analysis.FunctionThresholds{
+		Complexity: s.base.ComplexityThreshold,
+		Nesting:    s.base.NestingThreshold,
+		Length:     s.base.LengthThreshold,
+	}
+	report, err := analysis.AnalyzeFunctions(summary.TargetDir, summary.Paths, thresholds)
+	if err != nil {
+		return rpc.Errorf(rpc.Internal, "failed to analyze functions: %v", err)
+	}
+	for _, function := range report.Functions {
+		if function.Complexity <= thresholds.Complexity && function.MaxNesting <= thresholds.Nesting && function.Lines <= thresholds.Length {
+			continue
+		}
+		result.Functions = append(result.Functions, rpc.Function{
+			Path:       function.Path,
+			Function:   function.Function,
+			StartLine:  int64(function.StartLine),
+			EndLine:    int64(function.EndLine),
+			Complexity: int64(function.Complexity),
+			MaxNesting: int64(function.MaxNesting),
+			Lines:      int64(function.Lines),
+		})
+	}
+
+	for _, dead := range analysis.FindDeadFiles(summary.TargetDir, summary.Paths) {
+		result.DeadFiles = append(result.DeadFiles, rpc.DeadFile{Path: dead.Path, Kind: dead.Kind, Files: dead.Files})
+	}
+	return send(result)
+}
+
+// selectFiles runs the scan of a request through the pipeline of the
+// command line and returns the files it selects
+func (s *codectxService) selectFiles(ctx context.Context, req *rpc.ScanRequest) (runSummary, error) {
+	dir, err := s.resolveDirectory(req.Directory)
+	if err != nil {
+		return runSummary{}, err
+	}
+	opts := s.base
+	opts.TargetDir = dir
+	opts.Verbose = false
+	if len(req.Extensions) > 0 {
+		opts.Extensions = strings.Join(req.Extensions, ",")
+	}
+	if len(req.Exclude) > 0 {
+		opts.Exclude = strings.Join(req.Exclude, ",")
+	}
+	opts.IncludeRegex = append(slices.Clone(opts.IncludeRegex), req.IncludeRegex...)
+	opts.ExcludeRegex = append(slices.Clone(opts.ExcludeRegex), req.ExcludeRegex...)
+	opts.IncludeDotfiles = opts.IncludeDotfiles || req.IncludeDotfiles
+	if req.MaxFileSize != "" {
+		opts.MaxFileSize = req.MaxFileSize
+	}
+	if req.ModifiedSince != "" {
+		opts.ModifiedSince = req.ModifiedSince
+	}
+	if req.GitOnly != "" {
+		opts.GitOnly = req.GitOnly
+	}
+	if req.Tests != "" {
+		opts.Tests = req.Tests
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary, err := selectFiles(ctx, opts)
+	if err != nil && ctx.Err() == nil {
+		return summary, rpc.Errorf(rpc.FailedPrecondition, "%v", err)
+	}
+	return summary, err
+}
+
+// resolveDirectory returns the absolute path of a directory relative to the
+// root, refusing paths that lead outside it
+func (s *codectxService) resolveDirectory(dir string) (string, error) {
+	relDir := filepath.FromSlash(dir)
+	if relDir == "" {
+		relDir = "."
+	}
+	if !filepath.IsLocal(relDir) {
+		return "", rpc.Errorf(rpc.InvalidArgument, "directory must be relative to the served root: %s", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(s.root, relDir))
+	if err != nil {
+		return "", rpc.Errorf(rpc.NotFound, "no such directory: %s", dir)
+	}
+	if !within(s.root, resolved) {
+		return "", rpc.Errorf(rpc.PermissionDenied, "%s is outside the served root", dir)
+	}
+	if !isDirectory(resolved) {
+		return "", rpc.Errorf(rpc.InvalidArgument, "not a directory: %s", dir)
+	}
+	return resolved, nil
+}
+
+// within reports whether path is root or inside it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// describeFile reads the size, language, tokens, and optionally the content
+// of a file of dir
+func describeFile(dir, relPath string, withContent bool) (*rpc.File, error) {
+	fullPath := filepath.Join(dir, filepath.FromSlash(relPath))
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	file := &rpc.File{Path: relPath, SizeBytes: info.Size(), KeyFile: analysis.IsKeyFile(relPath)}
+	if lang, ok := language.DetectFile(fullPath); ok {
+		file.Language = lang.ID
+	}
+	tokens, err := stats.EstimateTokens(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	file.Tokens = int64(tokens)
+	if withContent {
+		if file.Content, err = os.ReadFile(fullPath); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
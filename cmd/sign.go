@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"codectx/internal/attest"
+	"codectx/internal/remote"
+)
+
+// signingKey loads the --sign key, checking that the output goes to a file
+// the detached signature can be written next to
+func signingKey(opts Options) (ed25519.PrivateKey, error) {
+	if opts.Sign == "" {
+		return nil, nil
+	}
+	if opts.Output == "" {
+		return nil, errors.New("--sign requires --output, as the signature is written next to the output file")
+	}
+	if remote.IsRemote(opts.Output) {
+		return nil, errors.New("--sign requires a local --output file, as the signature is written next to it")
+	}
+	return attest.LoadPrivateKey(opts.Sign)
+}
+
+// signOutput writes the detached signature of the output file
+func signOutput(opts Options, key ed25519.PrivateKey, stderr io.Writer) error {
+	info := currentVersionInfo()
+	tool := attest.Tool{Name: "codectx", Version: info.Version, Commit: info.Commit}
+	if _, err := attest.SignFile(opts.Output, key, tool); err != nil {
+		return err
+	}
+	if opts.Verbose {
+		fmt.Fprintf(stderr, "Signed %s with key %s\n", opts.Output+attest.Extension, attest.Fingerprint(key.Public().(ed25519.PublicKey)))
+	}
+	return nil
+}
+
+// runVerify checks a signed output file: "codectx verify FILE [--key PUBKEY]"
+func runVerify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	keyPath := flags.String("key", "", "Public key the file must be signed with (PEM or base64)")
+	signaturePath := flags.String("signature", "", "Signature file (default: FILE.sig)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: codectx verify FILE [--key PUBKEY] [--signature SIGFILE]")
+	}
+	path := flags.Arg(0)
+	// The flags may also follow the file
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument: %s", flags.Arg(0))
+	}
+	if *signaturePath == "" {
+		*signaturePath = path + attest.Extension
+	}
+
+	var trusted ed25519.PublicKey
+	if *keyPath != "" {
+		data, err := os.ReadFile(*keyPath)
+		if err != nil {
+			return err
+		}
+		if trusted, err = attest.ParsePublicKey(data); err != nil {
+			return fmt.Errorf("invalid public key %s: %w", *keyPath, err)
+		}
+	}
+
+	signature, err := attest.VerifyFile(path, *signaturePath, trusted)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	publicKey, _ := attest.ParsePublicKey([]byte(signature.PublicKey))
+	producer := signature.Tool + " " + signature.Version
+	if signature.Commit != "" {
+		producer += " (commit " + shortHash(signature.Commit) + ")"
+	}
+	fmt.Printf("%s: good signature by key %s\n", path, attest.Fingerprint(publicKey))
+	fmt.Printf("  Produced by %s, signed %s\n", producer, signature.Created)
+	if trusted == nil {
+		fmt.Fprintln(os.Stderr, "Warning: the signing key was not checked; pass --key to require a trusted key")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"codectx/internal/filter"
+	"codectx/internal/git"
+	"codectx/internal/platform"
+)
+
+// sparseCheckout reports the tracked files a sparse checkout leaves out of the
+// working tree, which the scan passes over. With --materialize, it first checks
+// out those that --extensions and --include-regex match.
+func (r *runner) sparseCheckout(targetDir string) (*git.SparseCheckout, error) {
+	if r.opts.Materialize && r.opts.Extensions == "" && len(r.opts.IncludeRegex) == 0 {
+		return nil, fmt.Errorf("--materialize needs --extensions or --include-regex to choose the files to check out")
+	}
+	sparse, err := git.GetSparseCheckout(targetDir)
+	if err != nil {
+		fmt.Fprintf(r.stderr, "Warning: failed to inspect the sparse checkout: %v\n", err)
+		return nil, nil
+	}
+	if sparse == nil || len(sparse.Missing) == 0 {
+		return sparse, nil
+	}
+
+	if r.opts.Materialize {
+		matcher := filter.NewFilter(r.opts.Extensions, r.opts.Exclude, r.opts.IncludeDotfiles)
+		matcher.SetRootDir(targetDir)
+		if err := matcher.SetRegexPatterns(r.opts.IncludeRegex, r.opts.ExcludeRegex); err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, relPath := range sparse.Missing {
+			if matcher.ShouldInclude(platform.JoinSlash(targetDir, relPath)) {
+				files = append(files, relPath)
+			}
+		}
+		if len(files) > 0 {
+			fmt.Fprintf(r.stderr, "Checking out %d files of the sparse checkout...\n", len(files))
+			if err := sparse.Materialize(targetDir, files); err != nil {
+				return nil, err
+			}
+			if sparse, err = git.GetSparseCheckout(targetDir); err != nil || sparse == nil || len(sparse.Missing) == 0 {
+				return sparse, err
+			}
+		}
+	}
+
+	if r.opts.Verbose {
+		for _, relPath := range sparse.NotMaterialized {
+			fmt.Fprintf(r.stderr, "Not checked out: %s\n", relPath)
+		}
+	}
+	fmt.Fprintf(r.stderr, "Note: %d tracked files are not checked out in this sparse checkout and are skipped (see --materialize)\n", sparse.NotMaterializedFiles)
+	return sparse, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import "os"
+
+// subcommand runs "codectx NAME ARGS..." with the arguments after the name.
+// It reports false when the arguments do not select it, so that a directory
+// with the same name can still be scanned.
+type subcommand func(args []string) (bool, error)
+
+// subcommands maps the name of each subcommand to its handler. The names are
+// reserved and cannot be used as alias names. A nil handler marks the
+// subcommands that name what to scan, such as "codectx pr 12", which are
+// parsed with the scan options instead. Set in init, as runAlias refers back
+// to it.
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"docs": func(args []string) (bool, error) {
+			if len(args) == 1 && (args[0] == docsMan || args[0] == docsMarkdown) {
+				return true, runDocs(args[0])
+			}
+			return false, nil
+		},
+		"again":       withoutArgs("again", runAgain),
+		"alias":       withArgs("alias", runAlias),
+		"plugins":     withoutArgs("plugins", runPlugins),
+		"self-update": withArgs("self-update", runSelfUpdate),
+		"render":      withArgs("render", runRender),
+		"query":       withArgs("query", runQuery),
+		"merge":       withArgs("merge", runMerge),
+		"serve":       withArgs("serve", runServe),
+		"verify":      withArgs("verify", runVerify),
+		"bench":       withArgs("bench", runBench),
+		"history": func(args []string) (bool, error) {
+			if len(args) <= 1 && !isDirectory("history") {
+				return true, runHistory(args)
+			}
+			return false, nil
+		},
+		"focus": nil,
+		"pr":    nil,
+		"image": nil,
+	}
+}
+
+// withArgs returns a subcommand that runs unless name is a directory
+func withArgs(name string, run func(args []string) error) subcommand {
+	return func(args []string) (bool, error) {
+		if isDirectory(name) {
+			return false, nil
+		}
+		return true, run(args)
+	}
+}
+
+// withoutArgs returns a subcommand that runs when it is given no arguments
+// and name is not a directory
+func withoutArgs(name string, run func() error) subcommand {
+	return func(args []string) (bool, error) {
+		if len(args) > 0 || isDirectory(name) {
+			return false, nil
+		}
+		return true, run()
+	}
+}
+
+// isSubcommand reports whether name is reserved for a subcommand
+func isSubcommand(name string) bool {
+	_, ok := subcommands[name]
+	return ok
+}
+
+// runSubcommand runs the subcommand named by the first argument, such as
+// "codectx docs man". It reports false when the arguments do not select a
+// subcommand, so that a directory with the same name can still be scanned.
+func runSubcommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	run := subcommands[args[0]]
+	if run == nil {
+		return false, nil
+	}
+	return run(args[1:])
+}
+
+// isDirectory reports whether path names an existing directory
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codectx/internal/update"
+)
+
+// runSelfUpdate replaces the running binary with the latest release after
+// verifying its checksum and the checksum signature. A build without a public
+// key refuses to install unless --insecure trusts the checksums alone.
+func runSelfUpdate(args []string) error {
+	flags := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	checkOnly := flags.Bool("check", false, "Only report whether a newer release is available")
+	insecure := flags.Bool("insecure", false, "Install without a signature when the build has no public key, trusting the checksums alone")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if updatePublicKey == "" && !*insecure && !*checkOnly {
+		return errors.New("this build has no public key to verify releases with; install a release build, or pass --insecure to trust the checksums alone")
+	}
+
+	releaseURL := update.DefaultReleaseURL
+	if url := os.Getenv("CODECTX_UPDATE_URL"); url != "" {
+		releaseURL = url
+	}
+	release, err := update.LatestRelease(releaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+	if !update.IsNewer(release.Tag, version) {
+		fmt.Printf("codectx %s is up to date\n", version)
+		return nil
+	}
+	if *checkOnly {
+		fmt.Printf("codectx %s is available (current: %s)\n", release.Tag, version)
+		return nil
+	}
+
+	// Fetch and verify the checksums, then the binary
+	binaryName := update.BinaryAssetName()
+	binaryAsset, ok := release.Asset(binaryName)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for this platform (%s)", release.Tag, binaryName)
+	}
+	checksumsAsset, ok := release.Asset(update.ChecksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s", release.Tag, update.ChecksumsAsset)
+	}
+	checksums, err := update.Download(checksumsAsset.URL)
+	if err != nil {
+		return err
+	}
+	if updatePublicKey != "" {
+		signatureAsset, ok := release.Asset(update.SignatureAsset)
+		if !ok {
+			return fmt.Errorf("release %s is not signed", release.Tag)
+		}
+		signature, err := update.Download(signatureAsset.URL)
+		if err != nil {
+			return err
+		}
+		if err := update.VerifySignature(checksums, signature, updatePublicKey); err != nil {
+			return fmt.Errorf("release %s: %w", release.Tag, err)
+		}
+	}
+	binary, err := update.Download(binaryAsset.URL)
+	if err != nil {
+		return err
+	}
+	if err := update.VerifyChecksum(binary, checksums, binaryName); err != nil {
+		return err
+	}
+
+	// Replace the executable, following symlinks to the real file
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the codectx binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	if err := update.ReplaceExecutable(executable, binary); err != nil {
+		return err
+	}
+	fmt.Printf("Updated codectx %s -> %s\n", version, release.Tag)
+	return nil
+}
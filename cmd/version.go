@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at build time with
+//
+//	go build -ldflags "-X codectx/cmd.version=v1.2.3 -X codectx/cmd.commit=$(git rev-parse HEAD) -X codectx/cmd.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "v0.1.0"
+	commit    = ""
+	buildDate = ""
+
+	// updatePublicKey is the base64 Ed25519 key release checksums are signed with.
+	// Self-update refuses releases without a valid signature, and refuses to
+	// install at all without a key unless given --insecure.
+	updatePublicKey = ""
+)
+
+// versionInfo is the output of --version --json
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// currentVersionInfo returns the build information, falling back to the VCS
+// details the Go toolchain embeds when the ldflags were not set
+func currentVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// printVersion prints the version, as JSON when asJSON is set
+func printVersion(asJSON bool) error {
+	info := currentVersionInfo()
+	if asJSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("codectx %s", info.Version)
+	if info.Commit != "" {
+		short := info.Commit
+		if len(short) > 12 {
+			short = short[:12]
+		}
+		fmt.Printf(" (%s", short)
+		if info.BuildDate != "" {
+			fmt.Printf(", %s", info.BuildDate)
+		}
+		fmt.Print(")")
+	}
+	fmt.Println()
+	return nil
+}
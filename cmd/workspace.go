@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"codectx/internal/analysis"
+)
+
+// workspaceFiles narrows the included files (with a leading slash) to the
+// --workspace workspace, the workspaces it depends on, and the files at the
+// root that configure the monorepo
+func (r *runner) workspaceFiles(targetDir string, included []string) ([]string, error) {
+	graph, err := analysis.DetectWorkspaces(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := analysis.FindWorkspace(graph, r.opts.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	closure := graph.DependencyClosure(workspace)
+	if r.opts.Verbose {
+		names := make([]string, len(closure))
+		for i, dependency := range closure {
+			names[i] = dependency.Name
+		}
+		fmt.Fprintf(r.stderr, "Workspace %s: %s\n", workspace.Name, strings.Join(names, ", "))
+	}
+	return withCleanPaths(included, func(paths []string) []string {
+		return analysis.WorkspaceFiles(closure, paths)
+	}), nil
+}
@@ -0,0 +1,200 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// API surface modes
+const (
+	APISurfaceReplace = "replace" // The API replaces the content of the package's Go files
+	APISurfaceAppend  = "append"  // The API is added after the file contents
+)
+
+// ParseAPISurfaceMode validates an --api-surface mode
+func ParseAPISurfaceMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case APISurfaceReplace:
+		return APISurfaceReplace, nil
+	case APISurfaceAppend:
+		return APISurfaceAppend, nil
+	}
+	return "", fmt.Errorf("invalid API surface mode: %s (expected replace or append)", mode)
+}
+
+// APISurface is the exported API of the Go packages in a repository
+type APISurface struct {
+	Packages []APIPackage `json:"packages"`
+	Tokens   int          `json:"estimated_tokens"`
+	Files    []string     `json:"-"` // Go files whose declarations the API covers
+}
+
+// APIPackage is the exported API of one Go package
+type APIPackage struct {
+	Dir   string    `json:"dir"` // Directory relative to the scanned root, with slashes
+	Name  string    `json:"name"`
+	Doc   string    `json:"doc,omitempty"`
+	Decls []APIDecl `json:"decls"`
+}
+
+// APIDecl is an exported declaration: a constant or variable group, a function,
+// or a type with its constructors and method set
+type APIDecl struct {
+	Kind      string    `json:"kind"` // const, var, func, type, or method
+	Name      string    `json:"name"`
+	Doc       string    `json:"doc,omitempty"`
+	Signature string    `json:"signature"` // Declaration without function bodies or unexported fields
+	Methods   []APIDecl `json:"methods,omitempty"`
+}
+
+// BuildAPISurface extracts the exported API of the Go packages among the given
+// files, skipping tests and commands (package main). paths are slash-separated
+// paths relative to rootDir, without a leading slash.
+func BuildAPISurface(rootDir string, paths []string) (*APISurface, error) {
+	type packageKey struct{ dir, name string }
+	fset := token.NewFileSet()
+	files := make(map[packageKey][]*ast.File)
+	filePaths := make(map[packageKey][]string)
+	var keys []packageKey
+
+	for _, relPath := range paths {
+		if !strings.HasSuffix(relPath, ".go") || strings.HasSuffix(relPath, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, platform.JoinSlash(rootDir, relPath), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue // Files that don't parse keep their content
+		}
+		if file.Name.Name == "main" {
+			continue
+		}
+		key := packageKey{dir: path.Dir(relPath), name: file.Name.Name}
+		if _, ok := files[key]; !ok {
+			keys = append(keys, key)
+		}
+		files[key] = append(files[key], file)
+		filePaths[key] = append(filePaths[key], relPath)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dir != keys[j].dir {
+			return keys[i].dir < keys[j].dir
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	surface := &APISurface{Packages: []APIPackage{}}
+	for _, key := range keys {
+		pkg, err := doc.NewFromFiles(fset, files[key], key.dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the API of %s: %w", key.dir, err)
+		}
+		apiPackage := APIPackage{Dir: key.dir, Name: pkg.Name, Doc: strings.TrimSpace(pkg.Doc), Decls: packageDecls(fset, pkg)}
+		surface.Packages = append(surface.Packages, apiPackage)
+		surface.Files = append(surface.Files, filePaths[key]...)
+		surface.Tokens += estimateAPITokens(apiPackage)
+	}
+	return surface, nil
+}
+
+// packageDecls lists the exported declarations of a package in the order of go
+// doc: constants, variables, functions, then types with their own constants,
+// variables, constructors, and methods
+func packageDecls(fset *token.FileSet, pkg *doc.Package) []APIDecl {
+	var decls []APIDecl
+	decls = append(decls, valueDecls(fset, pkg.Consts, "const")...)
+	decls = append(decls, valueDecls(fset, pkg.Vars, "var")...)
+	for _, fn := range pkg.Funcs {
+		decls = append(decls, funcDecl(fset, fn, "func"))
+	}
+	for _, typ := range pkg.Types {
+		typ.Decl.Doc = nil
+		decl := APIDecl{Kind: "type", Name: typ.Name, Doc: strings.TrimSpace(typ.Doc), Signature: printNode(fset, typ.Decl)}
+		for _, method := range typ.Methods {
+			decl.Methods = append(decl.Methods, funcDecl(fset, method, "method"))
+		}
+		decls = append(decls, decl)
+		decls = append(decls, valueDecls(fset, typ.Consts, "const")...)
+		decls = append(decls, valueDecls(fset, typ.Vars, "var")...)
+		for _, fn := range typ.Funcs {
+			decls = append(decls, funcDecl(fset, fn, "func"))
+		}
+	}
+	return decls
+}
+
+// valueDecls returns constant or variable groups
+func valueDecls(fset *token.FileSet, values []*doc.Value, kind string) []APIDecl {
+	decls := make([]APIDecl, 0, len(values))
+	for _, value := range values {
+		value.Decl.Doc = nil
+		decls = append(decls, APIDecl{Kind: kind, Name: strings.Join(value.Names, ", "), Doc: strings.TrimSpace(value.Doc), Signature: printNode(fset, value.Decl)})
+	}
+	return decls
+}
+
+// funcDecl returns a function or method without its body
+func funcDecl(fset *token.FileSet, fn *doc.Func, kind string) APIDecl {
+	fn.Decl.Doc = nil
+	fn.Decl.Body = nil
+	return APIDecl{Kind: kind, Name: fn.Name, Doc: strings.TrimSpace(fn.Doc), Signature: printNode(fset, fn.Decl)}
+}
+
+// printNode prints a declaration as Go source
+func printNode(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Source renders the package API as Go source without function bodies, with the
+// doc comments above their declarations
+func (p APIPackage) Source() string {
+	var b strings.Builder
+	writeDocComment(&b, p.Doc)
+	fmt.Fprintf(&b, "package %s\n", p.Name)
+	for _, decl := range p.Decls {
+		b.WriteString("\n")
+		writeDocComment(&b, decl.Doc)
+		b.WriteString(decl.Signature)
+		b.WriteString("\n")
+		for _, method := range decl.Methods {
+			b.WriteString("\n")
+			writeDocComment(&b, method.Doc)
+			b.WriteString(method.Signature)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// writeDocComment writes doc as // comment lines
+func writeDocComment(b *strings.Builder, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		if line == "" {
+			b.WriteString("//\n")
+		} else {
+			b.WriteString("// " + line + "\n")
+		}
+	}
+}
+
+// estimateAPITokens estimates the tokens of a package's API (about 4 characters per token)
+func estimateAPITokens(pkg APIPackage) int {
+	return (len(pkg.Dir) + len(pkg.Source()) + 3) / 4
+}
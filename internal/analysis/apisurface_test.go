@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildAPISurface(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_api_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"shapes/shapes.go": `// Package shapes computes areas.
+package shapes
+
+// Shape has an area.
+type Shape interface {
+	Area() float64
+}
+
+// Square is a square.
+type Square struct {
+	Side  float64
+	cache float64
+}
+
+// NewSquare returns a square with the given side.
+func NewSquare(side float64) *Square {
+	return &Square{Side: side}
+}
+
+// Area returns the area of the square.
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func (s *Square) reset() {}
+
+const unit = 1
+`,
+		"shapes/shapes_test.go": "package shapes\n\nfunc TestArea() {}\n",
+		"main.go":               "package main\n\nfunc Run() {}\n",
+		"broken/broken.go":      "package broken\n\nfunc {\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	surface, err := BuildAPISurface(tempDir, []string{"broken/broken.go", "main.go", "shapes/shapes.go", "shapes/shapes_test.go"})
+	if err != nil {
+		t.Fatalf("BuildAPISurface failed: %v", err)
+	}
+	if len(surface.Packages) != 1 {
+		t.Fatalf("Expected 1 package, got %d", len(surface.Packages))
+	}
+	if len(surface.Files) != 1 || surface.Files[0] != "shapes/shapes.go" {
+		t.Errorf("Expected the API to cover shapes/shapes.go, got %v", surface.Files)
+	}
+
+	pkg := surface.Packages[0]
+	if pkg.Dir != "shapes" || pkg.Name != "shapes" || pkg.Doc != "Package shapes computes areas." {
+		t.Errorf("Unexpected package: %+v", pkg)
+	}
+
+	source := pkg.Source()
+	for _, want := range []string{
+		"// Package shapes computes areas.\npackage shapes\n",
+		"// NewSquare returns a square with the given side.\nfunc NewSquare(side float64) *Square\n",
+		"// Area returns the area of the square.\nfunc (s *Square) Area() float64\n",
+		"Side float64",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("Expected the API to contain %q, got:\n%s", want, source)
+		}
+	}
+	for _, unwanted := range []string{"cache", "reset", "unit", "s.Side * s.Side"} {
+		if strings.Contains(source, unwanted) {
+			t.Errorf("Expected the API not to contain %q, got:\n%s", unwanted, source)
+		}
+	}
+}
+
+func TestParseAPISurfaceMode(t *testing.T) {
+	if mode, err := ParseAPISurfaceMode("Append"); err != nil || mode != APISurfaceAppend {
+		t.Errorf("Expected append, got %q (%v)", mode, err)
+	}
+	if _, err := ParseAPISurfaceMode("inline"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
@@ -1,12 +1,15 @@
 package analysis
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"codectx/internal/language"
+	"codectx/internal/utils"
 )
 
 // ComplexityAnalysis represents the complexity analysis results for a project
@@ -65,15 +68,17 @@ func AnalyzeProjectComplexity(rootDir string) (*ComplexityAnalysis, error) {
 			return nil
 		}
 
-		// Get file extension
+		// Get file extension, or the name of a special file such as a Dockerfile
 		ext := strings.ToLower(filepath.Ext(path))
-		if ext == "" {
+		if language.IsSpecialFile(path) {
+			ext = filepath.Base(path)
+		} else if ext == "" {
 			return nil
+		} else {
+			// Remove the leading dot
+			ext = ext[1:]
 		}
 
-		// Remove the leading dot
-		ext = ext[1:]
-
 		// Analyze file complexity
 		fileMetrics, err := analyzeFileComplexity(path, ext)
 		if err != nil {
@@ -145,31 +150,31 @@ func AnalyzeProjectComplexity(rootDir string) (*ComplexityAnalysis, error) {
 }
 
 // PrintComplexityAnalysis prints the complexity analysis results
-func PrintComplexityAnalysis(analysis *ComplexityAnalysis) {
-	fmt.Println("\nComplexity Analysis:")
-	fmt.Println("===================")
+func PrintComplexityAnalysis(analysis *ComplexityAnalysis, w io.Writer) {
+	fmt.Fprintln(w, "\nComplexity Analysis:")
+	fmt.Fprintln(w, "===================")
 
-	fmt.Println("\nCode Metrics:")
-	fmt.Printf("  Total lines: %d\n", analysis.TotalLines)
-	fmt.Printf("  Code lines: %d (%.1f%%)\n", analysis.CodeLines, float64(analysis.CodeLines)/float64(analysis.TotalLines)*100)
-	fmt.Printf("  Comment lines: %d (%.1f%%)\n", analysis.CommentLines, float64(analysis.CommentLines)/float64(analysis.TotalLines)*100)
-	fmt.Printf("  Blank lines: %d (%.1f%%)\n", analysis.BlankLines, float64(analysis.BlankLines)/float64(analysis.TotalLines)*100)
-	fmt.Printf("  Code density: %.1f%%\n", analysis.CodeDensity)
+	fmt.Fprintln(w, "\nCode Metrics:")
+	fmt.Fprintf(w, "  Total lines: %d\n", analysis.TotalLines)
+	fmt.Fprintf(w, "  Code lines: %d (%.1f%%)\n", analysis.CodeLines, float64(analysis.CodeLines)/float64(analysis.TotalLines)*100)
+	fmt.Fprintf(w, "  Comment lines: %d (%.1f%%)\n", analysis.CommentLines, float64(analysis.CommentLines)/float64(analysis.TotalLines)*100)
+	fmt.Fprintf(w, "  Blank lines: %d (%.1f%%)\n", analysis.BlankLines, float64(analysis.BlankLines)/float64(analysis.TotalLines)*100)
+	fmt.Fprintf(w, "  Code density: %.1f%%\n", analysis.CodeDensity)
 
 	// Print language metrics
 	if len(analysis.LanguageMetrics) > 0 {
-		fmt.Println("\nLanguage Distribution:")
+		fmt.Fprintln(w, "\nLanguage Distribution:")
 		for lang, metrics := range analysis.LanguageMetrics {
-			fmt.Printf("  %s: %d files (%.1f%%) - %d lines\n",
+			fmt.Fprintf(w, "  %s: %d files (%.1f%%) - %d lines\n",
 				lang, metrics.Files, metrics.Percentage, metrics.Lines)
 		}
 	}
 
 	// Print complex files
 	if len(analysis.ComplexFiles) > 0 {
-		fmt.Println("\nComplex Files:")
+		fmt.Fprintln(w, "\nComplex Files:")
 		for _, file := range analysis.ComplexFiles {
-			fmt.Printf("  %s: %d lines, complexity score: %.1f\n",
+			fmt.Fprintf(w, "  %s: %d lines, complexity score: %.1f\n",
 				file.Path, file.Lines, file.ComplexityScore)
 		}
 	}
@@ -196,18 +201,13 @@ func analyzeFileComplexity(path, ext string) (*FileMetrics, error) {
 
 	metrics := &FileMetrics{}
 
-	// Define comment patterns based on file extension
-	var lineCommentPattern, blockCommentStartPattern, blockCommentEndPattern string
+	// Define comment patterns based on the language
+	lang, _ := language.Detect(path)
+	lineCommentPattern := lang.LineComment
+	var blockCommentStartPattern, blockCommentEndPattern string
 
 	switch ext {
-	case "go", "c", "cpp", "java", "js", "ts", "cs", "php", "swift":
-		lineCommentPattern = "//"
-		blockCommentStartPattern = "/*"
-		blockCommentEndPattern = "*/"
-	case "py", "rb", "sh", "bash":
-		lineCommentPattern = "#"
-	case "sql":
-		lineCommentPattern = "--"
+	case "go", "c", "cpp", "java", "js", "ts", "cs", "php", "swift", "sql":
 		blockCommentStartPattern = "/*"
 		blockCommentEndPattern = "*/"
 	case "html", "xml":
@@ -221,7 +221,7 @@ func analyzeFileComplexity(path, ext string) (*FileMetrics, error) {
 
 	inBlockComment := false
 
-	scanner := bufio.NewScanner(file)
+	scanner := utils.NewLineReader(file, 0)
 	for scanner.Scan() {
 		line := scanner.Text()
 		metrics.Lines++
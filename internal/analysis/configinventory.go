@@ -0,0 +1,351 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/language"
+	"codectx/internal/platform"
+)
+
+// maxConfigFileSize is the size above which files are not searched for
+// configuration
+const maxConfigFileSize = 1024 * 1024
+
+// Limits of the configuration inventory printed in the stats
+const (
+	maxEnvReferencesShown = 3
+	maxConfigKeysShown    = 50
+)
+
+// envPatterns match reads of environment variables, capturing the name
+var envPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bos\.(?:Getenv|LookupEnv)\(\s*"([^"]+)"`),             // Go
+	regexp.MustCompile(`\bos\.environ(?:\.get\(|\[)\s*['"]([^'"]+)['"]`),       // Python
+	regexp.MustCompile(`\bgetenv\(\s*['"]([^'"]+)['"]`),                        // Python, PHP, C, Java
+	regexp.MustCompile(`\b(?:process|import\.meta)\.env\.([A-Za-z_]\w*)`),      // JavaScript
+	regexp.MustCompile(`\b(?:process|import\.meta)\.env\[\s*['"]([^'"]+)['"]`), // JavaScript
+	regexp.MustCompile(`\bENV(?:\.fetch\(\s*|\[\s*)['"]([^'"]+)['"]`),          // Ruby
+	regexp.MustCompile(`\benv::var(?:_os)?\(\s*"([^"]+)"`),                     // Rust
+	regexp.MustCompile(`\bEnvironment\.GetEnvironmentVariable\(\s*"([^"]+)"`),  // C#
+}
+
+// dotenvPattern matches a variable set in a .env file, capturing the name
+var dotenvPattern = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_]\w*)\s*=`)
+
+var (
+	// yamlKeyPattern matches a YAML mapping key, capturing its indentation, the
+	// key, and the value
+	yamlKeyPattern = regexp.MustCompile(`^(\s*)("[^"]+"|'[^']+'|[^\s#'"{}\[\],&*!|>%@` + "`" + `-][^:#]*?)\s*:(?:\s+(.*))?$`)
+	// tomlTablePattern matches a TOML table header, capturing its name
+	tomlTablePattern = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?\s*(?:#.*)?$`)
+	// tomlKeyPattern matches a TOML key/value pair, capturing the key
+	tomlKeyPattern = regexp.MustCompile(`^\s*("[^"]+"|[A-Za-z0-9_.\-]+)\s*=`)
+)
+
+// configDirs are the names of directories holding configuration files
+var configDirs = map[string]bool{"config": true, "configs": true, "conf": true, "settings": true}
+
+// configNameWords are words in the names of configuration files
+var configNameWords = []string{"config", "settings", "application", "appsettings"}
+
+// ConfigInventory is the configuration surface of a project: the environment
+// variables it reads and the keys of its configuration files
+type ConfigInventory struct {
+	EnvVars     []EnvVar     `json:"env_vars"`     // By name
+	ConfigFiles []ConfigFile `json:"config_files"` // By path
+}
+
+// EnvVar is an environment variable and where it is read or set
+type EnvVar struct {
+	Name       string         `json:"name"`
+	References []EnvReference `json:"references"`
+}
+
+// EnvReference is a line reading or setting an environment variable
+type EnvReference struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+}
+
+// ConfigFile is a configuration file and its keys
+type ConfigFile struct {
+	Path   string   `json:"path"`
+	Format string   `json:"format"` // json, yaml, or toml
+	Keys   []string `json:"keys"`   // Dotted paths of the leaf keys, in order of appearance
+}
+
+// FindConfigInventory finds the environment variables read by the source files
+// among paths, other than tests, and set in .env files, and the keys of the
+// JSON, YAML, and TOML configuration files among them: those under a config
+// directory or named after config, settings, or application, other than
+// tsconfig and jsconfig. Only names are recorded, never values. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func FindConfigInventory(rootDir string, paths []string) (*ConfigInventory, error) {
+	inventory := &ConfigInventory{EnvVars: []EnvVar{}, ConfigFiles: []ConfigFile{}}
+	references := make(map[string][]EnvReference)
+	for _, relPath := range paths {
+		lang, _ := language.Detect(relPath)
+		var patterns []*regexp.Regexp
+		format := ""
+		switch {
+		case lang.ID == "dotenv" || strings.HasPrefix(strings.ToLower(path.Base(relPath)), ".env."):
+			lang.LineComment = "#" // Variants such as .env.example
+			patterns = []*regexp.Regexp{dotenvPattern}
+		case (lang.Class == language.ClassCode || lang.Class == language.ClassScript) && !IsTestFile(relPath):
+			patterns = envPatterns
+		case (lang.ID == "json" || lang.ID == "yaml" || lang.ID == "toml") && isConfigFile(relPath):
+			format = lang.ID
+		default:
+			continue
+		}
+
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		info, err := os.Stat(fullPath)
+		if err != nil || info.Size() > maxConfigFileSize {
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+
+		if format != "" {
+			if keys := configKeys(format, content); len(keys) > 0 {
+				inventory.ConfigFiles = append(inventory.ConfigFiles, ConfigFile{Path: relPath, Format: format, Keys: keys})
+			}
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			if lang.LineComment != "" && strings.HasPrefix(strings.TrimSpace(line), lang.LineComment) {
+				continue
+			}
+			seen := make(map[string]bool)
+			for _, pattern := range patterns {
+				for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+					if !seen[m[1]] {
+						seen[m[1]] = true
+						references[m[1]] = append(references[m[1]], EnvReference{Path: relPath, Line: i + 1})
+					}
+				}
+			}
+		}
+	}
+
+	for name, refs := range references {
+		inventory.EnvVars = append(inventory.EnvVars, EnvVar{Name: name, References: refs})
+	}
+	sort.Slice(inventory.EnvVars, func(i, j int) bool {
+		return inventory.EnvVars[i].Name < inventory.EnvVars[j].Name
+	})
+	sort.Slice(inventory.ConfigFiles, func(i, j int) bool {
+		return inventory.ConfigFiles[i].Path < inventory.ConfigFiles[j].Path
+	})
+	return inventory, nil
+}
+
+// isConfigFile reports whether a data file likely configures the project
+// rather than a tool
+func isConfigFile(relPath string) bool {
+	name := strings.ToLower(path.Base(relPath))
+	if strings.HasPrefix(name, "tsconfig") || strings.HasPrefix(name, "jsconfig") {
+		return false
+	}
+	for _, word := range configNameWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	for _, dir := range strings.Split(path.Dir(relPath), "/") {
+		if configDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	return false
+}
+
+// configKeys returns the dotted paths of the leaf keys of a configuration
+// file, without duplicates, or nil when it has none or doesn't parse
+func configKeys(format string, content []byte) []string {
+	var keys []string
+	switch format {
+	case "json":
+		var value any
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil
+		}
+		keys = jsonKeys("", value)
+	case "yaml":
+		keys = yamlKeys(content)
+	case "toml":
+		keys = tomlKeys(content)
+	}
+
+	seen := make(map[string]bool)
+	unique := keys[:0]
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// jsonKeys returns the dotted paths of the leaf keys of a JSON value, with
+// object keys in sorted order and arrays as leaves
+func jsonKeys(prefix string, value any) []string {
+	object, ok := value.(map[string]any)
+	if !ok || len(object) == 0 {
+		if prefix == "" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []string
+	for _, name := range names {
+		keys = append(keys, jsonKeys(joinKey(prefix, name), object[name])...)
+	}
+	return keys
+}
+
+// yamlKeys returns the dotted paths of the leaf keys of a YAML document,
+// following indentation. Keys in lists and the lines of block scalars are
+// skipped.
+func yamlKeys(content []byte) []string {
+	type parent struct {
+		indent   int
+		key      string
+		hasChild bool
+	}
+	var keys []string
+	var parents []parent
+	// pop closes the parents indented at least as deep as indent, keeping
+	// those without children as leaves
+	pop := func(indent int) {
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			if last := parents[len(parents)-1]; !last.hasChild {
+				keys = append(keys, last.key)
+			}
+			parents = parents[:len(parents)-1]
+		}
+	}
+
+	// Lines indented deeper than these are in a block scalar or list item
+	blockIndent, listIndent := -1, -1
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := indentation(line)
+		if blockIndent >= 0 && indent > blockIndent || listIndent >= 0 && indent > listIndent {
+			continue
+		}
+		blockIndent, listIndent = -1, -1
+		if trimmed == "---" {
+			pop(0)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "-") {
+			listIndent = indent
+			continue
+		}
+
+		m := yamlKeyPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue // Flow values
+		}
+		pop(indent)
+		key := strings.Trim(m[2], `"'`)
+		if len(parents) > 0 {
+			parents[len(parents)-1].hasChild = true
+			key = joinKey(parents[len(parents)-1].key, key)
+		}
+		value := strings.TrimSpace(codeOnly(m[3], "#"))
+		switch {
+		case value == "":
+			parents = append(parents, parent{indent: indent, key: key})
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			blockIndent = indent
+			keys = append(keys, key)
+		default:
+			keys = append(keys, key)
+		}
+	}
+	pop(0)
+	return keys
+}
+
+// tomlKeys returns the dotted paths of the keys of a TOML document, prefixed
+// by their table
+func tomlKeys(content []byte) []string {
+	var keys []string
+	table := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		if m := tomlTablePattern.FindStringSubmatch(line); m != nil {
+			table = m[1]
+			continue
+		}
+		if m := tomlKeyPattern.FindStringSubmatch(line); m != nil {
+			keys = append(keys, joinKey(table, strings.Trim(m[1], `"`)))
+		}
+	}
+	return keys
+}
+
+// joinKey joins a key to the dotted path of its parent
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// PrintConfigInventory prints the environment variables and configuration keys
+func PrintConfigInventory(inventory *ConfigInventory, w io.Writer) {
+	fmt.Fprintln(w, "\nConfiguration Inventory:")
+	fmt.Fprintln(w, "========================")
+	if len(inventory.EnvVars) == 0 && len(inventory.ConfigFiles) == 0 {
+		fmt.Fprintln(w, "  No environment variables or configuration files found")
+		return
+	}
+
+	if len(inventory.EnvVars) > 0 {
+		fmt.Fprintf(w, "Environment variables (%d):\n", len(inventory.EnvVars))
+		for _, envVar := range inventory.EnvVars {
+			var locations []string
+			for i, ref := range envVar.References {
+				if i == maxEnvReferencesShown {
+					locations = append(locations, fmt.Sprintf("+%d more", len(envVar.References)-maxEnvReferencesShown))
+					break
+				}
+				locations = append(locations, fmt.Sprintf("%s:%d", ref.Path, ref.Line))
+			}
+			fmt.Fprintf(w, "  %s (%s)\n", envVar.Name, strings.Join(locations, ", "))
+		}
+	}
+
+	for _, file := range inventory.ConfigFiles {
+		fmt.Fprintf(w, "%s (%s, %s):\n", file.Path, strings.ToUpper(file.Format), plural(len(file.Keys), "key"))
+		for i, key := range file.Keys {
+			if i == maxConfigKeysShown {
+				fmt.Fprintf(w, "  ... and %d more\n", len(file.Keys)-maxConfigKeysShown)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", key)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindConfigInventory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_config_inventory_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go": `package main
+
+func main() {
+	// os.Getenv("COMMENTED_OUT")
+	url := os.Getenv("DATABASE_URL")
+	if _, ok := os.LookupEnv("DEBUG"); ok {
+	}
+}
+`,
+		"main_test.go":  "package main\n\nvar _ = os.Getenv(\"TEST_ONLY\")\n",
+		"web/server.js": "const port = process.env.PORT || process.env['PORT'];\nconst key = import.meta.env.VITE_KEY;\n",
+		"app.py":        "import os\nhost = os.environ.get('HOST', 'localhost')\nport = os.environ['PORT']\n",
+		".env.example":  "# Settings\nDATABASE_URL=postgres://localhost\nexport SECRET=changeme\n",
+		"config/app.yaml": `# Application settings
+server:
+  host: localhost
+  port: 8080
+  tls:
+features:
+  - name: beta
+    enabled: true
+banner: |
+  welcome: to the app
+log_level: info
+`,
+		"settings.toml": `title = "app"
+
+[database]
+url = "postgres://localhost"
+"pool size" = 5
+
+[[workers]]
+name = "mail"
+`,
+		"config.json":   `{"cache": {"ttl": 60, "backends": ["redis"]}, "debug": false}`,
+		"tsconfig.json": `{"compilerOptions": {"strict": true}}`,
+		"package.json":  `{"name": "app"}`,
+	}
+	var paths []string
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+
+	inventory, err := FindConfigInventory(tempDir, paths)
+	if err != nil {
+		t.Fatalf("FindConfigInventory failed: %v", err)
+	}
+
+	envVars := make(map[string]int)
+	for _, envVar := range inventory.EnvVars {
+		envVars[envVar.Name] = len(envVar.References)
+	}
+	expectedEnvVars := map[string]int{"DATABASE_URL": 2, "DEBUG": 1, "HOST": 1, "PORT": 2, "SECRET": 1, "VITE_KEY": 1}
+	if !reflect.DeepEqual(envVars, expectedEnvVars) {
+		t.Errorf("Expected environment variables %v, got %v", expectedEnvVars, envVars)
+	}
+	for i := 1; i < len(inventory.EnvVars); i++ {
+		if inventory.EnvVars[i-1].Name > inventory.EnvVars[i].Name {
+			t.Errorf("Expected environment variables sorted by name, got %+v", inventory.EnvVars)
+			break
+		}
+	}
+
+	expectedFiles := []ConfigFile{
+		{Path: "config.json", Format: "json", Keys: []string{"cache.backends", "cache.ttl", "debug"}},
+		{Path: "config/app.yaml", Format: "yaml", Keys: []string{"server.host", "server.port", "server.tls", "features", "banner", "log_level"}},
+		{Path: "settings.toml", Format: "toml", Keys: []string{"title", "database.url", "database.pool size", "workers.name"}},
+	}
+	if !reflect.DeepEqual(inventory.ConfigFiles, expectedFiles) {
+		t.Errorf("Expected config files %+v, got %+v", expectedFiles, inventory.ConfigFiles)
+	}
+}
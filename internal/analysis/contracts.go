@@ -0,0 +1,281 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// Kinds of API contracts
+const (
+	ContractOpenAPI  = "openapi"  // OpenAPI or Swagger document
+	ContractAsyncAPI = "asyncapi" // AsyncAPI document
+	ContractProto    = "protobuf" // Protocol Buffers definitions
+	ContractGraphQL  = "graphql"  // GraphQL schema
+	ContractThrift   = "thrift"   // Thrift IDL
+)
+
+// contractSniffSize is how much of a YAML or JSON file is read to tell whether
+// it is an API document
+const contractSniffSize = 4096
+
+// maxContractFileSize is the size above which contracts are not read for operations
+const maxContractFileSize = 1024 * 1024
+
+// contractExtensions are the extensions of interface definition files
+var contractExtensions = map[string]string{
+	".proto":    ContractProto,
+	".graphql":  ContractGraphQL,
+	".graphqls": ContractGraphQL,
+	".gql":      ContractGraphQL,
+	".thrift":   ContractThrift,
+}
+
+// httpMethods are the operations of an OpenAPI path item, in display order
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var (
+	// apiDocumentPattern matches the version field opening an OpenAPI, Swagger,
+	// or AsyncAPI document, capturing its name
+	apiDocumentPattern = regexp.MustCompile(`(?m)^(openapi|swagger|asyncapi):|"(openapi|swagger|asyncapi)"\s*:\s*"`)
+	// protoOperationPattern matches the services, RPCs, messages, and enums of a .proto file
+	protoOperationPattern = regexp.MustCompile(`^\s*(?:service|rpc|message|enum)\s+\w`)
+	// graphqlDefinitionPattern matches a top-level GraphQL definition, capturing
+	// the type name
+	graphqlDefinitionPattern = regexp.MustCompile(`^(?:extend\s+)?(?:type|input|enum|interface|union|scalar|schema|directive)\b\s*@?(\w*)`)
+	// thriftDefinitionPattern matches a top-level Thrift definition
+	thriftDefinitionPattern = regexp.MustCompile(`^\s*(?:service|struct|enum|exception|union)\s+\w`)
+)
+
+// Contract is an API contract file and the operations it defines
+type Contract struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	// Operations such as "GET /users", "rpc GetUser(GetUserRequest) returns (User)",
+	// or "Query.user(id: ID!): User"
+	Operations []string `json:"operations"`
+}
+
+// ContractKind returns the kind of API contract a file is, or "" if it isn't
+// one: a .proto, GraphQL, or Thrift file, or a YAML or JSON file named after
+// OpenAPI, Swagger, or AsyncAPI or starting with their version field. relPath
+// is a slash-separated path relative to rootDir.
+func ContractKind(rootDir, relPath string) string {
+	ext := strings.ToLower(path.Ext(relPath))
+	if kind, ok := contractExtensions[ext]; ok {
+		return kind
+	}
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return ""
+	}
+	name := strings.ToLower(path.Base(relPath))
+	switch {
+	case strings.HasPrefix(name, "openapi"), strings.HasPrefix(name, "swagger"):
+		return ContractOpenAPI
+	case strings.HasPrefix(name, "asyncapi"):
+		return ContractAsyncAPI
+	}
+
+	file, err := os.Open(platform.JoinSlash(rootDir, relPath))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	head := make([]byte, contractSniffSize)
+	n, _ := io.ReadFull(file, head)
+	m := apiDocumentPattern.FindSubmatch(head[:n])
+	if m == nil {
+		return ""
+	}
+	if string(m[1]) == "asyncapi" || string(m[2]) == "asyncapi" {
+		return ContractAsyncAPI
+	}
+	return ContractOpenAPI
+}
+
+// ContractsFirst returns the paths with the API contracts moved to the front,
+// keeping the order within each group
+func ContractsFirst(rootDir string, paths []string) []string {
+	var contracts, others []string
+	for _, relPath := range paths {
+		if ContractKind(rootDir, relPath) != "" {
+			contracts = append(contracts, relPath)
+		} else {
+			others = append(others, relPath)
+		}
+	}
+	return slices.Concat(contracts, others)
+}
+
+// FindContracts returns the API contracts among paths with the operations each
+// defines: the HTTP operations of OpenAPI documents, the channels of AsyncAPI
+// documents, the services, RPCs, and messages of .proto files, the type
+// definitions and root fields of GraphQL schemas, and the definitions of Thrift
+// files. paths are slash-separated paths relative to rootDir, without a
+// leading slash.
+func FindContracts(rootDir string, paths []string) []Contract {
+	contracts := []Contract{}
+	for _, relPath := range paths {
+		kind := ContractKind(rootDir, relPath)
+		if kind == "" {
+			continue
+		}
+		contract := Contract{Path: relPath, Kind: kind, Operations: []string{}}
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		if info, err := os.Stat(fullPath); err == nil && info.Size() <= maxContractFileSize {
+			if content, err := os.ReadFile(fullPath); err == nil {
+				contract.Operations = contractOperations(kind, path.Ext(relPath), content)
+			}
+		}
+		contracts = append(contracts, contract)
+	}
+	return contracts
+}
+
+// contractOperations lists the operations of a contract
+func contractOperations(kind, ext string, content []byte) []string {
+	operations := []string{}
+	switch kind {
+	case ContractOpenAPI, ContractAsyncAPI:
+		section := "paths"
+		if kind == ContractAsyncAPI {
+			section = "channels"
+		}
+		var items map[string][]string
+		if strings.EqualFold(ext, ".json") {
+			items = jsonSectionItems(content, section)
+		} else {
+			items = yamlSectionItems(content, section)
+		}
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if kind == ContractAsyncAPI {
+				operations = append(operations, "channel "+key)
+				continue
+			}
+			for _, method := range httpMethods {
+				if slices.Contains(items[key], method) {
+					operations = append(operations, strings.ToUpper(method)+" "+key)
+				}
+			}
+		}
+	case ContractProto, ContractThrift:
+		pattern := protoOperationPattern
+		if kind == ContractThrift {
+			pattern = thriftDefinitionPattern
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if pattern.MatchString(line) {
+				operations = append(operations, definitionText(line))
+			}
+		}
+	case ContractGraphQL:
+		operations = graphqlOperations(content)
+	}
+	return operations
+}
+
+// definitionText trims a definition line of its body and trailing comment
+func definitionText(line string) string {
+	line = codeOnly(line, "//")
+	if i := strings.IndexAny(line, "{;"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+// graphqlOperations lists the type definitions of a GraphQL schema and the
+// fields of its Query, Mutation, and Subscription types
+func graphqlOperations(content []byte) []string {
+	var operations []string
+	rootType := "" // The root type whose fields are being read
+	depth := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		code := codeOnly(line, "#")
+		trimmed := strings.TrimSpace(code)
+		if depth == 0 {
+			if m := graphqlDefinitionPattern.FindStringSubmatch(trimmed); m != nil {
+				operations = append(operations, definitionText(trimmed))
+				rootType = ""
+				if m[1] == "Query" || m[1] == "Mutation" || m[1] == "Subscription" {
+					rootType = m[1]
+				}
+			}
+		} else if depth == 1 && rootType != "" && trimmed != "" && trimmed != "}" && !strings.HasPrefix(trimmed, `"`) {
+			operations = append(operations, rootType+"."+trimmed)
+		}
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		depth = max(depth, 0)
+	}
+	return operations
+}
+
+// jsonSectionItems returns the keys of a top-level object of a JSON document,
+// each with the keys of its value
+func jsonSectionItems(content []byte, section string) map[string][]string {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil
+	}
+	var entries map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(document[section], &entries); err != nil {
+		return nil
+	}
+	items := make(map[string][]string, len(entries))
+	for key, value := range entries {
+		items[key] = []string{}
+		for child := range value {
+			items[key] = append(items[key], strings.ToLower(child))
+		}
+	}
+	return items
+}
+
+// yamlSectionItems returns the keys of a top-level mapping of a YAML document,
+// each with the keys of its value, following indentation
+func yamlSectionItems(content []byte, section string) map[string][]string {
+	items := make(map[string][]string)
+	inSection := false
+	itemIndent, childIndent := -1, -1
+	current := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := indentation(line)
+		if indent == 0 {
+			inSection = strings.HasPrefix(trimmed, section+":")
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		m := yamlKeyPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key := strings.Trim(m[2], `"'`)
+		switch {
+		case itemIndent < 0 || indent == itemIndent:
+			itemIndent, childIndent = indent, -1
+			current = key
+			items[current] = []string{}
+		case indent > itemIndent && (childIndent < 0 || indent == childIndent):
+			childIndent = indent
+			items[current] = append(items[current], strings.ToLower(key))
+		}
+	}
+	return items
+}
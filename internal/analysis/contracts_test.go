@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindContracts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_contracts_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"api/spec.yaml": `openapi: 3.1.0
+info:
+  title: Users
+paths:
+  /users:
+    get:
+      summary: List users
+    post:
+      summary: Create a user
+  "/users/{id}":
+    parameters: []
+    delete:
+      summary: Delete a user
+components:
+  schemas: {}
+`,
+		"swagger.json": `{"swagger": "2.0", "paths": {"/health": {"get": {}}}}`,
+		"events.yaml":  "asyncapi: 2.6.0\nchannels:\n  user/signedup:\n    subscribe: {}\n",
+		"proto/users.proto": `syntax = "proto3";
+
+service Users {
+  rpc GetUser(GetUserRequest) returns (User); // Fetch one user
+}
+
+message User {
+  string id = 1;
+}
+`,
+		"schema.graphql": `# The root query
+type Query {
+  "Find a user"
+  user(id: ID!): User
+}
+
+type User {
+  id: ID!
+}
+`,
+		"config.yaml": "server:\n  port: 8080\n",
+		"main.go":     "package main\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	paths := []string{"api/spec.yaml", "config.yaml", "events.yaml", "main.go", "proto/users.proto", "schema.graphql", "swagger.json"}
+
+	expected := []Contract{
+		{Path: "api/spec.yaml", Kind: ContractOpenAPI, Operations: []string{"GET /users", "POST /users", "DELETE /users/{id}"}},
+		{Path: "events.yaml", Kind: ContractAsyncAPI, Operations: []string{"channel user/signedup"}},
+		{Path: "proto/users.proto", Kind: ContractProto, Operations: []string{"service Users", "rpc GetUser(GetUserRequest) returns (User)", "message User"}},
+		{Path: "schema.graphql", Kind: ContractGraphQL, Operations: []string{"type Query", "Query.user(id: ID!): User", "type User"}},
+		{Path: "swagger.json", Kind: ContractOpenAPI, Operations: []string{"GET /health"}},
+	}
+	if got := FindContracts(tempDir, paths); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	expectedOrder := []string{"api/spec.yaml", "events.yaml", "proto/users.proto", "schema.graphql", "swagger.json", "config.yaml", "main.go"}
+	if got := ContractsFirst(tempDir, paths); !reflect.DeepEqual(got, expectedOrder) {
+		t.Errorf("Expected %v, got %v", expectedOrder, got)
+	}
+}
@@ -0,0 +1,219 @@
+package analysis
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// Dead file modes
+const (
+	DeadFilesReport  = "report"  // Dead files are listed in the stats and JSON metadata
+	DeadFilesExclude = "exclude" // Dead files are also left out of the output
+)
+
+// Kinds of dead files
+const (
+	DeadPackage = "package" // A Go package no other package imports
+	DeadAsset   = "asset"   // An image, font, or media file no other file mentions
+)
+
+// maxReferenceFileSize is the size above which files are not searched for
+// references to assets
+const maxReferenceFileSize = 1024 * 1024
+
+// assetExtensions are the extensions of files that are only used when another
+// file refers to them by name
+var assetExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true,
+	".svg": true, ".ico": true, ".bmp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp3": true, ".wav": true, ".ogg": true, ".mp4": true, ".webm": true,
+}
+
+// goEmbedPattern matches a //go:embed directive and its patterns
+var goEmbedPattern = regexp.MustCompile(`(?m)^//go:embed\s+(.+)$`)
+
+// DeadFile is a file, or the files of a Go package, that nothing else in the
+// repository uses
+type DeadFile struct {
+	Path  string   `json:"path"` // The asset, or the directory of the package
+	Kind  string   `json:"kind"` // DeadPackage or DeadAsset
+	Files []string `json:"files"`
+}
+
+// ParseDeadFilesMode validates a --dead-files mode
+func ParseDeadFilesMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case DeadFilesReport:
+		return DeadFilesReport, nil
+	case DeadFilesExclude:
+		return DeadFilesExclude, nil
+	}
+	return "", fmt.Errorf("invalid dead files mode: %s (expected report or exclude)", mode)
+}
+
+// FindDeadFiles finds the Go packages that no other package imports and the
+// assets that no other file mentions by name or embeds, among paths.
+// Commands (package main), the root package of each module, and packages
+// outside a module are never dead, since they are used from outside the
+// repository. paths are slash-separated paths relative to rootDir, without a
+// leading slash.
+func FindDeadFiles(rootDir string, paths []string) []DeadFile {
+	known := make(map[string]bool, len(paths))
+	for _, relPath := range paths {
+		known[relPath] = true
+	}
+
+	dead := deadPackages(rootDir, paths, known)
+	for _, asset := range orphanedAssets(rootDir, paths) {
+		dead = append(dead, DeadFile{Path: asset, Kind: DeadAsset, Files: []string{asset}})
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].Path < dead[j].Path
+	})
+	return dead
+}
+
+// deadPackages returns the Go packages of a module that no file outside the
+// package imports
+func deadPackages(rootDir string, paths []string, known map[string]bool) []DeadFile {
+	packageFiles := make(map[string][]string)
+	for _, relPath := range paths {
+		if isGoFile(relPath) && !strings.HasSuffix(relPath, "_test.go") && !strings.Contains("/"+relPath, "/testdata/") {
+			packageFiles[path.Dir(relPath)] = append(packageFiles[path.Dir(relPath)], relPath)
+		}
+	}
+
+	imported := make(map[string]bool)
+	for from, files := range resolveImports(rootDir, paths, known) {
+		for _, to := range files {
+			if path.Dir(to) != path.Dir(from) {
+				imported[path.Dir(to)] = true
+			}
+		}
+	}
+
+	dead := []DeadFile{}
+	fset := token.NewFileSet()
+	for dir, files := range packageFiles {
+		if imported[dir] || known[path.Join(dir, "go.mod")] || !inGoModule(dir, known) {
+			continue
+		}
+		file, err := parser.ParseFile(fset, platform.JoinSlash(rootDir, files[0]), nil, parser.PackageClauseOnly)
+		if err != nil || file.Name.Name == "main" {
+			continue
+		}
+		sort.Strings(files)
+		dead = append(dead, DeadFile{Path: dir, Kind: DeadPackage, Files: files})
+	}
+	return dead
+}
+
+// inGoModule reports whether a directory is inside a module with a go.mod among
+// the known paths
+func inGoModule(dir string, known map[string]bool) bool {
+	for {
+		if known[path.Join(dir, "go.mod")] {
+			return true
+		}
+		if dir == "." || dir == "/" {
+			return false
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// orphanedAssets returns the assets among paths whose name appears in no other
+// text file and that no //go:embed directive matches
+func orphanedAssets(rootDir string, paths []string) []string {
+	unreferenced := make(map[string]bool)
+	for _, relPath := range paths {
+		if assetExtensions[strings.ToLower(path.Ext(relPath))] {
+			unreferenced[relPath] = true
+		}
+	}
+	if len(unreferenced) == 0 {
+		return nil
+	}
+
+	for _, relPath := range paths {
+		if len(unreferenced) == 0 {
+			break
+		}
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		info, err := os.Stat(fullPath)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxReferenceFileSize {
+			continue
+		}
+		if isBinary, err := isBinaryFile(fullPath); err != nil || isBinary {
+			continue
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+		content := string(data)
+
+		var embeds []string
+		if isGoFile(relPath) {
+			for _, match := range goEmbedPattern.FindAllStringSubmatch(content, -1) {
+				for _, pattern := range strings.Fields(match[1]) {
+					pattern = strings.TrimPrefix(strings.Trim(pattern, "\"`"), "all:")
+					embeds = append(embeds, path.Join(path.Dir(relPath), pattern))
+				}
+			}
+		}
+
+		for asset := range unreferenced {
+			if asset != relPath && (strings.Contains(content, path.Base(asset)) || matchesEmbed(asset, embeds)) {
+				delete(unreferenced, asset)
+			}
+		}
+	}
+
+	assets := make([]string, 0, len(unreferenced))
+	for asset := range unreferenced {
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+// matchesEmbed reports whether a //go:embed pattern, relative to the scanned
+// directory, matches a file or a directory holding it
+func matchesEmbed(relPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		for dir := relPath; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrintDeadFiles prints the dead files
+func PrintDeadFiles(dead []DeadFile, w io.Writer) {
+	fmt.Fprintln(w, "\nDead Files:")
+	fmt.Fprintln(w, "===========")
+	if len(dead) == 0 {
+		fmt.Fprintln(w, "  No unused packages or assets found")
+		return
+	}
+	for _, file := range dead {
+		switch file.Kind {
+		case DeadPackage:
+			fmt.Fprintf(w, "  %s/ (Go package, %s, not imported by any other package)\n", file.Path, plural(len(file.Files), "file"))
+		case DeadAsset:
+			fmt.Fprintf(w, "  %s (asset, not referenced by any file)\n", file.Path)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDeadFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_deadfiles_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"go.mod":                 "module example.com/app\n\ngo 1.24\n",
+		"app.go":                 "package app\n",
+		"cmd/app/main.go":        "package main\n\nimport \"example.com/app/internal/used\"\n",
+		"internal/used/used.go":  "package used\n",
+		"internal/old/old.go":    "package old\n",
+		"internal/old/extra.go":  "package old\n",
+		"internal/old/a_test.go": "package old\n\nimport \"example.com/app/internal/used\"\n",
+		"web/web.go":             "package web\n\nimport \"embed\"\n\n//go:embed static\nvar static embed.FS\n",
+		"web/static/logo.png":    "\x89PNG\x00",
+		"cmd/app/app_test.go":    "package main\n\nimport _ \"example.com/app/web\"\n",
+		"assets/used.svg":        "<svg/>",
+		"assets/unused.svg":      "<svg><use href=\"used.svg\"/></svg>",
+		"index.html":             "<img src=\"assets/photo.jpg\">\n",
+		"assets/photo.jpg":       "\xff\xd8\xff\x00",
+		"scripts/tool/tool.go":   "package tool\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+
+	expected := []DeadFile{
+		{Path: "assets/unused.svg", Kind: DeadAsset, Files: []string{"assets/unused.svg"}},
+		{Path: "internal/old", Kind: DeadPackage, Files: []string{"internal/old/extra.go", "internal/old/old.go"}},
+		{Path: "scripts/tool", Kind: DeadPackage, Files: []string{"scripts/tool/tool.go"}},
+	}
+	if dead := FindDeadFiles(tempDir, paths); !reflect.DeepEqual(dead, expected) {
+		t.Errorf("Expected dead files %+v, got %+v", expected, dead)
+	}
+}
@@ -0,0 +1,319 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// maxUndocumentedShown is the number of undocumented symbols printed in the stats
+const maxUndocumentedShown = 50
+
+var (
+	// jsExportPattern matches an exported JS or TS declaration
+	jsExportPattern = regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:abstract\s+)?(function\*?|class|const|let|var|interface|type|enum)\s+(\w+)`)
+	// pythonDefPattern matches a Python function or class
+	pythonDefPattern = regexp.MustCompile(`^\s*(?:async\s+)?(def|class)\s+(\w+)`)
+)
+
+// DocCoverage is how many of the public symbols of the source files have doc
+// comments: exported Go declarations, Python functions and classes not named
+// with a leading underscore, and exported JS and TS declarations
+type DocCoverage struct {
+	Symbols      int                  `json:"symbols"`
+	Documented   int                  `json:"documented"`
+	Percent      float64              `json:"percent"`
+	Languages    []DocLanguage        `json:"languages"`
+	Undocumented []UndocumentedSymbol `json:"undocumented"`
+}
+
+// DocLanguage is the documentation coverage of one language
+type DocLanguage struct {
+	Language   string  `json:"language"`
+	Symbols    int     `json:"symbols"`
+	Documented int     `json:"documented"`
+	Percent    float64 `json:"percent"`
+}
+
+// UndocumentedSymbol is a public symbol without a doc comment
+type UndocumentedSymbol struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"` // function, method, type, class, const, var, ...
+	Name string `json:"name"`
+}
+
+// publicSymbol is a public declaration and whether it is documented
+type publicSymbol struct {
+	UndocumentedSymbol
+	documented bool
+}
+
+// AnalyzeDocCoverage measures the documentation coverage of the Go, Python, JS,
+// and TS files among paths, other than tests. Go files are parsed; a symbol in
+// a parenthesized group is documented by the group's comment too. Python
+// symbols need a docstring and JS and TS ones a /** JSDoc */ comment. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func AnalyzeDocCoverage(rootDir string, paths []string) (*DocCoverage, error) {
+	coverage := &DocCoverage{Languages: []DocLanguage{}, Undocumented: []UndocumentedSymbol{}}
+	languages := make(map[string]*DocLanguage)
+	fset := token.NewFileSet()
+	for _, relPath := range paths {
+		if IsTestFile(relPath) {
+			continue
+		}
+		var lang string
+		switch strings.ToLower(path.Ext(relPath)) {
+		case ".go":
+			lang = "Go"
+		case ".py":
+			lang = "Python"
+		case ".js", ".jsx", ".mjs", ".cjs":
+			lang = "JavaScript"
+		case ".ts", ".tsx":
+			lang = "TypeScript"
+		default:
+			continue
+		}
+		content, err := os.ReadFile(platform.JoinSlash(rootDir, relPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+
+		var symbols []publicSymbol
+		switch lang {
+		case "Go":
+			symbols = goPublicSymbols(fset, relPath, content)
+		case "Python":
+			symbols = pythonPublicSymbols(content)
+		default:
+			symbols = jsPublicSymbols(content)
+		}
+		if len(symbols) == 0 {
+			continue
+		}
+
+		if languages[lang] == nil {
+			languages[lang] = &DocLanguage{Language: lang}
+		}
+		for _, symbol := range symbols {
+			languages[lang].Symbols++
+			if symbol.documented {
+				languages[lang].Documented++
+				continue
+			}
+			symbol.Path = relPath
+			coverage.Undocumented = append(coverage.Undocumented, symbol.UndocumentedSymbol)
+		}
+	}
+
+	for _, lang := range languages {
+		lang.Percent = percentOf(lang.Documented, lang.Symbols)
+		coverage.Symbols += lang.Symbols
+		coverage.Documented += lang.Documented
+		coverage.Languages = append(coverage.Languages, *lang)
+	}
+	coverage.Percent = percentOf(coverage.Documented, coverage.Symbols)
+	sort.Slice(coverage.Languages, func(i, j int) bool {
+		return coverage.Languages[i].Language < coverage.Languages[j].Language
+	})
+	return coverage, nil
+}
+
+// goPublicSymbols returns the exported declarations of a Go file. Methods count
+// when their receiver type is exported. Commands and files that don't parse
+// are skipped.
+func goPublicSymbols(fset *token.FileSet, relPath string, content []byte) []publicSymbol {
+	file, err := parser.ParseFile(fset, relPath, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil || file.Name.Name == "main" {
+		return nil
+	}
+
+	var symbols []publicSymbol
+	add := func(pos token.Pos, kind, name string, documented bool) {
+		symbols = append(symbols, publicSymbol{
+			UndocumentedSymbol: UndocumentedSymbol{Line: fset.Position(pos).Line, Kind: kind, Name: name},
+			documented:         documented,
+		})
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			if decl.Recv == nil {
+				add(decl.Pos(), "function", decl.Name.Name, decl.Doc != nil)
+			} else if receiver := receiverName(decl.Recv.List[0].Type); ast.IsExported(receiver) {
+				add(decl.Pos(), "method", receiver+"."+decl.Name.Name, decl.Doc != nil)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						add(spec.Pos(), "type", spec.Name.Name, decl.Doc != nil || spec.Doc != nil || spec.Comment != nil)
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							add(name.Pos(), decl.Tok.String(), name.Name, decl.Doc != nil || spec.Doc != nil || spec.Comment != nil)
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// pythonPublicSymbols returns the functions and classes of a Python file not
+// named with a leading underscore, at the top level or in a class, with
+// whether their body starts with a docstring
+func pythonPublicSymbols(content []byte) []publicSymbol {
+	lines := strings.Split(string(content), "\n")
+	var symbols []publicSymbol
+	// Indentation of the classes holding the current line; functions nested in
+	// functions are private
+	var classIndents []int
+	functionIndent := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := indentation(line)
+		for len(classIndents) > 0 && indent <= classIndents[len(classIndents)-1] {
+			classIndents = classIndents[:len(classIndents)-1]
+		}
+		if functionIndent >= 0 && indent <= functionIndent {
+			functionIndent = -1
+		}
+
+		m := pythonDefPattern.FindStringSubmatch(line)
+		if m == nil || functionIndent >= 0 {
+			continue
+		}
+		kind, name := m[1], m[2]
+		if kind == "class" {
+			classIndents = append(classIndents, indent)
+		} else {
+			functionIndent = indent
+		}
+		if strings.HasPrefix(name, "_") || (indent > 0 && (kind == "class" && len(classIndents) < 2 || kind == "def" && len(classIndents) == 0)) {
+			continue
+		}
+		if kind == "def" {
+			kind = "function"
+			if len(classIndents) > 0 {
+				kind = "method"
+			}
+		}
+		symbols = append(symbols, publicSymbol{
+			UndocumentedSymbol: UndocumentedSymbol{Line: i + 1, Kind: kind, Name: name},
+			documented:         pythonHasDocstring(lines, i),
+		})
+	}
+	return symbols
+}
+
+// pythonHasDocstring reports whether the body of the def or class at line
+// start begins with a string literal
+func pythonHasDocstring(lines []string, start int) bool {
+	i := start
+	for i < len(lines) && !strings.HasSuffix(strings.TrimSpace(codeOnly(lines[i], "#")), ":") {
+		i++ // A signature spanning lines
+	}
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		trimmed = strings.TrimLeft(trimmed, "rRuUbB")
+		return strings.HasPrefix(trimmed, `"`) || strings.HasPrefix(trimmed, "'")
+	}
+	return false
+}
+
+// jsPublicSymbols returns the exported declarations of a JS or TS file, with
+// whether a /** JSDoc */ comment ends right before them
+func jsPublicSymbols(content []byte) []publicSymbol {
+	lines := strings.Split(string(content), "\n")
+	var symbols []publicSymbol
+	for i, line := range lines {
+		m := jsExportPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := m[1]
+		if strings.HasPrefix(kind, "function") {
+			kind = "function"
+		}
+		symbols = append(symbols, publicSymbol{
+			UndocumentedSymbol: UndocumentedSymbol{Line: i + 1, Kind: kind, Name: m[2]},
+			documented:         jsHasDoc(lines, i),
+		})
+	}
+	return symbols
+}
+
+// jsHasDoc reports whether a /** JSDoc */ comment ends on the line before
+// line start, skipping blank lines and decorators
+func jsHasDoc(lines []string, start int) bool {
+	i := start - 1
+	for i >= 0 && (strings.TrimSpace(lines[i]) == "" || strings.HasPrefix(strings.TrimSpace(lines[i]), "@")) {
+		i--
+	}
+	if i < 0 || !strings.HasSuffix(strings.TrimSpace(lines[i]), "*/") {
+		return false
+	}
+	for ; i >= 0; i-- {
+		if j := strings.Index(lines[i], "/*"); j >= 0 {
+			return strings.HasPrefix(lines[i][j:], "/**")
+		}
+	}
+	return false
+}
+
+// percentOf returns part as a percentage of total, truncated to one decimal
+// place so that it only reads 100 when all are, or 100 when total is 0
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(part*1000/total) / 10
+}
+
+// PrintDocCoverage prints the documentation coverage and the undocumented symbols
+func PrintDocCoverage(coverage *DocCoverage, w io.Writer) {
+	fmt.Fprintln(w, "\nDocumentation Coverage:")
+	fmt.Fprintln(w, "=======================")
+	if coverage.Symbols == 0 {
+		fmt.Fprintln(w, "  No public Go, Python, JavaScript, or TypeScript symbols found")
+		return
+	}
+	fmt.Fprintf(w, "  Overall: %.1f%% (%d of %s documented)\n", coverage.Percent, coverage.Documented, plural(coverage.Symbols, "public symbol"))
+	for _, lang := range coverage.Languages {
+		fmt.Fprintf(w, "  %s: %.1f%% (%d of %d)\n", lang.Language, lang.Percent, lang.Documented, lang.Symbols)
+	}
+
+	if len(coverage.Undocumented) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nUndocumented symbols:")
+	for i, symbol := range coverage.Undocumented {
+		if i == maxUndocumentedShown {
+			fmt.Fprintf(w, "  ... and %d more\n", len(coverage.Undocumented)-maxUndocumentedShown)
+			break
+		}
+		fmt.Fprintf(w, "  %s:%d %s %s\n", symbol.Path, symbol.Line, symbol.Kind, symbol.Name)
+	}
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeDocCoverage(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_doccoverage_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"api.go": `package api
+
+// Client talks to the API
+type Client struct{}
+
+func New() *Client { return nil }
+
+// Get fetches a resource
+func (c *Client) Get() {}
+
+func (c *Client) Put() {}
+
+func (c *client) Delete() {}
+
+// Limits of requests
+const (
+	MaxRetries = 3
+	Timeout    = 10
+)
+
+var (
+	DefaultClient = New() // Shared client
+	Verbose       bool
+)
+
+func helper() {}
+`,
+		"api_test.go": "package api\n\nfunc TestNew(t *testing.T) {}\n",
+		"main.go":     "package main\n\nfunc Run() {}\n",
+		"lib.py": `class Parser:
+    """Parses input."""
+
+    def parse(self, text):
+        def inner():
+            pass
+        return text
+
+    def _reset(self):
+        pass
+
+def load(path,
+         strict=False):
+    # Read the file
+    r"""Loads a file."""
+
+def _private():
+    pass
+`,
+		"util.ts": `/**
+ * Formats a date.
+ */
+export function formatDate(d: Date): string {
+  return "";
+}
+
+// Not a JSDoc comment
+export const parse = (s: string) => s;
+
+/* Not a JSDoc comment either */
+export default class Store {}
+
+function internal() {}
+`,
+		"README.md": "# API\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	paths := []string{"README.md", "api.go", "api_test.go", "lib.py", "main.go", "util.ts"}
+
+	coverage, err := AnalyzeDocCoverage(tempDir, paths)
+	if err != nil {
+		t.Fatalf("AnalyzeDocCoverage failed: %v", err)
+	}
+	expected := &DocCoverage{
+		Symbols:    14,
+		Documented: 8,
+		Percent:    57.1,
+		Languages: []DocLanguage{
+			{Language: "Go", Symbols: 8, Documented: 5, Percent: 62.5},
+			{Language: "Python", Symbols: 3, Documented: 2, Percent: 66.6},
+			{Language: "TypeScript", Symbols: 3, Documented: 1, Percent: 33.3},
+		},
+		Undocumented: []UndocumentedSymbol{
+			{Path: "api.go", Line: 6, Kind: "function", Name: "New"},
+			{Path: "api.go", Line: 11, Kind: "method", Name: "Client.Put"},
+			{Path: "api.go", Line: 23, Kind: "var", Name: "Verbose"},
+			{Path: "lib.py", Line: 4, Kind: "method", Name: "parse"},
+			{Path: "util.ts", Line: 9, Kind: "const", Name: "parse"},
+			{Path: "util.ts", Line: 12, Kind: "class", Name: "Store"},
+		},
+	}
+	if !reflect.DeepEqual(coverage, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, coverage)
+	}
+}
+
+func TestAnalyzeDocCoverage_NoSymbols(t *testing.T) {
+	coverage, err := AnalyzeDocCoverage(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("AnalyzeDocCoverage failed: %v", err)
+	}
+	if coverage.Symbols != 0 || coverage.Percent != 100 || len(coverage.Languages) != 0 {
+		t.Errorf("Expected full coverage of no symbols, got %+v", coverage)
+	}
+}
@@ -0,0 +1,345 @@
+package analysis
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// DefaultFocusTokens is the default token budget of a focused context slice
+const DefaultFocusTokens = 16000
+
+// Relations of the files in a focused context slice to its target
+const (
+	FocusTarget     = "target"
+	FocusDependency = "dependency" // Used by the target
+	FocusDependent  = "dependent"  // Uses the target
+)
+
+// Focus is a context slice around one file or symbol: the target file, the
+// files it depends on, and the files depending on it
+type Focus struct {
+	Target  string      `json:"target"`           // Path of the target file
+	Symbol  string      `json:"symbol,omitempty"` // Target symbol, when focusing on one
+	Files   []FocusFile `json:"files"`            // The target, then dependencies and dependents, closest first
+	Tokens  int         `json:"estimated_tokens"`
+	Omitted int         `json:"omitted_files"` // Related files left out to stay within the budget
+}
+
+// FocusFile is a file of a context slice
+type FocusFile struct {
+	Path     string `json:"path"`
+	Relation string `json:"relation"`
+	Tokens   int    `json:"estimated_tokens"`
+}
+
+var (
+	jsImportPattern     = regexp.MustCompile(`(?:from|import|require\()\s*['"](\.{1,2}/[^'"]+)['"]`)
+	pythonFromPattern   = regexp.MustCompile(`(?m)^\s*from\s+(\.*)([\w.]*)\s+import\b`)
+	pythonImportPattern = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+)`)
+)
+
+// jsExtensions are tried, in order, when resolving an extensionless JS or TS import
+var jsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+// BuildFocus selects the files related to target, which is either one of the
+// given paths or the name of a symbol defined in one of them. Files are related
+// through imports and through the symbols of the cross-reference index, with Go
+// uses across packages only counted where the package is imported. For a
+// symbol, the dependents are the files using it. The target is always included;
+// the related files are added, most references first, while they fit within the
+// token budget (0 for no budget). paths are slash-separated paths relative to
+// rootDir, without a leading slash.
+func BuildFocus(rootDir string, paths []string, target string, tokenBudget int) (*Focus, error) {
+	index, err := BuildXref(rootDir, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	focus := &Focus{Files: []FocusFile{}}
+	known := make(map[string]bool, len(paths))
+	for _, relPath := range paths {
+		known[relPath] = true
+	}
+	if known[target] {
+		focus.Target = target
+	} else {
+		var definers []string
+		for _, symbol := range index.Symbols {
+			if symbol.Name == target {
+				definers = append(definers, symbol.File)
+			}
+		}
+		switch len(definers) {
+		case 0:
+			return nil, fmt.Errorf("focus target %q is neither an included file nor a defined symbol", target)
+		case 1:
+			focus.Target, focus.Symbol = definers[0], target
+		default:
+			return nil, fmt.Errorf("symbol %q is defined in several files (%s); focus on one of them", target, strings.Join(definers, ", "))
+		}
+	}
+
+	imports := resolveImports(rootDir, paths, known)
+	importsDir := func(from, to string) bool {
+		for _, imported := range imports[from] {
+			if path.Dir(imported) == path.Dir(to) {
+				return true
+			}
+		}
+		return false
+	}
+	// related reports whether a use in from may refer to a definition in to
+	related := func(from, to string) bool {
+		fromGo, toGo := isGoFile(from), isGoFile(to)
+		switch {
+		case fromGo != toGo:
+			return false
+		case fromGo:
+			return path.Dir(from) == path.Dir(to) || importsDir(from, to)
+		}
+		return true
+	}
+
+	definitions := make(map[string]int)
+	for _, symbol := range index.Symbols {
+		definitions[symbol.Name]++
+	}
+
+	// Weigh the related files by the references between them and the target
+	dependencies := make(map[string]float64)
+	dependents := make(map[string]float64)
+	for _, symbol := range index.Symbols {
+		if len(symbol.Name) < 3 {
+			continue
+		}
+		share := 1 / float64(definitions[symbol.Name])
+		if symbol.File != focus.Target {
+			if n := symbol.Files[focus.Target]; n > 0 && related(focus.Target, symbol.File) {
+				dependencies[symbol.File] += float64(n) * share
+			}
+			continue
+		}
+		if focus.Symbol != "" && symbol.Name != focus.Symbol {
+			continue
+		}
+		for file, n := range symbol.Files {
+			if file != focus.Target && related(file, focus.Target) {
+				dependents[file] += float64(n) * share
+			}
+		}
+	}
+	for _, imported := range imports[focus.Target] {
+		if !isGoFile(imported) {
+			dependencies[imported]++
+		}
+	}
+	if focus.Symbol == "" {
+		for file, imported := range imports {
+			for _, relPath := range imported {
+				if relPath == focus.Target && !isGoFile(file) {
+					dependents[file]++
+				}
+			}
+		}
+	}
+
+	// Files both used by and using the target count as dependencies
+	type candidate struct {
+		file   FocusFile
+		weight float64
+	}
+	var candidates []candidate
+	for file, weight := range dependencies {
+		candidates = append(candidates, candidate{FocusFile{Path: file, Relation: FocusDependency}, weight + dependents[file]})
+	}
+	for file, weight := range dependents {
+		if _, ok := dependencies[file]; !ok {
+			candidates = append(candidates, candidate{FocusFile{Path: file, Relation: FocusDependent}, weight})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight > candidates[j].weight
+		}
+		if candidates[i].file.Relation != candidates[j].file.Relation {
+			return candidates[i].file.Relation == FocusDependency
+		}
+		return candidates[i].file.Path < candidates[j].file.Path
+	})
+
+	// Fill the budget with the target first, then in order of weight
+	targetFile := FocusFile{Path: focus.Target, Relation: FocusTarget, Tokens: estimateFileTokens(rootDir, focus.Target)}
+	focus.Tokens = targetFile.Tokens
+	var selected []FocusFile
+	for _, c := range candidates {
+		c.file.Tokens = estimateFileTokens(rootDir, c.file.Path)
+		if tokenBudget > 0 && focus.Tokens+c.file.Tokens > tokenBudget {
+			focus.Omitted++
+			continue
+		}
+		selected = append(selected, c.file)
+		focus.Tokens += c.file.Tokens
+	}
+
+	// Group the dependencies before the dependents, keeping the weight order
+	focus.Files = append(focus.Files, targetFile)
+	for _, relation := range []string{FocusDependency, FocusDependent} {
+		for _, file := range selected {
+			if file.Relation == relation {
+				focus.Files = append(focus.Files, file)
+			}
+		}
+	}
+	return focus, nil
+}
+
+// Paths returns the paths of the files in the slice, in order
+func (f *Focus) Paths() []string {
+	paths := make([]string, len(f.Files))
+	for i, file := range f.Files {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// resolveImports returns the files each file imports among the known paths.
+// Go imports of packages in the repository's modules resolve to the files of
+// the package; relative JS and TS imports and Python imports to their module.
+func resolveImports(rootDir string, paths []string, known map[string]bool) map[string][]string {
+	// Go modules by directory, and the Go files of each package directory
+	modules := make(map[string]string)
+	packageFiles := make(map[string][]string)
+	for _, relPath := range paths {
+		if path.Base(relPath) == "go.mod" {
+			if data, err := os.ReadFile(platform.JoinSlash(rootDir, relPath)); err == nil {
+				if match := goModulePattern.FindSubmatch(data); match != nil {
+					modules[path.Dir(relPath)] = string(match[1])
+				}
+			}
+		} else if isGoFile(relPath) && !strings.HasSuffix(relPath, "_test.go") {
+			packageFiles[path.Dir(relPath)] = append(packageFiles[path.Dir(relPath)], relPath)
+		}
+	}
+
+	imports := make(map[string][]string)
+	fset := token.NewFileSet()
+	for _, relPath := range paths {
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		switch strings.ToLower(path.Ext(relPath)) {
+		case ".go":
+			file, err := parser.ParseFile(fset, fullPath, nil, parser.ImportsOnly)
+			if err != nil {
+				continue
+			}
+			for _, spec := range file.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					continue
+				}
+				if dir, ok := goPackageDir(modules, importPath); ok {
+					imports[relPath] = append(imports[relPath], packageFiles[dir]...)
+				}
+			}
+		case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+			content, err := os.ReadFile(fullPath)
+			if err != nil {
+				continue
+			}
+			for _, match := range jsImportPattern.FindAllStringSubmatch(string(content), -1) {
+				if resolved := resolveJSImport(path.Join(path.Dir(relPath), match[1]), known); resolved != "" {
+					imports[relPath] = append(imports[relPath], resolved)
+				}
+			}
+		case ".py":
+			content, err := os.ReadFile(fullPath)
+			if err != nil {
+				continue
+			}
+			for _, match := range pythonFromPattern.FindAllStringSubmatch(string(content), -1) {
+				base := "."
+				if dots := len(match[1]); dots > 0 {
+					base = path.Dir(relPath)
+					for i := 1; i < dots; i++ {
+						base = path.Dir(base)
+					}
+				}
+				if resolved := resolvePythonImport(base, match[2], known); resolved != "" {
+					imports[relPath] = append(imports[relPath], resolved)
+				}
+			}
+			for _, match := range pythonImportPattern.FindAllStringSubmatch(string(content), -1) {
+				if resolved := resolvePythonImport(".", match[1], known); resolved != "" {
+					imports[relPath] = append(imports[relPath], resolved)
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// goPackageDir returns the directory of a package in one of the modules
+func goPackageDir(modules map[string]string, importPath string) (string, bool) {
+	for dir, module := range modules {
+		if importPath == module {
+			return dir, true
+		}
+		if sub, ok := strings.CutPrefix(importPath, module+"/"); ok {
+			return path.Join(dir, sub), true
+		}
+	}
+	return "", false
+}
+
+// resolveJSImport returns the known file a relative import refers to, trying
+// the extensions and index files the way bundlers do
+func resolveJSImport(base string, known map[string]bool) string {
+	if known[base] {
+		return base
+	}
+	for _, ext := range jsExtensions {
+		if known[base+ext] {
+			return base + ext
+		}
+	}
+	for _, ext := range jsExtensions {
+		if known[base+"/index"+ext] {
+			return base + "/index" + ext
+		}
+	}
+	return ""
+}
+
+// resolvePythonImport returns the known module or package file of a dotted
+// module name relative to base
+func resolvePythonImport(base, module string, known map[string]bool) string {
+	modulePath := path.Join(base, strings.ReplaceAll(module, ".", "/"))
+	for _, candidate := range []string{modulePath + ".py", modulePath + "/__init__.py"} {
+		if known[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// isGoFile reports whether a path names a Go source file
+func isGoFile(relPath string) bool {
+	return strings.EqualFold(path.Ext(relPath), ".go")
+}
+
+// estimateFileTokens estimates the tokens of a file's content (about 4 characters per token)
+func estimateFileTokens(rootDir, relPath string) int {
+	info, err := os.Stat(platform.JoinSlash(rootDir, relPath))
+	if err != nil {
+		return 0
+	}
+	return int((info.Size() + 3) / 4)
+}
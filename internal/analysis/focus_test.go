@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildFocus(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_focus_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.22\n",
+		"main.go":          "package main\n\nimport \"example.com/app/store\"\n\nfunc main() {\n\tstore.Open(\"db\")\n}\n",
+		"store/store.go":   "package store\n\n// Open opens a store\nfunc Open(path string) *Store {\n\treturn &Store{path: encodePath(path)}\n}\n",
+		"store/types.go":   "package store\n\ntype Store struct {\n\tpath string\n}\n",
+		"store/encode.go":  "package store\n\nfunc encodePath(path string) string {\n\treturn path\n}\n",
+		"other/open.go":    "package other\n\n// Uses a different Open, not the store's\nfunc run() {\n\tOpen()\n}\n",
+		"web/app.ts":       "import { render } from './view'\n\nrender()\n",
+		"web/view.ts":      "export function render() {}\n",
+		"web/unrelated.ts": "export function helper() {}\n",
+		"py/main.py":       "from .models import User\n\nUser()\n",
+		"py/models.py":     "class User:\n    pass\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		budget   int
+		expected []string
+		omitted  int
+	}{
+		{
+			name:     "go file",
+			target:   "store/store.go",
+			expected: []string{"store/store.go:target", "store/types.go:dependency", "store/encode.go:dependency", "main.go:dependent"},
+		},
+		{
+			name:     "go symbol",
+			target:   "encodePath",
+			expected: []string{"store/encode.go:target", "store/store.go:dependent"},
+		},
+		{
+			name:     "ts import",
+			target:   "web/app.ts",
+			expected: []string{"web/app.ts:target", "web/view.ts:dependency"},
+		},
+		{
+			name:     "ts dependent",
+			target:   "web/view.ts",
+			expected: []string{"web/view.ts:target", "web/app.ts:dependent"},
+		},
+		{
+			name:     "python relative import",
+			target:   "py/main.py",
+			expected: []string{"py/main.py:target", "py/models.py:dependency"},
+		},
+		{
+			name:     "budget",
+			target:   "store/store.go",
+			budget:   45,
+			expected: []string{"store/store.go:target", "store/types.go:dependency"},
+			omitted:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			focus, err := BuildFocus(tempDir, paths, tt.target, tt.budget)
+			if err != nil {
+				t.Fatalf("BuildFocus failed: %v", err)
+			}
+			var got []string
+			for _, file := range focus.Files {
+				got = append(got, file.Path+":"+file.Relation)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected files %v, got %v", tt.expected, got)
+			}
+			if focus.Omitted != tt.omitted {
+				t.Errorf("Expected %d omitted files, got %d", tt.omitted, focus.Omitted)
+			}
+		})
+	}
+
+	if _, err := BuildFocus(tempDir, paths, "missing", 0); err == nil || !strings.Contains(err.Error(), "neither") {
+		t.Errorf("Expected an error for an unknown target, got %v", err)
+	}
+}
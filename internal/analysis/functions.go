@@ -0,0 +1,413 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// Default thresholds above which functions are reported
+const (
+	DefaultComplexityThreshold = 10 // Cyclomatic complexity
+	DefaultNestingThreshold    = 4  // Levels of nested blocks
+	DefaultLengthThreshold     = 60 // Lines
+)
+
+// maxFunctionFileSize is the size above which files are not analyzed per function
+const maxFunctionFileSize = 1024 * 1024
+
+// maxComplexFunctions is the number of complex functions printed in the stats
+const maxComplexFunctions = 20
+
+var (
+	// braceDecisionPattern matches the branches of C-like languages
+	braceDecisionPattern = regexp.MustCompile(`\b(?:if|for|foreach|while|case|catch)\b|&&|\|\|`)
+	// pythonDecisionPattern matches the branches of Python
+	pythonDecisionPattern = regexp.MustCompile(`\b(?:if|elif|for|while|except|case|and|or)\b`)
+	// stringLiteralPattern matches single-line string literals, so that braces
+	// and keywords in them are not counted
+	stringLiteralPattern = regexp.MustCompile("\"(?:\\\\.|[^\"\\\\])*\"|'(?:\\\\.|[^'\\\\])*'|`[^`]*`")
+)
+
+// FunctionComplexity is the cyclomatic complexity of a function: one plus the
+// number of branches (conditions, loops, cases, and boolean operators) in it
+type FunctionComplexity struct {
+	Path       string `json:"path"`
+	Function   string `json:"function"` // Methods of Go types as Type.Method
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Lines      int    `json:"lines"`
+	Complexity int    `json:"complexity"`
+	MaxNesting int    `json:"max_nesting"` // Deepest nesting of blocks (conditions, loops, closures) in the function
+}
+
+// FileFunctions sums up the functions of a file
+type FileFunctions struct {
+	Path            string `json:"path"`
+	Functions       int    `json:"functions"`
+	MaxNesting      int    `json:"max_nesting"`
+	LongestFunction string `json:"longest_function"`
+	LongestLines    int    `json:"longest_function_lines"`
+}
+
+// FunctionThresholds are the values above which functions are reported
+type FunctionThresholds struct {
+	Complexity int
+	Nesting    int
+	Length     int // Lines
+}
+
+// FunctionReport is the complexity, nesting, and length of each function, for
+// tools to gate on
+type FunctionReport struct {
+	Threshold        int                  `json:"threshold"` // Of complexity
+	NestingThreshold int                  `json:"nesting_threshold"`
+	LengthThreshold  int                  `json:"length_threshold"`
+	OverThreshold    int                  `json:"over_threshold"` // Functions with a complexity above the threshold
+	Functions        []FunctionComplexity `json:"functions"`      // Most complex first
+	Files            []FileFunctions      `json:"files"`
+}
+
+// DeeplyNested returns the functions nested deeper than the threshold, deepest first
+func (r *FunctionReport) DeeplyNested() []FunctionComplexity {
+	return r.over(func(f FunctionComplexity) int { return f.MaxNesting }, r.NestingThreshold)
+}
+
+// TooLong returns the functions longer than the threshold, longest first
+func (r *FunctionReport) TooLong() []FunctionComplexity {
+	return r.over(func(f FunctionComplexity) int { return f.Lines }, r.LengthThreshold)
+}
+
+// over returns the functions whose metric is above threshold, highest first
+func (r *FunctionReport) over(metric func(FunctionComplexity) int, threshold int) []FunctionComplexity {
+	var functions []FunctionComplexity
+	for _, function := range r.Functions {
+		if metric(function) > threshold {
+			functions = append(functions, function)
+		}
+	}
+	sort.SliceStable(functions, func(i, j int) bool {
+		return metric(functions[i]) > metric(functions[j])
+	})
+	return functions
+}
+
+// AnalyzeFunctions computes the cyclomatic complexity, nesting depth, and length
+// of the functions in the source files among paths, other than tests. Go files are parsed; functions
+// of other C-like languages and of Python are found with the repository map's
+// declaration patterns and bounded by their braces or indentation. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func AnalyzeFunctions(rootDir string, paths []string, thresholds FunctionThresholds) (*FunctionReport, error) {
+	report := &FunctionReport{
+		Threshold:        thresholds.Complexity,
+		NestingThreshold: thresholds.Nesting,
+		LengthThreshold:  thresholds.Length,
+		Functions:        []FunctionComplexity{},
+		Files:            []FileFunctions{},
+	}
+	fset := token.NewFileSet()
+	for _, relPath := range paths {
+		ext := strings.ToLower(path.Ext(relPath))
+		if IsTestFile(relPath) || ext == ".rb" || signaturePatternsFor(ext) == nil {
+			continue
+		}
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		info, err := os.Stat(fullPath)
+		if err != nil || info.Size() > maxFunctionFileSize {
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+
+		var functions []FunctionComplexity
+		switch ext {
+		case ".go":
+			functions = goFunctionComplexity(fset, fullPath, content)
+		case ".py":
+			functions = pythonFunctionComplexity(content)
+		default:
+			functions = braceFunctionComplexity(content, signaturePatternsFor(ext))
+		}
+		if len(functions) == 0 {
+			continue
+		}
+		file := FileFunctions{Path: relPath, Functions: len(functions)}
+		for _, function := range functions {
+			function.Path = relPath
+			function.Lines = function.EndLine - function.StartLine + 1
+			report.Functions = append(report.Functions, function)
+			if function.Complexity > thresholds.Complexity {
+				report.OverThreshold++
+			}
+			file.MaxNesting = max(file.MaxNesting, function.MaxNesting)
+			if function.Lines > file.LongestLines {
+				file.LongestFunction, file.LongestLines = function.Function, function.Lines
+			}
+		}
+		report.Files = append(report.Files, file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+
+	sort.SliceStable(report.Functions, func(i, j int) bool {
+		a, b := report.Functions[i], report.Functions[j]
+		if a.Complexity != b.Complexity {
+			return a.Complexity > b.Complexity
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.StartLine < b.StartLine
+	})
+	return report, nil
+}
+
+// goFunctionComplexity measures the functions of a Go file, counting function
+// literals toward the function holding them. An else if is at the level of its
+// if. Files that don't parse are skipped.
+func goFunctionComplexity(fset *token.FileSet, path string, content []byte) []FunctionComplexity {
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var functions []FunctionComplexity
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = receiverName(fn.Recv.List[0].Type) + "." + name
+		}
+
+		complexity := 1
+		depth, maxDepth := 0, 0
+		var nests []bool // Whether each node being visited adds a level
+		elseIfs := make(map[*ast.IfStmt]bool)
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			if node == nil {
+				if nests[len(nests)-1] {
+					depth--
+				}
+				nests = nests[:len(nests)-1]
+				return true
+			}
+
+			nested := false
+			switch node := node.(type) {
+			case *ast.IfStmt:
+				complexity++
+				if elseIf, ok := node.Else.(*ast.IfStmt); ok {
+					elseIfs[elseIf] = true
+				}
+				nested = !elseIfs[node]
+			case *ast.ForStmt, *ast.RangeStmt:
+				complexity++
+				nested = true
+			case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+				nested = true
+			case *ast.CaseClause:
+				if node.List != nil {
+					complexity++ // Not default
+				}
+			case *ast.CommClause:
+				if node.Comm != nil {
+					complexity++
+				}
+			case *ast.BinaryExpr:
+				if node.Op == token.LAND || node.Op == token.LOR {
+					complexity++
+				}
+			}
+			if nested {
+				depth++
+				maxDepth = max(maxDepth, depth)
+			}
+			nests = append(nests, nested)
+			return true
+		})
+		functions = append(functions, FunctionComplexity{
+			Function:   name,
+			StartLine:  fset.Position(fn.Pos()).Line,
+			EndLine:    fset.Position(fn.End()).Line,
+			Complexity: complexity,
+			MaxNesting: maxDepth,
+		})
+	}
+	return functions
+}
+
+// receiverName returns the type name of a method receiver, such as "Scanner"
+// for (s *Scanner) or (l *List[T])
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return "?"
+}
+
+// braceFunctionComplexity measures the functions of a C-like language, each
+// running from its declaration to the brace closing its body and nested as deep
+// as the braces in it
+func braceFunctionComplexity(content []byte, patterns []signaturePattern) []FunctionComplexity {
+	lines := strings.Split(string(content), "\n")
+	var functions []FunctionComplexity
+	for start, line := range lines {
+		sig, ok := matchSignature(line, patterns)
+		if !ok || (sig.Kind != "function" && sig.Kind != "method") {
+			continue
+		}
+
+		depth, maxDepth, opened := 0, 0, false
+		complexity := 1
+		end := -1
+		for i := start; i < len(lines) && end < 0; i++ {
+			code := codeOnly(lines[i], "//")
+			if !opened && strings.Contains(code, ";") && !strings.Contains(code, "{") {
+				break // A declaration without a body
+			}
+			if opened || strings.Contains(code, "{") {
+				complexity += len(braceDecisionPattern.FindAllStringIndex(code, -1))
+			}
+			for _, c := range code {
+				switch c {
+				case '{':
+					depth++
+					maxDepth = max(maxDepth, depth)
+					opened = true
+				case '}':
+					depth--
+				}
+				if opened && depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			continue
+		}
+		functions = append(functions, FunctionComplexity{Function: sig.Name, StartLine: start + 1, EndLine: end + 1, Complexity: complexity, MaxNesting: maxDepth - 1})
+	}
+	return functions
+}
+
+// pythonFunctionComplexity measures the functions of a Python file, each
+// running from its def to the last line indented deeper and nested as deep as
+// its indentation, in units of its first line's
+func pythonFunctionComplexity(content []byte) []FunctionComplexity {
+	lines := strings.Split(string(content), "\n")
+	var functions []FunctionComplexity
+	for start, line := range lines {
+		sig, ok := matchSignature(line, pythonSignatures)
+		if !ok || sig.Kind != "function" {
+			continue
+		}
+
+		indent := indentation(line)
+		complexity := 1
+		end := start
+		unit, deepest := 0, 0
+		for i := start + 1; i < len(lines); i++ {
+			code := codeOnly(lines[i], "#")
+			if strings.TrimSpace(code) == "" {
+				continue
+			}
+			if indentation(lines[i]) <= indent {
+				break
+			}
+			complexity += len(pythonDecisionPattern.FindAllStringIndex(code, -1))
+			end = i
+			if unit == 0 {
+				unit = indentation(lines[i]) - indent
+			}
+			deepest = max(deepest, indentation(lines[i])-indent)
+		}
+		nesting := 0
+		if unit > 0 {
+			nesting = deepest/unit - 1
+		}
+		functions = append(functions, FunctionComplexity{Function: sig.Name, StartLine: start + 1, EndLine: end + 1, Complexity: complexity, MaxNesting: nesting})
+	}
+	return functions
+}
+
+// codeOnly blanks the string literals of a line and drops its trailing comment
+func codeOnly(line, lineComment string) string {
+	line = stringLiteralPattern.ReplaceAllString(line, `""`)
+	if i := strings.Index(line, lineComment); i >= 0 {
+		line = line[:i]
+	}
+	return line
+}
+
+// indentation returns the width of a line's leading whitespace
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// PrintFunctionReport prints the functions above the complexity threshold and
+// the files with deeply nested or long functions
+func PrintFunctionReport(report *FunctionReport, w io.Writer) {
+	printComplexFunctions(report, w)
+
+	title := fmt.Sprintf("Nesting and Function Length (over %d levels or %d lines):", report.NestingThreshold, report.LengthThreshold)
+	fmt.Fprintln(w, "\n"+title)
+	fmt.Fprintln(w, strings.Repeat("=", len(title)))
+	var files []FileFunctions
+	for _, file := range report.Files {
+		if file.MaxNesting > report.NestingThreshold || file.LongestLines > report.LengthThreshold {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(w, "  None of %s\n", plural(len(report.Files), "file"))
+		return
+	}
+	for i, file := range files {
+		if i == maxComplexFunctions {
+			fmt.Fprintf(w, "  ... and %d more\n", len(files)-maxComplexFunctions)
+			break
+		}
+		fmt.Fprintf(w, "  %s: max nesting %d, longest function %s (%d lines)\n", file.Path, file.MaxNesting, file.LongestFunction, file.LongestLines)
+	}
+}
+
+// printComplexFunctions prints the functions above the complexity threshold
+func printComplexFunctions(report *FunctionReport, w io.Writer) {
+	title := fmt.Sprintf("Complex Functions (cyclomatic complexity over %d):", report.Threshold)
+	fmt.Fprintln(w, "\n"+title)
+	fmt.Fprintln(w, strings.Repeat("=", len(title)))
+	if report.OverThreshold == 0 {
+		fmt.Fprintf(w, "  None of %s\n", plural(len(report.Functions), "function"))
+		return
+	}
+	for i, function := range report.Functions[:report.OverThreshold] {
+		if i == maxComplexFunctions {
+			fmt.Fprintf(w, "  ... and %d more\n", report.OverThreshold-maxComplexFunctions)
+			break
+		}
+		fmt.Fprintf(w, "  %s:%d-%d %s: %d\n", function.Path, function.StartLine, function.EndLine, function.Function, function.Complexity)
+	}
+}
@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeFunctions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_functions_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"parse.go": `package parse
+
+func Simple() int {
+	return 1
+}
+
+func (p *Parser[T]) Next(s string) bool {
+	for _, c := range s {
+		if c == 'a' && p.ok || c == 'b' {
+			return true
+		}
+	}
+	switch s {
+	case "x":
+	case "y", "z":
+	default:
+	}
+	return false
+}
+`,
+		"parse_test.go": "package parse\n\nfunc TestSimple(t *testing.T) {\n\tif Simple() != 1 {\n\t}\n}\n",
+		"app.js": `function handle(req) {
+  // if this were commented out code
+  if (req.ok && req.body) {
+    return "if { while";
+  }
+  for (const item of req.items) {
+    log(item);
+  }
+}
+`,
+		"tool.py": `def run(args):
+    if args and args.verbose:
+        print("if while")
+    for arg in args:
+        pass
+
+def noop():
+    pass
+`,
+	}
+	var paths []string
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+
+	report, err := AnalyzeFunctions(tempDir, paths, FunctionThresholds{Complexity: 3, Nesting: 1, Length: 8})
+	if err != nil {
+		t.Fatalf("AnalyzeFunctions failed: %v", err)
+	}
+	expected := &FunctionReport{
+		Threshold:        3,
+		NestingThreshold: 1,
+		LengthThreshold:  8,
+		OverThreshold:    3,
+		Functions: []FunctionComplexity{
+			{Path: "parse.go", Function: "Parser.Next", StartLine: 7, EndLine: 19, Lines: 13, Complexity: 7, MaxNesting: 2},
+			{Path: "app.js", Function: "handle", StartLine: 1, EndLine: 9, Lines: 9, Complexity: 4, MaxNesting: 1},
+			{Path: "tool.py", Function: "run", StartLine: 1, EndLine: 5, Lines: 5, Complexity: 4, MaxNesting: 1},
+			{Path: "parse.go", Function: "Simple", StartLine: 3, EndLine: 5, Lines: 3, Complexity: 1, MaxNesting: 0},
+			{Path: "tool.py", Function: "noop", StartLine: 7, EndLine: 8, Lines: 2, Complexity: 1, MaxNesting: 0},
+		},
+		Files: []FileFunctions{
+			{Path: "app.js", Functions: 1, MaxNesting: 1, LongestFunction: "handle", LongestLines: 9},
+			{Path: "parse.go", Functions: 2, MaxNesting: 2, LongestFunction: "Parser.Next", LongestLines: 13},
+			{Path: "tool.py", Functions: 2, MaxNesting: 1, LongestFunction: "run", LongestLines: 5},
+		},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+
+	if nested := report.DeeplyNested(); len(nested) != 1 || nested[0].Function != "Parser.Next" {
+		t.Errorf("Expected Parser.Next to be deeply nested, got %+v", nested)
+	}
+	if long := report.TooLong(); len(long) != 2 || long[0].Function != "Parser.Next" || long[1].Function != "handle" {
+		t.Errorf("Expected Parser.Next and handle to be too long, got %+v", long)
+	}
+
+	health := NewHealthCheck()
+	health.AddFunctionFindings(report)
+	expectedWarnings := []string{"Functions nested deeper than 1 levels: 1", "Functions longer than 8 lines: 2"}
+	if !reflect.DeepEqual(health.Warnings, expectedWarnings) {
+		t.Errorf("Expected warnings %v, got %v", expectedWarnings, health.Warnings)
+	}
+}
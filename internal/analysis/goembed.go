@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// goEmbedVarPattern matches the variable declared after a //go:embed directive
+var goEmbedVarPattern = regexp.MustCompile(`^\s*var\s+(\w+)`)
+
+// GoEmbed is a //go:embed directive and the files it embeds in its package
+type GoEmbed struct {
+	Package  string          `json:"package"` // Directory of the package
+	File     string          `json:"file"`
+	Line     int             `json:"line"`
+	Variable string          `json:"variable,omitempty"`
+	Patterns []string        `json:"patterns"`
+	Assets   []EmbeddedAsset `json:"assets"`
+}
+
+// EmbeddedAsset is a file embedded by a //go:embed directive
+type EmbeddedAsset struct {
+	Path     string `json:"path"`
+	Included bool   `json:"included"` // False when the file is left out of the output, as binary files are
+}
+
+// FindGoEmbeds finds the //go:embed directives of the Go files among included
+// and resolves their patterns against all the scanned files, so that embedded
+// assets left out of the output are still named. A pattern matching a
+// directory embeds the files under it, except those whose names start with a
+// dot or underscore unless the pattern has the all: prefix. Paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func FindGoEmbeds(rootDir string, included, all []string) []GoEmbed {
+	isIncluded := make(map[string]bool, len(included))
+	for _, relPath := range included {
+		isIncluded[relPath] = true
+	}
+
+	embeds := []GoEmbed{}
+	for _, relPath := range included {
+		if !isGoFile(relPath) {
+			continue
+		}
+		content, err := os.ReadFile(platform.JoinSlash(rootDir, relPath))
+		if err != nil || !strings.Contains(string(content), "//go:embed") {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		for i := 0; i < len(lines); i++ {
+			m := goEmbedPattern.FindStringSubmatch(lines[i])
+			if m == nil {
+				continue
+			}
+			dir := path.Dir(relPath)
+			embed := GoEmbed{Package: dir, File: relPath, Line: i + 1, Patterns: strings.Fields(m[1]), Assets: []EmbeddedAsset{}}
+			// Consecutive directives embed into the same variable
+			for i+1 < len(lines) {
+				next := goEmbedPattern.FindStringSubmatch(lines[i+1])
+				if next == nil {
+					break
+				}
+				embed.Patterns = append(embed.Patterns, strings.Fields(next[1])...)
+				i++
+			}
+			if i+1 < len(lines) {
+				if v := goEmbedVarPattern.FindStringSubmatch(lines[i+1]); v != nil {
+					embed.Variable = v[1]
+				}
+			}
+
+			for _, candidate := range all {
+				if embedsFile(dir, embed.Patterns, candidate) {
+					embed.Assets = append(embed.Assets, EmbeddedAsset{Path: candidate, Included: isIncluded[candidate]})
+				}
+			}
+			embeds = append(embeds, embed)
+		}
+	}
+	sort.SliceStable(embeds, func(i, j int) bool {
+		return embeds[i].Package < embeds[j].Package
+	})
+	return embeds
+}
+
+// embedsFile reports whether the //go:embed patterns of the package in dir
+// embed the file at relPath
+func embedsFile(dir string, patterns []string, relPath string) bool {
+	rel := relPath
+	if dir != "." {
+		var ok bool
+		if rel, ok = strings.CutPrefix(relPath, dir+"/"); !ok {
+			return false
+		}
+	}
+	for _, pattern := range patterns {
+		pattern, all := strings.CutPrefix(strings.Trim(pattern, "\"`"), "all:")
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		// A directory embeds the files under it
+		for sub := path.Dir(rel); sub != "."; sub = path.Dir(sub) {
+			if ok, _ := path.Match(pattern, sub); ok {
+				return all || !hasHiddenPart(strings.TrimPrefix(rel, sub+"/"))
+			}
+		}
+	}
+	return false
+}
+
+// hasHiddenPart reports whether any element of a path starts with a dot or
+// underscore, which //go:embed skips in directories
+func hasHiddenPart(relPath string) bool {
+	for _, part := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasPrefix(part, "_") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindGoEmbeds(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_goembed_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"web/assets.go": `package web
+
+import "embed"
+
+//go:embed static
+var static embed.FS
+
+//go:embed templates/*.html
+//go:embed all:config
+var files embed.FS
+`,
+		"web/static/app.css":        "body {}\n",
+		"web/static/_hidden.css":    "p {}\n",
+		"web/static/font.woff2":     "wOF2",
+		"web/templates/index.html":  "<html></html>\n",
+		"web/templates/notes.txt":   "notes\n",
+		"web/config/_defaults.json": "{}\n",
+		"main.go":                   "package main\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	all := []string{"main.go", "web/assets.go", "web/config/_defaults.json", "web/static/_hidden.css", "web/static/app.css", "web/static/font.woff2", "web/templates/index.html", "web/templates/notes.txt"}
+	included := []string{"main.go", "web/assets.go", "web/config/_defaults.json", "web/static/_hidden.css", "web/static/app.css", "web/templates/index.html", "web/templates/notes.txt"}
+
+	expected := []GoEmbed{
+		{Package: "web", File: "web/assets.go", Line: 5, Variable: "static", Patterns: []string{"static"}, Assets: []EmbeddedAsset{
+			{Path: "web/static/app.css", Included: true},
+			{Path: "web/static/font.woff2"},
+		}},
+		{Package: "web", File: "web/assets.go", Line: 8, Variable: "files", Patterns: []string{"templates/*.html", "all:config"}, Assets: []EmbeddedAsset{
+			{Path: "web/config/_defaults.json", Included: true},
+			{Path: "web/templates/index.html", Included: true},
+		}},
+	}
+	if got := FindGoEmbeds(tempDir, included, all); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
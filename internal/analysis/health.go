@@ -2,28 +2,60 @@ package analysis
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"codectx/internal/audit"
+	"codectx/internal/coverage"
+	"codectx/internal/platform"
 )
 
 // HealthCheck represents the health check results for a project
 type HealthCheck struct {
-	HasReadme        bool     `json:"has_readme"`
-	HasLicense       bool     `json:"has_license"`
-	HasGitignore     bool     `json:"has_gitignore"`
-	HasTests         bool     `json:"has_tests"`
-	LargeFiles       []string `json:"large_files"`
-	EmptyDirectories []string `json:"empty_directories"`
-	BinaryFiles      int      `json:"binary_files_count"`
-	Warnings         []string `json:"warnings"`
+	HasReadme          bool              `json:"has_readme"`
+	HasLicense         bool              `json:"has_license"`
+	HasGitignore       bool              `json:"has_gitignore"`
+	HasTests           bool              `json:"has_tests"`
+	LargeFiles         []string          `json:"large_files"`
+	EmptyDirectories   []string          `json:"empty_directories"`
+	BinaryFiles        int               `json:"binary_files_count"`
+	PersonalMetadata   []MetadataFinding `json:"personal_metadata"`
+	MixedLineEndings   []string          `json:"mixed_line_endings"`
+	WorldWritableFiles []string          `json:"world_writable_files"`
+	SetuidFiles        []string          `json:"setuid_files"` // Setuid or setgid
+	Leftovers          []Leftover        `json:"leftovers"`    // Conflict markers and debug statements
+	DeeplyNested       []string          `json:"deeply_nested_functions"`
+	LongFunctions      []string          `json:"long_functions"`
+	Warnings           []string          `json:"warnings"`
+
+	// Known vulnerabilities of the dependencies, when they were audited
+	DependenciesAudited bool            `json:"dependencies_audited"`
+	Vulnerabilities     []audit.Finding `json:"vulnerabilities"`
+
+	// Test coverage, when a coverage report was given
+	CoverageMeasured bool     `json:"coverage_measured"`
+	CoveragePercent  float64  `json:"coverage_percent"`
+	CoverageUnit     string   `json:"coverage_unit,omitempty"` // statements or lines
+	UntestedFiles    []string `json:"untested_files"`
 }
 
 // NewHealthCheck creates a new health check
 func NewHealthCheck() *HealthCheck {
 	return &HealthCheck{
-		LargeFiles:       []string{},
-		EmptyDirectories: []string{},
-		Warnings:         []string{},
+		LargeFiles:         []string{},
+		EmptyDirectories:   []string{},
+		PersonalMetadata:   []MetadataFinding{},
+		MixedLineEndings:   []string{},
+		WorldWritableFiles: []string{},
+		SetuidFiles:        []string{},
+		Leftovers:          []Leftover{},
+		DeeplyNested:       []string{},
+		LongFunctions:      []string{},
+		Warnings:           []string{},
+		Vulnerabilities:    []audit.Finding{},
+		UntestedFiles:      []string{},
 	}
 }
 
@@ -74,12 +106,48 @@ func CheckProjectHealth(rootDir string, largeFileSizeThreshold int64) (*HealthCh
 			}
 		}
 
-		// Check for binary files
+		// Check for binary files, and for text files mixing LF and CRLF line endings
 		if !info.IsDir() {
 			isBinary, err := isBinaryFile(path)
 			if err == nil && isBinary {
 				health.BinaryFiles++
 			}
+			if err == nil && !isBinary && info.Size() <= largeFileSizeThreshold {
+				if mixed, err := hasMixedLineEndings(path); err == nil && mixed {
+					relPath, err := filepath.Rel(rootDir, path)
+					if err == nil {
+						health.MixedLineEndings = append(health.MixedLineEndings, filepath.ToSlash(relPath))
+					}
+				}
+			}
+		}
+
+		// Check for files anyone can modify, and for files running with their owner's rights
+		if info.Mode().IsRegular() {
+			perms := platform.FilePermissions(info)
+			if perms.WorldWritable || perms.Setuid || perms.Setgid {
+				relPath, err := filepath.Rel(rootDir, path)
+				if err == nil {
+					entry := fmt.Sprintf("%s (%s)", filepath.ToSlash(relPath), perms.Symbolic)
+					if perms.WorldWritable {
+						health.WorldWritableFiles = append(health.WorldWritableFiles, entry)
+					}
+					if perms.Setuid || perms.Setgid {
+						health.SetuidFiles = append(health.SetuidFiles, entry)
+					}
+				}
+			}
+		}
+
+		// Check for personal metadata (EXIF GPS positions, document authors, ...)
+		if !info.IsDir() && HasMetadataSupport(path) {
+			fields, err := ScanPersonalMetadata(path)
+			if err == nil && len(fields) > 0 {
+				relPath, err := filepath.Rel(rootDir, path)
+				if err == nil {
+					health.PersonalMetadata = append(health.PersonalMetadata, MetadataFinding{Path: filepath.ToSlash(relPath), Fields: fields})
+				}
+			}
 		}
 
 		return nil
@@ -111,43 +179,194 @@ func CheckProjectHealth(rootDir string, largeFileSizeThreshold int64) (*HealthCh
 	if health.BinaryFiles > 0 {
 		health.Warnings = append(health.Warnings, fmt.Sprintf("Binary files: %d (consider adding to .gitignore)", health.BinaryFiles))
 	}
+	if len(health.MixedLineEndings) > 0 {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Files with mixed line endings: %d", len(health.MixedLineEndings)))
+	}
+	if len(health.WorldWritableFiles) > 0 {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("World-writable files: %d", len(health.WorldWritableFiles)))
+	}
+	if len(health.SetuidFiles) > 0 {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Setuid or setgid files: %d", len(health.SetuidFiles)))
+	}
+	if len(health.PersonalMetadata) > 0 {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("Files with personal metadata: %d (scrub before sharing the output)", len(health.PersonalMetadata)))
+	}
 
 	return health, nil
 }
 
+// AddLeftovers records the merge conflict markers and debug statements found
+func (h *HealthCheck) AddLeftovers(leftovers []Leftover) {
+	h.Leftovers = append(h.Leftovers, leftovers...)
+	conflicts, debug := 0, 0
+	for _, leftover := range leftovers {
+		if leftover.Kind == LeftoverConflict {
+			conflicts++
+		} else {
+			debug++
+		}
+	}
+	if conflicts > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Merge conflict markers: %d", conflicts))
+	}
+	if debug > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Debug statements in non-test code: %d", debug))
+	}
+}
+
+// AddFunctionFindings records the functions nested deeper or longer than the
+// thresholds of a function report
+func (h *HealthCheck) AddFunctionFindings(report *FunctionReport) {
+	for _, function := range report.DeeplyNested() {
+		h.DeeplyNested = append(h.DeeplyNested, fmt.Sprintf("%s:%d %s (%d levels)", function.Path, function.StartLine, function.Function, function.MaxNesting))
+	}
+	for _, function := range report.TooLong() {
+		h.LongFunctions = append(h.LongFunctions, fmt.Sprintf("%s:%d %s (%d lines)", function.Path, function.StartLine, function.Function, function.Lines))
+	}
+	if len(h.DeeplyNested) > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Functions nested deeper than %d levels: %d", report.NestingThreshold, len(h.DeeplyNested)))
+	}
+	if len(h.LongFunctions) > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Functions longer than %d lines: %d", report.LengthThreshold, len(h.LongFunctions)))
+	}
+}
+
+// AddVulnerabilities records the findings of a dependency audit
+func (h *HealthCheck) AddVulnerabilities(findings []audit.Finding) {
+	h.DependenciesAudited = true
+	h.Vulnerabilities = append(h.Vulnerabilities, findings...)
+	if len(findings) > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Known vulnerabilities in dependencies: %d", len(findings)))
+	}
+}
+
+// AddCoverage records the test coverage of a coverage report, flagging the
+// files it lists without any coverage
+func (h *HealthCheck) AddCoverage(report *coverage.Report) {
+	h.CoverageMeasured = true
+	h.CoveragePercent = report.Percent()
+	h.CoverageUnit = report.Unit()
+	h.UntestedFiles = append(h.UntestedFiles, report.Untested()...)
+	if len(h.UntestedFiles) > 0 {
+		h.Warnings = append(h.Warnings, fmt.Sprintf("Files without test coverage: %d", len(h.UntestedFiles)))
+	}
+}
+
 // PrintHealthCheck prints the health check results
-func PrintHealthCheck(health *HealthCheck) {
-	fmt.Println("\nProject Health Check:")
-	fmt.Println("=====================")
+func PrintHealthCheck(health *HealthCheck, w io.Writer) {
+	fmt.Fprintln(w, "\nProject Health Check:")
+	fmt.Fprintln(w, "=====================")
 
 	// Print warnings
 	if len(health.Warnings) > 0 {
-		fmt.Println("\nWarnings:")
+		fmt.Fprintln(w, "\nWarnings:")
 		for _, warning := range health.Warnings {
-			fmt.Printf("⚠️  %s\n", warning)
+			fmt.Fprintf(w, "⚠️  %s\n", warning)
 		}
 	}
 
 	// Print positive checks
-	fmt.Println("\nChecks:")
-	printCheck(health.HasReadme, "README.md present")
-	printCheck(health.HasLicense, "LICENSE file present")
-	printCheck(health.HasGitignore, ".gitignore configured")
-	printCheck(health.HasTests, "Tests present")
+	fmt.Fprintln(w, "\nChecks:")
+	printCheck(w, health.HasReadme, "README.md present")
+	printCheck(w, health.HasLicense, "LICENSE file present")
+	printCheck(w, health.HasGitignore, ".gitignore configured")
+	printCheck(w, health.HasTests, "Tests present")
+	if health.DependenciesAudited {
+		printCheck(w, len(health.Vulnerabilities) == 0, "No known vulnerabilities in dependencies")
+	}
+	if health.CoverageMeasured {
+		printCheck(w, len(health.UntestedFiles) == 0, fmt.Sprintf("Test coverage: %.1f%% of %s", health.CoveragePercent, health.CoverageUnit))
+	}
 
 	// Print large files
 	if len(health.LargeFiles) > 0 {
-		fmt.Println("\nLarge files:")
+		fmt.Fprintln(w, "\nLarge files:")
 		for _, file := range health.LargeFiles {
-			fmt.Printf("  %s\n", file)
+			fmt.Fprintf(w, "  %s\n", file)
 		}
 	}
 
 	// Print empty directories
 	if len(health.EmptyDirectories) > 0 {
-		fmt.Println("\nEmpty directories:")
+		fmt.Fprintln(w, "\nEmpty directories:")
 		for _, dir := range health.EmptyDirectories {
-			fmt.Printf("  %s\n", dir)
+			fmt.Fprintf(w, "  %s\n", dir)
+		}
+	}
+
+	// Print files with mixed line endings
+	if len(health.MixedLineEndings) > 0 {
+		fmt.Fprintln(w, "\nMixed line endings:")
+		for _, file := range health.MixedLineEndings {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+
+	// Print files with risky permissions
+	if len(health.WorldWritableFiles) > 0 {
+		fmt.Fprintln(w, "\nWorld-writable files:")
+		for _, file := range health.WorldWritableFiles {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+	if len(health.SetuidFiles) > 0 {
+		fmt.Fprintln(w, "\nSetuid and setgid files:")
+		for _, file := range health.SetuidFiles {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+
+	// Print conflict markers and debug statements
+	if len(health.Leftovers) > 0 {
+		fmt.Fprintln(w, "\nLeftovers:")
+		for _, leftover := range health.Leftovers {
+			fmt.Fprintf(w, "  %s:%d: [%s] %s\n", leftover.Path, leftover.Line, leftover.Kind, leftover.Text)
+		}
+	}
+
+	// Print deeply nested and long functions
+	if len(health.DeeplyNested) > 0 {
+		fmt.Fprintln(w, "\nDeeply nested functions:")
+		for _, function := range health.DeeplyNested {
+			fmt.Fprintf(w, "  %s\n", function)
+		}
+	}
+	if len(health.LongFunctions) > 0 {
+		fmt.Fprintln(w, "\nLong functions:")
+		for _, function := range health.LongFunctions {
+			fmt.Fprintf(w, "  %s\n", function)
+		}
+	}
+
+	// Print files without test coverage
+	if len(health.UntestedFiles) > 0 {
+		fmt.Fprintln(w, "\nUntested files:")
+		for _, file := range health.UntestedFiles {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+
+	// Print vulnerable dependencies
+	if len(health.Vulnerabilities) > 0 {
+		fmt.Fprintln(w, "\nVulnerable dependencies:")
+		for _, finding := range health.Vulnerabilities {
+			dep := finding.Dependency
+			fmt.Fprintf(w, "  [%s] %s %s (%s): %s", finding.Severity, dep.Name, dep.Version, dep.Manifest, finding.ID)
+			if finding.Summary != "" {
+				fmt.Fprintf(w, " - %s", finding.Summary)
+			}
+			if finding.Fixed != "" {
+				fmt.Fprintf(w, " (fixed in %s)", finding.Fixed)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	// Print files with personal metadata
+	if len(health.PersonalMetadata) > 0 {
+		fmt.Fprintln(w, "\nPersonal metadata:")
+		for _, finding := range health.PersonalMetadata {
+			fmt.Fprintf(w, "  %s: %s\n", finding.Path, strings.Join(finding.Fields, ", "))
 		}
 	}
 }
@@ -217,6 +436,50 @@ func isBinaryFile(path string) (bool, error) {
 	return false, nil
 }
 
+// hasMixedLineEndings checks if a file uses more than one of LF, CRLF, and CR line endings
+func hasMixedLineEndings(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var lf, crlf, cr bool
+	prevCR := false
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := file.Read(buf)
+		for _, b := range buf[:n] {
+			switch {
+			case b == '\n' && prevCR:
+				crlf = true
+			case b == '\n':
+				lf = true
+			case prevCR:
+				cr = true
+			}
+			prevCR = b == '\r'
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	if prevCR {
+		cr = true
+	}
+
+	styles := 0
+	for _, used := range []bool{lf, crlf, cr} {
+		if used {
+			styles++
+		}
+	}
+	return styles > 1, nil
+}
+
 // hasFilesWithSuffix checks if a directory has files with a specific suffix
 func hasFilesWithSuffix(rootDir, suffix string) bool {
 	found := false
@@ -234,10 +497,10 @@ func hasFilesWithSuffix(rootDir, suffix string) bool {
 }
 
 // printCheck prints a check result
-func printCheck(condition bool, message string) {
+func printCheck(w io.Writer, condition bool, message string) {
 	if condition {
-		fmt.Printf("✅ %s\n", message)
+		fmt.Fprintf(w, "✅ %s\n", message)
 	} else {
-		fmt.Printf("❌ %s\n", message)
+		fmt.Fprintf(w, "❌ %s\n", message)
 	}
 }
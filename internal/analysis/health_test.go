@@ -0,0 +1,86 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codectx/internal/platform"
+)
+
+func TestHasMixedLineEndings(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_health_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"lf.txt", "a\nb\n", false},
+		{"crlf.txt", "a\r\nb\r\n", false},
+		{"mixed.txt", "a\r\nb\nc\r\n", true},
+		{"cr-and-lf.txt", "a\rb\n", true},
+		{"trailing-cr.txt", "a\nb\r", true},
+		{"none.txt", "abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			mixed, err := hasMixedLineEndings(path)
+			if err != nil {
+				t.Fatalf("hasMixedLineEndings failed: %v", err)
+			}
+			if mixed != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, mixed)
+			}
+		})
+	}
+}
+
+func TestCheckProjectHealth_Permissions(t *testing.T) {
+	if platform.IsWindows {
+		t.Skip("Windows doesn't have Unix mode bits")
+	}
+	tempDir := t.TempDir()
+
+	modes := map[string]os.FileMode{
+		"main.go":      0644,
+		"build.sh":     0755,
+		"shared.log":   0666,
+		"bin/escalate": 0755 | os.ModeSetuid,
+	}
+	for name, mode := range modes {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0600); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("Failed to chmod file: %v", err)
+		}
+	}
+
+	health, err := CheckProjectHealth(tempDir, 1024*1024)
+	if err != nil {
+		t.Fatalf("CheckProjectHealth failed: %v", err)
+	}
+	if !reflect.DeepEqual(health.WorldWritableFiles, []string{"shared.log (-rw-rw-rw-)"}) {
+		t.Errorf("Expected shared.log to be world-writable, got %v", health.WorldWritableFiles)
+	}
+	if info, err := os.Stat(filepath.Join(tempDir, "bin", "escalate")); err == nil && info.Mode()&os.ModeSetuid != 0 {
+		if !reflect.DeepEqual(health.SetuidFiles, []string{"bin/escalate (-rwsr-xr-x)"}) {
+			t.Errorf("Expected bin/escalate to be setuid, got %v", health.SetuidFiles)
+		}
+	}
+}
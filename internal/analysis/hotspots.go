@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HotspotPeriod is the period over which churn is counted, as git --since understands it
+const HotspotPeriod = "1 year ago"
+
+// maxHotspots is the number of hotspots reported
+const maxHotspots = 10
+
+// Hotspot is a file that changes often and is complex, and so is likely to
+// hold defects and to be expensive to change
+type Hotspot struct {
+	Path       string  `json:"path"`
+	Commits    int     `json:"commits"` // Commits changing the file within HotspotPeriod
+	Lines      int     `json:"lines"`
+	Complexity float64 `json:"complexity_score"`
+	Score      float64 `json:"score"` // Churn times complexity, both relative to the highest, from 0 to 100
+}
+
+// RankHotspots ranks the existing files under rootDir by churn times
+// complexity, after the code forensics approach: each is scaled to the highest
+// among the changed files, so the riskiest file scores 100. churn maps paths
+// relative to rootDir, with slashes, to their number of commits. Files without
+// complexity never rank.
+func RankHotspots(rootDir string, churn map[string]int) ([]Hotspot, error) {
+	var hotspots []Hotspot
+	maxCommits, maxComplexity := 0, 0.0
+	for relPath, commits := range churn {
+		path := filepath.Join(rootDir, filepath.FromSlash(relPath))
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue // Deleted since, or not a regular file
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == "" {
+			continue
+		}
+		metrics, err := analyzeFileComplexity(path, ext[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", relPath, err)
+		}
+		if metrics.ComplexityScore == 0 {
+			continue
+		}
+		hotspots = append(hotspots, Hotspot{Path: relPath, Commits: commits, Lines: metrics.Lines, Complexity: metrics.ComplexityScore})
+		maxCommits = max(maxCommits, commits)
+		maxComplexity = max(maxComplexity, metrics.ComplexityScore)
+	}
+
+	for i := range hotspots {
+		score := float64(hotspots[i].Commits) / float64(maxCommits) * hotspots[i].Complexity / maxComplexity * 100
+		hotspots[i].Score = math.Round(score*10) / 10
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		return hotspots[i].Path < hotspots[j].Path
+	})
+	if len(hotspots) > maxHotspots {
+		hotspots = hotspots[:maxHotspots]
+	}
+	return hotspots, nil
+}
+
+// PrintHotspots prints the hotspot ranking
+func PrintHotspots(hotspots []Hotspot, w io.Writer) {
+	fmt.Fprintln(w, "\nHotspots (churn x complexity):")
+	fmt.Fprintln(w, "==============================")
+	if len(hotspots) == 0 {
+		fmt.Fprintf(w, "  No complex files changed since %s\n", HotspotPeriod)
+		return
+	}
+	for i, hotspot := range hotspots {
+		fmt.Fprintf(w, "  %2d. %s: score %.1f (%s since %s, complexity score %.1f, %d lines)\n",
+			i+1, hotspot.Path, hotspot.Score, plural(hotspot.Commits, "commit"), HotspotPeriod, hotspot.Complexity, hotspot.Lines)
+	}
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRankHotspots(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_hotspots_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"parse.go":   "package parse\n\nfunc Parse(s string) int {\n\tif s == \"\" {\n\t\treturn 0\n\t}\n\treturn 1\n}\n",
+		"lex/lex.go": "package lex\n\nfunc Lex() {}\n",
+		"notes.txt":  "notes\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	// Files without complexity and deleted files are left out
+	churn := map[string]int{"parse.go": 2, "lex/lex.go": 4, "notes.txt": 9, "deleted.go": 5}
+	expected := []Hotspot{
+		{Path: "parse.go", Commits: 2, Lines: 8, Complexity: 1.5, Score: 50},
+		{Path: "lex/lex.go", Commits: 4, Lines: 3, Complexity: 0.5, Score: 33.3},
+	}
+
+	hotspots, err := RankHotspots(tempDir, churn)
+	if err != nil {
+		t.Fatalf("RankHotspots failed: %v", err)
+	}
+	if !reflect.DeepEqual(hotspots, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, hotspots)
+	}
+}
@@ -0,0 +1,296 @@
+package analysis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// maxIaCFileSize is the size above which YAML files are not read to tell
+// whether they are Kubernetes manifests, nor files counted for resources
+const maxIaCFileSize = 1024 * 1024
+
+// Kinds of infrastructure-as-code modules
+const (
+	IaCTerraform  = "terraform"  // A directory of .tf files, with variables and Terragrunt configuration
+	IaCHelm       = "helm"       // A chart: the directory with Chart.yaml and everything under it
+	IaCKubernetes = "kubernetes" // A directory of manifests and Kustomize files
+)
+
+var (
+	// terraformBlockPattern matches a resource or data source block of
+	// Terraform, capturing the block and the type
+	terraformBlockPattern = regexp.MustCompile(`^\s*(resource|data)\s+"([^"]+)"\s+"[^"]+"`)
+	// terraformModulePattern matches a module block of Terraform
+	terraformModulePattern = regexp.MustCompile(`^\s*module\s+"[^"]+"\s*\{`)
+	// manifestKindPattern matches the kind of a Kubernetes object at the top
+	// level of a YAML document, capturing it
+	manifestKindPattern = regexp.MustCompile(`^kind:\s*["']?([A-Za-z][\w.]*)`)
+	// manifestAPIVersionPattern matches the apiVersion of a Kubernetes object
+	manifestAPIVersionPattern = regexp.MustCompile(`^apiVersion:\s*\S`)
+)
+
+// kustomizationNames are the file names of Kustomize
+var kustomizationNames = map[string]bool{"kustomization.yaml": true, "kustomization.yml": true, "kustomization": true}
+
+// IaCInventory is the infrastructure as code of a project, by module
+type IaCInventory struct {
+	Modules   []IaCModule     `json:"modules"`   // By path
+	Resources []ResourceCount `json:"resources"` // Totals across modules, most first
+}
+
+// IaCModule is a Terraform module, Helm chart, or directory of Kubernetes
+// manifests, with the resources it declares
+type IaCModule struct {
+	Path      string          `json:"path"` // "." for the root
+	Kind      string          `json:"kind"` // IaCTerraform, IaCHelm, or IaCKubernetes
+	Files     []string        `json:"files"`
+	Resources []ResourceCount `json:"resources"` // Most first
+}
+
+// ResourceCount is the number of resources of a type: a Terraform resource
+// type ("aws_s3_bucket"), data source ("data.aws_ami"), or "module" call, or a
+// Kubernetes kind ("Deployment")
+type ResourceCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// IsTerraformFile reports whether a file is Terraform or Terragrunt configuration
+func IsTerraformFile(relPath string) bool {
+	name := strings.ToLower(path.Base(relPath))
+	return strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") ||
+		strings.HasSuffix(name, ".tfvars") || strings.HasSuffix(name, ".tfvars.json") ||
+		name == "terragrunt.hcl" || strings.HasSuffix(name, ".tftest.hcl")
+}
+
+// IaCFiles keeps the infrastructure-as-code files among paths and returns
+// them grouped by module, with the modules in order of path. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func IaCFiles(rootDir string, paths []string) []string {
+	var files []string
+	for _, module := range findIaCModules(rootDir, paths) {
+		files = append(files, module.Files...)
+	}
+	return files
+}
+
+// FindIaC finds the Terraform modules, Helm charts, and directories of
+// Kubernetes manifests among paths and counts the resources they declare.
+// paths are slash-separated paths relative to rootDir, without a leading slash.
+func FindIaC(rootDir string, paths []string) (*IaCInventory, error) {
+	inventory := &IaCInventory{Modules: []IaCModule{}, Resources: []ResourceCount{}}
+	totals := make(map[string]int)
+	for _, module := range findIaCModules(rootDir, paths) {
+		counts := make(map[string]int)
+		for _, relPath := range module.Files {
+			if err := countResources(rootDir, relPath, module.Kind, counts); err != nil {
+				return nil, err
+			}
+		}
+		module.Resources = sortedCounts(counts)
+		for resourceType, count := range counts {
+			totals[resourceType] += count
+		}
+		inventory.Modules = append(inventory.Modules, module)
+	}
+	inventory.Resources = sortedCounts(totals)
+	return inventory, nil
+}
+
+// findIaCModules groups the infrastructure-as-code files among paths into
+// modules: everything under a Chart.yaml belongs to the innermost chart, and
+// Terraform files and Kubernetes manifests to their directory
+func findIaCModules(rootDir string, paths []string) []IaCModule {
+	charts := make(map[string]bool)
+	for _, relPath := range paths {
+		if name := path.Base(relPath); name == "Chart.yaml" || name == "Chart.yml" {
+			charts[path.Dir(relPath)] = true
+		}
+	}
+
+	modules := make(map[string]*IaCModule)
+	add := func(dir, kind, relPath string) {
+		key := kind + "\x00" + dir
+		module := modules[key]
+		if module == nil {
+			module = &IaCModule{Path: dir, Kind: kind}
+			modules[key] = module
+		}
+		module.Files = append(module.Files, relPath)
+	}
+	for _, relPath := range paths {
+		if chart, ok := enclosingChart(charts, relPath); ok {
+			add(chart, IaCHelm, relPath)
+			continue
+		}
+		switch {
+		case IsTerraformFile(relPath):
+			add(path.Dir(relPath), IaCTerraform, relPath)
+		case kustomizationNames[strings.ToLower(path.Base(relPath))] || isManifest(rootDir, relPath):
+			add(path.Dir(relPath), IaCKubernetes, relPath)
+		}
+	}
+
+	result := make([]IaCModule, 0, len(modules))
+	for _, module := range modules {
+		sort.Strings(module.Files)
+		result = append(result, *module)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+// enclosingChart returns the directory of the innermost Helm chart containing
+// relPath
+func enclosingChart(charts map[string]bool, relPath string) (string, bool) {
+	for dir := path.Dir(relPath); ; dir = path.Dir(dir) {
+		if charts[dir] {
+			return dir, true
+		}
+		if dir == "." || dir == "/" {
+			return "", false
+		}
+	}
+}
+
+// isManifest reports whether a YAML file is a Kubernetes manifest: a
+// document at its top level has both apiVersion and kind
+func isManifest(rootDir, relPath string) bool {
+	switch strings.ToLower(path.Ext(relPath)) {
+	case ".yaml", ".yml":
+	default:
+		return false
+	}
+	fullPath := platform.JoinSlash(rootDir, relPath)
+	if info, err := os.Stat(fullPath); err != nil || info.Size() > maxIaCFileSize {
+		return false
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	hasAPIVersion, hasKind := false, false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxIaCFileSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") {
+			hasAPIVersion, hasKind = false, false
+			continue
+		}
+		hasAPIVersion = hasAPIVersion || manifestAPIVersionPattern.MatchString(line)
+		hasKind = hasKind || manifestKindPattern.MatchString(line)
+		if hasAPIVersion && hasKind {
+			return true
+		}
+	}
+	return false
+}
+
+// countResources adds the resources a file of a module declares to counts
+func countResources(rootDir, relPath, kind string, counts map[string]int) error {
+	if kind == IaCHelm && !strings.HasPrefix(relPath, "templates/") && !strings.Contains(relPath, "/templates/") {
+		return nil // Only templates render to objects
+	}
+	if kind == IaCTerraform && !strings.HasSuffix(strings.ToLower(relPath), ".tf") {
+		return nil // Variables, JSON syntax, and Terragrunt declare no blocks we count
+	}
+	fullPath := platform.JoinSlash(rootDir, relPath)
+	if info, err := os.Stat(fullPath); err != nil || info.Size() > maxIaCFileSize {
+		return nil
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	return countResourcesIn(file, kind, counts)
+}
+
+// countResourcesIn counts the resources declared by r, a Terraform file or
+// YAML manifests, in counts
+func countResourcesIn(r io.Reader, kind string, counts map[string]int) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxIaCFileSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if kind == IaCTerraform {
+			if m := terraformBlockPattern.FindStringSubmatch(line); m != nil {
+				resourceType := m[2]
+				if m[1] == "data" {
+					resourceType = "data." + resourceType
+				}
+				counts[resourceType]++
+			} else if terraformModulePattern.MatchString(line) {
+				counts["module"]++
+			}
+			continue
+		}
+		if m := manifestKindPattern.FindStringSubmatch(line); m != nil {
+			counts[m[1]]++
+		}
+	}
+	return scanner.Err()
+}
+
+// sortedCounts lists counts by type, most first
+func sortedCounts(counts map[string]int) []ResourceCount {
+	result := make([]ResourceCount, 0, len(counts))
+	for resourceType, count := range counts {
+		result = append(result, ResourceCount{Type: resourceType, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// PrintIaC prints the infrastructure-as-code modules and their resource counts
+func PrintIaC(inventory *IaCInventory, w io.Writer) {
+	fmt.Fprintln(w, "\nInfrastructure as Code:")
+	fmt.Fprintln(w, "=======================")
+	if len(inventory.Modules) == 0 {
+		fmt.Fprintln(w, "  No Terraform, Helm, or Kubernetes files found")
+		return
+	}
+
+	total := 0
+	for _, resource := range inventory.Resources {
+		total += resource.Count
+	}
+	fmt.Fprintf(w, "Resources (%d):\n", total)
+	for _, resource := range inventory.Resources {
+		fmt.Fprintf(w, "  %-40s %d\n", resource.Type, resource.Count)
+	}
+
+	fmt.Fprintf(w, "Modules (%d):\n", len(inventory.Modules))
+	for _, module := range inventory.Modules {
+		var resources []string
+		for _, resource := range module.Resources {
+			resources = append(resources, fmt.Sprintf("%d %s", resource.Count, resource.Type))
+		}
+		summary := plural(len(module.Files), "file")
+		if len(resources) > 0 {
+			summary += ": " + strings.Join(resources, ", ")
+		}
+		fmt.Fprintf(w, "  %s (%s, %s)\n", module.Path, module.Kind, summary)
+	}
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindIaC(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_iac_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go": "package main\n",
+		"infra/main.tf": `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+module "vpc" {
+  source = "./modules/vpc"
+}
+`,
+		"infra/terraform.tfvars":    "region = \"us-east-1\"\n",
+		"infra/modules/vpc/main.tf": "resource \"aws_vpc\" \"main\" {}\nresource \"aws_subnet\" \"a\" {}\n",
+		"infra/live/terragrunt.hcl": "terraform {\n  source = \"../modules/vpc\"\n}\n",
+		"charts/web/Chart.yaml":     "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"charts/web/values.yaml":    "replicas: 2\n",
+		"charts/web/templates/app.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+---
+apiVersion: v1
+kind: Service
+`,
+		"charts/web/templates/_helpers.tpl": "{{- define \"web.name\" -}}web{{- end -}}\n",
+		"deploy/app.yaml": `# The application
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+---
+apiVersion: v1
+kind: ConfigMap
+`,
+		"deploy/kustomization.yaml": "resources:\n  - app.yaml\n",
+		"config/app.yaml":           "server:\n  kind: http\n",
+		".github/workflows/ci.yml":  "name: CI\non: push\n",
+	}
+	var paths []string
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+
+	expectedFiles := []string{
+		"charts/web/Chart.yaml", "charts/web/templates/_helpers.tpl", "charts/web/templates/app.yaml", "charts/web/values.yaml",
+		"deploy/app.yaml", "deploy/kustomization.yaml",
+		"infra/main.tf", "infra/terraform.tfvars",
+		"infra/live/terragrunt.hcl",
+		"infra/modules/vpc/main.tf",
+	}
+	if got := IaCFiles(tempDir, paths); !reflect.DeepEqual(got, expectedFiles) {
+		t.Errorf("Expected %v, got %v", expectedFiles, got)
+	}
+
+	inventory, err := FindIaC(tempDir, paths)
+	if err != nil {
+		t.Fatalf("FindIaC failed: %v", err)
+	}
+	expectedModules := []IaCModule{
+		{
+			Path: "charts/web", Kind: IaCHelm,
+			Files:     []string{"charts/web/Chart.yaml", "charts/web/templates/_helpers.tpl", "charts/web/templates/app.yaml", "charts/web/values.yaml"},
+			Resources: []ResourceCount{{"Deployment", 1}, {"Service", 1}},
+		},
+		{
+			Path: "deploy", Kind: IaCKubernetes,
+			Files:     []string{"deploy/app.yaml", "deploy/kustomization.yaml"},
+			Resources: []ResourceCount{{"ConfigMap", 1}, {"Deployment", 1}},
+		},
+		{
+			Path: "infra", Kind: IaCTerraform,
+			Files:     []string{"infra/main.tf", "infra/terraform.tfvars"},
+			Resources: []ResourceCount{{"aws_s3_bucket", 2}, {"data.aws_ami", 1}, {"module", 1}},
+		},
+		{Path: "infra/live", Kind: IaCTerraform, Files: []string{"infra/live/terragrunt.hcl"}, Resources: []ResourceCount{}},
+		{
+			Path: "infra/modules/vpc", Kind: IaCTerraform,
+			Files:     []string{"infra/modules/vpc/main.tf"},
+			Resources: []ResourceCount{{"aws_subnet", 1}, {"aws_vpc", 1}},
+		},
+	}
+	if !reflect.DeepEqual(inventory.Modules, expectedModules) {
+		t.Errorf("Expected modules %+v, got %+v", expectedModules, inventory.Modules)
+	}
+	expectedResources := []ResourceCount{
+		{"Deployment", 2}, {"aws_s3_bucket", 2},
+		{"ConfigMap", 1}, {"Service", 1}, {"aws_subnet", 1}, {"aws_vpc", 1}, {"data.aws_ami", 1}, {"module", 1},
+	}
+	if !reflect.DeepEqual(inventory.Resources, expectedResources) {
+		t.Errorf("Expected resources %+v, got %+v", expectedResources, inventory.Resources)
+	}
+}
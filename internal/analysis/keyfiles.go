@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"path"
+	"strings"
+)
+
+// Kinds of key files reported by KeyFileKind
+const (
+	KeyFileEntryPoint = "entry point"
+	KeyFileManifest   = "manifest"
+	KeyFileBuild      = "build"
+	KeyFileDocs       = "docs"
+	KeyFileConfig     = "config"
+)
+
+// keyFileNames maps lower-cased file names to the kind of key file they are
+var keyFileNames = map[string]string{
+	// Entry points
+	"main.go":     KeyFileEntryPoint,
+	"main.py":     KeyFileEntryPoint,
+	"__main__.py": KeyFileEntryPoint,
+	"app.py":      KeyFileEntryPoint,
+	"manage.py":   KeyFileEntryPoint,
+	"main.rs":     KeyFileEntryPoint,
+	"lib.rs":      KeyFileEntryPoint,
+	"main.c":      KeyFileEntryPoint,
+	"main.cpp":    KeyFileEntryPoint,
+	"program.cs":  KeyFileEntryPoint,
+	"index.js":    KeyFileEntryPoint,
+	"index.ts":    KeyFileEntryPoint,
+	"main.js":     KeyFileEntryPoint,
+	"main.ts":     KeyFileEntryPoint,
+	"server.js":   KeyFileEntryPoint,
+	"server.ts":   KeyFileEntryPoint,
+	"app.js":      KeyFileEntryPoint,
+	"app.ts":      KeyFileEntryPoint,
+
+	// Package manifests
+	"go.mod":           KeyFileManifest,
+	"package.json":     KeyFileManifest,
+	"cargo.toml":       KeyFileManifest,
+	"pyproject.toml":   KeyFileManifest,
+	"setup.py":         KeyFileManifest,
+	"setup.cfg":        KeyFileManifest,
+	"requirements.txt": KeyFileManifest,
+	"gemfile":          KeyFileManifest,
+	"pom.xml":          KeyFileManifest,
+	"build.gradle":     KeyFileManifest,
+	"build.gradle.kts": KeyFileManifest,
+	"composer.json":    KeyFileManifest,
+	"package.swift":    KeyFileManifest,
+
+	// Build and deployment
+	"makefile":            KeyFileBuild,
+	"gnumakefile":         KeyFileBuild,
+	"dockerfile":          KeyFileBuild,
+	"docker-compose.yml":  KeyFileBuild,
+	"docker-compose.yaml": KeyFileBuild,
+	"compose.yml":         KeyFileBuild,
+	"compose.yaml":        KeyFileBuild,
+	"cmakelists.txt":      KeyFileBuild,
+	"justfile":            KeyFileBuild,
+	"taskfile.yml":        KeyFileBuild,
+
+	// Settings and configuration
+	"settings.py":   KeyFileConfig,
+	"settings.json": KeyFileConfig,
+	"tsconfig.json": KeyFileConfig,
+	".env.example":  KeyFileConfig,
+}
+
+// KeyFileKind reports why a file is a high-signal "key file" (an entry point,
+// manifest, build file, README, or configuration file), or "" if it is not one.
+// relPath is a slash-separated path relative to the target directory.
+func KeyFileKind(relPath string) string {
+	name := strings.ToLower(path.Base(relPath))
+	if kind, ok := keyFileNames[name]; ok {
+		return kind
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	switch {
+	case strings.HasPrefix(base, "readme"):
+		return KeyFileDocs
+	case strings.HasPrefix(name, "dockerfile."):
+		return KeyFileBuild
+	case ext == ".csproj" || ext == ".gemspec":
+		return KeyFileManifest
+	case base == "config" || base == "settings" || strings.HasSuffix(base, ".config"):
+		// config.yaml, settings.toml, vite.config.ts, ...
+		return KeyFileConfig
+	}
+	return ""
+}
+
+// IsKeyFile reports whether a file is a key file
+func IsKeyFile(relPath string) bool {
+	return KeyFileKind(relPath) != ""
+}
@@ -2,10 +2,14 @@ package analysis
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"codectx/internal/language"
+	"codectx/internal/utils"
 )
 
 // LanguageStats represents the language statistics for a project
@@ -36,9 +40,6 @@ func NewLanguageStats() *LanguageStats {
 func AnalyzeLanguages(rootDir string) (*LanguageStats, error) {
 	stats := NewLanguageStats()
 
-	// Map file extensions to languages
-	extToLang := getExtensionToLanguageMap()
-
 	// Track extensions for each language
 	langToExts := make(map[string]map[string]bool)
 
@@ -58,17 +59,13 @@ func AnalyzeLanguages(rootDir string) (*LanguageStats, error) {
 			return nil
 		}
 
-		// Get file extension
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == "" {
+		// Get the language from the file name, extension, shebang, or modeline
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+		detected, ok := language.DetectFile(path)
+		if !ok && ext == "" {
 			return nil
 		}
-
-		// Remove the leading dot
-		ext = ext[1:]
-
-		// Get language for this extension
-		lang, ok := extToLang[ext]
+		lang := detected.Name
 		if !ok {
 			lang = "Other"
 		}
@@ -86,10 +83,13 @@ func AnalyzeLanguages(rootDir string) (*LanguageStats, error) {
 			}
 		}
 
-		// Track extensions for this language
+		// Track extensions for this language, or the names of files without one
 		if _, ok := langToExts[lang]; !ok {
 			langToExts[lang] = make(map[string]bool)
 		}
+		if ext == "" {
+			ext = filepath.Base(path)
+		}
 		langToExts[lang][ext] = true
 
 		// Update total stats
@@ -134,137 +134,23 @@ func AnalyzeLanguages(rootDir string) (*LanguageStats, error) {
 }
 
 // PrintLanguageStats prints the language statistics
-func PrintLanguageStats(stats *LanguageStats) {
-	fmt.Println("\nLanguage Statistics:")
-	fmt.Println("====================")
+func PrintLanguageStats(stats *LanguageStats, units utils.SizeUnits, w io.Writer) {
+	fmt.Fprintln(w, "\nLanguage Statistics:")
+	fmt.Fprintln(w, "====================")
 
-	fmt.Printf("\nTotal files: %d\n", stats.TotalFiles)
-	fmt.Printf("Total size: %.2f MB\n", float64(stats.TotalSize)/(1024*1024))
+	fmt.Fprintf(w, "\nTotal files: %s\n", utils.FormatCount(stats.TotalFiles))
+	fmt.Fprintf(w, "Total size: %s\n", utils.FormatSize(stats.TotalSize, units))
 
-	fmt.Println("\nLanguage Distribution:")
+	fmt.Fprintln(w, "\nLanguage Distribution:")
 	for _, lang := range stats.TopLanguages {
-		fmt.Printf("  %s: %d files (%.1f%%) - %.2f KB\n",
-			lang.Name, lang.Files, lang.Percentage, float64(lang.Size)/1024)
+		fmt.Fprintf(w, "  %s: %s files (%.1f%%) - %s\n",
+			lang.Name, utils.FormatCount(lang.Files), lang.Percentage, utils.FormatSize(lang.Size, units))
 	}
 
-	fmt.Println("\nFile Extensions by Language:")
+	fmt.Fprintln(w, "\nFile Extensions by Language:")
 	for _, lang := range stats.TopLanguages {
 		if len(lang.Extensions) > 0 {
-			fmt.Printf("  %s: %s\n", lang.Name, strings.Join(lang.Extensions, ", "))
+			fmt.Fprintf(w, "  %s: %s\n", lang.Name, strings.Join(lang.Extensions, ", "))
 		}
 	}
 }
-
-// getExtensionToLanguageMap returns a map of file extensions to languages
-func getExtensionToLanguageMap() map[string]string {
-	return map[string]string{
-		// Go
-		"go": "Go",
-
-		// Web
-		"html": "HTML",
-		"htm":  "HTML",
-		"css":  "CSS",
-		"scss": "CSS",
-		"sass": "CSS",
-		"less": "CSS",
-		"js":   "JavaScript",
-		"jsx":  "JavaScript",
-		"ts":   "TypeScript",
-		"tsx":  "TypeScript",
-
-		// C-family
-		"c":   "C",
-		"h":   "C",
-		"cpp": "C++",
-		"hpp": "C++",
-		"cc":  "C++",
-		"cxx": "C++",
-		"c++": "C++",
-		"cs":  "C#",
-
-		// JVM
-		"java":   "Java",
-		"kt":     "Kotlin",
-		"kts":    "Kotlin",
-		"scala":  "Scala",
-		"groovy": "Groovy",
-
-		// Python
-		"py":  "Python",
-		"pyc": "Python",
-		"pyd": "Python",
-		"pyo": "Python",
-		"pyw": "Python",
-
-		// Ruby
-		"rb":  "Ruby",
-		"erb": "Ruby",
-
-		// PHP
-		"php": "PHP",
-
-		// Swift
-		"swift": "Swift",
-
-		// Rust
-		"rs": "Rust",
-
-		// Shell
-		"sh":   "Shell",
-		"bash": "Shell",
-		"zsh":  "Shell",
-
-		// Data formats
-		"json": "JSON",
-		"yaml": "YAML",
-		"yml":  "YAML",
-		"xml":  "XML",
-		"toml": "TOML",
-		"csv":  "CSV",
-		"tsv":  "CSV",
-
-		// Markdown and text
-		"md":       "Markdown",
-		"markdown": "Markdown",
-		"txt":      "Text",
-		"rst":      "Text",
-
-		// Config files
-		"ini":  "Config",
-		"cfg":  "Config",
-		"conf": "Config",
-
-		// Documentation
-		"pdf":  "Document",
-		"doc":  "Document",
-		"docx": "Document",
-		"odt":  "Document",
-
-		// Images
-		"png":  "Image",
-		"jpg":  "Image",
-		"jpeg": "Image",
-		"gif":  "Image",
-		"svg":  "Image",
-		"webp": "Image",
-		"ico":  "Image",
-
-		// Audio
-		"mp3": "Audio",
-		"wav": "Audio",
-		"ogg": "Audio",
-
-		// Video
-		"mp4":  "Video",
-		"webm": "Video",
-		"avi":  "Video",
-
-		// Archives
-		"zip": "Archive",
-		"tar": "Archive",
-		"gz":  "Archive",
-		"rar": "Archive",
-		"7z":  "Archive",
-	}
-}
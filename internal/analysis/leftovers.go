@@ -0,0 +1,202 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"codectx/internal/language"
+	"codectx/internal/utils"
+)
+
+// Kinds of leftovers
+const (
+	LeftoverConflict = "conflict" // An unresolved merge conflict
+	LeftoverDebug    = "debug"    // A debug statement in non-test code
+)
+
+// maxLeftoverFileSize is the size above which files are not searched for leftovers
+const maxLeftoverFileSize = 1024 * 1024
+
+// maxLeftoverText is the length at which a leftover's line is cut off
+const maxLeftoverText = 120
+
+// Leftover is a line that was likely committed by mistake
+type Leftover struct {
+	Path string `json:"path"` // Relative to the scanned directory, with slashes
+	Line int    `json:"line"`
+	Kind string `json:"kind"` // LeftoverConflict or LeftoverDebug
+	Text string `json:"text"`
+}
+
+// debugPatterns lists the debug statements flagged in non-test code, by language
+var debugPatterns = map[string][]*regexp.Regexp{
+	"go": {
+		goPrintPattern,
+		regexp.MustCompile(`^\s*print(ln)?\(`),
+		regexp.MustCompile(`\bspew\.Dump\(`),
+	},
+	"javascript": jsDebugPatterns,
+	"typescript": jsDebugPatterns,
+	"python": {
+		regexp.MustCompile(`\bbreakpoint\(\)`),
+		regexp.MustCompile(`\bi?pdb\.set_trace\(\)`),
+	},
+	"ruby": {
+		regexp.MustCompile(`\bbinding\.(pry|irb)\b`),
+		regexp.MustCompile(`^\s*byebug\b`),
+	},
+	"php": {
+		regexp.MustCompile(`\bvar_dump\(`),
+		regexp.MustCompile(`\bdd\(`),
+	},
+	"java": {
+		regexp.MustCompile(`\bSystem\.(out|err)\.print(ln)?\(`),
+		regexp.MustCompile(`\.printStackTrace\(\)`),
+	},
+	"rust": {
+		regexp.MustCompile(`\bdbg!\(`),
+	},
+}
+
+// goPrintPattern matches printing to stdout in Go, which commands do on purpose
+var goPrintPattern = regexp.MustCompile(`\bfmt\.Print(ln|f)?\(`)
+
+// jsDebugPatterns are the debug statements of JavaScript and TypeScript
+var jsDebugPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bconsole\.(log|debug|trace|dir)\(`),
+	regexp.MustCompile(`^\s*debugger\b`),
+}
+
+// CompileDebugPatterns compiles regular expressions matching debug statements,
+// in addition to the built-in ones
+func CompileDebugPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// FindLeftovers searches the text files under rootDir for merge conflict
+// markers, and the source files that aren't tests for debug statements: the
+// built-in ones of their language and those matching extra. Commented out
+// lines, and fmt.Print calls in Go commands, are ignored. Dependency and build
+// directories are skipped.
+func FindLeftovers(rootDir string, extra []*regexp.Regexp) ([]Leftover, error) {
+	var leftovers []Leftover
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != rootDir && (info.Name() == ".git" || projectSkipDirs[info.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Size() > maxLeftoverFileSize {
+			return nil
+		}
+		if isBinary, err := isBinaryFile(path); err != nil || isBinary {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		var patterns []*regexp.Regexp
+		lang, ok := language.Detect(path)
+		if ok && lang.Class != language.ClassData && lang.Class != language.ClassText && !IsTestFile(relPath) {
+			patterns = slices.Concat(debugPatterns[lang.ID], extra)
+			if lang.ID == "go" && isGoCommand(relPath) {
+				patterns = slices.DeleteFunc(patterns, func(re *regexp.Regexp) bool { return re == goPrintPattern })
+			}
+		}
+		found, err := findFileLeftovers(path, lang.LineComment, patterns)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+		for _, leftover := range found {
+			leftover.Path = relPath
+			leftovers = append(leftovers, leftover)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find leftovers: %w", err)
+	}
+	return leftovers, nil
+}
+
+// findFileLeftovers returns the conflict markers of a file and the lines
+// matching a debug pattern, skipping those starting with lineComment
+func findFileLeftovers(path, lineComment string, patterns []*regexp.Regexp) ([]Leftover, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var leftovers []Leftover
+	reader := utils.NewLineReader(file, 0)
+	for reader.Scan() {
+		line, text := reader.LineNumber(), reader.Text()
+		if isConflictMarker(text) {
+			leftovers = append(leftovers, Leftover{Line: line, Kind: LeftoverConflict, Text: leftoverText(text)})
+			continue
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+		trimmed := strings.TrimSpace(text)
+		if lineComment != "" && strings.HasPrefix(trimmed, lineComment) {
+			continue
+		}
+		for _, pattern := range patterns {
+			if pattern.MatchString(text) {
+				leftovers = append(leftovers, Leftover{Line: line, Kind: LeftoverDebug, Text: leftoverText(trimmed)})
+				break
+			}
+		}
+	}
+	return leftovers, reader.Err()
+}
+
+// isGoCommand reports whether a Go file likely belongs to a command, which
+// prints its output: main.go or a file under a cmd directory
+func isGoCommand(relPath string) bool {
+	return path.Base(relPath) == "main.go" || strings.HasPrefix(relPath, "cmd/") || strings.Contains(relPath, "/cmd/")
+}
+
+// isConflictMarker reports whether a line starts an unresolved merge conflict,
+// as "<<<<<<< HEAD" does
+func isConflictMarker(line string) bool {
+	rest, ok := strings.CutPrefix(line, "<<<<<<<")
+	return ok && (rest == "" || rest[0] == ' ')
+}
+
+// leftoverText trims a leftover's line and cuts it off at maxLeftoverText
+func leftoverText(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= maxLeftoverText {
+		return text
+	}
+	cut := maxLeftoverText
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
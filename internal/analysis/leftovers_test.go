@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindLeftovers(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_leftovers_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go":                   "package main\n\nfunc main() {\n\tfmt.Println(\"usage\")\n}\n",
+		"app/app.go":                "package app\n\nfunc Run() {\n\tfmt.Println(\"here\")\n\t// fmt.Println(\"commented out\")\n\tfmt.Fprintln(os.Stderr, \"fine\")\n}\n",
+		"main_test.go":              "package main\n\nfunc TestMain(t *testing.T) {\n\tfmt.Println(\"tests may print\")\n}\n",
+		"src/app.js":                "function run() {\n  console.log('debug');\n  debugger;\n  trace('custom');\n}\n",
+		"src/app.test.js":           "console.log('tests may log');\n",
+		"config.yaml":               "name: app\n<<<<<<< HEAD\nport: 80\n=======\nport: 8080\n>>>>>>> feature\n",
+		"README.md":                 "Call `console.log(value)` to print.\n\n<<<<<<<< not a marker\n",
+		"node_modules/lib/index.js": "console.log('vendored');\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	extra, err := CompileDebugPatterns([]string{`\btrace\(`})
+	if err != nil {
+		t.Fatalf("CompileDebugPatterns failed: %v", err)
+	}
+	leftovers, err := FindLeftovers(tempDir, extra)
+	if err != nil {
+		t.Fatalf("FindLeftovers failed: %v", err)
+	}
+
+	expected := []Leftover{
+		{Path: "app/app.go", Line: 4, Kind: LeftoverDebug, Text: `fmt.Println("here")`},
+		{Path: "config.yaml", Line: 2, Kind: LeftoverConflict, Text: "<<<<<<< HEAD"},
+		{Path: "src/app.js", Line: 2, Kind: LeftoverDebug, Text: "console.log('debug');"},
+		{Path: "src/app.js", Line: 3, Kind: LeftoverDebug, Text: "debugger;"},
+		{Path: "src/app.js", Line: 4, Kind: LeftoverDebug, Text: "trace('custom');"},
+	}
+	if !reflect.DeepEqual(leftovers, expected) {
+		t.Errorf("Expected leftovers %+v, got %+v", expected, leftovers)
+	}
+
+	health := NewHealthCheck()
+	health.AddLeftovers(leftovers)
+	expectedWarnings := []string{"Merge conflict markers: 1", "Debug statements in non-test code: 4"}
+	if !reflect.DeepEqual(health.Warnings, expectedWarnings) {
+		t.Errorf("Expected warnings %v, got %v", expectedWarnings, health.Warnings)
+	}
+
+	if _, err := CompileDebugPatterns([]string{"("}); err == nil {
+		t.Errorf("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxOwners is the number of primary contributors listed per directory
+const maxOwners = 3
+
+// Ownership is who changed the files of a project, overall and per directory
+type Ownership struct {
+	Contributors int                  `json:"contributors"`
+	BusFactor    int                  `json:"bus_factor"`
+	Owners       []Owner              `json:"owners"`
+	Directories  []DirectoryOwnership `json:"directories"`
+}
+
+// DirectoryOwnership is who changed the files directly in a directory
+type DirectoryOwnership struct {
+	Path      string  `json:"path"` // "." for the root directory
+	Changes   int     `json:"changes"`
+	BusFactor int     `json:"bus_factor"`
+	Owners    []Owner `json:"owners"` // Primary contributors, most changes first
+}
+
+// Owner is a contributor and their share of the changes
+type Owner struct {
+	Name    string  `json:"name"`
+	Changes int     `json:"changes"`
+	Share   float64 `json:"share"` // Percentage of the changes
+}
+
+// ComputeOwnership computes the ownership of the given files, relative paths
+// with slashes, from the commits each author made to each file (as returned
+// by git.GetAuthors). A change is a commit to a file. The bus factor is the
+// number of contributors who together made more than half of the changes, so
+// a directory with a bus factor of 1 depends on a single person.
+func ComputeOwnership(paths []string, authors map[string]map[string]int) *Ownership {
+	total := make(map[string]int)
+	byDir := make(map[string]map[string]int)
+	for _, relPath := range paths {
+		dir := path.Dir(relPath)
+		for author, changes := range authors[relPath] {
+			total[author] += changes
+			if byDir[dir] == nil {
+				byDir[dir] = make(map[string]int)
+			}
+			byDir[dir][author] += changes
+		}
+	}
+
+	owners, busFactor := rankOwners(total)
+	ownership := &Ownership{Contributors: len(total), BusFactor: busFactor, Owners: owners}
+	for dir, changes := range byDir {
+		owners, busFactor := rankOwners(changes)
+		directory := DirectoryOwnership{Path: dir, BusFactor: busFactor, Owners: owners}
+		for _, n := range changes {
+			directory.Changes += n
+		}
+		if len(directory.Owners) > maxOwners {
+			directory.Owners = directory.Owners[:maxOwners]
+		}
+		ownership.Directories = append(ownership.Directories, directory)
+	}
+	sort.Slice(ownership.Directories, func(i, j int) bool {
+		return ownership.Directories[i].Path < ownership.Directories[j].Path
+	})
+	if len(ownership.Owners) > maxOwners {
+		ownership.Owners = ownership.Owners[:maxOwners]
+	}
+	return ownership
+}
+
+// rankOwners sorts contributors by their changes and returns them with the
+// bus factor
+func rankOwners(changes map[string]int) ([]Owner, int) {
+	total := 0
+	for _, n := range changes {
+		total += n
+	}
+	owners := make([]Owner, 0, len(changes))
+	for name, n := range changes {
+		share := math.Round(float64(n)*1000/float64(total)) / 10
+		owners = append(owners, Owner{Name: name, Changes: n, Share: share})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Changes != owners[j].Changes {
+			return owners[i].Changes > owners[j].Changes
+		}
+		return owners[i].Name < owners[j].Name
+	})
+
+	busFactor, covered := 0, 0
+	for _, owner := range owners {
+		if covered*2 > total {
+			break
+		}
+		covered += owner.Changes
+		busFactor++
+	}
+	return owners, busFactor
+}
+
+// PrintOwnership prints the primary contributors and bus factors
+func PrintOwnership(ownership *Ownership, w io.Writer) {
+	fmt.Fprintln(w, "\nOwnership:")
+	fmt.Fprintln(w, "==========")
+	if ownership.Contributors == 0 {
+		fmt.Fprintln(w, "  No commits found for the included files")
+		return
+	}
+	fmt.Fprintf(w, "  Contributors: %d\n", ownership.Contributors)
+	fmt.Fprintf(w, "  Bus factor: %d\n", ownership.BusFactor)
+	fmt.Fprintf(w, "  Primary contributors: %s\n", formatOwners(ownership.Owners))
+
+	fmt.Fprintln(w, "\n  By directory:")
+	for _, dir := range ownership.Directories {
+		fmt.Fprintf(w, "    %s: %s (bus factor %d, %s)\n",
+			dir.Path, formatOwners(dir.Owners), dir.BusFactor, plural(dir.Changes, "change"))
+	}
+}
+
+// formatOwners lists owners with their shares, such as "alice 62.5%, bob 37.5%"
+func formatOwners(owners []Owner) string {
+	parts := make([]string, len(owners))
+	for i, owner := range owners {
+		parts[i] = fmt.Sprintf("%s %.1f%%", owner.Name, owner.Share)
+	}
+	return strings.Join(parts, ", ")
+}
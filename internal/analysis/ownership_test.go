@@ -0,0 +1,40 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeOwnership(t *testing.T) {
+	authors := map[string]map[string]int{
+		"main.go":        {"alice": 6, "bob": 2},
+		"cmd/run.go":     {"alice": 2, "bob": 2, "carol": 1, "dave": 1},
+		"cmd/root.go":    {"bob": 2},
+		"docs/guide.md":  {"erin": 1},
+		"vendor/skip.go": {"mallory": 9}, // Not included
+	}
+	paths := []string{"main.go", "cmd/run.go", "cmd/root.go", "docs/guide.md", "README.md"}
+
+	expected := &Ownership{
+		Contributors: 5,
+		BusFactor:    2,
+		Owners: []Owner{
+			{Name: "alice", Changes: 8, Share: 47.1},
+			{Name: "bob", Changes: 6, Share: 35.3},
+			{Name: "carol", Changes: 1, Share: 5.9},
+		},
+		Directories: []DirectoryOwnership{
+			{Path: ".", Changes: 8, BusFactor: 1, Owners: []Owner{{Name: "alice", Changes: 6, Share: 75}, {Name: "bob", Changes: 2, Share: 25}}},
+			{Path: "cmd", Changes: 8, BusFactor: 2, Owners: []Owner{
+				{Name: "bob", Changes: 4, Share: 50},
+				{Name: "alice", Changes: 2, Share: 25},
+				{Name: "carol", Changes: 1, Share: 12.5},
+			}},
+			{Path: "docs", Changes: 1, BusFactor: 1, Owners: []Owner{{Name: "erin", Changes: 1, Share: 100}}},
+		},
+	}
+
+	if got := ComputeOwnership(paths, authors); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
@@ -0,0 +1,281 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MetadataFinding lists the personal metadata embedded in a file
+type MetadataFinding struct {
+	Path   string   `json:"path"`
+	Fields []string `json:"fields"`
+}
+
+// maxMetadataScan bounds how much of a file is read when looking for metadata
+const maxMetadataScan = 16 * 1024 * 1024
+
+// Personal fields of EXIF blocks, by IFD tag
+var exifPersonalTags = map[uint16]string{
+	0x013B: "artist",
+	0x9C9D: "author",
+	0xA430: "camera owner",
+	0xA431: "camera serial number",
+}
+
+// EXIF pointers to sub-IFDs
+const (
+	exifIFDPointer = 0x8769
+	gpsIFDPointer  = 0x8825
+	gpsLatitude    = 0x0002
+)
+
+// pdfAuthorPattern matches a non-empty /Author entry in a PDF's document information
+var pdfAuthorPattern = regexp.MustCompile(`/Author\s*(?:\((?:[^)\\]|\\.)+\)|<[0-9A-Fa-f]{2,}>)`)
+
+// HasMetadataSupport reports whether ScanPersonalMetadata can inspect a file
+func HasMetadataSupport(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".png", ".tif", ".tiff", ".webp", ".docx", ".xlsx", ".pptx", ".odt", ".ods", ".odp", ".pdf":
+		return true
+	}
+	return false
+}
+
+// ScanPersonalMetadata returns the personal metadata fields embedded in an image,
+// office document, or PDF: EXIF GPS positions, artists, and camera owners in
+// images, and authors in documents. It returns nil for other files.
+func ScanPersonalMetadata(path string) ([]string, error) {
+	if !HasMetadataSupport(path) {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxMetadataScan))
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return jpegMetadata(data), nil
+	case ".png":
+		return pngMetadata(data), nil
+	case ".tif", ".tiff":
+		return exifMetadata(data), nil
+	case ".webp":
+		return webpMetadata(data), nil
+	case ".docx", ".xlsx", ".pptx":
+		return officeMetadata(data, "docProps/core.xml", map[string]string{"creator": "author", "lastModifiedBy": "last modified by"}), nil
+	case ".odt", ".ods", ".odp":
+		return officeMetadata(data, "meta.xml", map[string]string{"initial-creator": "author", "creator": "last modified by"}), nil
+	case ".pdf":
+		if pdfAuthorPattern.Match(data) {
+			return []string{"author"}, nil
+		}
+	}
+	return nil, nil
+}
+
+// jpegMetadata reads the EXIF block from a JPEG's APP1 segment
+func jpegMetadata(data []byte) []string {
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data starts; metadata segments come before it
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		if segment := data[pos+4 : end]; marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifMetadata(segment[6:])
+		}
+		pos = end
+	}
+	return nil
+}
+
+// pngMetadata reads eXIf chunks and Author text chunks from a PNG
+func pngMetadata(data []byte) []string {
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return nil
+	}
+	var fields []string
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		kind := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		chunk := data[pos+8 : end]
+		switch kind {
+		case "eXIf":
+			fields = appendFields(fields, exifMetadata(chunk)...)
+		case "tEXt", "iTXt", "zTXt":
+			if keyword, _, ok := bytes.Cut(chunk, []byte{0}); ok && strings.EqualFold(string(keyword), "Author") {
+				fields = appendFields(fields, "author")
+			}
+		case "IEND":
+			return fields
+		}
+		pos = end + 4 // Skip the CRC
+	}
+	return fields
+}
+
+// webpMetadata reads the EXIF chunk of an extended WebP file
+func webpMetadata(data []byte) []string {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	for pos := 12; pos+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		if string(data[pos:pos+4]) == "EXIF" {
+			return exifMetadata(bytes.TrimPrefix(data[pos+8:end], []byte("Exif\x00\x00")))
+		}
+		pos = end + length%2 // Chunks are padded to an even size
+	}
+	return nil
+}
+
+// exifMetadata reads the personal fields of a TIFF-structured EXIF block
+func exifMetadata(data []byte) []string {
+	if len(data) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	var fields []string
+	ifd0 := readIFD(data, order, order.Uint32(data[4:8]))
+	for _, ifd := range []map[uint16][]byte{ifd0, readIFD(data, order, pointerValue(ifd0, order, exifIFDPointer))} {
+		for tag, label := range exifPersonalTags {
+			if value, ok := ifd[tag]; ok && len(bytes.Trim(value, "\x00 ")) > 0 {
+				fields = appendFields(fields, label)
+			}
+		}
+	}
+	if gps := readIFD(data, order, pointerValue(ifd0, order, gpsIFDPointer)); gps[gpsLatitude] != nil {
+		fields = appendFields(fields, "GPS location")
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// readIFD reads the entries of an image file directory, mapping each tag to its value bytes
+func readIFD(data []byte, order binary.ByteOrder, offset uint32) map[uint16][]byte {
+	entries := make(map[uint16][]byte)
+	if offset == 0 || int(offset)+2 > len(data) {
+		return entries
+	}
+	count := int(order.Uint16(data[offset:]))
+	typeSizes := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+	for i := 0; i < count; i++ {
+		pos := int(offset) + 2 + i*12
+		if pos+12 > len(data) {
+			break
+		}
+		tag := order.Uint16(data[pos:])
+		size := typeSizes[order.Uint16(data[pos+2:])] * int(order.Uint32(data[pos+4:]))
+		if size <= 4 {
+			entries[tag] = data[pos+8 : pos+8+size]
+			continue
+		}
+		start := int(order.Uint32(data[pos+8:]))
+		if start >= 0 && start+size <= len(data) {
+			entries[tag] = data[start : start+size]
+		}
+	}
+	return entries
+}
+
+// pointerValue returns the offset stored in a LONG pointer entry, or 0
+func pointerValue(ifd map[uint16][]byte, order binary.ByteOrder, tag uint16) uint32 {
+	if value := ifd[tag]; len(value) == 4 {
+		return order.Uint32(value)
+	}
+	return 0
+}
+
+// officeMetadata reads the non-empty author elements of an office document's
+// metadata part. labels maps element local names to field labels.
+func officeMetadata(data []byte, part string, labels map[string]string) []string {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	entry, err := archive.Open(part)
+	if err != nil {
+		return nil
+	}
+	defer entry.Close()
+
+	var fields []string
+	decoder := xml.NewDecoder(io.LimitReader(entry, maxMetadataScan))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		label, ok := labels[start.Name.Local]
+		if !ok {
+			continue
+		}
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err == nil && strings.TrimSpace(text) != "" {
+			fields = appendFields(fields, label)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// appendFields appends labels that are not already present
+func appendFields(fields []string, labels ...string) []string {
+	for _, label := range labels {
+		found := false
+		for _, field := range fields {
+			if field == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fields = append(fields, label)
+		}
+	}
+	return fields
+}
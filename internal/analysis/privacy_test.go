@@ -0,0 +1,115 @@
+package analysis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanPersonalMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "privacy_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	exif := buildEXIF()
+	jpeg := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, byte((len(exif)+8)>>8), byte(len(exif)+8))
+	jpeg = append(append(jpeg, "Exif\x00\x00"...), exif...)
+	jpeg = append(jpeg, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9)
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	png = append(png, pngChunk("tEXt", "Author\x00Jane Doe")...)
+	png = append(png, pngChunk("IEND", "")...)
+
+	// Office documents are zip archives; each holds just its metadata part
+	office := make(map[string][]byte)
+	for name, part := range map[string][2]string{
+		"spec.docx": {"docProps/core.xml", `<cp:coreProperties xmlns:cp="cp" xmlns:dc="dc"><dc:creator>Jane Doe</dc:creator><cp:lastModifiedBy></cp:lastModifiedBy></cp:coreProperties>`},
+		"notes.odt": {"meta.xml", `<office:document-meta xmlns:office="o" xmlns:meta="m"><office:meta><meta:initial-creator>Jane</meta:initial-creator></office:meta></office:document-meta>`},
+	} {
+		var buf bytes.Buffer
+		writer := zip.NewWriter(&buf)
+		file, err := writer.Create(part[0])
+		if err == nil {
+			_, err = file.Write([]byte(part[1]))
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			t.Fatalf("Failed to build %s: %v", name, err)
+		}
+		office[name] = buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{"photo.jpg", jpeg, []string{"GPS location", "artist"}},
+		{"diagram.png", png, []string{"author"}},
+		{"plain.png", []byte("\x89PNG\r\n\x1a\n" + string(pngChunk("IEND", ""))), nil},
+		{"spec.docx", office["spec.docx"], []string{"author"}},
+		{"notes.odt", office["notes.odt"], []string{"author"}},
+		{"design.pdf", []byte("%PDF-1.4\n1 0 obj << /Title (Spec) /Author (Jane Doe) >> endobj"), []string{"author"}},
+		{"blank.pdf", []byte("%PDF-1.4\n1 0 obj << /Title (Spec) /Author () >> endobj"), nil},
+		{"main.go", []byte("package main // /Author (x)"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			fields, err := ScanPersonalMetadata(path)
+			if err != nil {
+				t.Fatalf("ScanPersonalMetadata failed: %v", err)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+// buildEXIF builds a little-endian EXIF block with an artist and a GPS latitude
+func buildEXIF() []byte {
+	le := binary.LittleEndian
+	data := []byte("II*\x00")
+	data = le.AppendUint32(data, 8)
+
+	// IFD0 at offset 8: Artist (ASCII, stored at offset 38) and the GPS pointer (offset 44)
+	data = le.AppendUint16(data, 2)
+	data = appendIFDEntry(data, 0x013B, 2, 6, 38)
+	data = appendIFDEntry(data, 0x8825, 4, 1, 44)
+	data = le.AppendUint32(data, 0)
+	data = append(data, "Alice\x00"...)
+
+	// GPS IFD at offset 44: GPSLatitude (RATIONAL x3, stored at offset 62)
+	data = le.AppendUint16(data, 1)
+	data = appendIFDEntry(data, 0x0002, 5, 3, 62)
+	data = le.AppendUint32(data, 0)
+	return append(data, make([]byte, 24)...)
+}
+
+func appendIFDEntry(data []byte, tag, kind uint16, count, value uint32) []byte {
+	le := binary.LittleEndian
+	data = le.AppendUint16(data, tag)
+	data = le.AppendUint16(data, kind)
+	data = le.AppendUint32(data, count)
+	return le.AppendUint32(data, value)
+}
+
+func pngChunk(kind, content string) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(content)))
+	chunk = append(chunk, kind+content...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE([]byte(kind+content)))
+}
@@ -0,0 +1,210 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// projectManifests lists the files that mark the root of a project, in order of
+// preference when a directory has several
+var projectManifests = []string{"go.mod", "package.json", "pyproject.toml", "pom.xml", "Cargo.toml"}
+
+// projectSkipDirs are directories never searched for projects
+var projectSkipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"__pycache__":  true,
+}
+
+var (
+	goModulePattern    = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	tomlNamePattern    = regexp.MustCompile(`(?m)^name\s*=\s*["']([^"']+)["']`)
+	pomParentPattern   = regexp.MustCompile(`(?s)<parent>.*?</parent>`)
+	pomArtifactPattern = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+)
+
+// Project is a project in a repository, rooted at a directory holding a manifest
+// such as go.mod or package.json. Monorepos hold several.
+type Project struct {
+	Name     string `json:"name"`     // Name from the manifest, or the directory name
+	Path     string `json:"path"`     // Directory relative to the scanned root, with slashes ("." for the root)
+	Manifest string `json:"manifest"` // File name of the manifest
+
+	// Totals of the files in the project, but not in its nested projects
+	Files     int            `json:"files"`
+	Size      int64          `json:"size_bytes"`
+	Tokens    int            `json:"estimated_tokens"`
+	Languages map[string]int `json:"languages,omitempty"` // Number of files per language
+}
+
+// DetectProjects finds the projects under rootDir, sorted by path. Dependency,
+// build output, and hidden directories are not searched.
+func DetectProjects(rootDir string) ([]Project, error) {
+	var projects []Project
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != rootDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != rootDir && (projectSkipDirs[name] || strings.HasPrefix(name, ".")) {
+			return filepath.SkipDir
+		}
+
+		for _, manifest := range projectManifests {
+			data, err := os.ReadFile(filepath.Join(path, manifest))
+			if err != nil {
+				continue
+			}
+			relPath, err := filepath.Rel(rootDir, path)
+			if err != nil {
+				return err
+			}
+			project := Project{Path: filepath.ToSlash(relPath), Manifest: manifest, Name: projectName(manifest, data)}
+			if project.Name == "" {
+				project.Name = filepath.Base(path)
+			}
+			projects = append(projects, project)
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect projects: %w", err)
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Path < projects[j].Path
+	})
+	return projects, nil
+}
+
+// projectName reads the name of a project from its manifest
+func projectName(manifest string, data []byte) string {
+	switch manifest {
+	case "go.mod":
+		if match := goModulePattern.FindSubmatch(data); match != nil {
+			return string(match[1])
+		}
+	case "package.json":
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			return pkg.Name
+		}
+	case "pyproject.toml", "Cargo.toml":
+		if match := tomlNamePattern.FindSubmatch(data); match != nil {
+			return string(match[1])
+		}
+	case "pom.xml":
+		data = pomParentPattern.ReplaceAll(data, nil)
+		if match := pomArtifactPattern.FindSubmatch(data); match != nil {
+			return string(match[1])
+		}
+	}
+	return ""
+}
+
+// FindProject returns the project with the given name or path. The last element
+// of a name or path also matches if it is unique, so "api" finds
+// "example.com/services/api".
+func FindProject(projects []Project, name string) (*Project, error) {
+	name = strings.TrimSuffix(filepath.ToSlash(name), "/")
+	for i := range projects {
+		if projects[i].Name == name || projects[i].Path == name {
+			return &projects[i], nil
+		}
+	}
+
+	var matches []*Project
+	for i := range projects {
+		if lastElement(projects[i].Name) == name || lastElement(projects[i].Path) == name {
+			matches = append(matches, &projects[i])
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	names := make([]string, len(projects))
+	for i, project := range projects {
+		names[i] = project.Name
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("project %q is ambiguous (detected: %s)", name, strings.Join(names, ", "))
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("project %q not found (no projects detected)", name)
+	}
+	return nil, fmt.Errorf("project %q not found (detected: %s)", name, strings.Join(names, ", "))
+}
+
+// ProjectFor returns the innermost project containing relPath (relative to the
+// scanned root, with slashes), or nil if it is in none
+func ProjectFor(projects []Project, relPath string) *Project {
+	var found *Project
+	for i := range projects {
+		dir := projects[i].Path
+		if dir == "." || relPath == dir || strings.HasPrefix(relPath, dir+"/") {
+			if found == nil || len(dir) > len(found.Path) || found.Path == "." {
+				found = &projects[i]
+			}
+		}
+	}
+	return found
+}
+
+// lastElement returns the part of a slash-separated name after the last slash
+func lastElement(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// PrintProjects prints the totals of each project
+func PrintProjects(projects []Project, units utils.SizeUnits, w io.Writer) {
+	fmt.Fprintln(w, "\nProjects:")
+	for _, project := range projects {
+		fmt.Fprintf(w, "  %s (%s, %s): %s files, %s, ~%s tokens",
+			project.Name, project.Path, project.Manifest, utils.FormatCount(project.Files), utils.FormatSize(project.Size, units), utils.FormatCount(project.Tokens))
+		if langs := formatLanguageCounts(project.Languages); langs != "" {
+			fmt.Fprintf(w, " - %s", langs)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// formatLanguageCounts lists languages with their file counts, most common first
+func formatLanguageCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
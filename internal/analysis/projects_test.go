@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectProjects(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_projects_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"go.mod":                            "module example.com/mono\n\ngo 1.22\n",
+		"services/api/go.mod":               "// API server\nmodule example.com/mono/services/api\n",
+		"web/package.json":                  `{"name": "@mono/web", "version": "1.0.0"}`,
+		"web/node_modules/dep/package.json": `{"name": "dep"}`,
+		"tools/pyproject.toml":              "[project]\nname = \"mono-tools\"\n",
+		"java/pom.xml":                      "<project><parent><artifactId>base</artifactId></parent><artifactId>core</artifactId></project>",
+		"misc/package.json":                 `{"private": true}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	projects, err := DetectProjects(tempDir)
+	if err != nil {
+		t.Fatalf("DetectProjects failed: %v", err)
+	}
+
+	expected := []Project{
+		{Name: "example.com/mono", Path: ".", Manifest: "go.mod"},
+		{Name: "core", Path: "java", Manifest: "pom.xml"},
+		{Name: "misc", Path: "misc", Manifest: "package.json"},
+		{Name: "example.com/mono/services/api", Path: "services/api", Manifest: "go.mod"},
+		{Name: "mono-tools", Path: "tools", Manifest: "pyproject.toml"},
+		{Name: "@mono/web", Path: "web", Manifest: "package.json"},
+	}
+	if len(projects) != len(expected) {
+		t.Fatalf("Expected %d projects, got %d: %+v", len(expected), len(projects), projects)
+	}
+	for i, want := range expected {
+		got := projects[i]
+		if got.Name != want.Name || got.Path != want.Path || got.Manifest != want.Manifest {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	projects := []Project{
+		{Name: "example.com/mono", Path: "."},
+		{Name: "example.com/mono/services/api", Path: "services/api"},
+		{Name: "@mono/web", Path: "web"},
+		{Name: "api", Path: "legacy/api"},
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"@mono/web", "web", false},
+		{"web", "web", false},
+		{"services/api", "services/api", false},
+		{"services/api/", "services/api", false},
+		{"api", "legacy/api", false},
+		{"mono", ".", false},
+		{"missing", "", true},
+	}
+
+	for _, tt := range tests {
+		project, err := FindProject(projects, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FindProject(%q): expected error %v, got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if err == nil && project.Path != tt.expected {
+			t.Errorf("FindProject(%q): expected %q, got %q", tt.name, tt.expected, project.Path)
+		}
+	}
+}
+
+func TestProjectFor(t *testing.T) {
+	projects := []Project{
+		{Name: "root", Path: "."},
+		{Name: "api", Path: "services/api"},
+		{Name: "web", Path: "web"},
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"main.go", "root"},
+		{"services/api/main.go", "api"},
+		{"services/apikeys/main.go", "root"},
+		{"web/src/index.js", "web"},
+	}
+
+	for _, tt := range tests {
+		project := ProjectFor(projects, tt.path)
+		if project == nil || project.Name != tt.expected {
+			t.Errorf("ProjectFor(%q): expected %q, got %+v", tt.path, tt.expected, project)
+		}
+	}
+	if project := ProjectFor(projects[1:], "main.go"); project != nil {
+		t.Errorf("Expected no project, got %+v", project)
+	}
+}
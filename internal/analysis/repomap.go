@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codectx/internal/coverage"
+	"codectx/internal/platform"
+)
+
+// DefaultRepoMapTokens is the default token budget of a repository map
+const DefaultRepoMapTokens = 1024
+
+// RepoMap is a compact, ranked overview of the declarations in a repository
+type RepoMap struct {
+	Workspaces *WorkspaceGraph `json:"workspaces,omitempty"` // The JS/TS workspaces included, listed first
+	Contracts  []Contract      `json:"contracts,omitempty"`  // Listed ahead of the files, whatever the budget
+	Files      []RepoMapFile   `json:"files"`
+	Tokens     int             `json:"estimated_tokens"`
+	Omitted    int             `json:"omitted_files"` // Files left out to stay within the budget
+}
+
+// RepoMapFile lists the declarations of one file in the map
+type RepoMapFile struct {
+	Path       string         `json:"path"`
+	Score      float64        `json:"score"`
+	KeyFile    bool           `json:"key_file,omitempty"`
+	Signatures []Signature    `json:"signatures"`
+	Coverage   *coverage.File `json:"coverage,omitempty"`
+}
+
+// identifierPattern finds identifiers when counting references between files
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// BuildRepoMap extracts signatures from the given files and ranks the files by
+// how often their declarations are referenced from other files, after key files. Files are added
+// in rank order while they fit within the token budget (0 for no budget), after
+// the workspace graph of a JS/TS monorepo and the operations of the API
+// contracts, which are always listed.
+// paths are slash-separated paths relative to rootDir, without a leading slash.
+func BuildRepoMap(rootDir string, paths []string, tokenBudget int) (*RepoMap, error) {
+	// Extract the declarations of every supported file
+	var candidates []RepoMapFile
+	definedIn := make(map[string][]int)
+	for _, relPath := range paths {
+		if !HasSignatureSupport(relPath) {
+			continue
+		}
+		signatures, err := ExtractSignatures(platform.JoinSlash(rootDir, relPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract signatures from %s: %w", relPath, err)
+		}
+		if len(signatures) == 0 {
+			continue
+		}
+
+		index := len(candidates)
+		candidates = append(candidates, RepoMapFile{Path: relPath, KeyFile: IsKeyFile(relPath), Signatures: signatures})
+		for _, sig := range signatures {
+			if len(sig.Name) >= 3 {
+				definedIn[sig.Name] = appendUnique(definedIn[sig.Name], index)
+			}
+		}
+	}
+
+	// Score each file by the references to its declarations from other files
+	for _, relPath := range paths {
+		content, err := os.ReadFile(platform.JoinSlash(rootDir, relPath))
+		if err != nil {
+			continue
+		}
+		for _, ident := range identifierPattern.FindAllString(string(content), -1) {
+			definers := definedIn[ident]
+			for _, index := range definers {
+				if candidates[index].Path != relPath {
+					candidates[index].Score += 1 / float64(len(definers))
+				}
+			}
+		}
+	}
+
+	// Rank key files first, then by score, preferring shallower paths on ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].KeyFile != candidates[j].KeyFile {
+			return candidates[i].KeyFile
+		}
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		di, dj := strings.Count(candidates[i].Path, "/"), strings.Count(candidates[j].Path, "/")
+		if di != dj {
+			return di < dj
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	// Fill the budget in rank order, skipping files that no longer fit
+	repoMap := &RepoMap{Contracts: FindContracts(rootDir, paths), Files: []RepoMapFile{}}
+	graph, err := DetectWorkspaces(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if repoMap.Workspaces = graph.including(paths); repoMap.Workspaces != nil {
+		repoMap.Tokens += estimateWorkspaceTokens(repoMap.Workspaces)
+	}
+	for _, contract := range repoMap.Contracts {
+		repoMap.Tokens += estimateContractTokens(contract)
+	}
+	for _, file := range candidates {
+		cost := estimateMapTokens(file)
+		if tokenBudget > 0 && repoMap.Tokens+cost > tokenBudget {
+			repoMap.Omitted++
+			continue
+		}
+		repoMap.Files = append(repoMap.Files, file)
+		repoMap.Tokens += cost
+	}
+	return repoMap, nil
+}
+
+// AddCoverage annotates the files of the map with their test coverage
+func (m *RepoMap) AddCoverage(report *coverage.Report) {
+	for i := range m.Files {
+		if file, ok := report.Lookup(m.Files[i].Path); ok {
+			m.Files[i].Coverage = file
+		}
+	}
+}
+
+// estimateMapTokens estimates the tokens a file's entry adds to the map (about 4 characters per token)
+func estimateMapTokens(file RepoMapFile) int {
+	size := len(file.Path) + 2
+	for _, sig := range file.Signatures {
+		size += len(sig.Text) + 8
+	}
+	return (size + 3) / 4
+}
+
+// estimateContractTokens estimates the tokens a contract's entry adds to the map
+func estimateContractTokens(contract Contract) int {
+	size := len(contract.Path) + len(contract.Kind) + 5
+	for _, operation := range contract.Operations {
+		size += len(operation) + 4
+	}
+	return (size + 3) / 4
+}
+
+// estimateWorkspaceTokens estimates the tokens the workspace graph adds to the map
+func estimateWorkspaceTokens(graph *WorkspaceGraph) int {
+	size := 16
+	for _, workspace := range graph.Workspaces {
+		size += len(workspace.Name) + len(workspace.Path) + 8
+		for _, dependency := range workspace.Dependencies {
+			size += len(dependency) + 2
+		}
+	}
+	return (size + 3) / 4
+}
+
+// appendUnique appends index unless it is already the last element
+func appendUnique(indexes []int, index int) []int {
+	if n := len(indexes); n > 0 && indexes[n-1] == index {
+		return indexes
+	}
+	return append(indexes, index)
+}
@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchSignature(t *testing.T) {
+	tests := []struct {
+		ext          string
+		line         string
+		expectedKind string
+		expectedName string
+	}{
+		{ext: ".go", line: "func (s *Scanner) Scan() (*FileEntry, error) {", expectedKind: "method", expectedName: "Scan"},
+		{ext: ".go", line: "func NewScanner(root string) *Scanner {", expectedKind: "function", expectedName: "NewScanner"},
+		{ext: ".go", line: "type Filter struct {", expectedKind: "type", expectedName: "Filter"},
+		{ext: ".py", line: "    async def fetch(self, url):", expectedKind: "function", expectedName: "fetch"},
+		{ext: ".py", line: "class Client(Base):", expectedKind: "class", expectedName: "Client"},
+		{ext: ".ts", line: "export default async function handler(req, res) {", expectedKind: "function", expectedName: "handler"},
+		{ext: ".ts", line: "export const useUser = (id: string) => {", expectedKind: "function", expectedName: "useUser"},
+		{ext: ".ts", line: "export interface Props {", expectedKind: "type", expectedName: "Props"},
+		{ext: ".java", line: "public class UserService {", expectedKind: "class", expectedName: "UserService"},
+		{ext: ".java", line: "    public List<User> findAll(int limit) {", expectedKind: "method", expectedName: "findAll"},
+		{ext: ".rs", line: "pub(crate) async fn run(config: Config) -> Result<()> {", expectedKind: "function", expectedName: "run"},
+		{ext: ".rs", line: "impl Display for Token {", expectedKind: "impl", expectedName: "Token"},
+		{ext: ".rb", line: "  def self.call(env)", expectedKind: "function", expectedName: "call"},
+		{ext: ".c", line: "static int parse_args(int argc, char **argv)", expectedKind: "function", expectedName: "parse_args"},
+		{ext: ".go", line: "	return NewScanner(root)", expectedKind: "", expectedName: ""},
+		{ext: ".c", line: "    if (x) {", expectedKind: "", expectedName: ""},
+		{ext: ".java", line: "        return service.findAll(10);", expectedKind: "", expectedName: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext+" "+tt.line, func(t *testing.T) {
+			sig, ok := matchSignature(tt.line, signaturePatternsFor(tt.ext))
+			if tt.expectedName == "" {
+				if ok {
+					t.Errorf("Expected no signature, got %s %s", sig.Kind, sig.Name)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a signature for %q", tt.line)
+			}
+			if sig.Kind != tt.expectedKind || sig.Name != tt.expectedName {
+				t.Errorf("Expected %s %s, got %s %s", tt.expectedKind, tt.expectedName, sig.Kind, sig.Name)
+			}
+		})
+	}
+}
+
+func TestBuildRepoMap(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_repomap_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"util/strings.go": "package util\n\nfunc Reverse(s string) string {\n\treturn s\n}\n",
+		"util/unused.go":  "package util\n\nfunc Orphan() {}\n",
+		"main.go":         "package main\n\nfunc main() {\n\tutil.Reverse(\"a\")\n}\n",
+		"cmd/run.go":      "package cmd\n\nfunc Run() {\n\tutil.Reverse(\"b\")\n}\n",
+		"README.md":       "# Reverse\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+		paths = append(paths, name)
+	}
+
+	repoMap, err := BuildRepoMap(tempDir, paths, 0)
+	if err != nil {
+		t.Fatalf("BuildRepoMap failed: %v", err)
+	}
+	if len(repoMap.Files) != 4 {
+		t.Fatalf("Expected 4 files with signatures, got %d", len(repoMap.Files))
+	}
+	if repoMap.Files[0].Path != "main.go" || !repoMap.Files[0].KeyFile {
+		t.Errorf("Expected the key file first, got %s", repoMap.Files[0].Path)
+	}
+	if repoMap.Files[1].Path != "util/strings.go" {
+		t.Errorf("Expected the most referenced file next, got %s", repoMap.Files[1].Path)
+	}
+	if sig := repoMap.Files[1].Signatures[0]; sig.Name != "Reverse" || sig.Line != 3 {
+		t.Errorf("Expected Reverse on line 3, got %s on line %d", sig.Name, sig.Line)
+	}
+
+	// A small budget keeps the highest-ranked files only
+	budget := estimateMapTokens(repoMap.Files[0])
+	limited, err := BuildRepoMap(tempDir, paths, budget)
+	if err != nil {
+		t.Fatalf("BuildRepoMap failed: %v", err)
+	}
+	if len(limited.Files) == 0 || limited.Files[0].Path != "main.go" {
+		t.Errorf("Expected the top file within the budget, got %v", limited.Files)
+	}
+	if limited.Tokens > budget {
+		t.Errorf("Expected at most %d tokens, got %d", budget, limited.Tokens)
+	}
+	if limited.Omitted+len(limited.Files) != 4 {
+		t.Errorf("Expected omitted and included files to add up to 4, got %d + %d", limited.Omitted, len(limited.Files))
+	}
+}
+
+func TestKeyFileKind(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "main.go", expected: KeyFileEntryPoint},
+		{path: "cmd/server/main.go", expected: KeyFileEntryPoint},
+		{path: "go.mod", expected: KeyFileManifest},
+		{path: "web/package.json", expected: KeyFileManifest},
+		{path: "api/Api.csproj", expected: KeyFileManifest},
+		{path: "Makefile", expected: KeyFileBuild},
+		{path: "deploy/Dockerfile.prod", expected: KeyFileBuild},
+		{path: "README.md", expected: KeyFileDocs},
+		{path: "README_ja.md", expected: KeyFileDocs},
+		{path: "config/settings.toml", expected: KeyFileConfig},
+		{path: "vite.config.ts", expected: KeyFileConfig},
+		{path: "internal/scanner/scanner.go", expected: ""},
+		{path: "docs/guide.md", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if kind := KeyFileKind(tt.path); kind != tt.expected {
+				t.Errorf("Expected %q for %s, got %q", tt.expected, tt.path, kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// maxModelFileSize is the size above which files are not searched for ORM models
+const maxModelFileSize = 1024 * 1024
+
+// migrationDirs are the names of directories holding database migrations
+var migrationDirs = map[string]bool{"migrations": true, "migration": true, "migrate": true}
+
+// modelDirs are the names of directories holding ORM models
+var modelDirs = map[string]bool{"models": true, "model": true, "entities": true, "entity": true}
+
+// schemaExtensions are the extensions of files that define a database schema
+var schemaExtensions = map[string]bool{".sql": true, ".prisma": true, ".dbml": true}
+
+// schemaNames are the names of the schema dumps of ORMs
+var schemaNames = map[string]bool{"schema.rb": true, "structure.sql": true}
+
+// modelPattern matches the declarations of ORM models: GORM and sqlx struct
+// tags, Django and SQLAlchemy models, TypeORM and JPA entities, Sequelize and
+// Mongoose models, Active Record, and Eloquent
+var modelPattern = regexp.MustCompile(`\bgorm:"|\bdb:"\w+"|\bmodels\.Model\b|\bColumn\(\s*(?:sa\.|db\.)?[A-Z]\w*|\bMapped\[|@Entity\b|\bsequelize\.define\(|\bnew\s+(?:mongoose\.)?Schema\(|<\s*(?:ApplicationRecord|ActiveRecord::Base)\b|\bextends\s+Model\b`)
+
+var (
+	// flywayVersionPattern matches the version of a Flyway migration, such as
+	// 1_2 in V1_2__add_users.sql
+	flywayVersionPattern = regexp.MustCompile(`^[Vv](\d+(?:[._]\d+)*)__`)
+	// migrationNumberPattern matches the sequence number or timestamp at the
+	// start of a migration's name, such as 0001 in 0001_initial.py
+	migrationNumberPattern = regexp.MustCompile(`^\d+`)
+)
+
+// IsMigration reports whether a file is a database migration: a source or SQL
+// file under a migrations directory or Alembic's versions directory
+func IsMigration(relPath string) bool {
+	dirs := strings.Split(path.Dir(relPath), "/")
+	for i, dir := range dirs {
+		if migrationDirs[strings.ToLower(dir)] || dir == "versions" && i > 0 && dirs[i-1] == "alembic" {
+			return isSchemaSource(relPath) && path.Base(relPath) != "__init__.py"
+		}
+	}
+	return false
+}
+
+// isSchemaSource reports whether a file holds SQL or code rather than docs or data
+func isSchemaSource(relPath string) bool {
+	ext := strings.ToLower(path.Ext(relPath))
+	return schemaExtensions[ext] || signaturePatternsFor(ext) != nil
+}
+
+// SchemaFiles keeps the database-related files among paths, other than tests:
+// schema definitions (SQL, Prisma, and DBML files and ORM schema dumps) first,
+// then migrations in the order they run, then ORM models, found under a models
+// directory or by their declarations. Migrations are ordered by directory and
+// by the sequence number or timestamp their names start with. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func SchemaFiles(rootDir string, paths []string) []string {
+	var schemas, migrations, models []string
+	for _, relPath := range paths {
+		if IsTestFile(relPath) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(relPath))
+		switch {
+		case IsMigration(relPath):
+			migrations = append(migrations, relPath)
+		case schemaExtensions[ext] || schemaNames[strings.ToLower(path.Base(relPath))]:
+			schemas = append(schemas, relPath)
+		case signaturePatternsFor(ext) != nil && isModelFile(rootDir, relPath):
+			models = append(models, relPath)
+		}
+	}
+
+	sort.Strings(schemas)
+	sort.Strings(models)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		a, b := migrations[i], migrations[j]
+		if path.Dir(a) != path.Dir(b) {
+			return path.Dir(a) < path.Dir(b)
+		}
+		if c := slices.Compare(migrationSequence(a), migrationSequence(b)); c != 0 {
+			return c < 0
+		}
+		return a < b
+	})
+	return slices.Concat(schemas, migrations, models)
+}
+
+// isModelFile reports whether a source file likely declares ORM models
+func isModelFile(rootDir, relPath string) bool {
+	for _, dir := range strings.Split(path.Dir(relPath), "/") {
+		if modelDirs[strings.ToLower(dir)] {
+			return true
+		}
+	}
+	fullPath := platform.JoinSlash(rootDir, relPath)
+	info, err := os.Stat(fullPath)
+	if err != nil || info.Size() > maxModelFileSize {
+		return false
+	}
+	content, err := os.ReadFile(fullPath)
+	return err == nil && modelPattern.Match(content)
+}
+
+// migrationSequence returns the numbers a migration's name starts with, such
+// as [1 2] for V1_2__add_users.sql, or nil for a name without a sequence,
+// which sorts first
+func migrationSequence(relPath string) []int {
+	name := path.Base(relPath)
+	version := migrationNumberPattern.FindString(name)
+	if m := flywayVersionPattern.FindStringSubmatch(name); m != nil {
+		version = m[1]
+	}
+	var sequence []int
+	for _, part := range strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' }) {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil
+		}
+		sequence = append(sequence, n)
+	}
+	return sequence
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_schema_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"db/schema.rb":                              "ActiveRecord::Schema.define do\nend\n",
+		"db/migrate/20240301090000_add_email.rb":    "class AddEmail < ActiveRecord::Migration[7.0]\nend\n",
+		"db/migrate/20240102120000_create_users.rb": "class CreateUsers < ActiveRecord::Migration[7.0]\nend\n",
+		"db/migration/V1_10__add_index.sql":         "CREATE INDEX users_email ON users (email);\n",
+		"db/migration/V1_2__add_users.sql":          "CREATE TABLE users (id INT);\n",
+		"app/migrations/0002_profile.py":            "class Migration:\n    pass\n",
+		"app/migrations/0001_initial.py":            "class Migration:\n    pass\n",
+		"app/migrations/__init__.py":                "",
+		"app/models/user.rb":                        "class User < ApplicationRecord\nend\n",
+		"internal/store/user.go":                    "package store\n\ntype User struct {\n\tID int `gorm:\"primaryKey\"`\n}\n",
+		"internal/store/user_test.go":               "package store\n\ntype fixture struct {\n\tID int `gorm:\"primaryKey\"`\n}\n",
+		"internal/api/handler.go":                   "package api\n",
+		"README.md":                                 "# App\n",
+	}
+	var paths []string
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		paths = append(paths, name)
+	}
+
+	expected := []string{
+		"db/schema.rb",
+		"app/migrations/0001_initial.py",
+		"app/migrations/0002_profile.py",
+		"db/migrate/20240102120000_create_users.rb",
+		"db/migrate/20240301090000_add_email.rb",
+		"db/migration/V1_2__add_users.sql",
+		"db/migration/V1_10__add_index.sql",
+		"app/models/user.rb",
+		"internal/store/user.go",
+	}
+	if got := SchemaFiles(tempDir, paths); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestMigrationSequence(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []int
+	}{
+		{"migrations/0001_initial.py", []int{1}},
+		{"db/migrate/20240102120000_create_users.rb", []int{20240102120000}},
+		{"sql/V1_2__add_users.sql", []int{1, 2}},
+		{"sql/V2.1__rename.sql", []int{2, 1}},
+		{"migrations/initial.sql", nil},
+	}
+	for _, test := range tests {
+		if got := migrationSequence(test.path); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("migrationSequence(%q): expected %v, got %v", test.path, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// Signature is a declaration found in a source file
+type Signature struct {
+	Kind string `json:"kind"` // function, method, class, type, ...
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	Text string `json:"text"` // The declaration line as written, without a trailing brace
+}
+
+// signaturePattern matches one kind of declaration; the "name" group captures its name
+type signaturePattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// maxSignatureLength limits how much of a declaration line is kept
+const maxSignatureLength = 160
+
+func sigPattern(kind, expr string) signaturePattern {
+	return signaturePattern{kind: kind, re: regexp.MustCompile(expr)}
+}
+
+var (
+	goSignatures = []signaturePattern{
+		sigPattern("method", `^func\s+\([^)]*\)\s*(?P<name>\w+)`),
+		sigPattern("function", `^func\s+(?P<name>\w+)`),
+		sigPattern("type", `^type\s+(?P<name>\w+)`),
+	}
+	pythonSignatures = []signaturePattern{
+		sigPattern("class", `^\s*class\s+(?P<name>\w+)`),
+		sigPattern("function", `^\s*(?:async\s+)?def\s+(?P<name>\w+)`),
+	}
+	jsSignatures = []signaturePattern{
+		sigPattern("function", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>\w+)`),
+		sigPattern("class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>\w+)`),
+		sigPattern("type", `^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(?P<name>\w+)`),
+		sigPattern("function", `^\s*(?:export\s+)?(?:const|let)\s+(?P<name>\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>`),
+	}
+	javaSignatures = []signaturePattern{
+		sigPattern("class", `^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|data|open|partial)\s+)*(?:class|interface|enum|record|object)\s+(?P<name>\w+)`),
+		sigPattern("function", `^\s*(?:(?:public|private|protected|internal|static|final|abstract|override|suspend|open|virtual|async)\s+)*fun\s+(?:<[^>]*>\s*)?(?:\w+\.)?(?P<name>\w+)\s*\(`),
+		sigPattern("method", `^\s*(?:(?:public|private|protected|internal|static|final|abstract|override|synchronized|virtual|async)\s+)+[\w<>\[\],.? ]+\s+(?P<name>\w+)\s*\([^;]*$`),
+	}
+	rustSignatures = []signaturePattern{
+		sigPattern("function", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+(?P<name>\w+)`),
+		sigPattern("type", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|union)\s+(?P<name>\w+)`),
+		sigPattern("impl", `^\s*impl(?:<[^>]*>)?\s+(?:[\w:<>]+\s+for\s+)?(?P<name>\w+)`),
+	}
+	rubySignatures = []signaturePattern{
+		sigPattern("class", `^\s*(?:class|module)\s+(?P<name>[\w:]+)`),
+		sigPattern("function", `^\s*def\s+(?:self\.)?(?P<name>\w+[?!=]?)`),
+	}
+	phpSignatures = []signaturePattern{
+		sigPattern("class", `^\s*(?:(?:abstract|final)\s+)?(?:class|interface|trait|enum)\s+(?P<name>\w+)`),
+		sigPattern("function", `^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+(?P<name>\w+)`),
+	}
+	swiftSignatures = []signaturePattern{
+		sigPattern("class", `^\s*(?:(?:public|private|internal|open|fileprivate|final)\s+)*(?:class|struct|enum|protocol|extension|actor)\s+(?P<name>\w+)`),
+		sigPattern("function", `^\s*(?:(?:public|private|internal|open|fileprivate|static|class|override|final|mutating)\s+)*func\s+(?P<name>\w+)`),
+	}
+	cSignatures = []signaturePattern{
+		sigPattern("type", `^(?:typedef\s+)?(?:struct|class|enum|union)\s+(?P<name>\w+)\s*[^;]*$`),
+		sigPattern("function", `^(?:[A-Za-z_][\w:<>,*&]*\s+)+\**(?P<name>[A-Za-z_][\w:~]*)\s*\([^;]*$`),
+	}
+
+	// cKeywords are words that the C function pattern must not treat as a name
+	cKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true, "else": true, "sizeof": true}
+)
+
+// signaturePatternsFor returns the declaration patterns for a file extension
+func signaturePatternsFor(ext string) []signaturePattern {
+	switch strings.ToLower(ext) {
+	case ".go":
+		return goSignatures
+	case ".py":
+		return pythonSignatures
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		return jsSignatures
+	case ".java", ".kt", ".kts", ".cs", ".scala":
+		return javaSignatures
+	case ".rs":
+		return rustSignatures
+	case ".rb":
+		return rubySignatures
+	case ".php":
+		return phpSignatures
+	case ".swift":
+		return swiftSignatures
+	case ".c", ".h", ".cpp", ".cc", ".cxx", ".hpp":
+		return cSignatures
+	default:
+		return nil
+	}
+}
+
+// HasSignatureSupport reports whether signatures can be extracted from files with the extension
+func HasSignatureSupport(path string) bool {
+	return signaturePatternsFor(filepath.Ext(path)) != nil
+}
+
+// ExtractSignatures returns the functions, classes, and types declared in a source file
+func ExtractSignatures(path string) ([]Signature, error) {
+	patterns := signaturePatternsFor(filepath.Ext(path))
+	if patterns == nil {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var signatures []Signature
+	scanner := utils.NewLineReader(file, 0)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if sig, ok := matchSignature(line, patterns); ok {
+			sig.Line = scanner.LineNumber()
+			signatures = append(signatures, sig)
+		}
+	}
+	return signatures, scanner.Err()
+}
+
+// matchSignature checks a line against the patterns, returning the first match
+func matchSignature(line string, patterns []signaturePattern) (Signature, bool) {
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[p.re.SubexpIndex("name")]
+		if cKeywords[name] {
+			continue
+		}
+		return Signature{Kind: p.kind, Name: name, Text: signatureText(line)}, true
+	}
+	return Signature{}, false
+}
+
+// signatureText trims a declaration line for display
+func signatureText(line string) string {
+	text := strings.TrimRight(line, " \t")
+	text = strings.TrimSuffix(text, "{")
+	text = strings.TrimRight(strings.ReplaceAll(text, "\t", "    "), " ")
+	if len(text) > maxSignatureLength {
+		text = text[:maxSignatureLength] + "..."
+	}
+	return text
+}
@@ -0,0 +1,316 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Categories of stack components, in the order they are listed
+const (
+	StackFramework      = "framework"
+	StackTesting        = "testing"
+	StackBuild          = "build"
+	StackInfrastructure = "infrastructure"
+	StackCI             = "ci"
+)
+
+var stackCategoryOrder = map[string]int{
+	StackFramework:      0,
+	StackTesting:        1,
+	StackBuild:          2,
+	StackInfrastructure: 3,
+	StackCI:             4,
+}
+
+// StackComponent is a framework or tool the repository uses
+type StackComponent struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Evidence string `json:"evidence"` // Relative path of the file it was detected from
+}
+
+// stackComponent is the name and category of a component
+type stackComponent struct {
+	name     string
+	category string
+}
+
+// dependencyRule detects a component from a dependency name in a manifest
+type dependencyRule struct {
+	needle    string // Lower-cased text naming the dependency
+	component stackComponent
+}
+
+// npmPackages maps package.json dependencies to components
+var npmPackages = map[string]stackComponent{
+	"react":            {"React", StackFramework},
+	"next":             {"Next.js", StackFramework},
+	"vue":              {"Vue", StackFramework},
+	"nuxt":             {"Nuxt", StackFramework},
+	"@angular/core":    {"Angular", StackFramework},
+	"svelte":           {"Svelte", StackFramework},
+	"express":          {"Express", StackFramework},
+	"@nestjs/core":     {"NestJS", StackFramework},
+	"electron":         {"Electron", StackFramework},
+	"jest":             {"Jest", StackTesting},
+	"vitest":           {"Vitest", StackTesting},
+	"mocha":            {"Mocha", StackTesting},
+	"@playwright/test": {"Playwright", StackTesting},
+	"cypress":          {"Cypress", StackTesting},
+	"vite":             {"Vite", StackBuild},
+	"webpack":          {"webpack", StackBuild},
+	"typescript":       {"TypeScript", StackBuild},
+}
+
+// manifestDependencies maps manifests without a structured parser to the
+// dependency names found in them, matched as lower-cased substrings
+var manifestDependencies = map[string][]dependencyRule{
+	"requirements.txt": pythonDependencies,
+	"pyproject.toml":   pythonDependencies,
+	"pipfile":          pythonDependencies,
+	"setup.py":         pythonDependencies,
+	"go.mod": {
+		{"github.com/gin-gonic/gin", stackComponent{"Gin", StackFramework}},
+		{"github.com/labstack/echo", stackComponent{"Echo", StackFramework}},
+		{"github.com/gofiber/fiber", stackComponent{"Fiber", StackFramework}},
+		{"github.com/go-chi/chi", stackComponent{"chi", StackFramework}},
+		{"google.golang.org/grpc", stackComponent{"gRPC", StackFramework}},
+		{"github.com/spf13/cobra", stackComponent{"Cobra", StackFramework}},
+		{"github.com/stretchr/testify", stackComponent{"testify", StackTesting}},
+	},
+	"pom.xml":          javaDependencies,
+	"build.gradle":     javaDependencies,
+	"build.gradle.kts": javaDependencies,
+	"gemfile": {
+		{"'rails'", stackComponent{"Rails", StackFramework}},
+		{`"rails"`, stackComponent{"Rails", StackFramework}},
+		{"'sinatra'", stackComponent{"Sinatra", StackFramework}},
+		{`"sinatra"`, stackComponent{"Sinatra", StackFramework}},
+		{"'rspec", stackComponent{"RSpec", StackTesting}},
+		{`"rspec`, stackComponent{"RSpec", StackTesting}},
+	},
+	"cargo.toml": {
+		{"actix-web", stackComponent{"Actix Web", StackFramework}},
+		{"axum", stackComponent{"Axum", StackFramework}},
+		{"rocket", stackComponent{"Rocket", StackFramework}},
+		{"tokio", stackComponent{"Tokio", StackFramework}},
+	},
+	"composer.json": {
+		{"laravel/framework", stackComponent{"Laravel", StackFramework}},
+		{"symfony/", stackComponent{"Symfony", StackFramework}},
+		{"phpunit/phpunit", stackComponent{"PHPUnit", StackTesting}},
+	},
+}
+
+var pythonDependencies = []dependencyRule{
+	{"django", stackComponent{"Django", StackFramework}},
+	{"flask", stackComponent{"Flask", StackFramework}},
+	{"fastapi", stackComponent{"FastAPI", StackFramework}},
+	{"pytest", stackComponent{"pytest", StackTesting}},
+}
+
+var javaDependencies = []dependencyRule{
+	{"spring-boot", stackComponent{"Spring Boot", StackFramework}},
+	{"org.springframework", stackComponent{"Spring", StackFramework}},
+	{"io.quarkus", stackComponent{"Quarkus", StackFramework}},
+	{"junit", stackComponent{"JUnit", StackTesting}},
+}
+
+// stackFileNames maps lower-cased marker file names to components
+var stackFileNames = map[string]stackComponent{
+	"manage.py":           {"Django", StackFramework},
+	"angular.json":        {"Angular", StackFramework},
+	"pom.xml":             {"Maven", StackBuild},
+	"build.gradle":        {"Gradle", StackBuild},
+	"build.gradle.kts":    {"Gradle", StackBuild},
+	"makefile":            {"Make", StackBuild},
+	"cmakelists.txt":      {"CMake", StackBuild},
+	"build.bazel":         {"Bazel", StackBuild},
+	"workspace":           {"Bazel", StackBuild},
+	"module.bazel":        {"Bazel", StackBuild},
+	"dockerfile":          {"Docker", StackInfrastructure},
+	"containerfile":       {"Docker", StackInfrastructure},
+	"docker-compose.yml":  {"Docker Compose", StackInfrastructure},
+	"docker-compose.yaml": {"Docker Compose", StackInfrastructure},
+	"compose.yml":         {"Docker Compose", StackInfrastructure},
+	"compose.yaml":        {"Docker Compose", StackInfrastructure},
+	"chart.yaml":          {"Helm", StackInfrastructure},
+	"kustomization.yaml":  {"Kustomize", StackInfrastructure},
+	"serverless.yml":      {"Serverless Framework", StackInfrastructure},
+	".gitlab-ci.yml":      {"GitLab CI", StackCI},
+	"jenkinsfile":         {"Jenkins", StackCI},
+	"azure-pipelines.yml": {"Azure Pipelines", StackCI},
+	".travis.yml":         {"Travis CI", StackCI},
+}
+
+// stackHiddenDirs are hidden directories searched for markers
+var stackHiddenDirs = map[string]bool{
+	".github":   true,
+	".circleci": true,
+}
+
+// DetectStack finds the frameworks and tools used under rootDir from marker
+// files and the dependencies in package manifests. Components are sorted by
+// category and name, each with the first file it was detected from.
+func DetectStack(rootDir string) ([]StackComponent, error) {
+	found := make(map[stackComponent]string)
+	add := func(component stackComponent, relPath string) {
+		if _, ok := found[component]; !ok {
+			found[component] = relPath
+		}
+	}
+
+	err := filepath.WalkDir(rootDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && filePath != rootDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		name := strings.ToLower(d.Name())
+		if d.IsDir() {
+			if filePath != rootDir && (projectSkipDirs[name] || (strings.HasPrefix(name, ".") && !stackHiddenDirs[name])) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootDir, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if component, ok := stackFileNames[name]; ok {
+			add(component, relPath)
+		}
+		for _, component := range stackFromFile(filePath, relPath, name) {
+			add(component, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect stack: %w", err)
+	}
+
+	components := make([]StackComponent, 0, len(found))
+	for component, evidence := range found {
+		components = append(components, StackComponent{Name: component.name, Category: component.category, Evidence: evidence})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Category != components[j].Category {
+			return stackCategoryOrder[components[i].Category] < stackCategoryOrder[components[j].Category]
+		}
+		return components[i].Name < components[j].Name
+	})
+	return components, nil
+}
+
+// stackFromFile returns the components detected from the content or location
+// of one file. name is the lower-cased file name.
+func stackFromFile(filePath, relPath, name string) []stackComponent {
+	ext := path.Ext(name)
+	switch {
+	case name == "package.json":
+		return npmDependencies(filePath)
+	case manifestDependencies[name] != nil:
+		return manifestMatches(filePath, name)
+	case ext == ".tf" || ext == ".tfvars":
+		return []stackComponent{{"Terraform", StackInfrastructure}}
+	case ext == ".yml" || ext == ".yaml":
+		if strings.HasPrefix(relPath, ".github/workflows/") {
+			return []stackComponent{{"GitHub Actions", StackCI}}
+		}
+		if strings.HasPrefix(relPath, ".circleci/") {
+			return []stackComponent{{"CircleCI", StackCI}}
+		}
+		if isKubernetesManifest(filePath) {
+			return []stackComponent{{"Kubernetes", StackInfrastructure}}
+		}
+	}
+	return nil
+}
+
+// npmDependencies returns the components among the dependencies of a package.json
+func npmDependencies(filePath string) []stackComponent {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies     map[string]string `json:"dependencies"`
+		DevDependencies  map[string]string `json:"devDependencies"`
+		PeerDependencies map[string]string `json:"peerDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+
+	var components []stackComponent
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies} {
+		for dep := range deps {
+			if component, ok := npmPackages[dep]; ok {
+				components = append(components, component)
+			}
+		}
+	}
+	return components
+}
+
+// manifestMatches returns the components whose dependency names appear in a manifest
+func manifestMatches(filePath, name string) []stackComponent {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	content := strings.ToLower(string(data))
+
+	var components []stackComponent
+	for _, dep := range manifestDependencies[name] {
+		if strings.Contains(content, dep.needle) {
+			components = append(components, dep.component)
+		}
+	}
+	return components
+}
+
+// isKubernetesManifest reports whether a YAML file declares a Kubernetes
+// object, with top-level apiVersion and kind keys near its start
+func isKubernetesManifest(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	hasAPIVersion, hasKind := false, false
+	scanner := bufio.NewScanner(io.LimitReader(file, 8192))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "apiVersion:") {
+			hasAPIVersion = true
+		} else if strings.HasPrefix(line, "kind:") {
+			hasKind = true
+		}
+		if hasAPIVersion && hasKind {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintStack prints the detected components grouped by category
+func PrintStack(components []StackComponent, w io.Writer) {
+	fmt.Fprintln(w, "\nStack:")
+	for _, component := range components {
+		fmt.Fprintf(w, "  %s (%s, from %s)\n", component.Name, component.Category, component.Evidence)
+	}
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectStack(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_stack_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"web/package.json":                  `{"dependencies": {"react": "^18.0.0"}, "devDependencies": {"jest": "^29.0.0"}}`,
+		"web/node_modules/vue/package.json": `{"dependencies": {"vue": "3"}}`,
+		"backend/requirements.txt":          "Django==4.2\npsycopg2\n",
+		"backend/manage.py":                 "import django\n",
+		"api/pom.xml":                       "<dependency><groupId>org.springframework.boot</groupId><artifactId>spring-boot-starter-web</artifactId></dependency>",
+		"infra/main.tf":                     "resource \"aws_s3_bucket\" \"b\" {}\n",
+		"deploy/app.yaml":                   "apiVersion: apps/v1\nkind: Deployment\n",
+		"config/settings.yaml":              "debug: true\n",
+		".github/workflows/ci.yml":          "on: push\n",
+		".cache/Dockerfile":                 "FROM scratch\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	components, err := DetectStack(tempDir)
+	if err != nil {
+		t.Fatalf("DetectStack failed: %v", err)
+	}
+
+	expected := []StackComponent{
+		{Name: "Django", Category: StackFramework, Evidence: "backend/manage.py"},
+		{Name: "React", Category: StackFramework, Evidence: "web/package.json"},
+		{Name: "Spring", Category: StackFramework, Evidence: "api/pom.xml"},
+		{Name: "Spring Boot", Category: StackFramework, Evidence: "api/pom.xml"},
+		{Name: "Jest", Category: StackTesting, Evidence: "web/package.json"},
+		{Name: "Maven", Category: StackBuild, Evidence: "api/pom.xml"},
+		{Name: "Kubernetes", Category: StackInfrastructure, Evidence: "deploy/app.yaml"},
+		{Name: "Terraform", Category: StackInfrastructure, Evidence: "infra/main.tf"},
+		{Name: "GitHub Actions", Category: StackCI, Evidence: ".github/workflows/ci.yml"},
+	}
+	if len(components) != len(expected) {
+		t.Fatalf("Expected %d components, got %d: %+v", len(expected), len(components), components)
+	}
+	for i, want := range expected {
+		if components[i] != want {
+			t.Errorf("Expected %+v, got %+v", want, components[i])
+		}
+	}
+}
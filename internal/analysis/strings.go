@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"codectx/internal/language"
+	"codectx/internal/platform"
+	"codectx/internal/utils"
+)
+
+// DefaultMinStringLength is the length from which string literals are reported
+const DefaultMinStringLength = 20
+
+// maxStringsFileSize is the size above which files are not searched for strings
+const maxStringsFileSize = 1024 * 1024
+
+// uiPathWords are the directory and file names of code that faces users
+var uiPathWords = map[string]bool{
+	"ui": true, "view": true, "views": true, "page": true, "pages": true, "screen": true, "screens": true,
+	"component": true, "components": true, "template": true, "templates": true, "layout": true, "layouts": true,
+	"handler": true, "handlers": true, "controller": true, "controllers": true, "route": true, "routes": true,
+	"web": true, "frontend": true, "cli": true, "cmd": true, "messages": true, "i18n": true,
+}
+
+// uiExtensions are the extensions of files that are user interface by nature
+var uiExtensions = map[string]bool{".jsx": true, ".tsx": true, ".html": true, ".htm": true, ".erb": true}
+
+// pathWordPattern splits a path into words, such as "user", "handler" for user_handler.go
+var pathWordPattern = regexp.MustCompile(`[A-Za-z][a-z]*|[A-Z]+`)
+
+// structTagPattern matches Go struct tags such as `json:"name,omitempty" yaml:"name"`
+var structTagPattern = regexp.MustCompile(`^(?:\w+:"[^"]*"\s*)+$`)
+
+// StringLiteral is a string literal that users likely read
+type StringLiteral struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"` // Without the quotes, escapes kept as written
+}
+
+// IsUIFile reports whether a file likely holds user interface code: JSX,
+// templates, or a file under (or named after) views, pages, handlers,
+// controllers, commands, and the like
+func IsUIFile(relPath string) bool {
+	if uiExtensions[strings.ToLower(path.Ext(relPath))] {
+		return true
+	}
+	for _, word := range pathWordPattern.FindAllString(strings.TrimSuffix(relPath, path.Ext(relPath)), -1) {
+		if uiPathWords[strings.ToLower(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// FindUserStrings extracts the single-line string literals of at least
+// minLength characters that read as prose from the user interface files among
+// paths, other than tests. Commented out lines are skipped. paths are
+// slash-separated paths relative to rootDir, without a leading slash.
+func FindUserStrings(rootDir string, paths []string, minLength int) ([]StringLiteral, error) {
+	literals := []StringLiteral{}
+	for _, relPath := range paths {
+		if IsTestFile(relPath) || !IsUIFile(relPath) {
+			continue
+		}
+		lang, ok := language.Detect(relPath)
+		if !ok || (lang.Class != language.ClassCode && lang.Class != language.ClassScript && lang.ID != "html" && lang.ID != "erb") {
+			continue
+		}
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		info, err := os.Stat(fullPath)
+		if err != nil || info.Size() > maxStringsFileSize {
+			continue
+		}
+
+		found, err := findFileStrings(fullPath, lang.LineComment, minLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+		for _, literal := range found {
+			literal.Path = relPath
+			literals = append(literals, literal)
+		}
+	}
+	return literals, nil
+}
+
+// findFileStrings returns the prose string literals of a file
+func findFileStrings(fullPath, lineComment string, minLength int) ([]StringLiteral, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var literals []StringLiteral
+	reader := utils.NewLineReader(file, 0)
+	for reader.Scan() {
+		line := reader.Text()
+		trimmed := strings.TrimSpace(line)
+		if lineComment != "" && strings.HasPrefix(trimmed, lineComment) || strings.HasPrefix(trimmed, "import ") {
+			continue
+		}
+		for _, quoted := range stringLiteralPattern.FindAllString(line, -1) {
+			text := quoted[1 : len(quoted)-1]
+			if len([]rune(text)) >= minLength && isProse(text) {
+				literals = append(literals, StringLiteral{Line: reader.LineNumber(), Text: text})
+			}
+		}
+	}
+	return literals, reader.Err()
+}
+
+// isProse reports whether the text of a string literal reads as words rather
+// than an identifier, path, MIME type, or struct tag: at least two of its
+// space-separated fields are words, ignoring trailing punctuation
+func isProse(text string) bool {
+	if structTagPattern.MatchString(text) {
+		return false
+	}
+	words := 0
+	for _, field := range strings.Fields(text) {
+		field = strings.TrimRight(field, ".,:;!?")
+		if field != "" && strings.IndexFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' }) < 0 {
+			words++
+		}
+	}
+	return words >= 2
+}
+
+// PrintUserStrings prints the user-facing string literals
+func PrintUserStrings(literals []StringLiteral, w io.Writer) {
+	fmt.Fprintln(w, "\nUser-Facing Strings:")
+	fmt.Fprintln(w, "====================")
+	if len(literals) == 0 {
+		fmt.Fprintln(w, "  No user-facing strings found")
+		return
+	}
+	fmt.Fprintf(w, "  %s\n", plural(len(literals), "string"))
+	for _, literal := range literals {
+		fmt.Fprintf(w, "  %s:%d \"%s\"\n", literal.Path, literal.Line, literal.Text)
+	}
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsUIFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"src/components/Button.tsx", true},
+		{"src/Button.jsx", true},
+		{"internal/handlers/user.go", true},
+		{"internal/api/userHandler.go", true},
+		{"app/controllers/users_controller.rb", true},
+		{"cmd/root.go", true},
+		{"internal/parser/lexer.go", false},
+		{"lib/utils.py", false},
+	}
+	for _, test := range tests {
+		if got := IsUIFile(test.path); got != test.expected {
+			t.Errorf("IsUIFile(%q): expected %v, got %v", test.path, test.expected, got)
+		}
+	}
+}
+
+func TestFindUserStrings(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_strings_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"handlers/user.go": `package handlers
+
+import "net/http"
+
+type User struct {
+	Name string ` + "`json:\"name,omitempty\" yaml:\"name\"`" + `
+}
+
+func Get(w http.ResponseWriter) {
+	// http.Error(w, "This line is commented out", 500)
+	http.Error(w, "The user could not be found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	log("short text")
+}
+`,
+		"handlers/user_test.go": "package handlers\n\nvar want = \"The user could not be found\"\n",
+		"components/Form.tsx":   "export const Form = () => <input placeholder='Enter your email address' />;\n",
+		"internal/lexer.go":     "package lexer\n\nvar msg = \"An error message in a library\"\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	paths := []string{"components/Form.tsx", "handlers/user.go", "handlers/user_test.go", "internal/lexer.go"}
+
+	literals, err := FindUserStrings(tempDir, paths, 20)
+	if err != nil {
+		t.Fatalf("FindUserStrings failed: %v", err)
+	}
+	expected := []StringLiteral{
+		{Path: "components/Form.tsx", Line: 1, Text: "Enter your email address"},
+		{Path: "handlers/user.go", Line: 11, Text: "The user could not be found"},
+	}
+	if !reflect.DeepEqual(literals, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, literals)
+	}
+}
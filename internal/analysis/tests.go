@@ -0,0 +1,165 @@
+package analysis
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Modes of --tests, which include or exclude test files as a group
+const (
+	TestsInclude = "include" // Test files are included like any other file
+	TestsSkip    = "skip"    // Test files are left out
+	TestsOnly    = "only"    // Only test files are included
+)
+
+// ParseTestsMode validates a --tests mode
+func ParseTestsMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case TestsInclude, "":
+		return TestsInclude, nil
+	case TestsSkip:
+		return TestsSkip, nil
+	case TestsOnly:
+		return TestsOnly, nil
+	}
+	return "", fmt.Errorf("invalid tests mode: %s (expected include, skip, or only)", mode)
+}
+
+// testDirs are directories holding tests apart from the code they cover
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"__tests__": true,
+	"spec":      true,
+}
+
+// scriptExtensions are the JS and TS extensions of .test and .spec files
+var scriptExtensions = map[string]bool{
+	".js":  true,
+	".jsx": true,
+	".mjs": true,
+	".cjs": true,
+	".ts":  true,
+	".tsx": true,
+}
+
+// IsTestFile reports whether a file is a test by the naming conventions of its
+// language: Go foo_test.go, JS and TS foo.test.ts and foo.spec.ts, Python
+// test_foo.py and foo_test.py, Ruby foo_spec.rb, and Java and Kotlin FooTest.java.
+// relPath is a slash-separated path relative to the target directory.
+func IsTestFile(relPath string) bool {
+	return testSubject(path.Base(relPath)) != ""
+}
+
+// testSubject returns the name of the source file a test file covers, such as
+// "parse.go" for "parse_test.go", or "" if the name is not a test's
+func testSubject(name string) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	switch {
+	case ext == ".go":
+		if subject, ok := strings.CutSuffix(stem, "_test"); ok && subject != "" {
+			return subject + ext
+		}
+	case scriptExtensions[ext]:
+		for _, suffix := range []string{".test", ".spec"} {
+			if subject, ok := strings.CutSuffix(stem, suffix); ok && subject != "" {
+				return subject + ext
+			}
+		}
+	case ext == ".py":
+		if subject, ok := strings.CutPrefix(stem, "test_"); ok && subject != "" {
+			return subject + ext
+		}
+		if subject, ok := strings.CutSuffix(stem, "_test"); ok && subject != "" {
+			return subject + ext
+		}
+	case ext == ".rb":
+		if subject, ok := strings.CutSuffix(stem, "_spec"); ok && subject != "" {
+			return subject + ext
+		}
+	case ext == ".java", ext == ".kt":
+		if subject, ok := strings.CutSuffix(stem, "Test"); ok && subject != "" {
+			return subject + ext
+		}
+	}
+	return ""
+}
+
+// FilterTests keeps the paths selected by a --tests mode
+func FilterTests(paths []string, mode string) []string {
+	if mode == TestsInclude {
+		return paths
+	}
+	kept := make([]string, 0, len(paths))
+	for _, relPath := range paths {
+		if IsTestFile(relPath) == (mode == TestsOnly) {
+			kept = append(kept, relPath)
+		}
+	}
+	return kept
+}
+
+// PairTests returns the paths with each test file moved right after the source
+// file it covers. The source is looked up next to the test; for tests kept in a
+// test directory (tests/, __tests__/, ...) also next to that directory; for Java
+// and Kotlin in the main tree matching src/test/; and otherwise anywhere, if
+// only one file has the name. Tests whose source isn't found keep their place.
+// paths are slash-separated paths relative to the target directory.
+func PairTests(paths []string) []string {
+	known := make(map[string]bool, len(paths))
+	byName := make(map[string][]string)
+	for _, relPath := range paths {
+		known[relPath] = true
+		if !IsTestFile(relPath) {
+			byName[path.Base(relPath)] = append(byName[path.Base(relPath)], relPath)
+		}
+	}
+
+	tests := make(map[string][]string) // Source to the tests covering it
+	paired := make(map[string]bool)
+	for _, relPath := range paths {
+		name := testSubject(path.Base(relPath))
+		if name == "" {
+			continue
+		}
+		if source := findTestSubject(relPath, name, known, byName); source != "" {
+			tests[source] = append(tests[source], relPath)
+			paired[relPath] = true
+		}
+	}
+
+	ordered := make([]string, 0, len(paths))
+	for _, relPath := range paths {
+		if paired[relPath] {
+			continue
+		}
+		ordered = append(ordered, relPath)
+		ordered = append(ordered, tests[relPath]...)
+	}
+	return ordered
+}
+
+// findTestSubject returns the path of the source file named name that the test
+// at testPath covers, or "" if there is none
+func findTestSubject(testPath, name string, known map[string]bool, byName map[string][]string) string {
+	dir := path.Dir(testPath)
+	candidates := []string{path.Join(dir, name)}
+	if testDirs[path.Base(dir)] {
+		candidates = append(candidates, path.Join(path.Dir(dir), name))
+	}
+	if strings.HasPrefix(testPath, "src/test/") || strings.Contains(testPath, "/src/test/") {
+		mainPath := strings.Replace("/"+testPath, "/src/test/", "/src/main/", 1)
+		candidates = append(candidates, path.Join(path.Dir(mainPath[1:]), name))
+	}
+	for _, candidate := range candidates {
+		if known[candidate] && candidate != testPath {
+			return candidate
+		}
+	}
+	if sources := byName[name]; len(sources) == 1 {
+		return sources[0]
+	}
+	return ""
+}
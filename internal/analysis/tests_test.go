@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"internal/parse_test.go", true},
+		{"internal/parse.go", false},
+		{"web/button.spec.ts", true},
+		{"web/button.test.jsx", true},
+		{"web/button.tsx", false},
+		{"tests/test_models.py", true},
+		{"models_test.py", true},
+		{"test.py", false},
+		{"spec/user_spec.rb", true},
+		{"src/test/java/app/UserTest.java", true},
+		{"Test.java", false},
+		{"notes_test.txt", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTestFile(tt.path); got != tt.expected {
+			t.Errorf("IsTestFile(%q): expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestPairTests(t *testing.T) {
+	paths := []string{
+		"README.md",
+		"app/models.py",
+		"app/views.py",
+		"internal/lex.go",
+		"internal/lex_test.go",
+		"internal/parse.go",
+		"internal/parse_test.go",
+		"src/main/java/app/User.java",
+		"src/test/java/app/UserTest.java",
+		"tests/test_models.py",
+		"tests/test_unknown.py",
+		"web/__tests__/button.test.ts",
+		"web/button.ts",
+		"web/form.spec.ts",
+		"web/form.ts",
+	}
+	expected := []string{
+		"README.md",
+		"app/models.py",
+		"tests/test_models.py",
+		"app/views.py",
+		"internal/lex.go",
+		"internal/lex_test.go",
+		"internal/parse.go",
+		"internal/parse_test.go",
+		"src/main/java/app/User.java",
+		"src/test/java/app/UserTest.java",
+		"tests/test_unknown.py",
+		"web/button.ts",
+		"web/__tests__/button.test.ts",
+		"web/form.ts",
+		"web/form.spec.ts",
+	}
+
+	if got := PairTests(paths); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestFilterTests(t *testing.T) {
+	paths := []string{"main.go", "main_test.go", "web/app.spec.ts", "web/app.ts"}
+
+	tests := []struct {
+		mode     string
+		expected []string
+	}{
+		{TestsInclude, paths},
+		{TestsSkip, []string{"main.go", "web/app.ts"}},
+		{TestsOnly, []string{"main_test.go", "web/app.spec.ts"}},
+	}
+
+	for _, tt := range tests {
+		if got := FilterTests(paths, tt.mode); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("FilterTests(%s): expected %v, got %v", tt.mode, tt.expected, got)
+		}
+	}
+
+	if _, err := ParseTestsMode("some"); err == nil {
+		t.Errorf("Expected an error for an invalid mode")
+	}
+}
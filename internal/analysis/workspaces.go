@@ -0,0 +1,353 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// workspaceRootFiles are the files at the root of a JS/TS monorepo that
+// configure its workspaces, kept with the files of a workspace
+var workspaceRootFiles = []string{"package.json", "pnpm-workspace.yaml", "nx.json", "turbo.json", "lerna.json", "tsconfig.json", "tsconfig.base.json"}
+
+// WorkspaceGraph is the workspaces of a JS/TS monorepo and the dependencies
+// between them
+type WorkspaceGraph struct {
+	Manager    string      `json:"manager"`         // "pnpm", "yarn", "npm", or "" for Nx projects alone
+	Tools      []string    `json:"tools,omitempty"` // Build orchestrators: "nx", "turbo"
+	Workspaces []Workspace `json:"workspaces"`      // By path
+}
+
+// Workspace is a package of a JS/TS monorepo
+type Workspace struct {
+	Name         string   `json:"name"`                   // Name from package.json or project.json, or the directory name
+	Path         string   `json:"path"`                   // Directory relative to the scanned root, with slashes
+	Dependencies []string `json:"dependencies,omitempty"` // Names of the workspaces it depends on, sorted
+}
+
+// DetectWorkspaces finds the workspaces of the JS/TS monorepo at rootDir:
+// those declared by pnpm-workspace.yaml or the workspaces of package.json
+// (npm and Yarn), and the project.json files of Nx. It returns nil when
+// rootDir is not a monorepo.
+func DetectWorkspaces(rootDir string) (*WorkspaceGraph, error) {
+	graph := &WorkspaceGraph{Workspaces: []Workspace{}}
+	var patterns []string
+	if data, err := os.ReadFile(filepath.Join(rootDir, "pnpm-workspace.yaml")); err == nil {
+		graph.Manager = "pnpm"
+		patterns = pnpmWorkspacePatterns(data)
+	} else if data, err := os.ReadFile(filepath.Join(rootDir, "package.json")); err == nil {
+		if patterns = packageWorkspacePatterns(data); len(patterns) > 0 {
+			graph.Manager = "npm"
+			if fileExists(filepath.Join(rootDir, "yarn.lock")) || fileExists(filepath.Join(rootDir, ".yarnrc.yml")) {
+				graph.Manager = "yarn"
+			}
+		}
+	}
+	nx := fileExists(filepath.Join(rootDir, "nx.json"))
+	if nx {
+		graph.Tools = append(graph.Tools, "nx")
+	}
+	if fileExists(filepath.Join(rootDir, "turbo.json")) {
+		graph.Tools = append(graph.Tools, "turbo")
+	}
+	if len(patterns) == 0 && !nx {
+		return nil, nil
+	}
+
+	// Collect the workspaces with the names of everything they depend on
+	dependsOn := make(map[string][]string)
+	err := filepath.WalkDir(rootDir, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && fullPath != rootDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || fullPath == rootDir {
+			return nil
+		}
+		name := d.Name()
+		if projectSkipDirs[name] || strings.HasPrefix(name, ".") {
+			return filepath.SkipDir
+		}
+		relPath, err := filepath.Rel(rootDir, fullPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		workspace := Workspace{Path: relPath}
+		var dependencies []string
+		found := false
+		if data, err := os.ReadFile(filepath.Join(fullPath, "package.json")); err == nil && matchWorkspacePatterns(patterns, relPath) {
+			found = true
+			workspace.Name, dependencies = packageDependencies(data)
+		}
+		if data, err := os.ReadFile(filepath.Join(fullPath, "project.json")); err == nil && nx {
+			found = true
+			var project struct {
+				Name                 string   `json:"name"`
+				ImplicitDependencies []string `json:"implicitDependencies"`
+			}
+			if json.Unmarshal(data, &project) == nil {
+				if workspace.Name == "" {
+					workspace.Name = project.Name
+				}
+				dependencies = append(dependencies, project.ImplicitDependencies...)
+			}
+		}
+		if !found {
+			return nil
+		}
+		if workspace.Name == "" {
+			workspace.Name = name
+		}
+		graph.Workspaces = append(graph.Workspaces, workspace)
+		dependsOn[relPath] = dependencies
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect workspaces: %w", err)
+	}
+	if len(graph.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	// Keep the dependencies on other workspaces
+	names := make(map[string]bool, len(graph.Workspaces))
+	for _, workspace := range graph.Workspaces {
+		names[workspace.Name] = true
+	}
+	for i := range graph.Workspaces {
+		workspace := &graph.Workspaces[i]
+		seen := make(map[string]bool)
+		for _, dependency := range dependsOn[workspace.Path] {
+			if names[dependency] && dependency != workspace.Name && !seen[dependency] {
+				seen[dependency] = true
+				workspace.Dependencies = append(workspace.Dependencies, dependency)
+			}
+		}
+		sort.Strings(workspace.Dependencies)
+	}
+	return graph, nil
+}
+
+// pnpmWorkspacePatterns reads the packages list of pnpm-workspace.yaml
+func pnpmWorkspacePatterns(data []byte) []string {
+	var patterns []string
+	inPackages := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			inPackages = strings.HasPrefix(line, "packages:")
+			continue
+		}
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if i := strings.Index(pattern, " #"); i >= 0 {
+				pattern = strings.TrimSpace(pattern[:i])
+			}
+			patterns = append(patterns, strings.Trim(pattern, `"'`))
+		}
+	}
+	return patterns
+}
+
+// packageWorkspacePatterns reads the workspaces of a root package.json: a
+// list of patterns, or Yarn's object with a packages list
+func packageWorkspacePatterns(data []byte) []string {
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &pkg) != nil || len(pkg.Workspaces) == 0 {
+		return nil
+	}
+	var patterns []string
+	if json.Unmarshal(pkg.Workspaces, &patterns) == nil {
+		return patterns
+	}
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	json.Unmarshal(pkg.Workspaces, &yarn)
+	return yarn.Packages
+}
+
+// packageDependencies reads the name of a package and the names of all its
+// dependencies from its package.json
+func packageDependencies(data []byte) (string, []string) {
+	var pkg struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return "", nil
+	}
+	var dependencies []string
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name := range deps {
+			dependencies = append(dependencies, name)
+		}
+	}
+	return pkg.Name, dependencies
+}
+
+// matchWorkspacePatterns reports whether a directory is a workspace under the
+// patterns of a monorepo: it matches a pattern and no "!" exclusion
+func matchWorkspacePatterns(patterns []string, dir string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"), "/")
+		if matchWorkspacePattern(strings.Split(pattern, "/"), strings.Split(dir, "/")) {
+			if exclude {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchWorkspacePattern matches the elements of a directory against those of
+// a pattern, where "**" matches any number of elements
+func matchWorkspacePattern(pattern, dir []string) bool {
+	if len(pattern) == 0 {
+		return len(dir) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(dir); i++ {
+			if matchWorkspacePattern(pattern[1:], dir[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(dir) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], dir[0]); err != nil || !ok {
+		return false
+	}
+	return matchWorkspacePattern(pattern[1:], dir[1:])
+}
+
+// FindWorkspace returns the workspace with the given name or path. The last
+// element of a name or path also matches if it is unique, so "web" finds
+// "@acme/web".
+func FindWorkspace(graph *WorkspaceGraph, name string) (*Workspace, error) {
+	if graph == nil {
+		return nil, fmt.Errorf("workspace %q not found (no pnpm, Yarn, npm, or Nx workspaces detected)", name)
+	}
+	name = strings.TrimSuffix(filepath.ToSlash(name), "/")
+	for i := range graph.Workspaces {
+		if graph.Workspaces[i].Name == name || graph.Workspaces[i].Path == name {
+			return &graph.Workspaces[i], nil
+		}
+	}
+
+	var matches []*Workspace
+	for i := range graph.Workspaces {
+		if lastElement(graph.Workspaces[i].Name) == name || lastElement(graph.Workspaces[i].Path) == name {
+			matches = append(matches, &graph.Workspaces[i])
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	names := make([]string, len(graph.Workspaces))
+	for i, workspace := range graph.Workspaces {
+		names[i] = workspace.Name
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("workspace %q is ambiguous (detected: %s)", name, strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("workspace %q not found (detected: %s)", name, strings.Join(names, ", "))
+}
+
+// DependencyClosure returns a workspace and the workspaces it depends on,
+// directly or not, by path
+func (g *WorkspaceGraph) DependencyClosure(workspace *Workspace) []Workspace {
+	byName := make(map[string]*Workspace, len(g.Workspaces))
+	for i := range g.Workspaces {
+		byName[g.Workspaces[i].Name] = &g.Workspaces[i]
+	}
+	seen := map[string]bool{workspace.Name: true}
+	queue := []*Workspace{workspace}
+	var closure []Workspace
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		closure = append(closure, *current)
+		for _, dependency := range current.Dependencies {
+			if !seen[dependency] && byName[dependency] != nil {
+				seen[dependency] = true
+				queue = append(queue, byName[dependency])
+			}
+		}
+	}
+	sort.Slice(closure, func(i, j int) bool {
+		return closure[i].Path < closure[j].Path
+	})
+	return closure
+}
+
+// including returns the graph limited to the workspaces holding some of
+// paths, or nil when none does
+func (g *WorkspaceGraph) including(paths []string) *WorkspaceGraph {
+	if g == nil {
+		return nil
+	}
+	limited := &WorkspaceGraph{Manager: g.Manager, Tools: g.Tools}
+	for _, workspace := range g.Workspaces {
+		if slices.ContainsFunc(paths, workspace.holds) {
+			limited.Workspaces = append(limited.Workspaces, workspace)
+		}
+	}
+	if len(limited.Workspaces) == 0 {
+		return nil
+	}
+	return limited
+}
+
+// WorkspaceFiles keeps the files of the given workspaces among paths, with
+// the root files that configure the monorepo. paths are slash-separated paths
+// relative to the root of the monorepo, without a leading slash.
+func WorkspaceFiles(workspaces []Workspace, paths []string) []string {
+	var files []string
+	for _, relPath := range paths {
+		if slices.Contains(workspaceRootFiles, relPath) {
+			files = append(files, relPath)
+			continue
+		}
+		for _, workspace := range workspaces {
+			if workspace.holds(relPath) {
+				files = append(files, relPath)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// holds reports whether a file is in the directory of the workspace
+func (w Workspace) holds(relPath string) bool {
+	return strings.HasPrefix(relPath, w.Path+"/")
+}
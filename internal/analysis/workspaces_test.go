@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeWorkspaceTree creates files under a new temporary directory
+func writeWorkspaceTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	tempDir, err := os.MkdirTemp("", "codectx_workspaces_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	return tempDir
+}
+
+func TestDetectWorkspaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected *WorkspaceGraph
+	}{
+		{
+			name: "pnpm with turbo",
+			files: map[string]string{
+				"package.json":                         `{"name": "acme", "private": true}`,
+				"pnpm-workspace.yaml":                  "packages:\n  - 'apps/*'\n  - \"packages/**\" # shared\n  - '!packages/legacy'\n",
+				"turbo.json":                           `{"tasks": {}}`,
+				"apps/web/package.json":                `{"name": "@acme/web", "dependencies": {"@acme/ui": "workspace:*", "react": "^18"}, "devDependencies": {"@acme/config": "workspace:*"}}`,
+				"packages/ui/package.json":             `{"name": "@acme/ui", "peerDependencies": {"react": "^18"}, "dependencies": {"@acme/utils": "workspace:*"}}`,
+				"packages/lib/utils/package.json":      `{"name": "@acme/utils"}`,
+				"packages/config/package.json":         `{"name": "@acme/config"}`,
+				"packages/legacy/package.json":         `{"name": "@acme/legacy"}`,
+				"apps/web/node_modules/x/package.json": `{"name": "x"}`,
+				"docs/package.json":                    `{"name": "docs"}`,
+			},
+			expected: &WorkspaceGraph{Manager: "pnpm", Tools: []string{"turbo"}, Workspaces: []Workspace{
+				{Name: "@acme/web", Path: "apps/web", Dependencies: []string{"@acme/config", "@acme/ui"}},
+				{Name: "@acme/config", Path: "packages/config"},
+				{Name: "@acme/utils", Path: "packages/lib/utils"},
+				{Name: "@acme/ui", Path: "packages/ui", Dependencies: []string{"@acme/utils"}},
+			}},
+		},
+		{
+			name: "yarn",
+			files: map[string]string{
+				"package.json":            `{"workspaces": {"packages": ["./services/*"]}}`,
+				"yarn.lock":               "",
+				"services/a/package.json": `{"name": "a", "dependencies": {"b": "*"}}`,
+				"services/b/package.json": `{}`,
+			},
+			expected: &WorkspaceGraph{Manager: "yarn", Workspaces: []Workspace{
+				{Name: "a", Path: "services/a", Dependencies: []string{"b"}},
+				{Name: "b", Path: "services/b"},
+			}},
+		},
+		{
+			name: "nx projects",
+			files: map[string]string{
+				"nx.json":                `{}`,
+				"apps/shop/project.json": `{"name": "shop", "implicitDependencies": ["cart"]}`,
+				"libs/cart/project.json": `{"name": "cart"}`,
+			},
+			expected: &WorkspaceGraph{Tools: []string{"nx"}, Workspaces: []Workspace{
+				{Name: "shop", Path: "apps/shop", Dependencies: []string{"cart"}},
+				{Name: "cart", Path: "libs/cart"},
+			}},
+		},
+		{
+			name:  "no monorepo",
+			files: map[string]string{"package.json": `{"name": "app"}`, "lib/package.json": `{"name": "lib"}`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graph, err := DetectWorkspaces(writeWorkspaceTree(t, test.files))
+			if err != nil {
+				t.Fatalf("DetectWorkspaces failed: %v", err)
+			}
+			if !reflect.DeepEqual(graph, test.expected) {
+				t.Errorf("Expected %+v, got %+v", test.expected, graph)
+			}
+		})
+	}
+}
+
+func TestWorkspaceScope(t *testing.T) {
+	graph := &WorkspaceGraph{Manager: "pnpm", Workspaces: []Workspace{
+		{Name: "@acme/admin", Path: "apps/admin", Dependencies: []string{"@acme/ui"}},
+		{Name: "@acme/web", Path: "apps/web", Dependencies: []string{"@acme/ui"}},
+		{Name: "@acme/ui", Path: "packages/ui", Dependencies: []string{"@acme/utils"}},
+		{Name: "@acme/utils", Path: "packages/utils"},
+		{Name: "ui-kit", Path: "legacy/ui"},
+	}}
+
+	if _, err := FindWorkspace(graph, "ui"); err == nil {
+		t.Error("Expected an error for an ambiguous workspace")
+	}
+	if _, err := FindWorkspace(graph, "mobile"); err == nil {
+		t.Error("Expected an error for an unknown workspace")
+	}
+	if _, err := FindWorkspace(nil, "web"); err == nil {
+		t.Error("Expected an error without workspaces")
+	}
+	workspace, err := FindWorkspace(graph, "apps/web/")
+	if err != nil {
+		t.Fatalf("FindWorkspace failed: %v", err)
+	}
+
+	closure := graph.DependencyClosure(workspace)
+	var names []string
+	for _, w := range closure {
+		names = append(names, w.Name)
+	}
+	if expected := []string{"@acme/web", "@acme/ui", "@acme/utils"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	paths := []string{
+		"package.json", "pnpm-workspace.yaml", "README.md",
+		"apps/admin/src/index.ts", "apps/web/src/index.ts", "apps/website/index.ts",
+		"packages/ui/button.tsx", "packages/utils/format.ts",
+	}
+	expected := []string{"package.json", "pnpm-workspace.yaml", "apps/web/src/index.ts", "packages/ui/button.tsx", "packages/utils/format.ts"}
+	if got := WorkspaceFiles(closure, paths); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
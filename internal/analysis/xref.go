@@ -0,0 +1,226 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// maxXrefFiles is the number of referencing files listed per symbol in text output
+const maxXrefFiles = 5
+
+// XrefIndex is a cross-reference index: where each symbol is defined and which
+// files use it
+type XrefIndex struct {
+	Symbols []XrefSymbol `json:"symbols"`
+	Tokens  int          `json:"estimated_tokens"`
+}
+
+// XrefSymbol is a symbol definition and the files using it
+type XrefSymbol struct {
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"` // function, method, type, const, var, class, ...
+	File       string         `json:"file"`
+	Line       int            `json:"line"`
+	References int            `json:"references"`      // Total uses outside the definition
+	Files      map[string]int `json:"files,omitempty"` // Uses per file, including the defining file
+}
+
+// ReferencingFiles returns the files that use the symbol, most uses first
+func (s XrefSymbol) ReferencingFiles() []string {
+	files := make([]string, 0, len(s.Files))
+	for file := range s.Files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if s.Files[files[i]] != s.Files[files[j]] {
+			return s.Files[files[i]] > s.Files[files[j]]
+		}
+		return files[i] < files[j]
+	})
+	return files
+}
+
+// BuildXref indexes the symbols defined in the given files and counts their
+// uses in all of them. Go files are parsed, so only identifiers in code count;
+// other languages use the declaration patterns of ExtractSignatures, and any
+// word matching a symbol name counts as a use. Symbols defined in several files
+// share their uses. paths are slash-separated paths relative to rootDir,
+// without a leading slash.
+func BuildXref(rootDir string, paths []string) (*XrefIndex, error) {
+	index := &XrefIndex{Symbols: []XrefSymbol{}}
+	uses := make(map[string]map[string]int) // File to identifier counts
+	fset := token.NewFileSet()
+
+	for _, relPath := range paths {
+		fullPath := platform.JoinSlash(rootDir, relPath)
+		var symbols []XrefSymbol
+		var counts map[string]int
+		var err error
+		switch {
+		case strings.EqualFold(filepath.Ext(relPath), ".go"):
+			symbols, counts, err = goXref(fset, fullPath)
+			if _, isSyntaxError := err.(scanner.ErrorList); isSyntaxError {
+				symbols, counts, err = patternXref(fullPath) // Fall back to the patterns for files that don't parse
+			}
+		case HasSignatureSupport(relPath):
+			symbols, counts, err = patternXref(fullPath)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to index %s: %w", relPath, err)
+		}
+		for i := range symbols {
+			symbols[i].File = relPath
+		}
+		index.Symbols = append(index.Symbols, symbols...)
+		uses[relPath] = counts
+	}
+
+	// Attribute the uses of each name to every symbol with that name
+	for i := range index.Symbols {
+		symbol := &index.Symbols[i]
+		for file, counts := range uses {
+			if n := counts[symbol.Name]; n > 0 {
+				if symbol.Files == nil {
+					symbol.Files = make(map[string]int)
+				}
+				symbol.Files[file] = n
+				symbol.References += n
+			}
+		}
+		index.Tokens += estimateXrefTokens(*symbol)
+	}
+	return index, nil
+}
+
+// goXref parses a Go file and returns its top-level declarations and the number
+// of uses of each identifier, not counting the declarations themselves
+func goXref(fset *token.FileSet, path string) ([]XrefSymbol, map[string]int, error) {
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var symbols []XrefSymbol
+	declared := make(map[*ast.Ident]bool)
+	add := func(ident *ast.Ident, kind string) {
+		if ident.Name == "_" {
+			return
+		}
+		declared[ident] = true
+		symbols = append(symbols, XrefSymbol{Name: ident.Name, Kind: kind, Line: fset.Position(ident.Pos()).Line})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				add(decl.Name, "method")
+			} else {
+				add(decl.Name, "function")
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name, "type")
+				case *ast.ValueSpec:
+					kind := "var"
+					if decl.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range spec.Names {
+						add(name, kind)
+					}
+				}
+			}
+		}
+	}
+
+	declared[file.Name] = true // The package clause doesn't use anything
+	counts := make(map[string]int)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && !declared[ident] {
+			counts[ident.Name]++
+		}
+		return true
+	})
+	return symbols, counts, nil
+}
+
+// patternXref returns the declarations of a file found by the signature patterns
+// and the number of uses of each word, not counting the declarations themselves
+func patternXref(path string) ([]XrefSymbol, map[string]int, error) {
+	signatures, err := ExtractSignatures(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, ident := range identifierPattern.FindAllString(string(content), -1) {
+		counts[ident]++
+	}
+
+	symbols := make([]XrefSymbol, 0, len(signatures))
+	for _, sig := range signatures {
+		symbols = append(symbols, XrefSymbol{Name: sig.Name, Kind: sig.Kind, Line: sig.Line})
+		if counts[sig.Name] > 0 {
+			counts[sig.Name]--
+		}
+	}
+	return symbols, counts, nil
+}
+
+// estimateXrefTokens estimates the tokens a symbol's entry adds to the index (about 4 characters per token)
+func estimateXrefTokens(symbol XrefSymbol) int {
+	size := len(symbol.Name) + len(symbol.Kind) + 24
+	files := 0
+	for file := range symbol.Files {
+		if files++; files > maxXrefFiles {
+			break
+		}
+		size += len(file) + 6
+	}
+	return (size + 3) / 4
+}
+
+// FormatXrefUses describes the uses of a symbol, listing up to maxXrefFiles
+// files, such as "7 uses in 2 files: cmd/run.go (5), main.go (2)"
+func FormatXrefUses(symbol XrefSymbol) string {
+	if symbol.References == 0 {
+		return "no uses"
+	}
+	files := symbol.ReferencingFiles()
+	text := fmt.Sprintf("%s in %s: ", plural(symbol.References, "use"), plural(len(files), "file"))
+	parts := make([]string, 0, maxXrefFiles+1)
+	for i, file := range files {
+		if i == maxXrefFiles {
+			parts = append(parts, fmt.Sprintf("+%d more", len(files)-maxXrefFiles))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", file, symbol.Files[file]))
+	}
+	return text + strings.Join(parts, ", ")
+}
+
+// plural returns a count with a noun, adding an s unless the count is one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildXref(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_xref_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"lib/parse.go": "package lib\n\n// Parse parses input\nfunc Parse(s string) Result {\n\treturn Result{}\n}\n\ntype Result struct{}\n\nconst maxDepth = 3\n",
+		"main.go":      "package main\n\nimport \"example.com/lib\"\n\nfunc main() {\n\tr := lib.Parse(\"x\") // Parse once\n\t_ = r\n\t_ = lib.Parse(\"y\")\n}\n",
+		"app.py":       "def parse_args():\n    pass\n\nparse_args()\n",
+		"notes.txt":    "Parse Result\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	index, err := BuildXref(tempDir, []string{"app.py", "lib/parse.go", "main.go", "notes.txt"})
+	if err != nil {
+		t.Fatalf("BuildXref failed: %v", err)
+	}
+
+	symbols := make(map[string]XrefSymbol)
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Name] = symbol
+	}
+
+	tests := []struct {
+		name       string
+		kind       string
+		file       string
+		line       int
+		references int
+	}{
+		{"parse_args", "function", "app.py", 1, 1},
+		{"Parse", "function", "lib/parse.go", 4, 2}, // Not the comments or the definition
+		{"Result", "type", "lib/parse.go", 8, 2},
+		{"maxDepth", "const", "lib/parse.go", 10, 0},
+		{"main", "function", "main.go", 5, 0}, // Not the package clause
+	}
+	for _, tt := range tests {
+		symbol, ok := symbols[tt.name]
+		if !ok {
+			t.Errorf("Expected symbol %s", tt.name)
+			continue
+		}
+		if symbol.Kind != tt.kind || symbol.File != tt.file || symbol.Line != tt.line || symbol.References != tt.references {
+			t.Errorf("Expected %s %s at %s:%d with %d references, got %+v", tt.kind, tt.name, tt.file, tt.line, tt.references, symbol)
+		}
+	}
+	if got := symbols["Parse"].Files["main.go"]; got != 2 {
+		t.Errorf("Expected 2 uses of Parse in main.go, got %d", got)
+	}
+	if len(index.Symbols) != len(tests) {
+		t.Errorf("Expected %d symbols, got %d", len(tests), len(index.Symbols))
+	}
+}
+
+func TestFormatXrefUses(t *testing.T) {
+	symbol := XrefSymbol{Name: "Parse", References: 7, Files: map[string]int{"main.go": 2, "cmd/run.go": 5}}
+	expected := "7 uses in 2 files: cmd/run.go (5), main.go (2)"
+	if got := FormatXrefUses(symbol); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	symbol = XrefSymbol{Name: "run", References: 1, Files: map[string]int{"main.go": 1}}
+	if got := FormatXrefUses(symbol); got != "1 use in 1 file: main.go (1)" {
+		t.Errorf("Expected one use, got %q", got)
+	}
+}
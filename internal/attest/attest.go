@@ -0,0 +1,209 @@
+package attest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Extension is appended to the name of an artifact for its detached signature
+const Extension = ".sig"
+
+// Algorithm is the signature algorithm of attestations
+const Algorithm = "ed25519"
+
+// Statement is what a signature attests: the artifact's digest and the tool
+// that produced it
+type Statement struct {
+	Artifact  string `json:"artifact"` // Base name of the signed file
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Tool      string `json:"tool"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Created   string `json:"created"`    // RFC 3339 time of signing
+	PublicKey string `json:"public_key"` // Base64 Ed25519 key of the signer
+}
+
+// Signature is a detached signature file: the statement and an Ed25519
+// signature of its JSON encoding
+type Signature struct {
+	Statement
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature"` // Base64
+}
+
+// Tool identifies the program producing an artifact
+type Tool struct {
+	Name    string
+	Version string
+	Commit  string
+}
+
+// LoadPrivateKey reads an Ed25519 private key from a PEM file (PKCS #8, as
+// written by "openssl genpkey -algorithm ed25519") or a file holding the
+// base64 seed or private key
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %s: %w", path, err)
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+		}
+		return privateKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("invalid signing key %s: expected PEM or base64", path)
+	case len(raw) == ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case len(raw) == ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("invalid signing key %s: expected a %d or %d byte Ed25519 key", path, ed25519.SeedSize, ed25519.PrivateKeySize)
+}
+
+// ParsePublicKey reads an Ed25519 public key in PEM (PKIX, as written by
+// "openssl pkey -pubout") or base64
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("not an Ed25519 key")
+		}
+		return publicKey, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("expected PEM or a base64 Ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Fingerprint identifies a public key like ssh-keygen, as "SHA256:" and the
+// unpadded base64 digest of the key
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// SignFile signs the artifact at path and writes its detached signature to
+// path with Extension appended. It returns the signature written.
+func SignFile(path string, key ed25519.PrivateKey, tool Tool) (*Signature, error) {
+	size, digest, err := digestFile(path)
+	if err != nil {
+		return nil, err
+	}
+	statement := Statement{
+		Artifact:  filepath.Base(path),
+		Size:      size,
+		SHA256:    digest,
+		Tool:      tool.Name,
+		Version:   tool.Version,
+		Commit:    tool.Commit,
+		Created:   time.Now().UTC().Format(time.RFC3339),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	message, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+	signature := &Signature{
+		Statement: statement,
+		Algorithm: Algorithm,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, message)),
+	}
+
+	data, err := json.MarshalIndent(signature, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+Extension, append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write signature: %w", err)
+	}
+	return signature, nil
+}
+
+// VerifyFile checks the artifact at path against the signature file at
+// signaturePath. When trusted is not nil, the artifact must also have been
+// signed with it. It returns the verified signature.
+func VerifyFile(path, signaturePath string, trusted ed25519.PublicKey) (*Signature, error) {
+	data, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return nil, err
+	}
+	var signature Signature
+	if err := json.Unmarshal(data, &signature); err != nil {
+		return nil, fmt.Errorf("invalid signature file %s: %w", signaturePath, err)
+	}
+	if signature.Algorithm != Algorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", signature.Algorithm)
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(signature.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key in %s", signaturePath)
+	}
+	if trusted != nil && !trusted.Equal(ed25519.PublicKey(publicKey)) {
+		return nil, fmt.Errorf("signed with key %s, not the trusted key %s", Fingerprint(publicKey), Fingerprint(trusted))
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature in %s", signaturePath)
+	}
+	message, err := json.Marshal(signature.Statement)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(publicKey, message, sig) {
+		return nil, errors.New("signature verification failed")
+	}
+
+	size, digest, err := digestFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if size != signature.Size || digest != signature.SHA256 {
+		return nil, errors.New("the file does not match its signature; it was modified after signing")
+	}
+	return &signature, nil
+}
+
+// digestFile returns the size and hex SHA-256 digest of a file
+func digestFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
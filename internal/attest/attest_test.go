@@ -0,0 +1,102 @@
+package attest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignFile_VerifyFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_attest_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	artifact := filepath.Join(tempDir, "context.md")
+	if err := os.WriteFile(artifact, []byte("# Context\n"), 0644); err != nil {
+		t.Fatalf("Failed to write artifact: %v", err)
+	}
+	tool := Tool{Name: "codectx", Version: "v1.2.3", Commit: "abc1234"}
+	if _, err := SignFile(artifact, privateKey, tool); err != nil {
+		t.Fatalf("SignFile failed: %v", err)
+	}
+
+	signature, err := VerifyFile(artifact, artifact+Extension, publicKey)
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if signature.Artifact != "context.md" || signature.Size != 10 || signature.Version != "v1.2.3" || signature.Commit != "abc1234" {
+		t.Errorf("Unexpected statement: %+v", signature.Statement)
+	}
+
+	// Any key is accepted without a trusted one, but not another trusted key
+	if _, err := VerifyFile(artifact, artifact+Extension, nil); err != nil {
+		t.Errorf("Expected the signature to verify without a trusted key, got %v", err)
+	}
+	if _, err := VerifyFile(artifact, artifact+Extension, otherKey); err == nil {
+		t.Errorf("Expected an error for an untrusted key")
+	}
+
+	// A modified artifact fails
+	if err := os.WriteFile(artifact, []byte("# Context\nextra\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify artifact: %v", err)
+	}
+	if _, err := VerifyFile(artifact, artifact+Extension, publicKey); err == nil {
+		t.Errorf("Expected an error for a modified artifact")
+	}
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_attest_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	files := map[string][]byte{
+		"key.pem":  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		"seed.txt": []byte(base64.StdEncoding.EncodeToString(privateKey.Seed()) + "\n"),
+		"key.txt":  []byte(base64.StdEncoding.EncodeToString(privateKey)),
+	}
+	for name, data := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+		key, err := LoadPrivateKey(path)
+		if err != nil {
+			t.Errorf("%s: LoadPrivateKey failed: %v", name, err)
+		} else if !key.Equal(privateKey) {
+			t.Errorf("%s: loaded a different key", name)
+		}
+	}
+
+	invalid := filepath.Join(tempDir, "invalid.txt")
+	if err := os.WriteFile(invalid, []byte("c2hvcnQ="), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	if _, err := LoadPrivateKey(invalid); err == nil {
+		t.Errorf("Expected an error for a short key")
+	}
+}
@@ -0,0 +1,170 @@
+// Package audit checks the dependencies pinned by package manifests and lock
+// files against the OSV vulnerability database (https://osv.dev), either
+// through its API or in an offline copy.
+package audit
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities of findings, from most to least severe
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+	SeverityUnknown  = "UNKNOWN"
+)
+
+var severityRank = map[string]int{
+	SeverityCritical: 0,
+	SeverityHigh:     1,
+	SeverityMedium:   2,
+	SeverityLow:      3,
+	SeverityUnknown:  4,
+}
+
+// Options configures where vulnerabilities are looked up
+type Options struct {
+	DBDir  string // Directory of OSV records to match offline ("" queries the API)
+	APIURL string // Base URL of the OSV API ("" for DefaultAPIURL)
+}
+
+// Finding is a known vulnerability affecting a dependency
+type Finding struct {
+	Dependency Dependency `json:"dependency"`
+	ID         string     `json:"id"`
+	Aliases    []string   `json:"aliases,omitempty"`
+	Summary    string     `json:"summary"`
+	Severity   string     `json:"severity"`
+	CVSS       string     `json:"cvss,omitempty"`  // CVSS vector, when the record has one
+	Fixed      string     `json:"fixed,omitempty"` // Lowest newer version with a fix
+}
+
+// Audit parses the manifests under rootDir and returns the known
+// vulnerabilities of their dependencies, most severe first. Without
+// opts.DBDir the dependency names and versions are sent to the OSV API.
+func Audit(rootDir string, opts Options) ([]Finding, error) {
+	deps, err := ParseManifests(rootDir)
+	if err != nil || len(deps) == 0 {
+		return nil, err
+	}
+
+	var findings []Finding
+	if opts.DBDir != "" {
+		findings, err = queryDB(opts.DBDir, deps)
+	} else {
+		apiURL := opts.APIURL
+		if apiURL == "" {
+			apiURL = DefaultAPIURL
+		}
+		findings, err = queryAPI(strings.TrimSuffix(apiURL, "/"), deps)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if severityRank[findings[i].Severity] != severityRank[findings[j].Severity] {
+			return severityRank[findings[i].Severity] < severityRank[findings[j].Severity]
+		}
+		return findings[i].ID < findings[j].ID
+	})
+	return findings, nil
+}
+
+// newFinding reports a vulnerability record for a dependency it affects
+func newFinding(dep Dependency, rec *record) Finding {
+	finding := Finding{
+		Dependency: dep,
+		ID:         rec.ID,
+		Aliases:    rec.Aliases,
+		Summary:    rec.Summary,
+		Severity:   normalizeSeverity(rec.DatabaseSpecific.Severity),
+		Fixed:      rec.fixedVersion(dep),
+	}
+	if finding.Summary == "" {
+		finding.Summary, _, _ = strings.Cut(strings.TrimSpace(rec.Details), "\n")
+	}
+	for _, severity := range rec.Severity {
+		if strings.HasPrefix(severity.Type, "CVSS") {
+			finding.CVSS = severity.Score
+			break
+		}
+	}
+	return finding
+}
+
+// normalizeSeverity maps the severity labels of advisory databases to the
+// Severity constants
+func normalizeSeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return SeverityCritical
+	case "HIGH":
+		return SeverityHigh
+	case "MODERATE", "MEDIUM":
+		return SeverityMedium
+	case "LOW":
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+// compareVersions compares two dotted versions such as "1.10.0" and "1.9.2",
+// numerically where both parts are numbers. A pre-release ("1.0.0-rc.1") comes
+// before its release.
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	a, _, _ = strings.Cut(a, "+")
+	b, _, _ = strings.Cut(b, "+")
+	aRelease, aPre, aHasPre := strings.Cut(a, "-")
+	bRelease, bPre, bHasPre := strings.Cut(b, "-")
+
+	if c := compareParts(strings.Split(aRelease, "."), strings.Split(bRelease, ".")); c != 0 {
+		return c
+	}
+	switch {
+	case aHasPre && !bHasPre:
+		return -1
+	case !aHasPre && bHasPre:
+		return 1
+	}
+	return compareParts(strings.Split(aPre, "."), strings.Split(bPre, "."))
+}
+
+// compareParts compares version parts one by one, treating missing numeric
+// parts as zero
+func compareParts(a, b []string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := "0", "0"
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case xErr == nil:
+			return -1 // Numeric identifiers sort before alphanumeric ones
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files with the given content under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+}
+
+func TestParseManifests(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_audit_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeFiles(t, tempDir, map[string]string{
+		"go.mod":                "module example.com/app\n\nrequire golang.org/x/text v0.3.7\n\nrequire (\n\tgithub.com/pkg/errors v0.9.1 // indirect\n)\n",
+		"web/package.json":      `{"dependencies": {"lodash": "^4.17.20", "left-pad": "latest", "local": "file:../local"}}`,
+		"api/package.json":      `{"dependencies": {"express": "^4.0.0"}}`,
+		"api/package-lock.json": `{"lockfileVersion": 3, "packages": {"": {"name": "api"}, "node_modules/express": {"version": "4.17.1"}}}`,
+		"py/requirements.txt":   "Django==3.2.0\nrequests>=2.0\n# comment\nPyYAML[extra] == 5.3\n",
+		"rs/Cargo.lock":         "[[package]]\nname = \"smallvec\"\nversion = \"1.6.0\"\n",
+		"rb/Gemfile.lock":       "GEM\n  specs:\n    rack (2.2.3)\n      mustermann (~> 1.0)\n",
+		"node_modules/x/go.mod": "module x\n\nrequire example.com/y v1.0.0\n",
+	})
+
+	deps, err := ParseManifests(tempDir)
+	if err != nil {
+		t.Fatalf("ParseManifests failed: %v", err)
+	}
+
+	expected := []Dependency{
+		{EcosystemNPM, "express", "4.17.1", "api/package-lock.json"},
+		{EcosystemGo, "github.com/pkg/errors", "0.9.1", "go.mod"},
+		{EcosystemGo, "golang.org/x/text", "0.3.7", "go.mod"},
+		{EcosystemPyPI, "django", "3.2.0", "py/requirements.txt"},
+		{EcosystemPyPI, "pyyaml", "5.3", "py/requirements.txt"},
+		{EcosystemRubyGems, "rack", "2.2.3", "rb/Gemfile.lock"},
+		{EcosystemCrates, "smallvec", "1.6.0", "rs/Cargo.lock"},
+		{EcosystemNPM, "lodash", "4.17.20", "web/package.json"},
+	}
+	if len(deps) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %d: %+v", len(expected), len(deps), deps)
+	}
+	for i, want := range expected {
+		if deps[i] != want {
+			t.Errorf("Expected %+v, got %+v", want, deps[i])
+		}
+	}
+}
+
+// lodashRecord is an OSV record affecting lodash before 4.17.21
+const lodashRecord = `{
+	"id": "GHSA-35jh-r3h4-6jhm",
+	"summary": "Command Injection in lodash",
+	"aliases": ["CVE-2021-23337"],
+	"affected": [{
+		"package": {"ecosystem": "npm", "name": "lodash"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+	}],
+	"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}],
+	"database_specific": {"severity": "HIGH"}
+}`
+
+func TestAudit_OfflineDB(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_audit_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeFiles(t, tempDir, map[string]string{
+		"repo/package.json":               `{"dependencies": {"lodash": "4.17.20", "react": "18.2.0"}}`,
+		"repo/requirements.txt":           "django==3.2.0\n",
+		"db/npm/GHSA-35jh-r3h4-6jhm.json": lodashRecord,
+		"db/PyPI/PYSEC-1.json":            `{"id": "PYSEC-1", "details": "SQL injection\nin QuerySet", "affected": [{"package": {"ecosystem": "PyPI", "name": "Django"}, "versions": ["3.2.0", "3.2.1"]}]}`,
+		"db/PyPI/PYSEC-2.json":            `{"id": "PYSEC-2", "affected": [{"package": {"ecosystem": "PyPI", "name": "django"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "4.0"}]}]}]}`,
+	})
+
+	findings, err := Audit(filepath.Join(tempDir, "repo"), Options{DBDir: filepath.Join(tempDir, "db")})
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+
+	lodash := findings[0]
+	if lodash.ID != "GHSA-35jh-r3h4-6jhm" || lodash.Severity != SeverityHigh || lodash.Fixed != "4.17.21" {
+		t.Errorf("Unexpected lodash finding: %+v", lodash)
+	}
+	if !strings.HasPrefix(lodash.CVSS, "CVSS:3.1/") {
+		t.Errorf("Expected the CVSS vector, got %q", lodash.CVSS)
+	}
+	django := findings[1]
+	if django.ID != "PYSEC-1" || django.Severity != SeverityUnknown || django.Summary != "SQL injection" {
+		t.Errorf("Unexpected django finding: %+v", django)
+	}
+}
+
+func TestAudit_API(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_audit_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	writeFiles(t, tempDir, map[string]string{
+		"package.json": `{"dependencies": {"lodash": "4.17.20", "react": "18.2.0"}}`,
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/querybatch":
+			var batch struct {
+				Queries []struct {
+					Package struct {
+						Name string `json:"name"`
+					} `json:"package"`
+				} `json:"queries"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var results []string
+			for _, query := range batch.Queries {
+				if query.Package.Name == "lodash" {
+					results = append(results, `{"vulns": [{"id": "GHSA-35jh-r3h4-6jhm"}]}`)
+				} else {
+					results = append(results, `{}`)
+				}
+			}
+			w.Write([]byte(`{"results": [` + strings.Join(results, ",") + `]}`))
+		case "/v1/vulns/GHSA-35jh-r3h4-6jhm":
+			w.Write([]byte(lodashRecord))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	findings, err := Audit(tempDir, Options{APIURL: server.URL})
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Dependency.Name != "lodash" || findings[0].Fixed != "4.17.21" {
+		t.Errorf("Unexpected findings: %+v", findings)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.10.0", "1.9.2", 1},
+		{"1.2", "1.2.0", 0},
+		{"v0.3.7", "0.3.8", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"2.0.0+build", "2.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%q, %q): expected %d, got %d", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings := []Finding{
+		{Dependency: Dependency{EcosystemNPM, "lodash", "4.17.20", "web/package.json"}, ID: "GHSA-1", Summary: "Prototype pollution", Severity: SeverityHigh, Fixed: "4.17.21"},
+		{Dependency: Dependency{EcosystemNPM, "lodash", "4.17.20", "api/package.json"}, ID: "GHSA-1", Summary: "Prototype pollution", Severity: SeverityHigh},
+		{Dependency: Dependency{EcosystemGo, "golang.org/x/text", "0.3.7", "go.mod"}, ID: "GO-2", Severity: SeverityUnknown},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, findings, "v1.0.0"); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to parse SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("Expected 2 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(run.Results))
+	}
+	if run.Results[0].Level != "error" || run.Results[2].Level != "note" {
+		t.Errorf("Expected levels error and note, got %s and %s", run.Results[0].Level, run.Results[2].Level)
+	}
+	if uri := run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "api/package.json" {
+		t.Errorf("Expected location api/package.json, got %s", uri)
+	}
+	expected := "lodash 4.17.20 is affected by GHSA-1: Prototype pollution (fixed in 4.17.21)"
+	if run.Results[0].Message.Text != expected {
+		t.Errorf("Expected %q, got %q", expected, run.Results[0].Message.Text)
+	}
+}
@@ -0,0 +1,271 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OSV ecosystems of the parsed manifests
+const (
+	EcosystemGo       = "Go"
+	EcosystemNPM      = "npm"
+	EcosystemPyPI     = "PyPI"
+	EcosystemCrates   = "crates.io"
+	EcosystemRubyGems = "RubyGems"
+)
+
+// Dependency is a package version pinned by a manifest or lock file
+type Dependency struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Manifest  string `json:"manifest"` // Relative path of the manifest, with slashes
+}
+
+// skipDirs are directories never searched for manifests
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"__pycache__":  true,
+}
+
+var (
+	goRequirePattern    = regexp.MustCompile(`^(?:require\s+)?(\S+)\s+(v\S+)`)
+	pinnedPattern       = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*===?\s*([^\s;,#]+)`)
+	gemSpecPattern      = regexp.MustCompile(`^    (\S+) \(([^)\s]+)\)$`)
+	cargoNamePattern    = regexp.MustCompile(`^name\s*=\s*"([^"]+)"`)
+	cargoVersionPattern = regexp.MustCompile(`^version\s*=\s*"([^"]+)"`)
+)
+
+// ParseManifests finds the manifests and lock files under rootDir and returns
+// the dependencies they pin, sorted by manifest, ecosystem, and name. Only exact
+// versions are audited: package.json is read when there is no package-lock.json,
+// with range operators such as ^ dropped from its versions.
+func ParseManifests(rootDir string) ([]Dependency, error) {
+	var deps []Dependency
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != rootDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != rootDir && (skipDirs[name] || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var parse func([]byte) []Dependency
+		switch d.Name() {
+		case "go.mod":
+			parse = parseGoMod
+		case "package-lock.json":
+			parse = parsePackageLock
+		case "package.json":
+			if _, err := os.Stat(filepath.Join(filepath.Dir(path), "package-lock.json")); err == nil {
+				return nil
+			}
+			parse = parsePackageJSON
+		case "requirements.txt":
+			parse = parseRequirements
+		case "Cargo.lock":
+			parse = parseCargoLock
+		case "Gemfile.lock":
+			parse = parseGemfileLock
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return err
+		}
+		for _, dep := range parse(data) {
+			dep.Manifest = filepath.ToSlash(relPath)
+			deps = append(deps, dep)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifests: %w", err)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Manifest != deps[j].Manifest {
+			return deps[i].Manifest < deps[j].Manifest
+		}
+		if deps[i].Ecosystem != deps[j].Ecosystem {
+			return deps[i].Ecosystem < deps[j].Ecosystem
+		}
+		return deps[i].Name < deps[j].Name
+	})
+	return deps, nil
+}
+
+// parseGoMod returns the required modules of a go.mod file
+func parseGoMod(data []byte) []Dependency {
+	var deps []Dependency
+	inRequire := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "require (":
+			inRequire = true
+			continue
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case !inRequire && !strings.HasPrefix(line, "require "):
+			continue
+		}
+		if match := goRequirePattern.FindStringSubmatch(line); match != nil {
+			// OSV lists Go module versions without the v prefix
+			deps = append(deps, Dependency{Ecosystem: EcosystemGo, Name: match[1], Version: strings.TrimPrefix(match[2], "v")})
+		}
+	}
+	return deps
+}
+
+// parsePackageLock returns the installed packages of a package-lock.json file
+func parsePackageLock(data []byte) []Dependency {
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+			Link    bool   `json:"link"`
+		} `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if json.Unmarshal(data, &lock) != nil {
+		return nil
+	}
+
+	seen := make(map[Dependency]bool)
+	var deps []Dependency
+	add := func(name, version string) {
+		dep := Dependency{Ecosystem: EcosystemNPM, Name: name, Version: version}
+		if name != "" && isExactVersion(version) && !seen[dep] {
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+
+	// Lockfile version 2 and later key packages by their node_modules path
+	for path, pkg := range lock.Packages {
+		i := strings.LastIndex(path, "node_modules/")
+		if i < 0 || pkg.Link {
+			continue
+		}
+		add(path[i+len("node_modules/"):], pkg.Version)
+	}
+	if len(lock.Packages) == 0 {
+		for name, pkg := range lock.Dependencies {
+			add(name, pkg.Version)
+		}
+	}
+	return deps
+}
+
+// parsePackageJSON returns the dependencies of a package.json file
+func parsePackageJSON(data []byte) []Dependency {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+
+	var deps []Dependency
+	for _, group := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for name, version := range group {
+			version = strings.TrimLeft(version, "^~=v ")
+			if isExactVersion(version) {
+				deps = append(deps, Dependency{Ecosystem: EcosystemNPM, Name: name, Version: version})
+			}
+		}
+	}
+	return deps
+}
+
+// parseRequirements returns the pinned packages (name==version) of a requirements.txt file
+func parseRequirements(data []byte) []Dependency {
+	var deps []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		match := pinnedPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match != nil && isExactVersion(match[2]) {
+			deps = append(deps, Dependency{Ecosystem: EcosystemPyPI, Name: normalizePyPIName(match[1]), Version: match[2]})
+		}
+	}
+	return deps
+}
+
+// parseCargoLock returns the packages of a Cargo.lock file
+func parseCargoLock(data []byte) []Dependency {
+	var deps []Dependency
+	var name string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "[[package]]" {
+			name = ""
+		} else if match := cargoNamePattern.FindStringSubmatch(line); match != nil {
+			name = match[1]
+		} else if match := cargoVersionPattern.FindStringSubmatch(line); match != nil && name != "" {
+			deps = append(deps, Dependency{Ecosystem: EcosystemCrates, Name: name, Version: match[1]})
+			name = ""
+		}
+	}
+	return deps
+}
+
+// parseGemfileLock returns the gems of a Gemfile.lock file
+func parseGemfileLock(data []byte) []Dependency {
+	var deps []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if match := gemSpecPattern.FindStringSubmatch(scanner.Text()); match != nil {
+			deps = append(deps, Dependency{Ecosystem: EcosystemRubyGems, Name: match[1], Version: match[2]})
+		}
+	}
+	return deps
+}
+
+// isExactVersion reports whether version names one release rather than a range,
+// tag, or URL
+func isExactVersion(version string) bool {
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return false
+	}
+	return !strings.ContainsAny(version, " <>|*xX/:")
+}
+
+// normalizePyPIName returns the normalized form of a Python package name
+func normalizePyPIName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
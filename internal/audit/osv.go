@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the base URL of the OSV API
+const DefaultAPIURL = "https://api.osv.dev"
+
+// batchSize is the number of queries sent in one OSV batch request
+const batchSize = 1000
+
+// maxResponseSize bounds the size of an OSV API response
+const maxResponseSize = 32 * 1024 * 1024
+
+// httpClient is used for all OSV requests
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// record is the part of an OSV vulnerability record used to report findings
+type record struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Details  string   `json:"details"`
+	Aliases  []string `json:"aliases"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string              `json:"type"`
+			Events []map[string]string `json:"events"`
+		} `json:"ranges"`
+		Versions []string `json:"versions"`
+	} `json:"affected"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// queryAPI asks the OSV API which vulnerabilities affect deps
+func queryAPI(apiURL string, deps []Dependency) ([]Finding, error) {
+	type query struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+		Version string `json:"version"`
+	}
+	var response struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		} `json:"results"`
+	}
+
+	records := make(map[string]*record)
+	var findings []Finding
+	for start := 0; start < len(deps); start += batchSize {
+		batch := deps[start:min(start+batchSize, len(deps))]
+		queries := make([]query, len(batch))
+		for i, dep := range batch {
+			queries[i].Package.Name = dep.Name
+			queries[i].Package.Ecosystem = dep.Ecosystem
+			queries[i].Version = dep.Version
+		}
+		body, err := json.Marshal(map[string]interface{}{"queries": queries})
+		if err != nil {
+			return nil, err
+		}
+		if err := request(http.MethodPost, apiURL+"/v1/querybatch", body, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Results) != len(batch) {
+			return nil, fmt.Errorf("OSV returned %d results for %d queries", len(response.Results), len(batch))
+		}
+
+		for i, result := range response.Results {
+			for _, vuln := range result.Vulns {
+				rec, ok := records[vuln.ID]
+				if !ok {
+					rec = &record{}
+					if err := request(http.MethodGet, apiURL+"/v1/vulns/"+url.PathEscape(vuln.ID), nil, rec); err != nil {
+						return nil, err
+					}
+					records[vuln.ID] = rec
+				}
+				findings = append(findings, newFinding(batch[i], rec))
+			}
+		}
+	}
+	return findings, nil
+}
+
+// request sends an OSV API request and decodes the JSON response into v
+func request(method, endpoint string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query OSV: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query OSV: %s returned %s", endpoint, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to query OSV: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse OSV response: %w", err)
+	}
+	return nil
+}
+
+// queryDB matches deps against a directory of OSV records, such as an
+// ecosystem's all.zip export unpacked
+func queryDB(dir string, deps []Dependency) ([]Finding, error) {
+	byPackage := make(map[string][]*record)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rec := &record{}
+		if err := json.Unmarshal(data, rec); err != nil || rec.ID == "" {
+			return nil // Not an OSV record
+		}
+		seen := make(map[string]bool)
+		for _, affected := range rec.Affected {
+			key := packageKey(affected.Package.Ecosystem, affected.Package.Name)
+			if !seen[key] {
+				seen[key] = true
+				byPackage[key] = append(byPackage[key], rec)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSV database: %w", err)
+	}
+
+	var findings []Finding
+	for _, dep := range deps {
+		for _, rec := range byPackage[packageKey(dep.Ecosystem, dep.Name)] {
+			if rec.affects(dep) {
+				findings = append(findings, newFinding(dep, rec))
+			}
+		}
+	}
+	return findings, nil
+}
+
+// packageKey identifies a package across records. Ecosystem variants such as
+// "Debian:12" are reduced to the ecosystem, and PyPI names are normalized.
+func packageKey(ecosystem, name string) string {
+	ecosystem, _, _ = strings.Cut(ecosystem, ":")
+	if ecosystem == EcosystemPyPI {
+		name = normalizePyPIName(name)
+	}
+	return ecosystem + "\x00" + name
+}
+
+// affects reports whether the record lists dep's version as affected, either
+// explicitly or within one of its version ranges
+func (r *record) affects(dep Dependency) bool {
+	key := packageKey(dep.Ecosystem, dep.Name)
+	for _, affected := range r.Affected {
+		if packageKey(affected.Package.Ecosystem, affected.Package.Name) != key {
+			continue
+		}
+		for _, version := range affected.Versions {
+			if version == dep.Version {
+				return true
+			}
+		}
+		for _, rng := range affected.Ranges {
+			if rng.Type != "SEMVER" && rng.Type != "ECOSYSTEM" {
+				continue // Git ranges name commits, not versions
+			}
+			if inRange(dep.Version, rng.Events) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inRange evaluates the events of an OSV range, in order, for version
+func inRange(version string, events []map[string]string) bool {
+	affected := false
+	for _, event := range events {
+		if introduced, ok := event["introduced"]; ok && (introduced == "0" || compareVersions(version, introduced) >= 0) {
+			affected = true
+		}
+		if fixed, ok := event["fixed"]; ok && compareVersions(version, fixed) >= 0 {
+			affected = false
+		}
+		if last, ok := event["last_affected"]; ok && compareVersions(version, last) > 0 {
+			affected = false
+		}
+	}
+	return affected
+}
+
+// fixedVersion returns the lowest version fixing the record for dep that is
+// newer than dep's version, or "" if none is known
+func (r *record) fixedVersion(dep Dependency) string {
+	key := packageKey(dep.Ecosystem, dep.Name)
+	fixed := ""
+	for _, affected := range r.Affected {
+		if packageKey(affected.Package.Ecosystem, affected.Package.Name) != key {
+			continue
+		}
+		for _, rng := range affected.Ranges {
+			for _, event := range rng.Events {
+				version, ok := event["fixed"]
+				if !ok || compareVersions(version, dep.Version) <= 0 {
+					continue
+				}
+				if fixed == "" || compareVersions(version, fixed) < 0 {
+					fixed = version
+				}
+			}
+		}
+	}
+	return fixed
+}
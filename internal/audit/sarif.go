@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIF log structure, limited to the properties codectx writes
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string            `json:"id"`
+	ShortDescription sarifMessage      `json:"shortDescription"`
+	HelpURI          string            `json:"helpUri"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log, with one rule per
+// vulnerability and one result per affected dependency, located at its manifest
+func WriteSARIF(w io.Writer, findings []Finding, toolVersion string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "codectx",
+			Version:        toolVersion,
+			InformationURI: "https://github.com/takeisa/codectx",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, finding := range findings {
+		if !rules[finding.ID] {
+			rules[finding.ID] = true
+			rule := sarifRule{
+				ID:               finding.ID,
+				ShortDescription: sarifMessage{Text: finding.Summary},
+				HelpURI:          "https://osv.dev/vulnerability/" + finding.ID,
+			}
+			if finding.CVSS != "" {
+				rule.Properties = map[string]string{"cvss": finding.CVSS}
+			}
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		dep := finding.Dependency
+		message := fmt.Sprintf("%s %s is affected by %s", dep.Name, dep.Version, finding.ID)
+		if finding.Summary != "" {
+			message += ": " + finding.Summary
+		}
+		if finding.Fixed != "" {
+			message += fmt.Sprintf(" (fixed in %s)", finding.Fixed)
+		}
+		result := sarifResult{
+			RuleID:     finding.ID,
+			Level:      sarifLevel(finding.Severity),
+			Message:    sarifMessage{Text: message},
+			Locations:  make([]sarifLocation, 1),
+			Properties: map[string]string{"severity": finding.Severity, "ecosystem": dep.Ecosystem},
+		}
+		result.Locations[0].PhysicalLocation.ArtifactLocation.URI = dep.Manifest
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}})
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
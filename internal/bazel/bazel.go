@@ -0,0 +1,100 @@
+package bazel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// Command is the Bazel executable, which is usually Bazelisk installed as bazel
+const Command = "bazel"
+
+var (
+	// patternPattern matches the target patterns that --bazel-target accepts:
+	// absolute labels in this or an external repository, with an optional
+	// target name or "...", and labels relative to the package
+	patternPattern = regexp.MustCompile(`^((@@?[\w.+~-]*)?//[\w./+=,@~-]*(:[\w./+=,@~*-]+)?|:[\w./+=,@~-]+)$`)
+	// locationPattern matches a line of "bazel query --output=location" for a
+	// source file, capturing its path
+	locationPattern = regexp.MustCompile(`^(.+):\d+:\d+: source file \S+$`)
+)
+
+// ValidatePattern checks that a target pattern, such as "//service/api/..." or
+// "//service/api:server", is a single label that can be placed in a query
+func ValidatePattern(pattern string) error {
+	if !patternPattern.MatchString(pattern) {
+		return fmt.Errorf("%q is not a Bazel target pattern (e.g., //service/api/... or //service/api:server)", pattern)
+	}
+	return nil
+}
+
+// SourceFiles runs "bazel query" in dir to resolve the source files that the
+// targets of pattern are built from, including those of their dependencies in
+// the same workspace. It returns slash-separated paths relative to dir; files
+// outside dir, such as those of external repositories, are left out.
+func SourceFiles(ctx context.Context, dir, pattern string) ([]string, error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(Command); err != nil {
+		return nil, fmt.Errorf("%s is not installed or not in PATH", Command)
+	}
+
+	query := fmt.Sprintf(`kind("source file", deps(%s))`, pattern)
+	cmd := exec.CommandContext(ctx, Command, "query", "--output=location", query)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("bazel query %s failed: %w: %s", pattern, err, errorLine(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("bazel query %s failed: %w", pattern, err)
+	}
+	return parseLocations(output, dir), nil
+}
+
+// parseLocations reads the paths of source files from the output of "bazel
+// query --output=location", relative to root
+func parseLocations(output []byte, root string) []string {
+	roots := []string{root}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil && resolved != root {
+		roots = append(roots, resolved)
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := locationPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		for _, root := range roots {
+			relPath, err := platform.RelSlash(root, match[1])
+			if err == nil && relPath != ".." && !strings.HasPrefix(relPath, "../") {
+				files = append(files, relPath)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// errorLine returns the last error of Bazel's messages, or their last line
+func errorLine(stderr []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], "ERROR: ") {
+			return strings.TrimSpace(strings.TrimPrefix(lines[i], "ERROR: "))
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
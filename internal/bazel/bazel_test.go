@@ -0,0 +1,88 @@
+package bazel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"//service/api/...", true},
+		{"//service/api:server", true},
+		{"//:all", true},
+		{"//...", true},
+		{"@rules_go//go/tools:all", true},
+		{":server", true},
+		{"service/api", false},
+		{"//a) union deps(//b", false},
+		{`//a:"b"`, false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if err := ValidatePattern(test.pattern); (err == nil) != test.valid {
+			t.Errorf("ValidatePattern(%q): expected valid %v, got %v", test.pattern, test.valid, err)
+		}
+	}
+}
+
+func TestParseLocations(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "work", "repo")
+	output := strings.Join([]string{
+		filepath.Join(root, "service", "api", "main.go") + ":1:1: source file //service/api:main.go",
+		filepath.Join(root, "lib", "util.go") + ":1:1: source file //lib:util.go",
+		filepath.Join(string(filepath.Separator), "cache", "external", "rules_go", "x.go") + ":1:1: source file @rules_go//:x.go",
+		"Loading: 0 packages loaded",
+		"",
+	}, "\n")
+
+	expected := []string{"service/api/main.go", "lib/util.go"}
+	if got := parseLocations([]byte(output), root); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake bazel is a shell script")
+	}
+	tempDir, err := os.MkdirTemp("", "bazel-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A fake bazel prints the location of a file in the directory it runs in
+	binDir := filepath.Join(tempDir, "bin")
+	os.Mkdir(binDir, 0755)
+	script := `#!/bin/sh
+case "$3" in
+*//broken*) echo "ERROR: no such package 'broken'" >&2; echo "INFO: Elapsed time: 0.1s" >&2; exit 7 ;;
+esac
+echo "$(pwd -P)/api/main.go:1:1: source file //api:main.go"
+`
+	if err := os.WriteFile(filepath.Join(binDir, Command), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write the fake bazel: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	files, err := SourceFiles(context.Background(), tempDir, "//api/...")
+	if err != nil {
+		t.Fatalf("SourceFiles failed: %v", err)
+	}
+	if expected := []string{"api/main.go"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	_, err = SourceFiles(context.Background(), tempDir, "//broken/...")
+	if err == nil || !strings.Contains(err.Error(), "no such package 'broken'") {
+		t.Errorf("Expected the error of bazel, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package benchtree generates synthetic source trees of a chosen size, so that
+// the benchmarks and "codectx bench" measure the same kind of repository
+package benchtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Spec describes a synthetic tree. Zero fields take the defaults below.
+type Spec struct {
+	Files       int // Number of files
+	FilesPerDir int // Files placed in each directory before the next one is started
+	DirsPerDir  int // Subdirectories of each directory
+	Lines       int // Lines of each text file
+}
+
+// Defaults of the fields of Spec
+const (
+	DefaultFiles       = 1000
+	DefaultFilesPerDir = 20
+	DefaultDirsPerDir  = 4
+	DefaultLines       = 100
+)
+
+// kinds are the files a tree is made of, in turn: mostly source code, with
+// documentation, data, and an occasional binary file that filters skip
+var kinds = []struct {
+	ext    string
+	format string // Format of line i, with i as %[1]d ("" for binary data)
+}{
+	{".go", "\tresult%[1]d := compute(input[%[1]d], options) // step %[1]d of the pipeline"},
+	{".py", "    value_%[1]d = transform(records[%[1]d], strict=True)  # step %[1]d"},
+	{".go", "\tif err := validate(items[%[1]d]); err != nil { return fmt.Errorf(\"item %[1]d: %%w\", err) }"},
+	{".ts", "  const item%[1]d = await fetchItem(%[1]d, { retries: 3 });"},
+	{".md", "Paragraph %[1]d describes how the service handles a request and what it returns."},
+	{".json", "  \"key%[1]d\": {\"id\": %[1]d, \"name\": \"entry %[1]d\", \"enabled\": true},"},
+	{".go", "\tcase %[1]d: return \"state %[1]d\""},
+	{".py", "    handlers[%[1]d] = lambda event: {\"status\": 200}"},
+	{".yaml", "setting_%[1]d: value-%[1]d"},
+	{".bin", ""},
+}
+
+// withDefaults fills in the zero fields of spec
+func (spec Spec) withDefaults() Spec {
+	if spec.Files <= 0 {
+		spec.Files = DefaultFiles
+	}
+	if spec.FilesPerDir <= 0 {
+		spec.FilesPerDir = DefaultFilesPerDir
+	}
+	if spec.DirsPerDir <= 0 {
+		spec.DirsPerDir = DefaultDirsPerDir
+	}
+	if spec.Lines <= 0 {
+		spec.Lines = DefaultLines
+	}
+	return spec
+}
+
+// Generate writes the tree described by spec into dir and returns the paths of
+// its files. The same spec always produces the same tree. Directories are
+// numbered breadth first: the files of the first directory are in dir itself,
+// and directory n is a subdirectory of directory (n-1)/DirsPerDir.
+func Generate(dir string, spec Spec) ([]string, error) {
+	spec = spec.withDefaults()
+
+	dirs := []string{dir}
+	paths := make([]string, 0, spec.Files)
+	for i := 0; i < spec.Files; i++ {
+		n := i / spec.FilesPerDir
+		for len(dirs) <= n {
+			parent := dirs[(len(dirs)-1)/spec.DirsPerDir]
+			sub := filepath.Join(parent, fmt.Sprintf("pkg%03d", len(dirs)))
+			if err := os.Mkdir(sub, 0755); err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, sub)
+		}
+
+		kind := kinds[i%len(kinds)]
+		path := filepath.Join(dirs[n], fmt.Sprintf("file%03d%s", i%spec.FilesPerDir, kind.ext))
+		if err := os.WriteFile(path, content(kind.format, spec.Lines), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// content returns lines of text in format, or binary data when format is empty
+func content(format string, lines int) []byte {
+	if format == "" {
+		data := make([]byte, lines*64)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		return data
+	}
+
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, format+"\n", i)
+	}
+	return []byte(b.String())
+}
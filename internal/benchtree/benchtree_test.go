@@ -0,0 +1,50 @@
+package benchtree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := Generate(dir, Spec{Files: 45, FilesPerDir: 10, DirsPerDir: 2, Lines: 3})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(paths) != 45 {
+		t.Fatalf("Expected 45 files, got %d", len(paths))
+	}
+
+	// Directories 1 and 2 are under the root, 3 and 4 under directory 1
+	expected := map[int]string{
+		0:  "file000.go",
+		10: "pkg001/file000.go",
+		25: "pkg002/file005.json",
+		44: "pkg001/pkg004/file004.md",
+	}
+	for i, relPath := range expected {
+		if paths[i] != filepath.Join(dir, filepath.FromSlash(relPath)) {
+			t.Errorf("Expected file %d at %s, got %s", i, relPath, paths[i])
+		}
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("Expected 3 lines, got %d:\n%s", lines, data)
+	}
+
+	// The same spec produces the same tree
+	again, err := Generate(t.TempDir(), Spec{Files: 45, FilesPerDir: 10, DirsPerDir: 2, Lines: 3})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	other, _ := os.ReadFile(again[0])
+	if string(other) != string(data) {
+		t.Errorf("Expected the same content, got %q and %q", data, other)
+	}
+}
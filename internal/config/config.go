@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codectx/internal/hooks"
+	"codectx/internal/language"
+)
+
+// FileName is the name of the configuration file in the codectx directory
+const FileName = "config.json"
+
+// Config holds the user settings stored in ~/.codectx/config.json
+type Config struct {
+	Aliases  map[string][]string `json:"aliases,omitempty"`  // Named argument lists, run as "codectx NAME"
+	Hooks    *hooks.Hooks        `json:"hooks,omitempty"`    // Commands run before scanning, per file, and after output
+	Defaults map[string]any      `json:"defaults,omitempty"` // Option values used when the flag is not given
+
+	// Languages of extensions (".gotmpl") and file names ("Jenkinsfile"), overriding the built-in ones
+	Languages map[string]string `json:"languages,omitempty"`
+}
+
+// Dir returns the per-user codectx directory, ~/.codectx
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".codectx"), nil
+}
+
+// DefaultPath returns the path of the configuration file, ~/.codectx/config.json
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the configuration file at path. A missing file is an empty configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if cfg.Hooks != nil {
+		if err := cfg.Hooks.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	if err := language.ValidateOverrides(cfg.Languages); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration to path, replacing the file atomically
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadAndSave(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, ".codectx", FileName)
+
+	// A missing file is an empty configuration
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Aliases) != 0 {
+		t.Errorf("Expected no aliases, got %v", cfg.Aliases)
+	}
+
+	cfg.Aliases = map[string][]string{"review": {"--format", "markdown", "--git-only", "--stats"}}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Aliases, cfg.Aliases) {
+		t.Errorf("Expected %v, got %v", cfg.Aliases, loaded.Aliases)
+	}
+
+	// Invalid JSON, hooks, and languages are reported
+	for _, content := range []string{"{", `{"hooks": {"pre-scan": [{"command": "make", "on_failure": "retry"}]}}`, `{"languages": {"a/b": "go"}}`} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected error for invalid config %s, got nil", content)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package coverage reads test coverage reports, Go coverage profiles (go test
+// -coverprofile) and LCOV tracefiles, and maps them onto the scanned files.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codectx/internal/platform"
+)
+
+// Report formats
+const (
+	FormatGo   = "go"   // Go coverage profile, counting statements
+	FormatLCOV = "lcov" // LCOV tracefile, counting lines
+)
+
+// Report is the coverage of the files of a report found under the scanned directory
+type Report struct {
+	Format string
+	Files  map[string]*File // By path relative to the scanned directory, with slashes
+}
+
+// File is the coverage of one file, in statements for Go profiles and in lines for LCOV
+type File struct {
+	Covered   int      `json:"covered"`
+	Total     int      `json:"total"`
+	Percent   float64  `json:"percent"`
+	Uncovered []string `json:"uncovered_lines,omitempty"` // Line ranges without coverage, such as "12-18"
+}
+
+// fileLines collects the per-line hit counts of a file while a report is read
+type fileLines struct {
+	hits       map[int]int // Line to hit count, for LCOV
+	statements map[string]block
+}
+
+// block is a Go coverage block
+type block struct {
+	startLine, endLine int
+	statements, count  int
+}
+
+// Load reads the report at reportPath, detecting its format, and returns the
+// coverage of its files under rootDir. Report paths are Go import paths or
+// absolute or relative paths; each is matched to the file under rootDir with
+// the longest path it ends with, and files not found under rootDir are dropped.
+func Load(reportPath, rootDir string) (*Report, error) {
+	file, err := os.Open(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	files := make(map[string]*fileLines)
+	lines := func(name string) *fileLines {
+		if files[name] == nil {
+			files[name] = &fileLines{hits: make(map[int]int), statements: make(map[string]block)}
+		}
+		return files[name]
+	}
+
+	report := &Report{Files: make(map[string]*File)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var current *fileLines
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNum == 1 && strings.HasPrefix(line, "mode:") {
+			report.Format = FormatGo
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		if report.Format == FormatGo {
+			// Blocks are "name.go:startLine.startCol,endLine.endCol statements count";
+			// profiles concatenated from several runs repeat the mode line
+			if strings.HasPrefix(line, "mode:") {
+				continue
+			}
+			name, b, err := parseGoBlock(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", reportPath, lineNum, err)
+			}
+			key := line[:strings.LastIndexByte(line, ' ')] // Runs of several test binaries list the same block
+			fl := lines(name)
+			if previous, ok := fl.statements[key]; ok && previous.count > b.count {
+				b.count = previous.count
+			}
+			fl.statements[key] = b
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		switch field {
+		case "SF":
+			report.Format = FormatLCOV
+			current = lines(value)
+		case "DA":
+			if current == nil {
+				return nil, fmt.Errorf("%s:%d: DA record outside a source file", reportPath, lineNum)
+			}
+			parts := strings.Split(value, ",")
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("%s:%d: invalid DA record %q", reportPath, lineNum, value)
+			}
+			number, err1 := strconv.Atoi(parts[0])
+			hits, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("%s:%d: invalid DA record %q", reportPath, lineNum, value)
+			}
+			current.hits[number] += hits
+		case "end_of_record":
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", reportPath, err)
+	}
+	if report.Format == "" {
+		return nil, fmt.Errorf("%s is neither a Go coverage profile nor an LCOV report", reportPath)
+	}
+
+	for name, fl := range files {
+		relPath, ok := resolvePath(rootDir, name)
+		if !ok {
+			continue
+		}
+		if _, ok := report.Files[relPath]; ok {
+			continue // The same file under two names, such as a module path and a relative path
+		}
+		report.Files[relPath] = fl.summarize()
+	}
+	return report, nil
+}
+
+// parseGoBlock parses a block line of a Go coverage profile
+func parseGoBlock(line string) (string, block, error) {
+	var b block
+	colon := strings.LastIndex(line, ":")
+	if colon < 0 {
+		return "", b, fmt.Errorf("invalid profile line %q", line)
+	}
+	name, rest := line[:colon], line[colon+1:]
+	var startCol, endCol int
+	if _, err := fmt.Sscanf(rest, "%d.%d,%d.%d %d %d", &b.startLine, &startCol, &b.endLine, &endCol, &b.statements, &b.count); err != nil {
+		return "", b, fmt.Errorf("invalid profile line %q", line)
+	}
+	return name, b, nil
+}
+
+// summarize computes the coverage of a file from its hits or blocks
+func (fl *fileLines) summarize() *File {
+	file := &File{}
+	uncovered := make(map[int]bool)
+	covered := make(map[int]bool)
+	for number, hits := range fl.hits {
+		file.Total++
+		if hits > 0 {
+			file.Covered++
+			covered[number] = true
+		} else {
+			uncovered[number] = true
+		}
+	}
+	for _, b := range fl.statements {
+		file.Total += b.statements
+		if b.count > 0 {
+			file.Covered += b.statements
+		}
+		for number := b.startLine; number <= b.endLine; number++ {
+			if b.count > 0 {
+				covered[number] = true
+			} else {
+				uncovered[number] = true
+			}
+		}
+	}
+	if file.Total > 0 {
+		file.Percent = math.Round(float64(file.Covered)*1000/float64(file.Total)) / 10
+	}
+
+	// A line shared by a covered and an uncovered block counts as covered
+	var numbers []int
+	for number := range uncovered {
+		if !covered[number] {
+			numbers = append(numbers, number)
+		}
+	}
+	file.Uncovered = lineRanges(numbers)
+	return file
+}
+
+// lineRanges compresses line numbers into ranges such as "3", "7-9"
+func lineRanges(numbers []int) []string {
+	sort.Ints(numbers)
+	var ranges []string
+	for i := 0; i < len(numbers); {
+		j := i
+		for j+1 < len(numbers) && numbers[j+1] == numbers[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(numbers[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", numbers[i], numbers[j]))
+		}
+		i = j + 1
+	}
+	return ranges
+}
+
+// resolvePath returns the path relative to rootDir of a file named in a report,
+// trying the name without its leading elements until a file exists
+func resolvePath(rootDir, name string) (string, bool) {
+	name = filepath.ToSlash(name)
+	if filepath.IsAbs(filepath.FromSlash(name)) {
+		if relPath, err := platform.RelSlash(rootDir, filepath.FromSlash(name)); err == nil && !strings.HasPrefix(relPath, "../") {
+			if isFile(rootDir, relPath) {
+				return relPath, true
+			}
+		}
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	for name != "" {
+		if isFile(rootDir, name) {
+			return name, true
+		}
+		_, rest, found := strings.Cut(name, "/")
+		if !found {
+			break
+		}
+		name = rest
+	}
+	return "", false
+}
+
+// isFile reports whether relPath names a regular file under rootDir
+func isFile(rootDir, relPath string) bool {
+	info, err := os.Stat(platform.JoinSlash(rootDir, relPath))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Lookup returns the coverage of a file, by its path relative to the scanned directory
+func (r *Report) Lookup(relPath string) (*File, bool) {
+	if r == nil {
+		return nil, false
+	}
+	file, ok := r.Files[relPath]
+	return file, ok
+}
+
+// Untested returns the files of the report without any coverage, sorted
+func (r *Report) Untested() []string {
+	var paths []string
+	for relPath, file := range r.Files {
+		if file.Total > 0 && file.Covered == 0 {
+			paths = append(paths, relPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Percent returns the coverage of all files in the report
+func (r *Report) Percent() float64 {
+	covered, total := 0, 0
+	for _, file := range r.Files {
+		covered += file.Covered
+		total += file.Total
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+// Unit returns what the report counts, statements or lines
+func (r *Report) Unit() string {
+	if r.Format == FormatGo {
+		return "statements"
+	}
+	return "lines"
+}
+
+// Describe summarizes a file's coverage for file headers, such as "72.5% covered"
+func (f *File) Describe() string {
+	return fmt.Sprintf("%.1f%% covered", f.Percent)
+}
@@ -0,0 +1,112 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_coverage_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, name := range []string{"internal/parse/parse.go", "internal/parse/lex.go", "src/app.js", "src/util.js"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("code\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	reports := map[string]string{
+		// The second run of the same block adds coverage, and other.go is outside the tree
+		"coverage.out": "mode: set\n" +
+			"example.com/app/internal/parse/parse.go:3.20,5.2 2 1\n" +
+			"example.com/app/internal/parse/parse.go:7.20,10.2 3 0\n" +
+			"example.com/app/internal/parse/parse.go:12.20,13.2 1 0\n" +
+			"example.com/app/internal/parse/lex.go:3.20,6.2 4 0\n" +
+			"example.com/other/other.go:1.1,2.2 1 1\n" +
+			"mode: set\n" +
+			"example.com/app/internal/parse/parse.go:12.20,13.2 1 1\n",
+		"lcov.info": "TN:\n" +
+			"SF:" + filepath.Join(tempDir, "src", "app.js") + "\n" +
+			"DA:1,4\nDA:2,0\nDA:3,0\nDA:5,1\n" +
+			"LF:4\nLH:2\nend_of_record\n" +
+			"SF:src/util.js\n" +
+			"DA:1,0\n" +
+			"end_of_record\n",
+	}
+
+	tests := []struct {
+		report   string
+		format   string
+		expected map[string]File
+		untested []string
+	}{
+		{
+			report: "coverage.out",
+			format: FormatGo,
+			expected: map[string]File{
+				"internal/parse/parse.go": {Covered: 3, Total: 6, Percent: 50, Uncovered: []string{"7-10"}},
+				"internal/parse/lex.go":   {Covered: 0, Total: 4, Percent: 0, Uncovered: []string{"3-6"}},
+			},
+			untested: []string{"internal/parse/lex.go"},
+		},
+		{
+			report: "lcov.info",
+			format: FormatLCOV,
+			expected: map[string]File{
+				"src/app.js":  {Covered: 2, Total: 4, Percent: 50, Uncovered: []string{"2-3"}},
+				"src/util.js": {Covered: 0, Total: 1, Percent: 0, Uncovered: []string{"1"}},
+			},
+			untested: []string{"src/util.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.report, func(t *testing.T) {
+			reportPath := filepath.Join(tempDir, tt.report)
+			if err := os.WriteFile(reportPath, []byte(reports[tt.report]), 0644); err != nil {
+				t.Fatalf("Failed to write report: %v", err)
+			}
+
+			report, err := Load(reportPath, tempDir)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if report.Format != tt.format {
+				t.Errorf("Expected format %s, got %s", tt.format, report.Format)
+			}
+			if len(report.Files) != len(tt.expected) {
+				t.Errorf("Expected %d files, got %d", len(tt.expected), len(report.Files))
+			}
+			for relPath, expected := range tt.expected {
+				file, ok := report.Lookup(relPath)
+				if !ok {
+					t.Errorf("Expected coverage of %s", relPath)
+					continue
+				}
+				if !reflect.DeepEqual(*file, expected) {
+					t.Errorf("Expected %s coverage %+v, got %+v", relPath, expected, *file)
+				}
+			}
+			if untested := report.Untested(); !reflect.DeepEqual(untested, tt.untested) {
+				t.Errorf("Expected untested files %v, got %v", tt.untested, untested)
+			}
+		})
+	}
+
+	notReport := filepath.Join(tempDir, "notes.txt")
+	if err := os.WriteFile(notReport, []byte("just notes\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Load(notReport, tempDir); err == nil {
+		t.Errorf("Expected an error for a file that isn't a coverage report")
+	}
+}
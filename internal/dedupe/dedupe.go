@@ -0,0 +1,84 @@
+package dedupe
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+
+	"codectx/internal/platform"
+)
+
+// file is a file seen by an Index. Its hash is computed only once another
+// file of the same size turns up.
+type file struct {
+	path    string
+	relPath string
+	hash    []byte
+}
+
+// Index finds files whose content is identical to a file seen earlier.
+// Files are compared by size first and by SHA-256 hash only on a size match.
+type Index struct {
+	Stat       platform.StatFunc // Source of file sizes (nil for os.Stat)
+	Duplicates int               // Files found to duplicate an earlier file
+	SavedBytes int64             // Total size of the duplicates
+	bySize     map[int64][]*file
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{bySize: make(map[int64][]*file)}
+}
+
+// Check returns the relative path of an earlier file with the same content as
+// the file at path, or "" if there is none, in which case the file is recorded
+// as the original for later files.
+func (x *Index) Check(path, relPath string) (string, error) {
+	info, err := platform.Stat(x.Stat, path)
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+
+	current := &file{path: path, relPath: relPath}
+	for _, seen := range x.bySize[size] {
+		if seen.hash == nil {
+			if seen.hash, err = hashFile(seen.path); err != nil {
+				return "", err
+			}
+		}
+		if current.hash == nil {
+			if current.hash, err = hashFile(path); err != nil {
+				return "", err
+			}
+		}
+		if string(seen.hash) == string(current.hash) {
+			x.Duplicates++
+			x.SavedBytes += size
+			return seen.relPath, nil
+		}
+	}
+
+	x.bySize[size] = append(x.bySize[size], current)
+	return "", nil
+}
+
+// SavedTokens returns the estimated tokens saved by leaving out the duplicates
+func (x *Index) SavedTokens() int {
+	return int(x.SavedBytes / 4)
+}
+
+// hashFile returns the SHA-256 hash of a file's content
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,67 @@
+package dedupe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex_Check(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_dedupe_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"a.txt", "same content"},
+		{"b.txt", "other stuff!"}, // Same size, different content
+		{"c.txt", "same content"},
+		{"d.txt", "short"},
+		{"e.txt", "other stuff!"},
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, file.name), []byte(file.content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file.name, err)
+		}
+	}
+
+	expected := map[string]string{
+		"a.txt": "",
+		"b.txt": "",
+		"c.txt": "a.txt",
+		"d.txt": "",
+		"e.txt": "b.txt",
+	}
+
+	index := NewIndex()
+	for _, file := range files {
+		original, err := index.Check(filepath.Join(tempDir, file.name), file.name)
+		if err != nil {
+			t.Fatalf("Check(%s) failed: %v", file.name, err)
+		}
+		if original != expected[file.name] {
+			t.Errorf("Check(%s): expected %q, got %q", file.name, expected[file.name], original)
+		}
+	}
+
+	if index.Duplicates != 2 {
+		t.Errorf("Expected 2 duplicates, got %d", index.Duplicates)
+	}
+	if index.SavedBytes != 24 {
+		t.Errorf("Expected 24 saved bytes, got %d", index.SavedBytes)
+	}
+	if index.SavedTokens() != 6 {
+		t.Errorf("Expected 6 saved tokens, got %d", index.SavedTokens())
+	}
+}
+
+func TestIndex_CheckMissingFile(t *testing.T) {
+	index := NewIndex()
+	if _, err := index.Check(filepath.Join(os.TempDir(), "codectx-missing-file"), "missing"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// extractDOCX returns the paragraphs of a Word document, one per line
+func extractDOCX(content []byte) (string, error) {
+	body, err := readZipEntry(content, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	return xmlParagraphs(body, func(name string) bool { return name == "p" }, func(se xml.StartElement) string {
+		switch se.Name.Local {
+		case "tab":
+			return "\t"
+		case "br", "cr":
+			return "\n"
+		}
+		return ""
+	}, "t")
+}
+
+// extractODT returns the paragraphs and headings of an OpenDocument text file, one per line
+func extractODT(content []byte) (string, error) {
+	body, err := readZipEntry(content, "content.xml")
+	if err != nil {
+		return "", err
+	}
+	return xmlParagraphs(body, func(name string) bool { return name == "p" || name == "h" }, func(se xml.StartElement) string {
+		switch se.Name.Local {
+		case "tab":
+			return "\t"
+		case "line-break":
+			return "\n"
+		case "s":
+			// <text:s text:c="3"/> stands for a run of spaces
+			count := 1
+			for _, attr := range se.Attr {
+				if attr.Name.Local == "c" {
+					if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+						count = n
+					}
+				}
+			}
+			return strings.Repeat(" ", count)
+		}
+		return ""
+	}, "")
+}
+
+// readZipEntry reads one file from a zip archive held in memory
+func readZipEntry(content []byte, name string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxExtractedSize))
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// xmlParagraphs walks a document body, writing the text inside paragraph
+// elements. textElement restricts character data to elements with that local
+// name ("" accepts text anywhere inside a paragraph), and inline maps empty
+// elements such as tabs and line breaks to their text.
+func xmlParagraphs(body []byte, isParagraph func(name string) bool, inline func(se xml.StartElement) string, textElement string) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var sb strings.Builder
+	depth := 0 // Nesting depth of paragraph elements
+	inText := textElement == ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case isParagraph(t.Name.Local):
+				depth++
+			case depth > 0 && textElement != "" && t.Name.Local == textElement:
+				inText = true
+			case depth > 0:
+				sb.WriteString(inline(t))
+			}
+		case xml.EndElement:
+			switch {
+			case isParagraph(t.Name.Local) && depth > 0:
+				depth--
+				sb.WriteString("\n")
+			case textElement != "" && t.Name.Local == textElement:
+				inText = false
+			}
+		case xml.CharData:
+			if depth > 0 && inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
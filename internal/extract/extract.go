@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxExtractedSize bounds how much data is read from a document before conversion
+const maxExtractedSize = 64 * 1024 * 1024
+
+// extractor converts the raw content of a file into plain text
+type extractor func(content []byte) (string, error)
+
+// Options selects which kinds of files are converted
+type Options struct {
+	Documents bool // Notebooks, R Markdown, and office documents
+	PDF       bool // PDF files, which are skipped as binary unless requested
+}
+
+// documentExtractors maps lower-cased file extensions to their extractor
+var documentExtractors = map[string]extractor{
+	".ipynb": extractNotebook,
+	".rmd":   extractRMarkdown,
+	".qmd":   extractRMarkdown,
+	".docx":  extractDOCX,
+	".odt":   extractODT,
+}
+
+// extractorFor returns the extractor enabled by opts for the path's extension
+func extractorFor(path string, opts Options) (extractor, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if opts.PDF && ext == ".pdf" {
+		return extractPDF, true
+	}
+	if opts.Documents {
+		extract, ok := documentExtractors[ext]
+		return extract, ok
+	}
+	return nil, false
+}
+
+// Supported reports whether files with the path's extension are converted
+func Supported(path string, opts Options) bool {
+	_, ok := extractorFor(path, opts)
+	return ok
+}
+
+// Text converts the file at path into plain text. ok is false, and the file is
+// left alone, when no enabled extractor handles its extension.
+func Text(path string, opts Options) (text string, ok bool, err error) {
+	extract, ok := extractorFor(path, opts)
+	if !ok {
+		return "", false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", true, err
+	}
+	if info.Size() > maxExtractedSize {
+		return "", true, fmt.Errorf("%s is too large to extract (%d bytes)", filepath.Base(path), info.Size())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", true, err
+	}
+	text, err = extract(content)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to extract text from %s: %w", filepath.Base(path), err)
+	}
+	return text, true, nil
+}
@@ -0,0 +1,152 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractNotebook(t *testing.T) {
+	notebook := `{
+  "cells": [
+    {"cell_type": "markdown", "source": ["# Analysis\n", "Load the data."]},
+    {"cell_type": "code", "source": "import pandas as pd\ndf = pd.read_csv('x.csv')", "outputs": [
+      {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB"}}
+    ]},
+    {"cell_type": "code", "source": [], "outputs": []}
+  ],
+  "metadata": {"language_info": {"name": "python"}}
+}`
+
+	text, err := extractNotebook([]byte(notebook))
+	if err != nil {
+		t.Fatalf("extractNotebook failed: %v", err)
+	}
+
+	expected := "# %% [markdown]\n# # Analysis\n# Load the data.\n\n# %%\nimport pandas as pd\ndf = pd.read_csv('x.csv')\n\n# %%\n"
+	if text != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, text)
+	}
+	if strings.Contains(text, "iVBORw0KGgo") {
+		t.Error("Expected cell outputs to be dropped")
+	}
+}
+
+func TestExtractNotebook_Invalid(t *testing.T) {
+	if _, err := extractNotebook([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid notebook")
+	}
+}
+
+func TestExtractRMarkdown(t *testing.T) {
+	input := "---\ntitle: Report\n---\n\n```{r setup, include=FALSE}\nlibrary(dplyr)\n```\n\nText\n```{Python}\nprint(1)\n```\n"
+	expected := "---\ntitle: Report\n---\n\n```r\nlibrary(dplyr)\n```\n\nText\n```python\nprint(1)\n```\n"
+
+	text, err := extractRMarkdown([]byte(input))
+	if err != nil {
+		t.Fatalf("extractRMarkdown failed: %v", err)
+	}
+	if text != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, text)
+	}
+}
+
+func TestExtractDOCX(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>Design </w:t></w:r><w:r><w:t>notes</w:t></w:r></w:p>
+    <w:p><w:r><w:t>a</w:t><w:tab/><w:t>b</w:t><w:br/><w:t>c</w:t></w:r></w:p>
+    <w:sectPr><w:pgSz w:w="12240"/></w:sectPr>
+  </w:body>
+</w:document>`
+
+	text, err := extractDOCX(zipWith(t, "word/document.xml", document))
+	if err != nil {
+		t.Fatalf("extractDOCX failed: %v", err)
+	}
+	if expected := "Design notes\na\tb\nc\n"; text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestExtractODT(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+  <office:body><office:text>
+    <text:h text:outline-level="1">Title</text:h>
+    <text:p>one<text:s text:c="3"/>two<text:line-break/>three</text:p>
+    <text:p><text:span>styled</text:span> text</text:p>
+  </office:text></office:body>
+</office:document-content>`
+
+	text, err := extractODT(zipWith(t, "content.xml", content))
+	if err != nil {
+		t.Fatalf("extractODT failed: %v", err)
+	}
+	if expected := "Title\none   two\nthree\nstyled text\n"; text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestExtractDOCX_MissingDocument(t *testing.T) {
+	if _, err := extractDOCX(zipWith(t, "other.xml", "<x/>")); err == nil {
+		t.Error("Expected error for archive without word/document.xml")
+	}
+}
+
+func TestText(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_extract_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	notebookPath := filepath.Join(tempDir, "Analysis.IPYNB")
+	if err := os.WriteFile(notebookPath, []byte(`{"cells": [{"cell_type": "code", "source": "x = 1"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	opts := Options{Documents: true}
+	text, ok, err := Text(notebookPath, opts)
+	if !ok || err != nil || text != "# %%\nx = 1\n" {
+		t.Errorf("Expected extracted notebook, got %q (ok=%v, err=%v)", text, ok, err)
+	}
+
+	sourcePath := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(sourcePath, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if _, ok, _ := Text(sourcePath, opts); ok {
+		t.Error("Expected no extractor for a Go file")
+	}
+	if Supported(sourcePath, opts) || !Supported(notebookPath, opts) {
+		t.Error("Unexpected result from Supported")
+	}
+	if Supported(notebookPath, Options{PDF: true}) {
+		t.Error("Expected notebooks to be left alone when only PDF extraction is enabled")
+	}
+	if Supported("spec.pdf", opts) || !Supported("spec.PDF", Options{PDF: true}) {
+		t.Error("Expected PDFs to be converted only when PDF extraction is enabled")
+	}
+}
+
+// zipWith builds an in-memory zip archive holding a single file
+func zipWith(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	file, err := writer.Create(name)
+	if err != nil {
+		t.Fatalf("Failed to create zip entry: %v", err)
+	}
+	if _, err := file.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write zip entry: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
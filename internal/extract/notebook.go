@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// notebook is the subset of the Jupyter notebook format that is extracted.
+// Cell outputs, including embedded images, are deliberately not decoded.
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// notebookCell is a single notebook cell
+type notebookCell struct {
+	CellType string          `json:"cell_type"`
+	Source   json.RawMessage `json:"source"`
+}
+
+// extractNotebook converts a notebook into "percent" script form: code cells are
+// kept as code and markdown cells become comments, each under a "# %%" marker
+func extractNotebook(content []byte) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, cell := range nb.Cells {
+		source, err := cellSource(cell.Source)
+		if err != nil {
+			return "", err
+		}
+		source = strings.TrimRight(source, "\n")
+
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch cell.CellType {
+		case "code":
+			sb.WriteString("# %%\n")
+			if source != "" {
+				sb.WriteString(source)
+				sb.WriteString("\n")
+			}
+		default:
+			sb.WriteString("# %% [" + cell.CellType + "]\n")
+			for _, line := range strings.Split(source, "\n") {
+				sb.WriteString(strings.TrimRight("# "+line, " "))
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// cellSource decodes a cell source, which is either a string or a list of lines
+func cellSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err == nil {
+		return strings.Join(lines, ""), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// rmdChunkPattern matches an R Markdown chunk header such as "```{r setup, echo=FALSE}"
+var rmdChunkPattern = regexp.MustCompile("^(\\s*```+)\\s*\\{([A-Za-z0-9_]+)[^}]*\\}\\s*$")
+
+// extractRMarkdown rewrites chunk headers as plain fenced code blocks ("```r"),
+// dropping chunk options, and leaves the prose untouched
+func extractRMarkdown(content []byte) (string, error) {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if m := rmdChunkPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r")); m != nil {
+			lines[i] = m[1] + strings.ToLower(m[2])
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,858 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The extractor below reads the text of a PDF without relying on its cross-reference
+// table: it scans the file for indirect objects (including those packed into object
+// streams), walks the page tree, and interprets the text operators of each page's
+// content streams. Only Flate-compressed and uncompressed streams are supported;
+// glyphs are mapped to Unicode through the fonts' ToUnicode CMaps when present.
+
+// PDF value types produced by the lexer
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfRef     int
+	pdfDelim   string
+	pdfArray   []interface{}
+	pdfDict    map[string]interface{}
+)
+
+// pdfObject is an indirect object and the raw data of its stream, if any
+type pdfObject struct {
+	value  interface{}
+	stream []byte
+}
+
+// pdfDocument holds the objects found in a PDF file
+type pdfDocument struct {
+	objects map[int]*pdfObject
+}
+
+// pdfFont maps character codes of a font to text
+type pdfFont struct {
+	toUnicode map[int]string
+	codeBytes int
+}
+
+var (
+	pdfObjectPattern = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfStreamPattern = regexp.MustCompile(`^\s*stream(?:\r\n|\n|\r)`)
+)
+
+// maxFormDepth limits how deeply form XObjects are followed
+const maxFormDepth = 5
+
+// extractPDF returns the text of each page of a PDF, separated by blank lines
+func extractPDF(content []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte("%PDF")) {
+		return "", errors.New("not a PDF file")
+	}
+	if bytes.Contains(content, []byte("/Encrypt")) {
+		return "", errors.New("encrypted PDFs are not supported")
+	}
+
+	doc := parsePDF(content)
+	pages := doc.pages()
+	if len(pages) == 0 {
+		return "", errors.New("no pages found")
+	}
+
+	var texts []string
+	for _, page := range pages {
+		var contents []byte
+		for _, data := range doc.streams(page.dict["Contents"]) {
+			contents = append(contents, data...)
+			contents = append(contents, '\n')
+		}
+		if text := strings.TrimSpace(doc.contentText(contents, page.resources, 0)); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n") + "\n", nil
+}
+
+// parsePDF collects the indirect objects of a PDF file. Later definitions of an
+// object replace earlier ones, as they do in incrementally updated files.
+func parsePDF(content []byte) *pdfDocument {
+	doc := &pdfDocument{objects: make(map[int]*pdfObject)}
+
+	matches := pdfObjectPattern.FindAllSubmatchIndex(content, -1)
+	for i, m := range matches {
+		num, err := strconv.Atoi(string(content[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		region := content[m[1]:end]
+
+		lexer := &pdfLexer{data: region}
+		value, err := lexer.parseValue()
+		if err != nil {
+			continue
+		}
+		obj := &pdfObject{value: value}
+
+		// Read the stream that follows a dictionary. A truncated value leaves
+		// the lexer at the end of the region.
+		pos := min(lexer.pos, len(region))
+		if loc := pdfStreamPattern.FindIndex(region[pos:]); loc != nil {
+			start := pos + loc[1]
+			stop := bytes.LastIndex(region, []byte("endstream"))
+			if length, ok := pdfOffset(pdfDictOf(value)["Length"], len(region)-start); ok {
+				stop = start + length
+			}
+			if stop >= start {
+				obj.stream = bytes.TrimRight(region[start:stop], "\r\n")
+			}
+		}
+		doc.objects[num] = obj
+	}
+
+	// Unpack objects stored in object streams
+	for _, num := range doc.sortedNumbers() {
+		obj := doc.objects[num]
+		dict := pdfDictOf(obj.value)
+		if dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data := doc.decodeStream(obj)
+		n, _ := dict["N"].(float64)
+		first, ok := pdfOffset(dict["First"], len(data))
+		if data == nil || !ok {
+			continue
+		}
+
+		header := &pdfLexer{data: data[:first]}
+		for j := 0; j < int(n); j++ {
+			objNum, err1 := header.next()
+			offset, err2 := header.next()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			numValue, ok1 := objNum.(float64)
+			offsetValue, ok2 := pdfOffset(offset, len(data)-first-1)
+			if !ok1 || !ok2 {
+				continue
+			}
+			if _, exists := doc.objects[int(numValue)]; exists {
+				continue
+			}
+			lexer := &pdfLexer{data: data[first+offsetValue:]}
+			if value, err := lexer.parseValue(); err == nil {
+				doc.objects[int(numValue)] = &pdfObject{value: value}
+			}
+		}
+	}
+	return doc
+}
+
+// pdfOffset returns value as an offset or length from 0 to limit, reporting
+// false for other values, such as negative ones
+func pdfOffset(value interface{}, limit int) (int, bool) {
+	f, ok := value.(float64)
+	if !ok || f < 0 || f > float64(limit) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// sortedNumbers returns the object numbers in ascending order
+func (d *pdfDocument) sortedNumbers() []int {
+	nums := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// resolve follows an indirect reference
+func (d *pdfDocument) resolve(value interface{}) interface{} {
+	for i := 0; i < 8; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		obj, ok := d.objects[int(ref)]
+		if !ok {
+			return nil
+		}
+		value = obj.value
+	}
+	return nil
+}
+
+// dict resolves a value to a dictionary, or an empty one
+func (d *pdfDocument) dict(value interface{}) pdfDict {
+	return pdfDictOf(d.resolve(value))
+}
+
+// pdfDictOf returns value as a dictionary, or an empty one
+func pdfDictOf(value interface{}) pdfDict {
+	if dict, ok := value.(pdfDict); ok {
+		return dict
+	}
+	return pdfDict{}
+}
+
+// decodeStream returns the decoded data of an object's stream, or nil if the
+// stream uses an unsupported filter
+func (d *pdfDocument) decodeStream(obj *pdfObject) []byte {
+	if obj == nil || obj.stream == nil {
+		return nil
+	}
+
+	var filters []interface{}
+	switch filter := d.resolve(pdfDictOf(obj.value)["Filter"]).(type) {
+	case pdfName:
+		filters = []interface{}{filter}
+	case pdfArray:
+		filters = filter
+	}
+
+	data := obj.stream
+	for _, filter := range filters {
+		if filter != pdfName("FlateDecode") {
+			return nil
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		// Keep what was decoded from slightly damaged streams
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxExtractedSize))
+		if err != nil && len(decoded) == 0 {
+			return nil
+		}
+		data = decoded
+	}
+	return data
+}
+
+// streams returns the decoded streams referenced by a value (a reference or an array of them)
+func (d *pdfDocument) streams(value interface{}) [][]byte {
+	var refs []interface{}
+	if array, ok := d.resolve(value).(pdfArray); ok {
+		refs = array
+	} else {
+		refs = []interface{}{value}
+	}
+
+	var result [][]byte
+	for _, ref := range refs {
+		if r, ok := ref.(pdfRef); ok {
+			if data := d.decodeStream(d.objects[int(r)]); data != nil {
+				result = append(result, data)
+			}
+		}
+	}
+	return result
+}
+
+// pdfPage is a page dictionary with its (possibly inherited) resources
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order
+func (d *pdfDocument) pages() []pdfPage {
+	var pages []pdfPage
+	visited := make(map[int]bool)
+
+	var walk func(value interface{}, resources pdfDict)
+	walk = func(value interface{}, resources pdfDict) {
+		if ref, ok := value.(pdfRef); ok {
+			if visited[int(ref)] {
+				return
+			}
+			visited[int(ref)] = true
+		}
+		node := d.dict(value)
+		if res, ok := node["Resources"]; ok {
+			resources = d.dict(res)
+		}
+		switch node["Type"] {
+		case pdfName("Pages"):
+			if kids, ok := d.resolve(node["Kids"]).(pdfArray); ok {
+				for _, kid := range kids {
+					walk(kid, resources)
+				}
+			}
+		case pdfName("Page"):
+			pages = append(pages, pdfPage{dict: node, resources: resources})
+		}
+	}
+
+	for _, num := range d.sortedNumbers() {
+		if catalog := pdfDictOf(d.objects[num].value); catalog["Type"] == pdfName("Catalog") {
+			walk(catalog["Pages"], nil)
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// Fall back to every page object in object order
+	for _, num := range d.sortedNumbers() {
+		if node := pdfDictOf(d.objects[num].value); node["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: node, resources: d.dict(node["Resources"])})
+		}
+	}
+	return pages
+}
+
+// fonts loads the fonts named in a resource dictionary
+func (d *pdfDocument) fonts(resources pdfDict) map[string]*pdfFont {
+	fonts := make(map[string]*pdfFont)
+	for name, ref := range d.dict(resources["Font"]) {
+		fontDict := d.dict(ref)
+		font := &pdfFont{codeBytes: 1}
+		if fontDict["Subtype"] == pdfName("Type0") {
+			font.codeBytes = 2
+		}
+		if toUnicode, ok := fontDict["ToUnicode"].(pdfRef); ok {
+			if data := d.decodeStream(d.objects[int(toUnicode)]); data != nil {
+				font.toUnicode, font.codeBytes = parseCMap(data, font.codeBytes)
+			}
+		}
+		fonts[name] = font
+	}
+	return fonts
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseCMap(data []byte, codeBytes int) (map[int]string, int) {
+	mapping := make(map[int]string)
+	lexer := &pdfLexer{data: data}
+	var operands []interface{}
+	for {
+		value, err := lexer.parseValue()
+		if err != nil {
+			break
+		}
+		keyword, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+
+		switch keyword {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if low, ok := operands[0].(pdfString); ok && len(low) > 0 {
+					codeBytes = len(low)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					mapping[bytesToCode(src)] = utf16BEString(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				low, ok1 := operands[i].(pdfString)
+				high, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				lo, hi := bytesToCode(low), bytesToCode(high)
+				if hi-lo > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := utf16.Decode(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for code := lo; code <= hi; code++ {
+						runes := append([]rune(nil), base...)
+						runes[len(runes)-1] += rune(code - lo)
+						mapping[code] = string(runes)
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && lo+j <= hi {
+							mapping[lo+j] = utf16BEString(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return mapping, codeBytes
+}
+
+// bytesToCode converts a big-endian byte string to a character code
+func bytesToCode(b []byte) int {
+	code := 0
+	for _, c := range b {
+		code = code<<8 | int(c)
+	}
+	return code
+}
+
+// utf16BE splits big-endian bytes into UTF-16 code units
+func utf16BE(b []byte) []uint16 {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return units
+}
+
+// utf16BEString decodes big-endian UTF-16 bytes
+func utf16BEString(b []byte) string {
+	return string(utf16.Decode(utf16BE(b)))
+}
+
+// decode converts a string shown with the font into text
+func (f *pdfFont) decode(s []byte) string {
+	var sb strings.Builder
+	if f != nil && f.toUnicode != nil {
+		step := f.codeBytes
+		if step < 1 {
+			step = 1
+		}
+		for i := 0; i+step <= len(s); i += step {
+			code := bytesToCode(s[i : i+step])
+			if text, ok := f.toUnicode[code]; ok {
+				sb.WriteString(text)
+			} else if step == 1 {
+				writeLatin1(&sb, s[i])
+			}
+		}
+		return sb.String()
+	}
+	if f != nil && f.codeBytes == 2 {
+		// Two-byte glyph codes cannot be read without a ToUnicode map
+		return ""
+	}
+	for _, c := range s {
+		writeLatin1(&sb, c)
+	}
+	return sb.String()
+}
+
+// writeLatin1 writes a single-byte character, dropping control characters
+func writeLatin1(sb *strings.Builder, c byte) {
+	if c >= 0x20 && (c < 0x7F || c >= 0xA0) {
+		sb.WriteRune(rune(c))
+	}
+}
+
+// pdfTextWriter collects extracted text, collapsing repeated line breaks and spaces
+type pdfTextWriter struct {
+	sb strings.Builder
+}
+
+func (w *pdfTextWriter) write(s string) {
+	w.sb.WriteString(s)
+}
+
+func (w *pdfTextWriter) lastByte() byte {
+	s := w.sb.String()
+	if s == "" {
+		return '\n'
+	}
+	return s[len(s)-1]
+}
+
+func (w *pdfTextWriter) newline() {
+	if w.lastByte() != '\n' {
+		w.sb.WriteByte('\n')
+	}
+}
+
+func (w *pdfTextWriter) space() {
+	if b := w.lastByte(); b != ' ' && b != '\n' {
+		w.sb.WriteByte(' ')
+	}
+}
+
+// contentText interprets the text operators of a content stream
+func (d *pdfDocument) contentText(data []byte, resources pdfDict, depth int) string {
+	fonts := d.fonts(resources)
+	var font *pdfFont
+	var out pdfTextWriter
+	var operands []interface{}
+	lastY, haveY := 0.0, false
+
+	lexer := &pdfLexer{data: data}
+	for {
+		value, err := lexer.parseValue()
+		if err != nil {
+			break
+		}
+		keyword, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+
+		switch keyword {
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = fonts[string(name)]
+				}
+			}
+		case "Tj", "'", "\"":
+			if keyword != "Tj" {
+				out.newline()
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					out.write(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				if array, ok := operands[len(operands)-1].(pdfArray); ok {
+					for _, item := range array {
+						switch v := item.(type) {
+						case pdfString:
+							out.write(font.decode(v))
+						case float64:
+							// Large negative adjustments separate words
+							if v < -250 {
+								out.space()
+							}
+						}
+					}
+				}
+			}
+		case "T*":
+			out.newline()
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+					out.newline()
+				} else {
+					out.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				if y, ok := operands[5].(float64); ok {
+					if haveY && y != lastY {
+						out.newline()
+					} else {
+						out.space()
+					}
+					lastY, haveY = y, true
+				}
+			}
+		case "Do":
+			if len(operands) > 0 && depth < maxFormDepth {
+				if name, ok := operands[len(operands)-1].(pdfName); ok {
+					out.write(d.formText(resources, string(name), depth))
+				}
+			}
+		case "BI":
+			lexer.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return out.sb.String()
+}
+
+// formText extracts the text of a form XObject drawn with the Do operator
+func (d *pdfDocument) formText(resources pdfDict, name string, depth int) string {
+	ref, ok := d.dict(resources["XObject"])[name].(pdfRef)
+	if !ok {
+		return ""
+	}
+	obj := d.objects[int(ref)]
+	if obj == nil || pdfDictOf(obj.value)["Subtype"] != pdfName("Form") {
+		return ""
+	}
+	data := d.decodeStream(obj)
+	if data == nil {
+		return ""
+	}
+	formResources := resources
+	if res, ok := pdfDictOf(obj.value)["Resources"]; ok {
+		formResources = d.dict(res)
+	}
+	return "\n" + d.contentText(data, formResources, depth+1) + "\n"
+}
+
+// pdfLexer tokenizes PDF object and content stream syntax
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// skipSpace skips whitespace and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next returns the next token
+func (l *pdfLexer) next() (interface{}, error) {
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, io.EOF
+		}
+
+		c := l.data[l.pos]
+		switch c {
+		case '/':
+			return l.readName(), nil
+		case '(':
+			return l.readLiteral(), nil
+		case '<':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+				l.pos += 2
+				return pdfDelim("<<"), nil
+			}
+			return l.readHex(), nil
+		case '>':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+				l.pos += 2
+				return pdfDelim(">>"), nil
+			}
+			l.pos++
+			continue
+		case '[', ']', '{', '}':
+			l.pos++
+			return pdfDelim(string(c)), nil
+		case ')':
+			l.pos++
+			continue
+		}
+
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		word := string(l.data[start:l.pos])
+		if strings.IndexByte("+-.0123456789", word[0]) >= 0 {
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				return n, nil
+			}
+		}
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return pdfKeyword(word), nil
+	}
+}
+
+// parseValue reads a complete value, assembling arrays, dictionaries, and references.
+// Closing delimiters are returned as pdfDelim values.
+func (l *pdfLexer) parseValue() (interface{}, error) {
+	token, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case pdfDelim:
+		switch t {
+		case "[":
+			array := pdfArray{}
+			for {
+				value, err := l.parseValue()
+				if err != nil || value == pdfDelim("]") {
+					return array, nil
+				}
+				array = append(array, value)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.parseValue()
+				if err != nil || key == pdfDelim(">>") {
+					return dict, nil
+				}
+				value, err := l.parseValue()
+				if err != nil {
+					return dict, nil
+				}
+				if name, ok := key.(pdfName); ok {
+					dict[string(name)] = value
+				}
+			}
+		}
+	case float64:
+		// An integer followed by "<generation> R" is an indirect reference
+		saved := l.pos
+		if gen, err := l.next(); err == nil {
+			if _, ok := gen.(float64); ok {
+				if r, err := l.next(); err == nil && r == pdfKeyword("R") {
+					return pdfRef(int(t)), nil
+				}
+			}
+		}
+		l.pos = saved
+	}
+	return token, nil
+}
+
+// readName reads a name, decoding #xx escapes
+func (l *pdfLexer) readName() pdfName {
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				sb.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		sb.WriteByte(c)
+		l.pos++
+	}
+	return pdfName(sb.String())
+}
+
+// readLiteral reads a parenthesized string, handling nesting and escapes
+func (l *pdfLexer) readLiteral() pdfString {
+	l.pos++
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A backslash at the end of a line continues the string
+				if e == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; k++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// readHex reads a hexadecimal string
+func (l *pdfLexer) readHex() pdfString {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; strings.IndexByte("0123456789abcdefABCDEF", c) >= 0 {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	if l.pos < len(l.data) {
+		l.pos++ // The closing '>'
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// skipInlineImage skips the binary data of an inline image (BI ... ID data EI)
+func (l *pdfLexer) skipInlineImage() {
+	id := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if id < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	start := l.pos + id + 2
+	for i := start; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && i > 0 && isPDFWhitespace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFWhitespace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,139 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+)
+
+func TestExtractPDF(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Design \\(draft\\)) Tj 0 -14 Td [(Spl) -20 (it) -400 (words)] TJ ET\n" +
+		"BT 72 680 Td <48656C6C6F> Tj T* (next) ' ET"
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R 3 0 R] /Count 2 /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [7 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		streamObject(content, false),
+		streamObject("BT /F1 12 Tf 72 720 Td (First page) Tj ET", true),
+	)
+
+	text, err := extractPDF(pdf)
+	if err != nil {
+		t.Fatalf("extractPDF failed: %v", err)
+	}
+	expected := "First page\n\nDesign (draft)\nSplit words\nHello\nnext\n"
+	if text != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, text)
+	}
+}
+
+func TestExtractPDF_ToUnicode(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"2 beginbfchar <0001> <65E5> <0002> <672C> endbfchar\n" +
+		"1 beginbfrange <0010> <0012> <0041> endbfrange\n" +
+		"endcmap\nend\nend\n"
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F0 5 0 R >> >> >>",
+		streamObject("BT /F0 10 Tf 1 0 0 1 50 700 Tm <00010002> Tj 1 0 0 1 50 680 Tm <001000110012> Tj ET", true),
+		"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /ToUnicode 6 0 R >>",
+		streamObject(cmap, true),
+	)
+
+	text, err := extractPDF(pdf)
+	if err != nil {
+		t.Fatalf("extractPDF failed: %v", err)
+	}
+	if expected := "日本\nABC\n"; text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestExtractPDF_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"not a PDF", []byte("hello")},
+		{"encrypted", buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Filter /Standard /V 2 /Encrypt true >>")},
+		{"no pages", buildPDF("<< /Type /Catalog >>")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := extractPDF(test.content); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestParsePDF_Malformed(t *testing.T) {
+	objectStream := func(first string, header string) string {
+		data := header + " << /Type /Font >>"
+		return fmt.Sprintf("<< /Type /ObjStm /N 1 /First %s /Length %d >>\nstream\n%s\nendstream", first, len(data), data)
+	}
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"negative First", buildPDF(objectStream("-5", "9 0"))},
+		{"First beyond the stream", buildPDF(objectStream("1e300", "9 0"))},
+		{"negative offset", buildPDF(objectStream("4", "9 -20"))},
+		{"overflowing offset", buildPDF(objectStream("4", "9 1e300"))},
+		{"negative Length", buildPDF("<< /Length -10 >>\nstream\nBT ET\nendstream")},
+		{"truncated object", []byte("%PDF-1.7\n0 0 obj <")},
+		{"truncated hex string", []byte("%PDF-1.7\n1 0 obj <4142")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsePDF(test.content)
+			extractPDF(test.content)
+		})
+	}
+}
+
+func FuzzParsePDF(f *testing.F) {
+	f.Add(buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		streamObject("BT /F1 12 Tf 72 720 Td (Hello) Tj ET", true),
+	))
+	f.Add(buildPDF("<< /Type /ObjStm /N 1 /First 4 /Length 22 >>\nstream\n5 0 << /Type /Font >>\nendstream"))
+	f.Add([]byte("%PDF-1.7\n0 0 obj <"))
+	f.Fuzz(func(t *testing.T, content []byte) {
+		extractPDF(content)
+	})
+}
+
+// buildPDF assembles a minimal PDF from object bodies numbered from 1
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	for i, object := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+// streamObject returns the body of a stream object, optionally Flate-compressed
+func streamObject(data string, compress bool) string {
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		writer := zlib.NewWriter(&buf)
+		writer.Write([]byte(data))
+		writer.Close()
+		data = buf.String()
+		filter = " /Filter /FlateDecode"
+	}
+	return fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), filter, data)
+}
@@ -1,12 +1,24 @@
 package filter
 
 import (
+	"fmt"
+	pathpkg "path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"codectx/internal/git"
+	"codectx/internal/platform"
 )
 
+// RegexRule is a single --exclude-regex rule
+type RegexRule struct {
+	Pattern    *regexp.Regexp
+	IsNegation bool // Prefixed with "!": re-includes paths excluded by earlier rules
+}
+
 // Filter defines criteria for including or excluding files
 type Filter struct {
 	Extensions      []string
@@ -14,7 +26,18 @@ type Filter struct {
 	IncludeDotfiles bool
 	GitIgnoreParser *git.GitIgnoreParser
 	GitTrackedOnly  bool
-	GitTrackedFiles []string
+	GitTrackedFiles []string // Tracked paths relative to GitRepoRoot, using forward slashes
+	GitRepoRoot     string   // Repository root the tracked paths are relative to
+	MinSize         int64     // Minimum file size in bytes (0 for no minimum)
+	MaxSize         int64     // Maximum file size in bytes (0 for no maximum)
+	ModifiedSince   time.Time // Only include files modified at or after this time (zero for no limit)
+	RootDir         string    // Scan root used to compute relative paths
+	IncludeRegexes  []*regexp.Regexp
+	ExcludeRegexes  []RegexRule
+	Stat            platform.StatFunc // Source of file sizes and times (nil for os.Stat)
+
+	gitTrackedSet map[string]bool
+	gitPrefix     *string // Path of RootDir relative to GitRepoRoot, computed on first use
 }
 
 // NewFilter creates a new filter with the given criteria
@@ -57,6 +80,213 @@ func (f *Filter) SetGitIgnoreParser(parser *git.GitIgnoreParser) {
 func (f *Filter) SetGitTrackedFiles(files []string) {
 	f.GitTrackedFiles = files
 	f.GitTrackedOnly = true
+
+	f.gitTrackedSet = make(map[string]bool, len(files))
+	for _, file := range files {
+		f.gitTrackedSet[filepath.ToSlash(file)] = true
+	}
+}
+
+// SetGitRepoRoot sets the repository root that tracked file paths are relative to
+func (f *Filter) SetGitRepoRoot(repoRoot string) {
+	f.GitRepoRoot = repoRoot
+	f.gitPrefix = nil
+}
+
+// gitRelativePath returns the path relative to the repository root with forward slashes.
+// Without a repository root, paths are taken relative to RootDir (or as given).
+func (f *Filter) gitRelativePath(path string) string {
+	relPath := strings.TrimPrefix(f.relativePath(path), "/")
+	if f.RootDir == "" || f.GitRepoRoot == "" {
+		return relPath
+	}
+
+	if f.gitPrefix == nil {
+		// Resolve symlinks so that e.g. /tmp and /private/tmp compare equal
+		prefix := ""
+		repoRoot, err1 := filepath.EvalSymlinks(f.GitRepoRoot)
+		rootDir, err2 := filepath.EvalSymlinks(f.RootDir)
+		if err1 == nil && err2 == nil {
+			if rel, err := platform.RelSlash(repoRoot, rootDir); err == nil && rel != "." {
+				prefix = rel
+			}
+		}
+		f.gitPrefix = &prefix
+	}
+
+	if *f.gitPrefix == "" {
+		return relPath
+	}
+	return *f.gitPrefix + "/" + relPath
+}
+
+// SetSizeRange restricts included files to the given size range in bytes (0 disables a bound)
+func (f *Filter) SetSizeRange(minSize, maxSize int64) {
+	f.MinSize = minSize
+	f.MaxSize = maxSize
+}
+
+// SetModifiedSince restricts included files to those modified at or after the given time
+func (f *Filter) SetModifiedSince(since time.Time) {
+	f.ModifiedSince = since
+}
+
+// SetRootDir sets the scan root that relative paths are computed against
+func (f *Filter) SetRootDir(rootDir string) {
+	f.RootDir = rootDir
+}
+
+// SetRegexPatterns compiles the --include-regex and --exclude-regex patterns.
+// Include patterns form an allow-list: when present, a file must match at least one.
+// Exclude patterns are evaluated in order and the last matching rule wins; a
+// pattern prefixed with "!" re-includes paths excluded by an earlier rule.
+func (f *Filter) SetRegexPatterns(include, exclude []string) error {
+	f.IncludeRegexes = nil
+	for _, pattern := range include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include regex %q: %w", pattern, err)
+		}
+		f.IncludeRegexes = append(f.IncludeRegexes, re)
+	}
+
+	f.ExcludeRegexes = nil
+	for _, pattern := range exclude {
+		rule := RegexRule{}
+		if strings.HasPrefix(pattern, "!") {
+			rule.IsNegation = true
+			pattern = pattern[1:]
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude regex %q: %w", pattern, err)
+		}
+		rule.Pattern = re
+		f.ExcludeRegexes = append(f.ExcludeRegexes, rule)
+	}
+
+	return nil
+}
+
+// relativePath returns the path relative to the scan root with forward slashes
+// and a leading "/" (e.g. "/src/main.go"), the form all patterns are matched
+// against. A relative root such as "../project" is resolved against the current
+// directory when the path is absolute, and vice versa.
+func (f *Filter) relativePath(path string) string {
+	if f.RootDir != "" {
+		root := f.RootDir
+		if filepath.IsAbs(root) != filepath.IsAbs(path) {
+			absRoot, err1 := filepath.Abs(root)
+			absPath, err2 := filepath.Abs(path)
+			if err1 == nil && err2 == nil {
+				root, path = absRoot, absPath
+			}
+		}
+		if relPath, err := platform.RelSlash(root, path); err == nil {
+			return "/" + relPath
+		}
+	}
+	return "/" + strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
+
+// matchesExcludePattern reports whether an --exclude glob matches a path
+// relative to the scan root (slash-separated, without a leading "/"), so that
+// directories above the scan root never cause a match. A pattern without a
+// slash matches the name of the file or of any directory it is in; a leading
+// "/" or a slash inside anchors it to the scan root, where it matches the path
+// or one of its parent directories. A trailing "/" only matches directories.
+func matchesExcludePattern(pattern, relPath string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	if dirOnly {
+		parts = parts[:len(parts)-1]
+	}
+	for i, part := range parts {
+		candidate := part
+		if anchored {
+			candidate = strings.Join(parts[:i+1], "/")
+		}
+		if matched, err := pathpkg.Match(pattern, candidate); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRegexes checks the path against the include and exclude regex rules
+func (f *Filter) matchesRegexes(path string) bool {
+	if len(f.IncludeRegexes) == 0 && len(f.ExcludeRegexes) == 0 {
+		return true
+	}
+
+	relPath := f.relativePath(path)
+
+	if len(f.IncludeRegexes) > 0 {
+		included := false
+		for _, re := range f.IncludeRegexes {
+			if re.MatchString(relPath) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	excluded := false
+	for _, rule := range f.ExcludeRegexes {
+		if rule.Pattern.MatchString(relPath) {
+			excluded = !rule.IsNegation
+		}
+	}
+	return !excluded
+}
+
+// ParseModifiedSince parses a date (2024-01-01), timestamp (RFC 3339), or relative
+// age (30m, 12h, 7d, 2w) into the earliest modification time to include
+func ParseModifiedSince(spec string, now time.Time) (time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", spec, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, spec); err == nil {
+		return t, nil
+	}
+
+	// Relative age: a number followed by a unit
+	unitPos := len(spec) - 1
+	value, err := strconv.Atoi(spec[:unitPos])
+	if err != nil || value < 0 {
+		return time.Time{}, fmt.Errorf("invalid modification time: %s (expected YYYY-MM-DD or an age like 7d)", spec)
+	}
+
+	var unit time.Duration
+	switch spec[unitPos] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return time.Time{}, fmt.Errorf("unknown time unit in %s (expected m, h, d, or w)", spec)
+	}
+
+	return now.Add(-time.Duration(value) * unit), nil
 }
 
 // ShouldInclude determines if a file should be included based on the filter criteria
@@ -70,16 +300,8 @@ func (f *Filter) ShouldInclude(path string) bool {
 	}
 
 	// Check if we should only include Git tracked files
-	if f.GitTrackedOnly && len(f.GitTrackedFiles) > 0 {
-		isTracked := false
-		relPath := path
-		for _, trackedPath := range f.GitTrackedFiles {
-			if trackedPath == relPath || filepath.Join(filepath.Dir(path), trackedPath) == path {
-				isTracked = true
-				break
-			}
-		}
-		if !isTracked {
+	if f.GitTrackedOnly {
+		if !f.gitTrackedSet[f.gitRelativePath(path)] {
 			return false
 		}
 	}
@@ -89,16 +311,32 @@ func (f *Filter) ShouldInclude(path string) bool {
 		return false
 	}
 
-	// Check exclusion patterns
+	// Check exclusion patterns against the path relative to the scan root
+	relPath := strings.TrimPrefix(f.relativePath(path), "/")
 	for _, pattern := range f.ExcludePatterns {
-		matched, err := filepath.Match(pattern, base)
-		if err == nil && matched {
+		if matchesExcludePattern(pattern, relPath) {
 			return false
 		}
+	}
 
-		// Also check if the pattern matches the full path
-		matched, err = filepath.Match(pattern, path)
-		if err == nil && matched {
+	// Check regex patterns
+	if !f.matchesRegexes(path) {
+		return false
+	}
+
+	// Check size and modification time constraints
+	if f.MinSize > 0 || f.MaxSize > 0 || !f.ModifiedSince.IsZero() {
+		info, err := platform.Stat(f.Stat, path)
+		if err != nil {
+			return false
+		}
+		if f.MinSize > 0 && info.Size() < f.MinSize {
+			return false
+		}
+		if f.MaxSize > 0 && info.Size() > f.MaxSize {
+			return false
+		}
+		if !f.ModifiedSince.IsZero() && info.ModTime().Before(f.ModifiedSince) {
 			return false
 		}
 	}
@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"codectx/internal/benchtree"
 )
 
 func TestNewFilter(t *testing.T) {
@@ -265,6 +268,16 @@ func TestFilter_SetGitTrackedFiles(t *testing.T) {
 	}
 }
 
+func TestFilter_ShouldInclude_NoGitTrackedFiles(t *testing.T) {
+	filter := NewFilter("", "", true)
+	filter.SetGitTrackedFiles([]string{})
+
+	// An empty list of tracked files includes nothing
+	if filter.ShouldInclude("src/main.go") {
+		t.Error("Expected no files to be included with an empty tracked list")
+	}
+}
+
 func TestFilter_ShouldInclude_GitTrackedFiles(t *testing.T) {
 	filter := NewFilter("", "", true)
 	trackedFiles := []string{
@@ -395,4 +408,297 @@ func TestFilter_Integration(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFilter_ShouldInclude_SizeAndModTime(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_size_filter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	smallFile := filepath.Join(tempDir, "small.txt")
+	largeFile := filepath.Join(tempDir, "large.txt")
+	oldFile := filepath.Join(tempDir, "old.txt")
+	for path, size := range map[string]int{smallFile: 10, largeFile: 2048, oldFile: 100} {
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", path, err)
+		}
+	}
+	oldTime := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(oldFile, oldTime, oldTime); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		minSize       int64
+		maxSize       int64
+		modifiedSince time.Time
+		path          string
+		expected      bool
+	}{
+		{name: "Below minimum", minSize: 50, path: smallFile, expected: false},
+		{name: "Above minimum", minSize: 50, path: largeFile, expected: true},
+		{name: "Above maximum", maxSize: 1024, path: largeFile, expected: false},
+		{name: "Below maximum", maxSize: 1024, path: smallFile, expected: true},
+		{name: "Modified too long ago", modifiedSince: time.Now().Add(-7 * 24 * time.Hour), path: oldFile, expected: false},
+		{name: "Recently modified", modifiedSince: time.Now().Add(-7 * 24 * time.Hour), path: smallFile, expected: true},
+		{name: "Missing file", minSize: 1, path: filepath.Join(tempDir, "missing.txt"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilter("", "", false)
+			filter.SetSizeRange(tt.minSize, tt.maxSize)
+			filter.SetModifiedSince(tt.modifiedSince)
+
+			if result := filter.ShouldInclude(tt.path); result != tt.expected {
+				t.Errorf("Expected %v for %s, got %v", tt.expected, filepath.Base(tt.path), result)
+			}
+		})
+	}
+}
+
+func TestParseModifiedSince(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec        string
+		expected    time.Time
+		expectError bool
+	}{
+		{spec: "", expected: time.Time{}},
+		{spec: "7d", expected: now.Add(-7 * 24 * time.Hour)},
+		{spec: "12h", expected: now.Add(-12 * time.Hour)},
+		{spec: "2w", expected: now.Add(-14 * 24 * time.Hour)},
+		{spec: "30m", expected: now.Add(-30 * time.Minute)},
+		{spec: "2024-01-01", expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)},
+		{spec: "2024-01-01T10:00:00Z", expected: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{spec: "7y", expectError: true},
+		{spec: "yesterday", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			result, err := ParseModifiedSince(tt.spec, now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !result.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilter_ShouldInclude_Regex(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "No patterns",
+			path:     "src/main.go",
+			expected: true,
+		},
+		{
+			name:     "Excluded by regex",
+			exclude:  []string{"/generated/"},
+			path:     "src/generated/api.pb.go",
+			expected: false,
+		},
+		{
+			name:     "Re-included by negated regex",
+			exclude:  []string{"/generated/", `!\.proto$`},
+			path:     "src/generated/api.proto",
+			expected: true,
+		},
+		{
+			name:     "Later exclude wins over negation",
+			exclude:  []string{`!\.proto$`, "/generated/"},
+			path:     "src/generated/api.proto",
+			expected: false,
+		},
+		{
+			name:     "Top-level directory matches with leading slash",
+			exclude:  []string{"^/vendor/"},
+			path:     "vendor/lib/lib.go",
+			expected: false,
+		},
+		{
+			name:     "Include allow-list matches",
+			include:  []string{`^/cmd/`, `\.md$`},
+			path:     "cmd/root.go",
+			expected: true,
+		},
+		{
+			name:     "Include allow-list does not match",
+			include:  []string{`^/cmd/`},
+			path:     "internal/scanner/scanner.go",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilter("", "", false)
+			filter.SetRootDir(root)
+			if err := filter.SetRegexPatterns(tt.include, tt.exclude); err != nil {
+				t.Fatalf("SetRegexPatterns failed: %v", err)
+			}
+
+			path := filepath.Join(root, filepath.FromSlash(tt.path))
+			if result := filter.ShouldInclude(path); result != tt.expected {
+				t.Errorf("Expected %v for %s, got %v", tt.expected, tt.path, result)
+			}
+		})
+	}
+}
+
+func TestFilter_SetRegexPatterns_Invalid(t *testing.T) {
+	filter := NewFilter("", "", false)
+	if err := filter.SetRegexPatterns([]string{"("}, nil); err == nil {
+		t.Error("Expected error for invalid include regex")
+	}
+	if err := filter.SetRegexPatterns(nil, []string{"![a-"}); err == nil {
+		t.Error("Expected error for invalid exclude regex")
+	}
+}
+
+func TestFilter_ShouldInclude_GitTrackedFiles_Subdirectory(t *testing.T) {
+	repoRoot, err := os.MkdirTemp("", "codectx_git_root_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(repoRoot)
+
+	// Scan a subdirectory while git reports paths relative to the repository root
+	scanRoot := filepath.Join(repoRoot, "services", "api")
+	if err := os.MkdirAll(scanRoot, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	filter := NewFilter("", "", false)
+	filter.SetRootDir(scanRoot)
+	filter.SetGitRepoRoot(repoRoot)
+	filter.SetGitTrackedFiles([]string{
+		"services/api/main.go",
+		"services/api/handlers/user.go",
+		"main.go",
+	})
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "main.go", expected: true},
+		{path: "handlers/user.go", expected: true},
+		{path: "handlers/scratch.go", expected: false},
+		{path: "services/api/main.go", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			fullPath := filepath.Join(scanRoot, filepath.FromSlash(tt.path))
+			if result := filter.ShouldInclude(fullPath); result != tt.expected {
+				t.Errorf("Expected %v for %s, got %v", tt.expected, tt.path, result)
+			}
+		})
+	}
+}
+
+func TestFilter_ShouldInclude_RelativeExcludes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filter_relative_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// The scan root itself lives inside a directory named like an excluded one
+	root := filepath.Join(tempDir, "build", "project")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns string
+		relPath  string
+		expected bool
+	}{
+		{"parent of the root is ignored", "build", "main.go", true},
+		{"directory name", "build", "build/output.go", false},
+		{"nested directory name", "node_modules", "web/node_modules/lib/index.js", false},
+		{"file name", "*.tmp", "src/cache.tmp", false},
+		{"trailing slash matches directories", "build/", "build/output.go", false},
+		{"trailing slash skips files", "build/", "build", true},
+		{"anchored to the root", "/build", "build/output.go", false},
+		{"anchored pattern skips nested directories", "/build", "src/build/output.go", true},
+		{"path pattern", "src/gen", "src/gen/api.go", false},
+		{"path pattern with glob", "src/*/testdata", "src/pkg/testdata/a.txt", false},
+		{"path pattern with dot prefix", "./src/gen", "src/gen/api.go", false},
+		{"path pattern doesn't match elsewhere", "src/gen", "lib/src/gen/api.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilter("", tt.patterns, true)
+			filter.SetRootDir(root)
+			result := filter.ShouldInclude(filepath.Join(root, filepath.FromSlash(tt.relPath)))
+			if result != tt.expected {
+				t.Errorf("Expected %v for %s with patterns %s, got %v", tt.expected, tt.relPath, tt.patterns, result)
+			}
+		})
+	}
+
+	// A ../-style root matches the same way as an absolute one
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	relRoot, err := filepath.Rel(wd, root)
+	if err != nil {
+		t.Skipf("Root is not reachable relative to the working directory: %v", err)
+	}
+	filter := NewFilter("", "build", true)
+	filter.SetRootDir(relRoot + string(filepath.Separator))
+	if !filter.ShouldInclude(filepath.Join(root, "main.go")) {
+		t.Errorf("Expected main.go to be included with root %s", relRoot)
+	}
+	if filter.ShouldInclude(filepath.Join(relRoot, "build", "output.go")) {
+		t.Errorf("Expected build/output.go to be excluded with root %s", relRoot)
+	}
+}
+
+// BenchmarkFilter_ShouldInclude filters the files of a synthetic tree by
+// extension, exclude pattern, and regex
+func BenchmarkFilter_ShouldInclude(b *testing.B) {
+	dir := b.TempDir()
+	paths, err := benchtree.Generate(dir, benchtree.Spec{Files: 1000, Lines: 1})
+	if err != nil {
+		b.Fatalf("Failed to generate tree: %v", err)
+	}
+	filter := NewFilter("go,py,ts", "pkg003/**,*.bin", false)
+	filter.SetRootDir(dir)
+	if err := filter.SetRegexPatterns(nil, []string{`_test\.go$`}); err != nil {
+		b.Fatalf("SetRegexPatterns failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			filter.ShouldInclude(path)
+		}
+	}
+}
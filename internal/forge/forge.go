@@ -0,0 +1,269 @@
+// Package forge fetches pull requests from GitHub and merge requests from
+// GitLab, with their changed files and comments, as review context.
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported forges
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// perPage is the page size of list requests, the maximum both forges allow
+const perPage = 100
+
+// maxPages bounds the pages fetched for one list
+const maxPages = 30
+
+// maxResponseSize bounds the size of an API response
+const maxResponseSize = 32 * 1024 * 1024
+
+// httpClient is used for all forge requests
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Ref identifies a pull request (a merge request on GitLab)
+type Ref struct {
+	Forge   string // GitHub or GitLab
+	APIURL  string // Base URL of the REST API, such as https://api.github.com
+	Project string // "owner/repo", or a GitLab path such as "group/subgroup/project"
+	Number  int
+}
+
+// PullRequest is a pull request with its changed files and comments
+type PullRequest struct {
+	Number     int           `json:"number"`
+	Title      string        `json:"title"`
+	Body       string        `json:"body,omitempty"`
+	Author     string        `json:"author"`
+	State      string        `json:"state"`
+	BaseBranch string        `json:"base_branch"`
+	HeadBranch string        `json:"head_branch"`
+	HeadCommit string        `json:"head_commit"`
+	URL        string        `json:"url"`
+	Files      []ChangedFile `json:"files"`
+	Comments   []Comment     `json:"comments,omitempty"` // Oldest first
+}
+
+// ChangedFile is a file changed by a pull request
+type ChangedFile struct {
+	Path         string `json:"path"`
+	PreviousPath string `json:"previous_path,omitempty"` // Before a rename
+	Status       string `json:"status"`                  // added, modified, removed, or renamed
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+}
+
+// Comment is a conversation or review comment; review comments are on a line
+// of a changed file
+type Comment struct {
+	Author  string    `json:"author"`
+	Path    string    `json:"path,omitempty"`
+	Line    int       `json:"line,omitempty"`
+	Body    string    `json:"body"`
+	Created time.Time `json:"created_at"`
+}
+
+// Removed reports whether the pull request deletes the file
+func (f ChangedFile) Removed() bool {
+	return f.Status == "removed"
+}
+
+// ParseRef parses a pull request URL, such as
+// https://github.com/owner/repo/pull/12 or
+// https://gitlab.com/group/project/-/merge_requests/34, or a number of a pull
+// request on the repository of remoteURL (the origin remote)
+func ParseRef(target, remoteURL string) (Ref, error) {
+	if number, err := strconv.Atoi(strings.TrimPrefix(target, "#")); err == nil {
+		if remoteURL == "" {
+			return Ref{}, fmt.Errorf("no origin remote to find pull request %d on; give its URL instead", number)
+		}
+		host, project, err := parseRemote(remoteURL)
+		if err != nil {
+			return Ref{}, err
+		}
+		kind := forgeKind(host)
+		if kind == "" {
+			return Ref{}, fmt.Errorf("cannot tell whether %s is GitHub or GitLab; give the pull request URL instead", host)
+		}
+		return Ref{Forge: kind, APIURL: apiURL(kind, "https", host), Project: project, Number: number}, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return Ref{}, fmt.Errorf("invalid pull request %q (expected a URL or a number)", target)
+	}
+	path := strings.Trim(u.Path, "/")
+	if project, rest, ok := strings.Cut(path, "/-/merge_requests/"); ok {
+		number, err := strconv.Atoi(strings.SplitN(rest, "/", 2)[0])
+		if err != nil {
+			return Ref{}, fmt.Errorf("invalid merge request URL %q", target)
+		}
+		return Ref{Forge: GitLab, APIURL: apiURL(GitLab, u.Scheme, u.Host), Project: project, Number: number}, nil
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) >= 4 && parts[2] == "pull" {
+		number, err := strconv.Atoi(parts[3])
+		if err != nil {
+			return Ref{}, fmt.Errorf("invalid pull request URL %q", target)
+		}
+		return Ref{Forge: GitHub, APIURL: apiURL(GitHub, u.Scheme, u.Host), Project: parts[0] + "/" + parts[1], Number: number}, nil
+	}
+	return Ref{}, fmt.Errorf("%q is not a GitHub pull request or GitLab merge request URL", target)
+}
+
+// parseRemote returns the host and project path of a Git remote URL, such as
+// git@github.com:owner/repo.git or https://gitlab.com/group/project
+func parseRemote(remoteURL string) (string, string, error) {
+	var host, path string
+	if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if userHost, p, ok := strings.Cut(remoteURL, ":"); ok {
+		// scp-like syntax: [user@]host:path
+		host, path = userHost[strings.LastIndex(userHost, "@")+1:], p
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("cannot find the repository of remote %s", remoteURL)
+	}
+	return host, path, nil
+}
+
+// forgeKind guesses the forge of a host from its name
+func forgeKind(host string) string {
+	switch {
+	case strings.Contains(host, "github"):
+		return GitHub
+	case strings.Contains(host, "gitlab"):
+		return GitLab
+	}
+	return ""
+}
+
+// apiURL returns the base URL of the REST API of a forge host; GitHub
+// Enterprise and self-managed GitLab serve it under the host
+func apiURL(kind, scheme, host string) string {
+	if scheme == "" {
+		scheme = "https"
+	}
+	if kind == GitLab {
+		return scheme + "://" + host + "/api/v4"
+	}
+	if host == "github.com" {
+		return "https://api.github.com"
+	}
+	return scheme + "://" + host + "/api/v3"
+}
+
+// Token returns the API token of a forge from the environment: GITHUB_TOKEN
+// or GH_TOKEN, or GITLAB_TOKEN
+func Token(kind string) string {
+	if kind == GitLab {
+		return os.Getenv("GITLAB_TOKEN")
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// Fetch fetches a pull request with its changed files and comments. Without a
+// token only public repositories can be read.
+func Fetch(ref Ref, token string) (*PullRequest, error) {
+	c := &client{ref: ref, token: token}
+	var pr *PullRequest
+	var err error
+	if ref.Forge == GitLab {
+		pr, err = c.fetchGitLab()
+	} else {
+		pr, err = c.fetchGitHub()
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(pr.Comments, func(i, j int) bool {
+		return pr.Comments[i].Created.Before(pr.Comments[j].Created)
+	})
+	return pr, nil
+}
+
+// client sends the API requests for one pull request
+type client struct {
+	ref   Ref
+	token string
+}
+
+// get requests an API path and decodes the JSON response into v
+func (c *client) get(path string, v interface{}) error {
+	endpoint := c.ref.APIURL + path
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "codectx")
+	if c.ref.Forge == GitLab {
+		if c.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", c.token)
+		}
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", c.ref.Forge, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to query %s: %s returned %s", c.ref.Forge, endpoint, resp.Status)
+		if c.token == "" && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound) {
+			variable := "GITHUB_TOKEN"
+			if c.ref.Forge == GitLab {
+				variable = "GITLAB_TOKEN"
+			}
+			err = fmt.Errorf("%w (set %s to read private repositories)", err, variable)
+		}
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", c.ref.Forge, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", c.ref.Forge, err)
+	}
+	return nil
+}
+
+// getAll requests every page of an API list and appends the items to list
+func getAll[T any](c *client, path string, list *[]T) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	for page := 1; page <= maxPages; page++ {
+		var items []T
+		if err := c.get(fmt.Sprintf("%s%sper_page=%d&page=%d", path, separator, perPage, page), &items); err != nil {
+			return err
+		}
+		*list = append(*list, items...)
+		if len(items) < perPage {
+			break
+		}
+	}
+	return nil
+}
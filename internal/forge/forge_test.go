@@ -0,0 +1,121 @@
+package forge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		target   string
+		remote   string
+		expected Ref
+		wantErr  bool
+	}{
+		{"https://github.com/owner/repo/pull/12", "", Ref{GitHub, "https://api.github.com", "owner/repo", 12}, false},
+		{"https://github.com/owner/repo/pull/12/files", "", Ref{GitHub, "https://api.github.com", "owner/repo", 12}, false},
+		{"https://github.example.com/owner/repo/pull/3", "", Ref{GitHub, "https://github.example.com/api/v3", "owner/repo", 3}, false},
+		{"https://gitlab.com/group/sub/project/-/merge_requests/34", "", Ref{GitLab, "https://gitlab.com/api/v4", "group/sub/project", 34}, false},
+		{"7", "git@github.com:owner/repo.git", Ref{GitHub, "https://api.github.com", "owner/repo", 7}, false},
+		{"#7", "https://gitlab.example.com/group/project.git", Ref{GitLab, "https://gitlab.example.com/api/v4", "group/project", 7}, false},
+		{"7", "ssh://git@github.com/owner/repo", Ref{GitHub, "https://api.github.com", "owner/repo", 7}, false},
+		{"7", "", Ref{}, true},
+		{"7", "git@example.com:owner/repo.git", Ref{}, true},
+		{"https://github.com/owner/repo/issues/12", "", Ref{}, true},
+		{"not-a-pr", "", Ref{}, true},
+	}
+
+	for _, tt := range tests {
+		ref, err := ParseRef(tt.target, tt.remote)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRef(%q, %q): expected error %v, got %v", tt.target, tt.remote, tt.wantErr, err)
+			continue
+		}
+		if ref != tt.expected {
+			t.Errorf("ParseRef(%q, %q): expected %+v, got %+v", tt.target, tt.remote, tt.expected, ref)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	responses := map[string]string{
+		"/repos/owner/repo/pulls/12": `{"number": 12, "title": "Fix parser", "body": "Handles empty input", "user": {"login": "alice"},
+			"state": "closed", "merged": true, "html_url": "https://github.com/owner/repo/pull/12",
+			"base": {"ref": "main"}, "head": {"ref": "fix-parser", "sha": "abc123"}}`,
+		"/repos/owner/repo/pulls/12/files": `[{"filename": "parse.go", "status": "modified", "additions": 3, "deletions": 1},
+			{"filename": "lex.go", "previous_filename": "lexer.go", "status": "renamed", "additions": 0, "deletions": 0}]`,
+		"/repos/owner/repo/issues/12/comments": `[{"user": {"login": "bob"}, "body": "Looks good", "created_at": "2024-05-02T10:00:00Z"}]`,
+		"/repos/owner/repo/pulls/12/comments":  `[{"user": {"login": "bob"}, "path": "parse.go", "line": 0, "original_line": 42, "body": "Empty input?", "created_at": "2024-05-01T10:00:00Z"}]`,
+		"/projects/group/project/merge_requests/34": `{"iid": 34, "title": "Add lexer", "description": "", "author": {"username": "carol"},
+			"state": "opened", "source_branch": "lexer", "target_branch": "main", "sha": "def456", "web_url": "https://gitlab.com/group/project/-/merge_requests/34"}`,
+		"/projects/group/project/merge_requests/34/diffs": `[{"old_path": "lex.go", "new_path": "lex.go", "new_file": true, "diff": "@@ -0,0 +1,2 @@\n+package lex\n+\n"},
+			{"old_path": "old.go", "new_path": "old.go", "deleted_file": true, "diff": "@@ -1 +0,0 @@\n-package old\n"}]`,
+		"/projects/group/project/merge_requests/34/notes": `[{"body": "added 1 commit", "author": {"username": "carol"}, "system": true, "created_at": "2024-05-01T09:00:00Z"},
+			{"body": "Why a new file?", "author": {"username": "dave"}, "created_at": "2024-05-01T11:00:00Z", "position": {"new_path": "lex.go", "new_line": 1}}]`,
+	}
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization")+r.Header.Get("PRIVATE-TOKEN"))
+		response, ok := responses[strings.ReplaceAll(r.URL.EscapedPath(), "%2F", "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+
+	pr, err := Fetch(Ref{Forge: GitHub, APIURL: server.URL, Project: "owner/repo", Number: 12}, "secret")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if pr.Title != "Fix parser" || pr.Author != "alice" || pr.State != "merged" || pr.HeadCommit != "abc123" || pr.BaseBranch != "main" {
+		t.Errorf("Unexpected pull request %+v", pr)
+	}
+	expectedFiles := []ChangedFile{
+		{Path: "parse.go", Status: "modified", Additions: 3, Deletions: 1},
+		{Path: "lex.go", PreviousPath: "lexer.go", Status: "renamed"},
+	}
+	if !reflect.DeepEqual(pr.Files, expectedFiles) {
+		t.Errorf("Expected files %+v, got %+v", expectedFiles, pr.Files)
+	}
+	if len(pr.Comments) != 2 || pr.Comments[0].Path != "parse.go" || pr.Comments[0].Line != 42 || pr.Comments[1].Body != "Looks good" {
+		t.Errorf("Expected the review comment before the later conversation comment, got %+v", pr.Comments)
+	}
+	for _, token := range tokens {
+		if token != "Bearer secret" {
+			t.Errorf("Expected the token to be sent, got %q", token)
+		}
+	}
+
+	tokens = nil
+	mr, err := Fetch(Ref{Forge: GitLab, APIURL: server.URL, Project: "group/project", Number: 34}, "secret")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if mr.Title != "Add lexer" || mr.Author != "carol" || mr.HeadBranch != "lexer" || mr.HeadCommit != "def456" {
+		t.Errorf("Unexpected merge request %+v", mr)
+	}
+	expectedFiles = []ChangedFile{
+		{Path: "lex.go", Status: "added", Additions: 2},
+		{Path: "old.go", Status: "removed", Deletions: 1},
+	}
+	if !reflect.DeepEqual(mr.Files, expectedFiles) {
+		t.Errorf("Expected files %+v, got %+v", expectedFiles, mr.Files)
+	}
+	if len(mr.Comments) != 1 || mr.Comments[0].Author != "dave" || mr.Comments[0].Path != "lex.go" || mr.Comments[0].Line != 1 {
+		t.Errorf("Expected one diff note without system notes, got %+v", mr.Comments)
+	}
+	if len(tokens) == 0 || tokens[0] != "secret" {
+		t.Errorf("Expected the token in PRIVATE-TOKEN, got %v", tokens)
+	}
+
+	_, err = Fetch(Ref{Forge: GitHub, APIURL: server.URL, Project: "owner/private", Number: 1}, "")
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("Expected a not found error suggesting a token, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package forge
+
+import (
+	"fmt"
+	"time"
+)
+
+// githubUser is the author of a GitHub pull request or comment
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+// fetchGitHub fetches a pull request from the GitHub REST API
+func (c *client) fetchGitHub() (*PullRequest, error) {
+	base := fmt.Sprintf("/repos/%s/pulls/%d", c.ref.Project, c.ref.Number)
+
+	var pull struct {
+		Number  int        `json:"number"`
+		Title   string     `json:"title"`
+		Body    string     `json:"body"`
+		User    githubUser `json:"user"`
+		State   string     `json:"state"`
+		Merged  bool       `json:"merged"`
+		HTMLURL string     `json:"html_url"`
+		Base    struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.get(base, &pull); err != nil {
+		return nil, err
+	}
+	pr := &PullRequest{
+		Number:     pull.Number,
+		Title:      pull.Title,
+		Body:       pull.Body,
+		Author:     pull.User.Login,
+		State:      pull.State,
+		BaseBranch: pull.Base.Ref,
+		HeadBranch: pull.Head.Ref,
+		HeadCommit: pull.Head.SHA,
+		URL:        pull.HTMLURL,
+	}
+	if pull.Merged {
+		pr.State = "merged"
+	}
+
+	var files []struct {
+		Filename         string `json:"filename"`
+		PreviousFilename string `json:"previous_filename"`
+		Status           string `json:"status"`
+		Additions        int    `json:"additions"`
+		Deletions        int    `json:"deletions"`
+	}
+	if err := getAll(c, base+"/files", &files); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		pr.Files = append(pr.Files, ChangedFile{
+			Path:         file.Filename,
+			PreviousPath: file.PreviousFilename,
+			Status:       file.Status,
+			Additions:    file.Additions,
+			Deletions:    file.Deletions,
+		})
+	}
+
+	// Conversation comments are issue comments; review comments are on lines
+	var issueComments []struct {
+		User      githubUser `json:"user"`
+		Body      string     `json:"body"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+	if err := getAll(c, fmt.Sprintf("/repos/%s/issues/%d/comments", c.ref.Project, c.ref.Number), &issueComments); err != nil {
+		return nil, err
+	}
+	for _, comment := range issueComments {
+		pr.Comments = append(pr.Comments, Comment{Author: comment.User.Login, Body: comment.Body, Created: comment.CreatedAt})
+	}
+
+	var reviewComments []struct {
+		User         githubUser `json:"user"`
+		Path         string     `json:"path"`
+		Line         int        `json:"line"`
+		OriginalLine int        `json:"original_line"` // For comments on lines changed since
+		Body         string     `json:"body"`
+		CreatedAt    time.Time  `json:"created_at"`
+	}
+	if err := getAll(c, base+"/comments", &reviewComments); err != nil {
+		return nil, err
+	}
+	for _, comment := range reviewComments {
+		line := comment.Line
+		if line == 0 {
+			line = comment.OriginalLine
+		}
+		pr.Comments = append(pr.Comments, Comment{Author: comment.User.Login, Path: comment.Path, Line: line, Body: comment.Body, Created: comment.CreatedAt})
+	}
+	return pr, nil
+}
@@ -0,0 +1,109 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// gitlabUser is the author of a GitLab merge request or note
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+// fetchGitLab fetches a merge request from the GitLab REST API
+func (c *client) fetchGitLab() (*PullRequest, error) {
+	base := fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(c.ref.Project), c.ref.Number)
+
+	var mr struct {
+		IID          int        `json:"iid"`
+		Title        string     `json:"title"`
+		Description  string     `json:"description"`
+		Author       gitlabUser `json:"author"`
+		State        string     `json:"state"`
+		SourceBranch string     `json:"source_branch"`
+		TargetBranch string     `json:"target_branch"`
+		SHA          string     `json:"sha"`
+		WebURL       string     `json:"web_url"`
+	}
+	if err := c.get(base, &mr); err != nil {
+		return nil, err
+	}
+	pr := &PullRequest{
+		Number:     mr.IID,
+		Title:      mr.Title,
+		Body:       mr.Description,
+		Author:     mr.Author.Username,
+		State:      mr.State,
+		BaseBranch: mr.TargetBranch,
+		HeadBranch: mr.SourceBranch,
+		HeadCommit: mr.SHA,
+		URL:        mr.WebURL,
+	}
+
+	// Diffs don't carry line counts, so they are counted from the patch
+	var diffs []struct {
+		OldPath     string `json:"old_path"`
+		NewPath     string `json:"new_path"`
+		NewFile     bool   `json:"new_file"`
+		RenamedFile bool   `json:"renamed_file"`
+		DeletedFile bool   `json:"deleted_file"`
+		Diff        string `json:"diff"`
+	}
+	if err := getAll(c, base+"/diffs", &diffs); err != nil {
+		return nil, err
+	}
+	for _, diff := range diffs {
+		file := ChangedFile{Path: diff.NewPath, Status: "modified"}
+		switch {
+		case diff.NewFile:
+			file.Status = "added"
+		case diff.DeletedFile:
+			file.Status = "removed"
+		case diff.RenamedFile:
+			file.Status = "renamed"
+			file.PreviousPath = diff.OldPath
+		}
+		for _, line := range strings.Split(diff.Diff, "\n") {
+			switch {
+			case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+				file.Additions++
+			case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+				file.Deletions++
+			}
+		}
+		pr.Files = append(pr.Files, file)
+	}
+
+	// Notes include system notes such as "added 2 commits", which are left out
+	var notes []struct {
+		Body      string     `json:"body"`
+		Author    gitlabUser `json:"author"`
+		System    bool       `json:"system"`
+		CreatedAt time.Time  `json:"created_at"`
+		Position  *struct {
+			NewPath string `json:"new_path"`
+			NewLine int    `json:"new_line"`
+			OldPath string `json:"old_path"`
+			OldLine int    `json:"old_line"`
+		} `json:"position"`
+	}
+	if err := getAll(c, base+"/notes?sort=asc", &notes); err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		comment := Comment{Author: note.Author.Username, Body: note.Body, Created: note.CreatedAt}
+		if position := note.Position; position != nil {
+			comment.Path, comment.Line = position.NewPath, position.NewLine
+			if comment.Line == 0 {
+				comment.Path, comment.Line = position.OldPath, position.OldLine
+			}
+		}
+		pr.Comments = append(pr.Comments, comment)
+	}
+	return pr, nil
+}
@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/analysis"
+	"codectx/internal/limits"
+)
+
+// FormatAPISurface formats the exported API of Go packages after the file contents
+func (f *Formatter) FormatAPISurface(surface *analysis.APISurface) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatAPISurface(surface) }); err != nil {
+		return err
+	}
+
+	if f.SizeLimiter != nil {
+		f.SizeLimiter.Charge(limits.CategoryContent, int64(surface.Tokens*4))
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.apiSurface(surface)
+}
+
+// apiSurface formats the API surface in text format
+func (r *textRenderer) apiSurface(surface *analysis.APISurface) error {
+	fmt.Fprintln(r.Writer, "\nAPI Surface:")
+	fmt.Fprintln(r.Writer, "--------------------------------------------------------------------------------")
+	for i, pkg := range surface.Packages {
+		if i > 0 {
+			fmt.Fprintln(r.Writer)
+		}
+		fmt.Fprintf(r.Writer, "%s:\n", pkg.Dir)
+		fmt.Fprint(r.Writer, pkg.Source())
+	}
+	return nil
+}
+
+// apiSurface formats the API surface in Markdown format
+func (r *markdownRenderer) apiSurface(surface *analysis.APISurface) error {
+	fmt.Fprintln(r.Writer, "\n## API Surface")
+	for _, pkg := range surface.Packages {
+		fmt.Fprintf(r.Writer, "\n### %s\n", pkg.Dir)
+		fmt.Fprintln(r.Writer, "```go")
+		fmt.Fprint(r.Writer, pkg.Source())
+		fmt.Fprintln(r.Writer, "```")
+	}
+	return nil
+}
+
+// apiSurface formats the API surface in HTML format
+func (r *htmlRenderer) apiSurface(surface *analysis.APISurface) error {
+	for _, pkg := range surface.Packages {
+		fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(pkg.Dir))
+		for _, line := range strings.Split(strings.TrimSuffix(pkg.Source(), "\n"), "\n") {
+			fmt.Fprintf(r.Writer, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+		}
+		fmt.Fprint(r.Writer, htmlFileFooter)
+	}
+	return nil
+}
+
+// apiSurface stores the API surface for the final JSON document
+func (r *jsonRenderer) apiSurface(surface *analysis.APISurface) error {
+	if r.document == nil {
+		return fmt.Errorf("JSON output not started")
+	}
+	r.document.APISurface = surface
+	return nil
+}
@@ -0,0 +1,156 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/git"
+)
+
+// SetChangelog records the changes since the last tag so that they are listed
+// after the directory tree and in JSON metadata
+func (f *Formatter) SetChangelog(changelog *git.Changelog) {
+	f.changelog = changelog
+}
+
+// fittedChangelog returns the changelog to output. Its diff is file content,
+// so it is left out when it doesn't fit the total limit.
+func (f *Formatter) fittedChangelog() *git.Changelog {
+	changelog := f.changelog
+	if changelog == nil || changelog.Diff == "" || f.SizeLimiter == nil || f.SizeLimiter.Fits(int64(len(changelog.Diff))) {
+		return changelog
+	}
+	omitted := *changelog
+	omitted.Diff, omitted.DiffOmitted = "", true
+	return &omitted
+}
+
+// diffOmittedNotice explains a diff left out of the output
+const diffOmittedNotice = "[Diff omitted: it exceeds the character limit]"
+
+// changelogSection formats the changes since the last tag in text format
+func (r *textRenderer) changelogSection(changelog *git.Changelog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s:\n", changelogTitle(changelog))
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range changelogLines(changelog) {
+		b.WriteString(line + "\n")
+	}
+	if changelog.Diff != "" {
+		b.WriteString("\nDiff:\n")
+		b.WriteString(changelog.Diff)
+	} else if changelog.DiffOmitted {
+		b.WriteString("\n" + diffOmittedNotice + "\n")
+	}
+	return b.String()
+}
+
+// changelogSection formats the changes since the last tag in Markdown format
+func (r *markdownRenderer) changelogSection(changelog *git.Changelog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n%s\n", changelogTitle(changelog), changelogSummary(changelog))
+	for _, group := range changelog.Groups {
+		fmt.Fprintf(&b, "\n### %s\n\n", group.Title)
+		for _, commit := range group.Commits {
+			fmt.Fprintf(&b, "- `%s` %s %s: %s\n", commit.Hash, commit.Date.Format("2006-01-02"), commit.Author, commit.Subject)
+		}
+	}
+
+	b.WriteString("\n### Files Changed\n\n")
+	for _, file := range changelog.Files {
+		fmt.Fprintf(&b, "- `%s` %s\n", file.Path, fileChangeCounts(file))
+	}
+
+	if changelog.Diff != "" {
+		fence := codeFence(changelog.Diff)
+		fmt.Fprintf(&b, "\n### Diff\n\n%sdiff\n%s", fence, changelog.Diff)
+		if !strings.HasSuffix(changelog.Diff, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(fence + "\n")
+	} else if changelog.DiffOmitted {
+		b.WriteString("\n" + diffOmittedNotice + "\n")
+	}
+	return b.String()
+}
+
+// changelogSection formats the changes since the last tag in HTML format
+func (r *htmlRenderer) changelogSection(changelog *git.Changelog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, html.EscapeString(changelogTitle(changelog)))
+	lines := changelogLines(changelog)
+	if changelog.Diff != "" {
+		lines = append(lines, "", "Diff:")
+		lines = append(lines, strings.Split(strings.TrimSuffix(changelog.Diff, "\n"), "\n")...)
+	} else if changelog.DiffOmitted {
+		lines = append(lines, "", diffOmittedNotice)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// changelogTitle returns the section title, such as "Changes Since v1.2.0",
+// or "All Changes" when there was no tag
+func changelogTitle(changelog *git.Changelog) string {
+	if changelog.Since == "" {
+		return "All Changes"
+	}
+	return "Changes Since " + changelog.Since
+}
+
+// changelogSummary counts the commits and changed lines, such as
+// "12 commits, 5 files changed (+120 -30)"
+func changelogSummary(changelog *git.Changelog) string {
+	additions, deletions := 0, 0
+	for _, file := range changelog.Files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+	return fmt.Sprintf("%s, %s changed (+%d -%d)", plural(changelog.Commits, "commit"), plural(len(changelog.Files), "file"), additions, deletions)
+}
+
+// changelogLines lists the commits by type and the changed files as plain text lines
+func changelogLines(changelog *git.Changelog) []string {
+	lines := []string{changelogSummary(changelog)}
+	for _, group := range changelog.Groups {
+		lines = append(lines, "", group.Title+":")
+		for _, commit := range group.Commits {
+			lines = append(lines, "  "+commitLine(commit))
+		}
+	}
+
+	lines = append(lines, "", "Files changed:")
+	for _, file := range changelog.Files {
+		lines = append(lines, fmt.Sprintf("  %s %s", file.Path, fileChangeCounts(file)))
+	}
+	return lines
+}
+
+// fileChangeCounts returns the changed lines of a file, such as "(+12 -3)" or "(binary)"
+func fileChangeCounts(file git.FileChange) string {
+	if file.Binary {
+		return "(binary)"
+	}
+	return fmt.Sprintf("(+%d -%d)", file.Additions, file.Deletions)
+}
+
+// plural formats a count with a noun, such as "1 file" or "3 files"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// codeFence returns a Markdown fence longer than any run of backticks in text
+func codeFence(text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence
+}
@@ -0,0 +1,69 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"codectx/internal/highlight"
+	"codectx/internal/scanner"
+)
+
+// textSeparator underlines file headers in text output
+const textSeparator = "--------------------------------------------------------------------------------"
+
+// treeBranchChars are the characters of every tree style's connectors and indentation
+const treeBranchChars = "│├└─┃┣┗━|`- "
+
+// writeTextFileHeader writes the header that precedes a file in text output
+func (f *Formatter) writeTextFileHeader(relativePath string) {
+	label := f.fileLabel(relativePath)
+	if f.Color {
+		fmt.Fprintf(f.Writer, "\n%s\n", highlight.Paint(highlight.Header, label+":"))
+		fmt.Fprintln(f.Writer, highlight.Paint(highlight.Dim, textSeparator))
+		return
+	}
+	fmt.Fprintf(f.Writer, "\n%s:\n", label)
+	fmt.Fprintln(f.Writer, textSeparator)
+}
+
+// colorLine formats a line of text output with syntax highlighting. highlighter
+// is nil for languages without highlighting rules.
+func (f *Formatter) colorLine(highlighter *highlight.Highlighter, lineNum int, line string) string {
+	if highlighter != nil {
+		line = highlighter.Line(line)
+	}
+	if f.ShowLineNumbers {
+		return highlight.Paint(highlight.Dim, fmt.Sprintf("%2d |", lineNum)) + " " + line + "\n"
+	}
+	return line + "\n"
+}
+
+// colorTree colors directories, key file and permission markers, and entry details in a text tree
+func colorTree(tree string) string {
+	lines := strings.Split(tree, "\n")
+	for i, line := range lines {
+		details := ""
+		if m := treeDetailsPattern.FindStringSubmatch(line); m != nil {
+			line, details = m[1], m[2]+highlight.Paint(highlight.Dim, m[3])
+		}
+
+		marker := ""
+		if strings.HasSuffix(line, scanner.DeniedMarker) {
+			line = strings.TrimSuffix(line, scanner.DeniedMarker)
+			marker = highlight.Paint(highlight.Dim, scanner.DeniedMarker)
+		}
+		if strings.HasSuffix(line, scanner.KeyFileMarker) {
+			line = strings.TrimSuffix(line, scanner.KeyFileMarker)
+			marker = highlight.Paint(highlight.Marker, scanner.KeyFileMarker) + marker
+		}
+
+		if strings.HasSuffix(line, "/") {
+			// Color the directory name, leaving the branch characters plain
+			name := strings.TrimLeft(line, treeBranchChars)
+			start := len(line) - len(name)
+			line = line[:start] + highlight.Paint(highlight.Dir, name)
+		}
+		lines[i] = line + marker + details
+	}
+	return strings.Join(lines, "\n")
+}
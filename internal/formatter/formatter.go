@@ -2,15 +2,36 @@ package formatter
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"codectx/internal/analysis"
+	"codectx/internal/coverage"
+	"codectx/internal/extract"
+	"codectx/internal/forge"
 	"codectx/internal/git"
+	"codectx/internal/images"
 	"codectx/internal/limits"
+	"codectx/internal/minified"
+	"codectx/internal/oci"
+	"codectx/internal/platform"
+	"codectx/internal/policy"
+	"codectx/internal/remote"
+	"codectx/internal/scanner"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
 )
 
+// TransformFunc rewrites the content of the file at path before it is formatted
+type TransformFunc func(path string, content []byte) ([]byte, error)
+
+// SourceFunc opens the content of the file at path
+type SourceFunc func(path string) (io.ReadCloser, error)
+
 // OutputFormat represents the format of the output
 type OutputFormat string
 
@@ -25,35 +46,82 @@ const (
 	JSONFormat OutputFormat = "json"
 )
 
+// DefaultBufferSize is the output buffer of formatters created by NewFormatter
+const DefaultBufferSize = 64 * 1024
+
 // Formatter handles the formatting of the output
 type Formatter struct {
 	Format          OutputFormat
 	ShowLineNumbers bool
 	Writer          io.Writer
-	jsonOutput      *JSONOutput
+	output          Renderer // Renderer of Format, created on first use
 	SizeLimiter     *limits.SizeLimiter
 	GitInfo         *git.GitInfo
+	TreeDetails     bool              // The tree carries aligned "(...)" details after each entry
+	MaxLineLength   int               // Longest line read from a file (0 for utils.DefaultMaxLineLength)
+	Header          string            // Rendered --header-file text placed before the context
+	Footer          string            // Rendered --footer-file text placed after the context
+	Extract         extract.Options   // Kinds of files converted to plain text before formatting
+	KeepDataURIs    bool              // Leave base64 data URIs and blobs in the output
+	RedactPII       bool              // Replace email addresses, phone numbers, and national IDs with placeholders
+	Images          string            // images.ModePlaceholder or images.ModeEmbed to describe image files ("" formats them as text)
+	TabWidth        int               // Expand tabs to tab stops this many columns apart (0 keeps tabs)
+	DetectIndent    bool              // Detect each file's indentation and rewrite lines indented the other way
+	EOL             string            // utils.EOLLF or utils.EOLCRLF to normalize line endings in JSON content ("" keeps them)
+	Minified        string            // minified.ModePlaceholder to describe minified JS and CSS ("" formats them as text)
+	Color           bool              // Write ANSI syntax highlighting in text output
+	SizeUnits       utils.SizeUnits   // Units of the sizes in --summary output and placeholders
+	Transform       TransformFunc     // Rewrites file content before formatting (nil for none)
+	Stat            platform.StatFunc // Source of file sizes and times (nil for os.Stat)
+	Source          SourceFunc        // Opens file content in place of the file system (nil to read the files)
+	MaxMemory       int64             // Bytes of file contents Render holds in memory before spilling the rest to disk (0 for no limit)
+	BufferSize      int               // Buffer writes to Writer in chunks of this many bytes from FormatTree until Finalize (0 writes through)
+	buffer          *bufio.Writer     // Buffer Writer was replaced with, or nil
+	destination     io.Writer         // Writer before buffering, which Close closes
+	extraOutputs    []extraOutput     // Outputs added with AddOutput
+	tee             *tee              // Formatters of the extra outputs, created when formatting starts
+	shared          *sharedSource     // Content of the file being formatted, read once for all outputs
+	keyFiles        []string
+	keyFileSet      map[string]bool
+	linkGroups      [][]string
+	directories     []scanner.DirectoryRecord
+	stack           []analysis.StackComponent
+	functions       *analysis.FunctionReport
+	hotspots        []analysis.Hotspot
+	ownership       *analysis.Ownership
+	deadFiles       []analysis.DeadFile
+	docCoverage     *analysis.DocCoverage
+	userStrings     []analysis.StringLiteral
+	configInventory *analysis.ConfigInventory
+	iac             *analysis.IaCInventory
+	goEmbeds        []analysis.GoEmbed
+	policyDecision  *policy.Decision
+	history         []git.Commit
+	pullRequest     *forge.PullRequest
+	image           *oci.Image
+	changelog       *git.Changelog
+	coverage        *coverage.Report
+	denied          []string
+	sparse          *git.SparseCheckout
+	invocation      *Invocation
+	targetDirectory string
+	scanOptions     JSONScanOptions
+	stats           *stats.StatsCollector
+	embeddedAssets  int
+	reclaimedBytes  int64
+	piiRedacted     utils.PIICounts
 }
 
 // NewFormatter creates a new formatter with the given format
 func NewFormatter(format string, showLineNumbers bool, outputPath string, sizeLimiter *limits.SizeLimiter, gitInfo *git.GitInfo) (*Formatter, error) {
-	var outputFormat OutputFormat
-	switch strings.ToLower(format) {
-	case "text":
-		outputFormat = TextFormat
-	case "html":
-		outputFormat = HTMLFormat
-	case "markdown":
-		outputFormat = MarkdownFormat
-	case "json":
-		outputFormat = JSONFormat
-	default:
+	outputFormat := OutputFormat(strings.ToLower(format))
+	if _, ok := renderers[outputFormat]; !ok {
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 
 	var writer io.Writer = os.Stdout
 	if outputPath != "" {
-		file, err := os.Create(outputPath)
+		file, err := CreateOutput(outputPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create output file: %w", err)
 		}
@@ -66,116 +134,409 @@ func NewFormatter(format string, showLineNumbers bool, outputPath string, sizeLi
 		Writer:          writer,
 		SizeLimiter:     sizeLimiter,
 		GitInfo:         gitInfo,
+		BufferSize:      DefaultBufferSize,
 	}, nil
 }
 
-// FormatTree formats the directory tree
-func (f *Formatter) FormatTree(tree string) error {
-	switch f.Format {
-	case TextFormat:
-		_, err := fmt.Fprintln(f.Writer, tree)
-		return err
-	case MarkdownFormat:
-		return f.formatTreeMarkdown(tree)
-	case JSONFormat:
-		return f.formatTreeJSON(tree)
-	case HTMLFormat:
-		return f.formatTreeHTML(tree)
-	default:
-		return fmt.Errorf("format not implemented: %s", f.Format)
+// CreateOutput opens an output target for writing: a local file, or an s3://
+// or http(s):// URL that the output is uploaded to when it is closed
+func CreateOutput(target string) (io.WriteCloser, error) {
+	if remote.IsRemote(target) {
+		return remote.Create(target)
 	}
+	return os.Create(target)
 }
 
-// FormatFileContent formats the content of a file
-func (f *Formatter) FormatFileContent(path, relativePath string) error {
-	switch f.Format {
-	case TextFormat:
-		return f.formatFileContentText(path, relativePath)
-	case MarkdownFormat:
-		return f.formatFileContentMarkdown(path, relativePath)
-	case JSONFormat:
-		return f.formatFileContentJSON(path, relativePath)
-	case HTMLFormat:
-		return f.formatFileContentHTML(path, relativePath)
-	default:
-		return fmt.Errorf("format not implemented: %s", f.Format)
-	}
-}
-
-// formatFileContentText formats the content of a file in text format
-func (f *Formatter) formatFileContentText(path, relativePath string) error {
-	// Check if we have a size limiter
-	if f.SizeLimiter != nil {
-		// Check if the file exceeds the maximum file size
-		withinLimit, fileSize, err := f.SizeLimiter.CheckFileSize(path)
-		if err != nil {
-			return fmt.Errorf("failed to check file size: %w", err)
+// IsBuiltinFormat reports whether format names an output format with a
+// registered renderer, rather than one a plugin provides
+func IsBuiltinFormat(format string) bool {
+	_, ok := renderers[OutputFormat(strings.ToLower(format))]
+	return ok
+}
+
+// openSource opens a file for formatting. Notebooks and rich documents are
+// converted to plain text first when enabled in Extract, and the content is
+// then passed through Transform if set. When Source is set, the content it
+// returns is used as is.
+func (f *Formatter) openSource(path string) (io.ReadCloser, error) {
+	if f.shared != nil {
+		return f.shared.open(path)
+	}
+	if f.Source != nil {
+		return f.Source(path)
+	}
+	text, ok, err := extract.Text(path, f.Extract)
+	if ok && err != nil {
+		return nil, err
+	}
+	if f.Transform == nil {
+		if ok {
+			return io.NopCloser(strings.NewReader(text)), nil
 		}
+		return os.Open(path)
+	}
 
-		if !withinLimit {
-			// File is too large, print a message instead of the content
-			fmt.Fprintf(f.Writer, "\n%s:\n", relativePath)
-			fmt.Fprintln(f.Writer, "--------------------------------------------------------------------------------")
-			fmt.Fprintln(f.Writer, f.SizeLimiter.GetFileTooLargeMessage(path, fileSize))
-			return nil
+	content := []byte(text)
+	if !ok {
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, err
 		}
 	}
+	transformed, err := f.Transform(path, content)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(transformed)), nil
+}
 
-	// Print the file header
-	fmt.Fprintf(f.Writer, "\n%s:\n", relativePath)
-	fmt.Fprintln(f.Writer, "--------------------------------------------------------------------------------")
+// stripEmbedded replaces embedded base64 assets in a line with a placeholder,
+// unless KeepDataURIs is set, and records the space reclaimed
+func (f *Formatter) stripEmbedded(line string) string {
+	if f.KeepDataURIs {
+		return line
+	}
+	stripped, assets := utils.StripEmbeddedData(line)
+	if assets > 0 {
+		f.embeddedAssets += assets
+		f.reclaimedBytes += int64(len(line) - len(stripped))
+	}
+	return stripped
+}
 
-	// Open the file
-	file, err := os.Open(path)
+// detectIndent returns the indentation style of the file at path when
+// DetectIndent is set, and nil otherwise
+func (f *Formatter) detectIndent(path string) *utils.IndentStyle {
+	if !f.DetectIndent {
+		return nil
+	}
+	file, err := f.openSource(path)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return nil
 	}
 	defer file.Close()
+	style, err := utils.DetectIndent(file)
+	if err != nil {
+		return nil
+	}
+	return &style
+}
 
-	// Read the file line by line
-	scanner := bufio.NewScanner(file)
-	lineNum := 1
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Format the line
-		var formattedLine string
-		if f.ShowLineNumbers {
-			formattedLine = fmt.Sprintf("%2d | %s\n", lineNum, line)
-		} else {
-			formattedLine = line + "\n"
+// redactPII replaces personal data in a line with placeholders when RedactPII
+// is set, and counts what was replaced
+func (f *Formatter) redactPII(line string) string {
+	if !f.RedactPII {
+		return line
+	}
+	redacted, counts := utils.RedactPII(line)
+	f.piiRedacted.Add(counts)
+	return redacted
+}
+
+// cleanLine prepares a line of file content for the output: embedded assets are
+// stripped, personal data is redacted when RedactPII is set, the indentation is
+// normalized to indent (if not nil), and tabs are expanded when TabWidth is set
+func (f *Formatter) cleanLine(line string, indent *utils.IndentStyle) string {
+	line = f.redactPII(f.stripEmbedded(line))
+	if indent != nil {
+		line = utils.NormalizeIndent(line, *indent)
+	}
+	return utils.ExpandTabs(line, f.TabWidth)
+}
+
+// EmbeddedData returns the number of embedded assets stripped from the output so
+// far and the estimated tokens reclaimed by stripping them
+func (f *Formatter) EmbeddedData() (assets int, reclaimedTokens int) {
+	return f.embeddedAssets, int(f.reclaimedBytes / 4)
+}
+
+// PIIRedacted returns the personal data redacted from the output so far
+func (f *Formatter) PIIRedacted() utils.PIICounts {
+	return f.piiRedacted
+}
+
+// SetKeyFiles records the key files (relative paths without a leading slash)
+// so that they can be marked in the output
+func (f *Formatter) SetKeyFiles(paths []string) {
+	f.keyFiles = paths
+	f.keyFileSet = make(map[string]bool, len(paths))
+	for _, relPath := range paths {
+		f.keyFileSet[relPath] = true
+	}
+}
+
+// SetCoverage records the test coverage of the files, so that their headers
+// show it and JSON output includes it
+func (f *Formatter) SetCoverage(report *coverage.Report) {
+	f.coverage = report
+}
+
+// fileLabel returns the path shown in a file's header, followed by its test
+// coverage when known, as in "cmd/run.go (72.5% covered)"
+func (f *Formatter) fileLabel(relativePath string) string {
+	if file, ok := f.coverage.Lookup(relativePath); ok {
+		return fmt.Sprintf("%s (%s)", relativePath, file.Describe())
+	}
+	return relativePath
+}
+
+// SetStack records the frameworks and tools detected in the scanned directory
+// so that they can be listed in JSON metadata
+func (f *Formatter) SetStack(components []analysis.StackComponent) {
+	f.stack = components
+}
+
+// SetFunctions records the complexity of each function so that it can be
+// listed in JSON metadata
+func (f *Formatter) SetFunctions(functions *analysis.FunctionReport) {
+	f.functions = functions
+}
+
+// SetHotspots records the files ranked by churn and complexity so that they
+// can be listed in JSON metadata
+func (f *Formatter) SetHotspots(hotspots []analysis.Hotspot) {
+	f.hotspots = hotspots
+}
+
+// SetOwnership records the primary contributors and bus factors so that they
+// can be listed in JSON metadata
+func (f *Formatter) SetOwnership(ownership *analysis.Ownership) {
+	f.ownership = ownership
+}
+
+// SetPolicyDecision records how the included files were checked against the
+// policy file, so that the decision is logged in JSON metadata
+func (f *Formatter) SetPolicyDecision(decision *policy.Decision) {
+	f.policyDecision = decision
+}
+
+// SetDeadFiles records the unused packages and assets so that they can be
+// listed in JSON metadata
+func (f *Formatter) SetDeadFiles(deadFiles []analysis.DeadFile) {
+	f.deadFiles = deadFiles
+}
+
+// SetDocCoverage records the documentation coverage and undocumented symbols
+// so that they can be listed in JSON metadata
+func (f *Formatter) SetDocCoverage(coverage *analysis.DocCoverage) {
+	f.docCoverage = coverage
+}
+
+// SetStrings records the user-facing string literals so that they can be
+// listed in JSON metadata
+func (f *Formatter) SetStrings(literals []analysis.StringLiteral) {
+	f.userStrings = literals
+}
+
+// SetConfigInventory records the environment variables and configuration keys
+// so that they can be listed in JSON metadata
+func (f *Formatter) SetConfigInventory(inventory *analysis.ConfigInventory) {
+	f.configInventory = inventory
+}
+
+// SetIaC records the infrastructure-as-code modules and their resource counts
+// so that they can be listed in JSON metadata
+func (f *Formatter) SetIaC(inventory *analysis.IaCInventory) {
+	f.iac = inventory
+}
+
+// SetLinkGroups records groups of paths (relative, without a leading slash)
+// that are the same physical file and were included only once, so that they
+// can be listed in JSON metadata
+func (f *Formatter) SetLinkGroups(groups [][]string) {
+	f.linkGroups = groups
+}
+
+// SetDirectories records the directories of the tree, so that JSON output
+// lists them with their file counts and sizes after the tree
+func (f *Formatter) SetDirectories(records []scanner.DirectoryRecord) {
+	f.directories = records
+}
+
+// SetDenied records the paths (relative, without a leading slash; directories
+// end in "/") that couldn't be read, so that they can be listed in JSON metadata
+func (f *Formatter) SetDenied(paths []string) {
+	f.denied = paths
+}
+
+// SetSparseCheckout records the files a sparse checkout or partial clone left
+// out of the working tree, so that they can be noted in JSON metadata
+func (f *Formatter) SetSparseCheckout(sparse *git.SparseCheckout) {
+	f.sparse = sparse
+}
+
+// SetTargetDirectory records the absolute path of the scanned directory, so
+// that JSON metadata names it
+func (f *Formatter) SetTargetDirectory(dir string) {
+	f.targetDirectory = dir
+}
+
+// SetScanOptions records the options that chose, limited, and ordered the
+// files, so that JSON metadata describes them
+func (f *Formatter) SetScanOptions(options JSONScanOptions) {
+	f.scanOptions = options
+}
+
+// SetStats records the statistics collector of the scan, whose totals JSON
+// metadata reports in place of the formatter's own estimates
+func (f *Formatter) SetStats(collector *stats.StatsCollector) {
+	f.stats = collector
+}
+
+// FormatTree starts the output and formats the directory tree
+func (f *Formatter) FormatTree(tree string) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatTree(tree) }); err != nil {
+		return err
+	}
+
+	// The tree is always emitted, so it is tracked without counting against
+	// the limit
+	if f.SizeLimiter != nil {
+		f.SizeLimiter.Charge(limits.CategoryTree, int64(len(tree)))
+	}
+
+	r, err := f.renderer()
+	if err != nil {
+		return err
+	}
+	f.startBuffering()
+	if err := r.Begin(); err != nil {
+		return err
+	}
+	return r.Tree(tree)
+}
+
+// startBuffering replaces Writer with a buffer of BufferSize bytes, so that the
+// many small writes of each line reach the destination in large chunks
+func (f *Formatter) startBuffering() {
+	if f.BufferSize <= 0 || f.buffer != nil {
+		return
+	}
+	f.destination = f.Writer
+	f.buffer = bufio.NewWriterSize(f.Writer, f.BufferSize)
+	f.Writer = f.buffer
+}
+
+// Flush writes the buffered output to the destination. Callers writing to the
+// same destination between files, such as --stats on stdout, flush first.
+func (f *Formatter) Flush() error {
+	if err := f.fanOut((*Formatter).Flush); err != nil {
+		return err
+	}
+	if f.buffer == nil {
+		return nil
+	}
+	return f.buffer.Flush()
+}
+
+// treeSections renders the sections placed after the directory tree, such as
+// the pull request, the changes since the last tag, the recent commits, and the
+// embedded files, and charges them to the content budget
+func (f *Formatter) treeSections() string {
+	r, ok := f.output.(contextRenderer)
+	if !ok {
+		return ""
+	}
+	var sections string
+	if f.image != nil {
+		sections += r.imageSection(f.image)
+	}
+	if f.pullRequest != nil {
+		sections += r.pullRequestSection(f.pullRequest)
+	}
+	if changelog := f.fittedChangelog(); changelog != nil {
+		sections += r.changelogSection(changelog)
+	}
+	if len(f.history) > 0 {
+		sections += r.historySection(f.history)
+	}
+	if len(f.goEmbeds) > 0 {
+		sections += r.goEmbedsSection(f.goEmbeds)
+	}
+	if f.SizeLimiter != nil && sections != "" {
+		f.SizeLimiter.Charge(limits.CategoryContent, int64(len(sections)))
+	}
+	return sections
+}
+
+// FormatFileContent formats the content of a file
+func (f *Formatter) FormatFileContent(path, relativePath string) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatFileContent(path, relativePath) }); err != nil {
+		return err
+	}
+
+	if f.Images != "" && f.Images != images.ModeSkip && images.IsImage(path) {
+		return f.formatImage(path, relativePath)
+	}
+	if f.Minified == minified.ModePlaceholder {
+		if info, ok, err := minified.Detect(path); err == nil && ok {
+			return f.formatMinified(path, relativePath, info)
 		}
+	}
+	if pointer, ok, err := git.DetectLFSPointer(path); err == nil && ok {
+		return f.formatLFSPointer(path, relativePath, pointer)
+	}
 
-		// Check if adding this line would exceed the total size limit
-		if f.SizeLimiter != nil && f.SizeLimiter.MaxTotalSize > 0 {
-			if !f.SizeLimiter.AddToTotalSize(int64(len(formattedLine))) {
-				// We've reached the limit, print a message and stop
-				fmt.Fprintln(f.Writer, f.SizeLimiter.GetTruncatedMessage())
-				return nil
-			}
+	// A file over the maximum size gets a notice instead of its content
+	if f.SizeLimiter != nil {
+		withinLimit, fileSize, err := f.SizeLimiter.CheckFileSize(path)
+		if err != nil {
+			return fmt.Errorf("failed to check file size: %w", err)
 		}
+		if !withinLimit {
+			return f.formatSkipped(path, relativePath, fileSize, SkipTooLarge, f.SizeLimiter.GetFileTooLargeMessage(path, fileSize))
+		}
+	}
 
-		// Write the line
-		fmt.Fprint(f.Writer, formattedLine)
-		lineNum++
+	r, err := f.renderer()
+	if err != nil {
+		return err
 	}
+	return r.File(path, relativePath)
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+// lineTooLongMessage returns the marker written in place of the rest of a file
+// whose reading stopped at a pathologically long line
+func lineTooLongMessage(err error) (string, bool) {
+	var tooLong *utils.LineTooLongError
+	if !errors.As(err, &tooLong) {
+		return "", false
 	}
+	return fmt.Sprintf("[Line %d exceeds the maximum line length of %d bytes - rest of file skipped]", tooLong.Line, tooLong.Max), true
+}
 
-	return nil
+// readError wraps an error that stopped reading the file at relativePath
+func readError(relativePath string, err error) error {
+	return fmt.Errorf("error reading file %s: %w", relativePath, err)
 }
 
-// Finalize performs any final operations needed for the formatter
+// Finalize reports the statistics, if collected, and finishes the output and
+// any extra outputs. The output is flushed even when finishing it fails.
 func (f *Formatter) Finalize() error {
-	switch f.Format {
-	case HTMLFormat:
-		return f.finalizeHTML()
-	case JSONFormat:
-		return f.finalizeJSON()
+	// The extra outputs take the settings recorded after the tree
+	teeErr := f.fanOut(func(output *Formatter) error {
+		output.denied, output.sparse, output.stats = f.denied, f.sparse, f.stats
+		return output.Finalize()
+	})
+
+	r, err := f.renderer()
+	if err != nil {
+		return err
 	}
-	return nil
+	if f.stats != nil {
+		err = r.Stats(f.stats)
+	}
+	if err == nil {
+		err = r.End()
+	}
+	if f.buffer != nil {
+		if flushErr := f.buffer.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if teeErr != nil {
+		return teeErr
+	}
+	return err
 }
 
 // Close closes any resources used by the formatter
@@ -185,8 +546,20 @@ func (f *Formatter) Close() error {
 		return err
 	}
 
-	// Then close the writer if it's closable
-	if closer, ok := f.Writer.(io.Closer); ok {
+	// Then close the writers, or the ones the buffers wrap, if they're closable
+	if err := f.fanOut((*Formatter).closeWriter); err != nil {
+		return err
+	}
+	return f.closeWriter()
+}
+
+// closeWriter closes the writer, or the one the buffer wraps, if it's closable
+func (f *Formatter) closeWriter() error {
+	writer := f.Writer
+	if f.buffer != nil {
+		writer = f.destination
+	}
+	if closer, ok := writer.(io.Closer); ok {
 		return closer.Close()
 	}
 	return nil
@@ -2,12 +2,28 @@ package formatter
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"image/png"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"codectx/internal/analysis"
+	"codectx/internal/benchtree"
+	"codectx/internal/extract"
+	"codectx/internal/forge"
+	"codectx/internal/git"
+	"codectx/internal/images"
 	"codectx/internal/limits"
+	"codectx/internal/platform"
+	"codectx/internal/scanner"
+	"codectx/internal/stats"
 )
 
 func TestNewFormatter(t *testing.T) {
@@ -100,6 +116,102 @@ func TestNewFormatter(t *testing.T) {
 	}
 }
 
+// recordingRenderer records the calls a formatter makes to its renderer in
+// recordedCalls
+type recordingRenderer struct{}
+
+var recordedCalls []string
+
+func (r recordingRenderer) Begin() error {
+	return r.record("Begin")
+}
+
+func (r recordingRenderer) Tree(tree string) error {
+	return r.record("Tree " + tree)
+}
+
+func (r recordingRenderer) File(path, relativePath string) error {
+	return r.record("File " + relativePath)
+}
+
+func (r recordingRenderer) Stats(*stats.StatsCollector) error {
+	return r.record("Stats")
+}
+
+func (r recordingRenderer) End() error {
+	return r.record("End")
+}
+
+func (r recordingRenderer) record(call string) error {
+	recordedCalls = append(recordedCalls, call)
+	return nil
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	const format OutputFormat = "recording"
+	if !IsBuiltinFormat(string(format)) {
+		RegisterRenderer(format, func(f *Formatter) Renderer { return recordingRenderer{} })
+	}
+	recordedCalls = nil
+
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "a.txt")
+	if err := os.WriteFile(testFile, []byte("a\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	formatter, err := NewFormatter("Recording", false, "", nil, nil)
+	if err != nil {
+		t.Fatalf("NewFormatter failed for a registered format: %v", err)
+	}
+	formatter.SetStats(stats.NewStatsCollector())
+	if err := formatter.FormatTree("└── a.txt"); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if err := formatter.FormatFileContent(testFile, "/a.txt"); err != nil {
+		t.Fatalf("FormatFileContent failed: %v", err)
+	}
+	// A renderer without sections leaves them out
+	if err := formatter.FormatXref(&analysis.XrefIndex{}); err != nil {
+		t.Fatalf("FormatXref failed: %v", err)
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	expected := []string{"Begin", "Tree └── a.txt", "File /a.txt", "Stats", "End"}
+	if !reflect.DeepEqual(recordedCalls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, recordedCalls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a format twice to panic")
+		}
+	}()
+	RegisterRenderer(TextFormat, func(f *Formatter) Renderer { return &textRenderer{f} })
+}
+
+func TestBuiltinRenderers_Sections(t *testing.T) {
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		formatter, err := NewFormatter(string(format), false, "", nil, nil)
+		if err != nil {
+			t.Fatalf("NewFormatter failed for %s: %v", format, err)
+		}
+		r, err := formatter.renderer()
+		if err != nil {
+			t.Fatalf("renderer failed for %s: %v", format, err)
+		}
+		if _, ok := r.(sectionRenderer); !ok {
+			t.Errorf("Expected the %s renderer to write sections", format)
+		}
+		// JSON carries the context in its metadata
+		if _, ok := r.(contextRenderer); ok != (format != JSONFormat) {
+			t.Errorf("Expected the %s renderer to list context sections: %v", format, !ok)
+		}
+	}
+}
+
 func TestNewFormatter_WithOutputFile(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "formatter_test")
 	if err != nil {
@@ -267,6 +379,35 @@ func TestFormatter_FormatFileContent_WithSizeLimit(t *testing.T) {
 	}
 }
 
+func TestFormatter_FormatTree_NotCountedAgainstLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(testFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	sizeLimiter, err := limits.NewSizeLimiter("1MB", 500)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+	var buf bytes.Buffer
+	formatter := &Formatter{Format: TextFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+
+	// A tree larger than the limit leaves the limit to the contents
+	if err := formatter.FormatTree(strings.Repeat("├── file.go\n", 100)); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if err := formatter.FormatFileContent(testFile, "/main.go"); err != nil {
+		t.Fatalf("FormatFileContent failed: %v", err)
+	}
+	if output := buf.String(); !strings.Contains(output, "package main") || strings.Contains(output, "truncated") {
+		t.Errorf("Expected the content to be included, got: %s", output)
+	}
+	if sizeLimiter.CategorySize(limits.CategoryTree) == 0 {
+		t.Error("Expected the tree to be tracked in its category")
+	}
+}
+
 func TestFormatter_FormatFileContent_LargeFile(t *testing.T) {
 	// Create a temporary file that exceeds file size limit
 	tempDir, err := os.MkdirTemp("", "formatter_large_file_test")
@@ -344,6 +485,114 @@ func TestFormatter_Close(t *testing.T) {
 	}
 }
 
+func TestFormatter_Buffering(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := &Formatter{Format: TextFormat, Writer: &buf, BufferSize: 1024}
+	if err := formatter.FormatTree("└── a.txt"); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected the tree to be buffered, got %q", buf.String())
+	}
+	if err := formatter.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "a.txt") {
+		t.Errorf("Expected the tree after Flush, got %q", buf.String())
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+}
+
+func TestFormatter_AddOutput(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(testFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var markdown, jsonOut bytes.Buffer
+	opened := 0
+	formatter := &Formatter{
+		Format: MarkdownFormat,
+		Writer: &markdown,
+		Source: func(path string) (io.ReadCloser, error) {
+			opened++
+			return os.Open(path)
+		},
+	}
+	if err := formatter.AddOutput("json", &jsonOut); err != nil {
+		t.Fatalf("AddOutput failed: %v", err)
+	}
+	if err := formatter.AddOutput("yaml", io.Discard); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+
+	if err := formatter.FormatTree("└── main.go"); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if err := formatter.FormatFileContent(testFile, "main.go"); err != nil {
+		t.Fatalf("FormatFileContent failed: %v", err)
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	if opened != 1 {
+		t.Errorf("Expected the file to be read once for both outputs, got %d", opened)
+	}
+	if !strings.Contains(markdown.String(), "### main.go") {
+		t.Errorf("Expected the Markdown output to contain the file, got %q", markdown.String())
+	}
+	var output JSONOutput
+	if err := json.Unmarshal(jsonOut.Bytes(), &output); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %q", err, jsonOut.String())
+	}
+	if len(output.Files) != 1 || output.Files[0].Content != "package main\n" {
+		t.Errorf("Expected the JSON output to contain the file, got %+v", output.Files)
+	}
+	if err := formatter.AddOutput("html", io.Discard); err == nil {
+		t.Error("Expected an error adding an output after formatting started")
+	}
+}
+
+func TestFormatter_FormatSummary(t *testing.T) {
+	collector := &stats.StatsCollector{TotalFiles: 2, TotalDirectories: 1, TotalSize: 2048, TextFiles: 2, EstimatedTokens: 300}
+	inventory := []InventoryEntry{
+		{Path: "main.go", Language: "Go", SizeBytes: 1536, Lines: 40, Tokens: 250},
+		{Path: "README.md", Language: "Markdown", SizeBytes: 512, Lines: 10, Tokens: 50},
+	}
+	tests := []struct {
+		format   OutputFormat
+		contains []string
+	}{
+		{TextFormat, []string{"Summary:", "Files: 2 (2 text, 0 binary)", "Languages: Go (1), Markdown (1)", "main.go    Go        1.5KB     40     250"}},
+		{MarkdownFormat, []string{"## Summary", "- Total size: 2.0KB", "| `main.go` | Go | 1.5KB | 40 | 250 |"}},
+		{HTMLFormat, []string{"Summary", "File Inventory", "README.md  Markdown"}},
+		{JSONFormat, []string{`"inventory": [`, `"path": "README.md"`, `"tokens": 250`}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: test.format, Writer: &buf}
+			if err := formatter.FormatTree("├── main.go\n└── README.md"); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.FormatSummary(collector, inventory); err != nil {
+				t.Fatalf("FormatSummary failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+			for _, expected := range test.contains {
+				if !strings.Contains(buf.String(), expected) {
+					t.Errorf("Expected output to contain %q, got: %s", expected, buf.String())
+				}
+			}
+		})
+	}
+}
+
 func TestOutputFormatConstants(t *testing.T) {
 	// Test that format constants are correctly defined
 	if TextFormat != "text" {
@@ -358,4 +607,1180 @@ func TestOutputFormatConstants(t *testing.T) {
 	if JSONFormat != "json" {
 		t.Errorf("Expected JSONFormat to be 'json', got '%s'", JSONFormat)
 	}
-}
\ No newline at end of file
+}
+func TestFormatter_FormatFileContent_PerFileCap(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_file_cap_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testContent := strings.Repeat("0123456789\n", 10)
+	testFile := filepath.Join(tempDir, "big.txt")
+	if err := os.WriteFile(testFile, []byte(testContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+			sizeLimiter.SetFileInclusionLimits(33, 0)
+
+			var buf bytes.Buffer
+			formatter := &Formatter{
+				Format:      format,
+				Writer:      &buf,
+				SizeLimiter: sizeLimiter,
+			}
+			if err := formatter.FormatFileContent(testFile, "/big.txt"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+
+			output := buf.String()
+			if count := strings.Count(output, "0123456789"); count != 3 {
+				t.Errorf("Expected 3 included lines, got %d: %s", count, output)
+			}
+			if !strings.Contains(output, "7 more lines omitted") {
+				t.Errorf("Expected omission marker, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_Notices(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_notices_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	bigFile := filepath.Join(tempDir, "big.txt")
+	if err := os.WriteFile(bigFile, []byte(strings.Repeat("x", 2048)), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	longFile := filepath.Join(tempDir, "long.txt")
+	if err := os.WriteFile(longFile, []byte(strings.Repeat("0123456789\n", 20)), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		format    OutputFormat
+		skipped   string
+		truncated string
+	}{
+		{MarkdownFormat, "> [!WARNING]\n> File too large", "```\n\n> [!NOTE]\n> Output truncated"},
+		{HTMLFormat, `<div class="notice skipped">File too large`, `<div class="notice truncated">Output truncated`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			sizeLimiter, _ := limits.NewSizeLimiter("1KB", 50)
+
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: tt.format, Writer: &buf, SizeLimiter: sizeLimiter}
+			if err := formatter.FormatFileContent(bigFile, "/big.txt"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+			if err := formatter.FormatFileContent(longFile, "/long.txt"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, tt.skipped) {
+				t.Errorf("Expected a skipped notice %q, got: %s", tt.skipped, output)
+			}
+			if !strings.Contains(output, tt.truncated) {
+				t.Errorf("Expected a truncated notice %q, got: %s", tt.truncated, output)
+			}
+			if strings.Contains(output, "xxxx") {
+				t.Errorf("Expected the content of the large file to be left out, got: %s", output)
+			}
+			if count := strings.Count(output, "0123456789"); count == 0 || count == 20 {
+				t.Errorf("Expected the head of the truncated file, got %d lines: %s", count, output)
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_LongLines(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_long_line_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A minified file with a single line longer than bufio.Scanner's 64KB limit
+	minified := filepath.Join(tempDir, "app.min.js")
+	if err := os.WriteFile(minified, []byte(strings.Repeat("a", 100*1024)+"\nend\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+	formatter := &Formatter{Format: TextFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+	if err := formatter.FormatFileContent(minified, "/app.min.js"); err != nil {
+		t.Fatalf("FormatFileContent failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "end") {
+		t.Error("Expected the line after the long line to be included")
+	}
+
+	// The same file with a smaller maximum line length is reported per file
+	buf.Reset()
+	formatter.MaxLineLength = 1024
+	err = formatter.FormatFileContent(minified, "/app.min.js")
+	if err == nil || !strings.Contains(err.Error(), "/app.min.js") {
+		t.Errorf("Expected an error naming the file, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "exceeds the maximum line length") {
+		t.Errorf("Expected a line length marker, got: %s", buf.String())
+	}
+}
+
+func TestFormatter_JSON_Streaming(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_json_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"a.go":  "package a\n\nfunc A() string { return \"<a>\" }\n",
+		"b.txt": "no trailing newline",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(tempDir, "a.go"), 0755); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+	formatter := &Formatter{Format: JSONFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+	formatter.SetTargetDirectory(tempDir)
+	if err := formatter.FormatTree("├── a.go\n└── b.txt"); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	for _, name := range []string{"a.go", "b.txt"} {
+		if err := formatter.FormatFileContent(filepath.Join(tempDir, name), "/"+name); err != nil {
+			t.Fatalf("FormatFileContent failed: %v", err)
+		}
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if output.Metadata.TotalFiles != 2 || len(output.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d (metadata %d)", len(output.Files), output.Metadata.TotalFiles)
+	}
+	if output.Metadata.TargetDirectory != tempDir {
+		t.Errorf("Expected target directory %s, got %q", tempDir, output.Metadata.TargetDirectory)
+	}
+	for _, file := range output.Files {
+		name := strings.TrimPrefix(file.RelativePath, "/")
+		if file.Content != files[name] {
+			t.Errorf("Expected content %q for %s, got %q", files[name], name, file.Content)
+		}
+	}
+	if output.Files[0].LineCount != 3 || output.Files[1].LineCount != 1 {
+		t.Errorf("Expected line counts 3 and 1, got %d and %d", output.Files[0].LineCount, output.Files[1].LineCount)
+	}
+	if perms := output.Files[0].Permissions; perms == nil {
+		t.Errorf("Expected permissions for a.go")
+	} else if !platform.IsWindows && (perms.Mode != "0755" || !perms.Executable) {
+		t.Errorf("Expected executable mode 0755 for a.go, got %+v", *perms)
+	}
+}
+
+func TestFormatter_JSON_NoFiles(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := &Formatter{Format: JSONFormat, Writer: &buf}
+	if err := formatter.FormatTree(""); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(output.Files) != 0 {
+		t.Errorf("Expected no files, got %d", len(output.Files))
+	}
+}
+
+func TestFormatter_JSON_SkippedAndTruncated(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_json_limits_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var lines strings.Builder
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&lines, "line %04d\n", i)
+	}
+	files := map[string]string{
+		"big.txt":   strings.Repeat("x", 2048),
+		"lines.txt": lines.String(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	sizeLimiter, _ := limits.NewSizeLimiter("1KB", 100)
+	formatter := &Formatter{Format: JSONFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+	for _, name := range []string{"big.txt", "lines.txt"} {
+		if err := formatter.FormatFileContent(filepath.Join(tempDir, name), "/"+name); err != nil {
+			t.Fatalf("FormatFileContent failed: %v", err)
+		}
+	}
+	if err := formatter.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(output.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(output.Files))
+	}
+
+	big := output.Files[0]
+	if !big.Skipped || big.SkipReason != SkipTooLarge || big.SizeBytes != 2048 {
+		t.Errorf("Expected big.txt to be skipped as too large, got %+v", big)
+	}
+	if !strings.Contains(big.Content, "too large") {
+		t.Errorf("Expected the too large message as content, got %q", big.Content)
+	}
+
+	truncated := output.Files[1]
+	if !truncated.Truncated || truncated.IncludedRange == nil {
+		t.Fatalf("Expected lines.txt to be truncated with an included range, got %+v", truncated)
+	}
+	if truncated.LineCount != 50 {
+		t.Errorf("Expected all 50 lines to be counted, got %d", truncated.LineCount)
+	}
+	included := *truncated.IncludedRange
+	if included.StartLine != 1 || included.EndLine == 0 || included.EndLine >= 50 {
+		t.Errorf("Expected a head of the file to be included, got %+v", included)
+	}
+	if included.StartByte != 0 || included.EndByte != int64(included.EndLine)*10 {
+		t.Errorf("Expected bytes to end after line %d, got %+v", included.EndLine, included)
+	}
+	if head := files["lines.txt"][:included.EndByte]; !strings.HasPrefix(truncated.Content, head+"[") {
+		t.Errorf("Expected the included content to be the head of the file, got %q", truncated.Content)
+	}
+	if !output.Metadata.Truncated || output.Metadata.SkippedFiles != 1 {
+		t.Errorf("Expected truncated metadata with 1 skipped file, got truncated=%v skipped=%d",
+			output.Metadata.Truncated, output.Metadata.SkippedFiles)
+	}
+}
+
+func TestRenderTemplateFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_template_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	data := TemplateData{ProjectName: "demo", TotalFiles: 3, TotalTokens: 1200}
+
+	tests := []struct {
+		name        string
+		template    string
+		vars        map[string]string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Built-in variables",
+			template: "Review {{.ProjectName}}: {{.TotalFiles}} files, {{.TotalTokens}} tokens\n",
+			expected: "Review demo: 3 files, 1200 tokens",
+		},
+		{
+			name:     "User variables",
+			template: "Reviewer: {{.reviewer}}",
+			vars:     map[string]string{"reviewer": "alice"},
+			expected: "Reviewer: alice",
+		},
+		{
+			name:        "Missing variable",
+			template:    "{{.unknown}}",
+			expectError: true,
+		},
+		{
+			name:        "Variable shadows a built-in",
+			template:    "{{.TotalTokens}}",
+			vars:        map[string]string{"TotalTokens": "0"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "header.md")
+			if err := os.WriteFile(path, []byte(tt.template), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			result, err := RenderTemplateFile(path, data, tt.vars)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"reviewer=alice", "focus=a=b"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vars["reviewer"] != "alice" || vars["focus"] != "a=b" {
+		t.Errorf("Unexpected vars: %v", vars)
+	}
+
+	for _, invalid := range []string{"novalue", "=x", "my-key=x", "1st=x"} {
+		if _, err := ParseVars([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFormatter_HeaderAndFooter(t *testing.T) {
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{
+				Format: format,
+				Writer: &buf,
+				Header: "You are reviewing this repo",
+				Footer: "End of context",
+			}
+			if err := formatter.FormatTree("└── main.go"); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			header := strings.Index(output, "You are reviewing this repo")
+			tree := strings.Index(output, "main.go")
+			footer := strings.Index(output, "End of context")
+			if header < 0 || footer < 0 {
+				t.Fatalf("Expected header and footer in output, got: %s", output)
+			}
+			if !(header < tree && tree < footer) {
+				t.Errorf("Expected header, tree, footer order, got: %s", output)
+			}
+
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Output is not valid JSON: %v", err)
+				}
+				if doc.Header != formatter.Header || doc.Footer != formatter.Footer {
+					t.Errorf("Expected header and footer fields, got %q and %q", doc.Header, doc.Footer)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_ExtractDocuments(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_extract_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	notebook := `{"cells": [{"cell_type": "code", "source": "print('hi')", "outputs": [{"data": {"image/png": "iVBORw0KGgo"}}]}]}`
+	testFile := filepath.Join(tempDir, "demo.ipynb")
+	if err := os.WriteFile(testFile, []byte(notebook), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+			formatter := &Formatter{Format: format, Writer: &buf, SizeLimiter: sizeLimiter, Extract: extract.Options{Documents: true}}
+			if err := formatter.FormatFileContent(testFile, "/demo.ipynb"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, "# %%") || strings.Contains(output, "iVBORw0KGgo") {
+				t.Errorf("Expected extracted notebook without outputs, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_EmbeddedData(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_embedded_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	payload := strings.Repeat("iVBORw0KGgoAAAANSUhEUgAA", 40)
+	testFile := filepath.Join(tempDir, "README.md")
+	if err := os.WriteFile(testFile, []byte("# Logo\n![logo](data:image/png;base64,"+payload+")\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, JSONFormat, HTMLFormat} {
+		for _, keep := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s keep=%v", format, keep), func(t *testing.T) {
+				var buf bytes.Buffer
+				formatter := &Formatter{Format: format, Writer: &buf, KeepDataURIs: keep}
+				if format == JSONFormat {
+					if err := formatter.FormatTree("."); err != nil {
+						t.Fatalf("FormatTree failed: %v", err)
+					}
+				}
+				if err := formatter.FormatFileContent(testFile, "README.md"); err != nil {
+					t.Fatalf("FormatFileContent failed: %v", err)
+				}
+
+				output := buf.String()
+				assets, reclaimed := formatter.EmbeddedData()
+				if keep {
+					if !strings.Contains(output, payload) || assets != 0 {
+						t.Errorf("Expected the data URI to be kept, got %d stripped assets", assets)
+					}
+					return
+				}
+				if strings.Contains(output, payload) || !strings.Contains(output, "[embedded data omitted: 720 bytes]") {
+					t.Errorf("Expected the data URI to be replaced, got: %s", output)
+				}
+				if assets != 1 || reclaimed <= 0 {
+					t.Errorf("Expected 1 stripped asset with reclaimed tokens, got %d and %d", assets, reclaimed)
+				}
+			})
+		}
+	}
+}
+
+func TestFormatter_FormatFileContent_Images(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_images_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	testFile := filepath.Join(tempDir, "logo.png")
+	if err := os.WriteFile(testFile, data.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		format    OutputFormat
+		mode      string
+		thumbnail bool
+	}{
+		{TextFormat, images.ModePlaceholder, false},
+		{MarkdownFormat, images.ModeEmbed, false},
+		{HTMLFormat, images.ModePlaceholder, false},
+		{HTMLFormat, images.ModeEmbed, true},
+		{JSONFormat, images.ModePlaceholder, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format)+" "+tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: tt.format, Writer: &buf, Images: tt.mode}
+			if err := formatter.FormatFileContent(testFile, "logo.png"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+			if tt.format == JSONFormat {
+				if err := formatter.Finalize(); err != nil {
+					t.Fatalf("Finalize failed: %v", err)
+				}
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, "PNG image, 40x30") {
+				t.Errorf("Expected image placeholder, got: %s", output)
+			}
+			if strings.Contains(output, "data:image/png;base64,") != tt.thumbnail {
+				t.Errorf("Expected thumbnail=%v, got: %s", tt.thumbnail, output)
+			}
+
+			if tt.format == JSONFormat {
+				var doc struct {
+					Files []struct {
+						Type  string       `json:"type"`
+						Image *images.Info `json:"image"`
+					} `json:"files"`
+				}
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if len(doc.Files) != 1 || doc.Files[0].Type != "image" || doc.Files[0].Image == nil || doc.Files[0].Image.Width != 40 {
+					t.Errorf("Expected an image entry, got: %s", output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_Color(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_color_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(testFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, color := range []bool{false, true} {
+		t.Run(fmt.Sprintf("color=%v", color), func(t *testing.T) {
+			var buf bytes.Buffer
+			sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+			formatter := &Formatter{Format: TextFormat, Writer: &buf, SizeLimiter: sizeLimiter, ShowLineNumbers: true, Color: color}
+			if err := formatter.FormatTree("root/\n└── pkg/\n    └── main.go [key]"); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.FormatFileContent(testFile, "pkg/main.go"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+
+			output := buf.String()
+			if strings.Contains(output, "\x1b[") != color {
+				t.Errorf("Expected ANSI colors=%v, got: %q", color, output)
+			}
+			if color {
+				for _, expected := range []string{"└── \x1b[1;34mpkg/\x1b[0m", "\x1b[33m [key]\x1b[0m", "\x1b[1;36mpkg/main.go:\x1b[0m", "\x1b[35mpackage\x1b[0m main"} {
+					if !strings.Contains(output, expected) {
+						t.Errorf("Expected output to contain %q, got: %q", expected, output)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_Transform(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_transform_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testFile := filepath.Join(tempDir, "config.env")
+	if err := os.WriteFile(testFile, []byte("TOKEN=secret\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	sizeLimiter, _ := limits.NewSizeLimiter("1MB", 0)
+	formatter := &Formatter{Format: MarkdownFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+	formatter.Transform = func(path string, content []byte) ([]byte, error) {
+		return bytes.ReplaceAll(content, []byte("secret"), []byte("[REDACTED]")), nil
+	}
+	if err := formatter.FormatFileContent(testFile, "config.env"); err != nil {
+		t.Fatalf("FormatFileContent failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "TOKEN=[REDACTED]") || strings.Contains(output, "secret") {
+		t.Errorf("Expected transformed content, got: %q", output)
+	}
+
+	// A failing transform reports an error
+	formatter.Transform = func(path string, content []byte) ([]byte, error) {
+		return nil, fmt.Errorf("redactor failed")
+	}
+	if err := formatter.FormatFileContent(testFile, "config.env"); err == nil {
+		t.Errorf("Expected transform error, got nil")
+	}
+}
+
+func TestFormatter_FormatDuplicate(t *testing.T) {
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			if err := formatter.FormatDuplicate("/project/vendor/util.go", "vendor/util.go", "util.go"); err != nil {
+				t.Fatalf("FormatDuplicate failed: %v", err)
+			}
+			if format == JSONFormat {
+				if err := formatter.Finalize(); err != nil {
+					t.Fatalf("Finalize failed: %v", err)
+				}
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, "vendor/util.go") || !strings.Contains(output, "[identical to util.go]") {
+				t.Errorf("Expected a duplicate stub, got: %s", output)
+			}
+
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if len(doc.Files) != 1 || doc.Files[0].Type != "duplicate" || doc.Files[0].DuplicateOf != "util.go" {
+					t.Errorf("Expected a duplicate entry, got: %s", output)
+				}
+				if doc.Metadata.DuplicateFiles != 1 {
+					t.Errorf("Expected 1 duplicate file in metadata, got %d", doc.Metadata.DuplicateFiles)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_FormatFileContent_LFSPointer(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "model.onnx")
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 2048\n"
+	if err := os.WriteFile(path, []byte(pointer), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			if err := formatter.FormatFileContent(path, "model.onnx"); err != nil {
+				t.Fatalf("FormatFileContent failed: %v", err)
+			}
+			if format == JSONFormat {
+				if err := formatter.Finalize(); err != nil {
+					t.Fatalf("Finalize failed: %v", err)
+				}
+			}
+
+			output := buf.String()
+			if !strings.Contains(output, "[Git LFS object sha256:4d7a214614ab…, 2.0KB, not fetched]") || strings.Contains(output, "spec/v1") {
+				t.Errorf("Expected an LFS placeholder instead of the pointer, got: %s", output)
+			}
+
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if len(doc.Files) != 1 || doc.Files[0].Type != "lfs_pointer" || doc.Files[0].LFS == nil || doc.Files[0].LFS.Size != 2048 {
+					t.Errorf("Expected an LFS pointer entry, got: %s", output)
+				}
+				if doc.Metadata.LFSPointers != 1 {
+					t.Errorf("Expected 1 LFS pointer in metadata, got %d", doc.Metadata.LFSPointers)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_Invocation(t *testing.T) {
+	invocation := &Invocation{
+		Command:    "codectx -e go --exclude vendor .",
+		Version:    "v1.2.3",
+		ConfigFile: "/home/alice/.codectx/config.json",
+		ConfigHash: "9f86d081884c7d65",
+		Filters:    []string{"--extensions go", "--exclude vendor"},
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			formatter.SetInvocation(invocation)
+			if err := formatter.FormatTree(""); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if !reflect.DeepEqual(doc.Metadata.Invocation, invocation) {
+					t.Errorf("Expected the invocation in metadata, got: %s", output)
+				}
+				return
+			}
+
+			for _, expected := range []string{"Invocation", "codectx -e go --exclude vendor .", "codectx v1.2.3", "/home/alice/.codectx/config.json", "9f86d081884c7d65", "--extensions go"} {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Expected output to contain %q, got: %s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_History(t *testing.T) {
+	files := make([]string, 12)
+	for i := range files {
+		files[i] = fmt.Sprintf("pkg/file%d.go", i)
+	}
+	commits := []git.Commit{
+		{Hash: "a1b2c3d", Author: "Alice", Date: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Subject: "Fix the <parser>", Files: []string{"parse.go"}},
+		{Hash: "e4f5a6b", Author: "Bob", Date: time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC), Subject: "Reformat", Files: files},
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			formatter.SetHistory(commits)
+			if err := formatter.FormatTree(""); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if len(doc.Metadata.History) != 2 || len(doc.Metadata.History[1].Files) != 12 {
+					t.Errorf("Expected both commits with all files in metadata, got: %s", output)
+				}
+				return
+			}
+
+			if !strings.Contains(output, "Recent Commits") || !strings.Contains(output, "2024-05-01 Alice") {
+				t.Errorf("Expected a history section, got: %s", output)
+			}
+			if !strings.Contains(output, "pkg/file9.go") || strings.Contains(output, "pkg/file10.go") || !strings.Contains(output, "... and 2 more files") {
+				t.Errorf("Expected 10 files of the large commit, got: %s", output)
+			}
+			if format == MarkdownFormat && strings.Index(output, "## Recent Commits") > strings.Index(output, "## Files") {
+				t.Errorf("Expected the history before the files, got: %s", output)
+			}
+			if format == HTMLFormat && !strings.Contains(output, "Fix the &lt;parser&gt;") {
+				t.Errorf("Expected an escaped subject, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestFormatter_PullRequest(t *testing.T) {
+	pr := &forge.PullRequest{
+		Number:     12,
+		Title:      "Fix the <parser>",
+		Body:       "Handles empty input",
+		Author:     "alice",
+		State:      "open",
+		BaseBranch: "main",
+		HeadBranch: "fix-parser",
+		HeadCommit: "0123456789abcdef",
+		Files: []forge.ChangedFile{
+			{Path: "parse.go", Status: "modified", Additions: 3, Deletions: 1},
+			{Path: "lex.go", PreviousPath: "lexer.go", Status: "renamed"},
+		},
+		Comments: []forge.Comment{
+			{Author: "bob", Path: "parse.go", Line: 42, Body: "Empty input?", Created: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			formatter.SetPullRequest(pr)
+			if err := formatter.FormatTree(""); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if doc.Metadata.PullRequest == nil || len(doc.Metadata.PullRequest.Files) != 2 || len(doc.Metadata.PullRequest.Comments) != 1 {
+					t.Errorf("Expected the pull request in metadata, got: %s", output)
+				}
+				return
+			}
+
+			for _, expected := range []string{"Pull Request #12", "Handles empty input", "parse.go", "(+3 -1)", "lexer.go", "parse.go:42", "Empty input?", "0123456"} {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Expected %q in the pull request section, got: %s", expected, output)
+				}
+			}
+			if format == HTMLFormat && !strings.Contains(output, "Fix the &lt;parser&gt;") {
+				t.Errorf("Expected an escaped title, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestFormatter_GoEmbeds(t *testing.T) {
+	embeds := []analysis.GoEmbed{{
+		Package:  "web",
+		File:     "web/assets.go",
+		Line:     8,
+		Variable: "static",
+		Patterns: []string{"static"},
+		Assets: []analysis.EmbeddedAsset{
+			{Path: "web/static/app.css", Included: true},
+			{Path: "web/static/font.woff2"},
+		},
+	}}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			formatter.SetGoEmbeds(embeds)
+			if err := formatter.FormatTree(""); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if len(doc.Metadata.GoEmbeds) != 1 || len(doc.Metadata.GoEmbeds[0].Assets) != 2 || doc.Metadata.GoEmbeds[0].Assets[1].Included {
+					t.Errorf("Expected the embedded files in metadata, got: %s", output)
+				}
+				return
+			}
+
+			for _, expected := range []string{"Embedded Files (go:embed)", "web/assets.go:8, var static", "web/static/app.css", "web/static/font.woff2` (not included)"} {
+				if format != MarkdownFormat {
+					expected = strings.ReplaceAll(expected, "`", "")
+				}
+				if !strings.Contains(output, expected) {
+					t.Errorf("Expected %q in the embedded files section, got: %s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatter_Changelog(t *testing.T) {
+	changelog := &git.Changelog{
+		Since:   "v1.0.0",
+		Commits: 2,
+		Groups: []git.CommitGroup{
+			{Type: "feat", Title: "Features", Commits: []git.Commit{{Hash: "a1b2c3d", Author: "Alice", Subject: "feat: add <flag>"}}},
+			{Type: "fix", Title: "Fixes", Commits: []git.Commit{{Hash: "e4f5a6b", Author: "Bob", Subject: "fix: handle errors"}}},
+		},
+		Files: []git.FileChange{{Path: "README.md", Additions: 3, Deletions: 1}, {Path: "logo.png", Binary: true}},
+		Diff:  "--- a/README.md\n+++ b/README.md\n+```go\n",
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: format, Writer: &buf}
+			formatter.SetChangelog(changelog)
+			if err := formatter.FormatTree(""); err != nil {
+				t.Fatalf("FormatTree failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+
+			output := buf.String()
+			if format == JSONFormat {
+				var doc JSONOutput
+				if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if doc.Metadata.Changelog == nil || len(doc.Metadata.Changelog.Groups) != 2 || doc.Metadata.Changelog.Diff == "" {
+					t.Errorf("Expected the changelog in metadata, got: %s", output)
+				}
+				return
+			}
+
+			for _, expected := range []string{"Changes Since v1.0.0", "2 commits, 2 files changed (+3 -1)", "Features", "a1b2c3d", "Fixes", "logo.png", "(binary)", "+++ b/README.md"} {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Expected %q in the changelog section, got: %s", expected, output)
+				}
+			}
+			if format == MarkdownFormat && !strings.Contains(output, "````diff\n") {
+				t.Errorf("Expected a fence longer than the backticks in the diff, got: %s", output)
+			}
+		})
+	}
+
+	// The diff is content, left out when it doesn't fit the limit
+	sizeLimiter, _ := limits.NewSizeLimiter("1MB", 10)
+	var buf bytes.Buffer
+	formatter := &Formatter{Format: TextFormat, Writer: &buf, SizeLimiter: sizeLimiter}
+	formatter.SetChangelog(changelog)
+	if err := formatter.FormatTree(""); err != nil {
+		t.Fatalf("FormatTree failed: %v", err)
+	}
+	if output := buf.String(); strings.Contains(output, "+++ b/README.md") || !strings.Contains(output, "Diff omitted") {
+		t.Errorf("Expected the diff to be omitted beyond the limit, got: %s", output)
+	}
+}
+
+func TestFormatter_Render(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "formatter_render_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	mainPath := filepath.Join(tempDir, "main.go")
+	copyPath := filepath.Join(tempDir, "copy.go")
+	if err := os.WriteFile(mainPath, []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	tree := "├── copy.go\n└── main.go\n"
+	commits := []git.Commit{{Hash: "a1b2c3d", Author: "Alice", Date: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Subject: "Add main", Files: []string{"main.go"}}}
+
+	// scan writes the files in a format, as a run does
+	scan := func(format OutputFormat) string {
+		var buf bytes.Buffer
+		formatter := &Formatter{Format: format, ShowLineNumbers: true, Writer: &buf}
+		formatter.SetKeyFiles([]string{"main.go"})
+		formatter.SetHistory(commits)
+		if err := formatter.FormatTree(tree); err != nil {
+			t.Fatalf("FormatTree failed: %v", err)
+		}
+		if err := formatter.FormatFileContent(mainPath, "main.go"); err != nil {
+			t.Fatalf("FormatFileContent failed: %v", err)
+		}
+		if err := formatter.FormatDuplicate(copyPath, "copy.go", "main.go"); err != nil {
+			t.Fatalf("FormatDuplicate failed: %v", err)
+		}
+		if err := formatter.Finalize(); err != nil {
+			t.Fatalf("Finalize failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	document := scan(JSONFormat)
+	expected := map[OutputFormat]string{TextFormat: scan(TextFormat), MarkdownFormat: scan(MarkdownFormat), HTMLFormat: scan(HTMLFormat)}
+
+	// The files are not read again
+	if err := os.Remove(mainPath); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	for _, target := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat} {
+		t.Run(string(target), func(t *testing.T) {
+			var buf bytes.Buffer
+			formatter := &Formatter{Format: target, ShowLineNumbers: true, Writer: &buf}
+			if err := formatter.Render(strings.NewReader(document)); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if err := formatter.Finalize(); err != nil {
+				t.Fatalf("Finalize failed: %v", err)
+			}
+			if got := buf.String(); got != expected[target] {
+				t.Errorf("Expected the output of the scan:\n%s\ngot:\n%s", expected[target], got)
+			}
+		})
+	}
+
+	// Contents beyond MaxMemory are spilled to disk and rendered the same way
+	var buf bytes.Buffer
+	spilling := &Formatter{Format: MarkdownFormat, ShowLineNumbers: true, Writer: &buf, MaxMemory: 1}
+	if err := spilling.Render(strings.NewReader(document)); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if err := spilling.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if got := buf.String(); got != expected[MarkdownFormat] {
+		t.Errorf("Expected the output of the scan with MaxMemory:\n%s\ngot:\n%s", expected[MarkdownFormat], got)
+	}
+
+	// Rendering into JSON is refused
+	formatter := &Formatter{Format: JSONFormat, Writer: io.Discard}
+	if err := formatter.Render(strings.NewReader(document)); err == nil {
+		t.Errorf("Expected an error rendering JSON into JSON")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	api := &JSONOutput{
+		DirectoryTree: "├── go.mod [key]\n└── LICENSE\n",
+		Directories:   []scanner.DirectoryRecord{{Path: ".", Files: 2, TotalFiles: 2, SizeBytes: 15}},
+		Files: []JSONFileInfo{
+			{Path: "/src/api/go.mod", RelativePath: "go.mod", Type: "text", Content: "module api\n"},
+			{Path: "/src/api/LICENSE", RelativePath: "LICENSE", Type: "text", Content: "MIT\n"},
+		},
+		Metadata: JSONMetadata{TotalFiles: 2, EstimatedTokens: 3, KeyFiles: []string{"go.mod"}, GitInfo: &git.GitInfo{Branch: "main"}},
+	}
+	web := &JSONOutput{
+		DirectoryTree: "├── LICENSE\n└── COPYING\n",
+		Directories:   []scanner.DirectoryRecord{{Path: ".", Files: 2, TotalFiles: 2, SizeBytes: 8}},
+		Files: []JSONFileInfo{
+			{Path: "/src/web/LICENSE", RelativePath: "LICENSE", Type: "text", Content: "MIT\n"},
+			{Path: "/src/web/COPYING", RelativePath: "COPYING", Type: "duplicate", Content: "[identical to LICENSE]", DuplicateOf: "LICENSE"},
+		},
+		Metadata: JSONMetadata{TotalFiles: 2, EstimatedTokens: 6, DuplicateFiles: 1},
+	}
+
+	merged := Merge([]MergeInput{{Label: "api", Document: api}, {Label: "web", Document: web}}, scanner.UnicodeTreeChars)
+
+	expectedTree := "├── api/\n│   ├── go.mod [key]\n│   └── LICENSE\n└── web/\n    ├── LICENSE\n    └── COPYING\n"
+	if merged.DirectoryTree != expectedTree {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expectedTree, merged.DirectoryTree)
+	}
+
+	expectedFiles := []JSONFileInfo{
+		{Path: "api/go.mod", RelativePath: "api/go.mod", Type: "text", Content: "module api\n"},
+		{Path: "api/LICENSE", RelativePath: "api/LICENSE", Type: "text", Content: "MIT\n"},
+		{Path: "web/LICENSE", RelativePath: "web/LICENSE", Type: "duplicate", Content: "[identical to api/LICENSE]", DuplicateOf: "api/LICENSE"},
+		{Path: "web/COPYING", RelativePath: "web/COPYING", Type: "duplicate", Content: "[identical to web/LICENSE]", DuplicateOf: "web/LICENSE"},
+	}
+	if !reflect.DeepEqual(merged.Files, expectedFiles) {
+		t.Errorf("Expected files %+v, got %+v", expectedFiles, merged.Files)
+	}
+
+	expectedDirectories := []scanner.DirectoryRecord{
+		{Path: ".", Directories: 2, TotalFiles: 4, SizeBytes: 23},
+		{Path: "api", Files: 2, TotalFiles: 2, SizeBytes: 15},
+		{Path: "web", Files: 2, TotalFiles: 2, SizeBytes: 8},
+	}
+	if !reflect.DeepEqual(merged.Directories, expectedDirectories) {
+		t.Errorf("Expected directories %+v, got %+v", expectedDirectories, merged.Directories)
+	}
+
+	metadata := merged.Metadata
+	if metadata.TotalFiles != 4 || metadata.DuplicateFiles != 2 {
+		t.Errorf("Expected 4 files and 2 duplicates, got %d and %d", metadata.TotalFiles, metadata.DuplicateFiles)
+	}
+	if !reflect.DeepEqual(metadata.KeyFiles, []string{"api/go.mod"}) {
+		t.Errorf("Expected key files [api/go.mod], got %v", metadata.KeyFiles)
+	}
+	if len(metadata.Sources) != 2 || metadata.Sources[0].Label != "api" || metadata.Sources[0].GitInfo == nil || metadata.Sources[1].Label != "web" {
+		t.Errorf("Expected the api and web sources, got %+v", metadata.Sources)
+	}
+}
+
+// BenchmarkFormatter_Output formats a synthetic repository of 50 files of 2000
+// lines into a file, writing each line through or buffering the output
+func BenchmarkFormatter_Output(b *testing.B) {
+	dir := b.TempDir()
+	line := "\tfmt.Fprintf(w, \"%s: %d\\n\", name, value) // a typical line of source code\n"
+	var paths []string
+	for i := 0; i < 50; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%02d.go", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat(line, 2000)), 0644); err != nil {
+			b.Fatalf("Failed to create test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	for _, bufferSize := range []int{0, DefaultBufferSize} {
+		name := "unbuffered"
+		if bufferSize > 0 {
+			name = "buffered"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				out, err := os.Create(filepath.Join(dir, "output.txt"))
+				if err != nil {
+					b.Fatalf("Failed to create output: %v", err)
+				}
+				formatter := &Formatter{Format: TextFormat, ShowLineNumbers: true, Writer: out, BufferSize: bufferSize}
+				if err := formatter.FormatTree("(tree)"); err != nil {
+					b.Fatalf("FormatTree failed: %v", err)
+				}
+				for _, path := range paths {
+					if err := formatter.FormatFileContent(path, "/"+filepath.Base(path)); err != nil {
+						b.Fatalf("FormatFileContent failed: %v", err)
+					}
+				}
+				if err := formatter.Close(); err != nil {
+					b.Fatalf("Close failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkFormatter_Formats formats the text files of a synthetic tree in
+// each built-in format, reporting the throughput in bytes of source
+func BenchmarkFormatter_Formats(b *testing.B) {
+	dir := b.TempDir()
+	paths, err := benchtree.Generate(dir, benchtree.Spec{Files: 200, Lines: 200})
+	if err != nil {
+		b.Fatalf("Failed to generate tree: %v", err)
+	}
+	var textPaths, relPaths []string
+	var size int64
+	for _, path := range paths {
+		if filepath.Ext(path) == ".bin" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			b.Fatalf("Failed to stat file: %v", err)
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		textPaths = append(textPaths, path)
+		relPaths = append(relPaths, "/"+filepath.ToSlash(relPath))
+		size += info.Size()
+	}
+
+	for _, format := range []OutputFormat{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat} {
+		b.Run(string(format), func(b *testing.B) {
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				formatter := &Formatter{Format: format, ShowLineNumbers: true, Writer: io.Discard, BufferSize: DefaultBufferSize}
+				if err := formatter.FormatTree("(tree)"); err != nil {
+					b.Fatalf("FormatTree failed: %v", err)
+				}
+				for j, path := range textPaths {
+					if err := formatter.FormatFileContent(path, relPaths[j]); err != nil {
+						b.Fatalf("FormatFileContent failed: %v", err)
+					}
+				}
+				if err := formatter.Finalize(); err != nil {
+					b.Fatalf("Finalize failed: %v", err)
+				}
+			}
+		})
+	}
+}
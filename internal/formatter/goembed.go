@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/analysis"
+)
+
+// SetGoEmbeds records the //go:embed directives so that the files they embed
+// are listed after the directory tree and in JSON metadata
+func (f *Formatter) SetGoEmbeds(embeds []analysis.GoEmbed) {
+	f.goEmbeds = embeds
+}
+
+// goEmbedsSection formats the embedded files in text format
+func (r *textRenderer) goEmbedsSection(embeds []analysis.GoEmbed) string {
+	var b strings.Builder
+	b.WriteString("\nEmbedded Files (go:embed):\n")
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, embed := range embeds {
+		fmt.Fprintf(&b, "%s\n", goEmbedLine(embed))
+		for _, asset := range embed.Assets {
+			fmt.Fprintf(&b, "    %s\n", embeddedAssetLabel(asset))
+		}
+	}
+	return b.String()
+}
+
+// goEmbedsSection formats the embedded files in Markdown format
+func (r *markdownRenderer) goEmbedsSection(embeds []analysis.GoEmbed) string {
+	var b strings.Builder
+	b.WriteString("\n## Embedded Files (go:embed)\n\n")
+	for _, embed := range embeds {
+		fmt.Fprintf(&b, "- %s\n", goEmbedLine(embed))
+		for _, asset := range embed.Assets {
+			if asset.Included {
+				fmt.Fprintf(&b, "  - `%s`\n", asset.Path)
+			} else {
+				fmt.Fprintf(&b, "  - `%s` (not included)\n", asset.Path)
+			}
+		}
+	}
+	return b.String()
+}
+
+// goEmbedsSection formats the embedded files in HTML format
+func (r *htmlRenderer) goEmbedsSection(embeds []analysis.GoEmbed) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, "Embedded Files (go:embed)")
+	for _, embed := range embeds {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(goEmbedLine(embed)))
+		for _, asset := range embed.Assets {
+			fmt.Fprintf(&b, "<span class=\"line\">    %s</span>\n", html.EscapeString(embeddedAssetLabel(asset)))
+		}
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// goEmbedLine describes a directive on one line, such as
+// "web (web/assets.go:12, var static): static/*"
+func goEmbedLine(embed analysis.GoEmbed) string {
+	location := fmt.Sprintf("%s:%d", embed.File, embed.Line)
+	if embed.Variable != "" {
+		location += ", var " + embed.Variable
+	}
+	line := fmt.Sprintf("%s (%s): %s", embed.Package, location, strings.Join(embed.Patterns, " "))
+	if len(embed.Assets) == 0 {
+		line += " (no matching files scanned)"
+	}
+	return line
+}
+
+// embeddedAssetLabel returns the path of an embedded file, noting when it is
+// left out of the output
+func embeddedAssetLabel(asset analysis.EmbeddedAsset) string {
+	if asset.Included {
+		return asset.Path
+	}
+	return asset.Path + " (not included)"
+}
@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/git"
+)
+
+// maxHistoryFiles is the number of files listed per commit in the history
+// section; JSON lists them all
+const maxHistoryFiles = 10
+
+// SetHistory records the recent commits so that they are listed after the
+// directory tree and in JSON metadata
+func (f *Formatter) SetHistory(commits []git.Commit) {
+	f.history = commits
+}
+
+// historySection formats the recent commits in text format
+func (r *textRenderer) historySection(commits []git.Commit) string {
+	var b strings.Builder
+	b.WriteString("\nRecent Commits:\n")
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "%s\n", commitLine(commit))
+		files, more := historyFiles(commit)
+		for _, file := range files {
+			fmt.Fprintf(&b, "    %s\n", file)
+		}
+		if more != "" {
+			fmt.Fprintf(&b, "    %s\n", more)
+		}
+	}
+	return b.String()
+}
+
+// historySection formats the recent commits in Markdown format
+func (r *markdownRenderer) historySection(commits []git.Commit) string {
+	var b strings.Builder
+	b.WriteString("\n## Recent Commits\n\n")
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "- `%s` %s %s: %s\n", commit.Hash, commit.Date.Format("2006-01-02"), commit.Author, commit.Subject)
+		files, more := historyFiles(commit)
+		for _, file := range files {
+			fmt.Fprintf(&b, "  - `%s`\n", file)
+		}
+		if more != "" {
+			fmt.Fprintf(&b, "  - %s\n", more)
+		}
+	}
+	return b.String()
+}
+
+// historySection formats the recent commits in HTML format
+func (r *htmlRenderer) historySection(commits []git.Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, "Recent Commits")
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(commitLine(commit)))
+		files, more := historyFiles(commit)
+		for _, file := range files {
+			fmt.Fprintf(&b, "<span class=\"line\">    %s</span>\n", html.EscapeString(file))
+		}
+		if more != "" {
+			fmt.Fprintf(&b, htmlOmittedLine, "    "+more)
+		}
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// commitLine summarizes a commit on one line, such as
+// "a1b2c3d 2024-05-01 Alice: Fix the parser"
+func commitLine(commit git.Commit) string {
+	return fmt.Sprintf("%s %s %s: %s", commit.Hash, commit.Date.Format("2006-01-02"), commit.Author, commit.Subject)
+}
+
+// historyFiles returns the files of a commit to list, and a note on the rest
+func historyFiles(commit git.Commit) ([]string, string) {
+	if len(commit.Files) <= maxHistoryFiles {
+		return commit.Files, ""
+	}
+	return commit.Files[:maxHistoryFiles], fmt.Sprintf("... and %d more files", len(commit.Files)-maxHistoryFiles)
+}
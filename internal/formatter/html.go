@@ -1,11 +1,13 @@
 package formatter
 
 import (
-	"bufio"
 	"fmt"
 	"html"
-	"os"
+	"regexp"
 	"strings"
+
+	"codectx/internal/stats"
+	"codectx/internal/utils"
 )
 
 // HTML template constants
@@ -45,6 +47,9 @@ const (
             margin: 20px 0; 
             font-size: 14px;
         }
+        .tree-details { 
+            color: #6c757d; 
+        }
         .file { 
             margin: 20px 0; 
             border: 1px solid #ddd; 
@@ -78,6 +83,38 @@ const (
         .line { 
             display: block; 
         }
+        .line.omitted {
+            color: #6c757d;
+            font-style: italic;
+        }
+        .notice {
+            font-family: sans-serif;
+            padding: 8px 12px;
+            border-radius: 4px;
+            white-space: normal;
+        }
+        .notice.skipped {
+            background: #fff3cd;
+            border-left: 4px solid #ffc107;
+            color: #664d03;
+        }
+        .notice.truncated {
+            background: #e7f1ff;
+            border-left: 4px solid #007acc;
+            color: #084298;
+        }
+        .thumbnail {
+            display: block;
+            max-width: 256px;
+            margin-bottom: 8px;
+        }
+        .preamble {
+            background: #fff;
+            border-left: 4px solid #007bff;
+            padding: 10px 15px;
+            margin: 20px 0;
+            white-space: pre-wrap;
+        }
         .metadata { 
             background: #e3f2fd; 
             padding: 10px; 
@@ -90,13 +127,15 @@ const (
 </head>
 <body>
     <div class="container">
-        <h1>Project Structure</h1>
+%s`
+
+	htmlTree = `        <h1>Project Structure</h1>
         <div class="tree">%s</div>
         <div class="files">
 `
 
 	htmlFooter = `        </div>
-    </div>
+%s    </div>
 </body>
 </html>
 `
@@ -109,47 +148,95 @@ const (
 	htmlFileFooter = `            </div>
         </div>
 `
+	htmlOmittedLine = "<span class=\"line omitted\">%s</span>\n"
 )
 
-// formatTreeHTML formats the directory tree in HTML format
-func (f *Formatter) formatTreeHTML(tree string) error {
-	// Escape the tree for HTML
-	escapedTree := html.EscapeString(tree)
-	// Replace newlines with <br> tags
-	escapedTree = strings.ReplaceAll(escapedTree, "\n", "<br>")
+// treeDetailsPattern splits a tree line into the entry and its aligned details
+var treeDetailsPattern = regexp.MustCompile(`^(.*\S)(\s{2,})(\(.*\))$`)
+
+// htmlRenderer writes a standalone HTML page
+type htmlRenderer struct {
+	*Formatter
+}
 
-	// Write the HTML header with the tree
-	_, err := fmt.Fprintf(f.Writer, htmlHeader, escapedTree)
+// Begin writes the head of the page and the header
+func (r *htmlRenderer) Begin() error {
+	r.chargePreamble(r.Header)
+	_, err := fmt.Fprintf(r.Writer, htmlHeader, htmlPreamble(r.Header))
 	return err
 }
 
-// formatFileContentHTML formats the content of a file in HTML format
-func (f *Formatter) formatFileContentHTML(path, relativePath string) error {
-	// Write the file header
-	_, err := fmt.Fprintf(f.Writer, htmlFileHeader, html.EscapeString(relativePath))
-	if err != nil {
+// Tree writes the directory tree and the sections that follow it
+func (r *htmlRenderer) Tree(tree string) error {
+	var escapedTree string
+	if r.TreeDetails {
+		// Escape each line, rendering the details in a muted style
+		lines := strings.Split(tree, "\n")
+		for i, line := range lines {
+			if m := treeDetailsPattern.FindStringSubmatch(line); m != nil {
+				lines[i] = html.EscapeString(m[1]) + m[2] + `<span class="tree-details">` + html.EscapeString(m[3]) + `</span>`
+			} else {
+				lines[i] = html.EscapeString(line)
+			}
+		}
+		escapedTree = strings.Join(lines, "<br>")
+	} else {
+		// Escape the tree for HTML
+		escapedTree = html.EscapeString(tree)
+		// Replace newlines with <br> tags
+		escapedTree = strings.ReplaceAll(escapedTree, "\n", "<br>")
+	}
+
+	if _, err := fmt.Fprintf(r.Writer, htmlTree, escapedTree); err != nil {
 		return err
 	}
+	_, err := fmt.Fprint(r.Writer, r.treeSections())
+	return err
+}
 
-	// Open the file
-	file, err := os.Open(path)
+// File writes the content of a file in a box under its path
+func (r *htmlRenderer) File(path, relativePath string) error {
+	// Open the file first, so that an unreadable file leaves no partial entry
+	file, err := r.openSource(path)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	// Write the file header
+	if _, err := fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(r.fileLabel(relativePath))); err != nil {
+		return err
+	}
+
 	// Read the file line by line
-	scanner := bufio.NewScanner(file)
+	indent := r.detectIndent(path)
+	scanner := utils.NewLineReader(file, r.MaxLineLength)
+	fileCap := r.SizeLimiter.NewFileCap()
+	limitReached := false
 	lineNum := 1
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := r.cleanLine(scanner.Text(), indent)
+		if !fileCap.Allow(line) {
+			continue
+		}
+
+		// Stop at the total size limit, charged for the line as plain text
+		if r.SizeLimiter != nil && r.SizeLimiter.IsLimited() {
+			reservation, ok := r.SizeLimiter.ReserveForPath(relativePath, int64(len(line)+1))
+			if !ok {
+				limitReached = true
+				break
+			}
+			reservation.Commit()
+		}
+
 		// Escape the line for HTML
 		escapedLine := html.EscapeString(line)
 
-		if f.ShowLineNumbers {
-			_, err = fmt.Fprintf(f.Writer, "<span class=\"line\"><span class=\"line-number\">%d</span>%s</span>\n", lineNum, escapedLine)
+		if r.ShowLineNumbers {
+			_, err = fmt.Fprintf(r.Writer, "<span class=\"line\"><span class=\"line-number\">%d</span>%s</span>\n", lineNum, escapedLine)
 		} else {
-			_, err = fmt.Fprintf(f.Writer, "<span class=\"line\">%s</span>\n", escapedLine)
+			_, err = fmt.Fprintf(r.Writer, "<span class=\"line\">%s</span>\n", escapedLine)
 		}
 
 		if err != nil {
@@ -159,17 +246,45 @@ func (f *Formatter) formatFileContentHTML(path, relativePath string) error {
 		lineNum++
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+	if fileCap.Truncated() {
+		if _, err := fmt.Fprintf(r.Writer, htmlOmittedLine, html.EscapeString(fileCap.OmissionMessage())); err != nil {
+			return err
+		}
 	}
 
-	// Write the file footer
-	_, err = fmt.Fprint(f.Writer, htmlFileFooter)
-	return err
+	// Write the file footer even when reading stopped early
+	readErr := scanner.Err()
+	if message, ok := lineTooLongMessage(readErr); ok {
+		if _, err := fmt.Fprintf(r.Writer, htmlOmittedLine, html.EscapeString(message)); err != nil {
+			return err
+		}
+	}
+	if limitReached {
+		if _, err := fmt.Fprint(r.Writer, htmlNotice(noticeTruncated, r.SizeLimiter.GetTruncatedMessageFor(relativePath))); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(r.Writer, htmlFileFooter); err != nil {
+		return err
+	}
+
+	if readErr != nil {
+		return readError(relativePath, readErr)
+	}
+	return nil
+}
+
+// Stats leaves the statistics to --stats, which prints them separately
+func (r *htmlRenderer) Stats(*stats.StatsCollector) error {
+	return nil
 }
 
-// finalizeHTML writes the HTML footer
-func (f *Formatter) finalizeHTML() error {
-	_, err := fmt.Fprint(f.Writer, htmlFooter)
+// End writes the invocation, the footer, and the end of the page
+func (r *htmlRenderer) End() error {
+	if err := r.writeInvocation(); err != nil {
+		return err
+	}
+	r.chargePreamble(r.Footer)
+	_, err := fmt.Fprintf(r.Writer, htmlFooter, htmlPreamble(r.Footer))
 	return err
 }
@@ -0,0 +1,91 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"codectx/internal/oci"
+)
+
+// SetImage records the container image of "codectx image" so that how its
+// container runs is listed after the directory tree and in JSON metadata
+func (f *Formatter) SetImage(image *oci.Image) {
+	f.image = image
+}
+
+// imageSection formats the container image in text format
+func (r *textRenderer) imageSection(image *oci.Image) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n", imageTitle(image))
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range imageLines(image) {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// imageSection formats the container image in Markdown format
+func (r *markdownRenderer) imageSection(image *oci.Image) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", imageTitle(image))
+	for _, line := range imageLines(image) {
+		if line == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	return b.String()
+}
+
+// imageSection formats the container image in HTML format
+func (r *htmlRenderer) imageSection(image *oci.Image) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, html.EscapeString(imageTitle(image)))
+	for _, line := range imageLines(image) {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// imageTitle returns the section title, such as "Container Image: alpine:3.20"
+func imageTitle(image *oci.Image) string {
+	return "Container Image: " + image.Reference
+}
+
+// imageLines lists the details and run configuration of an image as plain
+// text lines
+func imageLines(image *oci.Image) []string {
+	lines := []string{
+		"Digest: " + image.Digest,
+		"Platform: " + image.Platform,
+		fmt.Sprintf("Layers: %d", image.Layers),
+	}
+	config := image.Config
+	if len(config.Entrypoint) > 0 {
+		lines = append(lines, "Entrypoint: "+strings.Join(config.Entrypoint, " "))
+	}
+	if len(config.Cmd) > 0 {
+		lines = append(lines, "Cmd: "+strings.Join(config.Cmd, " "))
+	}
+	if config.WorkingDir != "" {
+		lines = append(lines, "Working directory: "+config.WorkingDir)
+	}
+	if config.User != "" {
+		lines = append(lines, "User: "+config.User)
+	}
+	for _, env := range config.Env {
+		lines = append(lines, "Env: "+env)
+	}
+	labels := make([]string, 0, len(config.Labels))
+	for key := range config.Labels {
+		labels = append(labels, key)
+	}
+	sort.Strings(labels)
+	for _, key := range labels {
+		lines = append(lines, fmt.Sprintf("Label: %s=%s", key, config.Labels[key]))
+	}
+	return lines
+}
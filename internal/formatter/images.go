@@ -0,0 +1,107 @@
+package formatter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"path/filepath"
+
+	"codectx/internal/images"
+	"codectx/internal/utils"
+)
+
+// formatImage writes a placeholder describing an image file in place of its content,
+// with an embedded thumbnail in HTML output when Images is images.ModeEmbed
+func (f *Formatter) formatImage(path, relativePath string) error {
+	info, err := images.ReadInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	placeholder := info.Placeholder(f.SizeUnits)
+	truncated := false
+	if f.SizeLimiter != nil && f.SizeLimiter.IsLimited() {
+		reservation, ok := f.SizeLimiter.ReserveForPath(relativePath, int64(len(placeholder)+1))
+		if ok {
+			reservation.Commit()
+		} else {
+			placeholder = f.SizeLimiter.GetTruncatedMessageFor(relativePath)
+			truncated = true
+		}
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.imageEntry(path, relativePath, info, placeholder, truncated)
+}
+
+// imageEntry writes the placeholder of an image under a header line
+func (r *textRenderer) imageEntry(path, relativePath string, info *images.Info, placeholder string, truncated bool) error {
+	r.writeTextFileHeader(relativePath)
+	_, err := fmt.Fprintln(r.Writer, placeholder)
+	return err
+}
+
+// imageEntry writes the placeholder of an image under its path
+func (r *markdownRenderer) imageEntry(path, relativePath string, info *images.Info, placeholder string, truncated bool) error {
+	_, err := fmt.Fprintf(r.Writer, "\n### %s\n%s\n", relativePath, placeholder)
+	return err
+}
+
+// imageEntry writes the placeholder of an image in a box under its path,
+// after a thumbnail when Images is images.ModeEmbed
+func (r *htmlRenderer) imageEntry(path, relativePath string, info *images.Info, placeholder string, truncated bool) error {
+	fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(relativePath))
+	if r.Images == images.ModeEmbed {
+		// Images that cannot be decoded (WebP, BMP, ICO) keep just the placeholder
+		if thumbnail, err := images.Thumbnail(path, images.DefaultThumbnailSize); err == nil {
+			fmt.Fprintf(r.Writer, "<img class=\"thumbnail\" src=\"data:image/png;base64,%s\" alt=\"%s\">\n",
+				base64.StdEncoding.EncodeToString(thumbnail), html.EscapeString(relativePath))
+		}
+	}
+	fmt.Fprintf(r.Writer, htmlOmittedLine, html.EscapeString(placeholder))
+	_, err := fmt.Fprint(r.Writer, htmlFileFooter)
+	return err
+}
+
+// imageEntry streams a file entry describing an image into the "files" array
+func (r *jsonRenderer) imageEntry(path, relativePath string, info *images.Info, placeholder string, truncated bool) error {
+	if err := r.start(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(path)
+	if ext != "" {
+		ext = ext[1:]
+	}
+
+	w := r.Writer
+	r.beginEntry()
+	writeJSONField(w, "", "path", path)
+	writeJSONField(w, ",", "relative_path", relativePath)
+	writeJSONField(w, ",", "type", "image")
+	writeJSONField(w, ",", "size_bytes", info.Size)
+	writeJSONField(w, ",", "extension", ext)
+	if mime, err := utils.DetectMIME(path); err == nil {
+		writeJSONField(w, ",", "mime_type", mime)
+	}
+	if r.keyFileSet[relativePath] {
+		writeJSONField(w, ",", "key_file", true)
+	}
+	writeJSONField(w, ",", "content", placeholder)
+	writeJSONField(w, ",", "image", info)
+	if truncated {
+		writeJSONField(w, ",", "truncated", true)
+	}
+	if _, err := fmt.Fprint(w, "\n    }"); err != nil {
+		return err
+	}
+
+	r.document.Metadata.TotalFiles++
+	r.document.Metadata.TotalSizeBytes += info.Size
+	r.document.Metadata.EstimatedTokens += len(placeholder) / 4
+	r.document.Metadata.Truncated = r.document.Metadata.Truncated || truncated
+	return nil
+}
@@ -0,0 +1,92 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/limits"
+)
+
+// Invocation records how an output was produced, so that whoever receives it
+// can run the same scan again
+type Invocation struct {
+	Command    string   `json:"command,omitempty"` // Shell command line ("" when codectx ran embedded)
+	Version    string   `json:"version"`           // codectx version
+	ConfigFile string   `json:"config_file,omitempty"`
+	ConfigHash string   `json:"config_sha256,omitempty"` // SHA-256 of the config file's content
+	Filters    []string `json:"filters,omitempty"`       // Options that chose the files, such as "--extensions go,md"
+}
+
+// SetInvocation records the command line, version, config file, and filters
+// of the run, so that they are listed at the end of the output and in JSON
+// metadata
+func (f *Formatter) SetInvocation(invocation *Invocation) {
+	f.invocation = invocation
+}
+
+// writeInvocation writes the invocation section at the end of text, Markdown,
+// and HTML output, and charges it to the metadata budget
+func (f *Formatter) writeInvocation() error {
+	r, ok := f.output.(contextRenderer)
+	if f.invocation == nil || !ok {
+		return nil
+	}
+	return f.writeSection(limits.CategoryMetadata, r.invocationSection(f.invocation))
+}
+
+// invocationSection formats the invocation in text format
+func (r *textRenderer) invocationSection(invocation *Invocation) string {
+	var b strings.Builder
+	b.WriteString("\nInvocation:\n")
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range invocationLines(invocation) {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// invocationSection formats the invocation in Markdown format
+func (r *markdownRenderer) invocationSection(invocation *Invocation) string {
+	var b strings.Builder
+	b.WriteString("\n## Invocation\n\n")
+	fmt.Fprintf(&b, "- Version: codectx %s\n", invocation.Version)
+	if invocation.ConfigFile != "" {
+		fmt.Fprintf(&b, "- Config: `%s` (SHA-256 `%s`)\n", invocation.ConfigFile, invocation.ConfigHash)
+	}
+	if len(invocation.Filters) > 0 {
+		fmt.Fprintf(&b, "- Filters: `%s`\n", strings.Join(invocation.Filters, "`, `"))
+	}
+	if invocation.Command != "" {
+		fence := codeFence(invocation.Command)
+		fmt.Fprintf(&b, "\n%sbash\n%s\n%s\n", fence, invocation.Command, fence)
+	}
+	return b.String()
+}
+
+// invocationSection formats the invocation in HTML format
+func (r *htmlRenderer) invocationSection(invocation *Invocation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, "Invocation")
+	for _, line := range invocationLines(invocation) {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// invocationLines lists the invocation as plain text lines
+func invocationLines(invocation *Invocation) []string {
+	var lines []string
+	if invocation.Command != "" {
+		lines = append(lines, "Command: "+invocation.Command)
+	}
+	lines = append(lines, "Version: codectx "+invocation.Version)
+	if invocation.ConfigFile != "" {
+		lines = append(lines, fmt.Sprintf("Config: %s (SHA-256 %s)", invocation.ConfigFile, invocation.ConfigHash))
+	}
+	if len(invocation.Filters) > 0 {
+		lines = append(lines, "Filters: "+strings.Join(invocation.Filters, "; "))
+	}
+	return lines
+}
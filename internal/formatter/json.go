@@ -3,152 +3,515 @@ package formatter
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io"
 	"path/filepath"
 	"time"
 
+	"codectx/internal/analysis"
+	"codectx/internal/coverage"
+	"codectx/internal/forge"
 	"codectx/internal/git"
+	"codectx/internal/limits"
+	"codectx/internal/minified"
+	"codectx/internal/oci"
+	"codectx/internal/platform"
+	"codectx/internal/policy"
+	"codectx/internal/scanner"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
 )
 
-// JSONOutput represents the structure of the JSON output
+// JSONOutput represents the structure of the JSON output. The formatter streams
+// the document in this field order and never holds Files in memory.
 type JSONOutput struct {
-	Metadata      JSONMetadata   `json:"metadata"`
-	DirectoryTree string         `json:"directory_tree"`
-	Files         []JSONFileInfo `json:"files"`
+	Header        string                    `json:"header,omitempty"`
+	DirectoryTree string                    `json:"directory_tree"`
+	Directories   []scanner.DirectoryRecord `json:"directories,omitempty"` // Set by --directory-records
+	Files         []JSONFileInfo            `json:"files"`
+	Inventory     []InventoryEntry          `json:"inventory,omitempty"` // The files of --summary output, which leaves out their contents
+	RepoMap       *analysis.RepoMap         `json:"repo_map,omitempty"`
+	APISurface    *analysis.APISurface      `json:"api_surface,omitempty"`
+	Xref          *analysis.XrefIndex       `json:"xref,omitempty"`
+	Footer        string                    `json:"footer,omitempty"`
+	Metadata      JSONMetadata              `json:"metadata"`
 }
 
 // JSONMetadata contains metadata about the scan
 type JSONMetadata struct {
-	TargetDirectory  string          `json:"target_directory"`
-	ScanTime         string          `json:"scan_time"`
-	TotalFiles       int             `json:"total_files"`
-	TotalDirectories int             `json:"total_directories"`
-	TotalSizeBytes   int64           `json:"total_size_bytes"`
-	EstimatedTokens  int             `json:"estimated_tokens"`
-	TextFiles        int             `json:"text_files"`
-	BinaryFiles      int             `json:"binary_files"`
-	ProcessingTime   string          `json:"processing_time,omitempty"`
-	Options          JSONScanOptions `json:"options"`
-	GitInfo          *git.GitInfo    `json:"git_info,omitempty"`
-	Truncated        bool            `json:"truncated,omitempty"`
+	TargetDirectory  string                    `json:"target_directory"`
+	ScanTime         string                    `json:"scan_time"`
+	TotalFiles       int                       `json:"total_files"`
+	TotalDirectories int                       `json:"total_directories"`
+	TotalSizeBytes   int64                     `json:"total_size_bytes"`
+	EstimatedTokens  int                       `json:"estimated_tokens"`
+	TextFiles        int                       `json:"text_files"`
+	BinaryFiles      int                       `json:"binary_files"`
+	ProcessingTime   string                    `json:"processing_time,omitempty"`
+	Options          JSONScanOptions           `json:"options"`
+	Invocation       *Invocation               `json:"invocation,omitempty"`
+	GitInfo          *git.GitInfo              `json:"git_info,omitempty"`
+	Truncated        bool                      `json:"truncated,omitempty"` // The character limit or a budget cut the output short
+	KeyFiles         []string                  `json:"key_files,omitempty"`
+	Stack            []analysis.StackComponent `json:"stack,omitempty"`
+	Functions        *analysis.FunctionReport  `json:"function_complexity,omitempty"`
+	Hotspots         []analysis.Hotspot        `json:"hotspots,omitempty"`
+	Ownership        *analysis.Ownership       `json:"ownership,omitempty"`
+	DeadFiles        []analysis.DeadFile       `json:"dead_files,omitempty"`
+	DocCoverage      *analysis.DocCoverage     `json:"doc_coverage,omitempty"`
+	Strings          []analysis.StringLiteral  `json:"strings,omitempty"`
+	ConfigInventory  *analysis.ConfigInventory `json:"config_inventory,omitempty"`
+	IaC              *analysis.IaCInventory    `json:"iac,omitempty"`
+	GoEmbeds         []analysis.GoEmbed        `json:"go_embeds,omitempty"`
+	Policy           *policy.Decision          `json:"policy,omitempty"`
+	History          []git.Commit              `json:"history,omitempty"` // Recent commits, newest first
+	PullRequest      *forge.PullRequest        `json:"pull_request,omitempty"`
+	Image            *oci.Image                `json:"image,omitempty"`
+	Changelog        *git.Changelog            `json:"changelog,omitempty"`
+	EmbeddedAssets   int                       `json:"embedded_assets_stripped,omitempty"`
+	ReclaimedTokens  int                       `json:"reclaimed_tokens,omitempty"`
+	PIIRedacted      *utils.PIICounts          `json:"pii_redacted,omitempty"`
+	DuplicateFiles   int                       `json:"duplicate_files,omitempty"`
+	MinifiedFiles    int                       `json:"minified_files,omitempty"`
+	LFSPointers      int                       `json:"lfs_pointers,omitempty"`
+	SkippedFiles     int                       `json:"skipped_files,omitempty"`
+	LinkGroups       [][]string                `json:"link_groups,omitempty"` // Paths of one physical file, included once
+	PermissionDenied []string                  `json:"permission_denied,omitempty"`
+	SparseCheckout   *git.SparseCheckout       `json:"sparse_checkout,omitempty"`
+	Sources          []MergedSource            `json:"sources,omitempty"` // Documents combined by "codectx merge"
 }
 
+// Orders of the files in the output, listed in JSONScanOptions.SortOrder
+const (
+	SortByPath         = "path"            // Path order of the directory tree
+	SortByNaturalPath  = "natural_path"    // Path order of the directory tree, numbers compared by value
+	SortKeyFilesFirst  = "key_files_first" // Key files moved first to fit the size limit
+	SortContractsFirst = "contracts_first" // API contracts moved first
+	SortPairedTests    = "paired_tests"    // Each test placed after the source it covers
+)
+
 // JSONScanOptions contains information about the scan options
 type JSONScanOptions struct {
 	IncludeLineNumbers bool     `json:"include_line_numbers"`
 	ExtensionsFilter   []string `json:"extensions_filter,omitempty"`
 	ExcludePatterns    []string `json:"exclude_patterns,omitempty"`
+	IncludeRegex       []string `json:"include_regex,omitempty"`
+	ExcludeRegex       []string `json:"exclude_regex,omitempty"`
 	Format             string   `json:"format"`
 	MaxFileSize        string   `json:"max_file_size,omitempty"`
 	CharacterLimit     int64    `json:"character_limit,omitempty"`
+	Budget             string   `json:"budget,omitempty"`
+	MinSize            string   `json:"min_size,omitempty"`
+	MaxSize            string   `json:"max_size,omitempty"`
+	ModifiedSince      string   `json:"modified_since,omitempty"`
 	IncludeDotfiles    bool     `json:"include_dotfiles"`
+	GitOnly            string   `json:"git_only,omitempty"` // "tracked" or "working"
+	RespectGitignore   bool     `json:"respect_gitignore"`
+	IncludeGitInfo     bool     `json:"include_git_info"`
+	GitStatus          bool     `json:"git_status"`
+	SortOrder          []string `json:"sort_order"` // SortByPath or SortByNaturalPath, then the reorderings applied to it
+	Tokenizer          string   `json:"tokenizer"`
 }
 
 // JSONFileInfo contains information about a file
 type JSONFileInfo struct {
-	Path         string `json:"path"`
-	RelativePath string `json:"relative_path"`
-	Type         string `json:"type"`
-	SizeBytes    int64  `json:"size_bytes"`
-	LineCount    int    `json:"line_count"`
-	Extension    string `json:"extension"`
-	Content      string `json:"content"`
-	Skipped      bool   `json:"skipped,omitempty"`
-	SkipReason   string `json:"skip_reason,omitempty"`
-	Truncated    bool   `json:"truncated,omitempty"`
-}
-
-// formatTreeJSON formats the directory tree in JSON format
-func (f *Formatter) formatTreeJSON(tree string) error {
-	// Store the tree for later use when we output the full JSON
+	Path          string                `json:"path"`
+	RelativePath  string                `json:"relative_path"`
+	Type          string                `json:"type"`
+	SizeBytes     int64                 `json:"size_bytes"`
+	LineCount     int                   `json:"line_count"`
+	Extension     string                `json:"extension"`
+	Content       string                `json:"content"`
+	Skipped       bool                  `json:"skipped,omitempty"`
+	SkipReason    string                `json:"skip_reason,omitempty"`
+	Truncated     bool                  `json:"truncated,omitempty"`
+	IncludedRange *ContentRange         `json:"included_range,omitempty"` // Set when Truncated
+	Error         string                `json:"error,omitempty"`
+	KeyFile       bool                  `json:"key_file,omitempty"`
+	DuplicateOf   string                `json:"duplicate_of,omitempty"`
+	MIMEType      string                `json:"mime_type,omitempty"`
+	Minified      *minified.Info        `json:"minified,omitempty"`
+	LFS           *git.LFSPointer       `json:"lfs,omitempty"`
+	Indent        *utils.IndentStyle    `json:"indent,omitempty"`
+	Coverage      *coverage.File        `json:"coverage,omitempty"`
+	Permissions   *platform.Permissions `json:"permissions,omitempty"`
+}
+
+// Reasons a file entry is Skipped, given as its SkipReason
+const (
+	SkipTooLarge = "too_large" // The file exceeds the maximum file size
+)
+
+// ContentRange is the head of a truncated file that its entry includes: lines
+// StartLine to EndLine, and bytes StartByte up to EndByte of the file after
+// any byte order mark. EndLine is 0 when no line was included.
+type ContentRange struct {
+	StartLine int   `json:"start_line"`
+	EndLine   int   `json:"end_line"`
+	StartByte int64 `json:"start_byte"`
+	EndByte   int64 `json:"end_byte"`
+}
+
+// lineEnding returns the terminator written after the current line: the line's
+// own ending, or the normalized one when EOL is set
+func (f *Formatter) lineEnding(reader *utils.LineReader) string {
+	ending := string(reader.Raw()[len(reader.Text()):])
+	if f.EOL == "" || ending == "" {
+		return ending
+	}
+	return utils.LineEnding(f.EOL)
+}
+
+// jsonRenderer streams a JSON document, whose metadata it collects as the
+// files are written
+type jsonRenderer struct {
+	*Formatter
+	document *JSONOutput // nil until the document has started
+	entries  int         // Entries written into the files array
+}
+
+// Begin opens the document and writes the header
+func (r *jsonRenderer) Begin() error {
+	r.chargePreamble(r.Header)
+	_, err := fmt.Fprint(r.Writer, "{")
+	if r.Header != "" {
+		writeJSONDocumentField(r.Writer, "header", r.Header)
+	}
+	return err
+}
+
+// Tree writes the directory tree and opens the files array. File entries are
+// streamed into it as they are formatted, and the metadata is written last.
+func (r *jsonRenderer) Tree(tree string) error {
 	metadata := JSONMetadata{
-		ScanTime: time.Now().Format(time.RFC3339),
-		Options: JSONScanOptions{
-			IncludeLineNumbers: f.ShowLineNumbers,
-		},
+		TargetDirectory: r.targetDirectory,
+		ScanTime:        time.Now().Format(time.RFC3339),
+		Options:         r.scanOptions,
+	}
+	metadata.Options.IncludeLineNumbers = r.ShowLineNumbers
+	if metadata.Options.Format == "" {
+		metadata.Options.Format = string(JSONFormat)
+	}
+	if len(metadata.Options.SortOrder) == 0 {
+		metadata.Options.SortOrder = []string{SortByPath}
 	}
 
 	// Add Git information if available
-	if f.GitInfo != nil {
-		metadata.GitInfo = f.GitInfo
+	if r.GitInfo != nil {
+		metadata.GitInfo = r.GitInfo
+	}
+	metadata.KeyFiles = r.keyFiles
+	metadata.Stack = r.stack
+	metadata.Functions = r.functions
+	metadata.Hotspots = r.hotspots
+	metadata.Ownership = r.ownership
+	metadata.DeadFiles = r.deadFiles
+	metadata.DocCoverage = r.docCoverage
+	metadata.Strings = r.userStrings
+	metadata.ConfigInventory = r.configInventory
+	metadata.IaC = r.iac
+	metadata.GoEmbeds = r.goEmbeds
+	metadata.Policy = r.policyDecision
+	metadata.History = r.history
+	metadata.PullRequest = r.pullRequest
+	metadata.Image = r.image
+	metadata.Changelog = r.fittedChangelog()
+	if metadata.Changelog != nil && r.SizeLimiter != nil {
+		r.SizeLimiter.Charge(limits.CategoryContent, int64(len(metadata.Changelog.Diff)))
 	}
+	metadata.LinkGroups = r.linkGroups
+	metadata.Invocation = r.invocation
 
-	f.jsonOutput = &JSONOutput{
+	r.document = &JSONOutput{
 		Metadata:      metadata,
 		DirectoryTree: tree,
-		Files:         []JSONFileInfo{},
+		Directories:   r.directories,
 	}
-	return nil
+
+	writeJSONDocumentField(r.Writer, "directory_tree", tree)
+	if r.directories != nil {
+		directories, err := json.MarshalIndent(r.directories, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal directories: %w", err)
+		}
+		fmt.Fprintf(r.Writer, "\n  \"directories\": %s,", directories)
+	}
+	_, err := fmt.Fprint(r.Writer, "\n  \"files\": [")
+	return err
+}
+
+// start opens the document with an empty tree if no tree was written, so that
+// files can be formatted on their own
+func (r *jsonRenderer) start() error {
+	if r.document != nil {
+		return nil
+	}
+	if err := r.Begin(); err != nil {
+		return err
+	}
+	return r.Tree("")
 }
 
-// formatFileContentJSON formats the content of a file in JSON format
-func (f *Formatter) formatFileContentJSON(path, relativePath string) error {
+// beginEntry starts the next entry of the files array
+func (r *jsonRenderer) beginEntry() {
+	separator := "\n"
+	if r.entries > 0 {
+		separator = ",\n"
+	}
+	fmt.Fprintf(r.Writer, "%s    {", separator)
+	r.entries++
+}
+
+// File streams a file entry into the "files" array, encoding the
+// content line by line so that large files are never loaded into memory at once
+func (r *jsonRenderer) File(path, relativePath string) error {
 	// Get file info
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := platform.Stat(r.Stat, path)
 	if err != nil {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// Read file content
-	content, err := ioutil.ReadFile(path)
+	file, err := r.openSource(path)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if err := r.start(); err != nil {
+		return err
 	}
 
-	// Count lines
+	// Get file extension
+	ext := filepath.Ext(path)
+	if ext != "" {
+		// Remove the leading dot
+		ext = ext[1:]
+	}
+
+	// Write the fields known up front
+	w := r.Writer
+	r.beginEntry()
+	writeJSONField(w, "", "path", path)
+	writeJSONField(w, ",", "relative_path", relativePath)
+	writeJSONField(w, ",", "type", "text")
+	writeJSONField(w, ",", "size_bytes", fileInfo.Size())
+	writeJSONField(w, ",", "extension", ext)
+	if mime, err := utils.DetectMIME(path); err == nil {
+		writeJSONField(w, ",", "mime_type", mime)
+	}
+	writeJSONField(w, ",", "permissions", platform.FilePermissions(fileInfo))
+	if r.keyFileSet[relativePath] {
+		writeJSONField(w, ",", "key_file", true)
+	}
+	indent := r.detectIndent(path)
+	if indent != nil {
+		writeJSONField(w, ",", "indent", indent)
+	}
+	if fileCoverage, ok := r.coverage.Lookup(relativePath); ok {
+		writeJSONField(w, ",", "coverage", fileCoverage)
+	}
+
+	// Stream the content, keeping the head of the file when a per-file cap or
+	// the total limit applies. Lines past either are still counted.
+	fmt.Fprint(w, ",\n      \"content\": \"")
+	reader := utils.NewLineReader(file, r.MaxLineLength)
+	fileCap := r.SizeLimiter.NewFileCap()
+	included := ContentRange{StartLine: 1}
+	limitReached := false
 	lineCount := 0
-	for _, b := range content {
-		if b == '\n' {
-			lineCount++
+	contentSize := 0
+	for reader.Scan() {
+		lineCount++
+		if limitReached {
+			continue
+		}
+		line := r.cleanLine(reader.Text(), indent)
+		if !fileCap.Allow(line) {
+			continue
 		}
+		content := line + r.lineEnding(reader)
+		if r.SizeLimiter != nil && r.SizeLimiter.IsLimited() {
+			reservation, ok := r.SizeLimiter.ReserveForPath(relativePath, int64(len(content)))
+			if !ok {
+				limitReached = true
+				continue
+			}
+			reservation.Commit()
+		}
+		writeJSONStringPart(w, content)
+		contentSize += len(content)
+		included.EndLine = lineCount
+		included.EndByte += int64(len(reader.Raw()))
 	}
-	// Add one for the last line if it doesn't end with a newline
-	if len(content) > 0 && content[len(content)-1] != '\n' {
-		lineCount++
+	if limitReached {
+		writeJSONStringPart(w, r.SizeLimiter.GetTruncatedMessageFor(relativePath)+"\n")
+	} else if fileCap.Truncated() {
+		writeJSONStringPart(w, fileCap.OmissionMessage()+"\n")
+	}
+	readErr := reader.Err()
+	message, tooLong := lineTooLongMessage(readErr)
+	if tooLong {
+		writeJSONStringPart(w, message+"\n")
+	}
+	fmt.Fprint(w, "\"")
+
+	// Write the fields known once the content has been read
+	writeJSONField(w, ",", "line_count", lineCount)
+	if limitReached || fileCap.Truncated() || tooLong {
+		writeJSONField(w, ",", "truncated", true)
+		writeJSONField(w, ",", "included_range", included)
+	}
+	if readErr != nil {
+		writeJSONField(w, ",", "error", readErr.Error())
+	}
+	if _, err := fmt.Fprint(w, "\n    }"); err != nil {
+		return err
+	}
+
+	r.document.Metadata.TotalFiles++
+	r.document.Metadata.TotalSizeBytes += fileInfo.Size()
+	r.document.Metadata.EstimatedTokens += contentSize / 4 // Rough estimate
+	if limitReached {
+		r.document.Metadata.Truncated = true
+	}
+
+	if readErr != nil {
+		return readError(relativePath, readErr)
+	}
+	return nil
+}
+
+// skippedEntry streams an entry for a file whose content was left out,
+// with the reason and the message written in its place
+func (r *jsonRenderer) skippedEntry(path, relativePath string, size int64, reason, message string) error {
+	if err := r.start(); err != nil {
+		return err
 	}
 
-	// Get file extension
 	ext := filepath.Ext(path)
 	if ext != "" {
-		// Remove the leading dot
 		ext = ext[1:]
 	}
 
-	// Add file info to the JSON output
-	fileEntry := JSONFileInfo{
-		Path:         path,
-		RelativePath: relativePath,
-		Type:         "text",
-		SizeBytes:    fileInfo.Size(),
-		LineCount:    lineCount,
-		Extension:    ext,
-		Content:      string(content),
+	w := r.Writer
+	r.beginEntry()
+	writeJSONField(w, "", "path", path)
+	writeJSONField(w, ",", "relative_path", relativePath)
+	writeJSONField(w, ",", "type", "text")
+	writeJSONField(w, ",", "size_bytes", size)
+	writeJSONField(w, ",", "extension", ext)
+	if r.keyFileSet[relativePath] {
+		writeJSONField(w, ",", "key_file", true)
 	}
+	writeJSONField(w, ",", "content", message)
+	writeJSONField(w, ",", "skipped", true)
+	writeJSONField(w, ",", "skip_reason", reason)
+	if _, err := fmt.Fprint(w, "\n    }"); err != nil {
+		return err
+	}
+
+	r.document.Metadata.TotalFiles++
+	r.document.Metadata.SkippedFiles++
+	return nil
+}
+
+// writeJSONDocumentField writes a top-level string field followed by a comma
+func writeJSONDocumentField(w io.Writer, key, value string) {
+	data, _ := json.Marshal(value)
+	fmt.Fprintf(w, "\n  %q: %s,", key, data)
+}
 
-	if f.jsonOutput != nil {
-		f.jsonOutput.Files = append(f.jsonOutput.Files, fileEntry)
-		f.jsonOutput.Metadata.TotalFiles++
-		f.jsonOutput.Metadata.TotalSizeBytes += fileEntry.SizeBytes
-		f.jsonOutput.Metadata.EstimatedTokens += len(content) / 4 // Rough estimate
+// writeJSONField writes one field of a streamed file entry, preceded by separator
+func writeJSONField(w io.Writer, separator, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte("null")
 	}
+	fmt.Fprintf(w, "%s\n      %q: %s", separator, key, data)
+}
 
+// writeJSONStringPart writes s escaped as part of an open JSON string
+func writeJSONStringPart(w io.Writer, s string) {
+	data, _ := json.Marshal(s)
+	w.Write(data[1 : len(data)-1])
+}
+
+// Stats replaces the totals estimated while streaming the files with those of
+// the statistics collector, so that they match --stats
+func (r *jsonRenderer) Stats(collector *stats.StatsCollector) error {
+	if r.document == nil {
+		return nil
+	}
+	metadata := &r.document.Metadata
+	metadata.TotalFiles = collector.TotalFiles
+	metadata.TotalDirectories = collector.TotalDirectories
+	metadata.TotalSizeBytes = collector.TotalSize
+	metadata.TextFiles = collector.TextFiles
+	metadata.BinaryFiles = collector.BinaryFiles
+	metadata.EstimatedTokens = collector.EstimatedTokens
+	metadata.ProcessingTime = fmt.Sprintf("%.3fs", collector.GetProcessingTime())
+	metadata.Options.Tokenizer = stats.Tokenizer
 	return nil
 }
 
-// finalizeJSON writes the complete JSON output
-func (f *Formatter) finalizeJSON() error {
-	if f.jsonOutput == nil {
+// End closes the files array and writes the metadata and the footer
+func (r *jsonRenderer) End() error {
+	if r.document == nil {
 		return fmt.Errorf("no JSON output to finalize")
 	}
+	r.chargePreamble(r.Footer)
+
+	r.document.Metadata.EmbeddedAssets, r.document.Metadata.ReclaimedTokens = r.EmbeddedData()
+	if r.RedactPII {
+		redacted := r.PIIRedacted()
+		r.document.Metadata.PIIRedacted = &redacted
+	}
+	r.document.Metadata.PermissionDenied = r.denied
+	r.document.Metadata.SparseCheckout = r.sparse
 
-	// Marshal the JSON output
-	jsonData, err := json.MarshalIndent(f.jsonOutput, "", "  ")
+	// The files array is closed on its own line when it has entries
+	closing := "],"
+	if r.entries > 0 {
+		closing = "\n  ],"
+	}
+
+	// Marshal the metadata at the document's indentation
+	metadata, err := json.MarshalIndent(r.document.Metadata, "  ", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	// Write the JSON output
-	_, err = fmt.Fprintln(f.Writer, string(jsonData))
+	fmt.Fprint(r.Writer, closing)
+	if r.document.Inventory != nil {
+		inventory, err := json.MarshalIndent(r.document.Inventory, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal inventory: %w", err)
+		}
+		fmt.Fprintf(r.Writer, "\n  \"inventory\": %s,", inventory)
+	}
+	if r.document.RepoMap != nil {
+		repoMap, err := json.MarshalIndent(r.document.RepoMap, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal repo map: %w", err)
+		}
+		fmt.Fprintf(r.Writer, "\n  \"repo_map\": %s,", repoMap)
+	}
+	if r.document.APISurface != nil {
+		apiSurface, err := json.MarshalIndent(r.document.APISurface, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal API surface: %w", err)
+		}
+		fmt.Fprintf(r.Writer, "\n  \"api_surface\": %s,", apiSurface)
+	}
+	if r.document.Xref != nil {
+		xref, err := json.MarshalIndent(r.document.Xref, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal xref: %w", err)
+		}
+		fmt.Fprintf(r.Writer, "\n  \"xref\": %s,", xref)
+	}
+	if r.Footer != "" {
+		writeJSONDocumentField(r.Writer, "footer", r.Footer)
+	}
+	_, err = fmt.Fprintf(r.Writer, "\n  \"metadata\": %s\n}\n", metadata)
 	return err
 }
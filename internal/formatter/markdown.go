@@ -1,125 +1,110 @@
 package formatter
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
+
+	"codectx/internal/language"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
 )
 
-// formatFileContentMarkdown formats the content of a file in Markdown format
-func (f *Formatter) formatFileContentMarkdown(path, relativePath string) error {
-	// Print the file header
-	fmt.Fprintf(f.Writer, "\n### %s\n", relativePath)
+// markdownRenderer writes Markdown output
+type markdownRenderer struct {
+	*Formatter
+}
 
-	// If the file has a specific extension, add it to the code block with proper language identifier
-	ext := filepath.Ext(relativePath)
-	langId := getLanguageIdentifier(ext)
-	fmt.Fprintf(f.Writer, "```%s\n", langId)
+// Begin writes the header
+func (r *markdownRenderer) Begin() error {
+	return r.writeHeader()
+}
 
-	// Open the file
-	file, err := os.Open(path)
+// Tree writes the directory tree and the sections that follow it
+func (r *markdownRenderer) Tree(tree string) error {
+	fmt.Fprintln(r.Writer, "# Project Structure")
+	fmt.Fprintln(r.Writer, "")
+	fmt.Fprintln(r.Writer, "## Directory Tree")
+	fmt.Fprintln(r.Writer, "```")
+	fmt.Fprintln(r.Writer, tree)
+	fmt.Fprintln(r.Writer, "```")
+	fmt.Fprint(r.Writer, r.treeSections())
+	fmt.Fprintln(r.Writer, "")
+	fmt.Fprintln(r.Writer, "## Files")
+	return nil
+}
+
+// File writes the content of a file as a code block labeled with its language
+func (r *markdownRenderer) File(path, relativePath string) error {
+	// Open the file first, so that an unreadable file leaves no partial entry
+	file, err := r.openSource(path)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	// Print the file header
+	fmt.Fprintf(r.Writer, "\n### %s\n", r.fileLabel(relativePath))
+
+	// Label the code block with the file's language
+	fmt.Fprintf(r.Writer, "```%s\n", language.FenceFile(path))
+
 	// Read the file line by line
-	scanner := bufio.NewScanner(file)
+	indent := r.detectIndent(path)
+	scanner := utils.NewLineReader(file, r.MaxLineLength)
+	fileCap := r.SizeLimiter.NewFileCap()
+	limitReached := false
 	lineNum := 1
 	for scanner.Scan() {
-		line := scanner.Text()
-		if f.ShowLineNumbers {
-			fmt.Fprintf(f.Writer, "%d | %s\n", lineNum, line)
-		} else {
-			fmt.Fprintln(f.Writer, line)
+		line := r.cleanLine(scanner.Text(), indent)
+		if !fileCap.Allow(line) {
+			continue
+		}
+		formattedLine := line + "\n"
+		if r.ShowLineNumbers {
+			formattedLine = fmt.Sprintf("%d | %s\n", lineNum, line)
 		}
+
+		// Stop at the total size limit, noting it after the code block
+		if r.SizeLimiter != nil && r.SizeLimiter.IsLimited() {
+			reservation, ok := r.SizeLimiter.ReserveForPath(relativePath, int64(len(formattedLine)))
+			if !ok {
+				limitReached = true
+				break
+			}
+			reservation.Commit()
+		}
+		fmt.Fprint(r.Writer, formattedLine)
 		lineNum++
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+	if fileCap.Truncated() {
+		fmt.Fprintln(r.Writer, fileCap.OmissionMessage())
 	}
 
-	// Close the code block
-	fmt.Fprintln(f.Writer, "```")
+	// Close the code block even when reading stopped early
+	readErr := scanner.Err()
+	if message, ok := lineTooLongMessage(readErr); ok {
+		fmt.Fprintln(r.Writer, message)
+	}
+	fmt.Fprintln(r.Writer, "```")
+	if limitReached {
+		fmt.Fprint(r.Writer, markdownNotice(noticeTruncated, r.SizeLimiter.GetTruncatedMessageFor(relativePath)))
+	}
 
+	if readErr != nil {
+		return readError(relativePath, readErr)
+	}
 	return nil
 }
 
-// formatTreeMarkdown formats the directory tree in Markdown format
-func (f *Formatter) formatTreeMarkdown(tree string) error {
-	fmt.Fprintln(f.Writer, "# Project Structure")
-	fmt.Fprintln(f.Writer, "")
-	fmt.Fprintln(f.Writer, "## Directory Tree")
-	fmt.Fprintln(f.Writer, "```")
-	fmt.Fprintln(f.Writer, tree)
-	fmt.Fprintln(f.Writer, "```")
-	fmt.Fprintln(f.Writer, "")
-	fmt.Fprintln(f.Writer, "## Files")
+// Stats leaves the statistics to --stats, which prints them separately
+func (r *markdownRenderer) Stats(*stats.StatsCollector) error {
 	return nil
 }
 
-// getLanguageIdentifier returns the appropriate language identifier for syntax highlighting
-func getLanguageIdentifier(ext string) string {
-	if ext == "" {
-		return ""
+// End writes the invocation and the footer
+func (r *markdownRenderer) End() error {
+	if err := r.writeInvocation(); err != nil {
+		return err
 	}
-
-	// Remove the leading dot
-	ext = ext[1:]
-
-	// Map common extensions to language identifiers
-	langMap := map[string]string{
-		"go":         "go",
-		"js":         "javascript",
-		"ts":         "typescript",
-		"py":         "python",
-		"java":       "java",
-		"c":          "c",
-		"cpp":        "cpp",
-		"cc":         "cpp",
-		"cxx":        "cpp",
-		"h":          "c",
-		"hpp":        "cpp",
-		"cs":         "csharp",
-		"php":        "php",
-		"rb":         "ruby",
-		"rs":         "rust",
-		"kt":         "kotlin",
-		"swift":      "swift",
-		"scala":      "scala",
-		"sh":         "bash",
-		"bash":       "bash",
-		"zsh":        "bash",
-		"fish":       "bash",
-		"ps1":        "powershell",
-		"sql":        "sql",
-		"html":       "html",
-		"htm":        "html",
-		"xml":        "xml",
-		"css":        "css",
-		"scss":       "scss",
-		"sass":       "sass",
-		"less":       "less",
-		"json":       "json",
-		"yaml":       "yaml",
-		"yml":        "yaml",
-		"toml":       "toml",
-		"ini":        "ini",
-		"cfg":        "ini",
-		"conf":       "ini",
-		"md":         "markdown",
-		"txt":        "text",
-		"log":        "text",
-		"gitignore":  "gitignore",
-		"dockerfile": "dockerfile",
-		"makefile":   "makefile",
-	}
-
-	if lang, exists := langMap[ext]; exists {
-		return lang
-	}
-
-	return ext
+	return r.writeFooter()
 }
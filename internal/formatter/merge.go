@@ -0,0 +1,152 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"strings"
+	"time"
+
+	"codectx/internal/git"
+	"codectx/internal/scanner"
+)
+
+// MergeInput is a JSON document to combine with others, and the label of the
+// root directory its files are placed under
+type MergeInput struct {
+	Label    string
+	Document *JSONOutput
+}
+
+// MergedSource records a document combined into a merged output
+type MergedSource struct {
+	Label           string       `json:"label"`
+	TargetDirectory string       `json:"target_directory,omitempty"`
+	ScanTime        string       `json:"scan_time,omitempty"`
+	TotalFiles      int          `json:"total_files"`
+	GitInfo         *git.GitInfo `json:"git_info,omitempty"`
+	Invocation      *Invocation  `json:"invocation,omitempty"`
+}
+
+// Merge combines JSON documents, such as the outputs for several
+// repositories, into one. Each document's tree and files are placed under a
+// root directory named by its label, and text files with the same content as
+// an earlier file become duplicate stubs. Sections that describe a single
+// scan, such as the history and the repository map, are not carried over;
+// the metadata lists each document's Git information under sources instead.
+func Merge(inputs []MergeInput, chars scanner.TreeChars) *JSONOutput {
+	merged := &JSONOutput{
+		Files: []JSONFileInfo{},
+		Metadata: JSONMetadata{
+			ScanTime: time.Now().Format(time.RFC3339),
+		},
+	}
+	metadata := &merged.Metadata
+	originals := make(map[[sha256.Size]byte]string)
+	var tree strings.Builder
+	root := scanner.DirectoryRecord{Path: ".", Directories: len(inputs)}
+
+	for i, input := range inputs {
+		doc, label := input.Document, input.Label
+		prefix := func(path string) string {
+			return label + "/" + path
+		}
+
+		// Draw the document's tree below its labeled root
+		connector, indent := chars.Branch, chars.Vertical
+		if i == len(inputs)-1 {
+			connector, indent = chars.Last, chars.Space
+		}
+		tree.WriteString(connector + label + "/\n")
+		for _, line := range strings.SplitAfter(doc.DirectoryTree, "\n") {
+			if line == "" {
+				continue
+			}
+			tree.WriteString(indent + line)
+			if !strings.HasSuffix(line, "\n") {
+				tree.WriteString("\n")
+			}
+		}
+
+		// The document's root directory becomes its labeled directory
+		for _, record := range doc.Directories {
+			if record.Path == "." {
+				record.Path = label
+				root.TotalFiles += record.TotalFiles
+				root.SizeBytes += record.SizeBytes
+			} else {
+				record.Path = prefix(record.Path)
+			}
+			merged.Directories = append(merged.Directories, record)
+		}
+
+		for _, file := range doc.Files {
+			file.Path = prefix(file.RelativePath)
+			file.RelativePath = file.Path
+			if file.DuplicateOf != "" {
+				file.DuplicateOf = prefix(file.DuplicateOf)
+				file.Content = DuplicateStub(file.DuplicateOf)
+			}
+			if file.Type == "text" && file.Content != "" {
+				sum := sha256.Sum256([]byte(file.Content))
+				if original, ok := originals[sum]; ok {
+					stub := DuplicateStub(original)
+					metadata.EstimatedTokens += len(stub)/4 - len(file.Content)/4
+					metadata.DuplicateFiles++
+					file = JSONFileInfo{
+						Path:         file.Path,
+						RelativePath: file.RelativePath,
+						Type:         "duplicate",
+						Extension:    file.Extension,
+						Content:      stub,
+						KeyFile:      file.KeyFile,
+						DuplicateOf:  original,
+					}
+				} else {
+					originals[sum] = file.RelativePath
+				}
+			}
+			merged.Files = append(merged.Files, file)
+		}
+
+		source := doc.Metadata
+		metadata.TotalFiles += source.TotalFiles
+		metadata.TotalDirectories += source.TotalDirectories
+		metadata.TotalSizeBytes += source.TotalSizeBytes
+		metadata.EstimatedTokens += source.EstimatedTokens
+		metadata.TextFiles += source.TextFiles
+		metadata.BinaryFiles += source.BinaryFiles
+		metadata.Truncated = metadata.Truncated || source.Truncated
+		metadata.EmbeddedAssets += source.EmbeddedAssets
+		metadata.ReclaimedTokens += source.ReclaimedTokens
+		metadata.DuplicateFiles += source.DuplicateFiles
+		metadata.MinifiedFiles += source.MinifiedFiles
+		metadata.LFSPointers += source.LFSPointers
+		metadata.SkippedFiles += source.SkippedFiles
+		for _, path := range source.KeyFiles {
+			metadata.KeyFiles = append(metadata.KeyFiles, prefix(path))
+		}
+		for _, group := range source.LinkGroups {
+			paths := make([]string, len(group))
+			for j, path := range group {
+				paths[j] = prefix(path)
+			}
+			metadata.LinkGroups = append(metadata.LinkGroups, paths)
+		}
+		for _, path := range source.PermissionDenied {
+			metadata.PermissionDenied = append(metadata.PermissionDenied, prefix(path))
+		}
+		metadata.Sources = append(metadata.Sources, MergedSource{
+			Label:           label,
+			TargetDirectory: source.TargetDirectory,
+			ScanTime:        source.ScanTime,
+			TotalFiles:      source.TotalFiles,
+			GitInfo:         source.GitInfo,
+			Invocation:      source.Invocation,
+		})
+	}
+
+	merged.DirectoryTree = tree.String()
+	if merged.Directories != nil {
+		merged.Directories = append([]scanner.DirectoryRecord{root}, merged.Directories...)
+	}
+	return merged
+}
@@ -0,0 +1,66 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Kinds of notices written where a file's content was left out
+const (
+	noticeSkipped   = "skipped"   // The whole file was left out, such as for exceeding the maximum file size
+	noticeTruncated = "truncated" // The limit stopped the file partway
+)
+
+// markdownNotice renders a message such as the size limiter's as a GitHub
+// admonition block, outside the file's code block
+func markdownNotice(kind, message string) string {
+	label := "NOTE"
+	if kind == noticeSkipped {
+		label = "WARNING"
+	}
+	return fmt.Sprintf("\n> [!%s]\n> %s\n", label, noticeText(message))
+}
+
+// htmlNotice renders a message such as the size limiter's as a banner in the
+// file's box, styled by its kind
+func htmlNotice(kind, message string) string {
+	return fmt.Sprintf("<div class=\"notice %s\">%s</div>\n", kind, html.EscapeString(noticeText(message)))
+}
+
+// noticeText drops the brackets that set a message apart in text output
+func noticeText(message string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(message, "["), "]")
+}
+
+// formatSkipped writes a notice in place of a file left out entirely, such as
+// one over the maximum file size; in JSON output the entry is marked skipped
+// with the reason
+func (f *Formatter) formatSkipped(path, relativePath string, size int64, reason, message string) error {
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.skippedEntry(path, relativePath, size, reason, message)
+}
+
+// skippedEntry writes the notice for a skipped file under a header line
+func (r *textRenderer) skippedEntry(path, relativePath string, size int64, reason, message string) error {
+	r.writeTextFileHeader(relativePath)
+	_, err := fmt.Fprintln(r.Writer, message)
+	return err
+}
+
+// skippedEntry writes the notice for a skipped file as an admonition under its path
+func (r *markdownRenderer) skippedEntry(path, relativePath string, size int64, reason, message string) error {
+	_, err := fmt.Fprintf(r.Writer, "\n### %s\n%s", r.fileLabel(relativePath), markdownNotice(noticeSkipped, message))
+	return err
+}
+
+// skippedEntry writes the notice for a skipped file as a banner in its box
+func (r *htmlRenderer) skippedEntry(path, relativePath string, size int64, reason, message string) error {
+	fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(r.fileLabel(relativePath)))
+	fmt.Fprint(r.Writer, htmlNotice(noticeSkipped, message))
+	_, err := fmt.Fprint(r.Writer, htmlFileFooter)
+	return err
+}
@@ -0,0 +1,124 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"text/template"
+
+	"codectx/internal/limits"
+)
+
+// TemplateData holds the values available to --header-file and --footer-file templates
+type TemplateData struct {
+	ProjectName string // Base name of the target directory
+	TargetDir   string
+	Format      string
+	Date        string // Generation date as YYYY-MM-DD
+	TotalFiles  int    // Files included in the output
+	TotalSize   int64  // Combined size of the included files in bytes
+	TotalTokens int    // Estimated tokens of the included files
+}
+
+// ParseVars parses "key=value" pairs given with --var
+func ParseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isTemplateIdentifier(key) {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// RenderTemplateFile renders the template at path with the built-in data and user
+// variables, so that both {{.TotalTokens}} and {{.reviewer}} can be used
+func RenderTemplateFile(path string, data TemplateData, vars map[string]string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	values := map[string]interface{}{
+		"ProjectName": data.ProjectName,
+		"TargetDir":   data.TargetDir,
+		"Format":      data.Format,
+		"Date":        data.Date,
+		"TotalFiles":  data.TotalFiles,
+		"TotalSize":   data.TotalSize,
+		"TotalTokens": data.TotalTokens,
+	}
+	for key, value := range vars {
+		if _, builtin := values[key]; builtin {
+			return "", fmt.Errorf("--var %s conflicts with a built-in template variable", key)
+		}
+		values[key] = value
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", path, err)
+	}
+	return strings.TrimRight(out.String(), "\n"), nil
+}
+
+// isTemplateIdentifier reports whether key can be referenced as {{.key}}
+func isTemplateIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeHeader writes the user-supplied header ahead of the generated context
+// in text and Markdown output
+func (f *Formatter) writeHeader() error {
+	if f.Header == "" {
+		return nil
+	}
+	f.chargePreamble(f.Header)
+	_, err := fmt.Fprintf(f.Writer, "%s\n\n", f.Header)
+	return err
+}
+
+// writeFooter writes the user-supplied footer after the generated context in
+// text and Markdown output
+func (f *Formatter) writeFooter() error {
+	if f.Footer == "" {
+		return nil
+	}
+	f.chargePreamble(f.Footer)
+	_, err := fmt.Fprintf(f.Writer, "\n%s\n", f.Footer)
+	return err
+}
+
+// chargePreamble records a header or footer, if set, as metadata, which
+// doesn't count against the total limit
+func (f *Formatter) chargePreamble(text string) {
+	if f.SizeLimiter != nil && text != "" {
+		f.SizeLimiter.Charge(limits.CategoryMetadata, int64(len(text)))
+	}
+}
+
+// htmlPreamble returns a header or footer as an HTML block, or "" if unset
+func htmlPreamble(text string) string {
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf("        <pre class=\"preamble\">%s</pre>\n", html.EscapeString(text))
+}
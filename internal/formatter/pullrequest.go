@@ -0,0 +1,135 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/forge"
+)
+
+// SetPullRequest records the pull request of "codectx pr" so that its details,
+// changed files, and comments are listed after the directory tree and in JSON
+// metadata
+func (f *Formatter) SetPullRequest(pr *forge.PullRequest) {
+	f.pullRequest = pr
+}
+
+// pullRequestSection formats a pull request in text format
+func (r *textRenderer) pullRequestSection(pr *forge.PullRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n", pullRequestTitle(pr))
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range pullRequestLines(pr) {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// pullRequestSection formats a pull request in Markdown format
+func (r *markdownRenderer) pullRequestSection(pr *forge.PullRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", pullRequestTitle(pr))
+	fmt.Fprintf(&b, "- URL: %s\n", pr.URL)
+	fmt.Fprintf(&b, "- Author: %s\n", pr.Author)
+	fmt.Fprintf(&b, "- State: %s\n", pr.State)
+	fmt.Fprintf(&b, "- Branches: `%s` into `%s` (%s)\n", pr.HeadBranch, pr.BaseBranch, shortCommit(pr.HeadCommit))
+	if body := strings.TrimSpace(pr.Body); body != "" {
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+
+	b.WriteString("\n### Changed Files\n\n")
+	for _, file := range pr.Files {
+		fmt.Fprintf(&b, "- %s `%s`%s (+%d -%d)\n", file.Status, file.Path, renamedFrom(file, "`"), file.Additions, file.Deletions)
+	}
+
+	if len(pr.Comments) > 0 {
+		b.WriteString("\n### Comments\n")
+		for _, comment := range pr.Comments {
+			fmt.Fprintf(&b, "\n**%s**", comment.Author)
+			if comment.Path != "" {
+				fmt.Fprintf(&b, " on `%s`", commentLocation(comment))
+			}
+			fmt.Fprintf(&b, " (%s):\n\n", comment.Created.Format("2006-01-02"))
+			for _, line := range strings.Split(strings.TrimSpace(comment.Body), "\n") {
+				fmt.Fprintf(&b, "> %s\n", strings.TrimRight(line, "\r"))
+			}
+		}
+	}
+	return b.String()
+}
+
+// pullRequestSection formats a pull request in HTML format
+func (r *htmlRenderer) pullRequestSection(pr *forge.PullRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, html.EscapeString(pullRequestTitle(pr)))
+	for _, line := range pullRequestLines(pr) {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	return b.String()
+}
+
+// pullRequestTitle returns the section title, such as "Pull Request #12: Fix parser"
+func pullRequestTitle(pr *forge.PullRequest) string {
+	return fmt.Sprintf("Pull Request #%d: %s", pr.Number, pr.Title)
+}
+
+// pullRequestLines lists the details, changed files, and comments of a pull
+// request as plain text lines
+func pullRequestLines(pr *forge.PullRequest) []string {
+	lines := []string{
+		"URL: " + pr.URL,
+		"Author: " + pr.Author,
+		"State: " + pr.State,
+		fmt.Sprintf("Branches: %s into %s (%s)", pr.HeadBranch, pr.BaseBranch, shortCommit(pr.HeadCommit)),
+	}
+	if body := strings.TrimSpace(pr.Body); body != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")...)
+	}
+
+	lines = append(lines, "", "Changed files:")
+	for _, file := range pr.Files {
+		lines = append(lines, fmt.Sprintf("  %s %s%s (+%d -%d)", file.Status, file.Path, renamedFrom(file, ""), file.Additions, file.Deletions))
+	}
+
+	if len(pr.Comments) > 0 {
+		lines = append(lines, "", "Comments:")
+		for _, comment := range pr.Comments {
+			header := "  " + comment.Author
+			if comment.Path != "" {
+				header += " on " + commentLocation(comment)
+			}
+			lines = append(lines, fmt.Sprintf("%s (%s):", header, comment.Created.Format("2006-01-02")))
+			for _, line := range strings.Split(strings.TrimSpace(comment.Body), "\n") {
+				lines = append(lines, "    "+strings.TrimRight(line, "\r"))
+			}
+		}
+	}
+	return lines
+}
+
+// renamedFrom notes the previous path of a renamed file, such as " (from lexer.go)"
+func renamedFrom(file forge.ChangedFile, quote string) string {
+	if file.PreviousPath == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (from %s%s%s)", quote, file.PreviousPath, quote)
+}
+
+// commentLocation returns the file and line a review comment is on, such as "parse.go:42"
+func commentLocation(comment forge.Comment) string {
+	if comment.Line == 0 {
+		return comment.Path
+	}
+	return fmt.Sprintf("%s:%d", comment.Path, comment.Line)
+}
+
+// shortCommit abbreviates a commit hash
+func shortCommit(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Render formats a document written in the JSON format again in f's format,
+// from the contents it holds rather than the scanned files, so that one scan
+// can be presented in several formats. Beyond MaxMemory bytes, the contents
+// are kept in a temporary file until they are formatted. The caller closes f
+// afterwards.
+func (f *Formatter) Render(r io.Reader) error {
+	store := newContentStore(f.MaxMemory)
+	defer store.close()
+	doc, err := decodeDocument(r, store)
+	if err != nil {
+		return fmt.Errorf("invalid JSON input: %w", err)
+	}
+	return f.renderDocument(doc, store)
+}
+
+// RenderDocument formats a decoded JSON document in f's format, as Render does
+func (f *Formatter) RenderDocument(doc *JSONOutput) error {
+	store := newContentStore(0)
+	for _, file := range doc.Files {
+		store.put(file.Path, file.Content)
+	}
+	return f.renderDocument(doc, store)
+}
+
+// renderDocument formats a decoded JSON document whose file contents are read
+// from store
+func (f *Formatter) renderDocument(doc *JSONOutput, store *contentStore) error {
+	if f.Format == JSONFormat {
+		return errors.New("the input is already JSON; choose text, markdown, or html")
+	}
+
+	// Restore what the scan recorded in the metadata
+	metadata := doc.Metadata
+	f.Header, f.Footer = doc.Header, doc.Footer
+	f.GitInfo = metadata.GitInfo
+	f.SetKeyFiles(metadata.KeyFiles)
+	f.SetStack(metadata.Stack)
+	f.SetHistory(metadata.History)
+	f.SetPullRequest(metadata.PullRequest)
+	f.SetImage(metadata.Image)
+	f.SetChangelog(metadata.Changelog)
+	f.SetGoEmbeds(metadata.GoEmbeds)
+	f.SetLinkGroups(metadata.LinkGroups)
+	f.SetDenied(metadata.PermissionDenied)
+	f.SetSparseCheckout(metadata.SparseCheckout)
+	f.SetInvocation(metadata.Invocation)
+	f.SetScanOptions(metadata.Options)
+	f.Source = store.open
+
+	if err := f.FormatTree(doc.DirectoryTree); err != nil {
+		return err
+	}
+	for _, file := range doc.Files {
+		var err error
+		if file.Skipped {
+			// Skipped files hold the notice written in place of their content
+			err = f.formatSkipped(file.Path, file.RelativePath, file.SizeBytes, file.SkipReason, file.Content)
+		} else if file.Type == "text" {
+			err = f.FormatFileContent(file.Path, file.RelativePath)
+		} else {
+			// Duplicates, minified assets, images, and LFS pointers hold their placeholder
+			err = f.formatStub(file.Path, file.RelativePath, file.Content, file.Type, "", nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if doc.RepoMap != nil {
+		if err := f.FormatRepoMap(doc.RepoMap); err != nil {
+			return err
+		}
+	}
+	if doc.APISurface != nil {
+		if err := f.FormatAPISurface(doc.APISurface); err != nil {
+			return err
+		}
+	}
+	if doc.Xref != nil {
+		if err := f.FormatXref(doc.Xref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
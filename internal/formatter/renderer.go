@@ -0,0 +1,115 @@
+package formatter
+
+import (
+	"fmt"
+
+	"codectx/internal/analysis"
+	"codectx/internal/forge"
+	"codectx/internal/git"
+	"codectx/internal/images"
+	"codectx/internal/limits"
+	"codectx/internal/oci"
+	"codectx/internal/stats"
+)
+
+// Renderer writes the output in one format. The Formatter handles what the
+// formats share, such as the size limits, cleaning lines, and the placeholders
+// for images and duplicates, and calls its renderer in order: Begin, Tree, File
+// for each text file, Stats when statistics were collected, and End.
+type Renderer interface {
+	Begin() error                                // Start the document, with the user-supplied header
+	Tree(tree string) error                      // Write the directory tree and the sections that follow it
+	File(path, relativePath string) error        // Write the content of a text file
+	Stats(collector *stats.StatsCollector) error // Report the statistics of the scan
+	End() error                                  // Finish the document, with the invocation and footer
+}
+
+// sectionRenderer is implemented by renderers that write what the Formatter
+// adds to the tree and file contents: the placeholders in place of a file's
+// content, and the sections of --summary, --api-surface, --repo-map, and
+// --xref. A renderer that doesn't implement it leaves them out.
+type sectionRenderer interface {
+	imageEntry(path, relativePath string, info *images.Info, placeholder string, truncated bool) error
+	stubEntry(path, relativePath, stub string, truncated bool, entryType, extraKey string, extraValue interface{}) error
+	skippedEntry(path, relativePath string, size int64, reason, message string) error
+	summary(collector *stats.StatsCollector, inventory []InventoryEntry) error
+	apiSurface(surface *analysis.APISurface) error
+	repoMap(repoMap *analysis.RepoMap) error
+	xref(index *analysis.XrefIndex) error
+}
+
+// contextRenderer is implemented by renderers of documents for reading, which
+// list the recorded context, such as the changelog, in sections of their own;
+// JSON output carries it in the metadata instead. Each method returns the
+// section so that the Formatter can charge it before it is written.
+type contextRenderer interface {
+	imageSection(image *oci.Image) string
+	pullRequestSection(pr *forge.PullRequest) string
+	changelogSection(changelog *git.Changelog) string
+	historySection(commits []git.Commit) string
+	goEmbedsSection(embeds []analysis.GoEmbed) string
+	invocationSection(invocation *Invocation) string
+}
+
+// RendererFactory creates the renderer writing a formatter's output. The
+// renderer reads the formatter's settings, such as Writer and ShowLineNumbers.
+type RendererFactory func(f *Formatter) Renderer
+
+// renderers maps each output format to the factory of its renderer
+var renderers = make(map[OutputFormat]RendererFactory)
+
+// RegisterRenderer makes an output format available to NewFormatter and
+// --format. It panics if the format is already registered.
+func RegisterRenderer(format OutputFormat, factory RendererFactory) {
+	if _, ok := renderers[format]; ok {
+		panic("formatter: RegisterRenderer called twice for " + string(format))
+	}
+	renderers[format] = factory
+}
+
+func init() {
+	RegisterRenderer(TextFormat, func(f *Formatter) Renderer { return &textRenderer{f} })
+	RegisterRenderer(MarkdownFormat, func(f *Formatter) Renderer { return &markdownRenderer{f} })
+	RegisterRenderer(HTMLFormat, func(f *Formatter) Renderer { return &htmlRenderer{f} })
+	RegisterRenderer(JSONFormat, func(f *Formatter) Renderer { return &jsonRenderer{Formatter: f} })
+}
+
+// renderer returns the renderer of f's format, created on first use
+func (f *Formatter) renderer() (Renderer, error) {
+	if f.output == nil {
+		factory, ok := renderers[f.Format]
+		if !ok {
+			return nil, fmt.Errorf("format not implemented: %s", f.Format)
+		}
+		f.output = factory(f)
+	}
+	return f.output, nil
+}
+
+// sections returns the renderer of f's format when it writes sections, or nil
+func (f *Formatter) sections() (sectionRenderer, error) {
+	r, err := f.renderer()
+	if err != nil {
+		return nil, err
+	}
+	sections, _ := r.(sectionRenderer)
+	return sections, nil
+}
+
+// writeSection charges a section to the budget and writes it
+func (f *Formatter) writeSection(category limits.Category, section string) error {
+	if f.SizeLimiter != nil {
+		f.SizeLimiter.Charge(category, int64(len(section)))
+	}
+	_, err := fmt.Fprint(f.Writer, section)
+	return err
+}
+
+// jsonDocument returns the JSON document being streamed, or nil when the
+// output is not JSON or hasn't started
+func (f *Formatter) jsonDocument() *JSONOutput {
+	if r, ok := f.output.(*jsonRenderer); ok {
+		return r.document
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"codectx/internal/analysis"
+	"codectx/internal/language"
+	"codectx/internal/limits"
+)
+
+// FormatRepoMap formats a repository map in place of the file contents
+func (f *Formatter) FormatRepoMap(repoMap *analysis.RepoMap) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatRepoMap(repoMap) }); err != nil {
+		return err
+	}
+
+	if f.SizeLimiter != nil {
+		f.SizeLimiter.Charge(limits.CategoryContent, int64(repoMap.Tokens*4))
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.repoMap(repoMap)
+}
+
+// repoMap formats a repository map in text format
+func (r *textRenderer) repoMap(repoMap *analysis.RepoMap) error {
+	fmt.Fprintln(r.Writer, "\nRepository Map:")
+	fmt.Fprintln(r.Writer, "--------------------------------------------------------------------------------")
+	if repoMap.Workspaces != nil {
+		fmt.Fprintf(r.Writer, "%s:\n", workspacesLabel(repoMap.Workspaces))
+		for _, line := range workspaceLines(repoMap.Workspaces) {
+			fmt.Fprintf(r.Writer, "      | %s\n", line)
+		}
+	}
+	for _, contract := range repoMap.Contracts {
+		fmt.Fprintf(r.Writer, "%s:\n", contractLabel(contract))
+		for _, operation := range contract.Operations {
+			fmt.Fprintf(r.Writer, "      | %s\n", operation)
+		}
+	}
+	for _, file := range repoMap.Files {
+		fmt.Fprintf(r.Writer, "%s:\n", repoMapLabel(file))
+		for _, sig := range file.Signatures {
+			fmt.Fprintf(r.Writer, "%5d | %s\n", sig.Line, sig.Text)
+		}
+	}
+	if repoMap.Omitted > 0 {
+		fmt.Fprintln(r.Writer, repoMapOmittedMessage(repoMap))
+	}
+	return nil
+}
+
+// repoMap formats a repository map in Markdown format
+func (r *markdownRenderer) repoMap(repoMap *analysis.RepoMap) error {
+	fmt.Fprintln(r.Writer, "\n## Repository Map")
+	if repoMap.Workspaces != nil {
+		fmt.Fprintf(r.Writer, "\n### %s\n", workspacesLabel(repoMap.Workspaces))
+		fmt.Fprintln(r.Writer)
+		for _, line := range workspaceLines(repoMap.Workspaces) {
+			fmt.Fprintf(r.Writer, "- %s\n", line)
+		}
+	}
+	if len(repoMap.Contracts) > 0 {
+		fmt.Fprintln(r.Writer, "\n### Contracts")
+		fmt.Fprintln(r.Writer)
+		for _, contract := range repoMap.Contracts {
+			fmt.Fprintf(r.Writer, "- `%s` (%s)\n", contract.Path, contract.Kind)
+			for _, operation := range contract.Operations {
+				fmt.Fprintf(r.Writer, "  - `%s`\n", operation)
+			}
+		}
+	}
+	for _, file := range repoMap.Files {
+		fmt.Fprintf(r.Writer, "\n### %s\n", repoMapLabel(file))
+		fmt.Fprintf(r.Writer, "```%s\n", language.Fence(file.Path))
+		for _, sig := range file.Signatures {
+			fmt.Fprintf(r.Writer, "%d | %s\n", sig.Line, sig.Text)
+		}
+		fmt.Fprintln(r.Writer, "```")
+	}
+	if repoMap.Omitted > 0 {
+		fmt.Fprintf(r.Writer, "\n_%s_\n", repoMapOmittedMessage(repoMap))
+	}
+	return nil
+}
+
+// repoMap formats a repository map in HTML format
+func (r *htmlRenderer) repoMap(repoMap *analysis.RepoMap) error {
+	if repoMap.Workspaces != nil {
+		fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(workspacesLabel(repoMap.Workspaces)))
+		for _, line := range workspaceLines(repoMap.Workspaces) {
+			fmt.Fprintf(r.Writer, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+		}
+		fmt.Fprint(r.Writer, htmlFileFooter)
+	}
+	for _, contract := range repoMap.Contracts {
+		fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(contractLabel(contract)))
+		for _, operation := range contract.Operations {
+			fmt.Fprintf(r.Writer, "<span class=\"line\">%s</span>\n", html.EscapeString(operation))
+		}
+		fmt.Fprint(r.Writer, htmlFileFooter)
+	}
+	for _, file := range repoMap.Files {
+		fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(repoMapLabel(file)))
+		for _, sig := range file.Signatures {
+			fmt.Fprintf(r.Writer, "<span class=\"line\"><span class=\"line-number\">%d</span>%s</span>\n", sig.Line, html.EscapeString(sig.Text))
+		}
+		fmt.Fprint(r.Writer, htmlFileFooter)
+	}
+	if repoMap.Omitted > 0 {
+		fmt.Fprintf(r.Writer, "        <div class=\"metadata\">%s</div>\n", html.EscapeString(repoMapOmittedMessage(repoMap)))
+	}
+	return nil
+}
+
+// repoMap stores a repository map for the final JSON document
+func (r *jsonRenderer) repoMap(repoMap *analysis.RepoMap) error {
+	if r.document == nil {
+		return fmt.Errorf("JSON output not started")
+	}
+	r.document.RepoMap = repoMap
+	return nil
+}
+
+// repoMapLabel returns the path of a file in the map, followed by its test
+// coverage when known
+func repoMapLabel(file analysis.RepoMapFile) string {
+	if file.Coverage != nil {
+		return fmt.Sprintf("%s (%s)", file.Path, file.Coverage.Describe())
+	}
+	return file.Path
+}
+
+// workspacesLabel returns the title of the workspace graph in the map,
+// followed by the package manager and build tools of the monorepo
+func workspacesLabel(graph *analysis.WorkspaceGraph) string {
+	tools := graph.Tools
+	if graph.Manager != "" {
+		tools = append([]string{graph.Manager}, tools...)
+	}
+	if len(tools) == 0 {
+		return "Workspaces"
+	}
+	return fmt.Sprintf("Workspaces (%s)", strings.Join(tools, ", "))
+}
+
+// workspaceLines lists each workspace of the graph with its directory and
+// the workspaces it depends on
+func workspaceLines(graph *analysis.WorkspaceGraph) []string {
+	lines := make([]string, len(graph.Workspaces))
+	for i, workspace := range graph.Workspaces {
+		lines[i] = fmt.Sprintf("%s (%s)", workspace.Name, workspace.Path)
+		if len(workspace.Dependencies) > 0 {
+			lines[i] += " -> " + strings.Join(workspace.Dependencies, ", ")
+		}
+	}
+	return lines
+}
+
+// contractLabel returns the path of an API contract in the map, followed by its kind
+func contractLabel(contract analysis.Contract) string {
+	return fmt.Sprintf("%s (%s contract)", contract.Path, contract.Kind)
+}
+
+// repoMapOmittedMessage returns the notice for files left out of the map
+func repoMapOmittedMessage(repoMap *analysis.RepoMap) string {
+	return fmt.Sprintf("[%d more files omitted to stay within the repo map budget]", repoMap.Omitted)
+}
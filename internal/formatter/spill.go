@@ -0,0 +1,152 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// contentStore holds the file contents of a decoded JSON document for Render.
+// Contents are kept in memory up to limit bytes; the rest are spilled to a
+// temporary file, so that rendering a large document doesn't need memory in
+// proportion to it.
+type contentStore struct {
+	limit   int64             // Bytes kept in memory (0 for no limit)
+	used    int64             // Bytes kept in memory so far
+	memory  map[string]string // Contents kept in memory by path
+	spill   *os.File          // Temporary file of the other contents, created when needed
+	spilled map[string]spilledContent
+	size    int64 // End of the temporary file
+}
+
+// spilledContent locates a content in the temporary file
+type spilledContent struct {
+	offset int64
+	length int64
+}
+
+// newContentStore creates a store keeping up to limit bytes in memory
+func newContentStore(limit int64) *contentStore {
+	return &contentStore{
+		limit:   limit,
+		memory:  make(map[string]string),
+		spilled: make(map[string]spilledContent),
+	}
+}
+
+// put stores the content of the file at path, spilling it to the temporary
+// file when it doesn't fit in memory
+func (s *contentStore) put(path, content string) error {
+	if s.limit <= 0 || s.used+int64(len(content)) <= s.limit {
+		s.memory[path] = content
+		s.used += int64(len(content))
+		return nil
+	}
+
+	if s.spill == nil {
+		file, err := os.CreateTemp("", "codectx-render-*")
+		if err != nil {
+			return fmt.Errorf("failed to spill contents to disk: %w", err)
+		}
+		s.spill = file
+	}
+	if _, err := io.WriteString(s.spill, content); err != nil {
+		return fmt.Errorf("failed to spill contents to disk: %w", err)
+	}
+	s.spilled[path] = spilledContent{offset: s.size, length: int64(len(content))}
+	s.size += int64(len(content))
+	return nil
+}
+
+// open returns the content of the file at path, as a SourceFunc
+func (s *contentStore) open(path string) (io.ReadCloser, error) {
+	if content, ok := s.memory[path]; ok {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+	if spilled, ok := s.spilled[path]; ok {
+		return io.NopCloser(io.NewSectionReader(s.spill, spilled.offset, spilled.length)), nil
+	}
+	return nil, fmt.Errorf("%s is not in the input", path)
+}
+
+// close removes the temporary file, if any
+func (s *contentStore) close() error {
+	if s.spill == nil {
+		return nil
+	}
+	err := s.spill.Close()
+	if removeErr := os.Remove(s.spill.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// decodeDocument decodes a JSON document, moving the contents of its text
+// files into store as they are read, so that no more than one of them is
+// held at a time beyond what the store keeps in memory
+func decodeDocument(r io.Reader, store *contentStore) (*JSONOutput, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	// Fields other than the files are decoded together at the end
+	fields := make(map[string]json.RawMessage)
+	var files []JSONFileInfo
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		if key != "files" {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+			fields[key] = value
+			continue
+		}
+
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		} else if token == nil {
+			continue
+		} else if token != json.Delim('[') {
+			return nil, errors.New("expected the files to be an array")
+		}
+		for decoder.More() {
+			var file JSONFileInfo
+			if err := decoder.Decode(&file); err != nil {
+				return nil, err
+			}
+			// Skipped files and stubs keep their notice, which is short
+			if file.Type == "text" && !file.Skipped {
+				if err := store.put(file.Path, file.Content); err != nil {
+					return nil, err
+				}
+				file.Content = ""
+			}
+			files = append(files, file)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var doc JSONOutput
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc.Files = files
+	return &doc, nil
+}
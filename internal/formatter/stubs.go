@@ -0,0 +1,126 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+
+	"codectx/internal/git"
+	"codectx/internal/minified"
+)
+
+// DuplicateStub returns the text written in place of a file whose content is
+// identical to an earlier file
+func DuplicateStub(original string) string {
+	return fmt.Sprintf("[identical to %s]", original)
+}
+
+// FormatDuplicate writes a stub in place of a file whose content is identical
+// to the earlier file original (a relative path)
+func (f *Formatter) FormatDuplicate(path, relativePath, original string) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatDuplicate(path, relativePath, original) }); err != nil {
+		return err
+	}
+
+	err := f.formatStub(path, relativePath, DuplicateStub(original), "duplicate", "duplicate_of", original)
+	if doc := f.jsonDocument(); err == nil && doc != nil {
+		doc.Metadata.DuplicateFiles++
+	}
+	return err
+}
+
+// formatMinified writes a placeholder in place of a minified asset
+func (f *Formatter) formatMinified(path, relativePath string, info *minified.Info) error {
+	err := f.formatStub(path, relativePath, info.Placeholder(f.SizeUnits), "minified", "minified", info)
+	if doc := f.jsonDocument(); err == nil && doc != nil {
+		doc.Metadata.MinifiedFiles++
+	}
+	return err
+}
+
+// formatLFSPointer writes a placeholder in place of a Git LFS pointer file
+func (f *Formatter) formatLFSPointer(path, relativePath string, pointer *git.LFSPointer) error {
+	err := f.formatStub(path, relativePath, pointer.Placeholder(f.SizeUnits), "lfs_pointer", "lfs", pointer)
+	if doc := f.jsonDocument(); err == nil && doc != nil {
+		doc.Metadata.LFSPointers++
+	}
+	return err
+}
+
+// formatStub writes a one-line stub in place of a file's content. In JSON
+// output the entry gets the given type and an extra field describing the stub.
+func (f *Formatter) formatStub(path, relativePath, stub, entryType, extraKey string, extraValue interface{}) error {
+	truncated := false
+	if f.SizeLimiter != nil && f.SizeLimiter.IsLimited() {
+		reservation, ok := f.SizeLimiter.ReserveForPath(relativePath, int64(len(stub)+1))
+		if ok {
+			reservation.Commit()
+		} else {
+			stub = f.SizeLimiter.GetTruncatedMessageFor(relativePath)
+			truncated = true
+		}
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.stubEntry(path, relativePath, stub, truncated, entryType, extraKey, extraValue)
+}
+
+// stubEntry writes a stub under a header line
+func (r *textRenderer) stubEntry(path, relativePath, stub string, truncated bool, entryType, extraKey string, extraValue interface{}) error {
+	r.writeTextFileHeader(relativePath)
+	_, err := fmt.Fprintln(r.Writer, stub)
+	return err
+}
+
+// stubEntry writes a stub under its path
+func (r *markdownRenderer) stubEntry(path, relativePath, stub string, truncated bool, entryType, extraKey string, extraValue interface{}) error {
+	_, err := fmt.Fprintf(r.Writer, "\n### %s\n%s\n", relativePath, stub)
+	return err
+}
+
+// stubEntry writes a stub in a box under its path
+func (r *htmlRenderer) stubEntry(path, relativePath, stub string, truncated bool, entryType, extraKey string, extraValue interface{}) error {
+	fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(relativePath))
+	fmt.Fprintf(r.Writer, htmlOmittedLine, html.EscapeString(stub))
+	_, err := fmt.Fprint(r.Writer, htmlFileFooter)
+	return err
+}
+
+// stubEntry streams a stub file entry into the "files" array, marked as
+// truncated when the limit replaced the stub
+func (r *jsonRenderer) stubEntry(path, relativePath, stub string, truncated bool, entryType, extraKey string, extraValue interface{}) error {
+	if err := r.start(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(path)
+	if ext != "" {
+		ext = ext[1:]
+	}
+
+	w := r.Writer
+	r.beginEntry()
+	writeJSONField(w, "", "path", path)
+	writeJSONField(w, ",", "relative_path", relativePath)
+	writeJSONField(w, ",", "type", entryType)
+	writeJSONField(w, ",", "extension", ext)
+	if r.keyFileSet[relativePath] {
+		writeJSONField(w, ",", "key_file", true)
+	}
+	writeJSONField(w, ",", "content", stub)
+	writeJSONField(w, ",", extraKey, extraValue)
+	if truncated {
+		writeJSONField(w, ",", "truncated", true)
+	}
+	if _, err := fmt.Fprint(w, "\n    }"); err != nil {
+		return err
+	}
+
+	r.document.Metadata.TotalFiles++
+	r.document.Metadata.EstimatedTokens += len(stub) / 4
+	r.document.Metadata.Truncated = r.document.Metadata.Truncated || truncated
+	return nil
+}
@@ -0,0 +1,177 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"codectx/internal/limits"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
+)
+
+// InventoryEntry describes an included file in the inventory of --summary
+// output, which lists the files in place of their contents
+type InventoryEntry struct {
+	Path      string `json:"path"`
+	Language  string `json:"language,omitempty"`
+	SizeBytes int64  `json:"size_bytes"`
+	Lines     int    `json:"lines"`
+	Tokens    int    `json:"tokens"`
+}
+
+// FormatSummary writes the statistics of the scan and the inventory of the
+// included files, for output that leaves out their contents. In JSON output
+// the statistics are the metadata's totals, and the inventory is added as its
+// own array.
+func (f *Formatter) FormatSummary(collector *stats.StatsCollector, inventory []InventoryEntry) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatSummary(collector, inventory) }); err != nil {
+		return err
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.summary(collector, inventory)
+}
+
+// summary writes the summary in text format
+func (r *textRenderer) summary(collector *stats.StatsCollector, inventory []InventoryEntry) error {
+	var b strings.Builder
+	b.WriteString("\nSummary:\n")
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range r.summaryLines(collector, inventory) {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\nFile Inventory:\n")
+	b.WriteString("--------------------------------------------------------------------------------\n")
+	for _, line := range r.inventoryLines(inventory) {
+		b.WriteString(line + "\n")
+	}
+	return r.writeSection(limits.CategoryContent, b.String())
+}
+
+// summary writes the summary in Markdown format, with the inventory as a table
+func (r *markdownRenderer) summary(collector *stats.StatsCollector, inventory []InventoryEntry) error {
+	var b strings.Builder
+	b.WriteString("\n## Summary\n\n")
+	for _, line := range r.summaryLines(collector, inventory) {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	b.WriteString("\n## File Inventory\n\n")
+	b.WriteString("| Path | Language | Size | Lines | Tokens |\n")
+	b.WriteString("| --- | --- | ---: | ---: | ---: |\n")
+	for _, entry := range inventory {
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %d | %d |\n", strings.ReplaceAll(entry.Path, "|", "\\|"), entry.Language, utils.FormatSize(entry.SizeBytes, r.SizeUnits), entry.Lines, entry.Tokens)
+	}
+	return r.writeSection(limits.CategoryContent, b.String())
+}
+
+// summary writes the summary in HTML format
+func (r *htmlRenderer) summary(collector *stats.StatsCollector, inventory []InventoryEntry) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, htmlFileHeader, "Summary")
+	for _, line := range r.summaryLines(collector, inventory) {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	fmt.Fprintf(&b, htmlFileHeader, "File Inventory")
+	for _, line := range r.inventoryLines(inventory) {
+		fmt.Fprintf(&b, "<span class=\"line\">%s</span>\n", html.EscapeString(line))
+	}
+	b.WriteString(htmlFileFooter)
+	return r.writeSection(limits.CategoryContent, b.String())
+}
+
+// summary adds the inventory to the JSON document, whose metadata has the totals
+func (r *jsonRenderer) summary(collector *stats.StatsCollector, inventory []InventoryEntry) error {
+	if r.document == nil {
+		return fmt.Errorf("JSON output not started")
+	}
+	r.document.Inventory = inventory
+	return nil
+}
+
+// summaryLines lists the totals of the scan, the languages of the included
+// files, and the detected stack as plain text lines
+func (f *Formatter) summaryLines(collector *stats.StatsCollector, inventory []InventoryEntry) []string {
+	var lines []string
+	if collector != nil {
+		lines = append(lines,
+			fmt.Sprintf("Files: %s (%s text, %s binary)", utils.FormatCount(collector.TotalFiles), utils.FormatCount(collector.TextFiles), utils.FormatCount(collector.BinaryFiles)),
+			"Directories: "+utils.FormatCount(collector.TotalDirectories),
+			"Total size: "+utils.FormatSize(collector.TotalSize, f.SizeUnits),
+			"Estimated tokens: ~"+utils.FormatCount(collector.EstimatedTokens),
+		)
+	}
+	if languages := inventoryLanguages(inventory); languages != "" {
+		lines = append(lines, "Languages: "+languages)
+	}
+	if len(f.stack) > 0 {
+		components := make([]string, len(f.stack))
+		for i, component := range f.stack {
+			components[i] = fmt.Sprintf("%s (%s)", component.Name, component.Category)
+		}
+		lines = append(lines, "Stack: "+strings.Join(components, ", "))
+	}
+	return lines
+}
+
+// inventoryLanguages lists the languages of the inventory with their file
+// counts, most common first
+func inventoryLanguages(inventory []InventoryEntry) string {
+	counts := make(map[string]int)
+	for _, entry := range inventory {
+		if entry.Language != "" {
+			counts[entry.Language]++
+		}
+	}
+	languages := make([]string, 0, len(counts))
+	for name := range counts {
+		languages = append(languages, name)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if counts[languages[i]] != counts[languages[j]] {
+			return counts[languages[i]] > counts[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+
+	parts := make([]string, len(languages))
+	for i, name := range languages {
+		parts[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// inventoryLines lays out the inventory as a table of plain text lines, with
+// the paths and languages left-aligned and the numbers right-aligned
+func (f *Formatter) inventoryLines(inventory []InventoryEntry) []string {
+	rows := [][]string{{"Path", "Language", "Size", "Lines", "Tokens"}}
+	for _, entry := range inventory {
+		rows = append(rows, []string{entry.Path, entry.Language, utils.FormatSize(entry.SizeBytes, f.SizeUnits), fmt.Sprint(entry.Lines), fmt.Sprint(entry.Tokens)})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i < 2 {
+				cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			} else {
+				cells[i] = fmt.Sprintf("%*s", widths[i], cell)
+			}
+		}
+		lines[r] = strings.Join(cells, "  ")
+	}
+	return lines
+}
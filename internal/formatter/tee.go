@@ -0,0 +1,108 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// tee writes the output in further formats alongside the formatter's own, as
+// --output-spec does. Each extra output is a copy of the formatter with its own
+// format, writer, renderer, and size limiter, and the content of each file is
+// read once and shared by all of them.
+type tee struct {
+	outputs []*Formatter
+	source  *sharedSource
+}
+
+// extraOutput is an output added with AddOutput before formatting started
+type extraOutput struct {
+	format OutputFormat
+	writer io.Writer
+}
+
+// AddOutput writes the output a second time, in format, to writer. The extra
+// output takes the formatter's settings when formatting starts, so it must be
+// added before FormatTree; Close closes writer if it's closable.
+func (f *Formatter) AddOutput(format string, writer io.Writer) error {
+	outputFormat := OutputFormat(strings.ToLower(format))
+	if _, ok := renderers[outputFormat]; !ok {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	if f.tee != nil {
+		return errors.New("outputs must be added before formatting starts")
+	}
+	f.extraOutputs = append(f.extraOutputs, extraOutput{format: outputFormat, writer: writer})
+	return nil
+}
+
+// fanOut runs fn on the formatter of each extra output, creating them from f
+// on first use
+func (f *Formatter) fanOut(fn func(output *Formatter) error) error {
+	if len(f.extraOutputs) == 0 {
+		return nil
+	}
+	if f.tee == nil {
+		f.startTee()
+	}
+	for _, output := range f.tee.outputs {
+		if err := fn(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startTee copies f for each extra output. A copy starts with nothing written
+// or counted, and charges a limiter of its own so that each output is cut at
+// the same limit as if it were the only one.
+func (f *Formatter) startTee() {
+	f.tee = &tee{source: &sharedSource{reader: &Formatter{Source: f.Source, Extract: f.Extract, Transform: f.Transform}}}
+	for _, extra := range f.extraOutputs {
+		output := *f
+		output.Format = extra.format
+		output.Writer = extra.writer
+		output.output, output.buffer, output.destination = nil, nil, nil
+		output.extraOutputs, output.tee = nil, nil
+		output.embeddedAssets, output.reclaimedBytes, output.piiRedacted = 0, 0, utils.PIICounts{}
+		output.scanOptions.Format = string(extra.format)
+		if f.SizeLimiter != nil {
+			output.SizeLimiter = f.SizeLimiter.Clone()
+		}
+		f.tee.outputs = append(f.tee.outputs, &output)
+	}
+	f.shared = f.tee.source
+	for _, output := range f.tee.outputs {
+		output.shared = f.tee.source
+	}
+}
+
+// sharedSource reads a file once for all the outputs of a tee, keeping the
+// content of the file being formatted until the next one is opened
+type sharedSource struct {
+	reader  *Formatter // Opens files the way the formatter did before the tee
+	path    string
+	content []byte
+	err     error
+}
+
+// open returns the content of the file at path, reading it on first use
+func (s *sharedSource) open(path string) (io.ReadCloser, error) {
+	if path != s.path {
+		s.path, s.content, s.err = path, nil, nil
+		file, err := s.reader.openSource(path)
+		if err == nil {
+			s.content, err = io.ReadAll(file)
+			file.Close()
+		}
+		s.err = err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return io.NopCloser(bytes.NewReader(s.content)), nil
+}
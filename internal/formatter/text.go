@@ -0,0 +1,120 @@
+package formatter
+
+import (
+	"fmt"
+
+	"codectx/internal/highlight"
+	"codectx/internal/language"
+	"codectx/internal/stats"
+	"codectx/internal/utils"
+)
+
+// textRenderer writes plain text output, the default format
+type textRenderer struct {
+	*Formatter
+}
+
+// Begin writes the header
+func (r *textRenderer) Begin() error {
+	return r.writeHeader()
+}
+
+// Tree writes the directory tree and the sections that follow it
+func (r *textRenderer) Tree(tree string) error {
+	if r.Color {
+		tree = colorTree(tree)
+	}
+	if _, err := fmt.Fprintln(r.Writer, tree); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(r.Writer, r.treeSections())
+	return err
+}
+
+// File writes the content of a file under a header line
+func (r *textRenderer) File(path, relativePath string) error {
+	// Open the file first, so that an unreadable file leaves no partial entry
+	file, err := r.openSource(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// Print the file header
+	r.writeTextFileHeader(relativePath)
+
+	// Read the file line by line
+	indent := r.detectIndent(path)
+	scanner := utils.NewLineReader(file, r.MaxLineLength)
+	fileCap := r.SizeLimiter.NewFileCap()
+	var highlighter *highlight.Highlighter
+	if r.Color {
+		highlighter = highlight.For(path)
+		if highlighter == nil {
+			if lang, ok := language.DetectFile(path); ok {
+				highlighter = highlight.ForLanguage(lang.ID)
+			}
+		}
+	}
+	lineNum := 1
+	for scanner.Scan() {
+		line := r.cleanLine(scanner.Text(), indent)
+
+		// Keep counting lines past the per-file cap for the omission marker
+		if !fileCap.Allow(line) {
+			continue
+		}
+
+		// Format the line
+		var formattedLine string
+		if r.ShowLineNumbers {
+			formattedLine = fmt.Sprintf("%2d | %s\n", lineNum, line)
+		} else {
+			formattedLine = line + "\n"
+		}
+
+		// Check if adding this line would exceed the total size limit
+		if r.SizeLimiter != nil && r.SizeLimiter.IsLimited() {
+			reservation, ok := r.SizeLimiter.ReserveForPath(relativePath, int64(len(formattedLine)))
+			if !ok {
+				// We've reached the limit, print a message and stop
+				fmt.Fprintln(r.Writer, r.SizeLimiter.GetTruncatedMessageFor(relativePath))
+				return nil
+			}
+			reservation.Commit()
+		}
+
+		// Write the line, colored after the limit was charged for the plain text
+		if r.Color {
+			formattedLine = r.colorLine(highlighter, lineNum, line)
+		}
+		fmt.Fprint(r.Writer, formattedLine)
+		lineNum++
+	}
+
+	if err := scanner.Err(); err != nil {
+		if message, ok := lineTooLongMessage(err); ok {
+			fmt.Fprintln(r.Writer, message)
+		}
+		return readError(relativePath, err)
+	}
+
+	if fileCap.Truncated() {
+		fmt.Fprintln(r.Writer, fileCap.OmissionMessage())
+	}
+
+	return nil
+}
+
+// Stats leaves the statistics to --stats, which prints them separately
+func (r *textRenderer) Stats(*stats.StatsCollector) error {
+	return nil
+}
+
+// End writes the invocation and the footer
+func (r *textRenderer) End() error {
+	if err := r.writeInvocation(); err != nil {
+		return err
+	}
+	return r.writeFooter()
+}
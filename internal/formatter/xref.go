@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+
+	"codectx/internal/analysis"
+	"codectx/internal/limits"
+)
+
+// FormatXref formats a cross-reference index as a section after the file contents
+func (f *Formatter) FormatXref(index *analysis.XrefIndex) error {
+	if err := f.fanOut(func(output *Formatter) error { return output.FormatXref(index) }); err != nil {
+		return err
+	}
+
+	if f.SizeLimiter != nil {
+		f.SizeLimiter.Charge(limits.CategoryContent, int64(index.Tokens*4))
+	}
+
+	r, err := f.sections()
+	if r == nil {
+		return err
+	}
+	return r.xref(index)
+}
+
+// xref formats a cross-reference index in text format
+func (r *textRenderer) xref(index *analysis.XrefIndex) error {
+	fmt.Fprintln(r.Writer, "\nCross-Reference Index:")
+	fmt.Fprintln(r.Writer, "--------------------------------------------------------------------------------")
+	for i, symbol := range index.Symbols {
+		if i == 0 || index.Symbols[i-1].File != symbol.File {
+			fmt.Fprintf(r.Writer, "%s:\n", symbol.File)
+		}
+		fmt.Fprintf(r.Writer, "%5d | %s %s: %s\n", symbol.Line, symbol.Kind, symbol.Name, analysis.FormatXrefUses(symbol))
+	}
+	return nil
+}
+
+// xref formats a cross-reference index in Markdown format
+func (r *markdownRenderer) xref(index *analysis.XrefIndex) error {
+	fmt.Fprintln(r.Writer, "\n## Cross-Reference Index")
+	for i, symbol := range index.Symbols {
+		if i == 0 || index.Symbols[i-1].File != symbol.File {
+			fmt.Fprintf(r.Writer, "\n### %s\n\n", symbol.File)
+		}
+		fmt.Fprintf(r.Writer, "- `%s` (%s, line %d): %s\n", symbol.Name, symbol.Kind, symbol.Line, analysis.FormatXrefUses(symbol))
+	}
+	return nil
+}
+
+// xref formats a cross-reference index in HTML format
+func (r *htmlRenderer) xref(index *analysis.XrefIndex) error {
+	for i, symbol := range index.Symbols {
+		if i == 0 || index.Symbols[i-1].File != symbol.File {
+			if i > 0 {
+				fmt.Fprint(r.Writer, htmlFileFooter)
+			}
+			fmt.Fprintf(r.Writer, htmlFileHeader, html.EscapeString(symbol.File))
+		}
+		text := fmt.Sprintf("%s %s: %s", symbol.Kind, symbol.Name, analysis.FormatXrefUses(symbol))
+		fmt.Fprintf(r.Writer, "<span class=\"line\"><span class=\"line-number\">%d</span>%s</span>\n", symbol.Line, html.EscapeString(text))
+	}
+	if len(index.Symbols) > 0 {
+		fmt.Fprint(r.Writer, htmlFileFooter)
+	}
+	return nil
+}
+
+// xref stores a cross-reference index for the final JSON document
+func (r *jsonRenderer) xref(index *analysis.XrefIndex) error {
+	if r.document == nil {
+		return fmt.Errorf("JSON output not started")
+	}
+	r.document.Xref = index
+	return nil
+}
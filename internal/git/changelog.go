@@ -0,0 +1,186 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoTag is returned by GetLatestTag when no tag is reachable from HEAD
+var ErrNoTag = errors.New("no tag found before HEAD")
+
+// Changelog is what changed under a directory since the last tag, as context
+// for writing release notes
+type Changelog struct {
+	Since       string        `json:"since"` // The last tag, or "" for all history when there is none
+	Commits     int           `json:"commits"`
+	Groups      []CommitGroup `json:"groups"` // Commits by conventional commit type, in the order of CommitTypes
+	Files       []FileChange  `json:"files"`
+	Diff        string        `json:"diff"`                   // Diff of the included files, set with GetChangelogDiff
+	DiffOmitted bool          `json:"diff_omitted,omitempty"` // The diff didn't fit the output limit
+
+	base string // Commit or tree the changes are relative to
+}
+
+// CommitGroup is the commits of one conventional commit type, newest first
+type CommitGroup struct {
+	Type    string   `json:"type"` // A key of CommitTypes
+	Title   string   `json:"title"`
+	Commits []Commit `json:"commits"`
+}
+
+// FileChange is a file changed since the last tag
+type FileChange struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// CommitTypes lists the groups of a changelog in order, by conventional commit
+// type, with their titles. Breaking changes of any type are grouped first, and
+// commits that don't follow the convention last.
+var CommitTypes = []struct{ Type, Title string }{
+	{"breaking", "Breaking Changes"},
+	{"feat", "Features"},
+	{"fix", "Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build and CI"},
+	{"chore", "Chores"},
+	{"other", "Other Changes"},
+}
+
+// commitTypeAliases maps other conventional commit types to a group
+var commitTypeAliases = map[string]string{
+	"feature": "feat",
+	"bugfix":  "fix",
+	"doc":     "docs",
+	"tests":   "test",
+	"ci":      "build",
+	"deps":    "build",
+	"style":   "chore",
+	"revert":  "chore",
+}
+
+// conventionalPattern matches a conventional commit subject, such as
+// "feat(parser)!: support empty input"
+var conventionalPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?: \S`)
+
+// CommitType returns the changelog group of a commit subject
+func CommitType(subject string) string {
+	m := conventionalPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return "other"
+	}
+	if m[2] == "!" {
+		return "breaking"
+	}
+	kind := strings.ToLower(m[1])
+	if alias, ok := commitTypeAliases[kind]; ok {
+		kind = alias
+	}
+	for _, t := range CommitTypes {
+		if t.Type == kind {
+			return kind
+		}
+	}
+	return "other"
+}
+
+// GetLatestTag returns the most recent tag reachable from HEAD
+func GetLatestTag(rootDir string) (string, error) {
+	// Check if git is available
+	if !isGitCommandAvailable() {
+		return "", fmt.Errorf("git command not available")
+	}
+
+	// Check if the directory is a git repository
+	if !isGitRepository(rootDir) {
+		return "", fmt.Errorf("not a git repository")
+	}
+
+	output, err := runGitCommand(rootDir, "describe", "--tags", "--abbrev=0", "HEAD")
+	if err != nil {
+		return "", ErrNoTag
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GetChangelog returns the commits and changed files under rootDir since the
+// last tag, or in all history when there is no tag. The diff is left to
+// GetChangelogDiff, once the files it may show are known.
+func GetChangelog(rootDir string) (*Changelog, error) {
+	tag, err := GetLatestTag(rootDir)
+	if err != nil && !errors.Is(err, ErrNoTag) {
+		return nil, err
+	}
+
+	base, revisions := tag, tag+"..HEAD"
+	if tag == "" {
+		// Without a tag, compare with the empty tree of the repository's hash
+		if base, err = runGitCommand(rootDir, "hash-object", "-t", "tree", "--stdin"); err != nil {
+			return nil, fmt.Errorf("failed to get the empty tree: %w", err)
+		}
+		base, revisions = strings.TrimSpace(base), "HEAD"
+	}
+	commits, err := logCommits(rootDir, revisions)
+	if err != nil {
+		return nil, err
+	}
+	changelog := &Changelog{Since: tag, Commits: len(commits), base: base}
+	byType := make(map[string][]Commit)
+	for _, commit := range commits {
+		kind := CommitType(commit.Subject)
+		byType[kind] = append(byType[kind], commit)
+	}
+	for _, t := range CommitTypes {
+		if len(byType[t.Type]) > 0 {
+			changelog.Groups = append(changelog.Groups, CommitGroup{Type: t.Type, Title: t.Title, Commits: byType[t.Type]})
+		}
+	}
+
+	// Paths are printed unquoted and relative to rootDir, as by git log
+	diffArgs := []string{"-c", "core.quotePath=false", "diff", "--relative", "--no-renames"}
+	numstat, err := runGitCommand(rootDir, append(diffArgs, "--numstat", base, "HEAD", "--", ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed files: %w", err)
+	}
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		file := FileChange{Path: fields[2], Binary: fields[0] == "-"}
+		file.Additions, _ = strconv.Atoi(fields[0])
+		file.Deletions, _ = strconv.Atoi(fields[1])
+		changelog.Files = append(changelog.Files, file)
+	}
+	return changelog, nil
+}
+
+// diffPathsPerCommand bounds the pathspecs of one git diff, keeping the
+// command line short
+const diffPathsPerCommand = 500
+
+// GetChangelogDiff returns the diff of the changelog limited to paths,
+// relative to rootDir, so that files left out of the output are left out of
+// the diff as well
+func GetChangelogDiff(rootDir string, changelog *Changelog, paths []string) (string, error) {
+	var diff strings.Builder
+	for start := 0; start < len(paths); start += diffPathsPerCommand {
+		chunk := paths[start:min(start+diffPathsPerCommand, len(paths))]
+		// Paths are matched literally and printed unquoted, relative to rootDir
+		args := []string{"--literal-pathspecs", "-c", "core.quotePath=false", "diff", "--relative", "--no-renames", changelog.base, "HEAD", "--"}
+		output, err := runGitCommand(rootDir, append(args, chunk...)...)
+		if err != nil {
+			return "", fmt.Errorf("failed to get diff: %w", err)
+		}
+		diff.WriteString(output)
+	}
+	return diff.String(), nil
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommitType(t *testing.T) {
+	tests := []struct {
+		subject  string
+		expected string
+	}{
+		{"feat: add --changelog-context", "feat"},
+		{"fix(parser): handle empty input", "fix"},
+		{"refactor!: drop the v1 API", "breaking"},
+		{"ci: cache modules", "build"},
+		{"Feat: capitalized type", "feat"},
+		{"wip: not a known type", "other"},
+		{"Update README", "other"},
+		{"fix:missing space", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := CommitType(tt.subject); got != tt.expected {
+			t.Errorf("CommitType(%q): expected %s, got %s", tt.subject, tt.expected, got)
+		}
+	}
+}
+
+func TestGetChangelog(t *testing.T) {
+	tempDir, commit := initTestRepo(t)
+	commit("Alice", "feat: first release", "main.go")
+
+	// Without a tag, the changelog covers all history
+	changelog, err := GetChangelog(tempDir)
+	if err != nil {
+		t.Fatalf("GetChangelog failed without tags: %v", err)
+	}
+	if changelog.Since != "" || changelog.Commits != 1 || len(changelog.Files) != 1 {
+		t.Errorf("Expected all history without tags, got %+v", changelog)
+	}
+	if diff, err := GetChangelogDiff(tempDir, changelog, []string{"main.go"}); err != nil || !strings.Contains(diff, "+++ b/main.go") {
+		t.Errorf("Expected the diff of main.go since the first commit, got %q, %v", diff, err)
+	}
+
+	if _, err := runGitCommand(tempDir, "tag", "v1.0.0"); err != nil {
+		t.Fatalf("Failed to tag: %v", err)
+	}
+	commit("Bob", "fix(main): handle errors", "main.go")
+	commit("Alice", "feat!: new config format", "config.go", "main.go")
+	commit("Alice", "Tidy up", "docs/guide.md")
+
+	changelog, err = GetChangelog(tempDir)
+	if err != nil {
+		t.Fatalf("GetChangelog failed: %v", err)
+	}
+	if changelog.Since != "v1.0.0" || changelog.Commits != 3 {
+		t.Errorf("Expected 3 commits since v1.0.0, got %d since %s", changelog.Commits, changelog.Since)
+	}
+	var groups []string
+	for _, group := range changelog.Groups {
+		groups = append(groups, group.Type)
+	}
+	if expected := []string{"breaking", "fix", "other"}; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected groups %v, got %v", expected, groups)
+	}
+	expectedFiles := []FileChange{
+		{Path: "config.go", Additions: 1},
+		{Path: "docs/guide.md", Additions: 1},
+		{Path: "main.go", Additions: 2},
+	}
+	if !reflect.DeepEqual(changelog.Files, expectedFiles) {
+		t.Errorf("Expected files %+v, got %+v", expectedFiles, changelog.Files)
+	}
+	if changelog.Diff != "" {
+		t.Errorf("Expected no diff before the files are known, got: %s", changelog.Diff)
+	}
+
+	// The diff covers only the paths given
+	diff, err := GetChangelogDiff(tempDir, changelog, []string{"config.go"})
+	if err != nil {
+		t.Fatalf("GetChangelogDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "+++ b/config.go") || strings.Contains(diff, "main.go") {
+		t.Errorf("Expected only the diff of config.go, got: %s", diff)
+	}
+	if diff, err := GetChangelogDiff(tempDir, changelog, nil); err != nil || diff != "" {
+		t.Errorf("Expected no diff without paths, got %q, %v", diff, err)
+	}
+}
@@ -3,8 +3,11 @@ package git
 import (
 	"bufio"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+
+	"codectx/internal/platform"
 )
 
 // GitIgnoreParser parses .gitignore files and checks if files should be ignored
@@ -88,27 +91,25 @@ func (g *GitIgnoreParser) ParseAllGitIgnores() error {
 
 // ShouldIgnore checks if a file should be ignored based on .gitignore rules
 func (g *GitIgnoreParser) ShouldIgnore(filePath string) bool {
-	// Make the path relative to the root directory
-	relPath, err := filepath.Rel(g.rootDir, filePath)
+	// Make the path relative to the root directory, normalized to forward slashes
+	relPath, err := platform.RelSlash(g.rootDir, filePath)
 	if err != nil {
 		return false
 	}
 
-	// Normalize path separators
-	relPath = filepath.ToSlash(relPath)
-
 	// Check each rule in reverse order (later rules override earlier ones)
 	for i := len(g.rules) - 1; i >= 0; i-- {
 		rule := g.rules[i]
 
 		// Check if the pattern matches
-		matched, _ := filepath.Match(rule.Pattern, relPath)
+		// Use path.Match so that "/" is the separator on every platform
+		matched, _ := path.Match(rule.Pattern, relPath)
 		if !matched {
 			// Also check if the pattern matches any part of the path
 			parts := strings.Split(relPath, "/")
 			for j := 0; j < len(parts); j++ {
 				subPath := strings.Join(parts[j:], "/")
-				matched, _ = filepath.Match(rule.Pattern, subPath)
+				matched, _ = path.Match(rule.Pattern, subPath)
 				if matched {
 					break
 				}
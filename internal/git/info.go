@@ -3,6 +3,7 @@ package git
 import (
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -83,7 +84,8 @@ func GetGitInfo(rootDir string) (*GitInfo, error) {
 	return info, nil
 }
 
-// GetGitTrackedFiles returns a list of files tracked by Git
+// GetGitTrackedFiles returns the files tracked by Git under rootDir.
+// Paths are relative to the repository root and use forward slashes.
 func GetGitTrackedFiles(rootDir string) ([]string, error) {
 	// Check if git is available
 	if !isGitCommandAvailable() {
@@ -95,16 +97,68 @@ func GetGitTrackedFiles(rootDir string) ([]string, error) {
 		return nil, fmt.Errorf("not a git repository")
 	}
 
-	// Get tracked files
-	output, err := runGitCommand(rootDir, "ls-files")
+	// Get tracked files, separated by NULs so that names are not quoted
+	output, err := runGitCommand(rootDir, "ls-files", "-z", "--full-name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tracked files: %w", err)
 	}
 
-	files := strings.Split(strings.TrimSpace(output), "\n")
+	files := strings.Split(output, "\x00")
 	return filterEmptyStrings(files), nil
 }
 
+// GetGitWorkingFiles returns the files tracked by Git under rootDir plus untracked
+// files that are not ignored, mirroring "git ls-files --cached --others --exclude-standard".
+// Paths are relative to the repository root and use forward slashes.
+func GetGitWorkingFiles(rootDir string) ([]string, error) {
+	// Check if git is available
+	if !isGitCommandAvailable() {
+		return nil, fmt.Errorf("git command not available")
+	}
+
+	// Check if the directory is a git repository
+	if !isGitRepository(rootDir) {
+		return nil, fmt.Errorf("not a git repository")
+	}
+
+	output, err := runGitCommand(rootDir, "ls-files", "-z", "--full-name", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working tree files: %w", err)
+	}
+
+	files := strings.Split(output, "\x00")
+	return filterEmptyStrings(files), nil
+}
+
+// GetRepoRoot returns the top-level directory of the repository containing dir
+func GetRepoRoot(dir string) (string, error) {
+	// Check if git is available
+	if !isGitCommandAvailable() {
+		return "", fmt.Errorf("git command not available")
+	}
+
+	output, err := runGitCommand(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return filepath.FromSlash(strings.TrimSpace(output)), nil
+}
+
+// GetRemoteURL returns the URL of a remote of the repository containing dir,
+// such as "origin"
+func GetRemoteURL(dir, remote string) (string, error) {
+	// Check if git is available
+	if !isGitCommandAvailable() {
+		return "", fmt.Errorf("git command not available")
+	}
+
+	output, err := runGitCommand(dir, "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
 // GetGitStatus returns the status of files in the repository
 func GetGitStatus(rootDir string) (map[string]string, error) {
 	// Check if git is available
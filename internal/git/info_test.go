@@ -3,6 +3,8 @@ package git
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -304,4 +306,130 @@ func TestGitInfoJSONTags(t *testing.T) {
 	var _ bool = info.IsDirty
 	var _ time.Time = info.LastModified
 	var _ string = info.RepositoryURL
-}
\ No newline at end of file
+}
+func TestGetGitWorkingFiles_Integration(t *testing.T) {
+	// Skip if git is not available
+	if !isGitCommandAvailable() {
+		t.Skip("Skipping integration test: git command not available")
+	}
+
+	tempDir, err := os.MkdirTemp("", "git_working_files_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if _, err := runGitCommand(tempDir, "init"); err != nil {
+		t.Skipf("Skipping integration test: git init failed: %v", err)
+	}
+
+	files := map[string]string{
+		".gitignore":     "*.log\n",
+		"tracked.go":     "package main\n",
+		"sub/new.go":     "package sub\n",
+		"debug.log":      "ignored\n",
+		"sub/nested.log": "ignored\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+	if _, err := runGitCommand(tempDir, "add", ".gitignore", "tracked.go"); err != nil {
+		t.Fatalf("git add failed: %v", err)
+	}
+
+	tracked, err := GetGitTrackedFiles(tempDir)
+	if err != nil {
+		t.Fatalf("GetGitTrackedFiles failed: %v", err)
+	}
+	if len(tracked) != 2 {
+		t.Errorf("Expected 2 tracked files, got %v", tracked)
+	}
+
+	working, err := GetGitWorkingFiles(tempDir)
+	if err != nil {
+		t.Fatalf("GetGitWorkingFiles failed: %v", err)
+	}
+	expected := map[string]bool{".gitignore": true, "tracked.go": true, "sub/new.go": true}
+	if len(working) != len(expected) {
+		t.Errorf("Expected %d working files, got %v", len(expected), working)
+	}
+	for _, file := range working {
+		if !expected[file] {
+			t.Errorf("Unexpected working file: %s", file)
+		}
+	}
+}
+
+func TestGetGitTrackedFiles_NonASCII(t *testing.T) {
+	if !isGitCommandAvailable() {
+		t.Skip("Skipping integration test: git command not available")
+	}
+
+	tempDir := t.TempDir()
+	if _, err := runGitCommand(tempDir, "init"); err != nil {
+		t.Skipf("Skipping integration test: git init failed: %v", err)
+	}
+	for _, name := range []string{"日本語/x.txt", "tab\tname.txt"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+	if _, err := runGitCommand(tempDir, "add", "-A"); err != nil {
+		t.Fatalf("git add failed: %v", err)
+	}
+
+	// Names are listed as they are on disk, not quoted
+	tracked, err := GetGitTrackedFiles(tempDir)
+	if err != nil {
+		t.Fatalf("GetGitTrackedFiles failed: %v", err)
+	}
+	expected := []string{"tab\tname.txt", "日本語/x.txt"}
+	if strings.Join(tracked, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %q, got %q", expected, tracked)
+	}
+}
+
+func TestGetGitWorkingFiles_NonASCII(t *testing.T) {
+	if !isGitCommandAvailable() {
+		t.Skip("Skipping integration test: git command not available")
+	}
+
+	tempDir := t.TempDir()
+	if _, err := runGitCommand(tempDir, "init"); err != nil {
+		t.Skipf("Skipping integration test: git init failed: %v", err)
+	}
+	for _, name := range []string{"日本語/x.txt", "新規 ファイル.txt"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+	if _, err := runGitCommand(tempDir, "add", "日本語/x.txt"); err != nil {
+		t.Fatalf("git add failed: %v", err)
+	}
+
+	// Both the tracked and the untracked name are listed unquoted
+	working, err := GetGitWorkingFiles(tempDir)
+	if err != nil {
+		t.Fatalf("GetGitWorkingFiles failed: %v", err)
+	}
+	expected := []string{"新規 ファイル.txt", "日本語/x.txt"}
+	sort.Strings(working)
+	sort.Strings(expected)
+	if strings.Join(working, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %q, got %q", expected, working)
+	}
+}
@@ -0,0 +1,161 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// maxLFSPointerSize bounds the size of a Git LFS pointer file; larger files
+// are never read as pointers
+const maxLFSPointerSize = 1024
+
+// lfsPullBatch bounds the paths passed to one "git lfs pull", to keep the
+// command line short
+const lfsPullBatch = 100
+
+// lfsSpecs are the version lines a pointer file starts with: the current
+// specification and the one of the pre-release git-media/hawser
+var lfsSpecs = []string{
+	"version https://git-lfs.github.com/spec/v1",
+	"version https://hawser.github.com/spec/v1",
+}
+
+// LFSPointer describes the Git LFS object a pointer file stands for
+type LFSPointer struct {
+	OID  string `json:"oid"`        // Object ID, such as "sha256:4d7a2146…"
+	Size int64  `json:"size_bytes"` // Size of the object's content
+}
+
+// DetectLFSPointer reports whether the file at path is a Git LFS pointer, a
+// small text file committed in place of content stored outside the repository
+// that hasn't been fetched
+func DetectLFSPointer(path string) (*LFSPointer, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if !stat.Mode().IsRegular() || stat.Size() > maxLFSPointerSize {
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxLFSPointerSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	pointer, ok := ParseLFSPointer(data)
+	return pointer, ok, nil
+}
+
+// ParseLFSPointer parses the content of a Git LFS pointer file: a version
+// line followed by "key value" lines, including the oid and size of the object
+func ParseLFSPointer(data []byte) (*LFSPointer, bool) {
+	if len(data) > maxLFSPointerSize {
+		return nil, false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		return nil, false
+	}
+	version := strings.TrimSpace(scanner.Text())
+	known := false
+	for _, spec := range lfsSpecs {
+		known = known || version == spec
+	}
+	if !known {
+		return nil, false
+	}
+
+	pointer := &LFSPointer{Size: -1}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, false
+		}
+		switch key {
+		case "oid":
+			pointer.OID = value
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, false
+			}
+			pointer.Size = size
+		}
+	}
+	if pointer.OID == "" || pointer.Size < 0 {
+		return nil, false
+	}
+	return pointer, true
+}
+
+// Describe returns a one-line description such as "Git LFS object sha256:4d7a2146…, 12.3MB, not fetched",
+// with the size in units
+func (p *LFSPointer) Describe(units utils.SizeUnits) string {
+	oid := p.OID
+	if algorithm, hash, ok := strings.Cut(oid, ":"); ok && len(hash) > 12 {
+		oid = algorithm + ":" + hash[:12] + "…"
+	}
+	return fmt.Sprintf("Git LFS object %s, %s, not fetched", oid, utils.FormatSize(p.Size, units))
+}
+
+// Placeholder returns the text written in place of a pointer file's content
+func (p *LFSPointer) Placeholder(units utils.SizeUnits) string {
+	return "[" + p.Describe(units) + "]"
+}
+
+// PullLFS downloads the Git LFS objects of the given pointer files, relative
+// to rootDir, and checks out their content in place of the pointers
+func PullLFS(rootDir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if !isGitCommandAvailable() || !isGitRepository(rootDir) {
+		return fmt.Errorf("%s is not in a Git repository", rootDir)
+	}
+	if _, err := runGitCommand(rootDir, "lfs", "version"); err != nil {
+		return fmt.Errorf("git-lfs is not installed")
+	}
+	repoRoot, err := GetRepoRoot(rootDir)
+	if err != nil {
+		return err
+	}
+	prefix, err := runGitCommand(rootDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return fmt.Errorf("failed to get the path in the repository: %w", err)
+	}
+	prefix = strings.TrimSpace(prefix)
+
+	// The include patterns are matched from the repository root and separated
+	// by commas, so a comma in a path is escaped as a wildcard
+	for start := 0; start < len(files); start += lfsPullBatch {
+		batch := files[start:min(start+lfsPullBatch, len(files))]
+		patterns := make([]string, len(batch))
+		for i, file := range batch {
+			patterns[i] = strings.ReplaceAll(prefix+file, ",", "?")
+		}
+		cmd := exec.Command("git", "lfs", "pull", "--include="+strings.Join(patterns, ","))
+		cmd.Dir = repoRoot
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to fetch %d Git LFS objects: %w: %s", len(batch), err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codectx/internal/utils"
+)
+
+const testLFSPointer = `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345678
+`
+
+func TestParseLFSPointer(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+	}{
+		{"pointer", testLFSPointer, true},
+		{"extension lines", strings.Replace(testLFSPointer, "oid", "ext-0-foo sha256:abc\noid", 1), true},
+		{"hawser", strings.Replace(testLFSPointer, "git-lfs", "hawser", 1), true},
+		{"unknown version", strings.Replace(testLFSPointer, "v1", "v2", 1), false},
+		{"no size", "version https://git-lfs.github.com/spec/v1\noid sha256:abc\n", false},
+		{"bad size", strings.Replace(testLFSPointer, "12345678", "big", 1), false},
+		{"text", "package main\n", false},
+		{"empty", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pointer, ok := ParseLFSPointer([]byte(test.content))
+			if ok != test.ok {
+				t.Fatalf("Expected %v, got %v", test.ok, ok)
+			}
+			if ok && pointer.Size != 12345678 {
+				t.Errorf("Expected size 12345678, got %d", pointer.Size)
+			}
+		})
+	}
+}
+
+func TestDetectLFSPointer(t *testing.T) {
+	tempDir := t.TempDir()
+	pointerPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(pointerPath, []byte(testLFSPointer), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	largePath := filepath.Join(tempDir, "large.txt")
+	if err := os.WriteFile(largePath, []byte(testLFSPointer+strings.Repeat("x", maxLFSPointerSize)), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	pointer, ok, err := DetectLFSPointer(pointerPath)
+	if err != nil || !ok {
+		t.Fatalf("Expected a pointer, got %v, %v", ok, err)
+	}
+	if expected := "[Git LFS object sha256:4d7a214614ab…, 11.8MB, not fetched]"; pointer.Placeholder(utils.BinaryUnits) != expected {
+		t.Errorf("Expected %q, got %q", expected, pointer.Placeholder(utils.BinaryUnits))
+	}
+	if expected := "[Git LFS object sha256:4d7a214614ab…, 12.3MB, not fetched]"; pointer.Placeholder(utils.SIUnits) != expected {
+		t.Errorf("Expected %q, got %q", expected, pointer.Placeholder(utils.SIUnits))
+	}
+	if _, ok, err := DetectLFSPointer(largePath); err != nil || ok {
+		t.Errorf("Expected no pointer in a large file, got %v, %v", ok, err)
+	}
+}
+
+func TestPullLFSOutsideRepository(t *testing.T) {
+	if err := PullLFS(t.TempDir(), []string{"model.bin"}); err == nil {
+		t.Error("Expected an error outside a repository")
+	}
+	if err := PullLFS(t.TempDir(), nil); err != nil {
+		t.Errorf("Expected no error without files, got %v", err)
+	}
+}
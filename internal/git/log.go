@@ -0,0 +1,128 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit and the files it changed
+type Commit struct {
+	Hash    string    `json:"hash"` // Abbreviated
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Files   []string  `json:"files"` // Relative to the scanned directory, with slashes
+}
+
+// GetChurn returns how many commits changed each file under rootDir since a
+// date or age git understands, such as "1 year ago" ("" for the whole history).
+// Paths are relative to rootDir and use forward slashes; files that no longer
+// exist are included.
+func GetChurn(rootDir, since string) (map[string]int, error) {
+	args := []string{"--pretty=format:"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	output, err := logFiles(rootDir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	churn := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		if path := strings.TrimSpace(line); path != "" {
+			churn[path]++
+		}
+	}
+	return churn, nil
+}
+
+// GetAuthors returns how many commits each author made to each file under
+// rootDir over the whole history, by file and then by author name as mapped by
+// .mailmap. Paths are as GetChurn returns them.
+func GetAuthors(rootDir string) (map[string]map[string]int, error) {
+	// Each commit starts with a NUL and its author, followed by its files
+	output, err := logFiles(rootDir, "--pretty=format:%x00%aN")
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make(map[string]map[string]int)
+	author := ""
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(line, "\x00"); ok {
+			author = strings.TrimSpace(name)
+			continue
+		}
+		path := strings.TrimSpace(line)
+		if path == "" {
+			continue
+		}
+		if authors[path] == nil {
+			authors[path] = make(map[string]int)
+		}
+		authors[path][author]++
+	}
+	return authors, nil
+}
+
+// GetHistory returns the last n commits changing files under rootDir, newest
+// first
+func GetHistory(rootDir string, n int) ([]Commit, error) {
+	return logCommits(rootDir, "-n", strconv.Itoa(n))
+}
+
+// logCommits runs git log with args, such as a revision range, and returns
+// the commits changing files under rootDir, newest first
+func logCommits(rootDir string, args ...string) ([]Commit, error) {
+	// Each commit starts with a NUL and its fields separated by unit separators,
+	// followed by its files
+	output, err := logFiles(rootDir, append([]string{"--pretty=format:%x00%h%x1f%aN%x1f%aI%x1f%s"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(output, "\n") {
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			fields := strings.SplitN(header, "\x1f", 4)
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("unexpected git log output %q", header)
+			}
+			date, err := time.Parse(time.RFC3339, fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse commit date: %w", err)
+			}
+			commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]})
+			continue
+		}
+		if path := strings.TrimSpace(line); path != "" && len(commits) > 0 {
+			commits[len(commits)-1].Files = append(commits[len(commits)-1].Files, path)
+		}
+	}
+	return commits, nil
+}
+
+// logFiles runs git log with the names of the files each commit changed under
+// rootDir, relative to it
+func logFiles(rootDir string, args ...string) (string, error) {
+	// Check if git is available
+	if !isGitCommandAvailable() {
+		return "", fmt.Errorf("git command not available")
+	}
+
+	// Check if the directory is a git repository
+	if !isGitRepository(rootDir) {
+		return "", fmt.Errorf("not a git repository")
+	}
+
+	// Paths are printed unquoted, as they are on disk
+	args = append([]string{"-c", "core.quotePath=false", "log", "--name-only", "--relative", "--no-renames"}, args...)
+	output, err := runGitCommand(rootDir, append(args, "--", ".")...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit history: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// initTestRepo creates a repository in a temp dir, returning it with a
+// function committing a change to files as an author
+func initTestRepo(t *testing.T) (string, func(author, subject string, files ...string)) {
+	t.Helper()
+	if !isGitCommandAvailable() {
+		t.Skip("git command not available")
+	}
+
+	tempDir, err := os.MkdirTemp("", "codectx_log_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	if _, err := runGitCommand(tempDir, "init", "-q"); err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	commit := func(author, subject string, files ...string) {
+		for _, name := range files {
+			path := filepath.Join(tempDir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create dir: %v", err)
+			}
+			content, _ := os.ReadFile(path)
+			if err := os.WriteFile(path, append(content, "change\n"...), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		if _, err := runGitCommand(tempDir, "add", "-A"); err != nil {
+			t.Fatalf("Failed to stage files: %v", err)
+		}
+		if _, err := runGitCommand(tempDir, "-c", "user.name="+author, "-c", "user.email=test@example.com", "commit", "-q", "-m", subject); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	}
+	return tempDir, commit
+}
+
+func TestLog(t *testing.T) {
+	tempDir, commit := initTestRepo(t)
+	commit("Alice", "change", "main.go", "sub/util.go")
+	commit("Bob", "change", "main.go")
+	commit("Alice", "change", "main.go", "sub/util.go")
+
+	churn, err := GetChurn(tempDir, "")
+	if err != nil {
+		t.Fatalf("GetChurn failed: %v", err)
+	}
+	expected := map[string]int{"main.go": 3, "sub/util.go": 2}
+	if !reflect.DeepEqual(churn, expected) {
+		t.Errorf("Expected %v, got %v", expected, churn)
+	}
+
+	// Paths are relative to the directory asked about
+	churn, err = GetChurn(filepath.Join(tempDir, "sub"), "")
+	if err != nil {
+		t.Fatalf("GetChurn failed: %v", err)
+	}
+	if expected := map[string]int{"util.go": 2}; !reflect.DeepEqual(churn, expected) {
+		t.Errorf("Expected %v, got %v", expected, churn)
+	}
+
+	authors, err := GetAuthors(tempDir)
+	if err != nil {
+		t.Fatalf("GetAuthors failed: %v", err)
+	}
+	expectedAuthors := map[string]map[string]int{
+		"main.go":     {"Alice": 2, "Bob": 1},
+		"sub/util.go": {"Alice": 2},
+	}
+	if !reflect.DeepEqual(authors, expectedAuthors) {
+		t.Errorf("Expected %v, got %v", expectedAuthors, authors)
+	}
+
+	history, err := GetHistory(tempDir, 2)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(history))
+	}
+	if history[0].Author != "Alice" || history[0].Subject != "change" || history[0].Hash == "" || history[0].Date.IsZero() {
+		t.Errorf("Unexpected commit %+v", history[0])
+	}
+	if expected := []string{"main.go", "sub/util.go"}; !reflect.DeepEqual(history[0].Files, expected) {
+		t.Errorf("Expected files %v, got %v", expected, history[0].Files)
+	}
+	if expected := []string{"main.go"}; !reflect.DeepEqual(history[1].Files, expected) {
+		t.Errorf("Expected files %v, got %v", expected, history[1].Files)
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SparseCheckout describes a working tree that holds only part of the
+// repository: a sparse checkout, or a partial clone that fetches file
+// contents on demand
+type SparseCheckout struct {
+	Sparse             bool   `json:"sparse"`                         // core.sparseCheckout is set
+	Cone               bool   `json:"cone,omitempty"`                 // The sparse patterns are directories (cone mode)
+	PartialCloneFilter string `json:"partial_clone_filter,omitempty"` // Objects left out of the clone, such as "blob:none"
+
+	// Tracked files under the scanned directory that are not in the working
+	// tree, as paths relative to it: directories (ending in "/") when none of
+	// their files are, and files otherwise
+	NotMaterialized      []string `json:"not_materialized,omitempty"`
+	NotMaterializedFiles int      `json:"not_materialized_files"`
+
+	// Missing are the tracked files that are not in the working tree, relative
+	// to the scanned directory
+	Missing []string `json:"-"`
+	// prefix is the scanned directory relative to the repository root, with a
+	// trailing slash ("" at the root)
+	prefix string
+}
+
+// GetSparseCheckout reports how the working tree at rootDir is sparse. It
+// returns nil when rootDir is not in a repository or the repository is
+// neither a sparse checkout nor a partial clone.
+func GetSparseCheckout(rootDir string) (*SparseCheckout, error) {
+	if !isGitCommandAvailable() || !isGitRepository(rootDir) {
+		return nil, nil
+	}
+
+	sparse := &SparseCheckout{}
+	if value, err := runGitCommand(rootDir, "config", "--bool", "core.sparseCheckout"); err == nil {
+		sparse.Sparse = strings.TrimSpace(value) == "true"
+	}
+	if remote, err := runGitCommand(rootDir, "config", "extensions.partialClone"); err == nil && strings.TrimSpace(remote) != "" {
+		filter, _ := runGitCommand(rootDir, "config", "remote."+strings.TrimSpace(remote)+".partialCloneFilter")
+		sparse.PartialCloneFilter = strings.TrimSpace(filter)
+		if sparse.PartialCloneFilter == "" {
+			sparse.PartialCloneFilter = "unknown"
+		}
+	}
+	if !sparse.Sparse && sparse.PartialCloneFilter == "" {
+		return nil, nil
+	}
+	if !sparse.Sparse {
+		return sparse, nil
+	}
+
+	if value, err := runGitCommand(rootDir, "config", "--bool", "core.sparseCheckoutCone"); err == nil {
+		sparse.Cone = strings.TrimSpace(value) == "true"
+	}
+	prefix, err := runGitCommand(rootDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the path in the repository: %w", err)
+	}
+	sparse.prefix = strings.TrimSpace(prefix)
+
+	// "ls-files -t" tags skip-worktree entries, those not checked out, with S
+	output, err := runGitCommand(rootDir, "ls-files", "-t", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files not checked out: %w", err)
+	}
+	var present []string
+	for _, entry := range strings.Split(output, "\x00") {
+		if len(entry) < 3 {
+			continue
+		}
+		if entry[0] == 'S' {
+			sparse.Missing = append(sparse.Missing, entry[2:])
+		} else {
+			present = append(present, entry[2:])
+		}
+	}
+	sparse.NotMaterialized = collapseMissing(sparse.Missing, present)
+	sparse.NotMaterializedFiles = len(sparse.Missing)
+	return sparse, nil
+}
+
+// collapseMissing lists the missing files compactly: a missing file is listed
+// under the outermost of its directories that holds no present file
+func collapseMissing(missing, present []string) []string {
+	populated := map[string]bool{".": true}
+	for _, file := range present {
+		for dir := path.Dir(file); !populated[dir]; dir = path.Dir(dir) {
+			populated[dir] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range missing {
+		entry := file
+		elements := strings.Split(file, "/")
+		for i := 1; i < len(elements); i++ {
+			if dir := strings.Join(elements[:i], "/"); !populated[dir] {
+				entry = dir + "/"
+				break
+			}
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			paths = append(paths, entry)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Materialize checks out tracked files of a sparse checkout that are not in
+// the working tree, given relative to the scanned directory. Only the files
+// are checked out, as cone-mode patterns could only add whole directories;
+// the sparse patterns are left unchanged, so that "git sparse-checkout
+// reapply" removes them again.
+func (s *SparseCheckout) Materialize(rootDir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	repoRoot, err := GetRepoRoot(rootDir)
+	if err != nil {
+		return err
+	}
+
+	var pathspecs strings.Builder
+	for _, file := range files {
+		pathspecs.WriteString(":(literal)" + s.prefix + file + "\x00")
+	}
+	cmd := exec.Command("git", "checkout", "--ignore-skip-worktree-bits", "--pathspec-from-file=-", "--pathspec-file-nul")
+	cmd.Dir = repoRoot
+	cmd.Stdin = strings.NewReader(pathspecs.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to check out %d files: %w: %s", len(files), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSparseCheckout(t *testing.T) {
+	tempDir, commit := initTestRepo(t)
+	if sparse, err := GetSparseCheckout(tempDir); err != nil || sparse != nil {
+		t.Fatalf("Expected no sparse checkout, got %+v, %v", sparse, err)
+	}
+
+	commit("Alice", "Add files", "main.go", "api/server.go", "web/README.md", "web/app/index.ts", "web/app/style.css", "web/lib/util.ts", "docs/guide.md")
+	if _, err := runGitCommand(tempDir, "sparse-checkout", "set", "--cone", "api"); err != nil {
+		t.Skipf("sparse-checkout not supported: %v", err)
+	}
+
+	sparse, err := GetSparseCheckout(tempDir)
+	if err != nil {
+		t.Fatalf("GetSparseCheckout failed: %v", err)
+	}
+	if sparse == nil || !sparse.Sparse || !sparse.Cone {
+		t.Fatalf("Expected a cone-mode sparse checkout, got %+v", sparse)
+	}
+	if expected := []string{"docs/", "web/"}; !reflect.DeepEqual(sparse.NotMaterialized, expected) {
+		t.Errorf("Expected %v not materialized, got %v", expected, sparse.NotMaterialized)
+	}
+	if sparse.NotMaterializedFiles != 5 {
+		t.Errorf("Expected 5 files not materialized, got %d", sparse.NotMaterializedFiles)
+	}
+
+	// Paths are relative to the scanned directory, and only the files given
+	// are checked out
+	web := filepath.Join(tempDir, "web")
+	if err := sparse.Materialize(tempDir, []string{"web/README.md"}); err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	sparse, err = GetSparseCheckout(web)
+	if err != nil {
+		t.Fatalf("GetSparseCheckout failed: %v", err)
+	}
+	if expected := []string{"app/", "lib/"}; !reflect.DeepEqual(sparse.NotMaterialized, expected) {
+		t.Errorf("Expected %v not materialized, got %v", expected, sparse.NotMaterialized)
+	}
+	if err := sparse.Materialize(web, []string{"app/index.ts"}); err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(web, "app", "index.ts")); err != nil {
+		t.Errorf("Expected web/app/index.ts to be checked out: %v", err)
+	}
+	sparse, err = GetSparseCheckout(tempDir)
+	if err != nil {
+		t.Fatalf("GetSparseCheckout failed: %v", err)
+	}
+	if expected := []string{"docs/", "web/app/style.css", "web/lib/"}; !reflect.DeepEqual(sparse.NotMaterialized, expected) {
+		t.Errorf("Expected %v not materialized, got %v", expected, sparse.NotMaterialized)
+	}
+}
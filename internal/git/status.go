@@ -2,6 +2,7 @@ package git
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -97,8 +98,12 @@ func GetGitStatusSummary(rootDir string) (*GitStatusSummary, error) {
 	}
 
 	// Process untracked files
+	trackedSet := make(map[string]bool, len(trackedFiles))
+	for _, path := range trackedFiles {
+		trackedSet[path] = true
+	}
 	for path, statusCode := range statuses {
-		if !isTracked(trackedFiles, path) {
+		if !trackedSet[path] {
 			status := &FileStatus{
 				Path:       path,
 				StatusCode: statusCode,
@@ -123,30 +128,30 @@ func GetGitStatusSummary(rootDir string) (*GitStatusSummary, error) {
 }
 
 // PrintGitStatus prints the Git status in a human-readable format
-func PrintGitStatus(rootDir string) error {
+func PrintGitStatus(rootDir string, w io.Writer) error {
 	summary, err := GetGitStatusSummary(rootDir)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Git Status Summary:")
-	fmt.Printf("  Branch: %s\n", summary.BranchName)
-	fmt.Printf("  Commit: %s\n", summary.CommitHash)
-	fmt.Printf("  Last commit: %s\n", summary.LastCommitTime)
-	fmt.Printf("  Repository state: %s\n", getRepositoryState(summary.IsDirty))
-	fmt.Println()
-	fmt.Printf("  Total files: %d\n", summary.TotalFiles)
-	fmt.Printf("  Tracked files: %d\n", summary.TrackedFiles)
-	fmt.Printf("  Untracked files: %d\n", summary.UntrackedFiles)
-	fmt.Printf("  Modified files: %d\n", summary.ModifiedFiles)
-	fmt.Printf("  Staged files: %d\n", summary.StagedFiles)
-	fmt.Println()
+	fmt.Fprintln(w, "Git Status Summary:")
+	fmt.Fprintf(w, "  Branch: %s\n", summary.BranchName)
+	fmt.Fprintf(w, "  Commit: %s\n", summary.CommitHash)
+	fmt.Fprintf(w, "  Last commit: %s\n", summary.LastCommitTime)
+	fmt.Fprintf(w, "  Repository state: %s\n", getRepositoryState(summary.IsDirty))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Total files: %d\n", summary.TotalFiles)
+	fmt.Fprintf(w, "  Tracked files: %d\n", summary.TrackedFiles)
+	fmt.Fprintf(w, "  Untracked files: %d\n", summary.UntrackedFiles)
+	fmt.Fprintf(w, "  Modified files: %d\n", summary.ModifiedFiles)
+	fmt.Fprintf(w, "  Staged files: %d\n", summary.StagedFiles)
+	fmt.Fprintln(w)
 
 	if summary.ModifiedFiles > 0 || summary.UntrackedFiles > 0 {
-		fmt.Println("  Changed files:")
+		fmt.Fprintln(w, "  Changed files:")
 		for _, status := range summary.FileStatuses {
 			if status.StatusCode != "" {
-				fmt.Printf("    %s %s\n", status.StatusCode, status.Path)
+				fmt.Fprintf(w, "    %s %s\n", status.StatusCode, status.Path)
 			}
 		}
 	}
@@ -156,16 +161,6 @@ func PrintGitStatus(rootDir string) error {
 
 // Helper functions
 
-// isTracked checks if a file is tracked by Git
-func isTracked(trackedFiles []string, path string) bool {
-	for _, trackedPath := range trackedFiles {
-		if trackedPath == path {
-			return true
-		}
-	}
-	return false
-}
-
 // isModified checks if a file is modified
 func isModified(statusCode string) bool {
 	return strings.Contains(statusCode, "M") || strings.Contains(statusCode, "D") || strings.Contains(statusCode, "R")
@@ -0,0 +1,329 @@
+package highlight
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ANSI escape sequences used for highlighting
+const (
+	Reset    = "\x1b[0m"
+	Dim      = "\x1b[2m"
+	Keyword  = "\x1b[35m"
+	String   = "\x1b[32m"
+	Comment  = "\x1b[90m"
+	Number   = "\x1b[36m"
+	Function = "\x1b[34m"
+	Header   = "\x1b[1;36m"
+	Dir      = "\x1b[1;34m"
+	Marker   = "\x1b[33m"
+)
+
+// language describes the lexical rules used to highlight one family of languages
+type language struct {
+	lineComments    []string
+	blockComment    [2]string
+	quotes          string // Characters that delimit strings
+	multilineQuotes string // Quotes whose strings may span lines, like Go's backticks
+	keywords        map[string]bool
+	caseInsensitive bool
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	goLanguage = &language{
+		lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"'`", multilineQuotes: "`",
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto if import
+			interface map package range return select struct switch type var nil true false iota`),
+	}
+	cLikeLanguage = &language{
+		lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"'",
+		keywords: words(`abstract auto bool boolean break case catch char class const continue default delete do
+			double else enum extends extern false final finally float for fun func goto guard if implements import
+			in inline int interface internal is let long namespace new null nullptr object override package
+			private protected public return short signed sizeof static struct super switch template this throw
+			throws true try typedef typename union unsigned using val var virtual void volatile when where while`),
+	}
+	jsLanguage = &language{
+		lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"'`", multilineQuotes: "`",
+		keywords: words(`as async await break case catch class const continue debugger default delete do else
+			enum export extends false finally for from function if implements import in instanceof interface let
+			new null of private protected public return static super switch this throw true try type typeof
+			undefined var void while with yield`),
+	}
+	rustLanguage = &language{
+		lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"",
+		keywords: words(`as async await break const continue crate dyn else enum extern false fn for if impl in
+			let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use
+			where while`),
+	}
+	pythonLanguage = &language{
+		lineComments: []string{"#"}, quotes: "\"'",
+		keywords: words(`and as assert async await break class continue def del elif else except False finally
+			for from global if import in is lambda None nonlocal not or pass raise return True try while with yield`),
+	}
+	rubyLanguage = &language{
+		lineComments: []string{"#"}, quotes: "\"'",
+		keywords: words(`alias and begin break case class def do else elsif end ensure false for if in
+			module next nil not or redo rescue retry return self super then true undef unless until when while yield`),
+	}
+	shellLanguage = &language{
+		lineComments: []string{"#"}, quotes: "\"'",
+		keywords: words(`case do done elif else esac export fi for function if in local return select then until while`),
+	}
+	phpLanguage = &language{
+		lineComments: []string{"//", "#"}, blockComment: [2]string{"/*", "*/"}, quotes: "\"'",
+		keywords: words(`abstract array as break case catch class const continue default do echo else elseif
+			extends false final finally fn for foreach function if implements interface namespace new null
+			private protected public return static switch throw trait true try use var while`),
+	}
+	sqlLanguage = &language{
+		lineComments: []string{"--"}, blockComment: [2]string{"/*", "*/"}, quotes: "'", caseInsensitive: true,
+		keywords: words(`add all alter and as asc begin by case commit create delete desc distinct drop else end
+			exists from group having if in index inner insert into is join key left like limit not null on or
+			order outer primary references right rollback select set table then union unique update values
+			view when where with`),
+	}
+	configLanguage = &language{
+		lineComments: []string{"#"}, quotes: "\"'",
+		keywords: words(`true false null yes no on off`),
+	}
+)
+
+// languages maps lower-cased file extensions to their lexical rules
+var languages = map[string]*language{
+	".go":    goLanguage,
+	".c":     cLikeLanguage,
+	".h":     cLikeLanguage,
+	".cc":    cLikeLanguage,
+	".cpp":   cLikeLanguage,
+	".cxx":   cLikeLanguage,
+	".hpp":   cLikeLanguage,
+	".java":  cLikeLanguage,
+	".cs":    cLikeLanguage,
+	".kt":    cLikeLanguage,
+	".kts":   cLikeLanguage,
+	".scala": cLikeLanguage,
+	".swift": cLikeLanguage,
+	".dart":  cLikeLanguage,
+	".js":    jsLanguage,
+	".jsx":   jsLanguage,
+	".mjs":   jsLanguage,
+	".cjs":   jsLanguage,
+	".ts":    jsLanguage,
+	".tsx":   jsLanguage,
+	".rs":    rustLanguage,
+	".py":    pythonLanguage,
+	".rb":    rubyLanguage,
+	".sh":    shellLanguage,
+	".bash":  shellLanguage,
+	".zsh":   shellLanguage,
+	".php":   phpLanguage,
+	".sql":   sqlLanguage,
+	".yaml":  configLanguage,
+	".yml":   configLanguage,
+	".toml":  configLanguage,
+}
+
+// languageIDs maps language identifiers (see the language package) to their lexical
+// rules, for files recognized by their shebang line or modeline
+var languageIDs = map[string]*language{
+	"go":         goLanguage,
+	"c":          cLikeLanguage,
+	"cpp":        cLikeLanguage,
+	"java":       cLikeLanguage,
+	"csharp":     cLikeLanguage,
+	"kotlin":     cLikeLanguage,
+	"scala":      cLikeLanguage,
+	"swift":      cLikeLanguage,
+	"groovy":     cLikeLanguage,
+	"javascript": jsLanguage,
+	"typescript": jsLanguage,
+	"rust":       rustLanguage,
+	"python":     pythonLanguage,
+	"starlark":   pythonLanguage,
+	"ruby":       rubyLanguage,
+	"shell":      shellLanguage,
+	"php":        phpLanguage,
+	"sql":        sqlLanguage,
+	"yaml":       configLanguage,
+	"toml":       configLanguage,
+}
+
+// Highlighter colors the lines of one file, carrying block comments and
+// multi-line strings from one line to the next
+type Highlighter struct {
+	lang      *language
+	inComment bool
+	openQuote byte
+}
+
+// For returns a highlighter for the file's language, or nil if it is not supported
+func For(path string) *Highlighter {
+	lang, ok := languages[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil
+	}
+	return &Highlighter{lang: lang}
+}
+
+// ForLanguage returns a highlighter for a language identifier, or nil if it is not supported
+func ForLanguage(id string) *Highlighter {
+	lang, ok := languageIDs[id]
+	if !ok {
+		return nil
+	}
+	return &Highlighter{lang: lang}
+}
+
+// Paint wraps text in an ANSI color, leaving empty text alone
+func Paint(color, text string) string {
+	if text == "" {
+		return ""
+	}
+	return color + text + Reset
+}
+
+// Line returns the line with ANSI syntax highlighting
+func (h *Highlighter) Line(line string) string {
+	var sb strings.Builder
+	i := 0
+
+	// Finish a block comment or string left open by a previous line
+	if h.inComment {
+		end := strings.Index(line, h.lang.blockComment[1])
+		if end < 0 {
+			return Paint(Comment, line)
+		}
+		i = end + len(h.lang.blockComment[1])
+		sb.WriteString(Paint(Comment, line[:i]))
+		h.inComment = false
+	} else if h.openQuote != 0 {
+		end := strings.IndexByte(line, h.openQuote)
+		if end < 0 {
+			return Paint(String, line)
+		}
+		i = end + 1
+		sb.WriteString(Paint(String, line[:i]))
+		h.openQuote = 0
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		c := line[i]
+
+		if start := h.lang.blockComment[0]; start != "" && strings.HasPrefix(rest, start) {
+			end := strings.Index(rest[len(start):], h.lang.blockComment[1])
+			if end < 0 {
+				h.inComment = true
+				sb.WriteString(Paint(Comment, rest))
+				break
+			}
+			n := len(start) + end + len(h.lang.blockComment[1])
+			sb.WriteString(Paint(Comment, rest[:n]))
+			i += n
+			continue
+		}
+		if h.isLineComment(rest) {
+			sb.WriteString(Paint(Comment, rest))
+			break
+		}
+
+		switch {
+		case strings.IndexByte(h.lang.quotes, c) >= 0:
+			n, closed := scanString(rest, c)
+			if !closed && strings.IndexByte(h.lang.multilineQuotes, c) >= 0 {
+				h.openQuote = c
+			}
+			sb.WriteString(Paint(String, rest[:n]))
+			i += n
+		case isDigit(c):
+			n := scanWhile(rest, isNumberChar)
+			sb.WriteString(Paint(Number, rest[:n]))
+			i += n
+		case isWordStart(c):
+			n := scanWhile(rest, isWordChar)
+			word := rest[:n]
+			switch {
+			case h.isKeyword(word):
+				sb.WriteString(Paint(Keyword, word))
+			case strings.HasPrefix(strings.TrimLeft(rest[n:], " "), "("):
+				sb.WriteString(Paint(Function, word))
+			default:
+				sb.WriteString(word)
+			}
+			i += n
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// isLineComment reports whether s starts with a line comment
+func (h *Highlighter) isLineComment(s string) bool {
+	for _, prefix := range h.lang.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyword reports whether word is a keyword of the language
+func (h *Highlighter) isKeyword(word string) bool {
+	if h.lang.caseInsensitive {
+		word = strings.ToLower(word)
+	}
+	return h.lang.keywords[word]
+}
+
+// scanString returns the length of the string starting at s[0] and whether it
+// was closed on this line. Backslash escapes are honored except in backtick strings.
+func scanString(s string, quote byte) (int, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1, true
+		}
+	}
+	return len(s), false
+}
+
+// scanWhile returns the length of the prefix of s whose bytes satisfy f
+func scanWhile(s string, f func(byte) bool) int {
+	i := 0
+	for i < len(s) && f(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
+
+// isNumberChar accepts the characters of numeric literals such as 0x1F, 1.5e3, and 1_000
+func isNumberChar(c byte) bool {
+	return isWordChar(c) || c == '.'
+}
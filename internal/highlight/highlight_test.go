@@ -0,0 +1,63 @@
+package highlight
+
+import "testing"
+
+func TestHighlighter_Line(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		lines    []string
+		expected []string
+	}{
+		{
+			name:     "Go keywords, calls, strings, and numbers",
+			path:     "main.go",
+			lines:    []string{`func main() { fmt.Println("a\"b", 42) }`},
+			expected: []string{Keyword + "func" + Reset + " " + Function + "main" + Reset + "() { fmt." + Function + "Println" + Reset + "(" + String + `"a\"b"` + Reset + ", " + Number + "42" + Reset + ") }"},
+		},
+		{
+			name:     "Block comment across lines",
+			path:     "lib.c",
+			lines:    []string{"int x; /* start", "middle", "end */ return"},
+			expected: []string{Keyword + "int" + Reset + " x; " + Comment + "/* start" + Reset, Comment + "middle" + Reset, Comment + "end */" + Reset + " " + Keyword + "return" + Reset},
+		},
+		{
+			name:     "Raw string across lines",
+			path:     "query.go",
+			lines:    []string{"q := `SELECT", "FROM t` // done"},
+			expected: []string{"q := " + String + "`SELECT" + Reset, String + "FROM t`" + Reset + " " + Comment + "// done" + Reset},
+		},
+		{
+			name:     "Python comment",
+			path:     "app.py",
+			lines:    []string{"x = 1.5  # ratio"},
+			expected: []string{"x = " + Number + "1.5" + Reset + "  " + Comment + "# ratio" + Reset},
+		},
+		{
+			name:     "Case-insensitive SQL keywords",
+			path:     "schema.sql",
+			lines:    []string{"select id -- key"},
+			expected: []string{Keyword + "select" + Reset + " id " + Comment + "-- key" + Reset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			highlighter := For(tt.path)
+			if highlighter == nil {
+				t.Fatalf("Expected a highlighter for %s", tt.path)
+			}
+			for i, line := range tt.lines {
+				if result := highlighter.Line(line); result != tt.expected[i] {
+					t.Errorf("Line %d: expected %q, got %q", i+1, tt.expected[i], result)
+				}
+			}
+		})
+	}
+}
+
+func TestFor_Unsupported(t *testing.T) {
+	if For("notes.txt") != nil {
+		t.Error("Expected no highlighter for a plain text file")
+	}
+}
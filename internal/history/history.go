@@ -0,0 +1,81 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"codectx/internal/config"
+)
+
+// FileName is the name of the run log in the codectx directory
+const FileName = "history.jsonl"
+
+// Entry records one codectx run
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Dir        string    `json:"dir"`  // Working directory the command ran in
+	Args       []string  `json:"args"` // Command line arguments, without the program name
+	Files      int       `json:"files"`
+	Tokens     int64     `json:"tokens"` // Estimated tokens of the output
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// DefaultPath returns the path of the run log, ~/.codectx/history.jsonl
+func DefaultPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Append adds an entry to the end of the run log at path, creating the file
+// and its directory if needed. The log is only readable by the user.
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads the entries of the run log at path, oldest first. A missing log
+// has no entries, and malformed lines are skipped.
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
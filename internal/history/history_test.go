@@ -0,0 +1,73 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "history-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, ".codectx", FileName)
+
+	// A missing log has no entries
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries, got %d", len(entries))
+	}
+
+	first := Entry{
+		Time:       time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Dir:        "/home/user/project",
+		Args:       []string{"-f", "markdown", "src"},
+		Files:      12,
+		Tokens:     3456,
+		DurationMS: 420,
+	}
+	second := Entry{Time: first.Time.Add(time.Hour), Dir: "/tmp", Args: []string{}}
+	for _, entry := range []Entry{first, second} {
+		if err := Append(path, entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// Malformed lines are skipped
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	file.WriteString("not json\n")
+	file.Close()
+
+	entries, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if !reflect.DeepEqual(entries[0], first) {
+		t.Errorf("Expected %+v, got %+v", first, entries[0])
+	}
+	if entries[1].Dir != "/tmp" {
+		t.Errorf("Expected second entry in /tmp, got %s", entries[1].Dir)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat log: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
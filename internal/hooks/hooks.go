@@ -0,0 +1,170 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a hook command without a timeout of its own
+const DefaultTimeout = 30 * time.Second
+
+// Failure policies of a hook
+const (
+	FailAbort  = "abort"  // Stop codectx with an error
+	FailWarn   = "warn"   // Print a warning and continue (default)
+	FailIgnore = "ignore" // Continue silently
+)
+
+// Hook is a shell command run at one stage of a codectx run
+type Hook struct {
+	Command   string   `json:"command"`
+	Match     []string `json:"match,omitempty"`      // Glob patterns of the files a transform applies to (default: all)
+	Timeout   string   `json:"timeout,omitempty"`    // Duration such as "10s" (default: 30s)
+	OnFailure string   `json:"on_failure,omitempty"` // abort, warn, or ignore
+}
+
+// Hooks holds the hooks configured for each stage
+type Hooks struct {
+	PreScan          []Hook `json:"pre-scan,omitempty"`           // Run before the directory is scanned
+	PerFileTransform []Hook `json:"per-file-transform,omitempty"` // Pipe each file's content through a command
+	PostOutput       []Hook `json:"post-output,omitempty"`        // Run after the output is written
+}
+
+// Validate checks the timeouts and failure policies of every hook
+func (h *Hooks) Validate() error {
+	stages := map[string][]Hook{"pre-scan": h.PreScan, "per-file-transform": h.PerFileTransform, "post-output": h.PostOutput}
+	for stage, hooks := range stages {
+		for _, hook := range hooks {
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("%s hook has no command", stage)
+			}
+			if _, err := hook.timeout(); err != nil {
+				return fmt.Errorf("%s hook %q: %w", stage, hook.Command, err)
+			}
+			switch hook.OnFailure {
+			case "", FailAbort, FailWarn, FailIgnore:
+			default:
+				return fmt.Errorf("%s hook %q: invalid on_failure: %s (abort, warn, ignore)", stage, hook.Command, hook.OnFailure)
+			}
+		}
+	}
+	return nil
+}
+
+// timeout returns the hook's timeout
+func (h Hook) timeout() (time.Duration, error) {
+	if h.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout: %s", h.Timeout)
+	}
+	return timeout, nil
+}
+
+// Matches reports whether a transform applies to a slash-separated relative path.
+// Patterns without a slash are matched against the file name.
+func (h Hook) Matches(relPath string) bool {
+	if len(h.Match) == 0 {
+		return true
+	}
+	for _, pattern := range h.Match {
+		target := relPath
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(relPath)
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the hook command in a shell in dir, with env added to the
+// environment. stdout receives the command's output.
+func (h Hook) Run(dir string, env []string, stdin io.Reader, stdout io.Writer) error {
+	timeout, err := h.timeout()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	// Don't wait for children that keep the output open after a timeout
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("hook %q timed out after %s", h.Command, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("hook %q failed: %w", h.Command, err)
+	}
+	return nil
+}
+
+// handleFailure applies the hook's failure policy, returning the error to stop with
+func (h Hook) handleFailure(err error) error {
+	switch h.OnFailure {
+	case FailAbort:
+		return err
+	case FailIgnore:
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+}
+
+// RunStage runs the hooks of a stage in order. Their output goes to stderr so
+// that it does not mix with the generated context.
+func RunStage(hooks []Hook, dir string, env []string) error {
+	for _, hook := range hooks {
+		if err := hook.Run(dir, env, nil, os.Stderr); err != nil {
+			if err := hook.handleFailure(err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Transform pipes content through the transform hooks matching relPath, in
+// order. A failed transform leaves the content unchanged unless it aborts.
+func Transform(hooks []Hook, relPath string, content []byte, dir string, env []string) ([]byte, error) {
+	for _, hook := range hooks {
+		if !hook.Matches(relPath) {
+			continue
+		}
+		var out bytes.Buffer
+		if err := hook.Run(dir, env, bytes.NewReader(content), &out); err != nil {
+			if err := hook.handleFailure(fmt.Errorf("%s: %w", relPath, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		content = out.Bytes()
+	}
+	return content, nil
+}
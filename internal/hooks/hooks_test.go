@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestHook_Matches(t *testing.T) {
+	tests := []struct {
+		match    []string
+		relPath  string
+		expected bool
+	}{
+		{nil, "src/main.go", true},
+		{[]string{"*.go"}, "src/main.go", true},
+		{[]string{"*.js"}, "src/main.go", false},
+		{[]string{"src/*.go"}, "src/main.go", true},
+		{[]string{"src/*.go"}, "cmd/main.go", false},
+		{[]string{"*.js", "*.go"}, "main.go", true},
+	}
+
+	for _, test := range tests {
+		hook := Hook{Command: "cat", Match: test.match}
+		if result := hook.Matches(test.relPath); result != test.expected {
+			t.Errorf("Matches(%v, %q): expected %v, got %v", test.match, test.relPath, test.expected, result)
+		}
+	}
+}
+
+func TestHooks_Validate(t *testing.T) {
+	tests := []struct {
+		hooks       Hooks
+		expectError bool
+	}{
+		{Hooks{PreScan: []Hook{{Command: "make generate", Timeout: "1m", OnFailure: FailAbort}}}, false},
+		{Hooks{PostOutput: []Hook{{Command: ""}}}, true},
+		{Hooks{PerFileTransform: []Hook{{Command: "cat", Timeout: "soon"}}}, true},
+		{Hooks{PerFileTransform: []Hook{{Command: "cat", OnFailure: "retry"}}}, true},
+	}
+
+	for i, test := range tests {
+		err := test.hooks.Validate()
+		if (err != nil) != test.expectError {
+			t.Errorf("Test %d: expected error %v, got %v", i, test.expectError, err)
+		}
+	}
+}
+
+func TestTransformAndRunStage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use sh in this test")
+	}
+
+	tempDir, err := os.MkdirTemp("", "hooks-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	transforms := []Hook{
+		{Command: "tr a-z A-Z", Match: []string{"*.txt"}},
+		{Command: "exit 1", OnFailure: FailIgnore},
+		{Command: `sed "s/$/ ($CODECTX_FILE)/"`},
+	}
+	content, err := Transform(transforms, "notes.txt", []byte("secret\n"), tempDir, []string{"CODECTX_FILE=notes.txt"})
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if !bytes.Equal(content, []byte("SECRET (notes.txt)\n")) {
+		t.Errorf("Expected transformed content, got %q", content)
+	}
+
+	// Aborting transforms and timeouts return an error
+	if _, err := Transform([]Hook{{Command: "exit 1", OnFailure: FailAbort}}, "a.go", nil, tempDir, nil); err == nil {
+		t.Errorf("Expected error from an aborting hook, got nil")
+	}
+	if _, err := Transform([]Hook{{Command: "exec sleep 5", Timeout: "100ms", OnFailure: FailAbort}}, "a.go", nil, tempDir, nil); err == nil {
+		t.Errorf("Expected timeout error, got nil")
+	}
+
+	// Stage hooks run in the given directory
+	stage := []Hook{{Command: "touch ran"}}
+	if err := RunStage(stage, tempDir, nil); err != nil {
+		t.Fatalf("RunStage failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "ran")); err != nil {
+		t.Errorf("Expected the hook to run in %s: %v", tempDir, err)
+	}
+}
@@ -0,0 +1,218 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF header decoder
+	_ "image/jpeg" // Register the JPEG header decoder
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// Modes of handling image files
+const (
+	ModePlaceholder = "placeholder" // Describe the image in place of its content
+	ModeEmbed       = "embed"       // Also embed a thumbnail in HTML output
+	ModeSkip        = "skip"        // Leave images out, like other binary files
+)
+
+// DefaultThumbnailSize is the longest side of an embedded thumbnail in pixels
+const DefaultThumbnailSize = 256
+
+// maxThumbnailSource bounds the size of images decoded for thumbnails
+const maxThumbnailSource = 16 * 1024 * 1024
+
+// imageFormats maps lower-cased image extensions to their format names
+var imageFormats = map[string]string{
+	".png":  "PNG",
+	".jpg":  "JPEG",
+	".jpeg": "JPEG",
+	".gif":  "GIF",
+	".bmp":  "BMP",
+	".webp": "WebP",
+	".ico":  "ICO",
+}
+
+// Info describes an image file, read from its header without decoding the pixels
+type Info struct {
+	Format string `json:"format"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Size   int64  `json:"size_bytes"`
+}
+
+// ParseMode validates an --images mode
+func ParseMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case ModePlaceholder, ModeEmbed, ModeSkip:
+		return strings.ToLower(mode), nil
+	default:
+		return "", fmt.Errorf("unknown image mode %q (expected placeholder, embed, or skip)", mode)
+	}
+}
+
+// IsImage reports whether a path has an image extension
+func IsImage(path string) bool {
+	_, ok := imageFormats[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// ReadInfo reads the format, dimensions, and size of an image. Dimensions are left
+// at zero when the header cannot be read.
+func ReadInfo(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Format: imageFormats[strings.ToLower(filepath.Ext(path))], Size: stat.Size()}
+
+	// The BMP, WebP, and ICO dimensions are within the first 30 bytes; the other
+	// formats are read by the standard library's header decoders
+	header, err := io.ReadAll(io.LimitReader(file, 32))
+	if err != nil {
+		return nil, err
+	}
+	switch info.Format {
+	case "BMP":
+		info.Width, info.Height = bmpSize(header)
+	case "WebP":
+		info.Width, info.Height = webpSize(header)
+	case "ICO":
+		info.Width, info.Height = icoSize(header)
+	default:
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if config, _, err := image.DecodeConfig(file); err == nil {
+			info.Width, info.Height = config.Width, config.Height
+		}
+	}
+	return info, nil
+}
+
+// Describe returns a one-line description such as "PNG image, 640x480, 12.3KB",
+// with the size in units
+func (i *Info) Describe(units utils.SizeUnits) string {
+	parts := []string{i.Format + " image"}
+	if i.Width > 0 && i.Height > 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d", i.Width, i.Height))
+	}
+	parts = append(parts, utils.FormatSize(i.Size, units))
+	return strings.Join(parts, ", ")
+}
+
+// Placeholder returns the text written in place of an image's content
+func (i *Info) Placeholder(units utils.SizeUnits) string {
+	return "[" + i.Describe(units) + "]"
+}
+
+// Thumbnail decodes a PNG, JPEG, or GIF image and returns a PNG copy scaled down so
+// that its longest side is at most maxSide pixels
+func Thumbnail(path string, maxSide int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if stat, err := file.Stat(); err == nil && stat.Size() > maxThumbnailSource {
+		return nil, fmt.Errorf("%s is too large for a thumbnail", filepath.Base(path))
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaleDown(src, maxSide)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown resizes an image with nearest-neighbor sampling so that it fits in a
+// maxSide square, keeping its aspect ratio
+func scaleDown(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) {
+		return src
+	}
+
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x*w/tw, bounds.Min.Y+y*h/th))
+		}
+	}
+	return dst
+}
+
+// bmpSize reads the dimensions from a BMP header
+func bmpSize(header []byte) (int, int) {
+	if len(header) < 26 || !bytes.HasPrefix(header, []byte("BM")) {
+		return 0, 0
+	}
+	width := int32(binary.LittleEndian.Uint32(header[18:22]))
+	height := int32(binary.LittleEndian.Uint32(header[22:26]))
+	if height < 0 {
+		// Top-down bitmaps store a negative height
+		height = -height
+	}
+	return int(width), int(height)
+}
+
+// webpSize reads the dimensions from a WebP header (lossy, lossless, or extended)
+func webpSize(header []byte) (int, int) {
+	if len(header) < 30 || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return 0, 0
+	}
+	chunk := header[20:]
+	switch string(header[12:16]) {
+	case "VP8 ":
+		return int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3FFF), int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3FFF)
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		return int(bits&0x3FFF) + 1, int(bits>>14&0x3FFF) + 1
+	case "VP8X":
+		width := int(chunk[4]) | int(chunk[5])<<8 | int(chunk[6])<<16
+		height := int(chunk[7]) | int(chunk[8])<<8 | int(chunk[9])<<16
+		return width + 1, height + 1
+	}
+	return 0, 0
+}
+
+// icoSize reads the dimensions of the first image in an ICO file
+func icoSize(header []byte) (int, int) {
+	if len(header) < 8 || !bytes.HasPrefix(header, []byte{0, 0, 1, 0}) {
+		return 0, 0
+	}
+	// A stored size of 0 means 256 pixels
+	width, height := int(header[6]), int(header[7])
+	if width == 0 {
+		width = 256
+	}
+	if height == 0 {
+		height = 256
+	}
+	return width, height
+}
@@ -0,0 +1,136 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codectx/internal/utils"
+)
+
+func TestReadInfo(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "images_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var pngData, gifData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if err := gif.Encode(&gifData, image.NewPaletted(image.Rect(0, 0, 16, 8), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatalf("Failed to encode GIF: %v", err)
+	}
+
+	bmp := make([]byte, 54)
+	copy(bmp, "BM")
+	binary.LittleEndian.PutUint32(bmp[18:], 100)
+	binary.LittleEndian.PutUint32(bmp[22:], uint32(0xFFFFFFCE)) // -50: top-down
+
+	webp := make([]byte, 30)
+	copy(webp, "RIFF")
+	copy(webp[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(webp[21:], uint32(320-1)|uint32(200-1)<<14)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected Info
+	}{
+		{"photo.png", pngData.Bytes(), Info{Format: "PNG", Width: 640, Height: 480}},
+		{"anim.GIF", gifData.Bytes(), Info{Format: "GIF", Width: 16, Height: 8}},
+		{"icon.bmp", bmp, Info{Format: "BMP", Width: 100, Height: 50}},
+		{"hero.webp", webp, Info{Format: "WebP", Width: 320, Height: 200}},
+		{"broken.jpg", []byte("not a jpeg"), Info{Format: "JPEG"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			if !IsImage(path) {
+				t.Errorf("Expected %s to be an image", tt.name)
+			}
+
+			info, err := ReadInfo(path)
+			if err != nil {
+				t.Fatalf("ReadInfo failed: %v", err)
+			}
+			tt.expected.Size = int64(len(tt.data))
+			if *info != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, *info)
+			}
+		})
+	}
+}
+
+func TestInfo_Describe(t *testing.T) {
+	tests := []struct {
+		info     Info
+		expected string
+	}{
+		{Info{Format: "PNG", Width: 640, Height: 480, Size: 12800}, "PNG image, 640x480, 12.5KB"},
+		{Info{Format: "JPEG", Size: 900}, "JPEG image, 900B"},
+	}
+
+	for _, tt := range tests {
+		if result := tt.info.Describe(utils.BinaryUnits); result != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, result)
+		}
+	}
+	if result := tests[1].info.Placeholder(utils.BinaryUnits); result != "[JPEG image, 900B]" {
+		t.Errorf("Expected bracketed placeholder, got %q", result)
+	}
+	if result := tests[0].info.Describe(utils.SIUnits); result != "PNG image, 640x480, 12.8kB" {
+		t.Errorf("Expected the size in SI units, got %q", result)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []string{"placeholder", "embed", "SKIP"} {
+		if _, err := ParseMode(mode); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", mode, err)
+		}
+	}
+	if _, err := ParseMode("inline"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "images_thumbnail_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 1000, 500))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	path := filepath.Join(tempDir, "wide.png")
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	thumbnail, err := Thumbnail(path, 100)
+	if err != nil {
+		t.Fatalf("Thumbnail failed: %v", err)
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(thumbnail))
+	if err != nil {
+		t.Fatalf("Thumbnail is not a PNG: %v", err)
+	}
+	if config.Width != 100 || config.Height != 50 {
+		t.Errorf("Expected 100x50 thumbnail, got %dx%d", config.Width, config.Height)
+	}
+}
@@ -0,0 +1,230 @@
+package language
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// modelineLines is the number of lines at each end of a file searched for modelines
+const modelineLines = 5
+
+// headSize and tailSize bound how much of a file is read to find a shebang or modeline
+const (
+	headSize = 4096
+	tailSize = 1024
+)
+
+// interpreters maps interpreter names in shebang lines, without version numbers, to language identifiers
+var interpreters = map[string]string{
+	"python":   "python",
+	"pypy":     "python",
+	"node":     "javascript",
+	"nodejs":   "javascript",
+	"deno":     "javascript",
+	"bun":      "javascript",
+	"ts-node":  "typescript",
+	"tsx":      "typescript",
+	"sh":       "shell",
+	"bash":     "shell",
+	"zsh":      "shell",
+	"dash":     "shell",
+	"ksh":      "shell",
+	"fish":     "shell",
+	"ruby":     "ruby",
+	"perl":     "perl",
+	"php":      "php",
+	"lua":      "lua",
+	"rscript":  "r",
+	"groovy":   "groovy",
+	"kotlin":   "kotlin",
+	"scala":    "scala",
+	"swift":    "swift",
+	"pwsh":     "powershell",
+	"make":     "makefile",
+	"starlark": "starlark",
+}
+
+// modeNames maps editor mode names that aren't language identifiers or extensions to language identifiers
+var modeNames = map[string]string{
+	"make":         "makefile",
+	"shell-script": "shell",
+	"js":           "javascript",
+	"c++":          "cpp",
+	"cs":           "csharp",
+	"bzl":          "starlark",
+	"groovy":       "groovy",
+	"jenkinsfile":  "groovy",
+}
+
+var (
+	// emacsModePattern matches Emacs file variables such as "-*- mode: python; coding: utf-8 -*-"
+	emacsModePattern = regexp.MustCompile(`-\*-.*?\bmode\s*:\s*([\w+.-]+).*?-\*-`)
+
+	// emacsShortModePattern matches the short form "-*- python -*-"
+	emacsShortModePattern = regexp.MustCompile(`-\*-\s*([\w+.-]+)\s*-\*-`)
+
+	// vimModePattern matches Vim modelines such as "vim: set ft=python :" or "vi: filetype=sh"
+	vimModePattern = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+.-]+)`)
+
+	// versionSuffix matches the version of an interpreter name, as in python3.11
+	versionSuffix = regexp.MustCompile(`[\d.]+$`)
+)
+
+// DetectFile returns the language of the file at path like Detect, and reads
+// the file to find a shebang line or an Emacs or Vim modeline when the name and
+// extension don't tell. It reports false if the language is still unknown.
+func DetectFile(path string) (Language, bool) {
+	if lang, ok := Detect(path); ok {
+		return lang, true
+	}
+	head, tail, err := readEnds(path)
+	if err != nil {
+		return Language{}, false
+	}
+	if id, ok := FromContent(head, tail); ok {
+		return Lookup(id), true
+	}
+	return Language{}, false
+}
+
+// FenceFile returns the Markdown code fence identifier for the file at path like
+// Fence, detecting the language from the content when the name doesn't tell
+func FenceFile(path string) string {
+	if lang, ok := DetectFile(path); ok {
+		return lang.Fence
+	}
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
+// FromContent returns the language identifier named by a modeline in the first
+// or last lines of a file, or by its shebang line. head and tail are the start
+// and the end of the file, which may overlap.
+func FromContent(head, tail []byte) (string, bool) {
+	lines := headLines(head)
+	for _, line := range append(lines, tailLines(tail)...) {
+		if id, ok := modeline(line); ok {
+			return id, true
+		}
+	}
+	if len(lines) > 0 {
+		return shebang(lines[0])
+	}
+	return "", false
+}
+
+// shebang returns the language of the interpreter named by a "#!" line
+func shebang(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#!") {
+		return "", false
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	// "#!/usr/bin/env -S python3 -u" names the interpreter after env and its options
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	name := strings.ToLower(interpreter)
+	if id, ok := interpreters[name]; ok {
+		return id, true
+	}
+	id, ok := interpreters[versionSuffix.ReplaceAllString(name, "")]
+	return id, ok
+}
+
+// modeline returns the language named by an Emacs or Vim modeline in line
+func modeline(line string) (string, bool) {
+	var mode string
+	if match := emacsModePattern.FindStringSubmatch(line); match != nil {
+		mode = match[1]
+	} else if match := emacsShortModePattern.FindStringSubmatch(line); match != nil {
+		mode = match[1]
+	} else if match := vimModePattern.FindStringSubmatch(line); match != nil {
+		mode = match[1]
+	} else {
+		return "", false
+	}
+
+	mode = strings.TrimSuffix(strings.ToLower(mode), "-mode")
+	if _, ok := languages[mode]; ok {
+		return mode, true
+	}
+	if id, ok := extensions[mode]; ok {
+		return id, true
+	}
+	if id, ok := modeNames[mode]; ok {
+		return id, true
+	}
+	return mode, mode != ""
+}
+
+// readEnds reads the start and the end of the file at path
+func readEnds(path string) (head, tail []byte, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	head = make([]byte, headSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+	if n < headSize {
+		return head, head, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() <= int64(headSize) {
+		return head, head, nil
+	}
+	offset := info.Size() - tailSize
+	if offset < int64(headSize) {
+		offset = int64(headSize)
+	}
+	tail = make([]byte, info.Size()-offset)
+	n, err = file.ReadAt(tail, offset)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	return head, tail[:n], nil
+}
+
+// headLines returns the first lines of data
+func headLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for len(lines) < modelineLines && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// tailLines returns the last lines of data
+func tailLines(data []byte) []string {
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	if len(lines) > modelineLines {
+		lines = lines[len(lines)-modelineLines:]
+	}
+	return lines
+}
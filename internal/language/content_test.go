@@ -0,0 +1,76 @@
+package language
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"env python", "#!/usr/bin/env python3\nprint(1)\n", "python"},
+		{"env with options", "#!/usr/bin/env -S node --harmony\n", "javascript"},
+		{"absolute path", "#!/bin/bash -e\nset -u\n", "shell"},
+		{"versioned interpreter", "#!/usr/local/bin/python3.11\n", "python"},
+		{"emacs mode", "#!/bin/sh\n# -*- mode: ruby; coding: utf-8 -*-\n", "ruby"},
+		{"emacs short form", "// -*- c++ -*-\n", "cpp"},
+		{"emacs coding only", "# -*- coding: utf-8 -*-\n", ""},
+		{"vim modeline at the end", "x = 1\n" + strings.Repeat("\n", 20) + "# vim: set ft=python :\n", "python"},
+		{"vim filetype extension", "# vi: filetype=sh\n", "shell"},
+		{"vim options only", "// vim: ts=4 sw=4\n", ""},
+		{"unknown interpreter", "#!/usr/bin/tclsh\n", ""},
+		{"no shebang", "echo hello\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.content)
+			id, ok := FromContent(data, data)
+			if ok != (tt.expected != "") || id != tt.expected {
+				t.Errorf("Expected %q, got %q (%v)", tt.expected, id, ok)
+			}
+		})
+	}
+}
+
+func TestDetectFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_language_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"deploy":  "#!/usr/bin/env python3\nimport sys\n",
+		"big":     "#!/bin/sh\n" + strings.Repeat("echo padding\n", 1000) + "# vim: ft=perl\n",
+		"main.go": "#!/usr/bin/env python3\n",
+		"notes":   "just text\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	expected := map[string]string{
+		"deploy":  "Python",
+		"big":     "Perl", // A modeline takes precedence over the shebang
+		"main.go": "Go",   // The extension takes precedence over the content
+		"notes":   "",
+	}
+	for name, want := range expected {
+		lang, ok := DetectFile(filepath.Join(tempDir, name))
+		if ok != (want != "") || lang.Name != want {
+			t.Errorf("DetectFile(%s): expected %q, got %q (%v)", name, want, lang.Name, ok)
+		}
+	}
+
+	if got := FenceFile(filepath.Join(tempDir, "deploy")); got != "python" {
+		t.Errorf("Expected fence python, got %q", got)
+	}
+}
@@ -0,0 +1,315 @@
+package language
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Token estimation classes
+const (
+	ClassCode   = "code"   // Compiled languages with C-style comments
+	ClassScript = "script" // Scripting languages with # or // comments
+	ClassData   = "data"   // Structured data
+	ClassText   = "text"   // Natural language
+)
+
+// Language describes how files of a language are labeled and estimated
+type Language struct {
+	ID    string // Identifier used in overrides, such as "go" or "python"
+	Name  string // Name in language statistics, such as "Go" or "Python"
+	Fence string // Markdown code fence identifier
+	Class string // Token estimation class ("" for the generic estimate)
+
+	LineComment string // Marker starting a line comment, such as "//" or "#" ("" if unknown)
+}
+
+// languages lists the known languages by identifier
+var languages = map[string]Language{
+	"go":         {Name: "Go", Fence: "go", Class: ClassCode, LineComment: "//"},
+	"javascript": {Name: "JavaScript", Fence: "javascript", Class: ClassScript, LineComment: "//"},
+	"typescript": {Name: "TypeScript", Fence: "typescript", Class: ClassScript, LineComment: "//"},
+	"python":     {Name: "Python", Fence: "python", Class: ClassScript, LineComment: "#"},
+	"notebook":   {Name: "Jupyter Notebook", Fence: "python"},
+	"java":       {Name: "Java", Fence: "java", Class: ClassCode, LineComment: "//"},
+	"kotlin":     {Name: "Kotlin", Fence: "kotlin", Class: ClassScript, LineComment: "//"},
+	"scala":      {Name: "Scala", Fence: "scala", LineComment: "//"},
+	"groovy":     {Name: "Groovy", Fence: "groovy", LineComment: "//"},
+	"c":          {Name: "C", Fence: "c", Class: ClassCode, LineComment: "//"},
+	"cpp":        {Name: "C++", Fence: "cpp", Class: ClassCode, LineComment: "//"},
+	"csharp":     {Name: "C#", Fence: "csharp", Class: ClassScript, LineComment: "//"},
+	"php":        {Name: "PHP", Fence: "php", Class: ClassScript, LineComment: "//"},
+	"ruby":       {Name: "Ruby", Fence: "ruby", Class: ClassScript, LineComment: "#"},
+	"erb":        {Name: "Ruby", Fence: "erb"},
+	"rust":       {Name: "Rust", Fence: "rust", Class: ClassScript, LineComment: "//"},
+	"swift":      {Name: "Swift", Fence: "swift", Class: ClassScript, LineComment: "//"},
+	"starlark":   {Name: "Starlark", Fence: "starlark", Class: ClassScript, LineComment: "#"},
+	"perl":       {Name: "Perl", Fence: "perl", Class: ClassScript, LineComment: "#"},
+	"lua":        {Name: "Lua", Fence: "lua", Class: ClassScript, LineComment: "--"},
+	"r":          {Name: "R", Fence: "r", Class: ClassScript, LineComment: "#"},
+	"shell":      {Name: "Shell", Fence: "bash", LineComment: "#"},
+	"powershell": {Name: "PowerShell", Fence: "powershell", LineComment: "#"},
+	"sql":        {Name: "SQL", Fence: "sql", LineComment: "--"},
+	"html":       {Name: "HTML", Fence: "html"},
+	"css":        {Name: "CSS", Fence: "css"},
+	"scss":       {Name: "CSS", Fence: "scss"},
+	"sass":       {Name: "CSS", Fence: "sass"},
+	"less":       {Name: "CSS", Fence: "less"},
+	"json":       {Name: "JSON", Fence: "json", Class: ClassData},
+	"yaml":       {Name: "YAML", Fence: "yaml", Class: ClassData, LineComment: "#"},
+	"xml":        {Name: "XML", Fence: "xml", Class: ClassData},
+	"toml":       {Name: "TOML", Fence: "toml", Class: ClassData, LineComment: "#"},
+	"csv":        {Name: "CSV", Fence: "csv"},
+	"ini":        {Name: "Config", Fence: "ini"},
+	"gitignore":  {Name: "Config", Fence: "gitignore", LineComment: "#"},
+	"dockerfile": {Name: "Dockerfile", Fence: "dockerfile", LineComment: "#"},
+	"makefile":   {Name: "Makefile", Fence: "makefile", LineComment: "#"},
+	"cmake":      {Name: "CMake", Fence: "cmake", LineComment: "#"},
+	"gomod":      {Name: "Go Module", Fence: "go-mod", LineComment: "//"},
+	"gosum":      {Name: "Go Checksums", Fence: "text"},
+	"just":       {Name: "Just", Fence: "just", LineComment: "#"},
+	"dotenv":     {Name: "Dotenv", Fence: "dotenv", LineComment: "#"},
+	"markdown":   {Name: "Markdown", Fence: "markdown", Class: ClassText},
+	"text":       {Name: "Text", Fence: "text", Class: ClassText},
+	"document":   {Name: "Document", Fence: "text"}, // Converted to plain text before formatting
+	"image":      {Name: "Image"},
+	"audio":      {Name: "Audio"},
+	"video":      {Name: "Video"},
+	"archive":    {Name: "Archive"},
+}
+
+// extensions maps lower-cased file extensions, without the dot, to language identifiers
+var extensions = map[string]string{
+	"go":         "go",
+	"js":         "javascript",
+	"jsx":        "javascript",
+	"mjs":        "javascript",
+	"cjs":        "javascript",
+	"ts":         "typescript",
+	"tsx":        "typescript",
+	"py":         "python",
+	"pyw":        "python",
+	"pyc":        "python",
+	"pyd":        "python",
+	"pyo":        "python",
+	"ipynb":      "notebook",
+	"java":       "java",
+	"kt":         "kotlin",
+	"kts":        "kotlin",
+	"scala":      "scala",
+	"groovy":     "groovy",
+	"c":          "c",
+	"h":          "c",
+	"cpp":        "cpp",
+	"cc":         "cpp",
+	"cxx":        "cpp",
+	"c++":        "cpp",
+	"hpp":        "cpp",
+	"cs":         "csharp",
+	"php":        "php",
+	"rb":         "ruby",
+	"erb":        "erb",
+	"rs":         "rust",
+	"swift":      "swift",
+	"bzl":        "starlark",
+	"star":       "starlark",
+	"pl":         "perl",
+	"pm":         "perl",
+	"lua":        "lua",
+	"r":          "r",
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"fish":       "shell",
+	"ps1":        "powershell",
+	"sql":        "sql",
+	"html":       "html",
+	"htm":        "html",
+	"css":        "css",
+	"scss":       "scss",
+	"sass":       "sass",
+	"less":       "less",
+	"json":       "json",
+	"yaml":       "yaml",
+	"yml":        "yaml",
+	"xml":        "xml",
+	"toml":       "toml",
+	"csv":        "csv",
+	"tsv":        "csv",
+	"ini":        "ini",
+	"cfg":        "ini",
+	"conf":       "ini",
+	"gitignore":  "gitignore",
+	"dockerfile": "dockerfile",
+	"mk":         "makefile",
+	"mak":        "makefile",
+	"cmake":      "cmake",
+	"md":         "markdown",
+	"markdown":   "markdown",
+	"rmd":        "markdown",
+	"qmd":        "markdown",
+	"txt":        "text",
+	"rst":        "text",
+	"log":        "text",
+	"pdf":        "document",
+	"doc":        "document",
+	"docx":       "document",
+	"odt":        "document",
+	"png":        "image",
+	"jpg":        "image",
+	"jpeg":       "image",
+	"gif":        "image",
+	"svg":        "image",
+	"webp":       "image",
+	"ico":        "image",
+	"mp3":        "audio",
+	"wav":        "audio",
+	"ogg":        "audio",
+	"mp4":        "video",
+	"webm":       "video",
+	"avi":        "video",
+	"zip":        "archive",
+	"tar":        "archive",
+	"gz":         "archive",
+	"rar":        "archive",
+	"7z":         "archive",
+}
+
+// fileNames maps lower-cased names of special files, which have no extension
+// or a misleading one, to language identifiers
+var fileNames = map[string]string{
+	"dockerfile":      "dockerfile",
+	"containerfile":   "dockerfile",
+	"makefile":        "makefile",
+	"gnumakefile":     "makefile",
+	"cmakelists.txt":  "cmake",
+	"go.mod":          "gomod",
+	"go.work":         "gomod",
+	"go.sum":          "gosum",
+	"gemfile":         "ruby",
+	"rakefile":        "ruby",
+	"podfile":         "ruby",
+	"vagrantfile":     "ruby",
+	"brewfile":        "ruby",
+	"guardfile":       "ruby",
+	"jenkinsfile":     "groovy",
+	"build.bazel":     "starlark",
+	"workspace":       "starlark",
+	"workspace.bazel": "starlark",
+	"buck":            "starlark",
+	"tiltfile":        "starlark",
+	"justfile":        "just",
+	"pipfile":         "toml",
+	"pipfile.lock":    "json",
+	"cargo.lock":      "toml",
+	"poetry.lock":     "toml",
+	".bashrc":         "shell",
+	".bash_profile":   "shell",
+	".zshrc":          "shell",
+	".profile":        "shell",
+	".editorconfig":   "ini",
+	".gitattributes":  "gitignore",
+	".dockerignore":   "gitignore",
+	".npmignore":      "gitignore",
+	".env":            "dotenv",
+	"codeowners":      "text",
+	"license":         "text",
+	"licence":         "text",
+	"copying":         "text",
+	"authors":         "text",
+	"readme":          "text",
+}
+
+var (
+	overridesMu sync.RWMutex
+	overrides   map[string]string // Lower-cased ".ext" or file name to language identifier
+)
+
+// ValidateOverrides checks language overrides, which map an extension with its
+// dot (".gotmpl") or a file name ("Jenkinsfile") to a language identifier
+func ValidateOverrides(o map[string]string) error {
+	for pattern, id := range o {
+		if pattern == "" || pattern == "." || strings.ContainsAny(pattern, `/\`) {
+			return fmt.Errorf("invalid language pattern %q (expected .EXT or a file name)", pattern)
+		}
+		if strings.TrimSpace(id) == "" {
+			return fmt.Errorf("missing language for %q", pattern)
+		}
+	}
+	return nil
+}
+
+// SetOverrides replaces the language overrides, which take precedence over the
+// built-in extensions and file names. A nil map removes them.
+func SetOverrides(o map[string]string) error {
+	if err := ValidateOverrides(o); err != nil {
+		return err
+	}
+	lowered := make(map[string]string, len(o))
+	for pattern, id := range o {
+		lowered[strings.ToLower(pattern)] = strings.TrimSpace(id)
+	}
+
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides = lowered
+	return nil
+}
+
+// Lookup returns the language with the given identifier. Identifiers that aren't
+// built in, such as "go-template", name their own language.
+func Lookup(id string) Language {
+	lang, ok := languages[strings.ToLower(id)]
+	if !ok {
+		return Language{ID: id, Name: id, Fence: id}
+	}
+	lang.ID = strings.ToLower(id)
+	return lang
+}
+
+// Detect returns the language of the file at path from its name or extension,
+// honoring the overrides. It reports false if the language is unknown.
+func Detect(path string) (Language, bool) {
+	name := strings.ToLower(filepath.Base(path))
+	ext := strings.ToLower(filepath.Ext(name))
+
+	overridesMu.RLock()
+	id, ok := overrides[name]
+	if !ok && ext != "" {
+		id, ok = overrides[ext]
+	}
+	overridesMu.RUnlock()
+	if ok {
+		return Lookup(id), true
+	}
+
+	if id, ok := fileNames[name]; ok {
+		return Lookup(id), true
+	}
+	if strings.HasPrefix(name, "dockerfile.") {
+		return Lookup("dockerfile"), true // Variants such as Dockerfile.dev
+	}
+	if ext != "" {
+		if id, ok := extensions[ext[1:]]; ok {
+			return Lookup(id), true
+		}
+	}
+	return Language{}, false
+}
+
+// IsSpecialFile reports whether the file at path has the name of a special
+// file, such as a Dockerfile, Makefile, or go.mod. Special files are text.
+func IsSpecialFile(path string) bool {
+	_, ok := fileNames[strings.ToLower(filepath.Base(path))]
+	return ok
+}
+
+// Fence returns the Markdown code fence identifier for the file at path: the
+// language's, or the file extension when the language is unknown
+func Fence(path string) string {
+	if lang, ok := Detect(path); ok {
+		return lang.Fence
+	}
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
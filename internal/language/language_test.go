@@ -0,0 +1,96 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	defer SetOverrides(nil)
+
+	tests := []struct {
+		path  string
+		name  string
+		fence string
+		class string
+		found bool
+	}{
+		{"main.go", "Go", "go", ClassCode, true},
+		{"src/App.TSX", "TypeScript", "typescript", ClassScript, true},
+		{"ci/Jenkinsfile", "Groovy", "groovy", "", true},
+		{"Dockerfile", "Dockerfile", "dockerfile", "", true},
+		{"notes.docx", "Document", "text", "", true},
+		{"deploy.tmpl", "", "", "", false},
+		{"LICENSE", "Text", "text", ClassText, true},
+		{"src/CMakeLists.txt", "CMake", "cmake", "", true},
+		{"go.mod", "Go Module", "go-mod", "", true},
+		{"Gemfile", "Ruby", "ruby", ClassScript, true},
+		{"Dockerfile.dev", "Dockerfile", "dockerfile", "", true},
+		{"VERSION", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			lang, ok := Detect(tt.path)
+			if ok != tt.found {
+				t.Fatalf("Expected found %v, got %v", tt.found, ok)
+			}
+			if lang.Name != tt.name || lang.Fence != tt.fence || lang.Class != tt.class {
+				t.Errorf("Expected %s/%s/%s, got %s/%s/%s", tt.name, tt.fence, tt.class, lang.Name, lang.Fence, lang.Class)
+			}
+		})
+	}
+}
+
+func TestSetOverrides(t *testing.T) {
+	defer SetOverrides(nil)
+
+	err := SetOverrides(map[string]string{
+		".gotmpl":  "go-template",
+		"BUILD":    "starlark",
+		".h":       "cpp",
+		"Makefile": "text",
+	})
+	if err != nil {
+		t.Fatalf("SetOverrides failed: %v", err)
+	}
+
+	tests := []struct {
+		path  string
+		name  string
+		fence string
+	}{
+		{"templates/page.gotmpl", "go-template", "go-template"},
+		{"pkg/BUILD", "Starlark", "starlark"},
+		{"pkg/build", "Starlark", "starlark"},
+		{"include/api.h", "C++", "cpp"},
+		{"Makefile", "Text", "text"},
+		{"main.go", "Go", "go"},
+	}
+	for _, tt := range tests {
+		lang, ok := Detect(tt.path)
+		if !ok || lang.Name != tt.name || lang.Fence != tt.fence {
+			t.Errorf("Detect(%s): expected %s/%s, got %s/%s (%v)", tt.path, tt.name, tt.fence, lang.Name, lang.Fence, ok)
+		}
+	}
+
+	if got := Fence("deploy.tmpl"); got != "tmpl" {
+		t.Errorf("Expected the extension as fence of an unknown language, got %q", got)
+	}
+
+	for _, invalid := range []map[string]string{{"": "go"}, {"a/b": "go"}, {".x": " "}} {
+		if err := SetOverrides(invalid); err == nil {
+			t.Errorf("Expected an error for %v", invalid)
+		}
+	}
+}
+
+func TestIsSpecialFile(t *testing.T) {
+	for _, path := range []string{"Dockerfile", "build/Makefile", "go.sum", ".env", "CMakeLists.txt"} {
+		if !IsSpecialFile(path) {
+			t.Errorf("Expected %s to be a special file", path)
+		}
+	}
+	for _, path := range []string{"main.go", "Makefile.in", "deploy"} {
+		if IsSpecialFile(path) {
+			t.Errorf("Expected %s not to be a special file", path)
+		}
+	}
+}
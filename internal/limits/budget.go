@@ -0,0 +1,145 @@
+package limits
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// BudgetRule allocates part of the total character limit to files matching a glob pattern
+type BudgetRule struct {
+	Pattern string  // Glob matched against the slash-separated relative path (supports **)
+	Percent float64 // Share of the total limit, used when greater than zero
+	Limit   int64   // Absolute limit in characters, used when Percent is zero
+}
+
+// budgetGroup tracks the usage of a single budget rule
+type budgetGroup struct {
+	rule    BudgetRule
+	max     int64
+	current int64
+}
+
+// ParseBudget parses a budget specification such as "tests/**=10%,docs/**=5%".
+// Each entry assigns either a percentage of the total limit or an absolute
+// number of characters to the files matching its pattern.
+func ParseBudget(spec string) ([]BudgetRule, error) {
+	var rules []BudgetRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eq := strings.LastIndex(entry, "=")
+		if eq <= 0 || eq == len(entry)-1 {
+			return nil, fmt.Errorf("invalid budget entry: %s (expected PATTERN=PERCENT%% or PATTERN=CHARS)", entry)
+		}
+
+		rule := BudgetRule{Pattern: strings.TrimSpace(entry[:eq])}
+		value := strings.TrimSpace(entry[eq+1:])
+		if strings.HasSuffix(value, "%") {
+			percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil || percent <= 0 || percent > 100 {
+				return nil, fmt.Errorf("invalid budget percentage: %s", value)
+			}
+			rule.Percent = percent
+		} else {
+			limit, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || limit <= 0 {
+				return nil, fmt.Errorf("invalid budget limit: %s", value)
+			}
+			rule.Limit = limit
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SetBudgets divides the total limit into groups. Files are charged to the first
+// rule whose pattern matches; files matching no rule share whatever remains of
+// the total limit, so no single group can consume the whole budget.
+func (l *SizeLimiter) SetBudgets(rules []BudgetRule) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.budgetGroups = nil
+	l.remainderMax = 0
+	l.remainderCurrent = 0
+
+	var allocated int64
+	var totalPercent float64
+	for _, rule := range rules {
+		group := &budgetGroup{rule: rule, max: rule.Limit}
+		if rule.Percent > 0 {
+			if l.maxTotalSize <= 0 {
+				return fmt.Errorf("percentage budget %s=%g%% requires a total limit (--limit)", rule.Pattern, rule.Percent)
+			}
+			totalPercent += rule.Percent
+			group.max = int64(float64(l.maxTotalSize) * rule.Percent / 100)
+		}
+		allocated += group.max
+		l.budgetGroups = append(l.budgetGroups, group)
+	}
+
+	if totalPercent > 100 {
+		return fmt.Errorf("budget percentages add up to %g%%, more than 100%%", totalPercent)
+	}
+	if l.maxTotalSize > 0 {
+		if allocated > l.maxTotalSize {
+			return fmt.Errorf("budgets allocate %d characters, more than the total limit of %d", allocated, l.maxTotalSize)
+		}
+		l.remainderMax = l.maxTotalSize - allocated
+	}
+
+	return nil
+}
+
+// HasBudgets reports whether per-path budget groups are configured
+func (l *SizeLimiter) HasBudgets() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.budgetGroups) > 0
+}
+
+// AddToPathSize charges size as content for the file at relPath, checking the
+// file's budget group as well as the total. It returns false once either limit is exceeded.
+func (l *SizeLimiter) AddToPathSize(relPath string, size int64) bool {
+	res, ok := l.ReserveForPath(relPath, size)
+	if !ok {
+		return false
+	}
+	res.Commit()
+	return true
+}
+
+// GetTruncatedMessageFor returns the truncation message for the file at relPath,
+// naming its budget group when the group limit rather than the total was reached
+func (l *SizeLimiter) GetTruncatedMessageFor(relPath string) string {
+	l.mu.Lock()
+	exhausted := l.exhausted
+	hasBudgets := len(l.budgetGroups) > 0
+	group := l.groupFor(relPath)
+	l.mu.Unlock()
+
+	if !hasBudgets || exhausted {
+		return l.GetTruncatedMessage()
+	}
+	if group != nil {
+		return fmt.Sprintf("[Output truncated: reached budget of %s characters for %s]", utils.FormatCount(group.max), group.rule.Pattern)
+	}
+	return fmt.Sprintf("[Output truncated: reached budget of %s characters for files outside budget groups]", utils.FormatCount(l.remainderMax))
+}
+
+// groupFor returns the budget group for a path, or nil if no rule matches.
+// The caller must hold l.mu.
+func (l *SizeLimiter) groupFor(relPath string) *budgetGroup {
+	for _, group := range l.budgetGroups {
+		if utils.MatchGlob(group.rule.Pattern, relPath) {
+			return group
+		}
+	}
+	return nil
+}
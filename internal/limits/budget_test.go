@@ -0,0 +1,134 @@
+package limits
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expected    []BudgetRule
+		expectError bool
+	}{
+		{
+			name:     "Empty",
+			spec:     "",
+			expected: nil,
+		},
+		{
+			name: "Percentages",
+			spec: "tests/**=10%, docs/**=5.5%",
+			expected: []BudgetRule{
+				{Pattern: "tests/**", Percent: 10},
+				{Pattern: "docs/**", Percent: 5.5},
+			},
+		},
+		{
+			name: "Absolute limit",
+			spec: "*.md=2000",
+			expected: []BudgetRule{
+				{Pattern: "*.md", Limit: 2000},
+			},
+		},
+		{name: "Missing value", spec: "tests/**=", expectError: true},
+		{name: "Missing pattern", spec: "=10%", expectError: true},
+		{name: "Percentage over 100", spec: "tests/**=150%", expectError: true},
+		{name: "Invalid number", spec: "tests/**=lots", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseBudget(tt.spec)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(rules) != len(tt.expected) {
+				t.Fatalf("Expected %d rules, got %d", len(tt.expected), len(rules))
+			}
+			for i, rule := range rules {
+				if rule != tt.expected[i] {
+					t.Errorf("Expected rule %+v, got %+v", tt.expected[i], rule)
+				}
+			}
+		})
+	}
+}
+
+func TestSizeLimiter_SetBudgets_Validation(t *testing.T) {
+	limiter, _ := NewSizeLimiter("1MB", 0)
+	if err := limiter.SetBudgets([]BudgetRule{{Pattern: "tests/**", Percent: 10}}); err == nil {
+		t.Error("Expected error for percentage budget without total limit")
+	}
+
+	limiter, _ = NewSizeLimiter("1MB", 1000)
+	err := limiter.SetBudgets([]BudgetRule{
+		{Pattern: "a/**", Percent: 60},
+		{Pattern: "b/**", Percent: 50},
+	})
+	if err == nil {
+		t.Error("Expected error when percentages exceed 100%")
+	}
+}
+
+func TestSizeLimiter_AddToPathSize(t *testing.T) {
+	limiter, _ := NewSizeLimiter("1MB", 1000)
+	err := limiter.SetBudgets([]BudgetRule{
+		{Pattern: "tests/**", Percent: 10},
+		{Pattern: "docs/**", Limit: 200},
+	})
+	if err != nil {
+		t.Fatalf("SetBudgets failed: %v", err)
+	}
+
+	// The tests group is capped at 100 characters
+	if !limiter.AddToPathSize("tests/a_test.go", 80) {
+		t.Error("Expected first tests write to fit the group budget")
+	}
+	if limiter.AddToPathSize("tests/b_test.go", 30) {
+		t.Error("Expected tests group budget to be exceeded")
+	}
+	if msg := limiter.GetTruncatedMessageFor("tests/b_test.go"); !strings.Contains(msg, "tests/**") {
+		t.Errorf("Expected group truncation message, got %s", msg)
+	}
+
+	// Other groups are unaffected by the exhausted tests group
+	if !limiter.AddToPathSize("docs/guide.md", 150) {
+		t.Error("Expected docs write to fit the group budget")
+	}
+
+	// Ungrouped files share the remaining 700 characters
+	if !limiter.AddToPathSize("main.go", 700) {
+		t.Error("Expected ungrouped write to fit the remainder")
+	}
+	if limiter.AddToPathSize("cmd/root.go", 1) {
+		t.Error("Expected remainder budget to be exceeded")
+	}
+	if msg := limiter.GetTruncatedMessageFor("cmd/root.go"); !strings.Contains(msg, "outside budget groups") {
+		t.Errorf("Expected remainder truncation message, got %s", msg)
+	}
+
+	if limiter.CurrentTotalSize() != 930 {
+		t.Errorf("Expected total size 930, got %d", limiter.CurrentTotalSize())
+	}
+}
+
+func TestSizeLimiter_AddToPathSize_NoBudgets(t *testing.T) {
+	limiter, _ := NewSizeLimiter("1MB", 100)
+	if !limiter.AddToPathSize("main.go", 100) {
+		t.Error("Expected write within total limit to succeed")
+	}
+	if limiter.AddToPathSize("main.go", 1) {
+		t.Error("Expected total limit to be exceeded")
+	}
+	if msg := limiter.GetTruncatedMessageFor("main.go"); msg != limiter.GetTruncatedMessage() {
+		t.Errorf("Expected total truncation message, got %s", msg)
+	}
+}
@@ -0,0 +1,93 @@
+package limits
+
+import "fmt"
+
+// FileCap tracks how much of a single file has been included and stops the file
+// once it reaches the per-file byte or token cap. Unlike the maximum file size,
+// which skips a file entirely, a cap keeps the head of the file.
+type FileCap struct {
+	maxBytes  int64
+	maxTokens int64
+	bytes     int64
+	tokens    int64
+	reached   bool
+	omitted   int
+}
+
+// SetFileInclusionLimits sets the per-file caps used by NewFileCap (0 for no cap)
+func (l *SizeLimiter) SetFileInclusionLimits(maxBytes, maxTokens int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxIncludedBytes = maxBytes
+	l.maxIncludedTokens = maxTokens
+}
+
+// NewFileCap returns a cap for one file, or nil if no per-file cap is configured
+func (l *SizeLimiter) NewFileCap() *FileCap {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxIncludedBytes <= 0 && l.maxIncludedTokens <= 0 {
+		return nil
+	}
+	return &FileCap{maxBytes: l.maxIncludedBytes, maxTokens: l.maxIncludedTokens}
+}
+
+// EstimateLineTokens gives a rough token count for a line of text (about 4 characters per token)
+func EstimateLineTokens(line string) int64 {
+	return int64(len(line)+3) / 4
+}
+
+// Allow reports whether line, given without its newline, still fits within the cap.
+// Once a line does not fit, every further line is refused and counted as omitted.
+// A nil cap allows everything.
+func (c *FileCap) Allow(line string) bool {
+	if c == nil {
+		return true
+	}
+	if c.reached {
+		c.omitted++
+		return false
+	}
+
+	bytes := c.bytes + int64(len(line)) + 1
+	tokens := c.tokens + EstimateLineTokens(line)
+	if (c.maxBytes > 0 && bytes > c.maxBytes) || (c.maxTokens > 0 && tokens > c.maxTokens) {
+		c.reached = true
+		c.omitted++
+		return false
+	}
+
+	c.bytes = bytes
+	c.tokens = tokens
+	return true
+}
+
+// Truncated reports whether any line was refused
+func (c *FileCap) Truncated() bool {
+	return c != nil && c.reached
+}
+
+// OmittedLines returns the number of lines refused so far
+func (c *FileCap) OmittedLines() int {
+	if c == nil {
+		return 0
+	}
+	return c.omitted
+}
+
+// OmissionMessage returns the marker written in place of the omitted lines
+func (c *FileCap) OmissionMessage() string {
+	var limit string
+	switch {
+	case c.maxBytes > 0 && c.maxTokens > 0:
+		limit = fmt.Sprintf("%d bytes or %d tokens per file", c.maxBytes, c.maxTokens)
+	case c.maxBytes > 0:
+		limit = fmt.Sprintf("%d bytes per file", c.maxBytes)
+	default:
+		limit = fmt.Sprintf("%d tokens per file", c.maxTokens)
+	}
+	return fmt.Sprintf("[... %d more lines omitted: reached limit of %s]", c.omitted, limit)
+}
@@ -2,9 +2,13 @@ package limits
 
 import (
 	"fmt"
-	"os"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
+
+	"codectx/internal/platform"
+	"codectx/internal/utils"
 )
 
 // SizeLimit represents a size limit in bytes
@@ -12,11 +16,74 @@ type SizeLimit struct {
 	MaxBytes int64
 }
 
-// SizeLimiter handles size limits for files and overall output
+// Category identifies the part of the output that characters are charged to.
+// Only content counts against the total limit; the tree and metadata are
+// tracked per category without taking from it.
+type Category int
+
+const (
+	// CategoryContent covers file contents
+	CategoryContent Category = iota
+	// CategoryTree covers the directory tree
+	CategoryTree
+	// CategoryMetadata covers headers, statistics, and other metadata
+	CategoryMetadata
+
+	categoryCount
+)
+
+// counted reports whether the category counts against the total limit
+func (c Category) counted() bool {
+	return c == CategoryContent
+}
+
+// String returns the name of the category
+func (c Category) String() string {
+	switch c {
+	case CategoryContent:
+		return "content"
+	case CategoryTree:
+		return "tree"
+	case CategoryMetadata:
+		return "metadata"
+	default:
+		return "unknown"
+	}
+}
+
+// SizeLimiter handles size limits for files and overall output.
+// It is safe for concurrent use.
 type SizeLimiter struct {
-	MaxFileSize      int64 // Maximum size of individual files in bytes
-	MaxTotalSize     int64 // Maximum total size of all output in bytes
-	CurrentTotalSize int64 // Current total size of all output in bytes
+	mu sync.Mutex
+
+	maxFileSize  int64 // Maximum size of individual files in bytes
+	maxTotalSize int64 // Maximum total size of all output (0 for no limit)
+	used         int64 // Committed size counted against the total limit
+	reserved     int64 // Size held by outstanding reservations
+	exhausted    bool  // Set once an addition exceeded the total limit
+
+	byCategory [categoryCount]int64
+
+	budgetGroups     []*budgetGroup // Per-path budget groups set by SetBudgets
+	remainderMax     int64          // Budget left for files outside all groups
+	remainderCurrent int64
+
+	maxIncludedBytes  int64 // Per-file cap on included content in bytes (0 for no cap)
+	maxIncludedTokens int64 // Per-file cap on included content in tokens (0 for no cap)
+
+	Stat      platform.StatFunc // Source of file sizes (nil for os.Stat)
+	SizeUnits utils.SizeUnits   // Units of the sizes in messages
+}
+
+// Reservation is a claim on part of the output budget. It must be followed by
+// either Commit, which records the size as used, or Release, which returns it.
+type Reservation struct {
+	limiter  *SizeLimiter
+	category Category
+	group    *budgetGroup
+	inRest   bool // Charged to the remainder shared by ungrouped files
+	size     int64
+	done     bool
 }
 
 // NewSizeLimiter creates a new size limiter with the given limits
@@ -34,86 +101,308 @@ func NewSizeLimiter(maxFileSize string, maxTotalSize int64) (*SizeLimiter, error
 	}
 
 	return &SizeLimiter{
-		MaxFileSize:      maxFileSizeBytes,
-		MaxTotalSize:     maxTotalSize,
-		CurrentTotalSize: 0,
+		maxFileSize:  maxFileSizeBytes,
+		maxTotalSize: maxTotalSize,
 	}, nil
 }
 
+// Clone returns a limiter with the same limits, budget groups, and per-file
+// caps as l but nothing charged, for an output written alongside l's
+func (l *SizeLimiter) Clone() *SizeLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	clone := &SizeLimiter{
+		maxFileSize:       l.maxFileSize,
+		maxTotalSize:      l.maxTotalSize,
+		remainderMax:      l.remainderMax,
+		maxIncludedBytes:  l.maxIncludedBytes,
+		maxIncludedTokens: l.maxIncludedTokens,
+		Stat:              l.Stat,
+		SizeUnits:         l.SizeUnits,
+	}
+	for _, group := range l.budgetGroups {
+		clone.budgetGroups = append(clone.budgetGroups, &budgetGroup{rule: group.rule, max: group.max})
+	}
+	return clone
+}
+
+// MaxFileSize returns the maximum size of individual files in bytes
+func (l *SizeLimiter) MaxFileSize() int64 {
+	return l.maxFileSize
+}
+
+// MaxTotalSize returns the maximum total output size (0 for no limit)
+func (l *SizeLimiter) MaxTotalSize() int64 {
+	return l.maxTotalSize
+}
+
+// CurrentTotalSize returns the committed output size counted against the
+// total limit, that of the content
+func (l *SizeLimiter) CurrentTotalSize() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used
+}
+
+// OutputSize returns the committed output size across all categories,
+// including those that don't count against the total limit
+func (l *SizeLimiter) OutputSize() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var size int64
+	for _, categorySize := range l.byCategory {
+		size += categorySize
+	}
+	return size
+}
+
+// CategorySize returns the committed output size for a single category
+func (l *SizeLimiter) CategorySize(category Category) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.byCategory[category]
+}
+
+// IsLimited reports whether a total limit or budget groups are configured
+func (l *SizeLimiter) IsLimited() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.maxTotalSize > 0 || len(l.budgetGroups) > 0
+}
+
 // CheckFileSize checks if a file exceeds the maximum file size
 func (l *SizeLimiter) CheckFileSize(path string) (bool, int64, error) {
 	// Get file info
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := platform.Stat(l.Stat, path)
 	if err != nil {
 		return false, 0, fmt.Errorf("failed to get file info: %w", err)
 	}
 
 	// Check if the file size exceeds the limit
 	fileSize := fileInfo.Size()
-	if l.MaxFileSize > 0 && fileSize > l.MaxFileSize {
+	if l.maxFileSize > 0 && fileSize > l.maxFileSize {
 		return false, fileSize, nil
 	}
 
 	return true, fileSize, nil
 }
 
-// AddToTotalSize adds the given size to the current total size and checks if it exceeds the limit
+// Reserve claims size characters of the total limit for the given category.
+// It returns false, and no reservation, if the claim would exceed the limit.
+// Categories that don't count against the limit are always granted.
+func (l *SizeLimiter) Reserve(category Category, size int64) (*Reservation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !category.counted() {
+		return &Reservation{limiter: l, category: category, size: size}, true
+	}
+	if !l.fitsTotalLocked(size) {
+		return nil, false
+	}
+	l.reserved += size
+	return &Reservation{limiter: l, category: category, size: size}, true
+}
+
+// ReserveForPath claims size characters of file content for the file at relPath,
+// checking the file's budget group as well as the total limit
+func (l *SizeLimiter) ReserveForPath(relPath string, size int64) (*Reservation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res := &Reservation{limiter: l, category: CategoryContent, size: size}
+	if len(l.budgetGroups) > 0 {
+		if group := l.groupFor(relPath); group != nil {
+			if group.max > 0 && group.current+size > group.max {
+				return nil, false
+			}
+			res.group = group
+		} else if l.remainderMax > 0 {
+			if l.remainderCurrent+size > l.remainderMax {
+				return nil, false
+			}
+			res.inRest = true
+		}
+	}
+
+	if !l.fitsTotalLocked(size) {
+		return nil, false
+	}
+
+	l.reserved += size
+	if res.group != nil {
+		res.group.current += size
+	} else if res.inRest {
+		l.remainderCurrent += size
+	}
+	return res, true
+}
+
+// Commit records the reserved size as used
+func (r *Reservation) Commit() {
+	r.CommitSize(r.size)
+}
+
+// CommitSize records size (at most the reserved size) as used and returns the rest
+func (r *Reservation) CommitSize(size int64) {
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.done {
+		return
+	}
+	r.done = true
+
+	if size > r.size {
+		size = r.size
+	}
+	l.byCategory[r.category] += size
+	if !r.category.counted() {
+		return
+	}
+	l.reserved -= r.size
+	l.used += size
+	l.releaseGroupLocked(r, r.size-size)
+}
+
+// Release returns the reserved size without using it
+func (r *Reservation) Release() {
+	l := r.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.done || !r.category.counted() {
+		return
+	}
+	r.done = true
+
+	l.reserved -= r.size
+	l.releaseGroupLocked(r, r.size)
+}
+
+// Charge records size as used for the category without checking the limit.
+// It is meant for output that is always emitted, such as the directory tree;
+// only content is added to the total the limit is checked against.
+func (l *SizeLimiter) Charge(category Category, size int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if category.counted() {
+		l.used += size
+	}
+	l.byCategory[category] += size
+}
+
+// AddToTotalSize charges size as file content and checks it against the total limit.
+// Once an addition exceeds the limit, the limiter stays exhausted and every further
+// addition fails, so that output is not resumed after a truncation notice.
 func (l *SizeLimiter) AddToTotalSize(size int64) bool {
-	l.CurrentTotalSize += size
-	return l.MaxTotalSize <= 0 || l.CurrentTotalSize <= l.MaxTotalSize
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.used += size
+	l.byCategory[CategoryContent] += size
+	if l.maxTotalSize > 0 && l.used+l.reserved > l.maxTotalSize {
+		l.exhausted = true
+	}
+	return !l.exhausted
+}
+
+// Fits reports whether size more characters of content fit within the total
+// limit, without claiming them or exhausting the limiter when they don't
+func (l *SizeLimiter) Fits(size int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.exhausted && (l.maxTotalSize <= 0 || l.used+l.reserved+size <= l.maxTotalSize)
+}
+
+// fitsTotalLocked checks whether size more characters fit within the total limit
+func (l *SizeLimiter) fitsTotalLocked(size int64) bool {
+	if l.exhausted {
+		return false
+	}
+	if l.maxTotalSize > 0 && l.used+l.reserved+size > l.maxTotalSize {
+		l.exhausted = true
+		return false
+	}
+	return true
+}
+
+// releaseGroupLocked returns unused reserved size to the reservation's budget group
+func (l *SizeLimiter) releaseGroupLocked(r *Reservation, unused int64) {
+	if r.group != nil {
+		r.group.current -= unused
+	} else if r.inRest {
+		l.remainderCurrent -= unused
+	}
 }
 
 // GetTruncatedMessage returns a message indicating that output was truncated
 func (l *SizeLimiter) GetTruncatedMessage() string {
-	return fmt.Sprintf("[Output truncated: reached character limit of %d]", l.MaxTotalSize)
+	return fmt.Sprintf("[Output truncated: reached character limit of %s]", utils.FormatCount(l.maxTotalSize))
 }
 
 // GetFileTooLargeMessage returns a message indicating that a file was too large
 func (l *SizeLimiter) GetFileTooLargeMessage(path string, size int64) string {
-	return fmt.Sprintf("[File too large: %.1fMB - skipped (max: %.1fMB)]", 
-		float64(size)/(1024*1024), float64(l.MaxFileSize)/(1024*1024))
+	return fmt.Sprintf("[File too large: %s - skipped (max: %s)]", utils.FormatSize(size, l.SizeUnits), utils.FormatSize(l.maxFileSize, l.SizeUnits))
+}
+
+// sizeUnits maps upper-cased unit suffixes to their multipliers. Decimal-looking
+// units (KB, MB, ...) are treated as binary multiples, matching their IEC forms.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
 }
 
-// ParseSize parses a size string (e.g., "1MB", "500KB") into bytes
+// ParseSize parses a size string (e.g., "1MB", "1.5MB", "500kB", "2GiB", "1TB") into bytes
 func ParseSize(sizeStr string) (int64, error) {
 	sizeStr = strings.TrimSpace(sizeStr)
 	if sizeStr == "" {
 		return 0, nil
 	}
 
-	// Find the last digit position
-	lastDigitPos := -1
+	// Split the numeric part from the unit
+	unitPos := len(sizeStr)
 	for i, r := range sizeStr {
-		if r < '0' || r > '9' {
-			lastDigitPos = i
+		if (r < '0' || r > '9') && r != '.' {
+			unitPos = i
 			break
 		}
 	}
 
-	// If no unit specified, assume bytes
-	if lastDigitPos == -1 {
-		return strconv.ParseInt(sizeStr, 10, 64)
-	}
-
 	// Parse the numeric part
-	valueStr := sizeStr[:lastDigitPos]
-	value, err := strconv.ParseInt(valueStr, 10, 64)
+	valueStr := sizeStr[:unitPos]
+	if valueStr == "" || strings.Count(valueStr, ".") > 1 {
+		return 0, fmt.Errorf("invalid size value: %s", sizeStr)
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
 	if err != nil {
 		return 0, fmt.Errorf("invalid size value: %s", valueStr)
 	}
 
 	// Parse the unit part
-	unit := strings.ToUpper(strings.TrimSpace(sizeStr[lastDigitPos:]))
-	switch unit {
-	case "B":
-		return value, nil
-	case "KB":
-		return value * 1024, nil
-	case "MB":
-		return value * 1024 * 1024, nil
-	case "GB":
-		return value * 1024 * 1024 * 1024, nil
-	default:
+	unit := strings.ToUpper(strings.TrimSpace(sizeStr[unitPos:]))
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
 		return 0, fmt.Errorf("unknown size unit: %s", unit)
 	}
-}
\ No newline at end of file
+
+	size := value * multiplier
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size too large: %s", sizeStr)
+	}
+	return int64(size), nil
+}
@@ -3,6 +3,7 @@ package limits
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -86,8 +87,38 @@ func TestParseSize(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name:        "Invalid unit",
+			name:        "Terabytes",
 			input:       "1TB",
+			expected:    1024 * 1024 * 1024 * 1024,
+			expectError: false,
+		},
+		{
+			name:        "Decimal value",
+			input:       "1.5MB",
+			expected:    1536 * 1024,
+			expectError: false,
+		},
+		{
+			name:        "IEC unit",
+			input:       "2GiB",
+			expected:    2 * 1024 * 1024 * 1024,
+			expectError: false,
+		},
+		{
+			name:        "Lowercase k",
+			input:       "500kB",
+			expected:    500 * 1024,
+			expectError: false,
+		},
+		{
+			name:        "Single letter unit",
+			input:       "4K",
+			expected:    4 * 1024,
+			expectError: false,
+		},
+		{
+			name:        "Invalid unit",
+			input:       "1XB",
 			expected:    0,
 			expectError: true,
 		},
@@ -105,7 +136,13 @@ func TestParseSize(t *testing.T) {
 		},
 		{
 			name:        "Invalid format",
-			input:       "1.5MB",
+			input:       "1.2.3MB",
+			expected:    0,
+			expectError: true,
+		},
+		{
+			name:        "Missing number",
+			input:       "MB",
 			expected:    0,
 			expectError: true,
 		},
@@ -201,16 +238,16 @@ func TestNewSizeLimiter(t *testing.T) {
 				return
 			}
 
-			if limiter.MaxFileSize != tt.expectedFileSize {
-				t.Errorf("Expected MaxFileSize %d, got %d", tt.expectedFileSize, limiter.MaxFileSize)
+			if limiter.MaxFileSize() != tt.expectedFileSize {
+				t.Errorf("Expected MaxFileSize %d, got %d", tt.expectedFileSize, limiter.MaxFileSize())
 			}
 
-			if limiter.MaxTotalSize != tt.expectedTotalSize {
-				t.Errorf("Expected MaxTotalSize %d, got %d", tt.expectedTotalSize, limiter.MaxTotalSize)
+			if limiter.MaxTotalSize() != tt.expectedTotalSize {
+				t.Errorf("Expected MaxTotalSize %d, got %d", tt.expectedTotalSize, limiter.MaxTotalSize())
 			}
 
-			if limiter.CurrentTotalSize != 0 {
-				t.Errorf("Expected CurrentTotalSize to be 0, got %d", limiter.CurrentTotalSize)
+			if limiter.CurrentTotalSize() != 0 {
+				t.Errorf("Expected CurrentTotalSize to be 0, got %d", limiter.CurrentTotalSize())
 			}
 		})
 	}
@@ -386,8 +423,8 @@ func TestSizeLimiter_AddToTotalSize(t *testing.T) {
 				expectedTotal += addition
 			}
 
-			if limiter.CurrentTotalSize != expectedTotal {
-				t.Errorf("Expected CurrentTotalSize %d, got %d", expectedTotal, limiter.CurrentTotalSize)
+			if limiter.CurrentTotalSize() != expectedTotal {
+				t.Errorf("Expected CurrentTotalSize %d, got %d", expectedTotal, limiter.CurrentTotalSize())
 			}
 		})
 	}
@@ -400,7 +437,7 @@ func TestSizeLimiter_GetTruncatedMessage(t *testing.T) {
 	}
 
 	message := limiter.GetTruncatedMessage()
-	expected := "[Output truncated: reached character limit of 10000]"
+	expected := "[Output truncated: reached character limit of 10,000]"
 
 	if message != expected {
 		t.Errorf("Expected message '%s', got '%s'", expected, message)
@@ -522,6 +559,149 @@ func TestSizeLimiter_Integration(t *testing.T) {
 	}
 }
 
+func TestSizeLimiter_ReserveCommitRelease(t *testing.T) {
+	limiter, err := NewSizeLimiter("1MB", 100)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+
+	first, ok := limiter.Reserve(CategoryContent, 60)
+	if !ok {
+		t.Fatal("Expected first reservation to succeed")
+	}
+
+	// Outstanding reservations count against the limit
+	if _, ok := limiter.Reserve(CategoryContent, 50); ok {
+		t.Error("Expected reservation beyond the remaining limit to fail")
+	}
+
+	first.Release()
+	if limiter.CurrentTotalSize() != 0 {
+		t.Errorf("Expected released reservation to leave size 0, got %d", limiter.CurrentTotalSize())
+	}
+
+	// Releasing does not exhaust the limiter, but the failed reservation above did
+	if _, ok := limiter.Reserve(CategoryContent, 10); ok {
+		t.Error("Expected limiter to stay exhausted after a failed reservation")
+	}
+}
+
+func TestSizeLimiter_CommitSize(t *testing.T) {
+	limiter, err := NewSizeLimiter("1MB", 100)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+
+	reservation, ok := limiter.Reserve(CategoryContent, 80)
+	if !ok {
+		t.Fatal("Expected reservation to succeed")
+	}
+	reservation.CommitSize(30)
+	reservation.Commit() // A second commit is ignored
+
+	if limiter.CurrentTotalSize() != 30 {
+		t.Errorf("Expected total size 30, got %d", limiter.CurrentTotalSize())
+	}
+	if limiter.CategorySize(CategoryContent) != 30 {
+		t.Errorf("Expected content size 30, got %d", limiter.CategorySize(CategoryContent))
+	}
+
+	// The unused part of the reservation is available again
+	if _, ok := limiter.Reserve(CategoryContent, 70); !ok {
+		t.Error("Expected unused reserved size to be returned to the limit")
+	}
+}
+
+func TestSizeLimiter_CategoryAccounting(t *testing.T) {
+	limiter, err := NewSizeLimiter("1MB", 120)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+
+	limiter.Charge(CategoryTree, 40)
+	limiter.AddToTotalSize(100)
+	if reservation, ok := limiter.Reserve(CategoryMetadata, 5); ok {
+		reservation.Commit()
+	}
+
+	expected := map[Category]int64{CategoryTree: 40, CategoryContent: 100, CategoryMetadata: 5}
+	for category, size := range expected {
+		if limiter.CategorySize(category) != size {
+			t.Errorf("Expected %s size %d, got %d", category, size, limiter.CategorySize(category))
+		}
+	}
+	// Only the content counts against the limit
+	if limiter.CurrentTotalSize() != 100 {
+		t.Errorf("Expected total size 100, got %d", limiter.CurrentTotalSize())
+	}
+	if limiter.OutputSize() != 145 {
+		t.Errorf("Expected output size 145, got %d", limiter.OutputSize())
+	}
+	if _, ok := limiter.Reserve(CategoryContent, 20); !ok {
+		t.Error("Expected the tree and metadata to leave the limit to the content")
+	}
+}
+
+func TestSizeLimiter_Clone(t *testing.T) {
+	limiter, err := NewSizeLimiter("1MB", 100)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+	if err := limiter.SetBudgets([]BudgetRule{{Pattern: "docs/**", Limit: 20}}); err != nil {
+		t.Fatalf("Failed to set budgets: %v", err)
+	}
+	limiter.AddToPathSize("docs/a.md", 20)
+	limiter.AddToPathSize("main.go", 80)
+
+	clone := limiter.Clone()
+	if clone.CurrentTotalSize() != 0 {
+		t.Errorf("Expected the clone to start with nothing charged, got %d", clone.CurrentTotalSize())
+	}
+	if clone.MaxTotalSize() != 100 || clone.MaxFileSize() != 1024*1024 {
+		t.Errorf("Expected the clone to keep the limits, got %d and %d", clone.MaxTotalSize(), clone.MaxFileSize())
+	}
+	if !clone.AddToPathSize("docs/a.md", 20) {
+		t.Error("Expected the clone's budget group to start empty")
+	}
+	if clone.AddToPathSize("docs/b.md", 1) {
+		t.Error("Expected the clone to keep the budget group's limit")
+	}
+	if limiter.CurrentTotalSize() != 100 {
+		t.Errorf("Expected the original to be unaffected, got %d", limiter.CurrentTotalSize())
+	}
+}
+
+func TestSizeLimiter_ConcurrentReservations(t *testing.T) {
+	limiter, err := NewSizeLimiter("1MB", 1000)
+	if err != nil {
+		t.Fatalf("Failed to create size limiter: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reservation, ok := limiter.Reserve(CategoryContent, 30); ok {
+				reservation.Commit()
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if granted != 33 {
+		t.Errorf("Expected 33 reservations of 30 to fit in 1000, got %d", granted)
+	}
+	if limiter.CurrentTotalSize() != int64(granted*30) {
+		t.Errorf("Expected total size %d, got %d", granted*30, limiter.CurrentTotalSize())
+	}
+}
+
 // Helper function to check if a string contains a substring
 func containsSubstring(s, substr string) bool {
 	return len(s) >= len(substr) && findSubstring(s, substr) != -1
@@ -535,4 +715,66 @@ func findSubstring(s, substr string) int {
 		}
 	}
 	return -1
-}
\ No newline at end of file
+}
+func TestFileCap(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxBytes      int64
+		maxTokens     int64
+		lines         []string
+		expectedKept  int
+		expectedOmits int
+	}{
+		{
+			name:         "No cap",
+			lines:        []string{"a", "b", "c"},
+			expectedKept: 3,
+		},
+		{
+			name:          "Byte cap",
+			maxBytes:      10,
+			lines:         []string{"1234", "5678", "9012", "3456"},
+			expectedKept:  2,
+			expectedOmits: 2,
+		},
+		{
+			name:          "Token cap",
+			maxTokens:     2,
+			lines:         []string{"abcd", "efgh", "ijkl"},
+			expectedKept:  2,
+			expectedOmits: 1,
+		},
+		{
+			name:          "Short line after cap is still omitted",
+			maxBytes:      6,
+			lines:         []string{"12345", "67890", "x"},
+			expectedKept:  1,
+			expectedOmits: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, _ := NewSizeLimiter("1MB", 0)
+			limiter.SetFileInclusionLimits(tt.maxBytes, tt.maxTokens)
+			fileCap := limiter.NewFileCap()
+
+			kept := 0
+			for _, line := range tt.lines {
+				if fileCap.Allow(line) {
+					kept++
+				}
+			}
+
+			if kept != tt.expectedKept {
+				t.Errorf("Expected %d kept lines, got %d", tt.expectedKept, kept)
+			}
+			if fileCap.OmittedLines() != tt.expectedOmits {
+				t.Errorf("Expected %d omitted lines, got %d", tt.expectedOmits, fileCap.OmittedLines())
+			}
+			if fileCap.Truncated() != (tt.expectedOmits > 0) {
+				t.Errorf("Expected Truncated %v, got %v", tt.expectedOmits > 0, fileCap.Truncated())
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package minified
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// Modes of handling minified assets
+const (
+	ModePlaceholder = "placeholder" // Describe the file in place of its content
+	ModeSkip        = "skip"        // Leave minified files out entirely
+	ModeInclude     = "include"     // Include them like any other text file
+)
+
+// sampleSize bounds how much of a file is read to detect minification
+const sampleSize = 64 * 1024
+
+// Thresholds of the minification heuristic: a file is minified when its longest
+// line is very long and the text is either dense or made of long lines on average
+const (
+	minLongestLine    = 1000
+	maxWhitespace     = 0.15
+	minAverageLineLen = 300
+)
+
+// assetKinds maps lower-cased extensions of the assets checked for minification to their kind
+var assetKinds = map[string]string{
+	".js":  "JavaScript",
+	".mjs": "JavaScript",
+	".cjs": "JavaScript",
+	".css": "CSS",
+	".map": "source map",
+}
+
+// Info describes a minified file
+type Info struct {
+	Kind        string  `json:"kind"`
+	Size        int64   `json:"size_bytes"`
+	LongestLine int     `json:"longest_line"`      // Longest line seen in the sampled head of the file
+	Whitespace  float64 `json:"whitespace_ratio"`  // Share of whitespace in the sample
+	ByName      bool    `json:"by_name,omitempty"` // Detected from a .min. file name
+}
+
+// ParseMode validates a --minified mode
+func ParseMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case ModePlaceholder, ModeSkip, ModeInclude:
+		return strings.ToLower(mode), nil
+	default:
+		return "", fmt.Errorf("unknown minified mode %q (expected placeholder, skip, or include)", mode)
+	}
+}
+
+// IsAsset reports whether a path has the extension of an asset checked for minification
+func IsAsset(path string) bool {
+	_, ok := assetKinds[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Detect reports whether the file at path is a minified asset or bundle, judged
+// from a .min. file name or from very long lines with little whitespace in the
+// head of the file. Files that aren't JS, CSS, or source maps are never minified.
+func Detect(path string) (*Info, bool, error) {
+	kind, ok := assetKinds[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	info := &Info{Kind: kind, Size: stat.Size()}
+
+	sample := make([]byte, sampleSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, false, err
+	}
+	sample = sample[:n]
+
+	lines, whitespace, lineLen := 0, 0, 0
+	for _, b := range sample {
+		switch b {
+		case '\n':
+			lines++
+			lineLen = 0
+			whitespace++
+			continue
+		case ' ', '\t', '\r':
+			whitespace++
+		}
+		lineLen++
+		if lineLen > info.LongestLine {
+			info.LongestLine = lineLen
+		}
+	}
+	if lineLen > 0 {
+		lines++
+	}
+	if n > 0 {
+		info.Whitespace = float64(whitespace) / float64(n)
+	}
+
+	info.ByName = strings.Contains(strings.ToLower(filepath.Base(path)), ".min.")
+	if info.ByName {
+		return info, true, nil
+	}
+	if info.LongestLine < minLongestLine {
+		return info, false, nil
+	}
+	return info, info.Whitespace < maxWhitespace || n/lines >= minAverageLineLen, nil
+}
+
+// Describe returns a one-line description such as "minified JavaScript, 120.5KB, longest line 98304 characters",
+// with the size in units
+func (i *Info) Describe(units utils.SizeUnits) string {
+	return fmt.Sprintf("minified %s, %s, longest line %d characters", i.Kind, utils.FormatSize(i.Size, units), i.LongestLine)
+}
+
+// Placeholder returns the text written in place of a minified file's content
+func (i *Info) Placeholder(units utils.SizeUnits) string {
+	return "[" + i.Describe(units) + "]"
+}
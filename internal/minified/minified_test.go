@@ -0,0 +1,90 @@
+package minified
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codectx/internal/utils"
+)
+
+func TestDetect(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_minified_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	minifiedJS := strings.Repeat("function a(b){return b+1};var c=a(2);", 100)
+	formattedJS := strings.Repeat("function a(b) {\n    return b + 1;\n}\n", 100)
+	longDataLine := "var data = \"" + strings.Repeat("x ", 800) + "\";\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"bundle.js", minifiedJS, true},
+		{"app.js", formattedJS, false},
+		{"app.min.js", formattedJS, true},
+		{"style.css", strings.Repeat(".a{color:red;margin:0}", 100), true},
+		{"data.js", longDataLine + formattedJS, false},
+		{"main.go", minifiedJS, false},
+		{"empty.js", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+
+			info, ok, err := Detect(path)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if ok != tt.expected {
+				t.Errorf("Expected minified %v, got %v", tt.expected, ok)
+			}
+			if ok && info.Size != int64(len(tt.content)) {
+				t.Errorf("Expected size %d, got %d", len(tt.content), info.Size)
+			}
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected string
+		wantErr  bool
+	}{
+		{"placeholder", ModePlaceholder, false},
+		{"SKIP", ModeSkip, false},
+		{"include", ModeInclude, false},
+		{"drop", "", true},
+	}
+
+	for _, tt := range tests {
+		mode, err := ParseMode(tt.mode)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMode(%q): expected error %v, got %v", tt.mode, tt.wantErr, err)
+		}
+		if mode != tt.expected {
+			t.Errorf("ParseMode(%q): expected %q, got %q", tt.mode, tt.expected, mode)
+		}
+	}
+}
+
+func TestInfo_Placeholder(t *testing.T) {
+	info := &Info{Kind: "JavaScript", Size: 123392, LongestLine: 65536}
+	expected := "[minified JavaScript, 120.5KB, longest line 65536 characters]"
+	if got := info.Placeholder(utils.BinaryUnits); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := info.Placeholder(utils.SIUnits); got != "[minified JavaScript, 123.4kB, longest line 65536 characters]" {
+		t.Errorf("Expected the size in SI units, got %q", got)
+	}
+}
@@ -0,0 +1,236 @@
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Prefixes of the whiteout files that delete the files of lower layers
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq" // Deletes the other contents of its directory
+)
+
+// maxExtractedSize bounds the bytes written for the files of an image, so
+// that an image can't fill the disk
+const maxExtractedSize = 16 << 30
+
+// errUnsupportedLayer is returned for layers in a compression codectx can't read
+var errUnsupportedLayer = errors.New("unsupported layer media type")
+
+// errImageTooLarge is returned once the files of an image exceed maxExtractedSize
+var errImageTooLarge = fmt.Errorf("image exceeds %dGB of files", maxExtractedSize>>30)
+
+// ExtractLayer applies a layer, a tar archive that may be compressed with
+// gzip, to the filesystem in dir. Whiteout files delete what lower layers
+// added; devices and FIFOs are skipped; and symlinks are rewritten to stay
+// within dir, as if it were the root of the filesystem.
+func ExtractLayer(r io.Reader, mediaType string, dir string) error {
+	remaining := int64(maxExtractedSize)
+	return applyLayer(r, mediaType, dir, &remaining)
+}
+
+// applyLayer is ExtractLayer writing no more than remaining bytes of files,
+// which it decreases by those written
+func applyLayer(r io.Reader, mediaType string, dir string, remaining *int64) error {
+	if strings.HasSuffix(mediaType, "+zstd") || strings.HasSuffix(mediaType, ".zstd") {
+		return fmt.Errorf("%w %s (zstd)", errUnsupportedLayer, mediaType)
+	}
+
+	// Detect gzip from the content, as some registries mislabel layers
+	buffered := bufio.NewReader(r)
+	if magic, err := buffered.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = buffered
+	}
+
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := applyEntry(archive, header, dir, remaining); err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+}
+
+// applyEntry applies one entry of a layer to dir
+func applyEntry(archive *tar.Reader, header *tar.Header, dir string, remaining *int64) error {
+	// Entry names are relative to the root, and ".." stops at it
+	name := path.Clean("/" + header.Name)
+	if name == "/" {
+		return nil
+	}
+	parent, base := path.Split(name)
+	if base == whiteoutOpaque || strings.HasPrefix(base, whiteoutPrefix) {
+		parentDir, ok := physicalDir(dir, parent)
+		if !ok {
+			return nil
+		}
+		if base != whiteoutOpaque {
+			// Whiteouts of "." and "..", such as ".wh..", would delete the
+			// directory or its parent, which may be outside dir
+			deleted := strings.TrimPrefix(base, whiteoutPrefix)
+			if deleted == "" || deleted == "." || deleted == ".." || strings.ContainsAny(deleted, `/\`) {
+				return nil
+			}
+			target := filepath.Join(parentDir, deleted)
+			if !contained(dir, target) {
+				return nil
+			}
+			return os.RemoveAll(target)
+		}
+		entries, _ := os.ReadDir(parentDir)
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(parentDir, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The parent may be a symlink of a lower layer, such as /bin to usr/bin
+	if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(parent)), 0755); err != nil {
+		return err
+	}
+	parentDir, ok := physicalDir(dir, parent)
+	if !ok {
+		return nil
+	}
+	target := filepath.Join(parentDir, base)
+	relParent, _ := filepath.Rel(dir, parentDir)
+	name = path.Join("/", filepath.ToSlash(relParent), base)
+
+	// Replace what a lower layer put there, but keep directories, whose
+	// contents carry over
+	if info, err := os.Lstat(target); err == nil && !(info.IsDir() && header.Typeflag == tar.TypeDir) {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0755)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := charge(remaining, header.Size); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755|0600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, archive); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(target, header.ModTime, header.ModTime)
+	case tar.TypeSymlink:
+		return os.Symlink(containedLink(name, header.Linkname), target)
+	case tar.TypeLink:
+		source := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+header.Linkname)))
+		if err := os.Link(source, target); err != nil {
+			info, err := os.Stat(source)
+			if err != nil {
+				return err
+			}
+			if err := charge(remaining, info.Size()); err != nil {
+				return err
+			}
+			return copyFile(source, target)
+		}
+		return nil
+	}
+	return nil // Devices, FIFOs, and other special files
+}
+
+// physicalDir resolves the symlinks of parent, a directory of the image, and
+// returns where it is in dir. It reports false if it doesn't exist or the
+// symlinks lead outside dir.
+func physicalDir(dir, parent string) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.FromSlash(parent)))
+	if err != nil {
+		return "", false
+	}
+	if !contained(dir, resolved) {
+		return "", false
+	}
+	return resolved, true
+}
+
+// contained reports whether target, a path without symlinks left to resolve
+// but possibly its last element, is dir or within it
+func contained(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// charge takes size bytes from remaining, or returns errImageTooLarge when
+// fewer are left
+func charge(remaining *int64, size int64) error {
+	if size > *remaining {
+		return errImageTooLarge
+	}
+	*remaining -= size
+	return nil
+}
+
+// containedLink rewrites the target of the symlink at name (an absolute path
+// within the image) as a relative path that resolves within the image
+func containedLink(name, linkname string) string {
+	resolved := linkname
+	if !path.IsAbs(linkname) {
+		resolved = path.Join(path.Dir(name), linkname)
+	}
+	resolved = path.Clean("/" + resolved)
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(name)), filepath.FromSlash(resolved))
+	if err != nil {
+		return "."
+	}
+	return rel
+}
+
+// copyFile copies a regular file, for hard links the filesystem refuses
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
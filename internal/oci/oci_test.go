@@ -0,0 +1,314 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	tests := []struct {
+		ref      string
+		expected Reference
+		wantErr  bool
+	}{
+		{"nginx", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}, false},
+		{"nginx:1.27-alpine", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27-alpine"}, false},
+		{"bitnami/redis:7", Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7"}, false},
+		{"ghcr.io/owner/app@" + digest, Reference{Registry: "ghcr.io", Repository: "owner/app", Digest: digest}, false},
+		{"localhost:5000/team/api:v2", Reference{Registry: "localhost:5000", Repository: "team/api", Tag: "v2"}, false},
+		{"Nginx", Reference{}, true},
+		{"nginx:", Reference{}, true},
+		{"nginx@sha256:abc", Reference{}, true},
+	}
+	for _, test := range tests {
+		ref, err := ParseReference(test.ref)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseReference(%q): expected an error, got %+v", test.ref, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseReference(%q) failed: %v", test.ref, err)
+		} else if ref != test.expected {
+			t.Errorf("ParseReference(%q): expected %+v, got %+v", test.ref, test.expected, ref)
+		}
+	}
+}
+
+// tarEntry is an entry of a test layer
+type tarEntry struct {
+	name     string
+	typeflag byte
+	content  string // Or the link target
+}
+
+// makeLayer builds a gzip-compressed layer
+func makeLayer(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Mode: 0644, ModTime: time.Unix(1700000000, 0)}
+		switch entry.typeflag {
+		case tar.TypeReg:
+			header.Size = int64(len(entry.content))
+		case tar.TypeDir:
+			header.Mode = 0755
+		case tar.TypeSymlink, tar.TypeLink:
+			header.Linkname = entry.content
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if entry.typeflag == tar.TypeReg {
+			tw.Write([]byte(entry.content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// listTree returns the files of dir with their contents, and symlinks with
+// their targets
+func listTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			files[rel] = "-> " + filepath.ToSlash(target)
+		case info.Mode().IsRegular():
+			data, _ := os.ReadFile(path)
+			files[rel] = string(data)
+		}
+		return nil
+	})
+	return files
+}
+
+func TestExtractLayer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "oci-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	root := filepath.Join(tempDir, "root")
+	os.Mkdir(root, 0755)
+
+	layers := [][]tarEntry{
+		{
+			{"usr/", tar.TypeDir, ""},
+			{"usr/bin/app", tar.TypeReg, "v1"},
+			{"bin", tar.TypeSymlink, "usr/bin"},
+			{"etc/passwd", tar.TypeReg, "root:x:0:0"},
+			{"etc/config/a.conf", tar.TypeReg, "a"},
+			{"etc/config/b.conf", tar.TypeReg, "b"},
+			{"etc/hostlink", tar.TypeSymlink, "/etc/passwd"},
+			{"etc/escape", tar.TypeSymlink, "../../../../etc/shadow"},
+			{"etc/up", tar.TypeSymlink, "/"},
+			{"../top.txt", tar.TypeReg, "top"},
+			{"dev/null", tar.TypeChar, ""},
+		},
+		{
+			// Files written through the /bin symlink land in /usr/bin
+			{"bin/tool", tar.TypeReg, "tool"},
+			{"usr/bin/app", tar.TypeReg, "v2"},
+			{"etc/.wh.passwd", tar.TypeReg, ""},
+			{"etc/config/.wh..wh..opq", tar.TypeReg, ""},
+			{"etc/config/c.conf", tar.TypeReg, "c"},
+			{"etc/up/etc/up/x/evil", tar.TypeSymlink, "../../../../../../../x"},
+			{"app/link", tar.TypeLink, "usr/bin/tool"},
+		},
+	}
+	for _, layer := range layers {
+		data := makeLayer(t, layer)
+		if err := ExtractLayer(bytes.NewReader(data), "application/vnd.oci.image.layer.v1.tar+gzip", root); err != nil {
+			t.Fatalf("ExtractLayer failed: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		"usr/bin/app":       "v2",
+		"usr/bin/tool":      "tool",
+		"bin":               "-> usr/bin",
+		"etc/config/c.conf": "c",
+		"etc/hostlink":      "-> passwd",
+		"etc/escape":        "-> shadow",
+		"etc/up":            "-> ..",
+		"x/evil":            "-> .",
+		"top.txt":           "top",
+		"app/link":          "tool",
+	}
+	if got := listTree(t, root); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "top.txt")); err == nil {
+		t.Error("Expected entries leading outside the root to stay inside it")
+	}
+
+	if err := ExtractLayer(strings.NewReader(""), "application/vnd.oci.image.layer.v1.tar+zstd", root); err == nil {
+		t.Error("Expected an error for zstd layers")
+	}
+}
+
+func TestExtractLayer_Whiteouts(t *testing.T) {
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "root")
+	os.Mkdir(root, 0755)
+	sibling := filepath.Join(tempDir, "sibling.txt")
+	if err := os.WriteFile(sibling, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	layer := makeLayer(t, []tarEntry{
+		{"etc/passwd", tar.TypeReg, "root:x:0:0"},
+		{".wh..", tar.TypeReg, ""},
+		{"etc/.wh..", tar.TypeReg, ""},
+		{"../.wh.sibling.txt", tar.TypeReg, ""},
+		{"etc/.wh.", tar.TypeReg, ""},
+	})
+	if err := ExtractLayer(bytes.NewReader(layer), "application/vnd.oci.image.layer.v1.tar+gzip", root); err != nil {
+		t.Fatalf("ExtractLayer failed: %v", err)
+	}
+	if _, err := os.Stat(sibling); err != nil {
+		t.Errorf("Expected whiteouts to leave files outside the root, got %v", err)
+	}
+	if got := listTree(t, root); !reflect.DeepEqual(got, map[string]string{"etc/passwd": "root:x:0:0"}) {
+		t.Errorf("Expected whiteouts of . and .. to be ignored, got %v", got)
+	}
+}
+
+func TestExtractLayer_SizeLimit(t *testing.T) {
+	root := t.TempDir()
+	layer := makeLayer(t, []tarEntry{
+		{"a.txt", tar.TypeReg, "12345"},
+		{"b.txt", tar.TypeReg, "67890"},
+	})
+
+	remaining := int64(8)
+	err := applyLayer(bytes.NewReader(layer), "application/vnd.oci.image.layer.v1.tar+gzip", root, &remaining)
+	if !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("Expected errImageTooLarge, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); err == nil {
+		t.Error("Expected the file beyond the limit not to be written")
+	}
+	if remaining != 3 {
+		t.Errorf("Expected 3 bytes to remain, got %d", remaining)
+	}
+}
+
+func TestPull(t *testing.T) {
+	layers := [][]byte{
+		makeLayer(t, []tarEntry{{"app/main.go", tar.TypeReg, "package main\n"}, {"app/old.txt", tar.TypeReg, "old\n"}}),
+		makeLayer(t, []tarEntry{{"app/.wh.old.txt", tar.TypeReg, ""}}),
+	}
+	config, _ := json.Marshal(map[string]any{
+		"os": "linux", "architecture": "arm64", "variant": "v8",
+		"config": map[string]any{"Entrypoint": []string{"/app/server"}, "WorkingDir": "/app", "Env": []string{"PORT=8080"}},
+	})
+	blobs := map[string][]byte{"sha256:" + sha256Hex(config): config}
+	manifest := map[string]any{
+		"mediaType": mediaTypeOCIManifest,
+		"config":    map[string]any{"digest": "sha256:" + sha256Hex(config), "size": len(config)},
+	}
+	var layerDescriptors []map[string]any
+	for _, layer := range layers {
+		digest := "sha256:" + sha256Hex(layer)
+		blobs[digest] = layer
+		layerDescriptors = append(layerDescriptors, map[string]any{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": digest, "size": len(layer)})
+	}
+	manifest["layers"] = layerDescriptors
+	manifestData, _ := json.Marshal(manifest)
+	manifestDigest := "sha256:" + sha256Hex(manifestData)
+	index, _ := json.Marshal(map[string]any{
+		"mediaType": mediaTypeOCIIndex,
+		"manifests": []map[string]any{
+			{"digest": "sha256:" + strings.Repeat("0", 64), "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+			{"digest": manifestDigest, "platform": map[string]string{"os": "linux", "architecture": "arm64", "variant": "v8"}},
+		},
+	})
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:team/api:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:team/api:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/team/api/manifests/v2":
+			w.Write(index)
+		case "/v2/team/api/manifests/" + manifestDigest:
+			w.Write(manifestData)
+		default:
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/team/api/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		}
+	}))
+	defer server.Close()
+
+	tempDir, err := os.MkdirTemp("", "oci-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	t.Setenv("DOCKER_CONFIG", tempDir) // No credentials
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/team/api:v2")
+	if err != nil {
+		t.Fatalf("ParseReference failed: %v", err)
+	}
+	image, err := Pull(context.Background(), ref, Platform{OS: "linux", Architecture: "arm64"}, tempDir)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if image.Digest != manifestDigest || image.Platform != "linux/arm64/v8" || image.Layers != 2 {
+		t.Errorf("Unexpected image %+v", image)
+	}
+	if image.Config.WorkingDir != "/app" || !reflect.DeepEqual(image.Config.Entrypoint, []string{"/app/server"}) {
+		t.Errorf("Expected the config of the image, got %+v", image.Config)
+	}
+	if got, expected := listTree(t, tempDir), map[string]string{"app/main.go": "package main\n"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	_, err = Pull(context.Background(), ref, Platform{OS: "linux", Architecture: "s390x"}, tempDir)
+	if err == nil || !strings.Contains(err.Error(), "linux/amd64, linux/arm64/v8") {
+		t.Errorf("Expected an error listing the available platforms, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package oci pulls container images from OCI and Docker registries and
+// extracts their filesystems, so that what a container ships can be scanned
+// like a directory.
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dockerHub is the registry of image references without a registry host
+const dockerHub = "docker.io"
+
+// Reference names an image in a registry
+type Reference struct {
+	Registry   string // Host, with the port if any, such as "ghcr.io" or "localhost:5000"
+	Repository string // Such as "library/nginx"
+	Tag        string // "" when Digest is set
+	Digest     string // Such as "sha256:...", or ""
+}
+
+// referencePattern matches the repository of a reference, in lower case
+// components separated by slashes
+var referencePattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// ParseReference parses an image reference as docker accepts it: "nginx",
+// "nginx:1.27", "ghcr.io/owner/app@sha256:...". References without a
+// registry are on Docker Hub, under "library/" for single names, and
+// references without a tag or digest are of the "latest" tag.
+func ParseReference(ref string) (Reference, error) {
+	r := Reference{Registry: dockerHub}
+	name := ref
+	if before, digest, ok := strings.Cut(name, "@"); ok {
+		name, r.Digest = before, digest
+		if !strings.HasPrefix(digest, "sha256:") || len(digest) != len("sha256:")+64 {
+			return r, fmt.Errorf("invalid digest in image reference %q", ref)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.Tag = name[:i], name[i+1:]
+		if r.Tag == "" {
+			return r, fmt.Errorf("empty tag in image reference %q", ref)
+		}
+	}
+
+	// The first component is a registry host if it looks like one
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		r.Registry, name = host, rest
+	}
+	if r.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if !referencePattern.MatchString(name) {
+		return r, fmt.Errorf("invalid image reference %q", ref)
+	}
+	r.Repository = name
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r, nil
+}
+
+// String returns the reference in its canonical form
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Name returns the last component of the repository, such as "nginx"
+func (r Reference) Name() string {
+	return r.Repository[strings.LastIndex(r.Repository, "/")+1:]
+}
+
+// object returns the tag or digest that names the manifest
+func (r Reference) object() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// baseURL returns the URL of the registry API. Registries on the local
+// machine are spoken to over plain HTTP, as docker does by default.
+func (r Reference) baseURL() string {
+	host := r.Registry
+	if host == dockerHub {
+		host = "registry-1.docker.io"
+	}
+	hostname := host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		hostname = host[:i]
+	}
+	if hostname == "localhost" || hostname == "127.0.0.1" || hostname == "[::1]" {
+		return "http://" + host + "/v2/"
+	}
+	return "https://" + host + "/v2/"
+}
@@ -0,0 +1,453 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Environment variables with the credentials of a private registry, used
+// before those docker login saves
+const (
+	UsernameEnv = "CODECTX_REGISTRY_USERNAME"
+	PasswordEnv = "CODECTX_REGISTRY_PASSWORD"
+)
+
+// Media types of manifests
+const (
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// acceptManifests is the Accept header of manifest requests
+const acceptManifests = mediaTypeOCIIndex + ", " + mediaTypeOCIManifest + ", " + mediaTypeDockerList + ", " + mediaTypeDockerManifest
+
+// maxManifestSize bounds the size of a manifest, config, or token response
+const maxManifestSize = 4 * 1024 * 1024
+
+// dockerHubCredentialsServer is the key of Docker Hub in the docker config file
+const dockerHubCredentialsServer = "https://index.docker.io/v1/"
+
+// httpClient is used for all registry requests. Layers can take long to
+// download, so only the wait for the response headers is bounded.
+var httpClient = &http.Client{Transport: registryTransport()}
+
+// registryTransport returns the default transport with a timeout for the
+// response headers
+func registryTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 60 * time.Second
+	return transport
+}
+
+// Platform is the operating system and architecture of an image
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// DefaultPlatform is Linux on the architecture codectx runs on
+func DefaultPlatform() Platform {
+	return Platform{OS: "linux", Architecture: runtime.GOARCH}
+}
+
+// ParsePlatform parses a platform such as "linux/amd64" or "linux/arm64/v8"
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q (expected OS/ARCH[/VARIANT], e.g., linux/amd64)", s)
+	}
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// String returns the platform as OS/ARCH[/VARIANT]
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// descriptor points to a manifest or blob
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// manifest is an image manifest or, with Manifests, an index of the
+// manifests of several platforms
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// Config is the part of an image's configuration that describes how its
+// container runs
+type Config struct {
+	Entrypoint []string          `json:"entrypoint,omitempty"`
+	Cmd        []string          `json:"cmd,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+	User       string            `json:"user,omitempty"`
+	Env        []string          `json:"env,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Image describes a pulled image
+type Image struct {
+	Reference string `json:"reference"` // Canonical, such as "docker.io/library/nginx:1.27"
+	Digest    string `json:"digest"`    // Of the manifest of the platform
+	Platform  string `json:"platform"`
+	Layers    int    `json:"layers"`
+	Size      int64  `json:"size_bytes"` // Compressed size of the layers
+	Config    Config `json:"config"`
+}
+
+// Pull downloads the image of a platform and extracts its filesystem into
+// dir, applying the layers in order
+func Pull(ctx context.Context, ref Reference, platform Platform, dir string) (*Image, error) {
+	c := &registryClient{ref: ref}
+	m, digest, err := c.manifest(ctx, ref.object())
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Manifests) > 0 {
+		selected, err := selectPlatform(m.Manifests, platform)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		if m, digest, err = c.manifest(ctx, selected.Digest); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("%s: manifest %s has no layers", ref, digest)
+	}
+
+	image := &Image{Reference: ref.String(), Digest: digest, Platform: platform.String(), Layers: len(m.Layers)}
+	var config struct {
+		Platform
+		Config struct {
+			Entrypoint []string
+			Cmd        []string
+			WorkingDir string
+			User       string
+			Env        []string
+			Labels     map[string]string
+		} `json:"config"`
+	}
+	if data, err := c.readBlob(ctx, m.Config, maxManifestSize); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid image config %s: %w", m.Config.Digest, err)
+	}
+	image.Config = Config(config.Config)
+	if config.OS != "" {
+		image.Platform = config.Platform.String()
+	}
+
+	remaining := int64(maxExtractedSize)
+	for i, layer := range m.Layers {
+		if err := c.extractLayer(ctx, layer, dir, &remaining); err != nil {
+			return nil, fmt.Errorf("layer %d of %s: %w", i+1, ref, err)
+		}
+		image.Size += layer.Size
+	}
+	return image, nil
+}
+
+// selectPlatform picks the manifest of a platform from an index. Without a
+// variant, any variant of the architecture matches.
+func selectPlatform(manifests []descriptor, platform Platform) (descriptor, error) {
+	var available []string
+	for _, m := range manifests {
+		if m.Platform == nil {
+			continue
+		}
+		if m.Platform.OS == platform.OS && m.Platform.Architecture == platform.Architecture &&
+			(platform.Variant == "" || m.Platform.Variant == platform.Variant) {
+			return m, nil
+		}
+		if m.Platform.OS != "unknown" {
+			available = append(available, m.Platform.String())
+		}
+	}
+	return descriptor{}, fmt.Errorf("no image for %s (available: %s)", platform, strings.Join(available, ", "))
+}
+
+// registryClient sends the requests for one repository, authenticating
+// when the registry asks to
+type registryClient struct {
+	ref           Reference
+	authorization string // Authorization header, once the registry asked for one
+}
+
+// manifest fetches a manifest by tag or digest and returns it with its digest
+func (c *registryClient) manifest(ctx context.Context, object string) (*manifest, string, error) {
+	resp, err := c.get(ctx, "manifests/"+object, acceptManifests)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the manifest of %s: %w", c.ref, err)
+	}
+	digest := "sha256:" + sha256Hex(data)
+	if strings.HasPrefix(object, "sha256:") && object != digest {
+		return nil, "", fmt.Errorf("manifest of %s does not match its digest %s", c.ref, object)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest of %s: %w", c.ref, err)
+	}
+	return &m, digest, nil
+}
+
+// readBlob downloads a small blob, such as the config, and checks its digest
+func (c *registryClient) readBlob(ctx context.Context, blob descriptor, limit int64) ([]byte, error) {
+	resp, err := c.get(ctx, "blobs/"+blob.Digest, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	if "sha256:"+sha256Hex(data) != blob.Digest {
+		return nil, fmt.Errorf("blob %s does not match its digest", blob.Digest)
+	}
+	return data, nil
+}
+
+// extractLayer downloads a layer and applies it to dir, checking its digest,
+// writing no more than remaining bytes of files
+func (c *registryClient) extractLayer(ctx context.Context, layer descriptor, dir string, remaining *int64) error {
+	if !strings.HasPrefix(layer.Digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %s", layer.Digest)
+	}
+	resp, err := c.get(ctx, "blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	body := io.TeeReader(resp.Body, hash)
+	if err := applyLayer(body, layer.MediaType, dir, remaining); err != nil {
+		return err
+	}
+	// The tar stream may end before the blob does
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+	if "sha256:"+hex.EncodeToString(hash.Sum(nil)) != layer.Digest {
+		return fmt.Errorf("blob %s does not match its digest", layer.Digest)
+	}
+	return nil
+}
+
+// get requests a path of the repository, authenticating and retrying once
+// when the registry answers 401
+func (c *registryClient) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	endpoint := c.ref.baseURL() + c.ref.Repository + "/" + path
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "codectx")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull %s: %w", c.ref, err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := c.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = fmt.Errorf("failed to pull %s: %s returned %s", c.ref, endpoint, resp.Status)
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			if username, _ := credentials(c.ref.Registry); username == "" {
+				err = fmt.Errorf("%w (log in with docker login, or set %s and %s, to pull private images)", err, UsernameEnv, PasswordEnv)
+			}
+		}
+		return nil, err
+	}
+}
+
+// authenticate answers the challenge of a 401 response: a bearer token from
+// the registry's token service, or basic authentication
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	username, password := credentials(c.ref.Registry)
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return fmt.Errorf("registry %s requires credentials (log in with docker login, or set %s and %s)", c.ref.Registry, UsernameEnv, PasswordEnv)
+		}
+		c.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s asks for unsupported authentication %q", c.ref.Registry, challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || (tokenURL.Scheme != "https" && tokenURL.Scheme != "http") {
+		return fmt.Errorf("registry %s has an invalid token service %q", c.ref.Registry, params["realm"])
+	}
+	query := tokenURL.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "codectx")
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to %s: %w", c.ref.Registry, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate to %s: token service returned %s", c.ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to authenticate to %s: %w", c.ref.Registry, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("failed to authenticate to %s: no token returned", c.ref.Registry)
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`
+// into its lower case scheme and parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			params[key] = value
+		}
+	}
+	return strings.ToLower(scheme), params
+}
+
+// credentials returns the username and password for a registry from the
+// environment, or else from the docker config file of docker login
+func credentials(registry string) (string, string) {
+	if username := os.Getenv(UsernameEnv); username != "" {
+		return username, os.Getenv(PasswordEnv)
+	}
+
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		configDir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &config) != nil {
+		return "", ""
+	}
+	server := registry
+	if registry == dockerHub {
+		server = dockerHubCredentialsServer
+	}
+	for _, key := range []string{server, "https://" + server} {
+		if entry, ok := config.Auths[key]; ok {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return "", ""
+			}
+			username, password, _ := strings.Cut(string(decoded), ":")
+			return username, password
+		}
+	}
+	return "", ""
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
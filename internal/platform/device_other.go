@@ -0,0 +1,11 @@
+//go:build !unix
+
+package platform
+
+import "os"
+
+// DeviceID returns the ID of the file system device holding a file, if the
+// platform reports it
+func DeviceID(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package platform
+
+import "os"
+
+// FileOwner returns the names of the user and group owning a file, or their IDs
+// when they have no name, if the platform reports them
+func FileOwner(info os.FileInfo) (owner, group string) {
+	return "", ""
+}
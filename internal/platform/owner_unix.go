@@ -0,0 +1,48 @@
+//go:build unix
+
+package platform
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// ownerNames caches user and group names by ID, as a lookup may read
+// /etc/passwd or query a directory service
+var ownerNames = struct {
+	sync.Mutex
+	users  map[uint32]string
+	groups map[uint32]string
+}{users: make(map[uint32]string), groups: make(map[uint32]string)}
+
+// FileOwner returns the names of the user and group owning a file, or their IDs
+// when they have no name, if the platform reports them
+func FileOwner(info os.FileInfo) (owner, group string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+
+	ownerNames.Lock()
+	defer ownerNames.Unlock()
+	owner, ok = ownerNames.users[stat.Uid]
+	if !ok {
+		owner = strconv.FormatUint(uint64(stat.Uid), 10)
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+		ownerNames.users[stat.Uid] = owner
+	}
+	group, ok = ownerNames.groups[stat.Gid]
+	if !ok {
+		group = strconv.FormatUint(uint64(stat.Gid), 10)
+		if g, err := user.LookupGroupId(group); err == nil {
+			group = g.Name
+		}
+		ownerNames.groups[stat.Gid] = group
+	}
+	return owner, group
+}
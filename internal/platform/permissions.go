@@ -0,0 +1,77 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+)
+
+// Permissions describes the mode bits and ownership of a file
+type Permissions struct {
+	Mode          string `json:"mode"`     // Octal, such as "0755" or "4755"
+	Symbolic      string `json:"symbolic"` // As ls prints it, such as "-rwxr-xr-x"
+	Executable    bool   `json:"executable"`
+	WorldWritable bool   `json:"world_writable,omitempty"`
+	Setuid        bool   `json:"setuid,omitempty"`
+	Setgid        bool   `json:"setgid,omitempty"`
+	Owner         string `json:"owner,omitempty"` // User name, or the ID when it has none; empty where the platform doesn't report it
+	Group         string `json:"group,omitempty"`
+}
+
+// FilePermissions returns the permissions of a file
+func FilePermissions(info os.FileInfo) Permissions {
+	mode := info.Mode()
+	special := os.FileMode(0)
+	if mode&os.ModeSetuid != 0 {
+		special |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		special |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		special |= 01000
+	}
+
+	perms := Permissions{
+		Mode:          fmt.Sprintf("%04o", uint32(special|mode.Perm())),
+		Symbolic:      symbolicMode(mode),
+		Executable:    !mode.IsDir() && mode.Perm()&0111 != 0,
+		WorldWritable: !IsWindows && mode.Perm()&0002 != 0, // Windows reports every writable file as 0666
+		Setuid:        mode&os.ModeSetuid != 0,
+		Setgid:        mode&os.ModeSetgid != 0,
+	}
+	perms.Owner, perms.Group = FileOwner(info)
+	return perms
+}
+
+// symbolicMode formats mode bits as ls does, with s and t in place of the
+// execute bits for setuid, setgid, and sticky files
+func symbolicMode(mode os.FileMode) string {
+	const rwx = "rwxrwxrwx"
+	b := []byte("----------")
+	switch {
+	case mode.IsDir():
+		b[0] = 'd'
+	case mode&os.ModeSymlink != 0:
+		b[0] = 'l'
+	}
+	for i := range 9 {
+		if mode&(1<<uint(8-i)) != 0 {
+			b[i+1] = rwx[i]
+		}
+	}
+
+	special := func(i int, set bool, letter byte) {
+		if !set {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = letter
+		} else {
+			b[i] = letter - 'a' + 'A'
+		}
+	}
+	special(3, mode&os.ModeSetuid != 0, 's')
+	special(6, mode&os.ModeSetgid != 0, 's')
+	special(9, mode&os.ModeSticky != 0, 't')
+	return string(b)
+}
@@ -0,0 +1,66 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// IsWindows reports whether codectx is running on Windows
+const IsWindows = runtime.GOOS == "windows"
+
+// IsTerminal reports whether f is an interactive terminal rather than a pipe or file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// NormalizeVolume upper-cases a Windows drive letter so that "c:\src" and
+// "C:\src" are treated as the same path. Other paths are returned unchanged.
+func NormalizeVolume(path string) string {
+	volume := filepath.VolumeName(path)
+	if len(volume) == 2 && volume[1] == ':' {
+		return strings.ToUpper(volume) + path[2:]
+	}
+	return path
+}
+
+// SameVolume reports whether two paths live on the same volume (drive letter or UNC share)
+func SameVolume(a, b string) bool {
+	return strings.EqualFold(filepath.VolumeName(a), filepath.VolumeName(b))
+}
+
+// RelSlash returns target relative to root using forward slashes on every platform
+func RelSlash(root, target string) (string, error) {
+	root = NormalizeVolume(root)
+	target = NormalizeVolume(target)
+
+	if !SameVolume(root, target) {
+		return "", fmt.Errorf("%s is on a different volume than %s", target, root)
+	}
+
+	relPath, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// JoinSlash joins a forward-slash relative path onto an OS-specific root path
+func JoinSlash(root, relPath string) string {
+	return filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(relPath, "/")))
+}
+
+// StatFunc returns information about a file, such as os.Stat or a lookup of the
+// information captured while scanning
+type StatFunc func(path string) (os.FileInfo, error)
+
+// Stat returns information about the file at path using fn, or os.Stat when fn is nil
+func Stat(fn StatFunc, path string) (os.FileInfo, error) {
+	if fn == nil {
+		return os.Stat(path)
+	}
+	return fn(path)
+}
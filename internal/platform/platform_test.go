@@ -0,0 +1,121 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelSlash(t *testing.T) {
+	root := filepath.Join("base", "project")
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{
+			name:     "File in root",
+			target:   filepath.Join(root, "main.go"),
+			expected: "main.go",
+		},
+		{
+			name:     "Nested file",
+			target:   filepath.Join(root, "internal", "pkg", "file.go"),
+			expected: "internal/pkg/file.go",
+		},
+		{
+			name:     "Root itself",
+			target:   root,
+			expected: ".",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relPath, err := RelSlash(root, tt.target)
+			if err != nil {
+				t.Fatalf("RelSlash failed: %v", err)
+			}
+			if relPath != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, relPath)
+			}
+		})
+	}
+}
+
+func TestNormalizeVolume(t *testing.T) {
+	if result := NormalizeVolume("/home/user/project"); result != "/home/user/project" {
+		t.Errorf("Expected path without volume to be unchanged, got %s", result)
+	}
+
+	if !IsWindows {
+		t.Skip("drive letters are only recognized on Windows")
+	}
+
+	if result := NormalizeVolume(`c:\src\project`); result != `C:\src\project` {
+		t.Errorf("Expected drive letter to be upper-cased, got %s", result)
+	}
+	if !SameVolume(`c:\src`, `C:\other`) {
+		t.Error("Expected drive letters to compare case-insensitively")
+	}
+}
+
+func TestJoinSlash(t *testing.T) {
+	root := filepath.Join("base", "project")
+	expected := filepath.Join(root, "sub", "file.txt")
+
+	if result := JoinSlash(root, "/sub/file.txt"); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if result := JoinSlash(root, "sub/file.txt"); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestFilePermissions(t *testing.T) {
+	if IsWindows {
+		t.Skip("Windows doesn't have Unix mode bits")
+	}
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		mode     os.FileMode
+		expected Permissions
+	}{
+		{"plain", 0644, Permissions{Mode: "0644", Symbolic: "-rw-r--r--"}},
+		{"script", 0755, Permissions{Mode: "0755", Symbolic: "-rwxr-xr-x", Executable: true}},
+		{"shared", 0666, Permissions{Mode: "0666", Symbolic: "-rw-rw-rw-", WorldWritable: true}},
+		{"setuid", 0755 | os.ModeSetuid, Permissions{Mode: "4755", Symbolic: "-rwsr-xr-x", Executable: true, Setuid: true}},
+		{"setgid", 0640 | os.ModeSetgid, Permissions{Mode: "2640", Symbolic: "-rw-r-S---", Setgid: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(path, nil, 0600); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+			if err := os.Chmod(path, tt.mode); err != nil {
+				t.Fatalf("Failed to chmod file: %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Failed to stat file: %v", err)
+			}
+			if info.Mode()&(os.ModeSetuid|os.ModeSetgid) != tt.mode&(os.ModeSetuid|os.ModeSetgid) {
+				t.Skip("File system doesn't keep setuid and setgid bits")
+			}
+
+			perms := FilePermissions(info)
+			if perms.Owner == "" || perms.Group == "" {
+				t.Errorf("Expected an owner and group, got %q and %q", perms.Owner, perms.Group)
+			}
+			perms.Owner, perms.Group = "", ""
+			if perms != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, perms)
+			}
+		})
+	}
+}
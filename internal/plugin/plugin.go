@@ -0,0 +1,266 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prefix is the file name prefix of plugin executables, e.g. codectx-licenses
+const Prefix = "codectx-"
+
+// infoTimeout bounds how long a plugin may take to describe itself
+const infoTimeout = 10 * time.Second
+
+// Commands passed to plugin executables as their first argument
+const (
+	CommandInfo    = "info"    // Print an Info object as JSON
+	CommandAnalyze = "analyze" // Read FileEvent lines, write Finding lines
+	CommandFormat  = "format"  // Read the JSON document, write the formatted output
+)
+
+// EventFile is the type of the event sent for each included file
+const EventFile = "file"
+
+// Plugin is an external executable found on PATH
+type Plugin struct {
+	Name string // File name without the prefix and extension
+	Path string
+}
+
+// Info is what a plugin reports about itself in response to "info"
+type Info struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Analyzer    bool     `json:"analyzer,omitempty"` // Handles "analyze"
+	Formats     []string `json:"formats,omitempty"`  // Output formats handled by "format FORMAT"
+}
+
+// FileEvent is sent to analyzers, one JSON object per line, for each included file
+type FileEvent struct {
+	Type     string `json:"type"`
+	Path     string `json:"path"`      // Slash-separated path relative to the target directory
+	FullPath string `json:"full_path"` // Path to read the file from
+	Size     int64  `json:"size"`
+}
+
+// Finding is reported by analyzers, one JSON object per line
+type Finding struct {
+	Plugin   string `json:"plugin,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Discover finds plugin executables in the directories of pathList (a PATH
+// value). When several directories contain the same plugin, the first wins.
+func Discover(pathList string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.IsDir() || !isExecutable(info) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// Find returns the plugin with the given name from pathList
+func Find(pathList, name string) (Plugin, bool) {
+	for _, p := range Discover(pathList) {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// FindFormat returns the plugin that handles an output format, preferring a
+// plugin with the same name as the format
+func FindFormat(pathList, format string) (Plugin, bool) {
+	plugins := Discover(pathList)
+	sort.SliceStable(plugins, func(i, j int) bool {
+		return plugins[i].Name == format && plugins[j].Name != format
+	})
+	for _, p := range plugins {
+		info, err := p.Info()
+		if err != nil {
+			continue
+		}
+		for _, f := range info.Formats {
+			if strings.EqualFold(f, format) {
+				return p, true
+			}
+		}
+	}
+	return Plugin{}, false
+}
+
+// pluginName extracts the plugin name from an executable's file name
+func pluginName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, Prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(fileName, Prefix)
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isExecutable reports whether a file can be run
+func isExecutable(info os.FileInfo) bool {
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// Info runs the plugin's "info" command
+func (p Plugin) Info() (*Info, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), infoTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, p.Path, CommandInfo).Output()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: info failed: %w", p.Name, err)
+	}
+	var info Info
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid info: %w", p.Name, err)
+	}
+	if info.Name == "" {
+		info.Name = p.Name
+	}
+	return &info, nil
+}
+
+// Analyzer is a running analyzer plugin
+type Analyzer struct {
+	plugin   Plugin
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	encoder  *json.Encoder
+	findings []Finding
+	done     chan error
+}
+
+// StartAnalyzer starts the plugin's "analyze" command
+func (p Plugin) StartAnalyzer() (*Analyzer, error) {
+	cmd := exec.Command(p.Path, CommandAnalyze)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+
+	a := &Analyzer{plugin: p, cmd: cmd, stdin: stdin, encoder: json.NewEncoder(stdin), done: make(chan error, 1)}
+	// Collect findings while events are written, so neither side blocks
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var finding Finding
+			if err := json.Unmarshal(scanner.Bytes(), &finding); err != nil || finding.Message == "" {
+				continue
+			}
+			finding.Plugin = p.Name
+			a.findings = append(a.findings, finding)
+		}
+		a.done <- scanner.Err()
+	}()
+	return a, nil
+}
+
+// File sends a file event to the analyzer
+func (a *Analyzer) File(event FileEvent) error {
+	event.Type = EventFile
+	if err := a.encoder.Encode(event); err != nil {
+		return fmt.Errorf("plugin %s: %w", a.plugin.Name, err)
+	}
+	return nil
+}
+
+// Finish signals the end of the events and returns the analyzer's findings
+func (a *Analyzer) Finish() ([]Finding, error) {
+	a.stdin.Close()
+	readErr := <-a.done
+	if err := a.cmd.Wait(); err != nil {
+		return a.findings, fmt.Errorf("plugin %s: %w", a.plugin.Name, err)
+	}
+	return a.findings, readErr
+}
+
+// FormatWriter feeds the JSON document to a formatter plugin, which writes the
+// formatted output. Closing it waits for the plugin to finish.
+type FormatWriter struct {
+	plugin Plugin
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+}
+
+// StartFormatter starts the plugin's "format" command, writing its output to out
+func (p Plugin) StartFormatter(format string, out io.Writer) (*FormatWriter, error) {
+	cmd := exec.Command(p.Path, CommandFormat, format)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+	return &FormatWriter{plugin: p, cmd: cmd, stdin: stdin}, nil
+}
+
+// Write passes JSON output to the plugin
+func (w *FormatWriter) Write(data []byte) (int, error) {
+	return w.stdin.Write(data)
+}
+
+// Close ends the input and waits for the plugin to finish
+func (w *FormatWriter) Close() error {
+	w.stdin.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin %s: %w", w.plugin.Name, err)
+	}
+	return nil
+}
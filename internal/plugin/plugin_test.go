@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeScript creates an executable shell script plugin in dir
+func writeScript(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	tempDir, err := os.MkdirTemp("", "plugin-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	first := filepath.Join(tempDir, "first")
+	second := filepath.Join(tempDir, "second")
+	os.Mkdir(first, 0755)
+	os.Mkdir(second, 0755)
+
+	writeScript(t, first, "codectx-count", `case "$1" in
+info) echo '{"description": "Counts files", "analyzer": true}' ;;
+analyze) while read -r line; do echo '{"path": "x", "message": "seen"}'; done ;;
+esac
+`)
+	writeScript(t, first, "codectx-upper", `case "$1" in
+info) echo '{"formats": ["upper"]}' ;;
+format) tr a-z A-Z ;;
+esac
+`)
+	writeScript(t, second, "codectx-count", "exit 1\n")
+	if err := os.WriteFile(filepath.Join(first, "codectx-notes.txt"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	pathList := first + string(os.PathListSeparator) + second
+	plugins := Discover(pathList)
+	if len(plugins) != 2 || plugins[0].Name != "count" || plugins[1].Name != "upper" {
+		t.Fatalf("Expected plugins count and upper, got %+v", plugins)
+	}
+	if filepath.Dir(plugins[0].Path) != first {
+		t.Errorf("Expected the first directory to win, got %s", plugins[0].Path)
+	}
+
+	// Info
+	info, err := plugins[0].Info()
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Name != "count" || !info.Analyzer || info.Description != "Counts files" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+
+	// Analyzer
+	analyzer, err := plugins[0].StartAnalyzer()
+	if err != nil {
+		t.Fatalf("StartAnalyzer failed: %v", err)
+	}
+	for _, path := range []string{"a.go", "b.go"} {
+		if err := analyzer.File(FileEvent{Path: path, FullPath: "/" + path}); err != nil {
+			t.Fatalf("File failed: %v", err)
+		}
+	}
+	findings, err := analyzer.Finish()
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if len(findings) != 2 || findings[0].Plugin != "count" || findings[0].Message != "seen" {
+		t.Errorf("Unexpected findings: %+v", findings)
+	}
+
+	// Formatter
+	p, ok := FindFormat(pathList, "upper")
+	if !ok || p.Name != "upper" {
+		t.Fatalf("Expected to find the upper format, got %+v", p)
+	}
+	var out bytes.Buffer
+	writer, err := p.StartFormatter("upper", &out)
+	if err != nil {
+		t.Fatalf("StartFormatter failed: %v", err)
+	}
+	writer.Write([]byte(`{"files": []}`))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if out.String() != `{"FILES": []}` {
+		t.Errorf("Expected upper-cased output, got %q", out.String())
+	}
+
+	if _, ok := FindFormat(pathList, "csv"); ok {
+		t.Errorf("Expected no plugin for csv")
+	}
+}
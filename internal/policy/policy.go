@@ -0,0 +1,226 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"codectx/internal/utils"
+)
+
+// FileName is the name of the policy file, looked up in the scanned directory
+// and at the root of its Git repository
+const FileName = ".codectx-policy.yaml"
+
+// Outcomes of a policy check
+const (
+	OutcomePassed     = "passed"     // No included file is denied
+	OutcomeOverridden = "overridden" // Denied files were output with --policy-override
+)
+
+// Rule is a pattern of paths that must never be exported
+type Rule struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Policy is the set of paths a repository forbids exporting. A policy file
+// lists them under deny, either as bare patterns or with a reason:
+//
+//	deny:
+//	  - secrets/**
+//	  - "*.pem"
+//	  - pattern: data/customers/
+//	    reason: Customer data
+type Policy struct {
+	File string // Path of the policy file
+	Deny []Rule
+}
+
+// Violation is an included file denied by a rule
+type Violation struct {
+	Path    string `json:"path"`
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Decision records how a run was checked against the policy
+type Decision struct {
+	File       string      `json:"file"`
+	Rules      int         `json:"rules"`
+	Outcome    string      `json:"outcome"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Load reads the policy file at path. A missing file is not an error; Load
+// returns nil for it.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	policy.File = path
+	return policy, nil
+}
+
+// Parse reads a policy from the YAML subset of policy files: a deny list of
+// patterns, each a scalar or a mapping with pattern and reason
+func Parse(data string) (*Policy, error) {
+	policy := &Policy{}
+	inDeny := false
+	var rule *Rule // The mapping rule being read
+	for i, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' && line[0] != '-' {
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok || strings.TrimSpace(key) != "deny" {
+				return nil, fmt.Errorf("line %d: unknown key %q (expected deny)", i+1, strings.TrimSpace(key))
+			}
+			inDeny = true
+			rule = nil
+			value = scalar(value)
+			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				for _, item := range strings.Split(value[1:len(value)-1], ",") {
+					if item = scalar(item); item != "" {
+						policy.Deny = append(policy.Deny, Rule{Pattern: item})
+					}
+				}
+			} else if value != "" {
+				return nil, fmt.Errorf("line %d: deny must be a list of patterns", i+1)
+			}
+			continue
+		}
+		if !inDeny {
+			return nil, fmt.Errorf("line %d: expected deny", i+1)
+		}
+
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok {
+			item = strings.TrimSpace(item)
+			policy.Deny = append(policy.Deny, Rule{})
+			rule = &policy.Deny[len(policy.Deny)-1]
+			if key, value, ok := strings.Cut(item, ":"); ok && isRuleKey(key) {
+				if err := setField(rule, key, value); err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+			} else {
+				rule.Pattern = scalar(item)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if rule == nil || !ok {
+			return nil, fmt.Errorf("line %d: expected a list item", i+1)
+		}
+		if err := setField(rule, key, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	for _, rule := range policy.Deny {
+		if rule.Pattern == "" {
+			return nil, errors.New("deny rule without a pattern")
+		}
+		for _, segment := range strings.Split(rule.Pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// isRuleKey reports whether key is a field of a mapping rule
+func isRuleKey(key string) bool {
+	key = strings.TrimSpace(key)
+	return key == "pattern" || key == "reason"
+}
+
+// setField sets a field of a mapping rule
+func setField(rule *Rule, key, value string) error {
+	switch strings.TrimSpace(key) {
+	case "pattern":
+		rule.Pattern = scalar(value)
+	case "reason":
+		rule.Reason = scalar(value)
+	default:
+		return fmt.Errorf("unknown rule key %q (expected pattern or reason)", strings.TrimSpace(key))
+	}
+	return nil
+}
+
+// scalar returns the value of a YAML scalar, unquoted and without a trailing comment
+func scalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// Check returns the paths denied by the policy. prefix is the slash-separated
+// path of the scanned directory relative to the directory of the policy file
+// ("" when they are the same), and paths are relative to the scanned directory,
+// without a leading slash.
+func (p *Policy) Check(prefix string, paths []string) []Violation {
+	var violations []Violation
+	for _, relPath := range paths {
+		policyPath := relPath
+		if prefix != "" {
+			policyPath = prefix + "/" + relPath
+		}
+		for _, rule := range p.Deny {
+			if rule.matches(policyPath) {
+				violations = append(violations, Violation{Path: relPath, Pattern: rule.Pattern, Reason: rule.Reason})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// matches reports whether the rule denies a path relative to the policy file,
+// like --exclude: a pattern without a slash matches the name of the file or of
+// any directory it is in, and a pattern with one matches the path or one of its
+// parent directories. A trailing "/" only matches directories, and "**" matches
+// any number of directories.
+func (r Rule) matches(relPath string) bool {
+	pattern := strings.TrimPrefix(r.Pattern, "./")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	if dirOnly {
+		parts = parts[:len(parts)-1]
+	}
+	for i, part := range parts {
+		candidate := part
+		if anchored {
+			candidate = strings.Join(parts[:i+1], "/")
+		}
+		if utils.MatchGlob(pattern, candidate) {
+			return true
+		}
+	}
+	return false
+}
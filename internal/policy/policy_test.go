@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []Rule
+		wantErr  bool
+	}{
+		{
+			name: "patterns and rules",
+			data: `# Never export these
+deny:
+  - secrets/**
+  - "*.pem"   # keys
+  - pattern: data/customers/
+    reason: "Customer data"
+`,
+			expected: []Rule{{Pattern: "secrets/**"}, {Pattern: "*.pem"}, {Pattern: "data/customers/", Reason: "Customer data"}},
+		},
+		{
+			name:     "flow list",
+			data:     "deny: [\"*.key\", .env]\n",
+			expected: []Rule{{Pattern: "*.key"}, {Pattern: ".env"}},
+		},
+		{
+			name:     "list at the key's indentation",
+			data:     "deny:\n- '*.p12'\n",
+			expected: []Rule{{Pattern: "*.p12"}},
+		},
+		{name: "unknown key", data: "allow:\n  - src/**\n", wantErr: true},
+		{name: "unknown rule key", data: "deny:\n  - pattern: a\n    why: b\n", wantErr: true},
+		{name: "rule without a pattern", data: "deny:\n  - reason: secret\n", wantErr: true},
+		{name: "invalid pattern", data: "deny:\n  - \"[a\"\n", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := Parse(test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", policy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(policy.Deny, test.expected) {
+				t.Errorf("Expected %+v, got %+v", test.expected, policy.Deny)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := &Policy{Deny: []Rule{
+		{Pattern: "secrets/**"},
+		{Pattern: "*.pem"},
+		{Pattern: "customers/", Reason: "Customer data"},
+		{Pattern: "/config/prod.yaml"},
+	}}
+	paths := []string{
+		"main.go",
+		"secrets/api.txt",
+		"certs/server.pem",
+		"exports/customers/list.csv",
+		"customers.go",
+		"config/prod.yaml",
+		"app/config/prod.yaml",
+	}
+
+	expected := []Violation{
+		{Path: "secrets/api.txt", Pattern: "secrets/**"},
+		{Path: "certs/server.pem", Pattern: "*.pem"},
+		{Path: "exports/customers/list.csv", Pattern: "customers/", Reason: "Customer data"},
+		{Path: "config/prod.yaml", Pattern: "/config/prod.yaml"},
+	}
+	if got := policy.Check("", paths); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// Paths of a subdirectory are matched from the policy file's directory
+	expected = []Violation{{Path: "api.txt", Pattern: "secrets/**"}}
+	if got := policy.Check("secrets", []string{"api.txt"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
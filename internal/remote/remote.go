@@ -0,0 +1,167 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// AuthorizationEnv holds the Authorization header sent with HTTP uploads
+const AuthorizationEnv = "CODECTX_OUTPUT_AUTHORIZATION"
+
+// httpClient is used for all uploads
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// maxErrorBody bounds how much of a failed upload's response is reported
+const maxErrorBody = 512
+
+// IsRemote reports whether an output target is an s3:// or http(s):// URL
+// rather than a local file
+func IsRemote(target string) bool {
+	for _, scheme := range []string{"s3://", "http://", "https://"} {
+		if len(target) > len(scheme) && strings.EqualFold(target[:len(scheme)], scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create returns a writer for a remote output target. The output is buffered
+// in a temporary file and uploaded when the writer is closed: s3:// targets
+// are put as objects with the AWS credentials in the environment, and
+// http(s):// targets are sent in a PUT request with the Authorization header
+// from CODECTX_OUTPUT_AUTHORIZATION, if set.
+func Create(target string) (io.WriteCloser, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output URL: %w", err)
+	}
+
+	var put putFunc
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		if put, err = s3Put(u); err != nil {
+			return nil, err
+		}
+	case "http", "https":
+		put = httpPut(u)
+	default:
+		return nil, fmt.Errorf("unsupported output URL scheme: %s", u.Scheme)
+	}
+
+	file, err := os.CreateTemp("", "codectx-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer output: %w", err)
+	}
+	digest := sha256.New()
+	return &upload{
+		target: displayURL(u),
+		file:   file,
+		digest: digest,
+		w:      io.MultiWriter(file, digest),
+		put:    put,
+	}, nil
+}
+
+// putFunc sends the buffered output, given its size and hex SHA-256 digest
+type putFunc func(body io.Reader, size int64, payloadHash string) error
+
+// upload buffers the output for a remote target until it is closed
+type upload struct {
+	target string
+	file   *os.File
+	digest hash.Hash
+	w      io.Writer
+	put    putFunc
+}
+
+// Write buffers p
+func (u *upload) Write(p []byte) (int, error) {
+	return u.w.Write(p)
+}
+
+// Close uploads the buffered output and removes the temporary file
+func (u *upload) Close() error {
+	defer os.Remove(u.file.Name())
+	defer u.file.Close()
+
+	size, err := u.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := u.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := u.put(u.file, size, hex.EncodeToString(u.digest.Sum(nil))); err != nil {
+		return fmt.Errorf("failed to upload output to %s: %w", u.target, err)
+	}
+	return nil
+}
+
+// httpPut uploads with a PUT request to u
+func httpPut(u *url.URL) putFunc {
+	return func(body io.Reader, size int64, _ string) error {
+		req, err := newPutRequest(u.String(), body, size, u.Path)
+		if err != nil {
+			return err
+		}
+		if authorization := os.Getenv(AuthorizationEnv); authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return send(req)
+	}
+}
+
+// newPutRequest creates an upload request, typed by the extension of name
+func newPutRequest(endpoint string, body io.Reader, size int64, name string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("User-Agent", "codectx")
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// send performs an upload request and checks that it succeeded
+func send(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// The error quotes the URL, which may hold credentials in its query
+		if urlErr, ok := err.(*url.Error); ok {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if message := strings.TrimSpace(string(data)); message != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, message)
+		}
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
+
+// displayURL returns u for messages, without the user information and query
+// that may carry credentials (as in presigned URLs)
+func displayURL(u *url.URL) string {
+	shown := *u
+	shown.User = nil
+	shown.RawQuery = ""
+	shown.Fragment = ""
+	return shown.String()
+}
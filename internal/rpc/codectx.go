@@ -0,0 +1,241 @@
+package rpc
+
+// ServiceName is the gRPC service of codectx, defined in codectx.proto
+const ServiceName = "codectx.v1.Codectx"
+
+// ScanRequest selects the files of a directory, with the filters of the
+// command line
+type ScanRequest struct {
+	Directory       string   // 1: Relative to the server's root ("" for the root)
+	Extensions      []string // 2
+	Exclude         []string // 3: Glob patterns
+	IncludeRegex    []string // 4
+	ExcludeRegex    []string // 5
+	IncludeDotfiles bool     // 6
+	MaxFileSize     string   // 7: Such as "1MB" ("" for the default)
+	ModifiedSince   string   // 8: Date or age, such as "7d"
+	GitOnly         string   // 9: "tracked" or "working" ("" for all files)
+	Tests           string   // 10: "include", "skip", or "only"
+	OmitContent     bool     // 11: Leave the content out of the File messages
+}
+
+// Unmarshal decodes a ScanRequest
+func (m *ScanRequest) Unmarshal(data []byte) error {
+	return Decode(data, func(f Field) error {
+		switch f.Number {
+		case 1:
+			m.Directory = string(f.Data)
+		case 2:
+			m.Extensions = append(m.Extensions, string(f.Data))
+		case 3:
+			m.Exclude = append(m.Exclude, string(f.Data))
+		case 4:
+			m.IncludeRegex = append(m.IncludeRegex, string(f.Data))
+		case 5:
+			m.ExcludeRegex = append(m.ExcludeRegex, string(f.Data))
+		case 6:
+			m.IncludeDotfiles = f.Varint != 0
+		case 7:
+			m.MaxFileSize = string(f.Data)
+		case 8:
+			m.ModifiedSince = string(f.Data)
+		case 9:
+			m.GitOnly = string(f.Data)
+		case 10:
+			m.Tests = string(f.Data)
+		case 11:
+			m.OmitContent = f.Varint != 0
+		}
+		return nil
+	})
+}
+
+// MarshalTo encodes a ScanRequest
+func (m *ScanRequest) MarshalTo(e *Encoder) {
+	e.String(1, m.Directory)
+	e.Strings(2, m.Extensions)
+	e.Strings(3, m.Exclude)
+	e.Strings(4, m.IncludeRegex)
+	e.Strings(5, m.ExcludeRegex)
+	e.Bool(6, m.IncludeDotfiles)
+	e.String(7, m.MaxFileSize)
+	e.String(8, m.ModifiedSince)
+	e.String(9, m.GitOnly)
+	e.String(10, m.Tests)
+	e.Bool(11, m.OmitContent)
+}
+
+// GetFileRequest names one file of a directory
+type GetFileRequest struct {
+	Directory string // 1: Relative to the server's root
+	Path      string // 2: Relative to the directory, with forward slashes
+}
+
+// Unmarshal decodes a GetFileRequest
+func (m *GetFileRequest) Unmarshal(data []byte) error {
+	return Decode(data, func(f Field) error {
+		switch f.Number {
+		case 1:
+			m.Directory = string(f.Data)
+		case 2:
+			m.Path = string(f.Data)
+		}
+		return nil
+	})
+}
+
+// MarshalTo encodes a GetFileRequest
+func (m *GetFileRequest) MarshalTo(e *Encoder) {
+	e.String(1, m.Directory)
+	e.String(2, m.Path)
+}
+
+// AnalyzeRequest selects the files to analyze
+type AnalyzeRequest struct {
+	Scan *ScanRequest // 1
+}
+
+// Unmarshal decodes an AnalyzeRequest
+func (m *AnalyzeRequest) Unmarshal(data []byte) error {
+	return Decode(data, func(f Field) error {
+		if f.Number == 1 {
+			m.Scan = &ScanRequest{}
+			return m.Scan.Unmarshal(f.Data)
+		}
+		return nil
+	})
+}
+
+// MarshalTo encodes an AnalyzeRequest
+func (m *AnalyzeRequest) MarshalTo(e *Encoder) {
+	if m.Scan != nil {
+		e.Message(1, m.Scan)
+	}
+}
+
+// File is a file selected by a scan
+type File struct {
+	Path      string // 1: Relative to the scanned directory, with forward slashes
+	Language  string // 2: Identifier, such as "go" ("" if unknown)
+	SizeBytes int64  // 3
+	Tokens    int64  // 4: Estimated
+	Content   []byte // 5
+	KeyFile   bool   // 6: An entry point, manifest, or other file worth reading first
+}
+
+// MarshalTo encodes a File
+func (m *File) MarshalTo(e *Encoder) {
+	e.String(1, m.Path)
+	e.String(2, m.Language)
+	e.Int64(3, m.SizeBytes)
+	e.Int64(4, m.Tokens)
+	e.BytesField(5, m.Content)
+	e.Bool(6, m.KeyFile)
+}
+
+// Stats sums up the files selected by a scan
+type Stats struct {
+	TotalFiles     int64           // 1
+	TotalSizeBytes int64           // 2
+	Tokens         int64           // 3
+	Languages      []LanguageStats // 4: Most tokens first
+}
+
+// MarshalTo encodes Stats
+func (m *Stats) MarshalTo(e *Encoder) {
+	e.Int64(1, m.TotalFiles)
+	e.Int64(2, m.TotalSizeBytes)
+	e.Int64(3, m.Tokens)
+	for i := range m.Languages {
+		e.Message(4, &m.Languages[i])
+	}
+}
+
+// LanguageStats sums up the files of one language
+type LanguageStats struct {
+	Language  string // 1: Name, such as "Go" ("Other" if unknown)
+	Files     int64  // 2
+	SizeBytes int64  // 3
+	Tokens    int64  // 4
+}
+
+// MarshalTo encodes LanguageStats
+func (m *LanguageStats) MarshalTo(e *Encoder) {
+	e.String(1, m.Language)
+	e.Int64(2, m.Files)
+	e.Int64(3, m.SizeBytes)
+	e.Int64(4, m.Tokens)
+}
+
+// Analysis is the stack, key files, complex functions, and unused files of
+// the files selected by a scan
+type Analysis struct {
+	Stack     []StackComponent // 1
+	KeyFiles  []string         // 2
+	Functions []Function       // 3: Over a threshold, most complex first
+	DeadFiles []DeadFile       // 4
+}
+
+// MarshalTo encodes an Analysis
+func (m *Analysis) MarshalTo(e *Encoder) {
+	for i := range m.Stack {
+		e.Message(1, &m.Stack[i])
+	}
+	e.Strings(2, m.KeyFiles)
+	for i := range m.Functions {
+		e.Message(3, &m.Functions[i])
+	}
+	for i := range m.DeadFiles {
+		e.Message(4, &m.DeadFiles[i])
+	}
+}
+
+// StackComponent is a framework, tool, or service detected in the project
+type StackComponent struct {
+	Name     string // 1
+	Category string // 2
+	Evidence string // 3: Path of the file it was detected from
+}
+
+// MarshalTo encodes a StackComponent
+func (m *StackComponent) MarshalTo(e *Encoder) {
+	e.String(1, m.Name)
+	e.String(2, m.Category)
+	e.String(3, m.Evidence)
+}
+
+// Function is a function over the complexity, nesting, or length threshold
+type Function struct {
+	Path       string // 1
+	Function   string // 2
+	StartLine  int64  // 3
+	EndLine    int64  // 4
+	Complexity int64  // 5
+	MaxNesting int64  // 6
+	Lines      int64  // 7
+}
+
+// MarshalTo encodes a Function
+func (m *Function) MarshalTo(e *Encoder) {
+	e.String(1, m.Path)
+	e.String(2, m.Function)
+	e.Int64(3, m.StartLine)
+	e.Int64(4, m.EndLine)
+	e.Int64(5, m.Complexity)
+	e.Int64(6, m.MaxNesting)
+	e.Int64(7, m.Lines)
+}
+
+// DeadFile is a package or asset nothing refers to
+type DeadFile struct {
+	Path  string   // 1
+	Kind  string   // 2: "package" or "asset"
+	Files []string // 3
+}
+
+// MarshalTo encodes a DeadFile
+func (m *DeadFile) MarshalTo(e *Encoder) {
+	e.String(1, m.Path)
+	e.String(2, m.Kind)
+	e.Strings(3, m.Files)
+}
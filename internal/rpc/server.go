@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MaxMessageSize bounds the size of a message, as gRPC clients do by default
+const MaxMessageSize = 4 * 1024 * 1024
+
+// Code is a gRPC status code
+type Code int
+
+// The gRPC status codes returned by the server
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	NotFound           Code = 5
+	PermissionDenied   Code = 7
+	FailedPrecondition Code = 9
+	Unimplemented      Code = 12
+	Internal           Code = 13
+)
+
+// Status is an error with a gRPC status code
+type Status struct {
+	Code    Code
+	Message string
+}
+
+// Error returns the status message
+func (s *Status) Error() string {
+	return s.Message
+}
+
+// Errorf returns a Status error with the given code
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Method handles the calls of one RPC. It decodes the request and calls send
+// with each response: once for a unary RPC, any number of times for a server
+// streaming one.
+type Method func(ctx context.Context, request []byte, send func(Marshaler) error) error
+
+// Server serves gRPC calls over HTTP/2. Only the identity encoding is
+// supported, and each call has one request message.
+type Server struct {
+	methods map[string]Method // By path, "/package.Service/Method"
+}
+
+// NewServer creates a server without methods
+func NewServer() *Server {
+	return &Server{methods: make(map[string]Method)}
+}
+
+// Handle registers the method of a service, such as "codectx.v1.Codectx" and "Scan"
+func (s *Server) Handle(service, name string, method Method) {
+	s.methods["/"+service+"/"+name] = method
+}
+
+// ListenAndServe serves gRPC on addr. Without TLS, HTTP/2 is spoken in
+// cleartext (h2c), as gRPC clients do for insecure channels.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves gRPC on listener until ctx is canceled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{Handler: s, Protocols: &protocols}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP handles one gRPC call
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "codectx serves gRPC only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+	err := s.call(r, w)
+	status, ok := err.(*Status)
+	switch {
+	case err == nil:
+		status = &Status{Code: OK}
+	case errors.Is(err, context.Canceled):
+		status = &Status{Code: Canceled, Message: err.Error()}
+	case !ok:
+		status = &Status{Code: Unknown, Message: err.Error()}
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(status.Code)))
+	if status.Message != "" {
+		w.Header().Set("Grpc-Message", encodeGRPCMessage(status.Message))
+	}
+}
+
+// call reads the request message and runs the method
+func (s *Server) call(r *http.Request, w http.ResponseWriter) error {
+	method, ok := s.methods[r.URL.Path]
+	if !ok {
+		return Errorf(Unimplemented, "unknown method %s", r.URL.Path)
+	}
+	request, err := readMessage(r.Body)
+	if err != nil {
+		return err
+	}
+	flusher, _ := w.(http.Flusher)
+	return method(r.Context(), request, func(m Marshaler) error {
+		if err := writeMessage(w, Marshal(m)); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// readMessage reads one length-prefixed message of a call
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, Errorf(InvalidArgument, "missing request message")
+	}
+	if header[0] != 0 {
+		return nil, Errorf(Unimplemented, "compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > MaxMessageSize {
+		return nil, Errorf(InvalidArgument, "request message of %d bytes exceeds %d", length, MaxMessageSize)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, Errorf(InvalidArgument, "truncated request message")
+	}
+	return message, nil
+}
+
+// writeMessage writes one length-prefixed, uncompressed message
+func writeMessage(w io.Writer, message []byte) error {
+	header := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+	_, err := w.Write(append(header, message...))
+	return err
+}
+
+// encodeGRPCMessage percent-encodes a status message for the grpc-message
+// trailer, as the protocol requires
+func encodeGRPCMessage(message string) string {
+	return strings.ReplaceAll(url.PathEscape(message), "+", "%2B")
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestScanRequest_RoundTrip(t *testing.T) {
+	want := ScanRequest{
+		Directory:       "services/api",
+		Extensions:      []string{"go", "proto"},
+		Exclude:         []string{"vendor/*"},
+		ExcludeRegex:    []string{`_gen\.go$`},
+		IncludeDotfiles: true,
+		MaxFileSize:     "2MB",
+		Tests:           "skip",
+	}
+	var got ScanRequest
+	if err := got.Unmarshal(Marshal(&want)); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// Nested in an AnalyzeRequest, with an unknown field to skip
+	e := Encoder{}
+	e.Message(1, &want)
+	e.Int64(15, 42)
+	var analyze AnalyzeRequest
+	if err := analyze.Unmarshal(e.Bytes()); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if analyze.Scan == nil || !reflect.DeepEqual(*analyze.Scan, want) {
+		t.Errorf("Expected %+v, got %+v", want, analyze.Scan)
+	}
+
+	if err := got.Unmarshal([]byte{0x0a, 0x05, 'a'}); err == nil {
+		t.Error("Expected an error for a truncated field")
+	}
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer()
+	server.Handle(ServiceName, "GetFile", func(ctx context.Context, request []byte, send func(Marshaler) error) error {
+		var req GetFileRequest
+		if err := req.Unmarshal(request); err != nil {
+			return err
+		}
+		if req.Path == "missing.go" {
+			return Errorf(NotFound, "no such file: %s", req.Path)
+		}
+		for _, name := range []string{"a.go", "b.go"} {
+			if err := send(&File{Path: req.Directory + "/" + name, Tokens: 3}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx, listener)
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	call := func(method string, req Marshaler) (*http.Response, [][]byte) {
+		t.Helper()
+		message := Marshal(req)
+		body := make([]byte, 5, 5+len(message))
+		binary.BigEndian.PutUint32(body[1:], uint32(len(message)))
+		body = append(body, message...)
+		httpReq, _ := http.NewRequest(http.MethodPost, "http://"+listener.Addr().String()+"/"+ServiceName+"/"+method, bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/grpc")
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Reading %s failed: %v", method, err)
+		}
+		var messages [][]byte
+		for len(data) >= 5 {
+			length := binary.BigEndian.Uint32(data[1:5])
+			messages = append(messages, data[5:5+length])
+			data = data[5+length:]
+		}
+		return resp, messages
+	}
+
+	resp, messages := call("GetFile", &GetFileRequest{Directory: "src", Path: "a.go"})
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+	if status := resp.Trailer.Get("Grpc-Status"); status != "0" {
+		t.Errorf("Expected status 0, got %q", status)
+	}
+	var paths []string
+	for _, message := range messages {
+		Decode(message, func(f Field) error {
+			if f.Number == 1 {
+				paths = append(paths, string(f.Data))
+			}
+			return nil
+		})
+	}
+	if want := []string{"src/a.go", "src/b.go"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+
+	resp, messages = call("GetFile", &GetFileRequest{Path: "missing.go"})
+	if status := resp.Trailer.Get("Grpc-Status"); status != "5" || len(messages) != 0 {
+		t.Errorf("Expected status 5 without messages, got %q with %d", status, len(messages))
+	}
+	if message := resp.Trailer.Get("Grpc-Message"); message != "no%20such%20file:%20missing.go" {
+		t.Errorf("Expected an encoded message, got %q", message)
+	}
+
+	resp, _ = call("Scan", &ScanRequest{})
+	if status := resp.Trailer.Get("Grpc-Status"); status != "12" {
+		t.Errorf("Expected status 12 for an unknown method, got %q", status)
+	}
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protocol Buffers wire types used by the codectx messages
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// Encoder appends fields in the Protocol Buffers wire format. Fields with the
+// zero value are omitted, as in proto3.
+type Encoder struct {
+	buf []byte
+}
+
+// Bytes returns the encoded message
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// tag appends the key of a field
+func (e *Encoder) tag(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+// Int64 appends an int64 field
+func (e *Encoder) Int64(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = binary.AppendUvarint(e.buf, uint64(v))
+}
+
+// Bool appends a bool field
+func (e *Encoder) Bool(field int, v bool) {
+	if !v {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = append(e.buf, 1)
+}
+
+// String appends a string field
+func (e *Encoder) String(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// Strings appends a repeated string field
+func (e *Encoder) Strings(field int, values []string) {
+	for _, s := range values {
+		e.tag(field, wireBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+		e.buf = append(e.buf, s...)
+	}
+}
+
+// BytesField appends a bytes field
+func (e *Encoder) BytesField(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// Message appends an embedded message field, encoded by m
+func (e *Encoder) Message(field int, m Marshaler) {
+	var inner Encoder
+	m.MarshalTo(&inner)
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(inner.buf)))
+	e.buf = append(e.buf, inner.buf...)
+}
+
+// Marshaler is a message that can be encoded
+type Marshaler interface {
+	MarshalTo(e *Encoder)
+}
+
+// Marshal encodes a message
+func Marshal(m Marshaler) []byte {
+	var e Encoder
+	m.MarshalTo(&e)
+	return e.Bytes()
+}
+
+// Field is a field read by Decode
+type Field struct {
+	Number int
+	Varint uint64 // Value of a varint field
+	Data   []byte // Value of a length-delimited field
+}
+
+// Decode calls fn with each field of an encoded message. Fields of the
+// fixed-size wire types are skipped, as no codectx message has any.
+func Decode(data []byte, fn func(Field) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		field := Field{Number: int(key >> 3)}
+		switch wireType := key & 7; wireType {
+		case wireVarint:
+			if field.Varint, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field.Number)
+			}
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return fmt.Errorf("invalid length of field %d", field.Number)
+			}
+			field.Data = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", field.Number)
+			}
+			data = data[8:]
+			continue
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", field.Number)
+			}
+			data = data[4:]
+			continue
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", wireType, field.Number)
+		}
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codectx/internal/platform"
+	"codectx/internal/utils"
+)
+
+// DetailField identifies a piece of metadata shown next to tree entries
+type DetailField string
+
+const (
+	// DetailSize shows the file size
+	DetailSize DetailField = "size"
+	// DetailLines shows the line count
+	DetailLines DetailField = "lines"
+	// DetailTokens shows the estimated token count
+	DetailTokens DetailField = "tokens"
+)
+
+// DefaultTreeDetails are the fields shown when --tree-details is given without a value
+const DefaultTreeDetails = "lines,tokens"
+
+// EntryDetails holds the metadata shown next to an entry in the tree.
+// For directories the values are totals over the whole subtree.
+type EntryDetails struct {
+	Size   int64
+	Lines  int
+	Tokens int
+}
+
+// TokenEstimator estimates the number of tokens in a text file
+type TokenEstimator func(path string) (int, error)
+
+// ParseTreeDetails parses a comma-separated list of detail fields
+func ParseTreeDetails(spec string) ([]DetailField, error) {
+	var fields []DetailField
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch DetailField(part) {
+		case "":
+			continue
+		case DetailSize, DetailLines, DetailTokens:
+			fields = append(fields, DetailField(part))
+		default:
+			return nil, fmt.Errorf("unsupported tree detail: %s (expected size, lines, or tokens)", part)
+		}
+	}
+	return fields, nil
+}
+
+// CollectDetails computes the details of every entry under root. Binary files
+// only contribute their size; directories aggregate the totals of their children.
+func (s *Scanner) CollectDetails(root *FileEntry, estimate TokenEstimator) {
+	s.collectDetailsRecursive(root, estimate)
+}
+
+// FileDetails maps the relative path of each file under root to the details
+// CollectDetails computed for it. Paths have a leading slash, like
+// GetRelativePaths.
+func (s *Scanner) FileDetails(root *FileEntry) map[string]*EntryDetails {
+	details := make(map[string]*EntryDetails)
+	s.collectFileDetails(root, details)
+	return details
+}
+
+// collectFileDetails recursively collects the details of the files under entry
+func (s *Scanner) collectFileDetails(entry *FileEntry, details map[string]*EntryDetails) {
+	if !entry.IsDir && entry.Details != nil {
+		if relPath, err := platform.RelSlash(s.RootDir, entry.Path); err == nil {
+			details["/"+relPath] = entry.Details
+		}
+	}
+	for _, child := range entry.Children {
+		s.collectFileDetails(child, details)
+	}
+}
+
+// collectDetailsRecursive computes the details of an entry and returns them
+func (s *Scanner) collectDetailsRecursive(entry *FileEntry, estimate TokenEstimator) *EntryDetails {
+	details := &EntryDetails{}
+
+	if entry.IsDir {
+		for _, child := range entry.Children {
+			childDetails := s.collectDetailsRecursive(child, estimate)
+			details.Size += childDetails.Size
+			details.Lines += childDetails.Lines
+			details.Tokens += childDetails.Tokens
+		}
+		entry.Details = details
+		return details
+	}
+
+	info, err := s.Stat(entry.Path)
+	if err != nil {
+		entry.Details = details
+		return details
+	}
+	details.Size = info.Size()
+
+	if isText, err := utils.IsTextFile(entry.Path); err == nil && isText {
+		if lines, err := countLines(entry.Path); err == nil {
+			details.Lines = lines
+		}
+		if estimate != nil {
+			if tokens, err := estimate(entry.Path); err == nil {
+				details.Tokens = tokens
+			}
+		}
+	}
+
+	entry.Details = details
+	return details
+}
+
+// formatDetails renders the selected fields, e.g. "(342 lines, ~1.2k tokens)"
+func (s *Scanner) formatDetails(details *EntryDetails) string {
+	if details == nil || len(s.TreeDetails) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range s.TreeDetails {
+		switch field {
+		case DetailSize:
+			parts = append(parts, utils.FormatSize(details.Size, s.SizeUnits))
+		case DetailLines:
+			if details.Lines == 1 {
+				parts = append(parts, "1 line")
+			} else {
+				parts = append(parts, formatCount(details.Lines)+" lines")
+			}
+		case DetailTokens:
+			parts = append(parts, "~"+formatCount(details.Tokens)+" tokens")
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// countLines counts the lines in a file, including a final line without a newline
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 32*1024)
+	lines := 0
+	var last byte
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if last != 0 && last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
+
+// formatCount abbreviates large counts, e.g. 1234 -> "1.2k"
+func formatCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"codectx/internal/platform"
+)
+
+// DirectoryRecord describes a directory of the tree, so that tools can rebuild
+// the tree, empty directories included, from JSON output
+type DirectoryRecord struct {
+	Path        string `json:"path"`                        // Relative and slash-separated ("." for the root)
+	Files       int    `json:"files"`                       // Files directly in the directory
+	Directories int    `json:"directories"`                 // Subdirectories directly in the directory
+	TotalFiles  int    `json:"total_files"`                 // Files anywhere below the directory
+	SizeBytes   int64  `json:"size_bytes"`                  // Total size of those files
+	Denied      bool   `json:"permission_denied,omitempty"` // The directory couldn't be read
+}
+
+// DirectoryRecords lists the directories under root, root first, in tree order
+func (s *Scanner) DirectoryRecords(root *FileEntry) []DirectoryRecord {
+	var records []DirectoryRecord
+	s.collectDirectoryRecords(root, &records)
+	return records
+}
+
+// collectDirectoryRecords adds the record of entry, if it is a directory, and
+// those of the directories below it, returning the number and size of the
+// files below it
+func (s *Scanner) collectDirectoryRecords(entry *FileEntry, records *[]DirectoryRecord) (int, int64) {
+	if !entry.IsDir {
+		return 1, entry.Size
+	}
+
+	relPath, err := platform.RelSlash(s.RootDir, entry.Path)
+	if err != nil {
+		relPath = entry.Path
+	}
+	// The record is filled in once the subtree is counted
+	index := len(*records)
+	*records = append(*records, DirectoryRecord{})
+
+	record := DirectoryRecord{Path: relPath, Denied: entry.Denied}
+	for _, child := range entry.Children {
+		if child.IsDir {
+			record.Directories++
+		} else {
+			record.Files++
+		}
+		files, size := s.collectDirectoryRecords(child, records)
+		record.TotalFiles += files
+		record.SizeBytes += size
+	}
+	(*records)[index] = record
+	return record.TotalFiles, record.SizeBytes
+}
@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"os"
+
+	"codectx/internal/platform"
+)
+
+// linkIndex finds files that are the same physical file as a file seen earlier,
+// such as hard links or files reached twice through a bind mount. Files are
+// grouped by size so that os.SameFile is only called on likely candidates.
+type linkIndex map[int64][]*FileEntry
+
+// add records a regular file and returns the earlier entry for the same physical file, if any
+func (l linkIndex) add(entry *FileEntry) *FileEntry {
+	if entry.IsDir || entry.Symlink || entry.info == nil || !entry.Mode.IsRegular() {
+		return nil
+	}
+	for _, seen := range l[entry.Size] {
+		if os.SameFile(seen.info, entry.info) {
+			return seen
+		}
+	}
+	l[entry.Size] = append(l[entry.Size], entry)
+	return nil
+}
+
+// LinkedFiles maps the relative path of each file that is the same physical
+// file as an earlier file in the tree (see FileEntry.LinkOf) to the relative
+// path of the first one. Paths have a leading slash, like GetRelativePaths.
+func (s *Scanner) LinkedFiles(root *FileEntry) map[string]string {
+	linked := make(map[string]string)
+	s.collectLinkedFiles(root, linked)
+	return linked
+}
+
+// collectLinkedFiles recursively collects the linked files under entry
+func (s *Scanner) collectLinkedFiles(entry *FileEntry, linked map[string]string) {
+	if entry.LinkOf != "" {
+		relPath, err := platform.RelSlash(s.RootDir, entry.Path)
+		original, originalErr := platform.RelSlash(s.RootDir, entry.LinkOf)
+		if err == nil && originalErr == nil {
+			linked["/"+relPath] = "/" + original
+		}
+	}
+	for _, child := range entry.Children {
+		s.collectLinkedFiles(child, linked)
+	}
+}
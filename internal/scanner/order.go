@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"cmp"
+	"strings"
+)
+
+// compareNames orders two names of the same directory. Names are compared
+// byte by byte, which doesn't depend on the platform or the locale; with
+// natural set, runs of digits are compared by their value first, so that
+// "file2" comes before "file10".
+func compareNames(a, b string, natural bool) int {
+	if natural {
+		if c := compareNatural(a, b); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// compareNatural compares two names, taking runs of digits as numbers. Names
+// that only differ in leading zeros, such as "file2" and "file02", are equal.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := splitDigits(a)
+			numB, restB := splitDigits(b)
+			numA = strings.TrimLeft(numA, "0")
+			numB = strings.TrimLeft(numB, "0")
+			// Without leading zeros, the longer number is the larger one
+			if len(numA) != len(numB) {
+				return cmp.Compare(len(numA), len(numB))
+			}
+			if c := strings.Compare(numA, numB); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// splitDigits splits the leading run of digits off s
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
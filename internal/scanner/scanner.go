@@ -1,66 +1,254 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"codectx/internal/platform"
+	"codectx/internal/utils"
 )
 
-// FileEntry represents a file or directory in the scanned structure
+// FileEntry represents a file or directory in the scanned structure. The size,
+// mode, and modification time are captured once during the scan; for symbolic
+// links they describe the link target.
 type FileEntry struct {
-	Path     string
-	IsDir    bool
-	Children []*FileEntry
+	Path       string
+	IsDir      bool
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	Symlink    bool   // Path is a symbolic link
+	LinkTarget string // Destination of the symbolic link as stored in the link
+	LinkOf     string // Path of an earlier entry for the same physical file (hard link or bind mount)
+	Denied     bool   // Permission was denied reading the entry; set by the scan for directories and by MarkDenied
+	Children   []*FileEntry
+	Details    *EntryDetails // Populated by CollectDetails
+	KeyFile    bool          // Set by MarkKeyFiles for high-signal files
+	info       os.FileInfo
+}
+
+// TreeChars holds the connectors used to draw the directory tree
+type TreeChars struct {
+	Branch   string // Connector for an entry with siblings below it
+	Last     string // Connector for the last entry in a directory
+	Vertical string // Indentation below an entry with siblings below it
+	Space    string // Indentation below the last entry in a directory
+}
+
+// UnicodeTreeChars draws the tree with box-drawing characters
+var UnicodeTreeChars = TreeChars{
+	Branch:   "├── ",
+	Last:     "└── ",
+	Vertical: "│   ",
+	Space:    "    ",
+}
+
+// ASCIITreeChars draws the tree with plain ASCII for consoles without Unicode support
+var ASCIITreeChars = TreeChars{
+	Branch:   "|-- ",
+	Last:     "`-- ",
+	Vertical: "|   ",
+	Space:    "    ",
+}
+
+// BoldTreeChars draws the tree with heavy box-drawing characters
+var BoldTreeChars = TreeChars{
+	Branch:   "┣━━ ",
+	Last:     "┗━━ ",
+	Vertical: "┃   ",
+	Space:    "    ",
+}
+
+// PlainTreeChars draws the tree using indentation only
+var PlainTreeChars = TreeChars{
+	Branch:   "",
+	Last:     "",
+	Vertical: "    ",
+	Space:    "    ",
+}
+
+// ParseTreeStyle returns the tree connectors for the given style name
+func ParseTreeStyle(style string) (TreeChars, error) {
+	switch strings.ToLower(strings.TrimSpace(style)) {
+	case "", "unicode":
+		return UnicodeTreeChars, nil
+	case "ascii":
+		return ASCIITreeChars, nil
+	case "bold":
+		return BoldTreeChars, nil
+	case "none":
+		return PlainTreeChars, nil
+	default:
+		return TreeChars{}, fmt.Errorf("unsupported tree style: %s", style)
+	}
 }
 
 // Scanner handles directory scanning and tree generation
 type Scanner struct {
 	RootDir         string
 	IncludeDotfiles bool
+	TreeChars       TreeChars
+	EastAsianWidth  bool            // Count ambiguous-width characters as two columns
+	TreeDetails     []DetailField   // Metadata appended to each tree entry
+	SizeUnits       utils.SizeUnits // Units of the sizes in tree details
+
+	// Limits that protect against walking pathological trees (0 for no limit)
+	MaxFiles int           // Stop the walk after this many files
+	MaxDepth int           // Don't descend more than this many directory levels below the root
+	Timeout  time.Duration // Stop the walk after this long
+
+	OneFileSystem bool // Don't descend into directories on other file systems (mount points)
+	NaturalSort   bool // Compare runs of digits in names by value, e.g. file2 before file10
+
+	Stopped        string   // Why the last walk stopped early, e.g. "100000 files" ("" if it finished)
+	DepthSkipped   int      // Directories the last walk didn't descend into because of MaxDepth
+	MountsSkipped  int      // Mount points the last walk didn't descend into because of OneFileSystem
+	SpecialSkipped int      // Sockets, FIFOs, and device nodes the last walk left out
+	Denied         []string // Directories the last walk couldn't read because permission was denied
+
+	infos      map[string]os.FileInfo
+	files      int
+	deadline   time.Time
+	rootDevice uint64
+	hasDevice  bool
 }
 
+// specialModes are the file types that are never read: sockets, FIFOs, and device nodes
+const specialModes = os.ModeSocket | os.ModeNamedPipe | os.ModeDevice | os.ModeCharDevice | os.ModeIrregular
+
 // NewScanner creates a new scanner for the given directory
 func NewScanner(rootDir string, includeDotfiles bool) *Scanner {
 	return &Scanner{
 		RootDir:         rootDir,
 		IncludeDotfiles: includeDotfiles,
+		TreeChars:       UnicodeTreeChars,
+		EastAsianWidth:  IsEastAsianLocale(),
 	}
 }
 
+// WalkFunc is called for each entry found by Walk. Entries carry their metadata
+// but not their children. Returning filepath.SkipDir from a directory skips its
+// contents, and from a file skips the rest of its directory; any other error
+// stops the walk and is returned by Walk.
+type WalkFunc func(entry *FileEntry) error
+
 // Scan performs the directory scan and returns the root entry
 func (s *Scanner) Scan() (*FileEntry, error) {
+	return s.ScanContext(context.Background())
+}
+
+// ScanContext builds the tree of entries streamed by Walk, records their
+// metadata for Stat, and sets LinkOf on files already seen at another path.
+// It stops early when ctx is canceled.
+func (s *Scanner) ScanContext(ctx context.Context) (*FileEntry, error) {
+	var root *FileEntry
+	dirs := make(map[string]*FileEntry)
+	links := make(linkIndex)
+	s.infos = make(map[string]os.FileInfo)
+
+	err := s.Walk(ctx, func(entry *FileEntry) error {
+		path := filepath.Clean(entry.Path)
+		if entry.info != nil {
+			s.infos[path] = entry.info
+		}
+		if original := links.add(entry); original != nil {
+			entry.LinkOf = original.Path
+		}
+		if root == nil {
+			root = entry
+		} else {
+			parent := dirs[filepath.Dir(path)]
+			parent.Children = append(parent.Children, entry)
+		}
+		if entry.IsDir {
+			dirs[path] = entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// Walk streams the entries under the root directory to fn, starting with the
+// root itself, without building the tree in memory. Each directory is followed
+// by its contents: directories first, then files, both ordered by comparing
+// their names byte by byte (see NaturalSort), so that the order is the same on
+// every platform and locale.
+// Subdirectories that can't be read are reported to stderr and left out.
+// Reaching MaxFiles or Timeout ends the walk without an error and sets Stopped,
+// so the entries found so far can still be used.
+func (s *Scanner) Walk(ctx context.Context, fn WalkFunc) error {
+	s.Stopped = ""
+	s.DepthSkipped = 0
+	s.MountsSkipped = 0
+	s.SpecialSkipped = 0
+	s.Denied = nil
+	s.files = 0
+	s.deadline = time.Time{}
+	if s.Timeout > 0 {
+		s.deadline = time.Now().Add(s.Timeout)
+	}
+
 	rootInfo, err := os.Stat(s.RootDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to access root directory: %w", err)
+		return fmt.Errorf("failed to access root directory: %w", err)
 	}
 
 	if !rootInfo.IsDir() {
-		return nil, fmt.Errorf("%s is not a directory", s.RootDir)
+		return fmt.Errorf("%s is not a directory", s.RootDir)
 	}
+	s.rootDevice, s.hasDevice = platform.DeviceID(rootInfo)
 
 	root := &FileEntry{
-		Path:  s.RootDir,
-		IsDir: true,
+		Path:    s.RootDir,
+		IsDir:   true,
+		Mode:    rootInfo.Mode(),
+		ModTime: rootInfo.ModTime(),
+		info:    rootInfo,
 	}
-
-	err = s.scanDir(root)
+	entries, err := s.readDir(root.Path)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := fn(root); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
 	}
 
-	return root, nil
+	err = s.walkDir(ctx, root, entries, 1, fn)
+	if err == errStopped {
+		return nil
+	}
+	return err
 }
 
-// scanDir recursively scans a directory and populates the children of the given entry
-func (s *Scanner) scanDir(entry *FileEntry) error {
-	entries, err := os.ReadDir(entry.Path)
-	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", entry.Path, err)
-	}
+// errStopped ends a walk that reached one of the scanner's limits
+var errStopped = errors.New("scan stopped at a limit")
 
+// walkDir streams the given entries of a directory at the given depth (1 for
+// the root's entries), descending into subdirectories
+func (s *Scanner) walkDir(ctx context.Context, entry *FileEntry, entries []os.DirEntry, depth int, fn WalkFunc) error {
 	for _, dirEntry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+			s.Stopped = s.Timeout.String()
+			return errStopped
+		}
+
 		name := dirEntry.Name()
 
 		// Skip dotfiles if not explicitly included
@@ -75,75 +263,270 @@ func (s *Scanner) scanDir(entry *FileEntry) error {
 			Path:  path,
 			IsDir: isDir,
 		}
+		child.Mode = dirEntry.Type()
+		if info, err := s.entryInfo(dirEntry, child); err == nil {
+			child.Size = info.Size()
+			child.Mode = info.Mode()
+			child.ModTime = info.ModTime()
+			child.info = info
+		}
 
-		if isDir {
-			if err := s.scanDir(child); err != nil {
-				// Just log the error and continue if we can't access a subdirectory
-				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		// Skip special files explicitly instead of attempting to read them
+		if child.Mode&specialModes != 0 {
+			s.SpecialSkipped++
+			continue
+		}
+
+		if !isDir {
+			if s.MaxFiles > 0 && s.files >= s.MaxFiles {
+				s.Stopped = fmt.Sprintf("%d files", s.MaxFiles)
+				return errStopped
+			}
+			s.files++
+			if err := fn(child); err != nil {
+				if err == filepath.SkipDir {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+
+		// Directories below MaxDepth and mount points are listed without their contents
+		if s.MaxDepth > 0 && depth >= s.MaxDepth {
+			s.DepthSkipped++
+			if err := fn(child); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if s.OneFileSystem && s.crossesFileSystem(child) {
+			s.MountsSkipped++
+			if err := fn(child); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+
+		children, err := s.readDir(path)
+		if errors.Is(err, fs.ErrPermission) {
+			// Record directories we may not read and list them without their contents
+			child.Denied = true
+			s.Denied = append(s.Denied, path)
+			if err := fn(child); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			// Just log the error and continue if we can't access a subdirectory
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if err := fn(child); err != nil {
+			if err == filepath.SkipDir {
 				continue
 			}
+			return err
+		}
+		if err := s.walkDir(ctx, child, children, depth+1, fn); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		entry.Children = append(entry.Children, child)
+// crossesFileSystem reports whether a directory is on a different file system than the root
+func (s *Scanner) crossesFileSystem(entry *FileEntry) bool {
+	if !s.hasDevice || entry.info == nil {
+		return false
 	}
+	device, ok := platform.DeviceID(entry.info)
+	return ok && device != s.rootDevice
+}
 
-	// Sort children: directories first, then files, both alphabetically
-	sort.Slice(entry.Children, func(i, j int) bool {
-		if entry.Children[i].IsDir != entry.Children[j].IsDir {
-			return entry.Children[i].IsDir
+// readDir reads a directory, ordering directories first, then files, both by name
+func (s *Scanner) readDir(path string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
 		}
-		return filepath.Base(entry.Children[i].Path) < filepath.Base(entry.Children[j].Path)
+		return compareNames(entries[i].Name(), entries[j].Name(), s.NaturalSort) < 0
 	})
 
-	return nil
+	return entries, nil
+}
+
+// entryInfo returns the file information of a directory entry, following
+// symbolic links so that it matches os.Stat, and records link details on child
+func (s *Scanner) entryInfo(dirEntry os.DirEntry, child *FileEntry) (os.FileInfo, error) {
+	if dirEntry.Type()&os.ModeSymlink == 0 {
+		return dirEntry.Info()
+	}
+	child.Symlink = true
+	child.LinkTarget, _ = os.Readlink(child.Path)
+	return os.Stat(child.Path)
+}
+
+// Stat returns the file information captured during the last scan, so the rest
+// of the pipeline doesn't have to stat every file again. Paths outside the scan
+// fall back to os.Stat.
+func (s *Scanner) Stat(path string) (os.FileInfo, error) {
+	if info, ok := s.infos[filepath.Clean(path)]; ok {
+		return info, nil
+	}
+	return os.Stat(path)
+}
+
+// treeLine is a single rendered entry of the tree
+type treeLine struct {
+	text    string
+	details string
 }
 
 // GenerateTree creates a string representation of the directory tree
 func (s *Scanner) GenerateTree(root *FileEntry) string {
+	var lines []treeLine
+	s.generateTreeRecursive(&lines, root, "", true)
+
+	// Align the details of all entries into a single column
+	width := 0
+	for _, line := range lines {
+		if w := StringWidth(line.text, s.EastAsianWidth); w > width {
+			width = w
+		}
+	}
+
 	var sb strings.Builder
-	s.generateTreeRecursive(&sb, root, "", true)
+	for _, line := range lines {
+		sb.WriteString(line.text)
+		if line.details != "" {
+			sb.WriteString(strings.Repeat(" ", width-StringWidth(line.text, s.EastAsianWidth)+2))
+			sb.WriteString(line.details)
+		}
+		sb.WriteString("\n")
+	}
 	return sb.String()
 }
 
 // generateTreeRecursive builds the tree representation recursively
-func (s *Scanner) generateTreeRecursive(sb *strings.Builder, entry *FileEntry, prefix string, isLast bool) {
+func (s *Scanner) generateTreeRecursive(lines *[]treeLine, entry *FileEntry, prefix string, isLast bool) {
 	// Skip the root directory itself
 	if entry.Path != s.RootDir {
+		var line treeLine
 		if isLast {
-			sb.WriteString(prefix + "└── ")
-			prefix += "    "
+			line.text = prefix + s.TreeChars.Last
+			prefix += s.continuation(s.TreeChars.Space, s.TreeChars.Last)
 		} else {
-			sb.WriteString(prefix + "├── ")
-			prefix += "│   "
+			line.text = prefix + s.TreeChars.Branch
+			prefix += s.continuation(s.TreeChars.Vertical, s.TreeChars.Branch)
 		}
 
 		// Write the entry name
-		sb.WriteString(filepath.Base(entry.Path))
+		line.text += filepath.Base(entry.Path)
 		if entry.IsDir {
-			sb.WriteString("/")
+			line.text += "/"
 		}
-		sb.WriteString("\n")
+		if entry.KeyFile {
+			line.text += KeyFileMarker
+		}
+		if entry.Denied {
+			line.text += DeniedMarker
+		}
+		line.details = s.formatDetails(entry.Details)
+		*lines = append(*lines, line)
 	}
 
 	// Process children
 	for i, child := range entry.Children {
 		isLastChild := i == len(entry.Children)-1
-		s.generateTreeRecursive(sb, child, prefix, isLastChild)
+		s.generateTreeRecursive(lines, child, prefix, isLastChild)
 	}
 }
 
-// GetRelativePaths returns a list of all file paths relative to the root directory
+// continuation pads an indentation segment so that it spans the same number of
+// terminal columns as the connector above it. Without this, box-drawing characters
+// rendered double-width by CJK terminals would shift nested entries out of line.
+func (s *Scanner) continuation(segment, connector string) string {
+	segmentWidth := StringWidth(segment, s.EastAsianWidth)
+	connectorWidth := StringWidth(connector, s.EastAsianWidth)
+	if segmentWidth < connectorWidth {
+		segment += strings.Repeat(" ", connectorWidth-segmentWidth)
+	}
+	return segment
+}
+
+// GetRelativePaths returns a list of all file paths relative to the root directory.
+// Paths always use forward slashes and start with "/", regardless of platform.
 func (s *Scanner) GetRelativePaths(root *FileEntry) []string {
 	var paths []string
 	s.collectRelativePaths(root, &paths)
 	return paths
 }
 
+// KeyFileMarker is appended to key files in the directory tree
+const KeyFileMarker = " [key]"
+
+// DeniedMarker is appended to entries that couldn't be read in the directory tree
+const DeniedMarker = " [permission denied]"
+
+// MarkKeyFiles flags the files for which isKey returns true and returns their
+// paths, slash-separated and relative to the root directory, in tree order
+func (s *Scanner) MarkKeyFiles(root *FileEntry, isKey func(relPath string) bool) []string {
+	var keyFiles []string
+	for _, relPath := range s.GetRelativePaths(root) {
+		if isKey(relPath[1:]) {
+			keyFiles = append(keyFiles, relPath[1:])
+		}
+	}
+
+	keySet := make(map[string]bool, len(keyFiles))
+	for _, relPath := range keyFiles {
+		keySet[relPath] = true
+	}
+	s.markKeyFilesRecursive(root, keySet)
+	return keyFiles
+}
+
+// markKeyFilesRecursive sets KeyFile on the files in keySet
+func (s *Scanner) markKeyFilesRecursive(entry *FileEntry, keySet map[string]bool) {
+	if !entry.IsDir {
+		if relPath, err := platform.RelSlash(s.RootDir, entry.Path); err == nil {
+			entry.KeyFile = keySet[relPath]
+		}
+	}
+	for _, child := range entry.Children {
+		s.markKeyFilesRecursive(child, keySet)
+	}
+}
+
+// MarkDenied flags the files in denied (slash-separated paths relative to the
+// root directory, without a leading slash) as unreadable
+func (s *Scanner) MarkDenied(root *FileEntry, denied map[string]bool) {
+	if len(denied) == 0 {
+		return
+	}
+	if !root.IsDir {
+		if relPath, err := platform.RelSlash(s.RootDir, root.Path); err == nil && denied[relPath] {
+			root.Denied = true
+		}
+	}
+	for _, child := range root.Children {
+		s.MarkDenied(child, denied)
+	}
+}
+
 // collectRelativePaths recursively collects relative paths from the given entry
 func (s *Scanner) collectRelativePaths(entry *FileEntry, paths *[]string) {
 	// Skip directories
 	if !entry.IsDir {
-		relPath, err := filepath.Rel(s.RootDir, entry.Path)
+		relPath, err := platform.RelSlash(s.RootDir, entry.Path)
 		if err == nil {
 			*paths = append(*paths, "/"+relPath)
 		}
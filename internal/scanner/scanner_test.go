@@ -1,10 +1,18 @@
 package scanner
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"codectx/internal/benchtree"
 )
 
 func TestNewScanner(t *testing.T) {
@@ -144,6 +152,37 @@ func TestScanner_GenerateTree(t *testing.T) {
 	}
 }
 
+func TestScanner_GenerateTree_ASCII(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_ascii_tree_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"dir/a.txt", "b.txt"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.TreeChars = ASCIITreeChars
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	tree := scanner.GenerateTree(root)
+	expected := "|-- dir/\n|   `-- a.txt\n`-- b.txt\n"
+	if tree != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, tree)
+	}
+}
+
 func TestScanner_GetRelativePaths(t *testing.T) {
 	// Create a temporary directory structure
 	tempDir, err := os.MkdirTemp("", "codectx_paths_test")
@@ -204,6 +243,66 @@ func TestScanner_GetRelativePaths(t *testing.T) {
 	}
 }
 
+func TestScanner_Order(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, file := range []string{"file10.txt", "file2.txt", "file02.txt", "File3.txt", "b/x.txt", "a10/x.txt", "a9/x.txt"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	tests := []struct {
+		natural  bool
+		expected []string
+	}{
+		{
+			natural:  false,
+			expected: []string{"/a10/x.txt", "/a9/x.txt", "/b/x.txt", "/File3.txt", "/file02.txt", "/file10.txt", "/file2.txt"},
+		},
+		{
+			natural:  true,
+			expected: []string{"/a9/x.txt", "/a10/x.txt", "/b/x.txt", "/File3.txt", "/file02.txt", "/file2.txt", "/file10.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		scanner := NewScanner(tempDir, false)
+		scanner.NaturalSort = tt.natural
+		root, err := scanner.Scan()
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if paths := scanner.GetRelativePaths(root); !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("NaturalSort %v: expected %v, got %v", tt.natural, tt.expected, paths)
+		}
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "file2", b: "file10", expected: -1},
+		{a: "file10", b: "file2", expected: 1},
+		{a: "file2", b: "file02", expected: 0},
+		{a: "v1.9.0", b: "v1.10.0", expected: -1},
+		{a: "abc", b: "abd", expected: -1},
+		{a: "file", b: "file1", expected: -1},
+		{a: "99", b: "100", expected: -1},
+	}
+
+	for _, tt := range tests {
+		if c := compareNatural(tt.a, tt.b); c != tt.expected {
+			t.Errorf("compareNatural(%q, %q): expected %d, got %d", tt.a, tt.b, tt.expected, c)
+		}
+	}
+}
+
 func TestScanner_ScanNonExistentDirectory(t *testing.T) {
 	scanner := NewScanner("/non/existent/path", false)
 	_, err := scanner.Scan()
@@ -228,4 +327,537 @@ func TestScanner_ScanFile(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when scanning a file instead of directory")
 	}
-}
\ No newline at end of file
+}
+func TestParseTreeStyle(t *testing.T) {
+	tests := []struct {
+		style       string
+		expected    TreeChars
+		expectError bool
+	}{
+		{style: "", expected: UnicodeTreeChars},
+		{style: "unicode", expected: UnicodeTreeChars},
+		{style: "ASCII", expected: ASCIITreeChars},
+		{style: "bold", expected: BoldTreeChars},
+		{style: "none", expected: PlainTreeChars},
+		{style: "fancy", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			chars, err := ParseTreeStyle(tt.style)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for style %q", tt.style)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if chars != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, chars)
+			}
+		})
+	}
+}
+
+func TestScanner_GenerateTree_EastAsianWidth(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_cjk_tree_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"資料/a.txt", "資料/sub/b.txt", "z.txt"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.EastAsianWidth = true
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// Box-drawing connectors are two columns wide each in CJK terminals, so
+	// the indentation under them must be padded to the same width
+	tree := scanner.GenerateTree(root)
+	expected := "│     │     └── b.txt"
+	if !strings.Contains(tree, expected) {
+		t.Errorf("Expected tree to contain %q, got:\n%s", expected, tree)
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		input     string
+		eastAsian bool
+		expected  int
+	}{
+		{input: "main.go", expected: 7},
+		{input: "資料.txt", expected: 8},
+		{input: "├── ", expected: 4},
+		{input: "├── ", eastAsian: true, expected: 7},
+		{input: "é", expected: 1},
+	}
+
+	for _, tt := range tests {
+		if width := StringWidth(tt.input, tt.eastAsian); width != tt.expected {
+			t.Errorf("StringWidth(%q, %v): expected %d, got %d", tt.input, tt.eastAsian, tt.expected, width)
+		}
+	}
+}
+
+func TestScanner_GenerateTree_Details(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_details_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	testStructure := map[string]string{
+		"dir/a.txt": "one\ntwo\n",
+		"dir/b.txt": "three",
+		"main.go":   "package main\n",
+	}
+	for file, content := range testStructure {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.TreeChars = ASCIITreeChars
+	scanner.TreeDetails, err = ParseTreeDetails("size,lines,tokens")
+	if err != nil {
+		t.Fatalf("ParseTreeDetails failed: %v", err)
+	}
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	scanner.CollectDetails(root, func(path string) (int, error) { return 1000, nil })
+	tree := scanner.GenerateTree(root)
+
+	expectedLines := []string{
+		"|-- dir/       (13B, 3 lines, ~2.0k tokens)",
+		"|   |-- a.txt  (8B, 2 lines, ~1.0k tokens)",
+		"|   `-- b.txt  (5B, 1 line, ~1.0k tokens)",
+		"`-- main.go    (13B, 1 line, ~1.0k tokens)",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(tree, line+"\n") {
+			t.Errorf("Expected tree to contain %q, got:\n%s", line, tree)
+		}
+	}
+}
+
+func TestScanner_DirectoryRecords(t *testing.T) {
+	tempDir := t.TempDir()
+	for file, content := range map[string]string{"dir/a.txt": "one\n", "dir/sub/b.txt": "three", "main.go": "package main\n"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "empty"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	scanner := NewScanner(tempDir, false)
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	expected := []DirectoryRecord{
+		{Path: ".", Files: 1, Directories: 2, TotalFiles: 3, SizeBytes: 22},
+		{Path: "dir", Files: 1, Directories: 1, TotalFiles: 2, SizeBytes: 9},
+		{Path: "dir/sub", Files: 1, TotalFiles: 1, SizeBytes: 5},
+		{Path: "empty"},
+	}
+	records := scanner.DirectoryRecords(root)
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %+v", len(expected), records)
+	}
+	for i, record := range records {
+		if record != expected[i] {
+			t.Errorf("Expected record %d to be %+v, got %+v", i, expected[i], record)
+		}
+	}
+}
+
+func TestParseTreeDetails(t *testing.T) {
+	fields, err := ParseTreeDetails(" lines , tokens ")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fields) != 2 || fields[0] != DetailLines || fields[1] != DetailTokens {
+		t.Errorf("Expected [lines tokens], got %v", fields)
+	}
+
+	if _, err := ParseTreeDetails("lines,owner"); err == nil {
+		t.Error("Expected error for unknown detail field")
+	}
+}
+
+func TestScanner_MarkKeyFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_key_files_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"cmd/main.go", "cmd/util.go", "go.mod"} {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.TreeChars = ASCIITreeChars
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	keyFiles := scanner.MarkKeyFiles(root, func(relPath string) bool {
+		return relPath == "cmd/main.go" || relPath == "go.mod"
+	})
+	if len(keyFiles) != 2 || keyFiles[0] != "cmd/main.go" || keyFiles[1] != "go.mod" {
+		t.Errorf("Expected [cmd/main.go go.mod], got %v", keyFiles)
+	}
+
+	expected := "|-- cmd/\n|   |-- main.go [key]\n|   `-- util.go\n`-- go.mod [key]\n"
+	if tree := scanner.GenerateTree(root); tree != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, tree)
+	}
+}
+
+func TestScanner_ScanMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+	hasLink := runtime.GOOS != "windows" && os.Symlink("file.txt", filepath.Join(tempDir, "link.txt")) == nil
+
+	scanner := NewScanner(tempDir, false)
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	entries := make(map[string]*FileEntry)
+	for _, child := range root.Children {
+		entries[filepath.Base(child.Path)] = child
+	}
+
+	file := entries["file.txt"]
+	if file == nil {
+		t.Fatal("Expected file.txt to be scanned")
+	}
+	if file.Size != 5 {
+		t.Errorf("Expected size 5, got %d", file.Size)
+	}
+	if !file.ModTime.Equal(modTime) {
+		t.Errorf("Expected modification time %v, got %v", modTime, file.ModTime)
+	}
+	if !file.Mode.IsRegular() || file.Symlink {
+		t.Errorf("Expected a regular file, got mode %v (symlink %v)", file.Mode, file.Symlink)
+	}
+
+	if hasLink {
+		link := entries["link.txt"]
+		if link == nil {
+			t.Fatal("Expected link.txt to be scanned")
+		}
+		if !link.Symlink || link.LinkTarget != "file.txt" {
+			t.Errorf("Expected a symlink to file.txt, got symlink %v target %q", link.Symlink, link.LinkTarget)
+		}
+		if link.Size != 5 {
+			t.Errorf("Expected the link to report the target size 5, got %d", link.Size)
+		}
+	}
+
+	// Stat serves the captured information, even after the file changes
+	if err := os.WriteFile(filePath, []byte("changed content"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite file: %v", err)
+	}
+	info, err := scanner.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != 5 {
+		t.Errorf("Expected the scanned size 5, got %d", info.Size())
+	}
+
+	// Paths outside the scan fall back to the file system
+	if _, err := scanner.Stat(filepath.Join(tempDir, "missing.txt")); err == nil {
+		t.Error("Expected an error for a file outside the scan")
+	}
+}
+
+func TestScanner_Walk(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"b.txt", "a/z.go", "a/y/x.md", "c/w.txt", ".hidden"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		skip     string
+		expected []string
+	}{
+		{
+			name:     "all entries",
+			expected: []string{".", "a", "a/y", "a/y/x.md", "a/z.go", "c", "c/w.txt", "b.txt"},
+		},
+		{
+			name:     "skip directory",
+			skip:     "a",
+			expected: []string{".", "a", "c", "c/w.txt", "b.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visited []string
+			scanner := NewScanner(tempDir, false)
+			err := scanner.Walk(context.Background(), func(entry *FileEntry) error {
+				relPath, _ := filepath.Rel(tempDir, entry.Path)
+				relPath = filepath.ToSlash(relPath)
+				visited = append(visited, relPath)
+				if entry.Children != nil {
+					t.Errorf("Expected no children for streamed entry %s", relPath)
+				}
+				if relPath == tt.skip {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Walk failed: %v", err)
+			}
+			if strings.Join(visited, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, visited)
+			}
+		})
+	}
+
+	// Errors from the callback stop the walk
+	stop := errors.New("stop")
+	count := 0
+	err = NewScanner(tempDir, false).Walk(context.Background(), func(entry *FileEntry) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 3 {
+		t.Errorf("Expected the walk to stop after 3 entries with the callback error, got %d entries and %v", count, err)
+	}
+
+	// A canceled context stops the scan
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewScanner(tempDir, false).ScanContext(ctx); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestScanner_LinkedFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "copy.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("same"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	if err := os.Link(filepath.Join(tempDir, "a.txt"), filepath.Join(tempDir, "sub", "link.txt")); err != nil {
+		t.Skipf("Hard links are not supported: %v", err)
+	}
+
+	scanner := NewScanner(tempDir, false)
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// Directories come first, so the link inside sub/ is the first occurrence
+	linked := scanner.LinkedFiles(root)
+	if len(linked) != 1 || linked["/a.txt"] != "/sub/link.txt" {
+		t.Errorf("Expected /a.txt to be linked to /sub/link.txt, got %v", linked)
+	}
+}
+
+func TestScanner_Limits(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"a/b/c/deep.txt", "a/mid.txt", "top1.txt", "top2.txt"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		configure    func(s *Scanner)
+		expected     []string
+		stopped      string
+		depthSkipped int
+	}{
+		{
+			name:     "no limits",
+			expected: []string{"/a/b/c/deep.txt", "/a/mid.txt", "/top1.txt", "/top2.txt"},
+		},
+		{
+			name:      "max files",
+			configure: func(s *Scanner) { s.MaxFiles = 2 },
+			expected:  []string{"/a/b/c/deep.txt", "/a/mid.txt"},
+			stopped:   "2 files",
+		},
+		{
+			name:         "max depth",
+			configure:    func(s *Scanner) { s.MaxDepth = 2 },
+			expected:     []string{"/a/mid.txt", "/top1.txt", "/top2.txt"},
+			depthSkipped: 1,
+		},
+		{
+			name:      "timeout",
+			configure: func(s *Scanner) { s.Timeout = time.Nanosecond },
+			stopped:   "1ns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tempDir, false)
+			if tt.configure != nil {
+				tt.configure(scanner)
+			}
+			root, err := scanner.Scan()
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+
+			paths := scanner.GetRelativePaths(root)
+			if strings.Join(paths, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, paths)
+			}
+			if scanner.Stopped != tt.stopped {
+				t.Errorf("Expected Stopped %q, got %q", tt.stopped, scanner.Stopped)
+			}
+			if scanner.DepthSkipped != tt.depthSkipped {
+				t.Errorf("Expected %d directories skipped, got %d", tt.depthSkipped, scanner.DepthSkipped)
+			}
+		})
+	}
+}
+
+func TestScanner_MarkDenied(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, file := range []string{"open.txt", "sub/locked.txt"} {
+		fullPath := filepath.Join(tempDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", fullPath, err)
+		}
+	}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.TreeChars = ASCIITreeChars
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	scanner.MarkDenied(root, map[string]bool{"sub/locked.txt": true})
+
+	tree := scanner.GenerateTree(root)
+	if !strings.Contains(tree, "locked.txt"+DeniedMarker) {
+		t.Errorf("Expected locked.txt to be marked, got: %s", tree)
+	}
+	if strings.Contains(tree, "open.txt"+DeniedMarker) {
+		t.Errorf("Expected open.txt not to be marked, got: %s", tree)
+	}
+}
+
+// BenchmarkScanner_Scan scans synthetic trees of increasing size
+func BenchmarkScanner_Scan(b *testing.B) {
+	for _, files := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("%d_files", files), func(b *testing.B) {
+			dir := b.TempDir()
+			if _, err := benchtree.Generate(dir, benchtree.Spec{Files: files, Lines: 10}); err != nil {
+				b.Fatalf("Failed to generate tree: %v", err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewScanner(dir, false).Scan(); err != nil {
+					b.Fatalf("Scan failed: %v", err)
+				}
+			}
+		})
+	}
+}
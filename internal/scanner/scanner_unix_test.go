@@ -0,0 +1,121 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"codectx/internal/platform"
+)
+
+func TestScanner_SkipsSpecialFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "file.txt"), []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(tempDir, "pipe"), 0644); err != nil {
+		t.Skipf("FIFOs are not supported: %v", err)
+	}
+
+	scanner := NewScanner(tempDir, false)
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	paths := scanner.GetRelativePaths(root)
+	if len(paths) != 1 || paths[0] != "/file.txt" {
+		t.Errorf("Expected only /file.txt, got %v", paths)
+	}
+	if scanner.SpecialSkipped != 1 {
+		t.Errorf("Expected 1 special file skipped, got %d", scanner.SpecialSkipped)
+	}
+}
+
+func TestScanner_CrossesFileSystem(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	info, err := os.Stat(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to stat temp dir: %v", err)
+	}
+	device, ok := platform.DeviceID(info)
+	if !ok {
+		t.Skip("Device IDs are not reported")
+	}
+	entry := &FileEntry{Path: tempDir, IsDir: true, info: info}
+
+	scanner := NewScanner(tempDir, false)
+	scanner.rootDevice, scanner.hasDevice = device, true
+	if scanner.crossesFileSystem(entry) {
+		t.Error("Expected a directory on the root device not to cross file systems")
+	}
+	scanner.rootDevice = device + 1
+	if !scanner.crossesFileSystem(entry) {
+		t.Error("Expected a directory on another device to cross file systems")
+	}
+
+	// Scanning a tree on one file system skips nothing
+	if err := os.Mkdir(filepath.Join(tempDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	scanner.OneFileSystem = true
+	if _, err := scanner.Scan(); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if scanner.MountsSkipped != 0 {
+		t.Errorf("Expected no mount points skipped, got %d", scanner.MountsSkipped)
+	}
+}
+
+func TestScanner_PermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions are not enforced for root")
+	}
+	tempDir, err := os.MkdirTemp("", "codectx_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	private := filepath.Join(tempDir, "private")
+	if err := os.MkdirAll(private, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(private, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Chmod(private, 0); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	defer os.Chmod(private, 0755)
+
+	scanner := NewScanner(tempDir, false)
+	root, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if len(scanner.Denied) != 1 || scanner.Denied[0] != private {
+		t.Errorf("Expected %s to be denied, got %v", private, scanner.Denied)
+	}
+	if len(root.Children) != 1 || !root.Children[0].Denied {
+		t.Fatalf("Expected the denied directory in the tree, got %v", root.Children)
+	}
+	if tree := scanner.GenerateTree(root); !strings.Contains(tree, "private/"+DeniedMarker) {
+		t.Errorf("Expected the directory to be marked in the tree, got: %s", tree)
+	}
+}
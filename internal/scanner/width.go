@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// wideRanges lists code point ranges rendered as two columns by terminals
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x20000, 0x3FFFD}, // CJK unified ideographs extensions B and later
+}
+
+// ambiguousRanges lists code point ranges that East Asian terminals render as two columns
+var ambiguousRanges = []struct{ lo, hi rune }{
+	{0x2010, 0x2027}, // General punctuation
+	{0x2190, 0x21FF}, // Arrows
+	{0x2500, 0x257F}, // Box drawing
+	{0x2580, 0x259F}, // Block elements
+	{0x25A0, 0x25FF}, // Geometric shapes
+}
+
+// RuneWidth returns the number of terminal columns used to display r.
+// When eastAsian is true, ambiguous-width characters such as box drawing
+// characters are counted as two columns, matching CJK terminal settings.
+func RuneWidth(r rune, eastAsian bool) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+	if inRanges(r, wideRanges) {
+		return 2
+	}
+	if eastAsian && inRanges(r, ambiguousRanges) {
+		return 2
+	}
+	return 1
+}
+
+// StringWidth returns the number of terminal columns used to display s
+func StringWidth(s string, eastAsian bool) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r, eastAsian)
+	}
+	return width
+}
+
+// IsEastAsianLocale reports whether the current locale is Chinese, Japanese, or Korean
+func IsEastAsianLocale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		value := strings.ToLower(os.Getenv(name))
+		if value == "" {
+			continue
+		}
+		return strings.HasPrefix(value, "ja") || strings.HasPrefix(value, "zh") || strings.HasPrefix(value, "ko")
+	}
+	return false
+}
+
+// inRanges checks if r falls within any of the given ranges
+func inRanges(r rune, ranges []struct{ lo, hi rune }) bool {
+	for _, rng := range ranges {
+		if r >= rng.lo && r <= rng.hi {
+			return true
+		}
+	}
+	return false
+}
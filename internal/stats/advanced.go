@@ -2,12 +2,16 @@ package stats
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"time"
 
 	"codectx/internal/analysis"
+	"codectx/internal/audit"
+	"codectx/internal/coverage"
 	"codectx/internal/git"
 	"codectx/internal/utils"
 )
@@ -18,6 +22,14 @@ type AdvancedStatsCollector struct {
 	rootDir            string
 	HealthCheck        *analysis.HealthCheck
 	ComplexityAnalysis *analysis.ComplexityAnalysis
+	Functions          *analysis.FunctionReport // Complexity of each function of the included files
+	Hotspots           []analysis.Hotspot       // Files ranked by churn and complexity (nil when not ranked)
+	Ownership          *analysis.Ownership
+	DeadFiles          []analysis.DeadFile // Unused packages and assets (nil when not searched)
+	DocCoverage        *analysis.DocCoverage
+	Strings            []analysis.StringLiteral // User-facing string literals (nil when not extracted)
+	ConfigInventory    *analysis.ConfigInventory
+	IaC                *analysis.IaCInventory // Infrastructure-as-code modules and resources (nil when not counted)
 	LanguageStats      *analysis.LanguageStats
 	GitInfo            *git.GitInfo
 	GitStatusSummary   *git.GitStatusSummary
@@ -70,6 +82,28 @@ func CollectAdvancedStats(rootDir string, options AdvancedStatsOptions) (*Advanc
 		}
 	}
 
+	if stats.HealthCheck != nil {
+		leftovers, err := analysis.FindLeftovers(rootDir, options.DebugPatterns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to search for leftovers: %v\n", err)
+		} else {
+			stats.HealthCheck.AddLeftovers(leftovers)
+		}
+	}
+
+	if stats.HealthCheck != nil && options.AuditDeps != nil {
+		findings, err := audit.Audit(rootDir, *options.AuditDeps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to audit dependencies: %v\n", err)
+		} else {
+			stats.HealthCheck.AddVulnerabilities(findings)
+		}
+	}
+
+	if stats.HealthCheck != nil && options.Coverage != nil {
+		stats.HealthCheck.AddCoverage(options.Coverage)
+	}
+
 	if options.ComplexityAnalysis {
 		complexityAnalysis, err := analysis.AnalyzeProjectComplexity(rootDir)
 		if err != nil {
@@ -110,33 +144,73 @@ func CollectAdvancedStats(rootDir string, options AdvancedStatsOptions) (*Advanc
 }
 
 // PrintAdvancedStats prints the advanced statistics
-func (s *AdvancedStatsCollector) PrintAdvancedStats() {
+func (s *AdvancedStatsCollector) PrintAdvancedStats(w io.Writer) {
 	// Print basic stats
-	s.PrintStats()
+	s.PrintStats(w)
 
 	// Print health check if available
 	if s.HealthCheck != nil {
-		analysis.PrintHealthCheck(s.HealthCheck)
+		analysis.PrintHealthCheck(s.HealthCheck, w)
 	}
 
 	// Print complexity analysis if available
 	if s.ComplexityAnalysis != nil {
-		analysis.PrintComplexityAnalysis(s.ComplexityAnalysis)
+		analysis.PrintComplexityAnalysis(s.ComplexityAnalysis, w)
+	}
+
+	// Print complex functions if analyzed
+	if s.Functions != nil {
+		analysis.PrintFunctionReport(s.Functions, w)
+	}
+
+	// Print hotspots if ranked
+	if s.Hotspots != nil {
+		analysis.PrintHotspots(s.Hotspots, w)
+	}
+
+	// Print ownership if available
+	if s.Ownership != nil {
+		analysis.PrintOwnership(s.Ownership, w)
+	}
+
+	// Print dead files if searched
+	if s.DeadFiles != nil {
+		analysis.PrintDeadFiles(s.DeadFiles, w)
+	}
+
+	// Print documentation coverage if measured
+	if s.DocCoverage != nil {
+		analysis.PrintDocCoverage(s.DocCoverage, w)
+	}
+
+	// Print user-facing strings if extracted
+	if s.Strings != nil {
+		analysis.PrintUserStrings(s.Strings, w)
+	}
+
+	// Print the configuration inventory if taken
+	if s.ConfigInventory != nil {
+		analysis.PrintConfigInventory(s.ConfigInventory, w)
+	}
+
+	// Print the infrastructure-as-code resources if counted
+	if s.IaC != nil {
+		analysis.PrintIaC(s.IaC, w)
 	}
 
 	// Print language stats if available
 	if s.LanguageStats != nil {
-		analysis.PrintLanguageStats(s.LanguageStats)
+		analysis.PrintLanguageStats(s.LanguageStats, s.SizeUnits, w)
 	}
 
 	// Print Git status if available
 	if s.GitStatusSummary != nil {
-		fmt.Println("\nGit Status:")
-		fmt.Println("===========")
-		fmt.Printf("  Tracked files: %d/%d\n", s.GitStatusSummary.TrackedFiles, s.GitStatusSummary.TotalFiles)
-		fmt.Printf("  Modified files: %d\n", s.GitStatusSummary.ModifiedFiles)
-		fmt.Printf("  Untracked files: %d\n", s.GitStatusSummary.UntrackedFiles)
-		fmt.Printf("  Last commit: %s\n", s.GitStatusSummary.LastCommitTime)
+		fmt.Fprintln(w, "\nGit Status:")
+		fmt.Fprintln(w, "===========")
+		fmt.Fprintf(w, "  Tracked files: %d/%d\n", s.GitStatusSummary.TrackedFiles, s.GitStatusSummary.TotalFiles)
+		fmt.Fprintf(w, "  Modified files: %d\n", s.GitStatusSummary.ModifiedFiles)
+		fmt.Fprintf(w, "  Untracked files: %d\n", s.GitStatusSummary.UntrackedFiles)
+		fmt.Fprintf(w, "  Last commit: %s\n", s.GitStatusSummary.LastCommitTime)
 	}
 }
 
@@ -147,6 +221,9 @@ type AdvancedStatsOptions struct {
 	LanguageStats      bool
 	GitInfo            bool
 	GitStatus          bool
+	AuditDeps          *audit.Options   // Audit dependencies for known vulnerabilities in the health check (nil to skip)
+	Coverage           *coverage.Report // Flag the untested files of a coverage report in the health check (nil to skip)
+	DebugPatterns      []*regexp.Regexp // Debug statements flagged in the health check besides the built-in ones
 }
 
 // GetTopFileExtensions returns the top file extensions by count
@@ -1,15 +1,19 @@
 package stats
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
 
+	"codectx/internal/analysis"
+	"codectx/internal/language"
+	"codectx/internal/platform"
 	"codectx/internal/utils"
 )
 
@@ -21,7 +25,22 @@ type StatsCollector struct {
 	TextFiles        int
 	BinaryFiles      int
 	EstimatedTokens  int
+	EmbeddedAssets   int                       // Base64 data URIs and blobs stripped from the output
+	ReclaimedTokens  int                       // Estimated tokens saved by stripping them
+	PIIRedacted      utils.PIICounts           // Personal data replaced with placeholders
+	DuplicateFiles   int                       // Files replaced by a stub pointing at identical content
+	DedupeTokens     int                       // Estimated tokens saved by deduplication
+	LFSFiles         int                       // Files tracked with Git LFS
+	LFSSize          int64                     // Size of their objects, fetched or not
+	LFSNotFetched    int                       // Those described by their pointer
+	MIMETypes        map[string]int            // Number of files of each detected MIME type
+	Projects         []analysis.Project        // Projects the files are attributed to, from SetProjects
+	Stack            []analysis.StackComponent // Frameworks and tools detected in the scanned directory
 	StartTime        time.Time
+	Stat             platform.StatFunc // Source of file sizes (nil for os.Stat)
+	SizeUnits        utils.SizeUnits   // Units the sizes are printed in
+
+	rootDir string // Directory the project paths are relative to
 }
 
 // NewStatsCollector creates a new stats collector
@@ -34,7 +53,7 @@ func NewStatsCollector() *StatsCollector {
 // AddFile adds a file to the statistics
 func (s *StatsCollector) AddFile(path string, isText bool) error {
 	// Get file info
-	fileInfo, err := os.Stat(path)
+	fileInfo, err := platform.Stat(s.Stat, path)
 	if err != nil {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
@@ -42,24 +61,89 @@ func (s *StatsCollector) AddFile(path string, isText bool) error {
 	// Update statistics
 	s.TotalFiles++
 	s.TotalSize += fileInfo.Size()
+	if mime, err := utils.DetectMIME(path); err == nil {
+		if s.MIMETypes == nil {
+			s.MIMETypes = make(map[string]int)
+		}
+		mime, _, _ = strings.Cut(mime, ";")
+		s.MIMETypes[mime]++
+	}
 
+	tokens := 0
 	if isText {
 		s.TextFiles++
 		// More accurate token estimation based on file content
-		tokens, err := EstimateTokens(path)
-		if err == nil {
-			s.EstimatedTokens += tokens
-		} else {
+		tokens, err = EstimateTokens(path)
+		if err != nil {
 			// Fallback to rough estimate: 1 token per 4 bytes
-			s.EstimatedTokens += int(fileInfo.Size() / 4)
+			tokens = int(fileInfo.Size() / 4)
 		}
+		s.EstimatedTokens += tokens
 	} else {
 		s.BinaryFiles++
 	}
+	s.addToProject(path, fileInfo.Size(), tokens)
 
 	return nil
 }
 
+// SetProjects attributes the files added from now on to the projects detected
+// in rootDir. Each file counts toward the innermost project containing it.
+func (s *StatsCollector) SetProjects(rootDir string, projects []analysis.Project) {
+	s.rootDir = rootDir
+	s.Projects = projects
+}
+
+// addToProject adds a file to the totals of its project
+func (s *StatsCollector) addToProject(path string, size int64, tokens int) {
+	if len(s.Projects) == 0 {
+		return
+	}
+	relPath, err := platform.RelSlash(s.rootDir, path)
+	if err != nil {
+		return
+	}
+	project := analysis.ProjectFor(s.Projects, relPath)
+	if project == nil {
+		return
+	}
+	project.Files++
+	project.Size += size
+	project.Tokens += tokens
+	if lang, ok := language.DetectFile(path); ok {
+		if project.Languages == nil {
+			project.Languages = make(map[string]int)
+		}
+		project.Languages[lang.Name]++
+	}
+}
+
+// AddEmbeddedData records embedded assets stripped from the output
+func (s *StatsCollector) AddEmbeddedData(assets, reclaimedTokens int) {
+	s.EmbeddedAssets += assets
+	s.ReclaimedTokens += reclaimedTokens
+}
+
+// AddPIIRedacted records personal data replaced with placeholders
+func (s *StatsCollector) AddPIIRedacted(counts utils.PIICounts) {
+	s.PIIRedacted.Add(counts)
+}
+
+// AddDuplicates records files replaced by a stub because their content was already included
+func (s *StatsCollector) AddDuplicates(files, savedTokens int) {
+	s.DuplicateFiles += files
+	s.DedupeTokens += savedTokens
+}
+
+// AddLFSObject records a file tracked with Git LFS, whose object is size bytes
+func (s *StatsCollector) AddLFSObject(size int64, fetched bool) {
+	s.LFSFiles++
+	s.LFSSize += size
+	if !fetched {
+		s.LFSNotFetched++
+	}
+}
+
 // AddDirectory adds a directory to the statistics
 func (s *StatsCollector) AddDirectory(path string) {
 	s.TotalDirectories++
@@ -71,15 +155,56 @@ func (s *StatsCollector) GetProcessingTime() float64 {
 }
 
 // PrintStats prints the statistics
-func (s *StatsCollector) PrintStats() {
-	fmt.Println("\nStatistics:")
-	fmt.Printf("  Total files: %d\n", s.TotalFiles)
-	fmt.Printf("  Total directories: %d\n", s.TotalDirectories)
-	fmt.Printf("  Total size: %.1fMB\n", float64(s.TotalSize)/(1024*1024))
-	fmt.Printf("  Text files: %d\n", s.TextFiles)
-	fmt.Printf("  Binary files: %d\n", s.BinaryFiles)
-	fmt.Printf("  Estimated tokens: ~%d\n", s.EstimatedTokens)
-	fmt.Printf("  Processing time: %.3fs\n", s.GetProcessingTime())
+func (s *StatsCollector) PrintStats(w io.Writer) {
+	fmt.Fprintln(w, "\nStatistics:")
+	fmt.Fprintf(w, "  Total files: %s\n", utils.FormatCount(s.TotalFiles))
+	fmt.Fprintf(w, "  Total directories: %s\n", utils.FormatCount(s.TotalDirectories))
+	fmt.Fprintf(w, "  Total size: %s\n", utils.FormatSize(s.TotalSize, s.SizeUnits))
+	fmt.Fprintf(w, "  Text files: %s\n", utils.FormatCount(s.TextFiles))
+	fmt.Fprintf(w, "  Binary files: %s\n", utils.FormatCount(s.BinaryFiles))
+	fmt.Fprintf(w, "  Estimated tokens: ~%s\n", utils.FormatCount(s.EstimatedTokens))
+	if len(s.MIMETypes) > 0 {
+		fmt.Fprintf(w, "  MIME types: %s\n", s.formatMIMETypes())
+	}
+	if s.EmbeddedAssets > 0 {
+		fmt.Fprintf(w, "  Embedded data stripped: %s assets (~%s tokens reclaimed)\n", utils.FormatCount(s.EmbeddedAssets), utils.FormatCount(s.ReclaimedTokens))
+	}
+	if s.PIIRedacted.Total() > 0 {
+		fmt.Fprintf(w, "  PII redacted: %s\n", s.PIIRedacted)
+	}
+	if s.DuplicateFiles > 0 {
+		fmt.Fprintf(w, "  Duplicates replaced: %s files (~%s tokens saved)\n", utils.FormatCount(s.DuplicateFiles), utils.FormatCount(s.DedupeTokens))
+	}
+	if s.LFSFiles > 0 {
+		fmt.Fprintf(w, "  Git LFS objects: %s files (%s), %s not fetched\n", utils.FormatCount(s.LFSFiles), utils.FormatSize(s.LFSSize, s.SizeUnits), utils.FormatCount(s.LFSNotFetched))
+	}
+	fmt.Fprintf(w, "  Processing time: %.3fs\n", s.GetProcessingTime())
+	if len(s.Projects) > 1 {
+		analysis.PrintProjects(s.Projects, s.SizeUnits, w)
+	}
+	if len(s.Stack) > 0 {
+		analysis.PrintStack(s.Stack, w)
+	}
+}
+
+// formatMIMETypes lists the MIME types with their file counts, most common first
+func (s *StatsCollector) formatMIMETypes() string {
+	types := make([]string, 0, len(s.MIMETypes))
+	for mime := range s.MIMETypes {
+		types = append(types, mime)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if s.MIMETypes[types[i]] != s.MIMETypes[types[j]] {
+			return s.MIMETypes[types[i]] > s.MIMETypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	parts := make([]string, len(types))
+	for i, mime := range types {
+		parts[i] = fmt.Sprintf("%s (%d)", mime, s.MIMETypes[mime])
+	}
+	return strings.Join(parts, ", ")
 }
 
 // CollectStats collects statistics for a directory
@@ -114,6 +239,8 @@ func CollectStats(rootDir string) (*StatsCollector, error) {
 	return stats, nil
 }
 
+// Tokenizer names the token estimate of EstimateTokens, reported in JSON metadata
+const Tokenizer = "language-heuristic"
 
 // EstimateTokens estimates the number of tokens in a text file
 func EstimateTokens(path string) (int, error) {
@@ -123,15 +250,15 @@ func EstimateTokens(path string) (int, error) {
 	}
 	defer file.Close()
 
-	// Get file extension for language-specific tokenization
-	ext := strings.ToLower(filepath.Ext(path))
+	// Get the language for language-specific tokenization
+	lang, _ := language.DetectFile(path)
 
 	var totalTokens int
-	scanner := bufio.NewScanner(file)
+	scanner := utils.NewLineReader(file, 0)
 
 	// Language-specific token estimation
-	switch ext {
-	case ".go", ".java", ".c", ".cpp", ".cc", ".cxx", ".h", ".hpp":
+	switch lang.Class {
+	case language.ClassCode:
 		// Code files: more tokens per word due to symbols
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
@@ -140,7 +267,7 @@ func EstimateTokens(path string) (int, error) {
 			}
 			totalTokens += estimateCodeLineTokens(line)
 		}
-	case ".js", ".ts", ".py", ".rb", ".php", ".cs", ".kt", ".swift", ".rs":
+	case language.ClassScript:
 		// Script/interpreted languages
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
@@ -149,7 +276,7 @@ func EstimateTokens(path string) (int, error) {
 			}
 			totalTokens += estimateCodeLineTokens(line)
 		}
-	case ".json", ".xml", ".yaml", ".yml", ".toml":
+	case language.ClassData:
 		// Structured data: fewer tokens per character
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
@@ -158,7 +285,7 @@ func EstimateTokens(path string) (int, error) {
 			}
 			totalTokens += estimateDataLineTokens(line)
 		}
-	case ".md", ".txt", ".rst":
+	case language.ClassText:
 		// Text files: natural language tokenization
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"codectx/internal/benchtree"
 	"codectx/internal/utils"
 )
 
@@ -59,6 +60,17 @@ func TestStatsCollector_AddDirectory(t *testing.T) {
 	}
 }
 
+func TestStatsCollector_AddEmbeddedData(t *testing.T) {
+	collector := NewStatsCollector()
+
+	collector.AddEmbeddedData(2, 300)
+	collector.AddEmbeddedData(1, 50)
+
+	if collector.EmbeddedAssets != 3 || collector.ReclaimedTokens != 350 {
+		t.Errorf("Expected 3 assets and 350 reclaimed tokens, got %d and %d", collector.EmbeddedAssets, collector.ReclaimedTokens)
+	}
+}
+
 func TestStatsCollector_AddFile(t *testing.T) {
 	// Create temporary files for testing
 	tempDir, err := os.MkdirTemp("", "stats_test")
@@ -135,6 +147,29 @@ func TestStatsCollector_GetProcessingTime(t *testing.T) {
 	}
 }
 
+func TestStatsCollector_PrintStats(t *testing.T) {
+	collector := NewStatsCollector()
+	collector.TotalFiles = 12345
+	collector.TotalSize = 1536000
+	collector.EstimatedTokens = 2500000
+
+	var b strings.Builder
+	collector.PrintStats(&b)
+	for _, expected := range []string{"Total files: 12,345", "Total size: 1.5MB", "Estimated tokens: ~2,500,000"} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, b.String())
+		}
+	}
+
+	collector.SizeUnits = utils.SIUnits
+	collector.TotalSize = 1500
+	b.Reset()
+	collector.PrintStats(&b)
+	if !strings.Contains(b.String(), "Total size: 1.5kB") {
+		t.Errorf("Expected SI total size in:\n%s", b.String())
+	}
+}
+
 func TestIsTextFile(t *testing.T) {
 	// Create temporary directory
 	tempDir, err := os.MkdirTemp("", "text_test")
@@ -588,4 +623,36 @@ func TestCollectStats(t *testing.T) {
 	if stats.GetProcessingTime() <= 0 {
 		t.Error("Expected processing time to be positive")
 	}
-}
\ No newline at end of file
+}
+
+// BenchmarkEstimateTokens estimates the tokens of the text files of a
+// synthetic tree, reporting the throughput in bytes of source
+func BenchmarkEstimateTokens(b *testing.B) {
+	dir := b.TempDir()
+	paths, err := benchtree.Generate(dir, benchtree.Spec{Files: 200, Lines: 200})
+	if err != nil {
+		b.Fatalf("Failed to generate tree: %v", err)
+	}
+	var textPaths []string
+	var size int64
+	for _, path := range paths {
+		if isText, err := utils.IsTextFile(path); err == nil && isText {
+			info, err := os.Stat(path)
+			if err != nil {
+				b.Fatalf("Failed to stat file: %v", err)
+			}
+			textPaths = append(textPaths, path)
+			size += info.Size()
+		}
+	}
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range textPaths {
+			if _, err := EstimateTokens(path); err != nil {
+				b.Fatalf("EstimateTokens failed: %v", err)
+			}
+		}
+	}
+}
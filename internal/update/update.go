@@ -0,0 +1,231 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReleaseURL is the GitHub API endpoint of the latest codectx release
+const DefaultReleaseURL = "https://api.github.com/repos/takeisa/codectx/releases/latest"
+
+// Names of the release assets that accompany the binaries
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// maxDownloadSize bounds the size of a downloaded asset
+const maxDownloadSize = 256 * 1024 * 1024
+
+// httpClient is used for all release requests
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Release is a published release and its downloadable assets
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// LatestRelease fetches the latest release from a GitHub releases API URL
+func LatestRelease(url string) (*Release, error) {
+	data, err := Download(url)
+	if err != nil {
+		return nil, err
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	if release.Tag == "" {
+		return nil, errors.New("release has no tag")
+	}
+	return &release, nil
+}
+
+// Asset returns the release asset with the given name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// BinaryAssetName returns the name of the release binary for the running platform,
+// e.g. "codectx-linux-amd64" or "codectx-windows-amd64.exe"
+func BinaryAssetName() string {
+	name := fmt.Sprintf("codectx-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Download fetches a URL, failing on non-200 responses
+func Download(url string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", "codectx-self-update")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxDownloadSize)
+	}
+	return data, nil
+}
+
+// IsNewer reports whether version latest is newer than current. Versions are
+// compared numerically by their dot-separated parts, ignoring a leading "v" and
+// any pre-release suffix; a current version that cannot be parsed is always older.
+func IsNewer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := 0; i < len(l) || i < len(c); i++ {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+// parseVersion splits a version such as "v1.2.3-rc1" into its numeric parts
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil, false
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// VerifyChecksum checks data against its SHA-256 entry in a checksums file with
+// lines of the form "<hex digest>  <file name>"
+func VerifyChecksum(data, checksums []byte, name string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// VerifySignature checks an Ed25519 signature (raw or base64) of data against a
+// base64-encoded public key
+func VerifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid update public key")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// ReplaceExecutable atomically replaces the executable at path with data. The new
+// file is written next to the old one and renamed over it; on Windows, where a
+// running executable cannot be overwritten, the old file is moved aside first.
+func ReplaceExecutable(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".codectx-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempPath, info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		oldPath := path + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(path, oldPath); err != nil {
+			return fmt.Errorf("failed to move the old binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
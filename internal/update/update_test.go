@@ -0,0 +1,140 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		latest   string
+		current  string
+		expected bool
+	}{
+		{"v0.2.0", "v0.1.0", true},
+		{"v0.1.0", "v0.1.0", false},
+		{"v0.1.0", "v0.2.0", false},
+		{"v1.10.0", "v1.9.3", true},
+		{"v1.2", "v1.2.0", false},
+		{"1.2.1", "v1.2.0", true},
+		{"v1.3.0-rc1", "v1.2.0", true},
+		{"v1.0.0", "dev", true},
+		{"nightly", "v1.0.0", false},
+	}
+
+	for _, test := range tests {
+		if result := IsNewer(test.latest, test.current); result != test.expected {
+			t.Errorf("IsNewer(%q, %q): expected %v, got %v", test.latest, test.current, test.expected, result)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("new binary")
+	sum := sha256.Sum256(data)
+	checksums := []byte(fmt.Sprintf("%s  codectx-linux-amd64\n0000  codectx-darwin-arm64\n", hex.EncodeToString(sum[:])))
+
+	if err := VerifyChecksum(data, checksums, "codectx-linux-amd64"); err != nil {
+		t.Errorf("Expected checksum to match, got %v", err)
+	}
+	if err := VerifyChecksum(data, checksums, "codectx-darwin-arm64"); err == nil {
+		t.Errorf("Expected checksum mismatch, got nil")
+	}
+	if err := VerifyChecksum(data, checksums, "codectx-windows-amd64.exe"); err == nil {
+		t.Errorf("Expected missing checksum error, got nil")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(publicKey)
+	data := []byte("checksums")
+	signature := ed25519.Sign(privateKey, data)
+
+	if err := VerifySignature(data, signature, encodedKey); err != nil {
+		t.Errorf("Expected raw signature to verify, got %v", err)
+	}
+	if err := VerifySignature(data, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), encodedKey); err != nil {
+		t.Errorf("Expected base64 signature to verify, got %v", err)
+	}
+	if err := VerifySignature([]byte("tampered"), signature, encodedKey); err == nil {
+		t.Errorf("Expected tampered data to fail verification")
+	}
+	if err := VerifySignature(data, signature, "not a key"); err == nil {
+		t.Errorf("Expected invalid key error, got nil")
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			fmt.Fprint(w, `{"tag_name": "v1.2.3", "assets": [{"name": "checksums.txt", "browser_download_url": "https://example.com/checksums.txt"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release, err := LatestRelease(server.URL + "/latest")
+	if err != nil {
+		t.Fatalf("LatestRelease failed: %v", err)
+	}
+	if release.Tag != "v1.2.3" {
+		t.Errorf("Expected tag v1.2.3, got %s", release.Tag)
+	}
+	asset, ok := release.Asset(ChecksumsAsset)
+	if !ok || asset.URL != "https://example.com/checksums.txt" {
+		t.Errorf("Expected checksums asset, got %+v", asset)
+	}
+	if _, ok := release.Asset(BinaryAssetName()); ok {
+		t.Errorf("Expected no binary asset")
+	}
+
+	if _, err := LatestRelease(server.URL + "/missing"); err == nil {
+		t.Errorf("Expected error for a missing release, got nil")
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "update-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "codectx")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := ReplaceExecutable(path, []byte("new")); err != nil {
+		t.Fatalf("ReplaceExecutable failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "new" {
+		t.Errorf("Expected new content, got %q", content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected executable permissions, got %v", info.Mode())
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Anonymizer renames identifying strings, such as company names, internal
+// hostnames, and product codenames, consistently across the output
+type Anonymizer struct {
+	pattern      *regexp.Regexp
+	replacements map[string]string // Replacement of each lower-cased original
+}
+
+// LoadAnonymizer reads an --anonymize mapping file
+func LoadAnonymizer(path string) (*Anonymizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	anonymizer, err := ParseAnonymizer(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %w", path, err)
+	}
+	return anonymizer, nil
+}
+
+// ParseAnonymizer parses a mapping with one "original = replacement" pair per
+// line. Blank lines and lines starting with # are ignored. Originals match
+// regardless of case, and longer originals take precedence over the shorter
+// ones they contain.
+func ParseAnonymizer(data string) (*Anonymizer, error) {
+	anonymizer := &Anonymizer{replacements: make(map[string]string)}
+	var originals []string
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		original, replacement, ok := strings.Cut(line, "=")
+		original, replacement = strings.TrimSpace(original), strings.TrimSpace(replacement)
+		if !ok || original == "" || replacement == "" {
+			return nil, fmt.Errorf("line %d: expected original = replacement", i+1)
+		}
+		key := strings.ToLower(original)
+		if _, ok := anonymizer.replacements[key]; ok {
+			return nil, fmt.Errorf("line %d: %q is mapped twice", i+1, original)
+		}
+		anonymizer.replacements[key] = replacement
+		originals = append(originals, regexp.QuoteMeta(original))
+	}
+	if len(originals) == 0 {
+		return nil, fmt.Errorf("no mappings")
+	}
+
+	sort.SliceStable(originals, func(i, j int) bool {
+		return len(originals[i]) > len(originals[j])
+	})
+	anonymizer.pattern = regexp.MustCompile("(?i)" + strings.Join(originals, "|"))
+	return anonymizer, nil
+}
+
+// Replace renames the originals in s, following the case of each match: with
+// the mapping "acme = example", ACME, Acme, and acme become EXAMPLE, Example,
+// and example
+func (a *Anonymizer) Replace(s string) string {
+	return a.pattern.ReplaceAllStringFunc(s, func(match string) string {
+		replacement := a.replacements[strings.ToLower(match)]
+		switch {
+		case !hasLetter(match, unicode.IsLetter):
+		case !hasLetter(match, unicode.IsLower):
+			return strings.ToUpper(replacement)
+		case !hasLetter(match, unicode.IsUpper):
+			return strings.ToLower(replacement)
+		case unicode.IsUpper([]rune(match)[0]):
+			runes := []rune(replacement)
+			runes[0] = unicode.ToUpper(runes[0])
+			return string(runes)
+		}
+		return replacement
+	})
+}
+
+// hasLetter reports whether s has a letter for which is reports true
+func hasLetter(s string, is func(rune) bool) bool {
+	return strings.IndexFunc(s, is) >= 0
+}
+
+// AnonymizeWriter renames the originals of an Anonymizer in everything
+// written to it. Output is passed on a line at a time, so that an original is
+// never split across writes; Flush writes an unterminated last line.
+type AnonymizeWriter struct {
+	w          io.Writer
+	anonymizer *Anonymizer
+	pending    []byte
+}
+
+// NewWriter creates a writer anonymizing the output written to w
+func (a *Anonymizer) NewWriter(w io.Writer) *AnonymizeWriter {
+	return &AnonymizeWriter{w: w, anonymizer: a}
+}
+
+// Write anonymizes the complete lines written so far and writes them
+func (a *AnonymizeWriter) Write(p []byte) (int, error) {
+	a.pending = append(a.pending, p...)
+	end := bytes.LastIndexByte(a.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	if _, err := io.WriteString(a.w, a.anonymizer.Replace(string(a.pending[:end+1]))); err != nil {
+		return 0, err
+	}
+	a.pending = append(a.pending[:0], a.pending[end+1:]...)
+	return len(p), nil
+}
+
+// Flush anonymizes and writes the unterminated last line, if any
+func (a *AnonymizeWriter) Flush() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(a.w, a.anonymizer.Replace(string(a.pending)))
+	a.pending = a.pending[:0]
+	return err
+}
+
+// Close flushes the writer and closes the underlying writer if it's closable
+func (a *AnonymizeWriter) Close() error {
+	if err := a.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := a.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
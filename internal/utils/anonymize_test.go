@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnonymizer_Replace(t *testing.T) {
+	anonymizer, err := ParseAnonymizer(`# Internal names
+Acme = Example
+db.corp.acme.net = db.example.com
+Roadrunner = ProjectX
+10.1.2.3 = 192.0.2.1
+`)
+	if err != nil {
+		t.Fatalf("ParseAnonymizer failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"package acme", "package example"},
+		{"const ACME_HOST = \"db.corp.acme.net\"", "const EXAMPLE_HOST = \"db.example.com\""},
+		{"type AcmeClient struct{} // Roadrunner release", "type ExampleClient struct{} // ProjectX release"},
+		{"ssh 10.1.2.3", "ssh 192.0.2.1"},
+		{"nothing to rename", "nothing to rename"},
+	}
+	for _, test := range tests {
+		if got := anonymizer.Replace(test.input); got != test.expected {
+			t.Errorf("Replace(%q): expected %q, got %q", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestParseAnonymizer_Errors(t *testing.T) {
+	for _, data := range []string{"", "# only comments\n", "acme\n", "acme =\n", "acme = a\nACME = b\n"} {
+		if _, err := ParseAnonymizer(data); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
+
+func TestAnonymizeWriter(t *testing.T) {
+	anonymizer, err := ParseAnonymizer("acme = example\n")
+	if err != nil {
+		t.Fatalf("ParseAnonymizer failed: %v", err)
+	}
+
+	// A name split across writes is still renamed
+	var buf bytes.Buffer
+	w := anonymizer.NewWriter(&buf)
+	for _, part := range []string{"package ac", "me\n// Ac", "me end"} {
+		if _, err := w.Write([]byte(part)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if got, expected := buf.String(), "package example\n"; got != expected {
+		t.Errorf("Expected %q before Flush, got %q", expected, got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got, expected := buf.String(), "package example\n// Example end"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MinEmbeddedDataLength is the shortest base64 payload treated as an embedded asset
+const MinEmbeddedDataLength = 256
+
+var (
+	// dataURIPattern matches base64 data URIs such as data:image/png;base64,iVBOR...
+	dataURIPattern = regexp.MustCompile(`(data:[\w.+-]*/?[\w.+-]*(?:;[\w.+-]+=[\w.+-]+)*;base64,)([A-Za-z0-9+/]+={0,2})`)
+
+	// base64RunPattern matches bare base64 runs, such as image outputs in notebook JSON
+	base64RunPattern = regexp.MustCompile(`[A-Za-z0-9+/]{256,}={0,2}`)
+)
+
+// StripEmbeddedData replaces base64 data URIs and long base64 blobs in a line with a
+// short placeholder. It returns the new line and the number of assets replaced.
+func StripEmbeddedData(line string) (string, int) {
+	// Most lines are short, and data URIs and blobs are long
+	if len(line) < MinEmbeddedDataLength {
+		return line, 0
+	}
+
+	assets := 0
+	line = dataURIPattern.ReplaceAllStringFunc(line, func(match string) string {
+		parts := dataURIPattern.FindStringSubmatch(match)
+		if len(parts[2]) < MinEmbeddedDataLength {
+			return match
+		}
+		assets++
+		return parts[1] + embeddedPlaceholder(len(parts[2]))
+	})
+	line = base64RunPattern.ReplaceAllStringFunc(line, func(match string) string {
+		if !looksLikeBase64(match) {
+			return match
+		}
+		assets++
+		return embeddedPlaceholder(len(match))
+	})
+	return line, assets
+}
+
+// looksLikeBase64 reports whether a run mixes upper case letters, lower case letters,
+// and digits, unlike long identifiers or hex digests
+func looksLikeBase64(s string) bool {
+	return strings.ContainsAny(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+		strings.ContainsAny(s, "abcdefghijklmnopqrstuvwxyz") &&
+		strings.ContainsAny(s, "0123456789")
+}
+
+// embeddedPlaceholder describes an omitted base64 payload of the given length
+func embeddedPlaceholder(encodedLen int) string {
+	return fmt.Sprintf("[embedded data omitted: %d bytes]", encodedLen*3/4)
+}
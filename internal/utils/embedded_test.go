@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripEmbeddedData(t *testing.T) {
+	payload := strings.Repeat("iVBORw0KGgoAAAANSUhEUgAA", 20) // 480 characters
+	hexDigest := strings.Repeat("0123456789abcdef", 20)
+
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+		assets   int
+	}{
+		{
+			name:     "Markdown image",
+			line:     "![logo](data:image/png;base64," + payload + ")",
+			expected: "![logo](data:image/png;base64,[embedded data omitted: 360 bytes])",
+			assets:   1,
+		},
+		{
+			name:     "HTML attribute with parameters",
+			line:     `<img src="data:image/svg+xml;charset=utf-8;base64,` + payload + `==">`,
+			expected: `<img src="data:image/svg+xml;charset=utf-8;base64,[embedded data omitted: 361 bytes]">`,
+			assets:   1,
+		},
+		{
+			name:     "Notebook output",
+			line:     `      "image/png": "` + payload + `\n",`,
+			expected: `      "image/png": "[embedded data omitted: 360 bytes]\n",`,
+			assets:   1,
+		},
+		{
+			name:     "Two data URIs",
+			line:     "a data:,x data:image/gif;base64," + payload + " b data:font/woff2;base64," + payload,
+			expected: "a data:,x data:image/gif;base64,[embedded data omitted: 360 bytes] b data:font/woff2;base64,[embedded data omitted: 360 bytes]",
+			assets:   2,
+		},
+		{
+			name:     "Short data URI",
+			line:     strings.Repeat(" ", 300) + "data:image/gif;base64,R0lGODlhAQABAAAAACw=",
+			expected: strings.Repeat(" ", 300) + "data:image/gif;base64,R0lGODlhAQABAAAAACw=",
+		},
+		{
+			name:     "Hex digest",
+			line:     "sum = " + hexDigest,
+			expected: "sum = " + hexDigest,
+		},
+		{
+			name:     "Short line",
+			line:     "x := 1",
+			expected: "x := 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, assets := StripEmbeddedData(tt.line)
+			if line != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, line)
+			}
+			if assets != tt.assets {
+				t.Errorf("Expected %d assets, got %d", tt.assets, assets)
+			}
+		})
+	}
+}
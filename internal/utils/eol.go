@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Line ending styles accepted by --normalize-eol
+const (
+	EOLLF   = "lf"
+	EOLCRLF = "crlf"
+)
+
+// ParseEOL validates a line ending style
+func ParseEOL(eol string) (string, error) {
+	switch strings.ToLower(eol) {
+	case EOLLF, EOLCRLF:
+		return strings.ToLower(eol), nil
+	default:
+		return "", fmt.Errorf("unknown line ending %q (expected lf or crlf)", eol)
+	}
+}
+
+// LineEnding returns the line terminator of a line ending style
+func LineEnding(eol string) string {
+	if eol == EOLCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// CRLFWriter writes everything written to it to the underlying writer with
+// "\n" line endings converted to "\r\n". Existing "\r\n" endings are kept.
+type CRLFWriter struct {
+	w      io.Writer
+	lastCR bool
+}
+
+// NewCRLFWriter creates a writer converting line endings to CRLF
+func NewCRLFWriter(w io.Writer) *CRLFWriter {
+	return &CRLFWriter{w: w}
+}
+
+// Write converts the line endings in p and writes the result
+func (c *CRLFWriter) Write(p []byte) (int, error) {
+	converted := make([]byte, 0, len(p)+len(p)/32)
+	for _, b := range p {
+		if b == '\n' && !c.lastCR {
+			converted = append(converted, '\r')
+		}
+		converted = append(converted, b)
+		c.lastCR = b == '\r'
+	}
+	if _, err := c.w.Write(converted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the underlying writer if it's closable
+func (c *CRLFWriter) Close() error {
+	if closer, ok := c.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRLFWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{"LF endings", []string{"a\nb\n"}, "a\r\nb\r\n"},
+		{"CRLF endings are kept", []string{"a\r\nb\n"}, "a\r\nb\r\n"},
+		{"CRLF split across writes", []string{"a\r", "\nb"}, "a\r\nb"},
+		{"No endings", []string{"abc"}, "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewCRLFWriter(&buf)
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				if err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+				if n != len(s) {
+					t.Errorf("Expected %d bytes written, got %d", len(s), n)
+				}
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestParseEOL(t *testing.T) {
+	if eol, err := ParseEOL("CRLF"); err != nil || eol != EOLCRLF {
+		t.Errorf("Expected %q, got %q (%v)", EOLCRLF, eol, err)
+	}
+	if _, err := ParseEOL("cr"); err == nil {
+		t.Error("Expected an error for an unknown line ending")
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern.
+// In addition to path.Match syntax, a "**" segment matches zero or more
+// directories, so "docs/**" matches everything under docs and "**/*.go"
+// matches Go files at any depth. A pattern ending in "/" matches a directory
+// prefix. Leading slashes on both pattern and path are ignored.
+func MatchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	name = strings.TrimPrefix(name, "/")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches pattern segments against path segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive "**" segments
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
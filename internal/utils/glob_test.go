@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "*.go", name: "main.go", expected: true},
+		{pattern: "*.go", name: "cmd/root.go", expected: false},
+		{pattern: "docs/**", name: "docs/guide/intro.md", expected: true},
+		{pattern: "docs/**", name: "docs", expected: true},
+		{pattern: "docs/**", name: "src/docs/intro.md", expected: false},
+		{pattern: "**/*.go", name: "main.go", expected: true},
+		{pattern: "**/*.go", name: "internal/scanner/scanner.go", expected: true},
+		{pattern: "**/testdata/**", name: "pkg/testdata/input.txt", expected: true},
+		{pattern: "tests/", name: "tests/unit/a_test.py", expected: true},
+		{pattern: "/vendor/**", name: "/vendor/lib/lib.go", expected: true},
+		{pattern: "src/*/main.go", name: "src/app/main.go", expected: true},
+		{pattern: "src/*/main.go", name: "src/app/cmd/main.go", expected: false},
+		{pattern: "[", name: "[", expected: false},
+	}
+
+	for _, tt := range tests {
+		if result := MatchGlob(tt.pattern, tt.name); result != tt.expected {
+			t.Errorf("MatchGlob(%q, %q): expected %v, got %v", tt.pattern, tt.name, tt.expected, result)
+		}
+	}
+}
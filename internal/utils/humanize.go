@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SizeUnits selects how byte counts are shown to people
+type SizeUnits int
+
+const (
+	// BinaryUnits shows sizes in powers of 1024 (KB, MB, GB, TB), the units
+	// ParseSize reads
+	BinaryUnits SizeUnits = iota
+	// SIUnits shows sizes in powers of 1000 (kB, MB, GB, TB)
+	SIUnits
+)
+
+// FormatSize renders a byte count in the largest unit it reaches, e.g.
+// "1.5MB". Bytes are shown as a whole number, e.g. "512B".
+func FormatSize(size int64, units SizeUnits) string {
+	base, labels := int64(1024), []string{"KB", "MB", "GB", "TB"}
+	if units == SIUnits {
+		base, labels = 1000, []string{"kB", "MB", "GB", "TB"}
+	}
+
+	if size < base && size > -base {
+		return strconv.FormatInt(size, 10) + "B"
+	}
+	value := float64(size) / float64(base)
+	unit := 0
+	for unit < len(labels)-1 && (value >= float64(base) || value <= -float64(base)) {
+		value /= float64(base)
+		unit++
+	}
+	return fmt.Sprintf("%.1f%s", value, labels[unit])
+}
+
+// FormatCount renders an integer with commas between groups of three digits,
+// e.g. "1,234,567", whatever the locale, so that output stays reproducible
+func FormatCount[T ~int | ~int64](n T) string {
+	digits := strconv.FormatInt(int64(n), 10)
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+
+	out := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range len(digits) {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, digits[i])
+	}
+	return sign + string(out)
+}
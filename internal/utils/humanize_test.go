@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     int64
+		units    SizeUnits
+		expected string
+	}{
+		{0, BinaryUnits, "0B"},
+		{1023, BinaryUnits, "1023B"},
+		{1536, BinaryUnits, "1.5KB"},
+		{5 * 1024 * 1024, BinaryUnits, "5.0MB"},
+		{3 << 30, BinaryUnits, "3.0GB"},
+		{2 << 40, BinaryUnits, "2.0TB"},
+		{999, SIUnits, "999B"},
+		{1500, SIUnits, "1.5kB"},
+		{1024 * 1024, SIUnits, "1.0MB"},
+		{2500000000, SIUnits, "2.5GB"},
+	}
+
+	for _, tt := range tests {
+		if formatted := FormatSize(tt.size, tt.units); formatted != tt.expected {
+			t.Errorf("FormatSize(%d, %v): expected %q, got %q", tt.size, tt.units, tt.expected, formatted)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{123456, "123,456"},
+		{1234567, "1,234,567"},
+		{-1234, "-1,234"},
+	}
+
+	for _, tt := range tests {
+		if formatted := FormatCount(tt.n); formatted != tt.expected {
+			t.Errorf("FormatCount(%d): expected %q, got %q", tt.n, tt.expected, formatted)
+		}
+	}
+}
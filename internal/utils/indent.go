@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"io"
+	"strings"
+)
+
+// Indentation styles reported by DetectIndent
+const (
+	IndentTabs   = "tabs"
+	IndentSpaces = "spaces"
+	IndentNone   = "none"
+)
+
+// DefaultIndentWidth is the number of spaces per level assumed when a file
+// has no space-indented lines to measure
+const DefaultIndentWidth = 4
+
+// IndentStyle describes how a file indents its lines
+type IndentStyle struct {
+	Style string `json:"style"`           // IndentTabs, IndentSpaces, or IndentNone
+	Width int    `json:"width,omitempty"` // Spaces per level, measured from space-indented lines
+	Mixed bool   `json:"mixed,omitempty"` // Some lines are indented the other way
+}
+
+// DetectIndent reads r and returns the indentation style used by most of its
+// indented lines. The width is the most common increase in indentation between
+// consecutive space-indented lines.
+func DetectIndent(r io.Reader) (IndentStyle, error) {
+	reader := NewLineReader(r, 0)
+	tabLines, spaceLines := 0, 0
+	steps := make(map[int]int)
+	previous := 0
+	for reader.Scan() {
+		line := reader.Text()
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		switch line[0] {
+		case '\t':
+			tabLines++
+		case ' ':
+			// Continuation lines of block comments are aligned, not indented
+			if strings.HasPrefix(trimmed, "*") {
+				continue
+			}
+			spaceLines++
+			spaces := len(line) - len(strings.TrimLeft(line, " "))
+			if spaces > previous {
+				steps[spaces-previous]++
+			}
+			previous = spaces
+			continue
+		}
+		previous = 0
+	}
+	if err := reader.Err(); err != nil {
+		return IndentStyle{}, err
+	}
+
+	style := IndentStyle{Style: IndentNone}
+	switch {
+	case tabLines == 0 && spaceLines == 0:
+		return style, nil
+	case tabLines > spaceLines:
+		style.Style = IndentTabs
+	default:
+		style.Style = IndentSpaces
+	}
+	style.Mixed = tabLines > 0 && spaceLines > 0
+	if spaceLines > 0 {
+		style.Width = DefaultIndentWidth
+		for step, count := range steps {
+			if count > steps[style.Width] || (count == steps[style.Width] && step < style.Width) {
+				style.Width = step
+			}
+		}
+	}
+	return style, nil
+}
+
+// NormalizeIndent rewrites the leading whitespace of line in the given style,
+// counting a tab as style.Width columns. Lines of files that aren't mixed are
+// returned as is.
+func NormalizeIndent(line string, style IndentStyle) string {
+	if !style.Mixed || style.Width <= 0 {
+		return line
+	}
+	body := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(body)]
+
+	columns := 0
+	for _, c := range indent {
+		if c == '\t' {
+			columns += style.Width - columns%style.Width
+		} else {
+			columns++
+		}
+	}
+	if style.Style == IndentTabs {
+		return strings.Repeat("\t", columns/style.Width) + strings.Repeat(" ", columns%style.Width) + body
+	}
+	return strings.Repeat(" ", columns) + body
+}
+
+// ExpandTabs replaces the tabs in line with spaces up to the next tab stop,
+// with tab stops every width columns
+func ExpandTabs(line string, width int) string {
+	if width <= 0 || !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for _, c := range line {
+		if c == '\t' {
+			spaces := width - column%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(c)
+		column++
+	}
+	return b.String()
+}
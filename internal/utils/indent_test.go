@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected IndentStyle
+	}{
+		{"Tabs", "func a() {\n\tb()\n\t\tc()\n}\n", IndentStyle{Style: IndentTabs}},
+		{"Two spaces", "a:\n  b:\n    c: 1\n  d: 2\n", IndentStyle{Style: IndentSpaces, Width: 2}},
+		{"Four spaces", "def a():\n    if b:\n        c()\n", IndentStyle{Style: IndentSpaces, Width: 4}},
+		{"Mixed, mostly tabs", "{\n\ta\n\tb\n    c\n}\n", IndentStyle{Style: IndentTabs, Width: 4, Mixed: true}},
+		{"Block comment", "\t/*\n\t * a\n\t */\n/*\n * b\n */\n", IndentStyle{Style: IndentTabs}},
+		{"No indentation", "a\nb\n", IndentStyle{Style: IndentNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, err := DetectIndent(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if style != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, style)
+			}
+		})
+	}
+}
+
+func TestNormalizeIndent(t *testing.T) {
+	tabs := IndentStyle{Style: IndentTabs, Width: 4, Mixed: true}
+	spaces := IndentStyle{Style: IndentSpaces, Width: 2, Mixed: true}
+
+	tests := []struct {
+		line     string
+		style    IndentStyle
+		expected string
+	}{
+		{"        a", tabs, "\t\ta"},
+		{"      a", tabs, "\t  a"},
+		{"\ta", tabs, "\ta"},
+		{"\t\ta  b", spaces, "    a  b"},
+		{" \ta", spaces, "  a"},
+		{"\ta", IndentStyle{Style: IndentSpaces, Width: 2}, "\ta"}, // Not mixed
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeIndent(tt.line, tt.style); got != tt.expected {
+			t.Errorf("NormalizeIndent(%q): expected %q, got %q", tt.line, tt.expected, got)
+		}
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		line     string
+		width    int
+		expected string
+	}{
+		{"\ta", 4, "    a"},
+		{"ab\tc", 4, "ab  c"},
+		{"abcd\te", 4, "abcd    e"},
+		{"é\tx", 2, "é x"},
+		{"\ta", 0, "\ta"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandTabs(tt.line, tt.width); got != tt.expected {
+			t.Errorf("ExpandTabs(%q, %d): expected %q, got %q", tt.line, tt.width, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLineLength is the longest line LineReader accepts unless configured otherwise
+const DefaultMaxLineLength = 1024 * 1024
+
+// LineTooLongError reports a line longer than the reader's maximum line length
+type LineTooLongError struct {
+	Line int // 1-based line number
+	Max  int
+}
+
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("line %d is longer than the maximum line length of %d bytes", e.Line, e.Max)
+}
+
+// utf8BOM is the byte order mark some editors put at the start of UTF-8 files
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// LineReader reads a file line by line like bufio.Scanner, but accepts lines up
+// to a configurable length instead of failing on lines longer than 64KB. A UTF-8
+// byte order mark at the start of the input is dropped.
+type LineReader struct {
+	reader  *bufio.Reader
+	maxLen  int
+	lineNum int
+	raw     []byte
+	err     error
+}
+
+// NewLineReader creates a line reader that rejects lines longer than maxLen bytes
+// (DefaultMaxLineLength if maxLen is 0 or less)
+func NewLineReader(r io.Reader, maxLen int) *LineReader {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLineLength
+	}
+	return &LineReader{reader: bufio.NewReader(r), maxLen: maxLen}
+}
+
+// Scan advances to the next line. It returns false at the end of the input or
+// on an error, which is then available from Err.
+func (lr *LineReader) Scan() bool {
+	if lr.err != nil {
+		return false
+	}
+
+	lr.raw = lr.raw[:0]
+	for {
+		chunk, err := lr.reader.ReadSlice('\n')
+		lr.raw = append(lr.raw, chunk...)
+
+		if len(bytes.TrimRight(lr.raw, "\r\n")) > lr.maxLen {
+			lr.err = &LineTooLongError{Line: lr.lineNum + 1, Max: lr.maxLen}
+			return false
+		}
+
+		if lr.lineNum == 0 && err != bufio.ErrBufferFull {
+			lr.raw = bytes.TrimPrefix(lr.raw, utf8BOM)
+		}
+
+		switch err {
+		case nil:
+			lr.lineNum++
+			return true
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(lr.raw) == 0 {
+				return false
+			}
+			lr.lineNum++
+			return true
+		default:
+			lr.err = err
+			return false
+		}
+	}
+}
+
+// Text returns the current line without its line terminator
+func (lr *LineReader) Text() string {
+	line := bytes.TrimSuffix(lr.raw, []byte("\n"))
+	return string(bytes.TrimSuffix(line, []byte("\r")))
+}
+
+// Raw returns the current line including its line terminator, if any.
+// The slice is only valid until the next call to Scan.
+func (lr *LineReader) Raw() []byte {
+	return lr.raw
+}
+
+// LineNumber returns the 1-based number of the current line
+func (lr *LineReader) LineNumber() int {
+	return lr.lineNum
+}
+
+// Err returns the first error encountered, other than io.EOF
+func (lr *LineReader) Err() error {
+	return lr.err
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	longLine := strings.Repeat("x", 100*1024)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "Empty input", input: "", expected: nil},
+		{name: "Trailing newline", input: "a\nb\n", expected: []string{"a", "b"}},
+		{name: "No trailing newline", input: "a\nb", expected: []string{"a", "b"}},
+		{name: "CRLF line endings", input: "a\r\nb\r\n", expected: []string{"a", "b"}},
+		{name: "Blank lines", input: "\n\na\n", expected: []string{"", "", "a"}},
+		{name: "UTF-8 BOM", input: "\xef\xbb\xbfa\nb\xef\xbb\xbf\n", expected: []string{"a", "b\xef\xbb\xbf"}},
+		{name: "Only a BOM", input: "\xef\xbb\xbf", expected: nil},
+		{name: "Line longer than 64KB", input: longLine + "\nend\n", expected: []string{longLine, "end"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewLineReader(strings.NewReader(tt.input), 0)
+			var lines []string
+			for reader.Scan() {
+				lines = append(lines, reader.Text())
+			}
+			if err := reader.Err(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines, got %d", len(tt.expected), len(lines))
+			}
+			for i := range lines {
+				if lines[i] != tt.expected[i] {
+					t.Errorf("Expected line %d to be %q, got %q", i+1, tt.expected[i], lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLineReader_Raw(t *testing.T) {
+	reader := NewLineReader(strings.NewReader("a\r\nb"), 0)
+	var raw []string
+	for reader.Scan() {
+		raw = append(raw, string(reader.Raw()))
+	}
+	if len(raw) != 2 || raw[0] != "a\r\n" || raw[1] != "b" {
+		t.Errorf("Expected raw lines [\"a\\r\\n\" \"b\"], got %q", raw)
+	}
+}
+
+func TestLineReader_LineTooLong(t *testing.T) {
+	input := "short\n" + strings.Repeat("x", 5000) + "\nafter\n"
+	reader := NewLineReader(strings.NewReader(input), 4096)
+
+	count := 0
+	for reader.Scan() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 line before the long line, got %d", count)
+	}
+
+	var tooLong *LineTooLongError
+	if !errors.As(reader.Err(), &tooLong) {
+		t.Fatalf("Expected LineTooLongError, got %v", reader.Err())
+	}
+	if tooLong.Line != 2 || tooLong.Max != 4096 {
+		t.Errorf("Expected line 2 with max 4096, got line %d with max %d", tooLong.Line, tooLong.Max)
+	}
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen is the number of leading bytes used to detect a file's type
+const sniffLen = 512
+
+// magicNumber identifies a binary file type by the bytes at the start of the file
+type magicNumber struct {
+	offset int
+	magic  []byte
+	mime   string
+}
+
+// magicNumbers lists binary types that net/http.DetectContentType doesn't know,
+// or reports only as application/octet-stream. They are checked first.
+var magicNumbers = []magicNumber{
+	{0, []byte("\x7fELF"), "application/x-elf"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xce}, "application/x-mach-binary"},
+	{0, []byte{0xfe, 0xed, 0xfa, 0xcf}, "application/x-mach-binary"},
+	{0, []byte{0xce, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+	{0, []byte{0xcf, 0xfa, 0xed, 0xfe}, "application/x-mach-binary"},
+	{0, []byte{0xca, 0xfe, 0xba, 0xbe}, "application/java-vm"}, // Java class or universal Mach-O
+	{0, []byte("MZ\x90\x00"), "application/vnd.microsoft.portable-executable"},
+	{0, []byte("\x00asm"), "application/wasm"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "application/x-xz"},
+	{0, []byte{0x28, 0xb5, 0x2f, 0xfd}, "application/zstd"},
+	{0, []byte("!<arch>\n"), "application/x-archive"},
+	{0, []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}, "application/x-ole-storage"},
+	{257, []byte("ustar"), "application/x-tar"},
+}
+
+// SniffMIME returns the MIME type of content from its leading bytes, such as
+// "image/png", "application/zip", "application/x-elf", or
+// "text/plain; charset=utf-8"
+func SniffMIME(data []byte) string {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	for _, m := range magicNumbers {
+		if len(data) >= m.offset+len(m.magic) && bytes.Equal(data[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.mime
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// DetectMIME returns the MIME type of the file at path from its leading bytes
+func DetectMIME(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return SniffMIME(buf[:n]), nil
+}
+
+// isBinaryMagic reports whether content starts with the magic number of a known binary type
+func isBinaryMagic(data []byte) bool {
+	mime := SniffMIME(data)
+	return !IsTextMIME(mime) && mime != "application/octet-stream"
+}
+
+// IsTextMIME reports whether a MIME type describes text content
+func IsTextMIME(mime string) bool {
+	mime, _, _ = strings.Cut(mime, ";")
+	switch {
+	case strings.HasPrefix(mime, "text/"):
+		return true
+	case mime == "application/json", mime == "application/xml", mime == "application/javascript",
+		mime == "application/postscript", mime == "image/svg+xml":
+		return true
+	}
+	return false
+}
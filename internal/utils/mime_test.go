@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSniffMIME(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png"},
+		{"zip", "PK\x03\x04\x14\x00\x00\x00", "application/zip"},
+		{"gzip", "\x1f\x8b\x08\x00\x00\x00\x00\x00", "application/x-gzip"},
+		{"elf", "\x7fELF\x02\x01\x01\x00", "application/x-elf"},
+		{"pe", "MZ\x90\x00\x03\x00\x00\x00", "application/vnd.microsoft.portable-executable"},
+		{"sqlite", "SQLite format 3\x00\x10\x00", "application/vnd.sqlite3"},
+		{"tar", strings.Repeat("\x00", 257) + "ustar\x0000", "application/x-tar"},
+		{"text", "package main\n", "text/plain; charset=utf-8"},
+		{"text starting like an executable", "MZ is a file format\n", "text/plain; charset=utf-8"},
+		{"empty", "", "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if mime := SniffMIME([]byte(tt.data)); mime != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, mime)
+			}
+		})
+	}
+}
+
+func TestIsTextMIME(t *testing.T) {
+	tests := []struct {
+		mime     string
+		expected bool
+	}{
+		{"text/plain; charset=utf-8", true},
+		{"text/html; charset=utf-8", true},
+		{"application/json", true},
+		{"image/svg+xml", true},
+		{"image/png", false},
+		{"application/x-elf", false},
+		{"application/octet-stream", false},
+	}
+
+	for _, tt := range tests {
+		if result := IsTextMIME(tt.mime); result != tt.expected {
+			t.Errorf("IsTextMIME(%q): expected %v, got %v", tt.mime, tt.expected, result)
+		}
+	}
+}
+
+func TestDetectMIME_IsTextFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "mime_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A zstd frame header followed by text has no null bytes and is mostly valid UTF-8
+	path := filepath.Join(tempDir, "archive.bin")
+	content := "\x28\xb5\x2f\xfd" + strings.Repeat("compressed data ", 20)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	mime, err := DetectMIME(path)
+	if err != nil {
+		t.Fatalf("DetectMIME failed: %v", err)
+	}
+	if mime != "application/zstd" {
+		t.Errorf("Expected application/zstd, got %q", mime)
+	}
+
+	isText, err := IsTextFile(path)
+	if err != nil {
+		t.Fatalf("IsTextFile failed: %v", err)
+	}
+	if isText {
+		t.Error("Expected a file with a binary magic number not to be text")
+	}
+
+	if _, err := DetectMIME(filepath.Join(tempDir, "missing")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders replacing redacted personal data
+const (
+	EmailPlaceholder      = "[email redacted]"
+	PhonePlaceholder      = "[phone redacted]"
+	NationalIDPlaceholder = "[national ID redacted]"
+)
+
+var (
+	// emailPattern matches an email address, capturing its top-level domain
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@(?:[A-Za-z0-9-]+\.)+([A-Za-z]{2,})\b`)
+
+	// phonePattern matches an international number (+44 20 7946 0958) or a
+	// North American one ((415) 555-0132, 415-555-0132)
+	phonePattern = regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}|(?:\(\d{3}\) ?|\b\d{3}[ .-])\d{3}[ .-]\d{4}\b`)
+
+	// nationalIDPatterns match national identification numbers: US Social
+	// Security numbers and UK National Insurance numbers
+	nationalIDPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b(?:00[1-9]|0[1-9]\d|[1-578]\d\d|6[0-57-9]\d|66[0-57-9])-(?:0[1-9]|[1-9]\d)-(?:000[1-9]|00[1-9]\d|0[1-9]\d\d|[1-9]\d{3})\b`),
+		regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`),
+	}
+)
+
+// fileExtensionDomains are top-level domains that are file extensions, so
+// that names such as icon@2x.png are not taken for email addresses
+var fileExtensionDomains = map[string]bool{
+	"png": true, "jpg": true, "jpeg": true, "gif": true, "svg": true, "webp": true,
+	"js": true, "ts": true, "css": true, "json": true, "go": true, "py": true, "rb": true,
+}
+
+// PIICounts counts the personal data redacted from the output
+type PIICounts struct {
+	Emails      int `json:"emails"`
+	Phones      int `json:"phone_numbers"`
+	NationalIDs int `json:"national_ids"`
+}
+
+// Total returns the number of redactions of all kinds
+func (c PIICounts) Total() int {
+	return c.Emails + c.Phones + c.NationalIDs
+}
+
+// Add adds the redactions of other to c
+func (c *PIICounts) Add(other PIICounts) {
+	c.Emails += other.Emails
+	c.Phones += other.Phones
+	c.NationalIDs += other.NationalIDs
+}
+
+// String describes the redactions, such as "3 emails, 1 phone number"
+func (c PIICounts) String() string {
+	var parts []string
+	for _, kind := range []struct {
+		n    int
+		noun string
+	}{{c.Emails, "email"}, {c.Phones, "phone number"}, {c.NationalIDs, "national ID"}} {
+		if kind.n == 1 {
+			parts = append(parts, "1 "+kind.noun)
+		} else if kind.n > 1 {
+			parts = append(parts, fmt.Sprintf("%d %ss", kind.n, kind.noun))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RedactPII replaces email addresses, phone numbers, and national ID numbers
+// in a line with placeholders. It returns the new line and what was replaced.
+func RedactPII(line string) (string, PIICounts) {
+	var counts PIICounts
+	// Most lines hold none, and every pattern needs a digit or an @
+	if !strings.ContainsAny(line, "@0123456789") {
+		return line, counts
+	}
+
+	for _, pattern := range nationalIDPatterns {
+		line = pattern.ReplaceAllStringFunc(line, func(string) string {
+			counts.NationalIDs++
+			return NationalIDPlaceholder
+		})
+	}
+	line = emailPattern.ReplaceAllStringFunc(line, func(match string) string {
+		tld := emailPattern.FindStringSubmatch(match)[1]
+		if fileExtensionDomains[strings.ToLower(tld)] {
+			return match
+		}
+		counts.Emails++
+		return EmailPlaceholder
+	})
+	line = phonePattern.ReplaceAllStringFunc(line, func(match string) string {
+		if digits := countDigits(match); digits < 8 || digits > 15 {
+			return match
+		}
+		counts.Phones++
+		return PhonePlaceholder
+	})
+	return line, counts
+}
+
+// countDigits returns the number of ASCII digits in s
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
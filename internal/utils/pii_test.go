@@ -0,0 +1,67 @@
+package utils
+
+import "testing"
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+		counts   PIICounts
+	}{
+		{
+			name:     "Email",
+			line:     `const support = "Jane.Doe+help@support.example.co.uk"`,
+			expected: `const support = "[email redacted]"`,
+			counts:   PIICounts{Emails: 1},
+		},
+		{
+			name:     "International phone",
+			line:     "Call +44 20 7946 0958 or +1-415-555-0132.",
+			expected: "Call [phone redacted] or [phone redacted].",
+			counts:   PIICounts{Phones: 2},
+		},
+		{
+			name:     "North American phone",
+			line:     "phone: (415) 555-0132, fax: 415.555.0199",
+			expected: "phone: [phone redacted], fax: [phone redacted]",
+			counts:   PIICounts{Phones: 2},
+		},
+		{
+			name:     "Social Security number",
+			line:     "ssn = '123-45-6789'",
+			expected: "ssn = '[national ID redacted]'",
+			counts:   PIICounts{NationalIDs: 1},
+		},
+		{
+			name:     "National Insurance number",
+			line:     "NI: AB 12 34 56 C",
+			expected: "NI: [national ID redacted]",
+			counts:   PIICounts{NationalIDs: 1},
+		},
+		{
+			name:     "Not personal data",
+			line:     `img := "icon@2x.png" // v1.2.3, 2024-01-02, 192.168.0.1, +5, 000-12-3456, x = 12345678`,
+			expected: `img := "icon@2x.png" // v1.2.3, 2024-01-02, 192.168.0.1, +5, 000-12-3456, x = 12345678`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, counts := RedactPII(test.line)
+			if got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+			if counts != test.counts {
+				t.Errorf("Expected %+v, got %+v", test.counts, counts)
+			}
+		})
+	}
+}
+
+func TestPIICounts_String(t *testing.T) {
+	counts := PIICounts{Emails: 3, Phones: 1}
+	if got, expected := counts.String(), "3 emails, 1 phone number"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"unicode/utf8"
+
+	"codectx/internal/language"
 )
 
 // IsTextFile checks if a file is a text file by looking at the first 512 bytes
@@ -28,6 +30,11 @@ func IsTextFile(path string) (bool, error) {
 			return true, nil
 		}
 	}
+
+	// Dockerfiles, Makefiles, go.mod, and other special files are text
+	if language.IsSpecialFile(path) {
+		return true, nil
+	}
 	
 	// If no extension or unknown extension, check content
 	// Open the file
@@ -60,6 +67,11 @@ func IsTextFile(path string) (bool, error) {
 		return false, nil
 	}
 
+	// Check for the magic numbers of binary formats without null bytes (e.g. gzip)
+	if isBinaryMagic(buf[:n]) {
+		return false, nil
+	}
+
 	// Check if the content is valid UTF-8 (allow partial sequences at the end)
 	// For text files, most of the content should be valid UTF-8
 	validUTF8Bytes := 0